@@ -0,0 +1,176 @@
+package hnswindex
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Bounds and steps of adaptive embedding (Config.AdaptiveEmbedding)
+const (
+	// DefaultAdaptiveMaxBatch caps the adaptive batch size when
+	// EmbedBatchSize is not set
+	DefaultAdaptiveMaxBatch = 256
+
+	adaptiveStartBatch  = 16
+	adaptiveBatchStep   = 8   // Texts added to the batch size after a fast call
+	adaptiveSlowdown    = 2.0 // Per-text latency over the best seen that counts as congestion
+	adaptiveCongestion  = 0.75
+	adaptiveErrorFactor = 0.5
+	adaptiveDrift       = 20 // The best latency moves 1/adaptiveDrift towards slower calls
+)
+
+// EmbedTuning is the state of adaptive embedding
+type EmbedTuning struct {
+	Enabled     bool          `json:"enabled"`
+	Concurrency int           `json:"concurrency"` // Embedder calls allowed at once
+	BatchSize   int           `json:"batch_size"`  // Texts per embedder call
+	Latency     time.Duration `json:"latency"`     // Best per-text latency seen, drifting towards recent calls
+	Calls       int           `json:"calls"`
+	Errors      int           `json:"errors"`
+}
+
+// EmbedTuning returns the concurrency and batch size adaptive embedding has
+// settled on. Enabled is false, and the rest zero, unless
+// Config.AdaptiveEmbedding is set.
+func (im *IndexManager) EmbedTuning() EmbedTuning {
+	if impl := im.getImpl(); impl != nil && impl.tuner != nil {
+		return impl.tuner.state()
+	}
+	return EmbedTuning{}
+}
+
+// embedTuner adapts embedder concurrency and batch size with AIMD: both
+// grow additively while calls stay fast and shrink multiplicatively when
+// per-text latency rises well above the best seen, as an overloaded local
+// model or a throttling API makes it, or when calls fail.
+type embedTuner struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	inFlight int
+	limit    float64 // Concurrency, between 1 and maxLimit
+	maxLimit int
+	batch    float64 // Batch size, between 1 and maxBatch
+	maxBatch int
+	best     time.Duration // Per-text latency baseline
+	calls    int
+	errors   int
+}
+
+// newEmbedTuner starts at one call at a time and a small batch, so a slow
+// embedder is not flooded before its latency is known
+func newEmbedTuner(maxConcurrency, maxBatch int) *embedTuner {
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	if maxBatch < 1 {
+		maxBatch = DefaultAdaptiveMaxBatch
+	}
+	t := &embedTuner{
+		limit:    1,
+		maxLimit: maxConcurrency,
+		batch:    float64(min(adaptiveStartBatch, maxBatch)),
+		maxBatch: maxBatch,
+	}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// acquire waits for a free call slot and returns the batch size to use
+func (t *embedTuner) acquire() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for t.inFlight >= int(t.limit) {
+		t.cond.Wait()
+	}
+	t.inFlight++
+	return int(t.batch)
+}
+
+// release frees a call slot and adapts to the outcome of a call that
+// embedded texts in elapsed
+func (t *embedTuner) release(texts int, elapsed time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inFlight--
+	t.calls++
+	defer t.cond.Broadcast()
+
+	limit, batch := t.limit, t.batch
+	switch {
+	case err != nil:
+		t.errors++
+		t.limit = max(1, t.limit*adaptiveErrorFactor)
+		t.batch = max(1, t.batch*adaptiveErrorFactor)
+	case texts > 0:
+		perText := elapsed / time.Duration(texts)
+		slow := t.best > 0 && float64(perText) > float64(t.best)*adaptiveSlowdown
+		if t.best == 0 || perText < t.best {
+			t.best = perText
+		} else {
+			t.best += (perText - t.best) / adaptiveDrift
+		}
+		if slow {
+			t.limit = max(1, t.limit*adaptiveCongestion)
+			t.batch = max(1, t.batch*adaptiveCongestion)
+			break
+		}
+		t.limit = min(float64(t.maxLimit), t.limit+1/t.limit)
+		// Only a full batch shows that a larger one would be used
+		if texts >= int(t.batch) {
+			t.batch = min(float64(t.maxBatch), t.batch+adaptiveBatchStep)
+		}
+	}
+
+	if int(limit) != int(t.limit) || int(batch) != int(t.batch) {
+		slog.Debug("Adapted embedding",
+			"concurrency", int(t.limit),
+			"batch_size", int(t.batch),
+			"latency_per_text", t.best,
+			"error", err,
+		)
+	}
+}
+
+func (t *embedTuner) state() EmbedTuning {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return EmbedTuning{
+		Enabled:     true,
+		Concurrency: int(t.limit),
+		BatchSize:   int(t.batch),
+		Latency:     t.best,
+		Calls:       t.calls,
+		Errors:      t.errors,
+	}
+}
+
+// generateEmbeddings embeds texts with the embedder. With adaptive
+// embedding the texts are sent in batches of the tuned size, each waiting
+// for a call slot.
+func (im *indexManagerImpl) generateEmbeddings(texts []string) ([][]float32, error) {
+	if im.tuner == nil {
+		return im.embedder.GenerateEmbeddings(texts)
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for len(embeddings) < len(texts) {
+		size := im.tuner.acquire()
+		batch := texts[len(embeddings):]
+		if len(batch) > size {
+			batch = batch[:size]
+		}
+		start := time.Now()
+		got, err := im.embedder.GenerateEmbeddings(batch)
+		im.tuner.release(len(batch), time.Since(start), err)
+		if err != nil {
+			return nil, err
+		}
+		if len(got) != len(batch) {
+			return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(got), len(batch))
+		}
+		embeddings = append(embeddings, got...)
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,143 @@
+package hnswindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedTuner_AIMD(t *testing.T) {
+	tuner := newEmbedTuner(4, 40)
+	assert.Equal(t, EmbedTuning{Enabled: true, Concurrency: 1, BatchSize: 16}, tuner.state())
+
+	// Fast full batches grow both limits up to their caps
+	for i := 0; i < 20; i++ {
+		size := tuner.acquire()
+		tuner.release(size, time.Duration(size)*time.Millisecond, nil)
+	}
+	state := tuner.state()
+	assert.Equal(t, 4, state.Concurrency)
+	assert.Equal(t, 40, state.BatchSize)
+	assert.Equal(t, time.Millisecond, state.Latency)
+
+	// Partial batches say nothing about larger ones
+	tuner.batch = 24
+	tuner.acquire()
+	tuner.release(3, 3*time.Millisecond, nil)
+	assert.Equal(t, 24, tuner.state().BatchSize)
+
+	// Congestion backs off by a quarter, errors by half
+	tuner.acquire()
+	tuner.release(24, 24*5*time.Millisecond, nil)
+	state = tuner.state()
+	assert.Equal(t, 3, state.Concurrency)
+	assert.Equal(t, 18, state.BatchSize)
+	assert.Greater(t, state.Latency, time.Millisecond)
+
+	tuner.acquire()
+	tuner.release(18, 0, errors.New("rate limited"))
+	state = tuner.state()
+	assert.Equal(t, 1, state.Concurrency)
+	assert.Equal(t, 9, state.BatchSize)
+	assert.Equal(t, 1, state.Errors)
+	assert.Equal(t, 23, state.Calls)
+
+	// Never below one
+	for i := 0; i < 10; i++ {
+		tuner.acquire()
+		tuner.release(1, 0, errors.New("down"))
+	}
+	state = tuner.state()
+	assert.Equal(t, 1, state.Concurrency)
+	assert.Equal(t, 1, state.BatchSize)
+}
+
+func TestEmbedTuner_LimitsConcurrency(t *testing.T) {
+	tuner := newEmbedTuner(8, 0)
+	assert.Equal(t, DefaultAdaptiveMaxBatch, tuner.maxBatch)
+
+	tuner.acquire()
+	acquired := make(chan struct{})
+	go func() {
+		tuner.acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second call started while the limit is one")
+	case <-time.After(20 * time.Millisecond):
+	}
+	tuner.release(16, time.Millisecond, nil)
+	<-acquired
+}
+
+// throttledEmbedder fails calls of more than limit texts, like an API
+// enforcing a request size limit, and records batch sizes
+type throttledEmbedder struct {
+	*MockEmbedder
+	limit int
+
+	mu      sync.Mutex
+	batches []int
+}
+
+func (e *throttledEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.batches = append(e.batches, len(texts))
+	e.mu.Unlock()
+	if len(texts) > e.limit {
+		return nil, fmt.Errorf("%d texts exceed the limit of %d", len(texts), e.limit)
+	}
+	return e.MockEmbedder.GenerateEmbeddings(texts)
+}
+
+func TestAdaptiveEmbedding(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 0
+	cfg.EmbedBatchSize = 32
+	cfg.AdaptiveEmbedding = true
+	manager := newMockManager(t, cfg)
+	throttled := &throttledEmbedder{MockEmbedder: NewMockEmbedder(768), limit: 12}
+	manager.SetEmbedder(throttled)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	var docs []Document
+	for i := 0; i < 20; i++ {
+		var content strings.Builder
+		for p := 0; p < 12; p++ {
+			fmt.Fprintf(&content, "Document %d paragraph %d talks about topic %d at some length.\n\n", i, p, i*p)
+		}
+		docs = append(docs, Document{URI: fmt.Sprintf("doc%d", i), Title: "Doc", Content: content.String()})
+	}
+	result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	// Oversized batches fail their documents and shrink the batch size
+	tuning := manager.EmbedTuning()
+	assert.True(t, tuning.Enabled)
+	assert.Greater(t, tuning.Errors, 0)
+	assert.LessOrEqual(t, tuning.BatchSize, 32)
+	for _, size := range throttled.batches {
+		assert.LessOrEqual(t, size, 32)
+	}
+	assert.Less(t, len(result.FailedURIs), len(docs))
+	assert.Greater(t, result.ProcessedChunks, 0)
+
+	diag, err := manager.Diagnostics()
+	require.NoError(t, err)
+	require.NotNil(t, diag.EmbedTuning)
+	assert.Equal(t, tuning.Calls, diag.EmbedTuning.Calls)
+
+	// Disabled by default
+	assert.False(t, newMockManager(t, nil).EmbedTuning().Enabled)
+}
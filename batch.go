@@ -0,0 +1,414 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/chunker"
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// ManagerTx collects document additions for several indexes that are
+// committed together by IndexManager.Batch
+type ManagerTx struct {
+	manager *indexManagerImpl
+	order   []string
+	adds    map[string][]Document
+
+	// ForceUpdate reprocesses documents regardless of their stored hash
+	ForceUpdate bool
+}
+
+// Add queues documents for addition to the named index.
+// Nothing is written until the batch function returns without error.
+// A group commit is atomic and cannot be split, so Add fails once more than
+// Config.MaxBatchDocuments documents are queued.
+func (tx *ManagerTx) Add(indexName string, docs ...Document) error {
+	index, err := tx.manager.openIndex(indexName)
+	if err != nil {
+		return err
+	}
+	if err := index.writable(); err != nil {
+		return err
+	}
+
+	if limit := tx.manager.config.MaxBatchDocuments; limit > 0 {
+		queued := len(docs)
+		for _, pending := range tx.adds {
+			queued += len(pending)
+		}
+		if queued > limit {
+			return fmt.Errorf("%w: batch has %d documents, maximum is %d", ErrLimitExceeded, queued, limit)
+		}
+	}
+
+	if _, queued := tx.adds[indexName]; !queued {
+		tx.order = append(tx.order, indexName)
+	}
+	tx.adds[indexName] = append(tx.adds[indexName], docs...)
+	return nil
+}
+
+// Batch adds documents to several indexes (e.g. a per-team and a global
+// index) with a single storage commit. Chunk embeddings are computed once
+// and shared between indexes that receive the same text. If fn returns an
+// error, or embedding or storage fails, nothing is written.
+// It returns a BatchResult per index.
+func (im *IndexManager) Batch(fn func(tx *ManagerTx) error) (map[string]*BatchResult, error) {
+	return im.BatchContext(context.Background(), fn)
+}
+
+// BatchContext is Batch with cancellation support
+func (im *IndexManager) BatchContext(ctx context.Context, fn func(tx *ManagerTx) error) (map[string]*BatchResult, error) {
+	if impl := im.getImpl(); impl != nil {
+		return impl.Batch(ctx, fn)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// pendingDocument is a document selected for processing in a group commit
+type pendingDocument struct {
+	index   *indexImpl
+	doc     Document
+	hash    string
+	chunks  []chunker.Chunk
+	created bool // Not in the index before
+}
+
+// Batch implementation
+func (im *indexManagerImpl) Batch(ctx context.Context, fn func(tx *ManagerTx) error) (map[string]*BatchResult, error) {
+	tx := &ManagerTx{
+		manager: im,
+		adds:    make(map[string][]Document),
+	}
+	if err := fn(tx); err != nil {
+		return nil, err
+	}
+	started := time.Now()
+
+	results := make(map[string]*BatchResult)
+	defer func() {
+		for _, result := range results {
+			result.finish(started)
+		}
+	}()
+	var pending []pendingDocument
+	chunkCache := make(map[string][]chunker.Chunk) // By document hash
+
+	// Phase 1: select changed documents and chunk them, sharing chunking
+	// between indexes that receive identical content
+	for _, name := range tx.order {
+		index, err := im.openIndex(name)
+		if err != nil {
+			return nil, err
+		}
+		release, err := index.acquire()
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		docs := dedupeDocuments(tx.adds[name])
+		result := &BatchResult{
+			TotalDocuments: len(docs),
+			FailedURIs:     make(map[string]string),
+		}
+		results[name] = result
+
+		schema, err := index.MetadataSchema()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load metadata schema for '%s': %w", name, err)
+		}
+
+		for _, doc := range docs {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+
+			doc, err := im.limitMetadata(doc)
+			if err != nil {
+				result.FailedURIs[doc.URI] = err.Error()
+				continue
+			}
+			if err := schema.Validate(doc.Metadata); err != nil {
+				result.FailedURIs[doc.URI] = err.Error()
+				continue
+			}
+			if err := im.checkContentSize(doc); err != nil {
+				result.FailedURIs[doc.URI] = err.Error()
+				continue
+			}
+
+			hash, err := im.hashDocument(doc)
+			if err != nil {
+				result.FailedURIs[doc.URI] = err.Error()
+				continue
+			}
+			existingHash, err := im.storage.GetDocumentHash(name, doc.URI)
+			created := err != nil
+			switch {
+			case err != nil:
+				result.NewDocuments++
+			case existingHash != hash || tx.ForceUpdate:
+				result.UpdatedDocuments++
+			default:
+				result.UnchangedDocuments++
+				continue
+			}
+
+			doc, err = im.extractBinary(ctx, doc)
+			if err != nil {
+				result.FailedURIs[doc.URI] = err.Error()
+				continue
+			}
+			if doc, err = im.loadContent(doc); err != nil {
+				result.FailedURIs[doc.URI] = err.Error()
+				continue
+			}
+
+			if doc.Content == "" && len(doc.Segments) > 0 {
+				doc.Content = segmentContent(doc.Segments)
+			}
+
+			chunks, ok := chunkCache[hash]
+			if !ok {
+				chunks, err = im.chunkDocument(doc)
+				if err != nil {
+					result.FailedURIs[doc.URI] = fmt.Sprintf("failed to chunk document: %v", err)
+					continue
+				}
+				chunkCache[hash] = chunks
+			}
+			if err := im.checkChunkCount(len(chunks)); err != nil {
+				result.FailedURIs[doc.URI] = err.Error()
+				continue
+			}
+
+			pending = append(pending, pendingDocument{
+				index:   index,
+				doc:     doc,
+				hash:    hash,
+				chunks:  chunks,
+				created: created,
+			})
+		}
+	}
+
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	// Phase 2: embed every distinct chunk text once
+	var texts []string
+	seen := make(map[string]bool)
+	for _, p := range pending {
+		for _, c := range p.chunks {
+			if !seen[c.Text] {
+				seen[c.Text] = true
+				texts = append(texts, c.Text)
+				// A text shared between indexes counts for the first
+				results[p.index.name].TokensEmbedded += im.chunker.CountTokens(c.Text)
+			}
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	embedStart := time.Now()
+	embeddings, err := im.embedTexts(texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	if len(embeddings) > 0 {
+		for _, p := range pending {
+			if err := p.index.checkDimension(len(embeddings[0])); err != nil {
+				return nil, err
+			}
+		}
+	}
+	vectors, err := im.lateVectors(texts)
+	if err != nil {
+		return nil, err
+	}
+	sparse, err := im.sparseVectors(texts)
+	if err != nil {
+		return nil, err
+	}
+	// Embedding and the commit are shared by the indexes of the batch, so
+	// each result reports their whole duration
+	for _, result := range results {
+		result.DurationEmbedding = time.Since(embedStart)
+	}
+	embeddingByText := make(map[string][]float32, len(texts))
+	vectorsByText := make(map[string][][]float32, len(texts))
+	sparseByText := make(map[string]map[string]float32, len(texts))
+	for idx, text := range texts {
+		embeddingByText[text] = embeddings[idx]
+		vectorsByText[text] = vectors[idx]
+		sparseByText[text] = sparse[idx]
+	}
+
+	slog.Info("Group commit embeddings generated",
+		"indexes", len(tx.order),
+		"documents", len(pending),
+		"unique_texts", len(texts),
+	)
+
+	// Phase 3: write all indexes in a single storage transaction
+	storageStart := time.Now()
+	writes := make([]storage.DocumentWrite, 0, len(pending))
+	for _, p := range pending {
+		w := storage.DocumentWrite{
+			Index: p.index.name,
+			Document: storage.Document{
+				URI:      p.doc.URI,
+				Title:    p.doc.Title,
+				Content:  p.doc.Content,
+				Hash:     p.hash,
+				Metadata: p.doc.Metadata,
+			},
+		}
+		for _, c := range p.chunks {
+			w.Chunks = append(w.Chunks, storage.Chunk{
+				ID:          c.ID,
+				DocumentURI: p.doc.URI,
+				Text:        c.Text,
+				Embedding:   embeddingByText[c.Text],
+				Vectors:     vectorsByText[c.Text],
+				Sparse:      sparseByText[c.Text],
+				Position:    c.Position,
+				Metadata:    chunkMetadata(p.doc.Metadata, c.Metadata),
+			})
+		}
+		writes = append(writes, w)
+	}
+
+	// Lock the indexes in name order, so concurrent batches reach each
+	// graph in the order they committed
+	var locked []*indexImpl
+	for _, p := range pending {
+		if !slices.Contains(locked, p.index) {
+			locked = append(locked, p.index)
+		}
+	}
+	sort.Slice(locked, func(a, b int) bool { return locked[a].name < locked[b].name })
+	for _, index := range locked {
+		index.commitMu.Lock()
+	}
+	for _, index := range locked {
+		if err = index.writable(); err != nil {
+			break
+		}
+		if err = im.injectFault(FaultCommit, index.name); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = im.storage.WriteDocuments(writes)
+	}
+	notIndexed := make(map[*indexImpl]map[string]error)
+	if err == nil {
+		// Phase 4: apply the committed chunks to the in-memory graphs
+		byIndex := make(map[*indexImpl][]storage.DocumentWrite)
+		for idx, w := range writes {
+			byIndex[pending[idx].index] = append(byIndex[pending[idx].index], w)
+		}
+		for index, indexWrites := range byIndex {
+			notIndexed[index] = index.applyWrites(indexWrites)
+		}
+	}
+	for _, index := range locked {
+		index.commitMu.Unlock()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+	for _, result := range results {
+		result.DurationStorage = time.Since(storageStart)
+	}
+
+	// Documents the graph could not take are failed; a retry indexes them
+	touched := make(map[*indexImpl]bool)
+	indexed := make([]bool, len(writes))
+	for idx, w := range writes {
+		index := pending[idx].index
+		touched[index] = true
+		if err := notIndexed[index][w.Document.URI]; err != nil {
+			results[index.name].FailedURIs[w.Document.URI] = fmt.Sprintf("failed to index document: %v", err)
+			continue
+		}
+		indexed[idx] = true
+		results[index.name].ProcessedChunks += len(w.Chunks)
+	}
+
+	for idx, w := range writes {
+		if !indexed[idx] {
+			continue
+		}
+		im.emitDocumentIndexed(DocumentEvent{
+			Index:   w.Index,
+			URI:     w.Document.URI,
+			Title:   w.Document.Title,
+			Chunks:  len(w.Chunks),
+			Created: pending[idx].created,
+		})
+	}
+
+	for index := range touched {
+		if im.config.AutoSave {
+			saveStart := time.Now()
+			err := im.injectFault(FaultSave, index.name)
+			if err == nil {
+				err = index.hnswIndex.Save()
+			}
+			results[index.name].DurationStorage += time.Since(saveStart)
+			if err != nil {
+				return results, fmt.Errorf("failed to save HNSW index '%s': %w", index.name, err)
+			}
+			im.indexSaved(index.name)
+		}
+
+		if err := index.recordConfig(); err != nil {
+			slog.Warn("Failed to record index configuration",
+				"index", index.name,
+				"error", err,
+			)
+		}
+
+		result := results[index.name]
+		im.storage.UpdateIndexMetadata(index.name, func(metadata *storage.IndexMetadata) {
+			metadata.LastUpdated = time.Now().UTC()
+			metadata.DocumentCount = result.NewDocuments + result.UpdatedDocuments
+			metadata.ChunkCount = result.ProcessedChunks
+		})
+		index.recordBatchStats(result, started)
+	}
+
+	return results, nil
+}
+
+// dedupeDocuments keeps the last queued version of each URI
+func dedupeDocuments(docs []Document) []Document {
+	last := make(map[string]int, len(docs))
+	for idx, doc := range docs {
+		last[doc.URI] = idx
+	}
+
+	deduped := make([]Document, 0, len(last))
+	for idx, doc := range docs {
+		if last[doc.URI] == idx {
+			deduped = append(deduped, doc)
+		}
+	}
+	return deduped
+}
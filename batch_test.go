@@ -0,0 +1,84 @@
+package hnswindex
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder records how many texts were embedded
+type countingEmbedder struct {
+	*MockEmbedder
+	texts int
+}
+
+func (c *countingEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	c.texts += len(texts)
+	return c.MockEmbedder.GenerateEmbeddings(texts)
+}
+
+func TestIndexManager_Batch(t *testing.T) {
+	manager := newMockManager(t, nil)
+	counter := &countingEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.getImpl().embedder = counter
+
+	team, err := manager.CreateIndex("team")
+	require.NoError(t, err)
+	global, err := manager.CreateIndex("global")
+	require.NoError(t, err)
+
+	doc := Document{URI: "doc://runbook", Title: "Runbook", Content: "restart the service and check the logs"}
+	results, err := manager.Batch(func(tx *ManagerTx) error {
+		if err := tx.Add("team", doc); err != nil {
+			return err
+		}
+		return tx.Add("global", doc)
+	})
+	require.NoError(t, err)
+	require.Contains(t, results, "team")
+	require.Contains(t, results, "global")
+	assert.Equal(t, 1, results["team"].NewDocuments)
+	assert.Equal(t, 1, results["global"].NewDocuments)
+	assert.Equal(t, results["team"].ProcessedChunks, counter.texts, "shared chunks are embedded once")
+
+	for _, index := range []*Index{team, global} {
+		stored, err := index.GetDocument("doc://runbook")
+		require.NoError(t, err)
+		assert.Equal(t, "Runbook", stored.Title)
+
+		hits, err := index.Search("restart the service", 1)
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		assert.Equal(t, "doc://runbook", hits[0].Document.URI)
+	}
+
+	// Unchanged documents are skipped
+	results, err = manager.Batch(func(tx *ManagerTx) error {
+		return tx.Add("team", doc)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, results["team"].UnchangedDocuments)
+}
+
+func TestIndexManager_BatchRollback(t *testing.T) {
+	manager := newMockManager(t, nil)
+	team, err := manager.CreateIndex("team")
+	require.NoError(t, err)
+
+	doc := Document{URI: "doc://1", Title: "One", Content: "content"}
+	_, err = manager.Batch(func(tx *ManagerTx) error {
+		require.NoError(t, tx.Add("team", doc))
+		return errors.New("abort")
+	})
+	assert.EqualError(t, err, "abort")
+
+	_, err = team.GetDocument("doc://1")
+	assert.Error(t, err)
+
+	_, err = manager.Batch(func(tx *ManagerTx) error {
+		return tx.Add("missing", doc)
+	})
+	assert.Error(t, err)
+}
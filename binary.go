@@ -0,0 +1,88 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/riclib/hnswindex/internal/vision"
+)
+
+// BinaryExtractor turns binary document content (images, scanned PDFs)
+// into text that can be chunked and embedded
+type BinaryExtractor interface {
+	Extract(ctx context.Context, data []byte, mimeType string) (string, error)
+	Name() string // Recorded in document metadata as "extracted_by"
+}
+
+// ExtractorFunc adapts a function to the BinaryExtractor interface,
+// for plugging in OCR libraries or external services
+type ExtractorFunc func(ctx context.Context, data []byte, mimeType string) (string, error)
+
+// Extract calls f
+func (f ExtractorFunc) Extract(ctx context.Context, data []byte, mimeType string) (string, error) {
+	return f(ctx, data, mimeType)
+}
+
+// Name identifies user-supplied extractors
+func (f ExtractorFunc) Name() string {
+	return "custom"
+}
+
+// NewOllamaVisionExtractor returns an extractor that transcribes or captions
+// images with an Ollama vision model (e.g. "llava"). It only accepts image
+// content types; scanned PDFs need a user-supplied extractor that rasterizes
+// or OCRs them.
+func NewOllamaVisionExtractor(ollamaURL, model string) (BinaryExtractor, error) {
+	return vision.NewOllamaVision(ollamaURL, model, "")
+}
+
+// SetBinaryExtractor sets the extractor used for documents that carry binary
+// Data. Without one, such documents are reported in BatchResult.FailedURIs.
+func (im *IndexManager) SetBinaryExtractor(extractor BinaryExtractor) {
+	if impl := im.getImpl(); impl != nil {
+		impl.mu.Lock()
+		impl.extractor = extractor
+		impl.mu.Unlock()
+	}
+}
+
+// extractBinary replaces the binary data of a document with extracted text.
+// Text documents are returned unchanged.
+func (im *indexManagerImpl) extractBinary(ctx context.Context, doc Document) (Document, error) {
+	if len(doc.Data) == 0 {
+		return doc, nil
+	}
+
+	im.mu.RLock()
+	extractor := im.extractor
+	im.mu.RUnlock()
+	if extractor == nil {
+		return doc, fmt.Errorf("document has binary content of type %q but no binary extractor is configured", doc.MIMEType)
+	}
+
+	text, err := extractor.Extract(ctx, doc.Data, doc.MIMEType)
+	if err != nil {
+		return doc, fmt.Errorf("failed to extract text with %s: %w", extractor.Name(), err)
+	}
+	if text == "" {
+		return doc, fmt.Errorf("%s extracted no text", extractor.Name())
+	}
+
+	// Keep any caller-supplied text (e.g. a caption) ahead of the extracted text
+	if doc.Content != "" {
+		text = doc.Content + "\n\n" + text
+	}
+
+	metadata := make(map[string]interface{}, len(doc.Metadata)+3)
+	for k, v := range doc.Metadata {
+		metadata[k] = v
+	}
+	metadata["source_mime_type"] = doc.MIMEType
+	metadata["source_size"] = len(doc.Data)
+	metadata["extracted_by"] = extractor.Name()
+
+	doc.Content = text
+	doc.Metadata = metadata
+	doc.Data = nil
+	return doc, nil
+}
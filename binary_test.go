@@ -0,0 +1,64 @@
+package hnswindex
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryExtraction(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("scans")
+	require.NoError(t, err)
+
+	scan := Document{
+		URI:      "file://scans/invoice.png",
+		Title:    "Invoice",
+		Data:     []byte("\x89PNG fake image bytes"),
+		MIMEType: "image/png",
+		Metadata: map[string]interface{}{"folder": "scans"},
+	}
+
+	// Without an extractor binary documents fail
+	result, err := index.AddDocumentBatch(context.Background(), []Document{scan}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.FailedURIs, scan.URI)
+
+	calls := 0
+	manager.SetBinaryExtractor(ExtractorFunc(func(ctx context.Context, data []byte, mimeType string) (string, error) {
+		calls++
+		assert.Equal(t, "image/png", mimeType)
+		return "INVOICE 42 total due 100 EUR", nil
+	}))
+
+	result, err = index.AddDocumentBatch(context.Background(), []Document{scan}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.FailedURIs)
+	assert.Equal(t, 1, calls)
+
+	stored, err := index.GetDocument(scan.URI)
+	require.NoError(t, err)
+	assert.Equal(t, "INVOICE 42 total due 100 EUR", stored.Content)
+	assert.Equal(t, "image/png", stored.Metadata["source_mime_type"])
+	assert.Equal(t, "custom", stored.Metadata["extracted_by"])
+	assert.Equal(t, "scans", stored.Metadata["folder"])
+	assert.Nil(t, stored.Data)
+
+	// Unchanged binary content is not extracted again
+	result, err = index.AddDocumentBatch(context.Background(), []Document{scan}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.UnchangedDocuments)
+	assert.Equal(t, 1, calls)
+
+	// Extraction errors are reported per document
+	manager.SetBinaryExtractor(ExtractorFunc(func(ctx context.Context, data []byte, mimeType string) (string, error) {
+		return "", errors.New("unreadable scan")
+	}))
+	scan.Data = []byte("different bytes")
+	result, err = index.AddDocumentBatch(context.Background(), []Document{scan}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.FailedURIs[scan.URI], "unreadable scan")
+}
@@ -0,0 +1,250 @@
+package hnswindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/indexer"
+)
+
+// boostRulesSettingKey is the index setting holding the boost rules
+const boostRulesSettingKey = "boost_rules"
+
+// BoostRule raises curated documents in search results, so official pages
+// rank above stale duplicates. A rule applies to every search, or only to
+// queries matching Query.
+type BoostRule struct {
+	Name  string   `json:"name,omitempty"`  // Shown in explanations
+	Query string   `json:"query,omitempty"` // Regular expression matched against the query, ignoring case; "" matches every query
+	URIs  []string `json:"uris"`            // Documents the rule applies to
+	Boost float64  `json:"boost,omitempty"` // Added to the score of their results; negative demotes them
+	Pin   bool     `json:"pin,omitempty"`   // Place their best result first, in URIs order, even when the graph missed it
+}
+
+// label names the rule in explanations
+func (r BoostRule) label(n int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("#%d", n+1)
+}
+
+// documentBoost is what the boost rules matching a query do to the results
+// of one document
+type documentBoost struct {
+	delta float64
+	rules []string // Rules raising or lowering the document
+	pin   int      // Pin order, 1-based; 0 if not pinned
+	by    string   // Rule pinning the document
+}
+
+// SetBoostRules replaces the boost rules of the index, which Search,
+// SearchWithOptions and SearchIter apply unless
+// SearchOptions.IgnoreBoostRules is set; SearchMulti ignores them. The
+// rules are persisted with the index; nil removes them.
+func (i *Index) SetBoostRules(rules []BoostRule) error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.SetBoostRules(rules)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// BoostRules returns the boost rules of the index, or nil if none are set
+func (i *Index) BoostRules() ([]BoostRule, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.BoostRules()
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// SetBoostRules implementation
+func (i *indexImpl) SetBoostRules(rules []BoostRule) error {
+	if len(rules) == 0 {
+		return i.manager.storage.SetIndexSetting(i.name, boostRulesSettingKey, nil)
+	}
+
+	for n, rule := range rules {
+		if len(rule.URIs) == 0 {
+			return fmt.Errorf("invalid boost rule %s: no URIs", rule.label(n))
+		}
+		if rule.Boost == 0 && !rule.Pin {
+			return fmt.Errorf("invalid boost rule %s: neither boost nor pin set", rule.label(n))
+		}
+		if _, err := compileBoostQuery(rule.Query); err != nil {
+			return fmt.Errorf("invalid boost rule %s: %w", rule.label(n), err)
+		}
+	}
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode boost rules: %w", err)
+	}
+	return i.manager.storage.SetIndexSetting(i.name, boostRulesSettingKey, data)
+}
+
+// BoostRules implementation
+func (i *indexImpl) BoostRules() ([]BoostRule, error) {
+	data, err := i.manager.storage.GetIndexSetting(i.name, boostRulesSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var rules []BoostRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode boost rules: %w", err)
+	}
+	return rules, nil
+}
+
+// compileBoostQuery compiles the query pattern of a rule, nil for one
+// matching every query
+func compileBoostQuery(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query pattern: %w", err)
+	}
+	return re, nil
+}
+
+// matchBoostRules returns what the boost rules matching query do, by
+// document URI, or nil if none match
+func (i *indexImpl) matchBoostRules(query string) (map[string]*documentBoost, error) {
+	rules, err := i.BoostRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load boost rules: %w", err)
+	}
+
+	var boosts map[string]*documentBoost
+	pins := 0
+	for n, rule := range rules {
+		re, err := compileBoostQuery(rule.Query)
+		if err != nil {
+			return nil, fmt.Errorf("boost rule %s: %w", rule.label(n), err)
+		}
+		if re != nil && !re.MatchString(query) {
+			continue
+		}
+		if boosts == nil {
+			boosts = make(map[string]*documentBoost)
+		}
+		for _, uri := range rule.URIs {
+			boost, ok := boosts[uri]
+			if !ok {
+				boost = &documentBoost{}
+				boosts[uri] = boost
+			}
+			if rule.Boost != 0 {
+				boost.delta += rule.Boost
+				boost.rules = append(boost.rules, rule.label(n))
+			}
+			if rule.Pin && boost.pin == 0 {
+				pins++
+				boost.pin = pins
+				boost.by = rule.label(n)
+			}
+		}
+	}
+	return boosts, nil
+}
+
+// pinnedCandidates adds the best chunk of each pinned document without a
+// graph hit to the hits, so pinned documents are found whatever the query
+func (i *indexImpl) pinnedCandidates(embedding []float32, hits []indexer.SearchResult, boosts map[string]*documentBoost, timing *SearchTiming) ([]indexer.SearchResult, error) {
+	start := time.Now()
+	found := make(map[uint64]bool, len(hits))
+	for _, hr := range hits {
+		found[hr.ID] = true
+	}
+	embedding = i.graphVector(embedding)
+
+	for uri, boost := range boosts {
+		if boost.pin == 0 {
+			continue
+		}
+		chunks, err := i.manager.storage.GetChunksByDocument(i.name, uri)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load pinned document '%s': %w", uri, err)
+		}
+
+		var best indexer.SearchResult
+		for _, chunk := range chunks {
+			if found[chunk.HNSWId] {
+				best = indexer.SearchResult{}
+				break
+			}
+			distance, ok := i.hnswIndex.Distance(embedding, chunk.HNSWId)
+			if !ok {
+				continue
+			}
+			if best.ID == 0 || distance < best.Distance {
+				best = indexer.SearchResult{ID: chunk.HNSWId, Score: i.graphScore(distance), Distance: distance}
+			}
+		}
+		if best.ID != 0 {
+			hits = append(hits, best)
+		}
+	}
+	timing.Graph += time.Since(start)
+	return hits, nil
+}
+
+// applyBoostRules adds the boost of the rules matching a result's document
+// to its score
+func applyBoostRules(result *SearchResult, boost *documentBoost) {
+	if boost.delta == 0 {
+		return
+	}
+	result.Score += boost.delta
+	if result.Explain != nil {
+		result.Explain.Score = result.Score
+		result.Explain.Adjustments = append(result.Explain.Adjustments, ScoreAdjustment{
+			Stage:  "boost_rule",
+			Delta:  boost.delta,
+			Reason: "boosted by rules " + strings.Join(boost.rules, ", "),
+		})
+	}
+}
+
+// pinResults moves the best result of each pinned document, results being
+// ordered by score, to the front in pin order and marks it pinned
+func pinResults(results []SearchResult, boosts map[string]*documentBoost) {
+	pinned := make(map[string]bool)
+	for idx := range results {
+		result := &results[idx]
+		boost, ok := boosts[result.Document.URI]
+		if !ok || boost.pin == 0 || pinned[result.Document.URI] {
+			continue
+		}
+		pinned[result.Document.URI] = true
+		result.Pinned = true
+		if result.Explain != nil {
+			result.Explain.Adjustments = append(result.Explain.Adjustments, ScoreAdjustment{
+				Stage:  "pin",
+				Reason: "pinned by rule " + boost.by,
+			})
+		}
+	}
+	if len(pinned) == 0 {
+		return
+	}
+
+	pinOrder := func(result SearchResult) int {
+		if !result.Pinned {
+			return len(boosts) + 1
+		}
+		return boosts[result.Document.URI].pin
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		return pinOrder(results[a]) < pinOrder(results[b])
+	})
+}
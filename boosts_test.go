@@ -0,0 +1,77 @@
+package hnswindex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resultURIs returns the document URIs of results in order
+func resultURIs(results []SearchResult) []string {
+	uris := make([]string, len(results))
+	for n, result := range results {
+		uris[n] = result.Document.URI
+	}
+	return uris
+}
+
+func TestIndex_BoostRules(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "stale", Title: "Install (old)", Content: "How to install the agent on a server"},
+		Document{URI: "official", Title: "Install", Content: "Installing the agent on servers"},
+		Document{URI: "faq", Title: "FAQ", Content: "Billing questions and invoices"},
+	)
+
+	query := "How to install the agent on a server"
+	results, err := index.Search(query, 2)
+	require.NoError(t, err)
+	require.Equal(t, "stale", results[0].Document.URI)
+
+	for _, rules := range [][]BoostRule{
+		{{Name: "none", Boost: 1}},
+		{{Name: "noop", URIs: []string{"official"}}},
+		{{Name: "bad", URIs: []string{"official"}, Boost: 1, Query: "("}},
+	} {
+		assert.Error(t, index.SetBoostRules(rules), rules[0].Name)
+	}
+
+	require.NoError(t, index.SetBoostRules([]BoostRule{
+		{Name: "official-install", Query: "INSTALL", URIs: []string{"official"}, Boost: 1},
+		{Name: "billing", Query: "invoice", URIs: []string{"faq"}, Pin: true},
+	}))
+	rules, err := index.BoostRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "official-install", rules[0].Name)
+
+	// Boosts reorder results, and only apply to matching queries
+	results, err = index.SearchWithOptions(query, 2, SearchOptions{Explain: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"official", "stale"}, resultURIs(results))
+	assert.Contains(t, results[0].Explain.Adjustments, ScoreAdjustment{
+		Stage:  "boost_rule",
+		Delta:  1,
+		Reason: "boosted by rules official-install",
+	})
+	assert.False(t, results[0].Pinned)
+
+	results, err = index.SearchWithOptions(query, 2, SearchOptions{IgnoreBoostRules: true})
+	require.NoError(t, err)
+	assert.Equal(t, "stale", results[0].Document.URI)
+
+	// Pinned documents come first even when the graph missed them
+	results, err = index.Search("install agent invoice", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "faq", results[0].Document.URI)
+	assert.True(t, results[0].Pinned)
+
+	require.NoError(t, index.SetBoostRules(nil))
+	rules, err = index.BoostRules()
+	require.NoError(t, err)
+	assert.Nil(t, rules)
+}
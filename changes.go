@@ -0,0 +1,71 @@
+package hnswindex
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// ChangeOp is the kind of change recorded in an index's change log
+type ChangeOp string
+
+// Change log operations
+const (
+	ChangeUpsert ChangeOp = storage.ChangeUpsert // Document added or updated
+	ChangeDelete ChangeOp = storage.ChangeDelete // Document deleted
+)
+
+// Change is an entry of an index's append-only change log. Entries are
+// written in the same storage transaction as the change, with sequence
+// numbers that increase by one per change and are never reused.
+type Change struct {
+	Seq       uint64    `json:"seq"`
+	Op        ChangeOp  `json:"op"`
+	URI       string    `json:"uri"`
+	Hash      string    `json:"hash,omitempty"` // Content hash after an upsert
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Changes returns up to limit changes with a sequence number greater than
+// since, oldest first (0 returns all). Consumers store the Seq of the last
+// change they processed and pass it to the next call to tail the index;
+// GetDocument returns the current state of an upserted document.
+func (i *Index) Changes(since uint64, limit int) ([]Change, error) {
+	if impl := i.getImpl(); impl != nil {
+		entries, err := impl.manager.storage.ListChanges(i.name, since, limit)
+		if err != nil {
+			return nil, err
+		}
+		changes := make([]Change, len(entries))
+		for idx, e := range entries {
+			changes[idx] = Change{
+				Seq:       e.Seq,
+				Op:        ChangeOp(e.Op),
+				URI:       e.URI,
+				Hash:      e.Hash,
+				Timestamp: e.Timestamp,
+			}
+		}
+		return changes, nil
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// LatestChange returns the sequence number of the most recent change, or 0
+// if nothing has been recorded. New consumers start tailing from it.
+func (i *Index) LatestChange() (uint64, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.manager.storage.LatestChangeSeq(i.name)
+	}
+	return 0, fmt.Errorf("implementation not available")
+}
+
+// TrimChanges deletes changes up to and including sequence number through,
+// once every consumer has processed them, and returns the number removed
+func (i *Index) TrimChanges(through uint64) (int, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.manager.storage.TrimChanges(i.name, through)
+	}
+	return 0, fmt.Errorf("implementation not available")
+}
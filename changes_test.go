@@ -0,0 +1,67 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_Changes(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("changes")
+	require.NoError(t, err)
+
+	latest, err := index.LatestChange()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), latest)
+
+	docs := []Document{
+		{URI: "doc1", Title: "One", Content: "First document content"},
+		{URI: "doc2", Title: "Two", Content: "Second document content"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	// Unchanged documents are not logged again
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	_, err = manager.Batch(func(tx *ManagerTx) error {
+		return tx.Add("changes", Document{URI: "doc3", Title: "Three", Content: "Third document content"})
+	})
+	require.NoError(t, err)
+	require.NoError(t, index.DeleteDocument("doc1"))
+
+	changes, err := index.Changes(0, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+
+	var ops []string
+	for _, c := range changes {
+		ops = append(ops, string(c.Op)+" "+c.URI)
+	}
+	// A batch's documents are logged in commit order
+	assert.ElementsMatch(t, []string{"upsert doc1", "upsert doc2"}, ops[:2])
+	assert.Equal(t, []string{"upsert doc3", "delete doc1"}, ops[2:])
+	assert.NotEmpty(t, changes[0].Hash)
+	assert.False(t, changes[0].Timestamp.IsZero())
+
+	// Tail from the last processed change
+	tail, err := index.Changes(changes[2].Seq, 10)
+	require.NoError(t, err)
+	require.Len(t, tail, 1)
+	assert.Equal(t, ChangeDelete, tail[0].Op)
+
+	latest, err = index.LatestChange()
+	require.NoError(t, err)
+	assert.Equal(t, changes[3].Seq, latest)
+
+	removed, err := index.TrimChanges(changes[1].Seq)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	changes, err = index.Changes(0, 0)
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+}
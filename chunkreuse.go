@@ -0,0 +1,52 @@
+package hnswindex
+
+import (
+	"log/slog"
+
+	"github.com/riclib/hnswindex/internal/chunker"
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// reusableChunks matches the new chunks of a document with its stored
+// chunks of the same text. A matched chunk keeps the stored embedding and
+// HNSW ID, so updating a long document only embeds and inserts the chunks
+// that changed. Chunks missing from the graph, such as those of a document
+// whose graph update failed, are embedded and inserted again. The result has an entry per new chunk, nil where none
+// matched.
+func (i *indexImpl) reusableChunks(uri string, chunks []chunker.Chunk) []*storage.Chunk {
+	previous := make([]*storage.Chunk, len(chunks))
+	stored, err := i.manager.storage.GetChunksByDocument(i.name, uri)
+	if err != nil || len(stored) == 0 {
+		return previous
+	}
+
+	// Stored chunks are in position order, so repeated texts pair up in order
+	dimension := i.embeddingDimension()
+	byText := make(map[string][]*storage.Chunk, len(stored))
+	for idx := range stored {
+		c := &stored[idx]
+		if c.HNSWId == 0 || len(c.Embedding) != dimension || !i.manager.hasChunkVectors(c) {
+			continue
+		}
+		if _, ok := i.hnswIndex.Lookup(c.HNSWId); ok {
+			byText[c.Text] = append(byText[c.Text], c)
+		}
+	}
+
+	reused := 0
+	for idx, chunk := range chunks {
+		if matches := byText[chunk.Text]; len(matches) > 0 {
+			previous[idx] = matches[0]
+			byText[chunk.Text] = matches[1:]
+			reused++
+		}
+	}
+
+	slog.Debug("Matched unchanged chunks",
+		"index", i.name,
+		"uri", uri,
+		"chunks", len(chunks),
+		"reused", reused,
+	)
+	return previous
+}
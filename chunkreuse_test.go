@@ -0,0 +1,79 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_UpdateReusesUnchangedChunks(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 0
+	manager := newMockManager(t, cfg)
+	counter := &countingEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.getImpl().embedder = counter
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	content := strings.Repeat("The deployment pipeline builds, tests, and ships every service. ", 20)
+	_, err = index.AddDocumentBatch(context.Background(), []Document{{URI: "doc1", Content: content}}, nil)
+	require.NoError(t, err)
+	before, err := index.getImpl().manager.storage.GetChunksByDocument("kb", "doc1")
+	require.NoError(t, err)
+	require.Greater(t, len(before), 2)
+	embedded := counter.texts
+
+	// Appending a sentence leaves the earlier chunks as they were
+	_, err = index.AddDocumentBatch(context.Background(), []Document{{URI: "doc1", Content: content + "Rollbacks are manual."}}, nil)
+	require.NoError(t, err)
+	after, err := index.getImpl().manager.storage.GetChunksByDocument("kb", "doc1")
+	require.NoError(t, err)
+
+	assert.Less(t, counter.texts-embedded, len(after), "only changed chunks are embedded")
+	assert.Equal(t, before[0].HNSWId, after[0].HNSWId)
+	assert.Equal(t, before[0].ID, after[0].ID)
+	assert.Equal(t, len(after), index.getImpl().hnswIndex.Size())
+
+	results, err := index.Search("Rollbacks are manual.", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc1", results[0].Document.URI)
+}
+
+func TestIndex_UpdateMiddleReusesChunks(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 100
+	cfg.ChunkOverlap = 20
+	cfg.ContentDefinedChunks = true
+	manager := newMockManager(t, cfg)
+	counter := &countingEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.getImpl().embedder = counter
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	paragraphs := make([]string, 40)
+	for i := range paragraphs {
+		paragraphs[i] = fmt.Sprintf("Step %d of the runbook: check the %d open alerts and restart service %d. "+
+			"Escalate after %d minutes.", i, i*3%11, i*7%13, i%5+1)
+	}
+	_, err = index.AddDocumentBatch(context.Background(), []Document{{URI: "doc1", Content: strings.Join(paragraphs, "\n\n")}}, nil)
+	require.NoError(t, err)
+	embedded := counter.texts
+
+	// Editing a paragraph in the middle leaves the chunk boundaries after
+	// it where they were
+	paragraphs[20] = "This step was rewritten and now says something else entirely."
+	_, err = index.AddDocumentBatch(context.Background(), []Document{{URI: "doc1", Content: strings.Join(paragraphs, "\n\n")}}, nil)
+	require.NoError(t, err)
+	after, err := index.getImpl().manager.storage.GetChunksByDocument("kb", "doc1")
+	require.NoError(t, err)
+
+	require.Greater(t, len(after), 10)
+	assert.LessOrEqual(t, counter.texts-embedded, 4, "most chunks are reused")
+	assert.Equal(t, len(after), index.getImpl().hnswIndex.Size())
+}
@@ -0,0 +1,180 @@
+package hnswindex
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+const (
+	clusterIterations      = 10 // k-means refinement rounds
+	clusterRepresentatives = 3  // Chunks closest to each centroid
+	clusterKeywords        = 8  // Most distinctive terms per cluster
+)
+
+// Cluster is a group of chunks with similar embeddings, as found by
+// Index.Cluster
+type Cluster struct {
+	Size            int            `json:"size"`      // Chunks in the cluster
+	Documents       int            `json:"documents"` // Distinct documents with a chunk in the cluster
+	Keywords        []string       `json:"keywords"`  // Terms most distinctive of the cluster
+	Representatives []ClusterChunk `json:"representatives"`
+	Centroid        []float32      `json:"-"`
+}
+
+// ClusterChunk is a chunk representative of a cluster
+type ClusterChunk struct {
+	Chunk
+	Similarity float64 `json:"similarity"` // Cosine similarity to the cluster centroid
+}
+
+// Cluster groups the chunks of the index into at most k topics by k-means
+// over their embeddings, for browsing an unfamiliar corpus. Each cluster
+// lists the chunks closest to its centroid and the terms that set it apart
+// from the rest of the index. Clusters are ordered by size, largest first.
+func (i *Index) Cluster(k int) ([]Cluster, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Cluster(k)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Cluster implementation
+func (i *indexImpl) Cluster(k int) ([]Cluster, error) {
+	if k <= 0 {
+		return nil, errors.New("number of clusters must be positive")
+	}
+
+	vectors, err := i.sampleEmbeddings(i.hnswIndex.Size())
+	if err != nil {
+		return nil, err
+	}
+	centroids := kmeans(vectors, k, clusterIterations)
+	if len(centroids) == 0 {
+		return []Cluster{}, nil
+	}
+
+	// Assign every chunk, not just the sample, to its nearest centroid
+	clusters := make([]Cluster, len(centroids))
+	documents := make([]map[string]bool, len(centroids))
+	terms := make([]map[string]int, len(centroids)) // Chunks per term in each cluster
+	corpus := make(map[string]int)                  // Chunks per term in the index
+	total := 0
+	for c := range clusters {
+		clusters[c].Centroid = centroids[c]
+		documents[c] = make(map[string]bool)
+		terms[c] = make(map[string]int)
+	}
+
+	dimension := i.embeddingDimension()
+	err = i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		if len(chunk.Embedding) != dimension {
+			return nil
+		}
+		c, similarity := nearestCentroid(normalizeEmbedding(chunk.Embedding), centroids)
+		cluster := &clusters[c]
+		cluster.Size++
+		documents[c][chunk.DocumentURI] = true
+		total++
+		for term := range keywordTerms(chunk.Text) {
+			terms[c][term]++
+			corpus[term]++
+		}
+
+		reps := cluster.Representatives
+		if len(reps) == clusterRepresentatives && similarity <= reps[len(reps)-1].Similarity {
+			return nil
+		}
+		rep := fromStorageChunk(&chunk)
+		rep.Embedding, rep.Vectors, rep.Sparse = nil, nil, nil
+		reps = append(reps, ClusterChunk{Chunk: rep, Similarity: similarity})
+		sort.SliceStable(reps, func(a, b int) bool { return reps[a].Similarity > reps[b].Similarity })
+		cluster.Representatives = reps[:min(len(reps), clusterRepresentatives)]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Cluster, 0, len(clusters))
+	for c := range clusters {
+		if clusters[c].Size == 0 {
+			continue
+		}
+		clusters[c].Documents = len(documents[c])
+		clusters[c].Keywords = topKeywords(terms[c], clusters[c].Size, corpus, total)
+		result = append(result, clusters[c])
+	}
+	sort.SliceStable(result, func(a, b int) bool { return result[a].Size > result[b].Size })
+	return result, nil
+}
+
+// topKeywords ranks the terms of a cluster by how much more often they
+// occur in its chunks than in the whole index (TF-IDF over chunks)
+func topKeywords(terms map[string]int, size int, corpus map[string]int, total int) []string {
+	type scored struct {
+		term  string
+		score float64
+	}
+	var ranked []scored
+	for term, count := range terms {
+		if count < 2 && size > 1 {
+			continue // Too rare in the cluster to describe it
+		}
+		idf := math.Log(1 + float64(total)/float64(corpus[term]))
+		ranked = append(ranked, scored{term, float64(count) / float64(size) * idf})
+	}
+	sort.Slice(ranked, func(a, b int) bool {
+		if ranked[a].score != ranked[b].score {
+			return ranked[a].score > ranked[b].score
+		}
+		return ranked[a].term < ranked[b].term
+	})
+
+	keywords := make([]string, 0, clusterKeywords)
+	for _, s := range ranked[:min(len(ranked), clusterKeywords)] {
+		keywords = append(keywords, s.term)
+	}
+	return keywords
+}
+
+// keywordTerms returns the distinct lowercase words of text that can serve
+// as keywords: at least three characters, not all digits, and not a
+// stopword
+func keywordTerms(text string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make(map[string]bool, len(words))
+	for _, w := range words {
+		if len([]rune(w)) < 3 || stopwords[w] || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		terms[w] = true
+	}
+	return terms
+}
+
+// stopwords are common English words that never make useful keywords
+var stopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true,
+	"not": true, "you": true, "all": true, "any": true, "can": true,
+	"has": true, "have": true, "had": true, "was": true, "were": true,
+	"this": true, "that": true, "these": true, "those": true, "with": true,
+	"from": true, "into": true, "onto": true, "they": true, "them": true,
+	"their": true, "there": true, "then": true, "than": true, "will": true,
+	"would": true, "should": true, "could": true, "when": true, "where": true,
+	"which": true, "what": true, "who": true, "how": true, "why": true,
+	"our": true, "your": true, "its": true, "been": true, "being": true,
+	"also": true, "more": true, "most": true, "some": true, "such": true,
+	"only": true, "other": true, "about": true, "over": true, "under": true,
+	"use": true, "used": true, "using": true, "each": true, "may": true,
+	"must": true, "one": true, "two": true, "via": true, "per": true,
+	"does": true, "did": true, "done": true, "out": true, "off": true,
+	"here": true, "very": true, "just": true, "like": true,
+}
@@ -0,0 +1,72 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_Cluster(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("topics")
+	require.NoError(t, err)
+
+	clusters, err := index.Cluster(3)
+	require.NoError(t, err)
+	assert.Empty(t, clusters)
+
+	texts := []string{
+		"database failover runbook for the primary",
+		"database failover drill checklist",
+		"failover of the cache tier",
+		"holiday calendar for the office",
+		"office seating plan",
+	}
+	var docs []Document
+	for n, text := range texts {
+		docs = append(docs, Document{URI: fmt.Sprintf("doc%d", n), Title: fmt.Sprintf("Doc %d", n), Content: text})
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	// A single cluster covers every chunk, keyed by its most common terms
+	clusters, err = index.Cluster(1)
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, 5, clusters[0].Size)
+	assert.Equal(t, 5, clusters[0].Documents)
+	assert.Equal(t, []string{"failover", "database", "office"}, clusters[0].Keywords)
+	require.Len(t, clusters[0].Representatives, clusterRepresentatives)
+	for n, rep := range clusters[0].Representatives {
+		assert.Nil(t, rep.Embedding)
+		assert.NotEmpty(t, rep.Text)
+		if n > 0 {
+			assert.GreaterOrEqual(t, clusters[0].Representatives[n-1].Similarity, rep.Similarity)
+		}
+	}
+
+	clusters, err = index.Cluster(3)
+	require.NoError(t, err)
+	require.NotEmpty(t, clusters)
+	size := 0
+	for n, c := range clusters {
+		size += c.Size
+		assert.NotEmpty(t, c.Representatives)
+		assert.Len(t, c.Centroid, 768)
+		if n > 0 {
+			assert.GreaterOrEqual(t, clusters[n-1].Size, c.Size)
+		}
+	}
+	assert.Equal(t, 5, size)
+
+	_, err = index.Cluster(0)
+	assert.Error(t, err)
+}
+
+func TestKeywordTerms(t *testing.T) {
+	terms := keywordTerms("The API v2: rotate keys, 2024 and RE-ROTATE the keys")
+	assert.Equal(t, map[string]bool{"api": true, "rotate": true, "keys": true}, terms)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate",
+	Short: "Set the description and labels of an index",
+	Long: `Describe what an index contains and label it, so "list" and "stats"
+show it. --label key=value adds or changes a label and --label key- removes
+it; other labels are kept.
+
+  demo annotate -i kb2 --description "Support articles, English" --label team=support
+  demo annotate -i tmp-test --label env=test --label owner-`,
+	RunE: runAnnotate,
+}
+
+func init() {
+	annotateCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	annotateCmd.Flags().String("description", "", "description of the index contents")
+	annotateCmd.Flags().Bool("clear-description", false, "remove the description")
+	annotateCmd.Flags().StringArray("label", nil, "set a label (key=value) or remove one (key-), repeatable")
+
+	rootCmd.AddCommand(annotateCmd)
+}
+
+func runAnnotate(cmd *cobra.Command, args []string) error {
+	description, _ := cmd.Flags().GetString("description")
+	clearDescription, _ := cmd.Flags().GetBool("clear-description")
+	changes, _ := cmd.Flags().GetStringArray("label")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	if description != "" || clearDescription {
+		if err := index.SetDescription(description); err != nil {
+			return fmt.Errorf("failed to set description: %w", err)
+		}
+	}
+
+	if len(changes) > 0 {
+		labels, err := index.Labels()
+		if err != nil {
+			return fmt.Errorf("failed to read labels: %w", err)
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		for _, change := range changes {
+			if key, value, ok := strings.Cut(change, "="); ok {
+				labels[key] = value
+			} else if key, ok := strings.CutSuffix(change, "-"); ok {
+				delete(labels, key)
+			} else {
+				return fmt.Errorf("invalid label %q: use key=value or key-", change)
+			}
+		}
+		if err := index.SetLabels(labels); err != nil {
+			return fmt.Errorf("failed to set labels: %w", err)
+		}
+	}
+
+	return showIndexStats(manager, indexName, false, false)
+}
+
+// formatLabels prints labels as sorted key=value pairs
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
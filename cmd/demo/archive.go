@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Move a single index between data directories",
+	Long: `Export one index (graph, documents, chunks, metadata and settings) as a
+versioned tar+gzip archive on stdout, or import one from stdin into this
+data directory. Imports are checked against the archive's manifest and
+create a new index without re-embedding anything, so indexes can be built
+on CI and shipped to production machines:
+
+  demo archive export -i kb > kb.tgz
+  demo archive import -i kb-2024 < kb.tgz`,
+}
+
+var archiveExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write an index archive to stdout",
+	RunE:  runArchiveExport,
+}
+
+var archiveImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create an index from an archive on stdin",
+	RunE:  runArchiveImport,
+}
+
+func init() {
+	archiveExportCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index to export")
+	archiveImportCmd.Flags().StringVarP(&indexName, "index", "i", "", "name of the new index (default: the exported name)")
+
+	archiveCmd.AddCommand(archiveExportCmd)
+	archiveCmd.AddCommand(archiveImportCmd)
+	rootCmd.AddCommand(archiveCmd)
+}
+
+func runArchiveExport(cmd *cobra.Command, args []string) error {
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	out := bufio.NewWriter(os.Stdout)
+	if err := manager.ExportIndex(indexName, out); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+func runArchiveImport(cmd *cobra.Command, args []string) error {
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.ImportIndex(indexName, bufio.NewReader(os.Stdin))
+	if err != nil {
+		return err
+	}
+	stats, err := index.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to get stats: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Index '%s' imported: %d documents, %d chunks\n", index.Name(), stats.DocumentCount, stats.ChunkCount)
+	return nil
+}
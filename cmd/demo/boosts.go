@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var boostsCmd = &cobra.Command{
+	Use:   "boosts",
+	Short: "List, add or remove the boost rules of an index",
+	Long: `Boost rules raise curated documents in search results, or pin them
+first, for every query or for queries matching a regular expression. With
+--add, a rule named by --add replaces any rule of that name.
+
+  demo boosts -i kb2
+  demo boosts -i kb2 --add official-install --query "install|setup" --uri doc://install --pin
+  demo boosts -i kb2 --add handbook --uri doc://handbook --boost 0.1
+  demo boosts -i kb2 --remove handbook`,
+	RunE: runBoosts,
+}
+
+func init() {
+	boostsCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	boostsCmd.Flags().String("add", "", "add or replace the rule with this name")
+	boostsCmd.Flags().String("query", "", "only apply the rule to queries matching this regular expression")
+	boostsCmd.Flags().StringArray("uri", nil, "document the rule applies to, repeatable")
+	boostsCmd.Flags().Float64("boost", 0, "add this to the score of the documents' results")
+	boostsCmd.Flags().Bool("pin", false, "place the documents' best results first")
+	boostsCmd.Flags().String("remove", "", "remove the rule with this name")
+	boostsCmd.Flags().Bool("clear", false, "remove every rule")
+
+	rootCmd.AddCommand(boostsCmd)
+}
+
+func runBoosts(cmd *cobra.Command, args []string) error {
+	add, _ := cmd.Flags().GetString("add")
+	remove, _ := cmd.Flags().GetString("remove")
+	clearAll, _ := cmd.Flags().GetBool("clear")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	rules, err := index.BoostRules()
+	if err != nil {
+		return fmt.Errorf("failed to read boost rules: %w", err)
+	}
+
+	if add != "" || remove != "" || clearAll {
+		var kept []hnswindex.BoostRule
+		if !clearAll {
+			for _, rule := range rules {
+				if rule.Name != add && rule.Name != remove {
+					kept = append(kept, rule)
+				}
+			}
+		}
+		if add != "" {
+			query, _ := cmd.Flags().GetString("query")
+			uris, _ := cmd.Flags().GetStringArray("uri")
+			boost, _ := cmd.Flags().GetFloat64("boost")
+			pin, _ := cmd.Flags().GetBool("pin")
+			kept = append(kept, hnswindex.BoostRule{Name: add, Query: query, URIs: uris, Boost: boost, Pin: pin})
+		}
+		if err := index.SetBoostRules(kept); err != nil {
+			return fmt.Errorf("failed to set boost rules: %w", err)
+		}
+		rules = kept
+	}
+
+	if len(rules) == 0 {
+		fmt.Printf("Index '%s' has no boost rules\n", indexName)
+		return nil
+	}
+	for _, rule := range rules {
+		query := rule.Query
+		if query == "" {
+			query = "(every query)"
+		}
+		action := fmt.Sprintf("boost %+.3f", rule.Boost)
+		if rule.Pin {
+			action = "pin"
+			if rule.Boost != 0 {
+				action += fmt.Sprintf(", boost %+.3f", rule.Boost)
+			}
+		}
+		fmt.Printf("%s: %s when %s\n", rule.Name, action, query)
+		fmt.Printf("   %s\n", strings.Join(rule.URIs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var changesCmd = &cobra.Command{
+	Use:   "changes",
+	Short: "Show the change log of an index",
+	Long: `List the document upserts and deletes recorded in the change log of an
+index after sequence number --since.`,
+	RunE: runChanges,
+}
+
+func init() {
+	changesCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	changesCmd.Flags().Uint64("since", 0, "show changes after this sequence number")
+	changesCmd.Flags().IntP("limit", "l", 100, "maximum number of changes")
+	changesCmd.Flags().Uint64("trim", 0, "delete changes up to and including this sequence number")
+
+	rootCmd.AddCommand(changesCmd)
+}
+
+func runChanges(cmd *cobra.Command, args []string) error {
+	since, _ := cmd.Flags().GetUint64("since")
+	limit, _ := cmd.Flags().GetInt("limit")
+	trim, _ := cmd.Flags().GetUint64("trim")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	if trim > 0 {
+		removed, err := index.TrimChanges(trim)
+		if err != nil {
+			return fmt.Errorf("failed to trim change log: %w", err)
+		}
+		fmt.Printf("Removed %d changes\n", removed)
+		return nil
+	}
+
+	changes, err := index.Changes(since, limit)
+	if err != nil {
+		return fmt.Errorf("failed to read change log: %w", err)
+	}
+	for _, c := range changes {
+		fmt.Printf("%6d  %s  %-6s  %s\n", c.Seq, c.Timestamp.Local().Format("2006-01-02 15:04:05"), c.Op, c.URI)
+	}
+
+	latest, err := index.LatestChange()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nLatest sequence: %d\n", latest)
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var clusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Group the chunks of an index into topics",
+	Long: `Run k-means over the chunk embeddings of an index and list each topic with
+its most distinctive keywords and the chunks closest to its center.`,
+	RunE: runCluster,
+}
+
+func init() {
+	clusterCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	clusterCmd.Flags().IntP("clusters", "k", 10, "number of clusters")
+
+	rootCmd.AddCommand(clusterCmd)
+}
+
+func runCluster(cmd *cobra.Command, args []string) error {
+	k, _ := cmd.Flags().GetInt("clusters")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	clusters, err := index.Cluster(k)
+	if err != nil {
+		return fmt.Errorf("failed to cluster index: %w", err)
+	}
+	if len(clusters) == 0 {
+		fmt.Println("Index is empty")
+		return nil
+	}
+
+	for n, c := range clusters {
+		fmt.Printf("%d. %s (%d chunks in %d documents)\n", n+1, strings.Join(c.Keywords, ", "), c.Size, c.Documents)
+		for _, rep := range c.Representatives {
+			fmt.Printf("   %.3f  %s: %s\n", rep.Similarity, rep.DocumentURI, rep.Preview(100))
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare search results of two index configurations",
+	Long: `Run the same query set against two indexes and print a side-by-side diff.
+The indexes can live in different data directories and use different
+embedding models, so chunk size or model changes can be evaluated before
+switching over.`,
+	RunE: runCompare,
+}
+
+func init() {
+	compareCmd.Flags().String("a-index", "", "first index name (required)")
+	compareCmd.Flags().String("b-index", "", "second index name (required)")
+	compareCmd.Flags().String("a-data", "", "data directory of the first index (default: --data)")
+	compareCmd.Flags().String("b-data", "", "data directory of the second index (default: --data)")
+	compareCmd.Flags().String("a-model", "", "embedding model of the first index (default: embed_model)")
+	compareCmd.Flags().String("b-model", "", "embedding model of the second index (default: embed_model)")
+	compareCmd.Flags().StringP("queries", "q", "", "file with one query per line (required)")
+	compareCmd.Flags().IntP("limit", "l", 5, "number of results per query")
+	compareCmd.Flags().Bool("json", false, "print the full report as JSON")
+	compareCmd.MarkFlagRequired("a-index")
+	compareCmd.MarkFlagRequired("b-index")
+	compareCmd.MarkFlagRequired("queries")
+
+	rootCmd.AddCommand(compareCmd)
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	aIndex, _ := cmd.Flags().GetString("a-index")
+	bIndex, _ := cmd.Flags().GetString("b-index")
+	aData, _ := cmd.Flags().GetString("a-data")
+	bData, _ := cmd.Flags().GetString("b-data")
+	aModel, _ := cmd.Flags().GetString("a-model")
+	bModel, _ := cmd.Flags().GetString("b-model")
+	queryFile, _ := cmd.Flags().GetString("queries")
+	limit, _ := cmd.Flags().GetInt("limit")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	queries, err := readQueries(queryFile)
+	if err != nil {
+		return err
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("no queries found in %s", queryFile)
+	}
+
+	managerA, err := openCompareManager(aData, aModel)
+	if err != nil {
+		return err
+	}
+	defer managerA.Close()
+
+	// Sharing one data directory means sharing one manager and database
+	managerB := managerA
+	if bData != aData || bModel != aModel {
+		managerB, err = openCompareManager(bData, bModel)
+		if err != nil {
+			return err
+		}
+		defer managerB.Close()
+	}
+
+	indexA, err := managerA.GetIndex(aIndex)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found: %w", aIndex, err)
+	}
+	indexB, err := managerB.GetIndex(bIndex)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found: %w", bIndex, err)
+	}
+
+	report, err := hnswindex.CompareIndexes(context.Background(), indexA, indexB, queries, limit)
+	if err != nil {
+		return fmt.Errorf("comparison failed: %w", err)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	for _, qc := range report.Queries {
+		fmt.Printf("Query: %s\n", qc.Query)
+		fmt.Printf("  %-50s | %-50s\n", "A: "+report.IndexA, "B: "+report.IndexB)
+		rows := len(qc.A)
+		if len(qc.B) > rows {
+			rows = len(qc.B)
+		}
+		for r := 0; r < rows; r++ {
+			fmt.Printf("  %-50s | %-50s\n", compareCell(qc.A, r), compareCell(qc.B, r))
+		}
+		if qc.ErrorA != "" {
+			fmt.Printf("  A error: %s\n", qc.ErrorA)
+		}
+		if qc.ErrorB != "" {
+			fmt.Printf("  B error: %s\n", qc.ErrorB)
+		}
+		fmt.Printf("  overlap %d, jaccard %.2f, latency A %s / B %s\n\n",
+			qc.Overlap, qc.Jaccard, qc.LatencyA, qc.LatencyB)
+	}
+
+	s := report.Summary
+	fmt.Printf("Summary over %d queries:\n", s.Queries)
+	fmt.Printf("  Mean jaccard: %.3f (mean overlap %.2f documents)\n", s.MeanJaccard, s.MeanOverlap)
+	fmt.Printf("  Same top result: %d/%d\n", s.SameTopCount, s.Queries)
+	fmt.Printf("  Mean top score: A %.3f, B %.3f\n", s.MeanTopScoreA, s.MeanTopScoreB)
+	fmt.Printf("  Mean latency: A %s, B %s\n", s.MeanLatencyA, s.MeanLatencyB)
+	fmt.Printf("  Zero-result queries: A %d, B %d\n", s.ZeroResultsA, s.ZeroResultsB)
+
+	return nil
+}
+
+// openCompareManager opens a manager with an optional data path and model override
+func openCompareManager(data, model string) (*hnswindex.IndexManager, error) {
+	config := loadConfig()
+	if data != "" {
+		config.DataPath = data
+	}
+	if model != "" {
+		config.EmbedModel = model
+	}
+
+	manager, err := hnswindex.NewIndexManager(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create index manager for %s: %w", config.DataPath, err)
+	}
+	return manager, nil
+}
+
+// readQueries reads one query per line, skipping blanks and # comments
+func readQueries(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open query file: %w", err)
+	}
+	defer file.Close()
+
+	var queries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	return queries, scanner.Err()
+}
+
+func compareCell(results []hnswindex.ComparedResult, row int) string {
+	if row >= len(results) {
+		return ""
+	}
+	title := hnswindex.TruncateText(results[row].Title, 35)
+	return fmt.Sprintf("%d. %s (%.3f)", row+1, title, results[row].Score)
+}
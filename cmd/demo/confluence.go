@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/pkg/confluence"
+	"github.com/spf13/cobra"
+)
+
+var confluenceSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Incrementally sync Confluence spaces into an index",
+	Long: `Sync one or more Confluence spaces into an index.
+
+The first sync of a space downloads every page. The time of each sync is
+saved as a cursor in the index, one per space, so later runs fetch only the
+pages modified since then. Pages deleted or moved out of a space are removed
+from the index. Run it on a schedule to keep the index current.`,
+	RunE: runConfluenceSync,
+}
+
+func init() {
+	confluenceSyncCmd.Flags().StringSliceP("space", "s", nil, "Confluence space keys to sync (required)")
+	confluenceSyncCmd.Flags().StringP("url", "u", "", "Confluence base URL (required)")
+	confluenceSyncCmd.Flags().String("username", "", "Confluence username (or use CONFLUENCE_USERNAME env)")
+	confluenceSyncCmd.Flags().String("token", "", "Confluence API token (or use CONFLUENCE_API_TOKEN env)")
+	confluenceSyncCmd.Flags().StringVarP(&indexName, "index", "i", "confluence", "Index name")
+	confluenceSyncCmd.Flags().Bool("full", false, "ignore the saved cursors and resync every page")
+	confluenceSyncCmd.MarkFlagRequired("space")
+	confluenceSyncCmd.MarkFlagRequired("url")
+
+	confluenceCmd.AddCommand(confluenceSyncCmd)
+}
+
+// spaceSync is the outcome of syncing one space
+type spaceSync struct {
+	space   string
+	since   string // Previous cursor, "" for a full sync
+	fetched int
+	removed int
+	result  *hnswindex.BatchResult
+}
+
+func runConfluenceSync(cmd *cobra.Command, args []string) error {
+	spaces, _ := cmd.Flags().GetStringSlice("space")
+	baseURL, _ := cmd.Flags().GetString("url")
+	username, _ := cmd.Flags().GetString("username")
+	apiToken, _ := cmd.Flags().GetString("token")
+	full, _ := cmd.Flags().GetBool("full")
+
+	if username == "" {
+		username = os.Getenv("CONFLUENCE_USERNAME")
+	}
+	if apiToken == "" {
+		apiToken = os.Getenv("CONFLUENCE_API_TOKEN")
+	}
+	if username == "" || apiToken == "" {
+		return fmt.Errorf("credentials required: --username and --token or CONFLUENCE_USERNAME and CONFLUENCE_API_TOKEN")
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		if verbose {
+			fmt.Printf("Creating new index: %s\n", indexName)
+		}
+		index, err = manager.CreateIndex(indexName)
+		if err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	var synced []spaceSync
+	for _, space := range spaces {
+		downloader, err := confluence.NewConfluenceDownloader(baseURL, username, apiToken, space)
+		if err != nil {
+			return fmt.Errorf("failed to create Confluence downloader: %w", err)
+		}
+		s, err := syncSpace(index, downloader, space, full)
+		if err != nil {
+			return fmt.Errorf("sync of space %s failed: %w", space, err)
+		}
+		synced = append(synced, s)
+	}
+
+	fmt.Printf("\nSync summary for index %s:\n", indexName)
+	fmt.Printf("  %-12s %-22s %8s %6s %8s %10s %8s %7s\n",
+		"SPACE", "SINCE", "FETCHED", "NEW", "UPDATED", "UNCHANGED", "REMOVED", "FAILED")
+	for _, s := range synced {
+		since := s.since
+		if since == "" {
+			since = "(full)"
+		}
+		fmt.Printf("  %-12s %-22s %8d %6d %8d %10d %8d %7d\n",
+			s.space, since, s.fetched, s.result.NewDocuments, s.result.UpdatedDocuments,
+			s.result.UnchangedDocuments, s.removed, len(s.result.FailedURIs))
+		for uri, msg := range s.result.FailedURIs {
+			fmt.Printf("    failed %s: %s\n", uri, msg)
+		}
+	}
+	return nil
+}
+
+// syncSpace fetches the pages of a space changed since its cursor, removes
+// deleted pages, and advances the cursor once everything is indexed
+func syncSpace(index *hnswindex.Index, downloader *confluence.ConfluenceDownloader, space string, full bool) (spaceSync, error) {
+	source := "confluence/" + space
+	s := spaceSync{space: space}
+
+	if !full {
+		cursor, err := index.SyncCursor(source)
+		if err != nil {
+			return s, err
+		}
+		s.since = cursor
+	}
+
+	// Pages modified while this sync runs are picked up by the next one
+	started := time.Now().UTC()
+
+	var documents []hnswindex.Document
+	if s.since == "" {
+		fmt.Printf("Running full sync of space %s...\n", space)
+		docs, err := downloader.DownloadSpace()
+		if err != nil {
+			return s, err
+		}
+		documents = docs
+	} else {
+		since, err := time.Parse(time.RFC3339, s.since)
+		if err != nil {
+			return s, fmt.Errorf("invalid cursor %q: %w", s.since, err)
+		}
+		fmt.Printf("Fetching pages of space %s changed since %s...\n", space, s.since)
+		docs, err := downloader.Changes(since)
+		if err != nil {
+			return s, err
+		}
+		documents = docs
+	}
+	s.fetched = len(documents)
+
+	removed, err := removeDeletedPages(index, downloader, space)
+	if err != nil {
+		return s, err
+	}
+	s.removed = removed
+
+	s.result = &hnswindex.BatchResult{}
+	if len(documents) > 0 {
+		result, err := index.AddDocumentBatch(context.Background(), documents, nil)
+		if err != nil {
+			return s, fmt.Errorf("failed to index pages: %w", err)
+		}
+		s.result = result
+	}
+
+	// Only advance the cursor once every change is indexed, so failed
+	// pages are fetched again next time
+	if len(s.result.FailedURIs) == 0 {
+		if err := index.SetSyncCursor(source, started.Format(time.RFC3339)); err != nil {
+			return s, fmt.Errorf("failed to save sync cursor: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// removeDeletedPages deletes indexed pages of the space that no longer
+// exist in it
+func removeDeletedPages(index *hnswindex.Index, downloader *confluence.ConfluenceDownloader, space string) (int, error) {
+	current, err := downloader.PageIDs()
+	if err != nil {
+		return 0, err
+	}
+	if len(current) == 0 {
+		// More likely a permissions problem than an emptied space
+		fmt.Printf("Space %s lists no pages, not removing anything\n", space)
+		return 0, nil
+	}
+	manifest, err := index.Manifest()
+	if err != nil {
+		return 0, err
+	}
+
+	prefix := downloader.DocumentURI("")
+	var stale []string
+	for uri := range manifest {
+		if id, ok := strings.CutPrefix(uri, prefix); ok && !current[id] {
+			stale = append(stale, uri)
+		}
+	}
+	sort.Strings(stale)
+
+	for _, uri := range stale {
+		if err := index.DeleteDocument(uri); err != nil {
+			return 0, fmt.Errorf("failed to remove %s: %w", uri, err)
+		}
+		if verbose {
+			fmt.Printf("Removed %s\n", uri)
+		}
+	}
+	return len(stale), nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <index-a> <index-b>",
+	Short: "Compare the documents of two indexes",
+	Long: `Report documents only in one index and documents whose content or
+metadata differ, to validate a migration before switching over. Use
+--map-uri to compare indexes with different URI schemes.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().String("b-data", "", "data directory of index b (default: --data)")
+	diffCmd.Flags().String("map-uri", "", "rewrite URI prefixes of index a, as old=new")
+	diffCmd.Flags().Int("show", 20, "maximum URIs to list per category")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	bData, _ := cmd.Flags().GetString("b-data")
+	mapping, _ := cmd.Flags().GetString("map-uri")
+	show, _ := cmd.Flags().GetInt("show")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	var opts hnswindex.DiffOptions
+	if bData != "" {
+		cfg := loadConfig()
+		cfg.DataPath = bData
+		target, err := hnswindex.NewIndexManager(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", bData, err)
+		}
+		defer target.Close()
+		opts.Target = target
+	}
+	if mapping != "" {
+		oldPrefix, newPrefix, ok := strings.Cut(mapping, "=")
+		if !ok {
+			return fmt.Errorf("--map-uri must have the form old=new")
+		}
+		opts.MapURI = func(uri string) string {
+			if strings.HasPrefix(uri, oldPrefix) {
+				return newPrefix + strings.TrimPrefix(uri, oldPrefix)
+			}
+			return uri
+		}
+	}
+
+	diff, err := manager.DiffIndexesWithOptions(args[0], args[1], opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Identical: %d\n", diff.Identical)
+	printURIs("Only in "+args[0], diff.OnlyInA, show)
+	printURIs("Only in "+args[1], diff.OnlyInB, show)
+	printURIs("Content changed", diff.ContentChanged, show)
+	printURIs("Metadata changed", diff.MetadataChanged, show)
+
+	if diff.Equal() {
+		fmt.Println("\nIndexes hold the same documents")
+	}
+	return nil
+}
+
+func printURIs(label string, uris []string, limit int) {
+	if len(uris) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s (%d):\n", label, len(uris))
+	for idx, uri := range uris {
+		if idx == limit {
+			fmt.Printf("  ... and %d more\n", len(uris)-limit)
+			break
+		}
+		fmt.Printf("  %s\n", uri)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var duplicatesCmd = &cobra.Command{
+	Use:   "duplicates",
+	Short: "Report duplicate and heavily overlapping documents",
+	Long: `List pairs of documents in an index whose chunks closely match, such as
+copy-pasted pages or forks of the same runbook.`,
+	RunE: runDuplicates,
+}
+
+func init() {
+	duplicatesCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	duplicatesCmd.Flags().Float64("threshold", hnswindex.DefaultDuplicateThreshold, "similarity at which two chunks match")
+
+	rootCmd.AddCommand(duplicatesCmd)
+}
+
+func runDuplicates(cmd *cobra.Command, args []string) error {
+	threshold, _ := cmd.Flags().GetFloat64("threshold")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	duplicates, err := index.FindDuplicates(threshold)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicates: %w", err)
+	}
+	if len(duplicates) == 0 {
+		fmt.Println("No duplicates found")
+		return nil
+	}
+
+	for _, d := range duplicates {
+		fmt.Printf("%3.0f%% overlap, similarity %.3f, %d shared chunks\n", d.Overlap*100, d.Similarity, d.SharedChunks)
+		fmt.Printf("   %s (%d chunks)\n", d.A, d.ChunksA)
+		fmt.Printf("   %s (%d chunks)\n", d.B, d.ChunksB)
+	}
+	fmt.Printf("\n%d pairs\n", len(duplicates))
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/pkg/gdrive"
+	"github.com/spf13/cobra"
+)
+
+var gdriveCmd = &cobra.Command{
+	Use:   "gdrive",
+	Short: "Index Google Docs and Sheets from Google Drive",
+	Long: `Index Google Docs and Sheets from Google Drive as text.
+
+The first run downloads every document (optionally limited to one folder) and
+saves a change token to the state file. Later runs fetch only the documents
+changed since then and remove deleted ones from the index.`,
+	RunE: runGDrive,
+}
+
+func init() {
+	gdriveCmd.Flags().StringVarP(&indexName, "index", "i", "gdrive", "index name")
+	gdriveCmd.Flags().String("folder", "", "Drive folder ID to index (default: all files)")
+	gdriveCmd.Flags().String("token", "", "OAuth2 access token (default: $GOOGLE_ACCESS_TOKEN)")
+	gdriveCmd.Flags().String("state", "./gdrive.token", "file storing the change token between runs")
+	gdriveCmd.Flags().Bool("full", false, "ignore the saved change token and resync everything")
+
+	rootCmd.AddCommand(gdriveCmd)
+}
+
+func runGDrive(cmd *cobra.Command, args []string) error {
+	folder, _ := cmd.Flags().GetString("folder")
+	token, _ := cmd.Flags().GetString("token")
+	statePath, _ := cmd.Flags().GetString("state")
+	full, _ := cmd.Flags().GetBool("full")
+
+	if token == "" {
+		token = os.Getenv("GOOGLE_ACCESS_TOKEN")
+	}
+	if token == "" {
+		return fmt.Errorf("an access token is required (--token or GOOGLE_ACCESS_TOKEN)")
+	}
+
+	client, err := gdrive.NewClient("", gdrive.StaticToken(token))
+	if err != nil {
+		return err
+	}
+
+	var pageToken string
+	if !full {
+		if data, err := os.ReadFile(statePath); err == nil {
+			pageToken = strings.TrimSpace(string(data))
+		}
+	}
+
+	ctx := context.Background()
+	var result *gdrive.SyncResult
+	if pageToken == "" {
+		fmt.Println("Running full sync of Google Drive...")
+		result, err = client.FullSync(ctx, folder)
+	} else {
+		fmt.Println("Fetching Google Drive changes...")
+		result, err = client.Changes(ctx, pageToken, folder)
+	}
+	if err != nil {
+		return err
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		if verbose {
+			fmt.Printf("Creating new index: %s\n", indexName)
+		}
+		index, err = manager.CreateIndex(indexName)
+		if err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	removed := 0
+	for _, uri := range result.Removed {
+		if _, err := index.GetDocument(uri); err != nil {
+			continue // Never indexed
+		}
+		if err := index.DeleteDocument(uri); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", uri, err)
+		}
+		removed++
+	}
+	if removed > 0 {
+		fmt.Printf("Removed %d deleted documents\n", removed)
+	}
+
+	if len(result.Documents) > 0 {
+		if err := indexDocuments(index, result.Documents); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println("No changed documents")
+	}
+
+	// Only advance the token once the changes are indexed
+	if err := os.WriteFile(statePath, []byte(result.NextToken+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to save change token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep [pattern]",
+	Short: "Find chunks containing an exact string or regular expression",
+	Long: `Find chunks containing an exact string, such as an error code or config
+key, or a regular expression with --regexp. Complements semantic search for
+lookups embeddings handle poorly.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGrep,
+}
+
+func init() {
+	grepCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	grepCmd.Flags().BoolP("regexp", "E", false, "treat the pattern as a regular expression")
+	grepCmd.Flags().Bool("ignore-case", false, "match case-insensitively")
+	grepCmd.Flags().IntP("limit", "l", hnswindex.DefaultGrepLimit, "maximum number of matches")
+
+	rootCmd.AddCommand(grepCmd)
+}
+
+func runGrep(cmd *cobra.Command, args []string) error {
+	useRegexp, _ := cmd.Flags().GetBool("regexp")
+	ignoreCase, _ := cmd.Flags().GetBool("ignore-case")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	matches, err := index.Grep(args[0], hnswindex.GrepOptions{
+		Regexp:     useRegexp,
+		IgnoreCase: ignoreCase,
+		Limit:      limit,
+	})
+	if err != nil {
+		return fmt.Errorf("grep failed: %w", err)
+	}
+
+	for _, m := range matches {
+		fmt.Printf("%s (%s)\n    %s\n", m.Title, m.URI, m.Line)
+	}
+	fmt.Printf("\n%d matches\n", len(matches))
+	return nil
+}
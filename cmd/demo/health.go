@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var healthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Report dead links and stale documents",
+	Long: `Check the documents of an index for sources that no longer exist (web
+pages returning 404/410, deleted files) and content not updated within
+--max-age, optionally pruning them from the index.`,
+	RunE: runHealth,
+}
+
+func init() {
+	healthCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	healthCmd.Flags().Bool("links", true, "check that document sources still exist")
+	healthCmd.Flags().Duration("max-age", 0, "report documents not modified within this duration (e.g. 8760h)")
+	healthCmd.Flags().Bool("prune-dead", false, "delete documents whose source is gone")
+	healthCmd.Flags().Bool("prune-stale", false, "delete stale documents")
+	healthCmd.Flags().Int("concurrency", 8, "parallel link checks")
+
+	rootCmd.AddCommand(healthCmd)
+}
+
+func runHealth(cmd *cobra.Command, args []string) error {
+	links, _ := cmd.Flags().GetBool("links")
+	maxAge, _ := cmd.Flags().GetDuration("max-age")
+	pruneDead, _ := cmd.Flags().GetBool("prune-dead")
+	pruneStale, _ := cmd.Flags().GetBool("prune-stale")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	report, err := index.HealthCheck(context.Background(), hnswindex.HealthOptions{
+		CheckLinks:  links,
+		MaxAge:      maxAge,
+		PruneDead:   pruneDead,
+		PruneStale:  pruneStale,
+		Concurrency: concurrency,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Checked %d documents in '%s'\n", report.Checked, indexName)
+	printHealth("Dead", report.Dead)
+	printHealth("Unreachable", report.Unreachable)
+	printHealth("Stale", report.Stale)
+	if maxAge > 0 && report.NoDate > 0 {
+		fmt.Printf("\n%d documents have no last-modified date\n", report.NoDate)
+	}
+	if len(report.Pruned) > 0 {
+		fmt.Printf("\nPruned %d documents\n", len(report.Pruned))
+	}
+	return nil
+}
+
+func printHealth(label string, list []hnswindex.DocumentHealth) {
+	if len(list) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s (%d):\n", label, len(list))
+	for _, h := range list {
+		fmt.Printf("  %s\n", h.Title)
+		fmt.Printf("    %s\n", h.URI)
+		if h.Error != "" {
+			fmt.Printf("    %s: %s\n", h.URL, h.Error)
+		}
+		if !h.LastModified.IsZero() {
+			fmt.Printf("    Last modified: %s (%d days ago)\n",
+				h.LastModified.Format("2006-01-02"), int(time.Since(h.LastModified).Hours()/24))
+		}
+	}
+}
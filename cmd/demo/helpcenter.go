@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/pkg/helpcenter"
+	"github.com/spf13/cobra"
+)
+
+var helpcenterCmd = &cobra.Command{
+	Use:   "helpcenter",
+	Short: "Index published help-center articles from Zendesk or Intercom",
+	Long: `Index the published articles of a Zendesk Guide or Intercom help center.
+Documents carry section, category, and locale metadata.`,
+	RunE: runHelpCenter,
+}
+
+func init() {
+	helpcenterCmd.Flags().StringVarP(&indexName, "index", "i", "helpcenter", "index name")
+	helpcenterCmd.Flags().String("provider", "zendesk", "help center provider (zendesk or intercom)")
+	helpcenterCmd.Flags().String("url", "", "help center URL (Zendesk: https://company.zendesk.com)")
+	helpcenterCmd.Flags().String("email", "", "Zendesk agent email")
+	helpcenterCmd.Flags().String("token", "", "API token (default: $HELPCENTER_TOKEN)")
+	helpcenterCmd.Flags().StringSlice("locale", nil, "locales to index (default: the help center default)")
+
+	rootCmd.AddCommand(helpcenterCmd)
+}
+
+func runHelpCenter(cmd *cobra.Command, args []string) error {
+	provider, _ := cmd.Flags().GetString("provider")
+	baseURL, _ := cmd.Flags().GetString("url")
+	email, _ := cmd.Flags().GetString("email")
+	token, _ := cmd.Flags().GetString("token")
+	locales, _ := cmd.Flags().GetStringSlice("locale")
+
+	if token == "" {
+		token = os.Getenv("HELPCENTER_TOKEN")
+	}
+
+	var source helpcenter.Source
+	var err error
+	switch strings.ToLower(provider) {
+	case "zendesk":
+		source, err = helpcenter.NewZendesk(baseURL, email, token)
+	case "intercom":
+		source, err = helpcenter.NewIntercom(baseURL, token)
+	default:
+		return fmt.Errorf("unknown provider %q (use zendesk or intercom)", provider)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Downloading %s articles...\n", source.Name())
+	docs, err := source.Articles(context.Background(), locales)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		fmt.Println("No published articles found")
+		return nil
+	}
+	fmt.Printf("Downloaded %d articles\n", len(docs))
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		if verbose {
+			fmt.Printf("Creating new index: %s\n", indexName)
+		}
+		index, err = manager.CreateIndex(indexName)
+		if err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	return indexDocuments(index, docs)
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/riclib/hnswindex"
 	"github.com/riclib/hnswindex/pkg/confluence"
@@ -42,6 +43,9 @@ var indexCmd = &cobra.Command{
 var searchCmd = &cobra.Command{
 	Use:   "search [query]",
 	Short: "Search indexed documents",
+	Long: `Search indexed documents. The query may include metadata filters:
+
+  demo search 'kind:runbook space:ENG -status:archived "database failover"'`,
 	Args:  cobra.MinimumNArgs(1),
 	RunE:  runSearch,
 }
@@ -58,6 +62,13 @@ var statsCmd = &cobra.Command{
 	RunE:  runStats,
 }
 
+var queryStatsCmd = &cobra.Command{
+	Use:   "querystats",
+	Short: "Show query log analytics for an index",
+	Long:  `Aggregate the query log of an index. Queries are only logged when query_log is enabled.`,
+	RunE:  runQueryStats,
+}
+
 var confluenceCmd = &cobra.Command{
 	Use:   "confluence",
 	Short: "Index Confluence space pages",
@@ -78,14 +89,34 @@ func init() {
 	// Index command flags
 	indexCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
 	indexCmd.Flags().StringP("dir", "d", "./", "directory containing markdown files")
+	indexCmd.Flags().String("chunk-titles", "", "title chunks by \"headings\" or with an Ollama model (\"ollama:llama3.2\")")
 	indexCmd.MarkFlagRequired("dir")
 
 	// Search command flags
 	searchCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
 	searchCmd.Flags().IntP("limit", "l", 5, "number of results")
+	searchCmd.Flags().Bool("explain", false, "show scoring details and timings for each result")
+	searchCmd.Flags().String("group-by", "", "return the best results per value of this metadata field")
+	searchCmd.Flags().Int("per-group", 1, "results per group with --group-by")
+	searchCmd.Flags().Bool("by-document", false, "one result per document, listing the chunks it matched with")
+	searchCmd.Flags().String("not", "", "steer results away from this text")
+	searchCmd.Flags().Float64("title-boost", 0, "raise results whose chunk title matches the query by up to this much")
+	searchCmd.Flags().String("model", "", "embed the query with this model instead of the index model")
+	searchCmd.Flags().Bool("late", false, "rescore results by MaxSim over sub-chunk vectors")
+	searchCmd.Flags().Int("first-chunks", 0, "only search the first N chunks of each document (0 = all)")
+	searchCmd.Flags().Bool("no-boosts", false, "ignore the index's boost rules")
+	searchCmd.Flags().Bool("suppressed", false, "include suppressed documents")
+	searchCmd.Flags().Bool("snippets", false, "show the best-matching part of each chunk with query words in bold")
+	searchCmd.Flags().Int("ef", 0, "graph candidates kept while searching (0 = the index's Ef)")
+	searchCmd.Flags().Int("exact-rerank", 0, "rescore the top N graph hits by exact similarity (0 = off)")
 
 	// Stats command flags
 	statsCmd.Flags().StringVarP(&indexName, "index", "i", "", "index name (empty for all)")
+	statsCmd.Flags().Bool("history", false, "also show the recorded stats history")
+	statsCmd.Flags().Bool("distribution", false, "also show chunk and embedding distributions (scans every chunk)")
+
+	// Query stats command flags
+	queryStatsCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
 
 	// Confluence command flags
 	confluenceCmd.Flags().StringP("space", "s", "", "Confluence space key (required)")
@@ -102,10 +133,12 @@ func init() {
 	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(queryStatsCmd)
 	rootCmd.AddCommand(confluenceCmd)
 
 	// Bind flags to viper
 	viper.BindPFlag("data_path", rootCmd.PersistentFlags().Lookup("data"))
+	viper.BindPFlag("log_level", rootCmd.PersistentFlags().Lookup("log-level"))
 }
 
 func initConfig() {
@@ -126,8 +159,31 @@ func initConfig() {
 	viper.SetDefault("embed_model", "nomic-embed-text")
 	viper.SetDefault("chunk_size", 512)
 	viper.SetDefault("chunk_overlap", 50)
+	viper.SetDefault("content_defined_chunks", false)
 	viper.SetDefault("max_workers", 8)
+	viper.SetDefault("chunk_workers", 4)
+	viper.SetDefault("pipeline_queue", 64)
 	viper.SetDefault("auto_save", true)
+	viper.SetDefault("query_log", false)
+	viper.SetDefault("embedding_cache", true)
+	viper.SetDefault("embedding_cache_entries", hnswindex.DefaultEmbeddingCacheEntries)
+	viper.SetDefault("adaptive_embedding", false)
+	viper.SetDefault("late_interaction", false)
+	viper.SetDefault("normalize_embeddings", false)
+	viper.SetDefault("blob_threshold", 32*1024)
+	viper.SetDefault("max_batch_documents", 0)
+	viper.SetDefault("max_chunks_per_document", 0)
+	viper.SetDefault("max_content_bytes", 0)
+	viper.SetDefault("max_metadata_bytes", 0)
+	viper.SetDefault("max_metadata_depth", 0)
+	viper.SetDefault("max_metadata_value_bytes", 0)
+	viper.SetDefault("metadata_overflow", "reject")
+	viper.SetDefault("compression", "none")
+	viper.SetDefault("quantization", "none")
+	viper.SetDefault("storage_layout", "shared")
+	viper.SetDefault("snapshot_on_save", false)
+	viper.SetDefault("snapshot_compression", "zstd")
+	viper.SetDefault("snapshot_part_size", hnswindex.DefaultSnapshotPartSize)
 
 	if err := viper.ReadInConfig(); err == nil && verbose {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
@@ -137,18 +193,105 @@ func initConfig() {
 	configureLogging()
 }
 
+// loadConfig builds an index manager configuration from viper settings
+func loadConfig() *hnswindex.Config {
+	config := hnswindex.NewConfig()
+	config.DataPath = viper.GetString("data_path")
+	config.OllamaURL = viper.GetString("ollama_url")
+	config.EmbedModel = viper.GetString("embed_model")
+	config.EmbedProvider = viper.GetString("embed_provider")
+	config.EmbedURL = viper.GetString("embed_url")
+	config.EmbedAPIKey = viper.GetString("embed_api_key")
+	config.EmbedBatchSize = viper.GetInt("embed_batch_size")
+	config.EmbedModelPath = viper.GetString("embed_model_path")
+	config.EmbedTruncate = embedTruncate()
+	config.EmbedKeepAlive = viper.GetString("embed_keep_alive")
+	config.EmbedModelOptions = viper.GetStringMap("embed_model_options")
+	config.EmbedDimensions = viper.GetInt("embed_dimensions")
+	config.ONNXRuntimePath = viper.GetString("onnxruntime_path")
+	config.ChunkSize = viper.GetInt("chunk_size")
+	config.ChunkOverlap = viper.GetInt("chunk_overlap")
+	config.ContentDefinedChunks = viper.GetBool("content_defined_chunks")
+	config.MaxWorkers = viper.GetInt("max_workers")
+	config.ChunkWorkers = viper.GetInt("chunk_workers")
+	config.PipelineQueue = viper.GetInt("pipeline_queue")
+	config.AutoSave = viper.GetBool("auto_save")
+	config.QueryLog = viper.GetBool("query_log")
+	config.EmbeddingCache = viper.GetBool("embedding_cache")
+	config.EmbeddingCacheEntries = viper.GetInt("embedding_cache_entries")
+	config.AdaptiveEmbedding = viper.GetBool("adaptive_embedding")
+	config.LateInteraction = viper.GetBool("late_interaction")
+	config.LateInteractionWords = viper.GetInt("late_interaction_words")
+	config.NormalizeEmbeddings = viper.GetBool("normalize_embeddings")
+	config.BlobThreshold = viper.GetInt("blob_threshold")
+	config.MaxBatchDocuments = viper.GetInt("max_batch_documents")
+	config.MaxChunksPerDocument = viper.GetInt("max_chunks_per_document")
+	config.MaxContentBytes = viper.GetInt("max_content_bytes")
+	config.MaxMetadataBytes = viper.GetInt("max_metadata_bytes")
+	config.MaxMetadataDepth = viper.GetInt("max_metadata_depth")
+	config.MaxMetadataValueBytes = viper.GetInt("max_metadata_value_bytes")
+	config.MetadataOverflow = viper.GetString("metadata_overflow")
+	config.Compression = viper.GetString("compression")
+	config.Quantization = viper.GetString("quantization")
+	config.StorageLayout = viper.GetString("storage_layout")
+	config.SnapshotCompression = viper.GetString("snapshot_compression")
+	config.SnapshotPartSize = viper.GetInt64("snapshot_part_size")
+
+	if rawURL := viper.GetString("snapshot_url"); rawURL != "" {
+		store, key, err := snapshotStore(rawURL)
+		if err != nil {
+			log.Fatalf("invalid snapshot_url: %v", err)
+		}
+		config.SnapshotStore = store
+		config.SnapshotKey = key
+		config.SnapshotOnSave = viper.GetBool("snapshot_on_save")
+	}
+	return config
+}
+
 func runIndex(cmd *cobra.Command, args []string) error {
 	dir, _ := cmd.Flags().GetString("dir")
+	chunkTitles, _ := cmd.Flags().GetString("chunk-titles")
 	
 	// Create index manager
 	config := hnswindex.NewConfig()
 	config.DataPath = viper.GetString("data_path")
 	config.OllamaURL = viper.GetString("ollama_url")
 	config.EmbedModel = viper.GetString("embed_model")
+	config.EmbedProvider = viper.GetString("embed_provider")
+	config.EmbedURL = viper.GetString("embed_url")
+	config.EmbedAPIKey = viper.GetString("embed_api_key")
+	config.EmbedBatchSize = viper.GetInt("embed_batch_size")
+	config.EmbedModelPath = viper.GetString("embed_model_path")
+	config.EmbedTruncate = embedTruncate()
+	config.EmbedKeepAlive = viper.GetString("embed_keep_alive")
+	config.EmbedModelOptions = viper.GetStringMap("embed_model_options")
+	config.EmbedDimensions = viper.GetInt("embed_dimensions")
+	config.ONNXRuntimePath = viper.GetString("onnxruntime_path")
 	config.ChunkSize = viper.GetInt("chunk_size")
 	config.ChunkOverlap = viper.GetInt("chunk_overlap")
+	config.ContentDefinedChunks = viper.GetBool("content_defined_chunks")
 	config.MaxWorkers = viper.GetInt("max_workers")
+	config.ChunkWorkers = viper.GetInt("chunk_workers")
+	config.PipelineQueue = viper.GetInt("pipeline_queue")
 	config.AutoSave = viper.GetBool("auto_save")
+	config.EmbeddingCache = viper.GetBool("embedding_cache")
+	config.EmbeddingCacheEntries = viper.GetInt("embedding_cache_entries")
+	config.AdaptiveEmbedding = viper.GetBool("adaptive_embedding")
+	config.LateInteraction = viper.GetBool("late_interaction")
+	config.LateInteractionWords = viper.GetInt("late_interaction_words")
+	config.NormalizeEmbeddings = viper.GetBool("normalize_embeddings")
+	config.BlobThreshold = viper.GetInt("blob_threshold")
+	config.MaxBatchDocuments = viper.GetInt("max_batch_documents")
+	config.MaxChunksPerDocument = viper.GetInt("max_chunks_per_document")
+	config.MaxContentBytes = viper.GetInt("max_content_bytes")
+	config.MaxMetadataBytes = viper.GetInt("max_metadata_bytes")
+	config.MaxMetadataDepth = viper.GetInt("max_metadata_depth")
+	config.MaxMetadataValueBytes = viper.GetInt("max_metadata_value_bytes")
+	config.MetadataOverflow = viper.GetString("metadata_overflow")
+	config.Compression = viper.GetString("compression")
+	config.Quantization = viper.GetString("quantization")
+	config.StorageLayout = viper.GetString("storage_layout")
 
 	manager, err := hnswindex.NewIndexManager(config)
 	if err != nil {
@@ -156,6 +299,20 @@ func runIndex(cmd *cobra.Command, args []string) error {
 	}
 	defer manager.Close()
 
+	switch {
+	case chunkTitles == "":
+	case chunkTitles == "headings":
+		manager.SetChunkTitler(hnswindex.NewHeadingTitler())
+	case strings.HasPrefix(chunkTitles, "ollama:"):
+		titler, err := hnswindex.NewOllamaChunkTitler(config.OllamaURL, strings.TrimPrefix(chunkTitles, "ollama:"))
+		if err != nil {
+			return err
+		}
+		manager.SetChunkTitler(titler)
+	default:
+		return fmt.Errorf("unknown --chunk-titles %q (use \"headings\" or \"ollama:<model>\")", chunkTitles)
+	}
+
 	// Get or create index
 	index, err := manager.GetIndex(indexName)
 	if err != nil {
@@ -180,31 +337,30 @@ func runIndex(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 
-		// Read file content
-		content, err := os.ReadFile(path)
+		info, err := d.Info()
 		if err != nil {
 			if verbose {
-				fmt.Printf("Warning: failed to read %s: %v\n", path, err)
+				fmt.Printf("Warning: failed to stat %s: %v\n", path, err)
 			}
 			return nil
 		}
 
-		// Create document
+		// Content is read lazily, only for new or changed files
 		relPath, _ := filepath.Rel(dir, path)
 		doc := hnswindex.Document{
-			URI:     fmt.Sprintf("file://%s", path),
-			Title:   filepath.Base(path),
-			Content: string(content),
+			URI:    fmt.Sprintf("file://%s", path),
+			Title:  filepath.Base(path),
+			Source: hnswindex.FileContent(path),
 			Metadata: map[string]interface{}{
 				"path":     path,
 				"rel_path": relPath,
-				"size":     len(content),
+				"size":     int(info.Size()),
 			},
 		}
 		documents = append(documents, doc)
 
 		if verbose {
-			fmt.Printf("Found: %s (%d bytes)\n", relPath, len(content))
+			fmt.Printf("Found: %s (%d bytes)\n", relPath, info.Size())
 		}
 		return nil
 	})
@@ -217,7 +373,11 @@ func runIndex(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Index documents
+	return indexDocuments(index, documents)
+}
+
+// indexDocuments adds documents to an index, printing progress and results
+func indexDocuments(index *hnswindex.Index, documents []hnswindex.Document) error {
 	fmt.Printf("Indexing %d documents...\n", len(documents))
 	
 	// Create progress channel
@@ -254,6 +414,10 @@ func runIndex(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Updated documents: %d\n", result.UpdatedDocuments)
 	fmt.Printf("  Unchanged documents: %d\n", result.UnchangedDocuments)
 	fmt.Printf("  Processed chunks: %d\n", result.ProcessedChunks)
+	fmt.Printf("  Tokens embedded: %d\n", result.TokensEmbedded)
+	fmt.Printf("  Duration: %v (embedding %v, storage %v), %.1f chunks/s\n",
+		result.DurationTotal.Round(time.Millisecond), result.DurationEmbedding.Round(time.Millisecond),
+		result.DurationStorage.Round(time.Millisecond), result.ChunksPerSecond)
 
 	if len(result.FailedURIs) > 0 {
 		fmt.Printf("\n  Failed documents:\n")
@@ -268,12 +432,37 @@ func runIndex(cmd *cobra.Command, args []string) error {
 func runSearch(cmd *cobra.Command, args []string) error {
 	query := strings.Join(args, " ")
 	limit, _ := cmd.Flags().GetInt("limit")
+	explain, _ := cmd.Flags().GetBool("explain")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	perGroup, _ := cmd.Flags().GetInt("per-group")
+	byDocument, _ := cmd.Flags().GetBool("by-document")
+	negative, _ := cmd.Flags().GetString("not")
+	titleBoost, _ := cmd.Flags().GetFloat64("title-boost")
+	model, _ := cmd.Flags().GetString("model")
+	late, _ := cmd.Flags().GetBool("late")
+	firstChunks, _ := cmd.Flags().GetInt("first-chunks")
+	noBoosts, _ := cmd.Flags().GetBool("no-boosts")
+	includeSuppressed, _ := cmd.Flags().GetBool("suppressed")
+	snippets, _ := cmd.Flags().GetBool("snippets")
+	ef, _ := cmd.Flags().GetInt("ef")
+	exactRerank, _ := cmd.Flags().GetInt("exact-rerank")
 
 	// Create index manager
 	config := hnswindex.NewConfig()
 	config.DataPath = viper.GetString("data_path")
 	config.OllamaURL = viper.GetString("ollama_url")
 	config.EmbedModel = viper.GetString("embed_model")
+	config.EmbedProvider = viper.GetString("embed_provider")
+	config.EmbedURL = viper.GetString("embed_url")
+	config.EmbedAPIKey = viper.GetString("embed_api_key")
+	config.EmbedBatchSize = viper.GetInt("embed_batch_size")
+	config.EmbedModelPath = viper.GetString("embed_model_path")
+	config.EmbedTruncate = embedTruncate()
+	config.EmbedKeepAlive = viper.GetString("embed_keep_alive")
+	config.EmbedModelOptions = viper.GetStringMap("embed_model_options")
+	config.EmbedDimensions = viper.GetInt("embed_dimensions")
+	config.ONNXRuntimePath = viper.GetString("onnxruntime_path")
+	config.QueryLog = viper.GetBool("query_log")
 
 	manager, err := hnswindex.NewIndexManager(config)
 	if err != nil {
@@ -289,7 +478,27 @@ func runSearch(cmd *cobra.Command, args []string) error {
 
 	// Search
 	fmt.Printf("Searching for: %s\n\n", query)
-	results, err := index.Search(query, limit)
+	results, err := index.Query(query, limit, hnswindex.SearchOptions{
+		Explain:  explain,
+		GroupBy:  groupBy,
+		PerGroup: perGroup,
+
+		GroupByDocument: byDocument,
+
+		NegativeQuery: negative,
+		TitleBoost:    titleBoost,
+		Model:         model,
+
+		LateInteraction: late,
+		ChunkPositions:  hnswindex.ChunkRange{To: firstChunks},
+
+		IgnoreBoostRules:  noBoosts,
+		IncludeSuppressed: includeSuppressed,
+		Snippets:          snippets,
+
+		EfSearch:    ef,
+		ExactRerank: exactRerank,
+	})
 	if err != nil {
 		return fmt.Errorf("search failed: %w", err)
 	}
@@ -302,21 +511,81 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	// Display results
 	for i, result := range results {
 		fmt.Printf("%d. %s (Score: %.3f)\n", i+1, result.Document.Title, result.Score)
+		if result.Pinned {
+			fmt.Printf("   Pinned\n")
+		}
+		if result.ChunkTitle != "" {
+			fmt.Printf("   Section: %s\n", result.ChunkTitle)
+		}
+		if groupBy != "" {
+			fmt.Printf("   %s: %s\n", groupBy, result.Group)
+		}
+		if len(result.Matches) > 1 {
+			fmt.Printf("   Matches: %d chunks\n", len(result.Matches))
+			for _, match := range result.Matches[1:] {
+				label := match.ChunkTitle
+				if label == "" {
+					label = hnswindex.TruncateText(strings.Join(strings.Fields(match.ChunkText), " "), 60)
+				}
+				fmt.Printf("     %.3f %s\n", match.Score, label)
+			}
+		}
 		if path, ok := result.Document.Metadata["path"].(string); ok {
 			fmt.Printf("   Path: %s\n", path)
 		}
+		if result.TimeRange != nil {
+			fmt.Printf("   At: %s (%.0fs-%.0fs)\n", result.DeepLink(), result.TimeRange.Start, result.TimeRange.End)
+		}
 		
 		// Show chunk preview
-		preview := result.ChunkText
-		if len(preview) > 200 {
-			preview = preview[:200] + "..."
+		if snippets {
+			fmt.Printf("   Snippet: %s\n", markSpans(result.Snippet, result.SnippetHighlights))
+		} else {
+			fmt.Printf("   Preview: %s\n", result.Preview(200))
 		}
-		fmt.Printf("   Preview: %s\n\n", preview)
+		if result.Explain != nil {
+			printExplain(result.Explain)
+		}
+		fmt.Println()
+	}
+
+	if explain && len(results) > 0 && results[0].Explain != nil {
+		t := results[0].Explain.Timing
+		fmt.Printf("Timing: embed %s, graph %s, hydrate %s, total %s\n",
+			t.Embed, t.Graph, t.Hydrate, t.Total)
 	}
 
 	return nil
 }
 
+// markSpans shows the spans of text in bold on terminals
+func markSpans(text string, spans []hnswindex.Span) string {
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		b.WriteString(text[last:span.Start])
+		b.WriteString("\033[1m" + text[span.Start:span.End] + "\033[0m")
+		last = span.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func printExplain(e *hnswindex.SearchExplain) {
+	fmt.Printf("   Explain: rank=%d hnsw_id=%d distance=%.4f similarity=%.4f normalized=%.4f final=%.4f\n",
+		e.Rank, e.HNSWId, e.Distance, e.Similarity, e.NormalizedScore, e.Score)
+	for _, f := range e.Filters {
+		status := "pass"
+		if !f.Passed {
+			status = "fail"
+		}
+		fmt.Printf("     filter %s: %s %s\n", f.Filter, status, f.Reason)
+	}
+	for _, a := range e.Adjustments {
+		fmt.Printf("     %s: %+.4f %s\n", a.Stage, a.Delta, a.Reason)
+	}
+}
+
 func runList(cmd *cobra.Command, args []string) error {
 	config := hnswindex.NewConfig()
 	config.DataPath = viper.GetString("data_path")
@@ -327,7 +596,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 	defer manager.Close()
 
-	indexes, err := manager.ListIndexes()
+	indexes, err := manager.ListIndexInfo()
 	if err != nil {
 		return fmt.Errorf("failed to list indexes: %w", err)
 	}
@@ -338,14 +607,24 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Println("Available indexes:")
-	for _, name := range indexes {
-		fmt.Printf("  - %s\n", name)
+	for _, info := range indexes {
+		fmt.Printf("  - %s (%d documents, %d chunks, %.2f MB, %s)",
+			info.Name, info.DocumentCount, info.ChunkCount, float64(info.SizeBytes)/(1024*1024), info.Model)
+		if info.Description != "" {
+			fmt.Printf(": %s", info.Description)
+		}
+		fmt.Println()
+		if len(info.Labels) > 0 {
+			fmt.Printf("      labels: %s\n", formatLabels(info.Labels))
+		}
 	}
 
 	return nil
 }
 
 func runStats(cmd *cobra.Command, args []string) error {
+	history, _ := cmd.Flags().GetBool("history")
+	distribution, _ := cmd.Flags().GetBool("distribution")
 	config := hnswindex.NewConfig()
 	config.DataPath = viper.GetString("data_path")
 
@@ -363,18 +642,18 @@ func runStats(cmd *cobra.Command, args []string) error {
 		}
 
 		for _, name := range indexes {
-			showIndexStats(manager, name)
+			showIndexStats(manager, name, history, distribution)
 			fmt.Println()
 		}
 	} else {
 		// Show stats for specific index
-		return showIndexStats(manager, indexName)
+		return showIndexStats(manager, indexName, history, distribution)
 	}
 
 	return nil
 }
 
-func showIndexStats(manager *hnswindex.IndexManager, name string) error {
+func showIndexStats(manager *hnswindex.IndexManager, name string, history, distribution bool) error {
 	index, err := manager.GetIndex(name)
 	if err != nil {
 		return fmt.Errorf("index '%s' not found: %w", name, err)
@@ -384,14 +663,134 @@ func showIndexStats(manager *hnswindex.IndexManager, name string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get stats: %w", err)
 	}
+	var dist *hnswindex.StatsDistribution
+	if distribution {
+		if dist, err = index.Distribution(); err != nil {
+			return fmt.Errorf("failed to get distribution: %w", err)
+		}
+	}
 
 	fmt.Printf("Index: %s\n", stats.Name)
+	if stats.Description != "" {
+		fmt.Printf("  Description: %s\n", stats.Description)
+	}
+	if len(stats.Labels) > 0 {
+		fmt.Printf("  Labels: %s\n", formatLabels(stats.Labels))
+	}
 	fmt.Printf("  Documents: %d\n", stats.DocumentCount)
 	fmt.Printf("  Chunks: %d\n", stats.ChunkCount)
-	fmt.Printf("  Last updated: %s\n", stats.LastUpdated)
+	for _, t := range []struct {
+		label string
+		time  time.Time
+	}{
+		{"Created", stats.CreatedAt},
+		{"Last updated", stats.LastUpdated},
+		{"Last saved", stats.LastSavedAt},
+		{"Last synced", stats.LastSyncAt},
+		{"Sealed", sealedAt(stats.Sealed)},
+	} {
+		if !t.time.IsZero() {
+			fmt.Printf("  %s: %s\n", t.label, t.time.Local().Format(time.RFC3339))
+		}
+	}
 	if stats.SizeBytes > 0 {
 		fmt.Printf("  Size: %.2f MB\n", float64(stats.SizeBytes)/(1024*1024))
 	}
+	if d := dist; d != nil {
+		fmt.Printf("  Chunks per document: min %.0f, p50 %.0f, p90 %.0f, p99 %.0f, max %.0f\n",
+			d.ChunksPerDocument.Min, d.ChunksPerDocument.P50, d.ChunksPerDocument.P90,
+			d.ChunksPerDocument.P99, d.ChunksPerDocument.Max)
+		fmt.Printf("  Chunk tokens: mean %.1f, p50 %.0f, p90 %.0f, max %.0f\n",
+			d.ChunkTokens.Mean, d.ChunkTokens.P50, d.ChunkTokens.P90, d.ChunkTokens.Max)
+		fmt.Printf("  Embedding norms: mean %.3f, stddev %.3f, min %.3f, max %.3f\n",
+			d.EmbeddingNorms.Mean, d.EmbeddingNorms.StdDev, d.EmbeddingNorms.Min, d.EmbeddingNorms.Max)
+		if d.EmptyDocuments > 0 {
+			fmt.Printf("  Documents without chunks: %d\n", d.EmptyDocuments)
+		}
+		if d.ZeroNormEmbeddings > 0 {
+			fmt.Printf("  Zero-norm embeddings: %d\n", d.ZeroNormEmbeddings)
+		}
+	}
+	if cfg, err := index.Config(); err == nil && cfg != nil {
+		fmt.Printf("  Chunking: %d tokens, %d overlap (%s)\n",
+			cfg.Chunker.ChunkSize, cfg.Chunker.ChunkOverlap, cfg.Chunker.Tokenizer)
+		fmt.Printf("  Embeddings: %s/%s, %d dimensions\n",
+			cfg.Embedder.Provider, cfg.Embedder.Model, cfg.Embedder.Dimension)
+		fmt.Printf("  HNSW: M=%d, ef=%d, %s distance\n",
+			cfg.HNSW.M, cfg.HNSW.Ef, cfg.HNSW.DistanceType)
+	}
+
+	if history {
+		snapshots, err := index.StatsHistory()
+		if err != nil {
+			return fmt.Errorf("failed to get stats history: %w", err)
+		}
+		fmt.Printf("  History (%d snapshots):\n", len(snapshots))
+		fmt.Printf("    %-20s %9s %9s %6s %8s %8s %9s %10s\n",
+			"TIME", "DOCS", "CHUNKS", "NEW", "UPDATED", "DELETED", "EMBEDDED", "BATCH")
+		for _, snap := range snapshots {
+			batch := "-"
+			if snap.BatchDuration > 0 {
+				batch = snap.BatchDuration.Round(time.Millisecond).String()
+			}
+			fmt.Printf("    %-20s %9d %9d %6d %8d %8d %9d %10s\n",
+				snap.Time.Local().Format("2006-01-02 15:04:05"), snap.Documents, snap.Chunks,
+				snap.NewDocuments, snap.UpdatedDocuments, snap.DeletedDocuments,
+				snap.ProcessedChunks, batch)
+		}
+	}
+
+	return nil
+}
+
+func runQueryStats(cmd *cobra.Command, args []string) error {
+	config := hnswindex.NewConfig()
+	config.DataPath = viper.GetString("data_path")
+
+	manager, err := hnswindex.NewIndexManager(config)
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found: %w", indexName, err)
+	}
+
+	stats, err := index.QueryStats()
+	if err != nil {
+		return fmt.Errorf("failed to get query stats: %w", err)
+	}
+
+	if stats.TotalQueries == 0 {
+		fmt.Println("No queries logged (enable with query_log: true)")
+		return nil
+	}
+
+	fmt.Printf("Query log for %s:\n", indexName)
+	fmt.Printf("  Queries: %d (%s to %s)\n", stats.TotalQueries,
+		stats.FirstQuery.Format("2006-01-02 15:04"), stats.LastQuery.Format("2006-01-02 15:04"))
+	fmt.Printf("  Zero-result queries: %d\n", stats.ZeroResultQueries)
+	fmt.Printf("  Latency: avg %.1f ms, p95 %.1f ms\n", stats.AvgLatencyMs, stats.P95LatencyMs)
+	fmt.Printf("  Average top score: %.3f\n", stats.AvgTopScore)
+	fmt.Printf("  Click-through rate: %.1f%%\n", stats.ClickThroughRate*100)
+	if stats.MeanClickRank > 0 {
+		fmt.Printf("  Mean clicked rank: %.2f\n", stats.MeanClickRank)
+	}
+
+	if len(stats.TopQueries) > 0 {
+		fmt.Println("\n  Top queries:")
+		for _, q := range stats.TopQueries {
+			fmt.Printf("    %4d  %s\n", q.Count, q.Query)
+		}
+	}
+	if len(stats.TopZeroResult) > 0 {
+		fmt.Println("\n  Top zero-result queries:")
+		for _, q := range stats.TopZeroResult {
+			fmt.Printf("    %4d  %s\n", q.Count, q.Query)
+		}
+	}
 
 	return nil
 }
@@ -450,10 +849,40 @@ func runConfluence(cmd *cobra.Command, args []string) error {
 	config.DataPath = viper.GetString("data_path")
 	config.OllamaURL = viper.GetString("ollama_url")
 	config.EmbedModel = viper.GetString("embed_model")
+	config.EmbedProvider = viper.GetString("embed_provider")
+	config.EmbedURL = viper.GetString("embed_url")
+	config.EmbedAPIKey = viper.GetString("embed_api_key")
+	config.EmbedBatchSize = viper.GetInt("embed_batch_size")
+	config.EmbedModelPath = viper.GetString("embed_model_path")
+	config.EmbedTruncate = embedTruncate()
+	config.EmbedKeepAlive = viper.GetString("embed_keep_alive")
+	config.EmbedModelOptions = viper.GetStringMap("embed_model_options")
+	config.EmbedDimensions = viper.GetInt("embed_dimensions")
+	config.ONNXRuntimePath = viper.GetString("onnxruntime_path")
 	config.ChunkSize = viper.GetInt("chunk_size")
 	config.ChunkOverlap = viper.GetInt("chunk_overlap")
+	config.ContentDefinedChunks = viper.GetBool("content_defined_chunks")
 	config.MaxWorkers = viper.GetInt("max_workers")
+	config.ChunkWorkers = viper.GetInt("chunk_workers")
+	config.PipelineQueue = viper.GetInt("pipeline_queue")
 	config.AutoSave = viper.GetBool("auto_save")
+	config.EmbeddingCache = viper.GetBool("embedding_cache")
+	config.EmbeddingCacheEntries = viper.GetInt("embedding_cache_entries")
+	config.AdaptiveEmbedding = viper.GetBool("adaptive_embedding")
+	config.LateInteraction = viper.GetBool("late_interaction")
+	config.LateInteractionWords = viper.GetInt("late_interaction_words")
+	config.NormalizeEmbeddings = viper.GetBool("normalize_embeddings")
+	config.BlobThreshold = viper.GetInt("blob_threshold")
+	config.MaxBatchDocuments = viper.GetInt("max_batch_documents")
+	config.MaxChunksPerDocument = viper.GetInt("max_chunks_per_document")
+	config.MaxContentBytes = viper.GetInt("max_content_bytes")
+	config.MaxMetadataBytes = viper.GetInt("max_metadata_bytes")
+	config.MaxMetadataDepth = viper.GetInt("max_metadata_depth")
+	config.MaxMetadataValueBytes = viper.GetInt("max_metadata_value_bytes")
+	config.MetadataOverflow = viper.GetString("metadata_overflow")
+	config.Compression = viper.GetString("compression")
+	config.Quantization = viper.GetString("quantization")
+	config.StorageLayout = viper.GetString("storage_layout")
 	
 	manager, err := hnswindex.NewIndexManager(config)
 	if err != nil {
@@ -510,6 +939,10 @@ func runConfluence(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Updated documents: %d\n", result.UpdatedDocuments)
 	fmt.Printf("  Unchanged documents: %d\n", result.UnchangedDocuments)
 	fmt.Printf("  Processed chunks: %d\n", result.ProcessedChunks)
+	fmt.Printf("  Tokens embedded: %d\n", result.TokensEmbedded)
+	fmt.Printf("  Duration: %v (embedding %v, storage %v), %.1f chunks/s\n",
+		result.DurationTotal.Round(time.Millisecond), result.DurationEmbedding.Round(time.Millisecond),
+		result.DurationStorage.Round(time.Millisecond), result.ChunksPerSecond)
 	
 	if len(result.FailedURIs) > 0 {
 		fmt.Printf("\n  Failed documents:\n")
@@ -524,30 +957,46 @@ func runConfluence(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// logLevelVar holds the log level, which serve changes when the config
+// file does
+var logLevelVar slog.LevelVar
+
+// embedTruncate returns the embed_truncate setting, nil if it is not set
+// so the embedding API's default applies
+func embedTruncate() *bool {
+	if !viper.IsSet("embed_truncate") {
+		return nil
+	}
+	truncate := viper.GetBool("embed_truncate")
+	return &truncate
+}
+
+// parseLogLevel converts a log_level setting to a level
+func parseLogLevel(name string) slog.Level {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
 func configureLogging() {
-	var level slog.Level
+	level := parseLogLevel(viper.GetString("log_level"))
 
 	// Debug flag overrides log-level
 	if debug {
 		level = slog.LevelDebug
-	} else {
-		switch strings.ToLower(logLevel) {
-		case "debug":
-			level = slog.LevelDebug
-		case "info":
-			level = slog.LevelInfo
-		case "warn", "warning":
-			level = slog.LevelWarn
-		case "error":
-			level = slog.LevelError
-		default:
-			level = slog.LevelInfo
-		}
 	}
+	logLevelVar.Set(level)
 
 	// Create a text handler with the specified level
 	opts := &slog.HandlerOptions{
-		Level: level,
+		Level: &logLevelVar,
 	}
 
 	// If verbose or debug, include source information
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var maintainCmd = &cobra.Command{
+	Use:   "maintain",
+	Short: "Verify, repair, prune, and compact indexes",
+	Long: `Check that the stored chunks of each index match its graph, and
+optionally repair what is found, delete documents whose expiry metadata
+(expires_at, expires, expiry_date or valid_until) has passed, and compact
+the graph to drop deleted vectors. "serve --maintenance-interval" does the
+same in the background.
+
+  demo maintain
+  demo maintain -i kb2 --repair --prune-expired --compact`,
+	RunE: runMaintain,
+}
+
+func init() {
+	maintainCmd.Flags().StringVarP(&indexName, "index", "i", "", "index name (empty for all)")
+	maintainCmd.Flags().Bool("repair", false, "repair the problems found")
+	maintainCmd.Flags().Bool("prune-expired", false, "delete expired documents")
+	maintainCmd.Flags().Bool("compact", false, "compact the graph")
+
+	rootCmd.AddCommand(maintainCmd)
+}
+
+func runMaintain(cmd *cobra.Command, args []string) error {
+	repair, _ := cmd.Flags().GetBool("repair")
+	pruneExpired, _ := cmd.Flags().GetBool("prune-expired")
+	compact, _ := cmd.Flags().GetBool("compact")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	names := []string{indexName}
+	if indexName == "" {
+		if names, err = manager.ListIndexes(); err != nil {
+			return fmt.Errorf("failed to list indexes: %w", err)
+		}
+	}
+
+	for _, name := range names {
+		index, err := manager.GetIndex(name)
+		if err != nil {
+			return fmt.Errorf("index '%s' not found", name)
+		}
+
+		report, err := index.Verify(repair)
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", name, err)
+		}
+		fmt.Printf("%s: %d documents, %d chunks, %d vectors, %d deleted vectors\n",
+			name, report.Documents, report.Chunks, report.GraphNodes, report.Tombstones)
+		if report.OK() {
+			fmt.Println("   Consistent")
+		} else {
+			fmt.Printf("   %d chunks without a vector, %d chunks without a document, %d vectors without a chunk\n",
+				len(report.MissingVectors), len(report.OrphanChunks), report.OrphanVectors)
+			if report.Repaired {
+				fmt.Println("   Repaired")
+			}
+		}
+
+		if pruneExpired {
+			expired, err := index.PruneExpired(nil)
+			if err != nil {
+				return fmt.Errorf("failed to prune %s: %w", name, err)
+			}
+			fmt.Printf("   Pruned %d expired documents\n", len(expired))
+		}
+		if compact {
+			dropped, err := index.Compact()
+			if err != nil {
+				return fmt.Errorf("failed to compact %s: %w", name, err)
+			}
+			fmt.Printf("   Compacted, dropping %d deleted vectors\n", dropped)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+var openapiCmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Index an OpenAPI or Swagger specification",
+	Long: `Index an OpenAPI 3 or Swagger 2 specification (JSON or YAML) with one
+document per endpoint, covering its description, parameters, request body,
+responses, and examples.`,
+	RunE: runOpenAPI,
+}
+
+func init() {
+	openapiCmd.Flags().StringVarP(&indexName, "index", "i", "api", "index name")
+	openapiCmd.Flags().StringSliceP("file", "f", nil, "specification files (required)")
+	openapiCmd.MarkFlagRequired("file")
+
+	rootCmd.AddCommand(openapiCmd)
+}
+
+func runOpenAPI(cmd *cobra.Command, args []string) error {
+	files, _ := cmd.Flags().GetStringSlice("file")
+
+	var docs []hnswindex.Document
+	for _, file := range files {
+		fileDocs, err := openapi.LoadFile(file)
+		if err != nil {
+			return err
+		}
+		if verbose {
+			fmt.Printf("Read %d endpoints from %s\n", len(fileDocs), file)
+		}
+		docs = append(docs, fileDocs...)
+	}
+	if len(docs) == 0 {
+		fmt.Println("No endpoints found")
+		return nil
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		if verbose {
+			fmt.Printf("Creating new index: %s\n", indexName)
+		}
+		index, err = manager.CreateIndex(indexName)
+		if err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	return indexDocuments(index, docs)
+}
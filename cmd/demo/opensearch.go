@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/pkg/opensearch"
+	"github.com/spf13/cobra"
+)
+
+var opensearchCmd = &cobra.Command{
+	Use:   "opensearch-export",
+	Short: "Export an index to Elasticsearch or OpenSearch",
+	Long: `Push the documents of an index, its chunks and their embeddings into an
+Elasticsearch or OpenSearch index, created with a mapping whose embedding
+field is a dense vector (dense_vector or knn_vector). Records are keyed by
+chunk ID and document URI, so running it again updates them in place;
+--recreate also drops records of documents deleted since.
+
+  demo opensearch-export -i kb --url http://localhost:9200 --target kb-mirror
+  ES_API_KEY=... demo opensearch-export -i kb --url https://es.example.com --target kb`,
+	RunE: runOpensearchExport,
+}
+
+func init() {
+	opensearchCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index to export")
+	opensearchCmd.Flags().StringP("url", "u", "", "cluster URL (or use ES_URL env)")
+	opensearchCmd.Flags().String("target", "", "target index in the cluster (default: the index name)")
+	opensearchCmd.Flags().String("engine", "", "elasticsearch or opensearch (default: detected)")
+	opensearchCmd.Flags().String("username", "", "username (or use ES_USERNAME env)")
+	opensearchCmd.Flags().String("password", "", "password (or use ES_PASSWORD env)")
+	opensearchCmd.Flags().String("api-key", "", "Elasticsearch API key (or use ES_API_KEY env)")
+	opensearchCmd.Flags().Int("batch-size", opensearch.DefaultBatchSize, "records per bulk request")
+	opensearchCmd.Flags().Bool("recreate", false, "delete the target index first")
+
+	rootCmd.AddCommand(opensearchCmd)
+}
+
+func runOpensearchExport(cmd *cobra.Command, args []string) error {
+	url, _ := cmd.Flags().GetString("url")
+	target, _ := cmd.Flags().GetString("target")
+	engine, _ := cmd.Flags().GetString("engine")
+	username, _ := cmd.Flags().GetString("username")
+	password, _ := cmd.Flags().GetString("password")
+	apiKey, _ := cmd.Flags().GetString("api-key")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	recreate, _ := cmd.Flags().GetBool("recreate")
+
+	if url == "" {
+		url = os.Getenv("ES_URL")
+	}
+	if username == "" {
+		username = os.Getenv("ES_USERNAME")
+	}
+	if password == "" {
+		password = os.Getenv("ES_PASSWORD")
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("ES_API_KEY")
+	}
+	if url == "" {
+		return fmt.Errorf("no cluster URL: set --url or ES_URL")
+	}
+	if target == "" {
+		target = indexName
+	}
+
+	exporter, err := opensearch.New(opensearch.Config{
+		URL:       url,
+		Index:     target,
+		Engine:    engine,
+		Username:  username,
+		Password:  password,
+		APIKey:    apiKey,
+		BatchSize: batchSize,
+		Recreate:  recreate,
+	})
+	if err != nil {
+		return err
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	report, err := exporter.Export(context.Background(), index)
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	fmt.Printf("Exported %d documents and %d chunks to %s index '%s' in %s\n",
+		report.Documents, report.Chunks, report.Engine, target, report.Duration.Round(1e6))
+
+	if len(report.Failed) > 0 {
+		ids := make([]string, 0, len(report.Failed))
+		for id := range report.Failed {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		fmt.Printf("%d records rejected:\n", len(ids))
+		for _, id := range ids {
+			fmt.Printf("  %s: %s\n", id, report.Failed[id])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var outliersCmd = &cobra.Command{
+	Use:   "outliers",
+	Short: "Report outlier and garbage chunks",
+	Long: `Flag chunks whose embedding is far from every other chunk of the index, and
+chunks whose text is mostly not language (base64, minified code), to help
+tune ingestion filters.`,
+	RunE: runOutliers,
+}
+
+func init() {
+	outliersCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	outliersCmd.Flags().Float64("z-score", hnswindex.DefaultOutlierZScore, "standard deviations above the mean neighbor distance that flag an outlier")
+	outliersCmd.Flags().Float64("min-language", hnswindex.DefaultMinLanguageRatio, "share of characters in plain words below which a chunk is garbage")
+
+	rootCmd.AddCommand(outliersCmd)
+}
+
+func runOutliers(cmd *cobra.Command, args []string) error {
+	zScore, _ := cmd.Flags().GetFloat64("z-score")
+	minLanguage, _ := cmd.Flags().GetFloat64("min-language")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	report, err := index.FindOutliers(hnswindex.OutlierOptions{
+		ZScore:           zScore,
+		MinLanguageRatio: minLanguage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find outliers: %w", err)
+	}
+
+	fmt.Printf("Checked %d chunks\n", report.Checked)
+	if len(report.Outliers) > 0 {
+		fmt.Printf("\nEmbedding outliers (%d):\n", len(report.Outliers))
+		for _, s := range report.Outliers {
+			fmt.Printf("  z=%.1f distance=%.3f  %s\n      %s\n", s.ZScore, s.Distance, s.DocumentURI, s.Preview)
+		}
+	}
+	if len(report.Garbage) > 0 {
+		fmt.Printf("\nMostly non-language text (%d):\n", len(report.Garbage))
+		for _, s := range report.Garbage {
+			fmt.Printf("  %3.0f%% words  %s\n      %s\n", s.LanguageRatio*100, s.DocumentURI, s.Preview)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var processCmd = &cobra.Command{
+	Use:   "process <file.md>",
+	Short: "Print a markdown file as the index pipeline would store it",
+	Long: `Run a markdown file through the chunking pipeline of an index without
+embedding or storing it, and print the chunks with their IDs, token counts
+and metadata as canonical JSON. Commit the output as a golden file to catch
+chunker changes that would re-embed every document.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProcess,
+}
+
+func init() {
+	processCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	processCmd.Flags().StringP("output", "o", "", "write to this file instead of stdout")
+
+	rootCmd.AddCommand(processCmd)
+}
+
+func runProcess(cmd *cobra.Command, args []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	path := args[0]
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	// Same URI and metadata as the index command
+	processed, err := index.ProcessDocument(context.Background(), hnswindex.Document{
+		URI:    fmt.Sprintf("file://%s", path),
+		Title:  filepath.Base(path),
+		Source: hnswindex.FileContent(path),
+		Metadata: map[string]interface{}{
+			"path":     path,
+			"rel_path": filepath.Base(path),
+			"size":     int(info.Size()),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to process %s: %w", path, err)
+	}
+
+	golden, err := processed.Golden()
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		return os.WriteFile(output, golden, 0644)
+	}
+	_, err = os.Stdout.Write(golden)
+	return err
+}
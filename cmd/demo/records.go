@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/pkg/records"
+	"github.com/spf13/cobra"
+)
+
+var recordsCmd = &cobra.Command{
+	Use:   "records",
+	Short: "Index rows of a CSV or JSON Lines file",
+	Long: `Map each row of a CSV (with header) or JSON Lines file to a document and
+index it. The URI comes from --uri-field, the title from --title-field, and
+the content is rendered from --content, a Go template over the row fields
+(e.g. "Q: {{.question}}\nA: {{.answer}}"). Without a template every field is
+written as "name: value".`,
+	RunE: runRecords,
+}
+
+func init() {
+	recordsCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	recordsCmd.Flags().StringP("file", "f", "", "CSV, .jsonl, or .ndjson file (required)")
+	recordsCmd.Flags().String("uri-field", "", "field holding the unique record ID (required)")
+	recordsCmd.Flags().String("uri-prefix", "", "prefix for document URIs, e.g. kb://faq/")
+	recordsCmd.Flags().String("title-field", "", "field holding the document title")
+	recordsCmd.Flags().String("content", "", "content template over the record fields")
+	recordsCmd.Flags().StringSlice("meta", nil, "fields to copy into document metadata")
+	recordsCmd.MarkFlagRequired("file")
+	recordsCmd.MarkFlagRequired("uri-field")
+
+	rootCmd.AddCommand(recordsCmd)
+}
+
+func runRecords(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	mapping := records.Mapping{}
+	mapping.URIField, _ = cmd.Flags().GetString("uri-field")
+	mapping.URIPrefix, _ = cmd.Flags().GetString("uri-prefix")
+	mapping.TitleField, _ = cmd.Flags().GetString("title-field")
+	mapping.ContentTemplate, _ = cmd.Flags().GetString("content")
+	mapping.MetadataFields, _ = cmd.Flags().GetStringSlice("meta")
+
+	documents, err := records.ReadFile(file, mapping)
+	if err != nil {
+		return err
+	}
+	if len(documents) == 0 {
+		fmt.Println("No records found")
+		return nil
+	}
+	if verbose {
+		fmt.Printf("Read %d records from %s\n", len(documents), file)
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		if verbose {
+			fmt.Printf("Creating new index: %s\n", indexName)
+		}
+		index, err = manager.CreateIndex(indexName)
+		if err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	return indexDocuments(index, documents)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var reduceCmd = &cobra.Command{
+	Use:   "reduce",
+	Short: "Reduce the embedding dimension of an index's graph",
+	Long: `Rebuild the graph of an index with shorter embeddings, by truncation (for
+Matryoshka models) or PCA fitted on the indexed chunks. Stored embeddings
+are kept, so --method none restores the full graph.`,
+	RunE: runReduce,
+}
+
+func init() {
+	reduceCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	reduceCmd.Flags().String("method", "pca", "reduction method: truncate, pca, or none")
+	reduceCmd.Flags().Int("dim", 256, "reduced dimension")
+
+	rootCmd.AddCommand(reduceCmd)
+}
+
+func runReduce(cmd *cobra.Command, args []string) error {
+	method, _ := cmd.Flags().GetString("method")
+	dim, _ := cmd.Flags().GetInt("dim")
+	if method == "none" {
+		method, dim = "", 0
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	r, err := index.Reduce(hnswindex.ReductionMethod(method), dim)
+	if err != nil {
+		return fmt.Errorf("reduction failed: %w", err)
+	}
+	if r == nil {
+		fmt.Printf("Index %s now uses full embeddings\n", indexName)
+		return nil
+	}
+	fmt.Printf("Index %s reduced from %d to %d dimensions (%s)\n", indexName, r.InputDimension, r.Dimension, r.Method)
+	if r.Method == hnswindex.ReductionPCA {
+		fmt.Printf("PCA fitted on %d chunks keeps %.1f%% of the variance\n", r.FittedOn, r.ExplainedVariance*100)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/riclib/hnswindex/server"
+	"github.com/spf13/viper"
+)
+
+// reloadableSettings are the settings serve applies when the config file
+// changes, grouped by what applies them
+var reloadableSettings = map[string]string{
+	"log_level":                 "logging",
+	"server.search_limit":       "search",
+	"server.title_boost":        "search",
+	"server.sparse_weight":      "search",
+	"server.search_model":       "search",
+	"server.rate_limit":         "rate_limits",
+	"server.rate_burst":         "rate_limits",
+	"server.client_concurrency": "rate_limits",
+	"server.max_concurrent":     "rate_limits",
+	"server.follow_interval":    "replication",
+}
+
+// reindexSettings change how documents are chunked or embedded, so indexes
+// built before the change would not match it
+var reindexSettings = map[string]bool{
+	"embed_provider":         true,
+	"embed_model":            true,
+	"embed_model_path":       true,
+	"embed_truncate":         true,
+	"embed_model_options":    true,
+	"embed_dimensions":       true,
+	"chunk_size":             true,
+	"chunk_overlap":          true,
+	"normalize_embeddings":   true,
+	"late_interaction":       true,
+	"late_interaction_words": true,
+}
+
+// configWatcher applies changes to the config file to a running server.
+// Settings that need a restart or reindexing are reported and left as
+// they are, and keep being reported until the file is changed back.
+type configWatcher struct {
+	srv      *server.Server
+	follower *server.Follower       // nil unless following a leader
+	running  map[string]interface{} // Settings in effect, flattened
+}
+
+// watchConfig starts watching the config file, if one was loaded
+func watchConfig(srv *server.Server, follower *server.Follower) {
+	if viper.ConfigFileUsed() == "" {
+		return
+	}
+	w := &configWatcher{
+		srv:      srv,
+		follower: follower,
+		running:  flattenSettings("", viper.AllSettings()),
+	}
+	viper.OnConfigChange(func(fsnotify.Event) { w.reload() })
+	viper.WatchConfig()
+	slog.Info("Watching config file for changes", "file", viper.ConfigFileUsed())
+}
+
+// reload compares the reread config file with the settings in effect and
+// applies the changes it can
+func (w *configWatcher) reload() {
+	settings := flattenSettings("", viper.AllSettings())
+	changed := w.changedSettings(settings)
+	if len(changed) == 0 {
+		return
+	}
+
+	apply := make(map[string]bool)
+	for _, key := range changed {
+		switch {
+		case reloadableSettings[key] != "":
+			apply[reloadableSettings[key]] = true
+			if value, ok := settings[key]; ok {
+				w.running[key] = value
+			} else {
+				delete(w.running, key)
+			}
+			slog.Info("Config setting reloaded", "key", key, "value", viper.Get(key))
+		case reindexSettings[strings.SplitN(key, ".", 2)[0]]: // Or a setting nested in one
+			slog.Error("Config setting not applied: changing it requires reindexing",
+				"key", key,
+				"hint", fmt.Sprintf("restart and rebuild the indexes to use the new %s", key),
+			)
+		default:
+			slog.Warn("Config setting not applied: changing it requires a restart", "key", key)
+		}
+	}
+
+	if apply["logging"] && !debug {
+		logLevelVar.Set(parseLogLevel(viper.GetString("log_level")))
+	}
+	if apply["search"] {
+		w.srv.SetSearchDefaults(searchDefaults())
+	}
+	if apply["rate_limits"] {
+		w.srv.SetRateLimits(rateLimits())
+	}
+	if apply["replication"] && w.follower != nil {
+		w.follower.SetInterval(viper.GetDuration("server.follow_interval"))
+	}
+}
+
+// changedSettings returns the keys whose value differs from the settings
+// in effect, sorted
+func (w *configWatcher) changedSettings(settings map[string]interface{}) []string {
+	var changed []string
+	for key, value := range settings {
+		if !reflect.DeepEqual(w.running[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range w.running {
+		if _, ok := settings[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// flattenSettings turns nested settings into dotted keys
+func flattenSettings(prefix string, settings map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for key, value := range settings {
+		if nested, ok := value.(map[string]interface{}); ok {
+			for k, v := range flattenSettings(prefix+key+".", nested) {
+				flat[k] = v
+			}
+			continue
+		}
+		flat[prefix+key] = value
+	}
+	return flat
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var routeCmd = &cobra.Command{
+	Use:   "route [query]",
+	Short: "Rank indexes by likely relevance to a query",
+	Long: `Compare the query with the centroids of every index's chunk embeddings and
+list the indexes from most to least likely to hold results.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRoute,
+}
+
+func init() {
+	rootCmd.AddCommand(routeCmd)
+}
+
+func runRoute(cmd *cobra.Command, args []string) error {
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	routes, err := manager.RouteQuery(strings.Join(args, " "))
+	if err != nil {
+		return fmt.Errorf("failed to route query: %w", err)
+	}
+	if len(routes) == 0 {
+		fmt.Println("No indexes found")
+		return nil
+	}
+	for _, r := range routes {
+		fmt.Printf("%.3f  %-20s %d chunks\n", r.Score, r.Index, r.Chunks)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve index search over HTTP",
+	Long: `Serve the indexes in the data directory over JSON/HTTP.
+
+With --diagnostics (or server.diagnostics: true in the config file) the
+server also exposes /debug/pprof/, Prometheus /metrics, and an index dump
+at /debug/indexes. Only enable it on trusted networks.
+
+/healthz checks storage and the embedder, and /readyz reports ready once
+every index has been searched once (disable with --warm-up=false); point
+Kubernetes liveness and readiness probes at them. --warm-up-query (or
+server.warm_up_queries) searches typical queries instead, so the graph
+regions they reach are in memory before the first user arrives.
+
+With --api-key (or server.api_keys in the config file) every request except
+the probes and the search page needs "Authorization: Bearer <key>" or
+"X-API-Key: <key>". Keys in the config file may be limited to some indexes:
+
+  server:
+    api_keys:
+      - {name: hr-bot, key: s3cret, indexes: [hr]}
+
+--rate-limit, --client-concurrency, and --max-concurrent throttle searches
+per API key (or per IP address without authentication) with 429 responses.
+
+--search-limit, --title-boost, --sparse-weight, and --search-model set the
+defaults of searches that leave those parameters out.
+
+While serving, changes to the config file are applied without a restart
+where that is safe: log_level, the search defaults, the rate limits, and
+server.follow_interval. Other changes are logged and not applied; those to
+the embedding model or chunking also need the indexes to be rebuilt.
+
+With --ui the server also serves a search page at / for trying the indexes
+from a browser.
+
+With --writes clients may also create and delete indexes and index and
+delete documents:
+
+  curl -X POST localhost:8080/indexes -d '{"name": "kb"}'
+  curl -X POST localhost:8080/indexes/kb/documents \
+    -d '{"documents": [{"uri": "doc://1", "title": "Hello", "content": "..."}]}'
+  curl -X DELETE 'localhost:8080/indexes/kb/documents?uri=doc://1'
+
+Index statistics and stored documents are served either way, at
+/indexes/{name}/stats and /indexes/{name}/documents?uri=.
+
+With --admin the server exposes POST /admin/save, which flushes unsaved
+graphs to disk. Graphs are also flushed when the server shuts down.
+
+With --maintenance-interval (or server.maintenance_interval) the indexes
+are verified and repaired, expired documents pruned, and graphs with many
+deleted vectors compacted in the background, e.g. every 6h. Replicas
+following a leader skip it.
+
+Replication: start the writer with --replication, and read replicas with
+--follow http://writer:8080 to copy its indexes (with embeddings) every
+--follow-interval. Replicas must not be indexed into directly.`,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().String("addr", ":8080", "address to listen on")
+	serveCmd.Flags().Bool("diagnostics", false, "expose pprof, metrics, and index diagnostics endpoints")
+	serveCmd.Flags().Bool("ui", false, "serve a web search page at /")
+	serveCmd.Flags().Bool("writes", false, "let clients create indexes and index and delete documents")
+	serveCmd.Flags().Bool("admin", false, "expose maintenance endpoints such as POST /admin/save")
+	serveCmd.Flags().Bool("warm-up", true, "search every index once before /readyz reports ready")
+	serveCmd.Flags().StringSlice("warm-up-query", nil, "search every index with this query during warm-up (repeatable)")
+
+	serveCmd.Flags().Bool("replication", false, "serve replication endpoints for followers")
+	serveCmd.Flags().String("follow", "", "replicate indexes from this leader URL")
+	serveCmd.Flags().Duration("follow-interval", 30*time.Second, "time between replication syncs")
+	serveCmd.Flags().StringSlice("api-key", nil, "require this API key (repeatable; default: $HNSW_API_KEY)")
+	serveCmd.Flags().String("follow-api-key", "", "API key for the leader with --follow")
+	serveCmd.Flags().Float64("rate-limit", 0, "searches per second per client (0: unlimited)")
+	serveCmd.Flags().Int("rate-burst", 0, "searches a client may send at once (default: one second's worth)")
+	serveCmd.Flags().Int("client-concurrency", 0, "concurrent searches per client (0: unlimited)")
+	serveCmd.Flags().Int("max-concurrent", 0, "concurrent searches across all clients (0: unlimited)")
+	serveCmd.Flags().Int("search-limit", server.DefaultSearchLimit, "results of searches without a limit")
+	serveCmd.Flags().Float64("title-boost", 0, "title boost of searches without title_boost")
+	serveCmd.Flags().Float64("sparse-weight", 0, "sparse weight of searches without sparse_weight")
+	serveCmd.Flags().String("search-model", "", "query model of searches without model")
+	serveCmd.Flags().Duration("maintenance-interval", 0, "verify, prune, and compact indexes this often (0: never)")
+
+	viper.BindPFlag("server.addr", serveCmd.Flags().Lookup("addr"))
+	viper.BindPFlag("server.diagnostics", serveCmd.Flags().Lookup("diagnostics"))
+	viper.BindPFlag("server.ui", serveCmd.Flags().Lookup("ui"))
+	viper.BindPFlag("server.writes", serveCmd.Flags().Lookup("writes"))
+	viper.BindPFlag("server.admin", serveCmd.Flags().Lookup("admin"))
+	viper.BindPFlag("server.warm_up", serveCmd.Flags().Lookup("warm-up"))
+	viper.BindPFlag("server.warm_up_queries", serveCmd.Flags().Lookup("warm-up-query"))
+	viper.BindPFlag("server.replication", serveCmd.Flags().Lookup("replication"))
+	viper.BindPFlag("server.follow", serveCmd.Flags().Lookup("follow"))
+	viper.BindPFlag("server.follow_interval", serveCmd.Flags().Lookup("follow-interval"))
+	viper.BindPFlag("server.follow_api_key", serveCmd.Flags().Lookup("follow-api-key"))
+	viper.BindPFlag("server.rate_limit", serveCmd.Flags().Lookup("rate-limit"))
+	viper.BindPFlag("server.rate_burst", serveCmd.Flags().Lookup("rate-burst"))
+	viper.BindPFlag("server.client_concurrency", serveCmd.Flags().Lookup("client-concurrency"))
+	viper.BindPFlag("server.max_concurrent", serveCmd.Flags().Lookup("max-concurrent"))
+	viper.BindPFlag("server.search_limit", serveCmd.Flags().Lookup("search-limit"))
+	viper.BindPFlag("server.title_boost", serveCmd.Flags().Lookup("title-boost"))
+	viper.BindPFlag("server.sparse_weight", serveCmd.Flags().Lookup("sparse-weight"))
+	viper.BindPFlag("server.search_model", serveCmd.Flags().Lookup("search-model"))
+	viper.BindPFlag("server.maintenance_interval", serveCmd.Flags().Lookup("maintenance-interval"))
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if viper.GetBool("server.writes") && viper.GetString("server.follow") != "" {
+		return fmt.Errorf("--writes cannot be combined with --follow: replicas must not be indexed into directly")
+	}
+	config := loadConfig()
+	if viper.GetString("server.follow") == "" {
+		config.MaintenanceInterval = viper.GetDuration("server.maintenance_interval")
+	}
+	manager, err := hnswindex.NewIndexManager(config)
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+	manager.Subscribe(hnswindex.EventHandlerFuncs{Maintenance: logMaintenance})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	apiKeys, err := loadAPIKeys(cmd)
+	if err != nil {
+		return err
+	}
+
+	addr := viper.GetString("server.addr")
+	srv := server.New(manager, server.Options{
+		Diagnostics:    viper.GetBool("server.diagnostics"),
+		Replication:    viper.GetBool("server.replication"),
+		UI:             viper.GetBool("server.ui"),
+		Writes:         viper.GetBool("server.writes"),
+		Admin:          viper.GetBool("server.admin"),
+		WarmUp:         viper.GetBool("server.warm_up"),
+		WarmUpQueries:  viper.GetStringSlice("server.warm_up_queries"),
+		APIKeys:        apiKeys,
+		RateLimits:     rateLimits(),
+		SearchDefaults: searchDefaults(),
+	})
+
+	var follower *server.Follower
+	if leader := viper.GetString("server.follow"); leader != "" {
+		follower = server.NewFollower(manager, leader, server.FollowerOptions{
+			Interval: viper.GetDuration("server.follow_interval"),
+			APIKey:   viper.GetString("server.follow_api_key"),
+		})
+		go follower.Run(ctx)
+		fmt.Printf("Replicating from %s\n", leader)
+	}
+	watchConfig(srv, follower)
+
+	fmt.Printf("Serving on %s (Ctrl+C to stop)\n", addr)
+	if viper.GetBool("server.ui") {
+		host := addr
+		if strings.HasPrefix(host, ":") {
+			host = "localhost" + host
+		}
+		fmt.Printf("Search page: http://%s/\n", host)
+	}
+	if len(apiKeys) > 0 {
+		fmt.Printf("Authentication required (%d API keys)\n", len(apiKeys))
+	}
+	err = srv.ListenAndServe(ctx, addr)
+
+	// Flush graphs left unsaved, as with auto_save off, before exiting
+	if saveErr := manager.SaveAll(context.Background()); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+// loadAPIKeys combines the --api-key flags, $HNSW_API_KEY, and the
+// server.api_keys entries of the config file
+func loadAPIKeys(cmd *cobra.Command) ([]server.APIKey, error) {
+	var keys []server.APIKey
+	if err := viper.UnmarshalKey("server.api_keys", &keys); err != nil {
+		return nil, fmt.Errorf("invalid server.api_keys: %w", err)
+	}
+	for idx, key := range keys {
+		if key.Key == "" {
+			return nil, fmt.Errorf("server.api_keys entry %d has no key", idx+1)
+		}
+	}
+
+	flagKeys, _ := cmd.Flags().GetStringSlice("api-key")
+	if len(flagKeys) == 0 {
+		if env := os.Getenv("HNSW_API_KEY"); env != "" {
+			flagKeys = []string{env}
+		}
+	}
+	for idx, key := range flagKeys {
+		keys = append(keys, server.APIKey{Key: key, Name: fmt.Sprintf("flag-%d", idx+1)})
+	}
+	return keys, nil
+}
+
+// rateLimits builds the server rate limits from viper settings
+func rateLimits() server.RateLimits {
+	return server.RateLimits{
+		RequestsPerSecond: viper.GetFloat64("server.rate_limit"),
+		Burst:             viper.GetInt("server.rate_burst"),
+		ClientConcurrency: viper.GetInt("server.client_concurrency"),
+		MaxConcurrent:     viper.GetInt("server.max_concurrent"),
+	}
+}
+
+// searchDefaults builds the server search defaults from viper settings
+func searchDefaults() server.SearchDefaults {
+	return server.SearchDefaults{
+		Limit:        viper.GetInt("server.search_limit"),
+		TitleBoost:   viper.GetFloat64("server.title_boost"),
+		SparseWeight: viper.GetFloat64("server.sparse_weight"),
+		Model:        viper.GetString("server.search_model"),
+	}
+}
+
+// logMaintenance logs the outcome of the background maintenance of an index
+func logMaintenance(report hnswindex.MaintenanceReport) {
+	if report.Error != "" {
+		slog.Error("Index maintenance failed",
+			"index", report.Index,
+			"error", report.Error,
+		)
+		return
+	}
+	slog.Info("Index maintenance completed",
+		"index", report.Index,
+		"consistent", report.Verify.OK(),
+		"repaired", report.Verify.Repaired,
+		"expired", len(report.Expired),
+		"compacted", report.Compacted,
+		"duration_ms", report.Duration.Milliseconds(),
+	)
+}
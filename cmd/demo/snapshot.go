@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/pkg/s3"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Persist the data directory to object storage",
+	Long: `Upload or restore a snapshot of the data directory (database and HNSW
+graphs) at snapshot_url, which is either s3://bucket/key.tar.gz (credentials
+and region from the AWS_* environment variables, AWS_ENDPOINT_URL for MinIO
+or GCS) or a local directory.
+
+With snapshot_url set in the config file, every command restores an empty
+data directory at startup, and snapshot_on_save uploads after each save.
+Restores check the files and documents of the snapshot against its
+manifest and fail without touching the data directory if any are missing
+or damaged.
+
+Snapshots are compressed with snapshot_compression (zstd or gzip) and
+uploaded in parts of snapshot_part_size bytes; parts that fail are retried
+and interrupted downloads resume where they stopped. export and import
+stream a snapshot through stdout and stdin instead:
+
+  demo snapshot export | aws s3 cp - s3://search/kb.tar.zst
+  aws s3 cp s3://search/kb.tar.zst - | demo snapshot import
+
+inspect opens a snapshot file read-only, without importing it, to look
+at an earlier state of the indexes:
+
+  demo snapshot inspect kb-2024-06.tar.zst
+  demo snapshot inspect kb-2024-06.tar.zst -i kb -q "refund policy"`,
+}
+
+var snapshotPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Upload a snapshot of the data directory",
+	RunE:  runSnapshotPush,
+}
+
+var snapshotPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Restore an empty data directory from the snapshot",
+	RunE:  runSnapshotPull,
+}
+
+var snapshotExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write a snapshot of the data directory to stdout",
+	RunE:  runSnapshotExport,
+}
+
+var snapshotImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Restore an empty data directory from a snapshot on stdin",
+	RunE:  runSnapshotImport,
+}
+
+var snapshotInspectCmd = &cobra.Command{
+	Use:   "inspect FILE",
+	Short: "List or search the indexes of a snapshot file, read-only",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSnapshotInspect,
+}
+
+func init() {
+	snapshotCmd.PersistentFlags().String("url", "", "snapshot location (default: snapshot_url from the config)")
+	viper.BindPFlag("snapshot_url", snapshotCmd.PersistentFlags().Lookup("url"))
+
+	snapshotCmd.AddCommand(snapshotPushCmd)
+	snapshotCmd.AddCommand(snapshotPullCmd)
+	snapshotCmd.AddCommand(snapshotExportCmd)
+	snapshotCmd.AddCommand(snapshotImportCmd)
+	snapshotInspectCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index to search")
+	snapshotInspectCmd.Flags().StringP("query", "q", "", "search the index instead of listing indexes")
+	snapshotInspectCmd.Flags().IntP("limit", "l", 5, "number of results")
+	snapshotCmd.AddCommand(snapshotInspectCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+// snapshotStore resolves a snapshot URL into an object store and key.
+// URLs ending in "/" use hnswindex.DefaultSnapshotKey under that prefix.
+func snapshotStore(rawURL string) (hnswindex.ObjectStore, string, error) {
+	if bucketAndKey, ok := strings.CutPrefix(rawURL, "s3://"); ok {
+		bucket, key, _ := strings.Cut(bucketAndKey, "/")
+		if bucket == "" {
+			return nil, "", fmt.Errorf("missing bucket in %q", rawURL)
+		}
+		if key == "" || strings.HasSuffix(key, "/") {
+			key += hnswindex.DefaultSnapshotKey
+		}
+		store, err := s3.New(s3.ConfigFromEnv(bucket))
+		return store, key, err
+	}
+
+	dir := strings.TrimPrefix(rawURL, "file://")
+	return hnswindex.NewDirStore(dir), hnswindex.DefaultSnapshotKey, nil
+}
+
+func runSnapshotPush(cmd *cobra.Command, args []string) error {
+	rawURL := viper.GetString("snapshot_url")
+	if rawURL == "" {
+		return fmt.Errorf("no snapshot location: set --url or snapshot_url")
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	store, key, err := snapshotStore(rawURL)
+	if err != nil {
+		return err
+	}
+	if err := manager.UploadSnapshot(context.Background(), store, key); err != nil {
+		return err
+	}
+	fmt.Printf("Snapshot uploaded to %s\n", rawURL)
+	return nil
+}
+
+func runSnapshotPull(cmd *cobra.Command, args []string) error {
+	rawURL := viper.GetString("snapshot_url")
+	if rawURL == "" {
+		return fmt.Errorf("no snapshot location: set --url or snapshot_url")
+	}
+
+	store, key, err := snapshotStore(rawURL)
+	if err != nil {
+		return err
+	}
+	restored, err := hnswindex.DownloadSnapshot(context.Background(), store, key, viper.GetString("data_path"))
+	if err != nil {
+		return err
+	}
+	if !restored {
+		fmt.Println("Nothing restored: the data directory is not empty or there is no snapshot")
+		return nil
+	}
+	fmt.Printf("Data directory restored from %s\n", rawURL)
+	return nil
+}
+
+func runSnapshotExport(cmd *cobra.Command, args []string) error {
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	out := bufio.NewWriter(os.Stdout)
+	if err := manager.WriteSnapshot(out); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+func runSnapshotImport(cmd *cobra.Command, args []string) error {
+	dataPath := viper.GetString("data_path")
+	if _, err := os.Stat(filepath.Join(dataPath, "indexes.db")); err == nil {
+		return fmt.Errorf("data directory %s is not empty", dataPath)
+	}
+
+	if err := hnswindex.RestoreSnapshot(bufio.NewReader(os.Stdin), dataPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Data directory %s restored\n", dataPath)
+	return nil
+}
+
+func runSnapshotInspect(cmd *cobra.Command, args []string) error {
+	query, _ := cmd.Flags().GetString("query")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	snap, err := manager.OpenSnapshot(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer snap.Close()
+
+	if query != "" {
+		index, err := snap.GetIndex(indexName)
+		if err != nil {
+			return fmt.Errorf("index '%s' not found in snapshot", indexName)
+		}
+		results, err := index.Search(query, limit)
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
+		}
+		if len(results) == 0 {
+			fmt.Println("No results found")
+			return nil
+		}
+		for i, result := range results {
+			fmt.Printf("%d. %s (Score: %.3f)\n", i+1, result.Document.Title, result.Score)
+			fmt.Printf("   URI: %s\n", result.Document.URI)
+		}
+		return nil
+	}
+
+	names, err := snap.ListIndexes()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		index, err := snap.GetIndex(name)
+		if err != nil {
+			return err
+		}
+		stats, err := index.Stats()
+		if err != nil {
+			return fmt.Errorf("failed to get stats of '%s': %w", name, err)
+		}
+		fmt.Printf("%s: %d documents, %d chunks", name, stats.DocumentCount, stats.ChunkCount)
+		if !stats.LastUpdated.IsZero() {
+			fmt.Printf(", last updated %s", stats.LastUpdated.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var suggestCmd = &cobra.Command{
+	Use:   "suggest [prefix]",
+	Short: "Complete a prefix with document titles and headings",
+	Long: `Complete a prefix with the titles and markdown headings of an index's
+documents, as a search box would while the user types.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSuggest,
+}
+
+func init() {
+	suggestCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	suggestCmd.Flags().IntP("limit", "l", hnswindex.DefaultSuggestLimit, "maximum number of suggestions")
+
+	rootCmd.AddCommand(suggestCmd)
+}
+
+func runSuggest(cmd *cobra.Command, args []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	suggestions, err := index.Suggest(args[0], limit)
+	if err != nil {
+		return fmt.Errorf("suggest failed: %w", err)
+	}
+
+	for _, s := range suggestions {
+		fmt.Printf("%-8s %s (%s", s.Kind, s.Text, s.URI)
+		if s.Documents > 1 {
+			fmt.Printf(" and %d more", s.Documents-1)
+		}
+		fmt.Println(")")
+	}
+	if len(suggestions) == 0 {
+		fmt.Println("No suggestions")
+	}
+	return nil
+}
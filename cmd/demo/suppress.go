@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var suppressCmd = &cobra.Command{
+	Use:   "suppress",
+	Short: "List, add or remove suppressions of documents from search results",
+	Long: `Suppressed documents stay in the index but are left out of search
+results. Suppress a document by URI, or every document matching filters in
+query syntax; remove a suppression by its ID, the URI or filters shown by
+the listing.
+
+  demo suppress -i kb2
+  demo suppress -i kb2 --uri doc://old-pricing --reason "superseded by doc://pricing"
+  demo suppress -i kb2 --filter "status:archived"
+  demo suppress -i kb2 --remove doc://old-pricing`,
+	RunE: runSuppress,
+}
+
+func init() {
+	suppressCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	suppressCmd.Flags().String("uri", "", "suppress the document with this URI")
+	suppressCmd.Flags().String("filter", "", "suppress the documents matching these filters, e.g. status:archived")
+	suppressCmd.Flags().String("reason", "", "why the documents are suppressed")
+	suppressCmd.Flags().String("remove", "", "remove the suppression with this ID")
+
+	rootCmd.AddCommand(suppressCmd)
+}
+
+func runSuppress(cmd *cobra.Command, args []string) error {
+	uri, _ := cmd.Flags().GetString("uri")
+	filter, _ := cmd.Flags().GetString("filter")
+	reason, _ := cmd.Flags().GetString("reason")
+	remove, _ := cmd.Flags().GetString("remove")
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	if uri != "" || filter != "" {
+		suppression := hnswindex.Suppression{URI: uri, Reason: reason}
+		if filter != "" {
+			parsed, err := hnswindex.ParseQuery(filter)
+			if err != nil {
+				return fmt.Errorf("invalid filter: %w", err)
+			}
+			if parsed.Text != "" {
+				return fmt.Errorf("invalid filter: %q is not a filter", parsed.Text)
+			}
+			suppression.Filters = parsed.Filters
+		}
+		if suppression, err = index.Suppress(suppression); err != nil {
+			return fmt.Errorf("failed to suppress: %w", err)
+		}
+		fmt.Printf("Suppressed %s\n", suppression.ID)
+	}
+	if remove != "" {
+		if err := index.Unsuppress(remove); err != nil {
+			return fmt.Errorf("failed to remove suppression: %w", err)
+		}
+		fmt.Printf("Removed suppression %s\n", remove)
+	}
+
+	suppressions, err := index.Suppressions()
+	if err != nil {
+		return fmt.Errorf("failed to read suppressions: %w", err)
+	}
+	if len(suppressions) == 0 {
+		fmt.Printf("Index '%s' has no suppressions\n", indexName)
+		return nil
+	}
+	fmt.Printf("Suppressions of index '%s':\n", indexName)
+	for _, s := range suppressions {
+		fmt.Printf("  %s (since %s)\n", s.ID, s.Created.Format("2006-01-02 15:04"))
+		if s.Reason != "" {
+			fmt.Printf("     %s\n", s.Reason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/pkg/transcript"
+	"github.com/spf13/cobra"
+)
+
+var transcriptCmd = &cobra.Command{
+	Use:   "transcript",
+	Short: "Index a Whisper JSON transcript",
+	Long: `Index a Whisper JSON transcript so search results link to the matching
+moment of the recording. Chunks follow segment boundaries and keep their
+start and end times.`,
+	RunE: runTranscript,
+}
+
+func init() {
+	transcriptCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	transcriptCmd.Flags().StringP("file", "f", "", "Whisper JSON file (required)")
+	transcriptCmd.Flags().String("media", "", "URI of the recording (default: file:// URI of the transcript)")
+	transcriptCmd.Flags().String("title", "", "document title (default: file name)")
+	transcriptCmd.MarkFlagRequired("file")
+
+	rootCmd.AddCommand(transcriptCmd)
+}
+
+func runTranscript(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	media, _ := cmd.Flags().GetString("media")
+	title, _ := cmd.Flags().GetString("title")
+
+	if media == "" {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", file, err)
+		}
+		media = "file://" + abs
+	}
+
+	doc, err := transcript.LoadWhisperFile(file, media, title)
+	if err != nil {
+		return err
+	}
+	if verbose {
+		fmt.Printf("Read %d segments from %s\n", len(doc.Segments), file)
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		if verbose {
+			fmt.Printf("Creating new index: %s\n", indexName)
+		}
+		index, err = manager.CreateIndex(indexName)
+		if err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	return indexDocuments(index, []hnswindex.Document{doc})
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/spf13/cobra"
+)
+
+var visualizeCmd = &cobra.Command{
+	Use:   "visualize",
+	Short: "Export embeddings for visual inspection",
+	Long: `Export the chunk embeddings of an index with their labels, to look at the
+cluster structure of a corpus.
+
+--format tsv (the default) writes vectors.tsv and metadata.tsv to the --out
+directory, to load at https://projector.tensorflow.org, which projects them
+with PCA, t-SNE or UMAP. --format json computes a 2-D (or --dim 3) PCA
+projection here and writes it as JSON to stdout or the --out file, for
+plotting with any charting tool.
+
+  demo visualize -i kb --out projector/ --label space --label kind
+  demo visualize -i kb --format json > kb-map.json`,
+	RunE: runVisualize,
+}
+
+func init() {
+	visualizeCmd.Flags().StringVarP(&indexName, "index", "i", "default", "index name")
+	visualizeCmd.Flags().StringP("format", "f", "tsv", "tsv (TensorFlow Projector) or json (PCA projection)")
+	visualizeCmd.Flags().StringP("out", "o", "", "directory for tsv (default: current), file for json (default: stdout)")
+	visualizeCmd.Flags().Int("dim", 2, "dimensions of the json projection (2 or 3)")
+	visualizeCmd.Flags().StringSlice("label", nil, "document metadata fields to add as tsv label columns")
+
+	rootCmd.AddCommand(visualizeCmd)
+}
+
+func runVisualize(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	out, _ := cmd.Flags().GetString("out")
+	dim, _ := cmd.Flags().GetInt("dim")
+	labels, _ := cmd.Flags().GetStringSlice("label")
+	if format != "tsv" && format != "json" {
+		return fmt.Errorf("unknown format %q: use tsv or json", format)
+	}
+
+	manager, err := hnswindex.NewIndexManager(loadConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create index manager: %w", err)
+	}
+	defer manager.Close()
+
+	index, err := manager.GetIndex(indexName)
+	if err != nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	if format == "json" {
+		return writeProjection(index, dim, out)
+	}
+	if out == "" {
+		out = "."
+	}
+	return writeProjectorTSV(index, out, labels)
+}
+
+// writeProjection writes a PCA projection of the index as JSON
+func writeProjection(index *hnswindex.Index, dim int, out string) error {
+	projection, err := index.Project(dim)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(projection); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Projected %d chunks to %d dimensions (%.0f%% of the variance)\n",
+		len(projection.Chunks), projection.Dimension, projection.ExplainedVariance*100)
+	return nil
+}
+
+// writeProjectorTSV writes the embeddings and labels of every chunk in the
+// TensorFlow Projector format: vectors.tsv holds one embedding per line and
+// metadata.tsv a header and the labels of each, in the same order
+func writeProjectorTSV(index *hnswindex.Index, dir string, labels []string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	vectorsFile, err := os.Create(filepath.Join(dir, "vectors.tsv"))
+	if err != nil {
+		return err
+	}
+	defer vectorsFile.Close()
+	metadataFile, err := os.Create(filepath.Join(dir, "metadata.tsv"))
+	if err != nil {
+		return err
+	}
+	defer metadataFile.Close()
+
+	vectors := bufio.NewWriter(vectorsFile)
+	metadata := bufio.NewWriter(metadataFile)
+	header := append([]string{"title", "uri", "position", "text"}, labels...)
+	fmt.Fprintln(metadata, strings.Join(header, "\t"))
+
+	count := 0
+	for doc := range index.Documents() {
+		for chunk := range index.Chunks(doc.URI) {
+			if len(chunk.Embedding) == 0 {
+				continue
+			}
+			values := make([]string, len(chunk.Embedding))
+			for n, v := range chunk.Embedding {
+				values[n] = strconv.FormatFloat(float64(v), 'g', -1, 32)
+			}
+			fmt.Fprintln(vectors, strings.Join(values, "\t"))
+
+			row := []string{doc.Title, doc.URI, strconv.Itoa(chunk.Position), chunk.Preview(100)}
+			for _, label := range labels {
+				value := ""
+				if v, ok := doc.Metadata[label]; ok {
+					value = fmt.Sprint(v)
+				}
+				row = append(row, value)
+			}
+			for n := range row {
+				row[n] = tsvField(row[n])
+			}
+			fmt.Fprintln(metadata, strings.Join(row, "\t"))
+			count++
+		}
+	}
+
+	if err := vectors.Flush(); err != nil {
+		return err
+	}
+	if err := metadata.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d embeddings to %s and %s\n", count, vectorsFile.Name(), metadataFile.Name())
+	fmt.Println("Load both at https://projector.tensorflow.org (Load > Choose file)")
+	return nil
+}
+
+// tsvField replaces the tabs and line breaks a TSV field cannot hold
+func tsvField(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
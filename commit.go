@@ -0,0 +1,86 @@
+package hnswindex
+
+import (
+	"log/slog"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// commitWrites stores documents with their chunks in one transaction and
+// then applies the new chunks to the graph. The graph only ever refers to
+// committed chunks, and changes reach it in the order they were committed.
+// It returns the committed documents the graph could not take, by URI.
+func (i *indexImpl) commitWrites(writes []storage.DocumentWrite) (map[string]error, error) {
+	i.commitMu.Lock()
+	defer i.commitMu.Unlock()
+
+	if err := i.writable(); err != nil {
+		return nil, err
+	}
+	if err := i.manager.injectFault(FaultCommit, i.name); err != nil {
+		return nil, err
+	}
+	if err := i.manager.storage.WriteDocuments(writes); err != nil {
+		return nil, err
+	}
+	return i.applyWrites(writes), nil
+}
+
+// applyWrites swaps the graph nodes of each committed document, one
+// document at a time, so searches never see part of a document. Nodes of
+// kept chunks stay as they are. The caller holds commitMu.
+//
+// A document whose nodes cannot be swapped is taken out of the graph
+// entirely and its stored hash is cleared, so adding it again indexes it
+// instead of skipping it as unchanged. Such documents are returned with
+// their errors, by URI.
+func (i *indexImpl) applyWrites(writes []storage.DocumentWrite) map[string]error {
+	var failed map[string]error
+	for _, w := range writes {
+		kept := make(map[uint64]bool, len(w.KeptHNSWIds))
+		for _, id := range w.KeptHNSWIds {
+			kept[id] = true
+		}
+		var vectors [][]float32
+		var ids []uint64
+		for _, c := range w.Chunks {
+			if !kept[c.HNSWId] {
+				vectors = append(vectors, i.graphVector(c.Embedding))
+				ids = append(ids, c.HNSWId)
+			}
+		}
+		err := i.manager.injectFault(FaultGraphAdd, i.name)
+		if err == nil {
+			err = i.hnswIndex.Replace(w.ReplacedHNSWIds, vectors, ids)
+		}
+		if err == nil {
+			continue
+		}
+
+		slog.Error("Failed to add committed chunks to HNSW index",
+			"index", i.name,
+			"uri", w.Document.URI,
+			"error", err,
+		)
+		remove := append(append([]uint64{}, w.ReplacedHNSWIds...), w.KeptHNSWIds...)
+		if rerr := i.hnswIndex.Replace(remove, nil, nil); rerr != nil {
+			slog.Error("Failed to remove document from HNSW index",
+				"index", i.name,
+				"uri", w.Document.URI,
+				"error", rerr,
+			)
+		}
+		if herr := i.manager.storage.ClearDocumentHash(i.name, w.Document.URI); herr != nil {
+			slog.Error("Failed to clear document hash",
+				"index", i.name,
+				"uri", w.Document.URI,
+				"error", herr,
+			)
+		}
+		if failed == nil {
+			failed = make(map[string]error)
+		}
+		failed[w.Document.URI] = err
+	}
+	return failed
+}
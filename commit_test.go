@@ -0,0 +1,93 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_UpdateReplacesGraphNodes(t *testing.T) {
+	manager := newMockManager(t, nil)
+	manager.getImpl().embedder = &poisonEmbedder{NewMockEmbedder(768)}
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	for _, content := range []string{"first version", "second version"} {
+		_, err = index.AddDocumentBatchWithOptions(context.Background(),
+			[]Document{{URI: "doc1", Content: content}}, nil, AddOptions{ForceUpdate: true})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, index.getImpl().hnswIndex.Size())
+
+	// A failed embedding keeps the previous version
+	result, err := index.AddDocumentBatch(context.Background(),
+		[]Document{{URI: "doc1", Content: "POISON version"}}, nil)
+	require.NoError(t, err)
+	assert.Len(t, result.FailedURIs, 1)
+
+	doc, err := index.GetDocument("doc1")
+	require.NoError(t, err)
+	assert.Equal(t, "second version", doc.Content)
+	assert.Equal(t, 1, index.getImpl().hnswIndex.Size())
+}
+
+func TestIndex_GraphFailureIsRetried(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	add := func(content string) *BatchResult {
+		result, err := index.AddDocumentBatch(context.Background(),
+			[]Document{{URI: "doc1", Content: content}}, nil)
+		require.NoError(t, err)
+		return result
+	}
+	add("first version")
+
+	// The update is stored but the graph refuses it: the document is
+	// reported as failed and taken out of the graph
+	manager.SetFaultInjector(&failOnce{point: FaultGraphAdd})
+	result := add("second version")
+	assert.Contains(t, result.FailedURIs, "doc1")
+	assert.Zero(t, result.ProcessedChunks)
+	assert.Zero(t, index.getImpl().hnswIndex.Size())
+
+	// Adding it again indexes it instead of skipping it as unchanged
+	result = add("second version")
+	assert.Empty(t, result.FailedURIs)
+	assert.Zero(t, result.UnchangedDocuments)
+	assert.Equal(t, 1, index.getImpl().hnswIndex.Size())
+	results, err := index.Search("second version", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doc1"}, resultURIs(results))
+}
+
+func TestIndex_SearchDuringWritesSeesWholeDocuments(t *testing.T) {
+	index, err := newMockManager(t, nil).CreateIndex("kb")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 50; n++ {
+			_, err := index.AddDocumentBatch(context.Background(), []Document{
+				{URI: fmt.Sprintf("doc%d", n%5), Content: fmt.Sprintf("version %d", n)},
+			}, nil)
+			assert.NoError(t, err)
+		}
+	}()
+
+	for n := 0; n < 50; n++ {
+		results, err := index.Search("version", 10)
+		require.NoError(t, err)
+		for _, r := range results {
+			assert.Equal(t, r.Document.Content, r.ChunkText)
+		}
+	}
+	wg.Wait()
+	assert.Equal(t, 5, index.getImpl().hnswIndex.Size())
+}
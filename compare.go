@@ -0,0 +1,195 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ComparisonReport is a side-by-side comparison of two indexes over a query set.
+// The indexes may come from different IndexManagers, which is how different
+// chunk sizes, embedding models, or HNSW parameters are compared.
+type ComparisonReport struct {
+	IndexA  string            `json:"index_a"`
+	IndexB  string            `json:"index_b"`
+	Limit   int               `json:"limit"`
+	Queries []QueryComparison `json:"queries"`
+	Summary ComparisonSummary `json:"summary"`
+}
+
+// QueryComparison holds the results of one query against both indexes
+type QueryComparison struct {
+	Query    string           `json:"query"`
+	A        []ComparedResult `json:"a"`
+	B        []ComparedResult `json:"b"`
+	Overlap  int              `json:"overlap"` // Documents present in both result lists
+	Jaccard  float64          `json:"jaccard"` // Overlap relative to the union of documents
+	OnlyA    []string         `json:"only_a,omitempty"`
+	OnlyB    []string         `json:"only_b,omitempty"`
+	SameTop  bool             `json:"same_top"` // Both indexes rank the same document first
+	LatencyA time.Duration    `json:"latency_a"`
+	LatencyB time.Duration    `json:"latency_b"`
+	ErrorA   string           `json:"error_a,omitempty"`
+	ErrorB   string           `json:"error_b,omitempty"`
+}
+
+// ComparedResult is a condensed search result used in comparisons
+type ComparedResult struct {
+	URI     string  `json:"uri"`
+	Title   string  `json:"title"`
+	ChunkID string  `json:"chunk_id"`
+	Score   float64 `json:"score"`
+}
+
+// ComparisonSummary aggregates metrics over all compared queries
+type ComparisonSummary struct {
+	Queries       int           `json:"queries"`
+	MeanJaccard   float64       `json:"mean_jaccard"`
+	MeanOverlap   float64       `json:"mean_overlap"`
+	SameTopCount  int           `json:"same_top_count"`
+	MeanTopScoreA float64       `json:"mean_top_score_a"`
+	MeanTopScoreB float64       `json:"mean_top_score_b"`
+	MeanLatencyA  time.Duration `json:"mean_latency_a"`
+	MeanLatencyB  time.Duration `json:"mean_latency_b"`
+	ZeroResultsA  int           `json:"zero_results_a"`
+	ZeroResultsB  int           `json:"zero_results_b"`
+}
+
+// CompareIndexes runs every query against both indexes and reports how the
+// result lists differ. Search errors are recorded per query rather than
+// aborting the comparison; cancellation of ctx stops it early.
+func CompareIndexes(ctx context.Context, a, b *Index, queries []string, limit int) (*ComparisonReport, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("both indexes are required")
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+
+	report := &ComparisonReport{
+		IndexA: a.Name(),
+		IndexB: b.Name(),
+		Limit:  limit,
+	}
+
+	var jaccardSum, overlapSum, topScoreA, topScoreB float64
+	var latencyA, latencyB time.Duration
+
+	for _, query := range queries {
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		default:
+		}
+
+		qc := QueryComparison{Query: query}
+
+		start := time.Now()
+		resultsA, err := a.Search(query, limit)
+		qc.LatencyA = time.Since(start)
+		if err != nil {
+			qc.ErrorA = err.Error()
+		}
+
+		start = time.Now()
+		resultsB, err := b.Search(query, limit)
+		qc.LatencyB = time.Since(start)
+		if err != nil {
+			qc.ErrorB = err.Error()
+		}
+
+		qc.A = condenseResults(resultsA)
+		qc.B = condenseResults(resultsB)
+		compareResultLists(&qc)
+
+		report.Queries = append(report.Queries, qc)
+
+		jaccardSum += qc.Jaccard
+		overlapSum += float64(qc.Overlap)
+		latencyA += qc.LatencyA
+		latencyB += qc.LatencyB
+		if qc.SameTop {
+			report.Summary.SameTopCount++
+		}
+		if len(qc.A) == 0 {
+			report.Summary.ZeroResultsA++
+		} else {
+			topScoreA += qc.A[0].Score
+		}
+		if len(qc.B) == 0 {
+			report.Summary.ZeroResultsB++
+		} else {
+			topScoreB += qc.B[0].Score
+		}
+	}
+
+	n := len(report.Queries)
+	report.Summary.Queries = n
+	if n > 0 {
+		report.Summary.MeanJaccard = jaccardSum / float64(n)
+		report.Summary.MeanOverlap = overlapSum / float64(n)
+		report.Summary.MeanLatencyA = latencyA / time.Duration(n)
+		report.Summary.MeanLatencyB = latencyB / time.Duration(n)
+	}
+	if withResults := n - report.Summary.ZeroResultsA; withResults > 0 {
+		report.Summary.MeanTopScoreA = topScoreA / float64(withResults)
+	}
+	if withResults := n - report.Summary.ZeroResultsB; withResults > 0 {
+		report.Summary.MeanTopScoreB = topScoreB / float64(withResults)
+	}
+
+	return report, nil
+}
+
+// condenseResults strips document content from search results
+func condenseResults(results []SearchResult) []ComparedResult {
+	condensed := make([]ComparedResult, 0, len(results))
+	for _, r := range results {
+		condensed = append(condensed, ComparedResult{
+			URI:     r.Document.URI,
+			Title:   r.Document.Title,
+			ChunkID: r.ChunkID,
+			Score:   r.Score,
+		})
+	}
+	return condensed
+}
+
+// compareResultLists fills the overlap metrics of a query comparison.
+// Results are compared by document URI since chunk IDs differ between
+// indexes built with different chunking settings.
+func compareResultLists(qc *QueryComparison) {
+	inA := make(map[string]bool)
+	for _, r := range qc.A {
+		inA[r.URI] = true
+	}
+	inB := make(map[string]bool)
+	for _, r := range qc.B {
+		inB[r.URI] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range qc.A {
+		if seen[r.URI] {
+			continue
+		}
+		seen[r.URI] = true
+		if inB[r.URI] {
+			qc.Overlap++
+		} else {
+			qc.OnlyA = append(qc.OnlyA, r.URI)
+		}
+	}
+	for _, r := range qc.B {
+		if seen[r.URI] {
+			continue
+		}
+		seen[r.URI] = true
+		qc.OnlyB = append(qc.OnlyB, r.URI)
+	}
+
+	if len(seen) > 0 {
+		qc.Jaccard = float64(qc.Overlap) / float64(len(seen))
+	}
+	qc.SameTop = len(qc.A) > 0 && len(qc.B) > 0 && qc.A[0].URI == qc.B[0].URI
+}
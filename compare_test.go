@@ -0,0 +1,61 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareResultLists(t *testing.T) {
+	qc := QueryComparison{
+		A: []ComparedResult{{URI: "doc1"}, {URI: "doc2"}, {URI: "doc2"}},
+		B: []ComparedResult{{URI: "doc1"}, {URI: "doc3"}},
+	}
+	compareResultLists(&qc)
+
+	assert.Equal(t, 1, qc.Overlap)
+	assert.Equal(t, []string{"doc2"}, qc.OnlyA)
+	assert.Equal(t, []string{"doc3"}, qc.OnlyB)
+	assert.InDelta(t, 1.0/3.0, qc.Jaccard, 1e-9)
+	assert.True(t, qc.SameTop)
+}
+
+func TestCompareIndexes(t *testing.T) {
+	cfgA := NewConfig()
+	cfgA.ChunkSize = 50
+	cfgA.ChunkOverlap = 10
+	managerA := newMockManager(t, cfgA)
+	managerB := newMockManager(t, nil)
+
+	docs := []Document{
+		{URI: "doc1", Title: "Go", Content: "Go is a statically typed language"},
+		{URI: "doc2", Title: "Python", Content: "Python is dynamically typed"},
+	}
+
+	indexA, err := managerA.CreateIndex("a")
+	require.NoError(t, err)
+	_, err = indexA.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	indexB, err := managerB.CreateIndex("b")
+	require.NoError(t, err)
+	_, err = indexB.AddDocumentBatch(context.Background(), docs[:1], nil)
+	require.NoError(t, err)
+
+	report, err := CompareIndexes(context.Background(), indexA, indexB, []string{"typed", "language"}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "a", report.IndexA)
+	assert.Equal(t, 2, report.Summary.Queries)
+	require.Len(t, report.Queries, 2)
+	for _, qc := range report.Queries {
+		assert.Len(t, qc.A, 2)
+		assert.Len(t, qc.B, 1)
+		assert.Equal(t, 1, qc.Overlap)
+		assert.Equal(t, []string{"doc2"}, qc.OnlyA)
+	}
+
+	_, err = CompareIndexes(context.Background(), indexA, indexB, nil, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package hnswindex
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// cursorSettingPrefix prefixes the index settings holding sync cursors
+const cursorSettingPrefix = "cursor:"
+
+// SyncCursor returns the cursor a connector saved for source with
+// SetSyncCursor, or "" if none was saved. Cursors live in the index's own
+// metadata, so they are included in snapshots and removed with the index.
+func (i *Index) SyncCursor(source string) (string, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.SyncCursor(source)
+	}
+	return "", fmt.Errorf("implementation not available")
+}
+
+// SetSyncCursor saves the position a connector has synced source up to,
+// such as a change token or a timestamp, and records the time as the
+// index's LastSyncAt. An empty cursor removes it.
+func (i *Index) SetSyncCursor(source, cursor string) error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.SetSyncCursor(source, cursor)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// SyncCursor implementation
+func (i *indexImpl) SyncCursor(source string) (string, error) {
+	if source == "" {
+		return "", errors.New("empty cursor source")
+	}
+	data, err := i.manager.storage.GetIndexSetting(i.name, cursorSettingPrefix+source)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SetSyncCursor implementation
+func (i *indexImpl) SetSyncCursor(source, cursor string) error {
+	if source == "" {
+		return errors.New("empty cursor source")
+	}
+	if cursor == "" {
+		return i.manager.storage.SetIndexSetting(i.name, cursorSettingPrefix+source, nil)
+	}
+	if err := i.manager.storage.SetIndexSetting(i.name, cursorSettingPrefix+source, []byte(cursor)); err != nil {
+		return err
+	}
+	return i.manager.storage.UpdateIndexMetadata(i.name, func(metadata *storage.IndexMetadata) {
+		metadata.LastSyncAt = time.Now().UTC()
+	})
+}
@@ -0,0 +1,41 @@
+package hnswindex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncCursor(t *testing.T) {
+	manager := newMockManager(t, nil)
+	defer manager.Close()
+
+	index, err := manager.CreateIndex("docs")
+	require.NoError(t, err)
+
+	cursor, err := index.SyncCursor("confluence/ENG")
+	require.NoError(t, err)
+	assert.Empty(t, cursor)
+
+	require.NoError(t, index.SetSyncCursor("confluence/ENG", "2024-05-01T10:00:00Z"))
+	require.NoError(t, index.SetSyncCursor("confluence/OPS", "2024-04-01T10:00:00Z"))
+
+	cursor, err = index.SyncCursor("confluence/ENG")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-01T10:00:00Z", cursor)
+
+	// Cursors are per source
+	cursor, err = index.SyncCursor("confluence/OPS")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-04-01T10:00:00Z", cursor)
+
+	require.NoError(t, index.SetSyncCursor("confluence/ENG", ""))
+	cursor, err = index.SyncCursor("confluence/ENG")
+	require.NoError(t, err)
+	assert.Empty(t, cursor)
+
+	_, err = index.SyncCursor("")
+	assert.Error(t, err)
+	assert.Error(t, index.SetSyncCursor("", "x"))
+}
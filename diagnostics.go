@@ -0,0 +1,102 @@
+package hnswindex
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Diagnostics is a point-in-time snapshot of a manager and its indexes,
+// cheap enough to collect on a running server
+type Diagnostics struct {
+	DataPath           string             `json:"data_path"`
+	DatabaseBytes      int64              `json:"database_bytes"`
+	EmbedModel         string             `json:"embed_model"`
+	EmbeddingCacheSize int                `json:"embedding_cache_size"`
+	BlobCount          int                `json:"blob_count"`
+	BlobBytes          int64              `json:"blob_bytes"`             // Stored (possibly compressed) size
+	EmbedTuning        *EmbedTuning       `json:"embed_tuning,omitempty"` // With AdaptiveEmbedding only
+	Indexes            []IndexDiagnostics `json:"indexes"`
+}
+
+// IndexDiagnostics describes the in-memory and stored state of one index
+type IndexDiagnostics struct {
+	Name        string      `json:"name"`
+	Documents   int         `json:"documents"`
+	Chunks      int         `json:"chunks"`
+	GraphNodes  int         `json:"graph_nodes"` // Vectors in the HNSW graph
+	Unsaved     bool        `json:"unsaved"`     // Graph has changes not yet written to disk
+	LastUpdated time.Time   `json:"last_updated,omitzero"`
+	CreatedAt   time.Time   `json:"created_at,omitzero"`
+	LastSavedAt time.Time   `json:"last_saved_at,omitzero"`
+	LastSyncAt  time.Time   `json:"last_sync_at,omitzero"`
+	Config      IndexConfig `json:"config"` // Effective pipeline configuration
+}
+
+// Diagnostics collects runtime diagnostics for all loaded indexes.
+// Unlike Index.Stats it does not scan chunks.
+func (im *IndexManager) Diagnostics() (*Diagnostics, error) {
+	if impl := im.getImpl(); impl != nil {
+		return impl.Diagnostics()
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Diagnostics implementation
+func (im *indexManagerImpl) Diagnostics() (*Diagnostics, error) {
+	diag := &Diagnostics{
+		DataPath:   im.config.DataPath,
+		EmbedModel: im.config.EmbedModel,
+	}
+	if im.tuner != nil {
+		tuning := im.tuner.state()
+		diag.EmbedTuning = &tuning
+	}
+
+	if info, err := os.Stat(filepath.Join(im.config.DataPath, "indexes.db")); err == nil {
+		diag.DatabaseBytes = info.Size()
+	}
+	for _, name := range im.storage.IndexFiles() {
+		if info, err := os.Stat(im.storage.IndexDBPath(name)); err == nil {
+			diag.DatabaseBytes += info.Size()
+		}
+	}
+
+	var err error
+	if diag.EmbeddingCacheSize, err = im.storage.CachedEmbeddingCount(); err != nil {
+		return nil, fmt.Errorf("failed to count cached embeddings: %w", err)
+	}
+	if diag.BlobCount, diag.BlobBytes, err = im.storage.BlobStats(); err != nil {
+		return nil, fmt.Errorf("failed to read blob stats: %w", err)
+	}
+
+	for _, idx := range im.indexes.all() {
+		metadata, err := im.storage.GetIndexMetadata(idx.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metadata of '%s': %w", idx.name, err)
+		}
+		uris, err := im.storage.ListDocuments(idx.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents of '%s': %w", idx.name, err)
+		}
+
+		graphNodes := idx.hnswIndex.Size()
+		unsaved := idx.hnswIndex.IsModified()
+
+		diag.Indexes = append(diag.Indexes, IndexDiagnostics{
+			Name:        idx.name,
+			Documents:   len(uris),
+			Chunks:      metadata.ChunkCount,
+			GraphNodes:  graphNodes,
+			Unsaved:     unsaved,
+			LastUpdated: metadata.LastUpdated,
+			CreatedAt:   idx.createdAt(metadata),
+			LastSavedAt: metadata.LastSavedAt,
+			LastSyncAt:  metadata.LastSyncAt,
+			Config:      idx.effectiveConfig(),
+		})
+	}
+
+	return diag, nil
+}
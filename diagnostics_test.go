@@ -0,0 +1,39 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnostics(t *testing.T) {
+	manager := newMockManager(t, nil)
+
+	index, err := manager.CreateIndex("b")
+	require.NoError(t, err)
+	_, err = manager.CreateIndex("a")
+	require.NoError(t, err)
+
+	_, err = index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "doc1", Title: "One", Content: "First document content"},
+		{URI: "doc2", Title: "Two", Content: "Second document content"},
+	}, nil)
+	require.NoError(t, err)
+
+	diag, err := manager.Diagnostics()
+	require.NoError(t, err)
+
+	assert.Greater(t, diag.DatabaseBytes, int64(0))
+	require.Len(t, diag.Indexes, 2)
+	assert.Equal(t, "a", diag.Indexes[0].Name)
+	assert.Equal(t, 0, diag.Indexes[0].Documents)
+
+	b := diag.Indexes[1]
+	assert.Equal(t, "b", b.Name)
+	assert.Equal(t, 2, b.Documents)
+	assert.Equal(t, b.Chunks, b.GraphNodes)
+	assert.Greater(t, b.Chunks, 0)
+	assert.Equal(t, 768, b.Config.Embedder.Dimension)
+}
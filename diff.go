@@ -0,0 +1,165 @@
+package hnswindex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// DiffOptions configures IndexManager.DiffIndexesWithOptions
+type DiffOptions struct {
+	// MapURI converts URIs of index a to their expected form in index b,
+	// e.g. to validate a URI scheme migration
+	MapURI func(uri string) string
+
+	// Target is the manager holding index b, e.g. one opened on another
+	// data directory. Defaults to the same manager.
+	Target *IndexManager
+}
+
+// IndexDiff is the difference between two indexes. URIs are reported as
+// they appear in index b, after MapURI.
+type IndexDiff struct {
+	OnlyInA         []string // Documents missing from b
+	OnlyInB         []string // Documents missing from a
+	ContentChanged  []string // Same URI, different title or content
+	MetadataChanged []string // Same title and content, different metadata
+	Identical       int      // Documents equal in both indexes
+}
+
+// Equal reports whether the indexes hold the same documents
+func (d *IndexDiff) Equal() bool {
+	return len(d.OnlyInA) == 0 && len(d.OnlyInB) == 0 &&
+		len(d.ContentChanged) == 0 && len(d.MetadataChanged) == 0
+}
+
+// DiffIndexes compares the documents of two indexes by URI and content hash
+func (im *IndexManager) DiffIndexes(a, b string) (*IndexDiff, error) {
+	return im.DiffIndexesWithOptions(a, b, DiffOptions{})
+}
+
+// DiffIndexesWithOptions compares two indexes with URI mapping or an index
+// in another manager
+func (im *IndexManager) DiffIndexesWithOptions(a, b string, opts DiffOptions) (*IndexDiff, error) {
+	impl := im.getImpl()
+	if impl == nil {
+		return nil, fmt.Errorf("implementation not available")
+	}
+
+	target := impl
+	if opts.Target != nil {
+		if target = opts.Target.getImpl(); target == nil {
+			return nil, fmt.Errorf("implementation not available")
+		}
+	}
+
+	return diffIndexes(impl, a, target, b, opts.MapURI)
+}
+
+// documentDigest summarizes a document for comparison
+type documentDigest struct {
+	content  string
+	metadata string
+}
+
+// diffIndexes compares index a of one manager with index b of another
+func diffIndexes(ma *indexManagerImpl, a string, mb *indexManagerImpl, b string, mapURI func(string) string) (*IndexDiff, error) {
+	for _, check := range []struct {
+		manager *indexManagerImpl
+		name    string
+	}{{ma, a}, {mb, b}} {
+		if _, exists := check.manager.indexes.get(check.name); !exists {
+			return nil, fmt.Errorf("index '%s' not found", check.name)
+		}
+	}
+
+	digestsA := make(map[string]documentDigest)
+	err := forEachStoredDocument(ma.storage, a, func(doc *storage.Document) error {
+		uri := doc.URI
+		if mapURI != nil {
+			uri = mapURI(uri)
+		}
+		digestsA[uri] = digestDocument(doc)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index '%s': %w", a, err)
+	}
+
+	diff := &IndexDiff{}
+	err = forEachStoredDocument(mb.storage, b, func(doc *storage.Document) error {
+		digestA, ok := digestsA[doc.URI]
+		if !ok {
+			diff.OnlyInB = append(diff.OnlyInB, doc.URI)
+			return nil
+		}
+		delete(digestsA, doc.URI)
+
+		digestB := digestDocument(doc)
+		switch {
+		case digestA.content != digestB.content:
+			diff.ContentChanged = append(diff.ContentChanged, doc.URI)
+		case digestA.metadata != digestB.metadata:
+			diff.MetadataChanged = append(diff.MetadataChanged, doc.URI)
+		default:
+			diff.Identical++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index '%s': %w", b, err)
+	}
+
+	for uri := range digestsA {
+		diff.OnlyInA = append(diff.OnlyInA, uri)
+	}
+	sort.Strings(diff.OnlyInA)
+
+	return diff, nil
+}
+
+// digestDocument hashes the title and content, and the canonical JSON of
+// the metadata, of a stored document. The stored document hash is not used
+// because it includes the URI.
+func digestDocument(doc *storage.Document) documentDigest {
+	h := sha256.New()
+	h.Write([]byte(doc.Title))
+	h.Write([]byte{0})
+	h.Write([]byte(doc.Content))
+
+	// encoding/json sorts map keys, so equal metadata encodes identically
+	var metadata []byte
+	if len(doc.Metadata) > 0 {
+		metadata, _ = json.Marshal(doc.Metadata)
+	}
+	sum := sha256.Sum256(metadata)
+
+	return documentDigest{
+		content:  hex.EncodeToString(h.Sum(nil)),
+		metadata: hex.EncodeToString(sum[:]),
+	}
+}
+
+// forEachStoredDocument calls fn for every stored document in URI order
+func forEachStoredDocument(s *storage.Storage, indexName string, fn func(*storage.Document) error) error {
+	after := ""
+	for {
+		page, err := s.ListDocumentsPage(indexName, after, documentPageSize)
+		if err != nil {
+			return err
+		}
+		for idx := range page {
+			if err := fn(&page[idx]); err != nil {
+				return err
+			}
+		}
+		if len(page) < documentPageSize {
+			return nil
+		}
+		after = page[len(page)-1].URI
+	}
+}
@@ -0,0 +1,82 @@
+package hnswindex
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexManager_DiffIndexes(t *testing.T) {
+	manager := newMockManager(t, nil)
+	ctx := context.Background()
+
+	a, err := manager.CreateIndex("old")
+	require.NoError(t, err)
+	b, err := manager.CreateIndex("new")
+	require.NoError(t, err)
+
+	_, err = a.AddDocumentBatch(ctx, []Document{
+		{URI: "file:///kb/same.md", Title: "Same", Content: "unchanged", Metadata: map[string]interface{}{"team": "x"}},
+		{URI: "file:///kb/edited.md", Title: "Edited", Content: "before"},
+		{URI: "file:///kb/meta.md", Title: "Meta", Content: "meta", Metadata: map[string]interface{}{"team": "x"}},
+		{URI: "file:///kb/removed.md", Title: "Removed", Content: "gone"},
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = b.AddDocumentBatch(ctx, []Document{
+		{URI: "kb://same.md", Title: "Same", Content: "unchanged", Metadata: map[string]interface{}{"team": "x"}},
+		{URI: "kb://edited.md", Title: "Edited", Content: "after"},
+		{URI: "kb://meta.md", Title: "Meta", Content: "meta", Metadata: map[string]interface{}{"team": "y"}},
+		{URI: "kb://added.md", Title: "Added", Content: "new"},
+	}, nil)
+	require.NoError(t, err)
+
+	// Without mapping every URI differs
+	diff, err := manager.DiffIndexes("old", "new")
+	require.NoError(t, err)
+	assert.Len(t, diff.OnlyInA, 4)
+	assert.Len(t, diff.OnlyInB, 4)
+	assert.False(t, diff.Equal())
+
+	diff, err = manager.DiffIndexesWithOptions("old", "new", DiffOptions{
+		MapURI: func(uri string) string {
+			return "kb://" + strings.TrimPrefix(uri, "file:///kb/")
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kb://removed.md"}, diff.OnlyInA)
+	assert.Equal(t, []string{"kb://added.md"}, diff.OnlyInB)
+	assert.Equal(t, []string{"kb://edited.md"}, diff.ContentChanged)
+	assert.Equal(t, []string{"kb://meta.md"}, diff.MetadataChanged)
+	assert.Equal(t, 1, diff.Identical)
+
+	diff, err = manager.DiffIndexes("old", "old")
+	require.NoError(t, err)
+	assert.True(t, diff.Equal())
+	assert.Equal(t, 4, diff.Identical)
+
+	_, err = manager.DiffIndexes("old", "missing")
+	assert.Error(t, err)
+}
+
+func TestIndexManager_DiffIndexes_AcrossManagers(t *testing.T) {
+	source := newMockManager(t, nil)
+	target := newMockManager(t, nil)
+	ctx := context.Background()
+
+	docs := []Document{{URI: "doc://1", Title: "One", Content: "first"}}
+	for _, m := range []*IndexManager{source, target} {
+		index, err := m.CreateIndex("kb")
+		require.NoError(t, err)
+		_, err = index.AddDocumentBatch(ctx, docs, nil)
+		require.NoError(t, err)
+	}
+
+	diff, err := source.DiffIndexesWithOptions("kb", "kb", DiffOptions{Target: target})
+	require.NoError(t, err)
+	assert.True(t, diff.Equal())
+	assert.Equal(t, 1, diff.Identical)
+}
@@ -0,0 +1,90 @@
+package hnswindex
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// docChunksMigratedKey is set on an index once all its document chunk
+	// mappings use sub-buckets
+	docChunksMigratedKey = "doc_chunks_migrated"
+
+	docChunksMigrationStep = 500 // Documents examined per transaction
+)
+
+// docChunksMigration converts the document chunk mappings of older
+// databases from JSON arrays to sub-buckets in the background, a few
+// hundred documents per transaction so writers are never held up for long
+type docChunksMigration struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// startDocChunksMigration migrates every index that is not migrated yet
+func (im *indexManagerImpl) startDocChunksMigration() *docChunksMigration {
+	m := &docChunksMigration{stop: make(chan struct{})}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for _, idx := range im.indexes.all() {
+			if !m.migrate(im, idx.name) {
+				return
+			}
+		}
+	}()
+	return m
+}
+
+// migrate converts the mappings of one index and reports whether to go on
+// with the next
+func (m *docChunksMigration) migrate(im *indexManagerImpl, name string) bool {
+	if done, err := im.storage.GetIndexSetting(name, docChunksMigratedKey); err != nil || done != nil {
+		return true
+	}
+
+	start := time.Now()
+	after := ""
+	for {
+		select {
+		case <-m.stop:
+			return false
+		default:
+		}
+
+		next, err := im.storage.MigrateDocChunks(name, after, docChunksMigrationStep)
+		if err != nil {
+			// The index may have been deleted meanwhile; retried next start
+			slog.Warn("Failed to migrate document chunk mappings",
+				"index", name,
+				"error", err,
+			)
+			return true
+		}
+		if next == "" {
+			break
+		}
+		after = next
+	}
+
+	if err := im.storage.SetIndexSetting(name, docChunksMigratedKey, []byte("true")); err != nil {
+		slog.Warn("Failed to record document chunk migration",
+			"index", name,
+			"error", err,
+		)
+		return true
+	}
+	slog.Debug("Document chunk mappings migrated",
+		"index", name,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return true
+}
+
+// close stops the migration and waits for its current step to finish
+func (m *docChunksMigration) close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	m.wg.Wait()
+}
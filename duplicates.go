@@ -0,0 +1,162 @@
+package hnswindex
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+const (
+	// DefaultDuplicateThreshold is a chunk similarity above which two
+	// chunks are near-identical text for typical embedding models
+	DefaultDuplicateThreshold = 0.95
+
+	// DuplicateMinOverlap is the share of a document's chunks that must
+	// match chunks of another document for FindDuplicates to report them
+	DuplicateMinOverlap = 0.5
+
+	duplicateNeighbors = 20 // Graph neighbors checked per chunk
+)
+
+// DuplicatePair reports two documents that are likely duplicates or copy
+// large parts of each other
+type DuplicatePair struct {
+	A string `json:"a"` // URI ordered first
+	B string `json:"b"`
+
+	// Overlap is the share of chunks with a match in the other document,
+	// for whichever of the two is covered more: 1 means one document is
+	// entirely contained in the other
+	Overlap      float64 `json:"overlap"`
+	Similarity   float64 `json:"similarity"`    // Mean similarity of the matching chunks
+	SharedChunks int     `json:"shared_chunks"` // Matching chunk pairs
+	ChunksA      int     `json:"chunks_a"`
+	ChunksB      int     `json:"chunks_b"`
+}
+
+// duplicateCandidate accumulates the chunk matches between two documents
+type duplicateCandidate struct {
+	matchedA   map[string]bool
+	matchedB   map[string]bool
+	pairs      map[[2]string]bool
+	similarity float64
+}
+
+// FindDuplicates reports pairs of documents whose chunks closely match:
+// copy-pasted pages, forks of the same runbook, or pages that embed
+// another. Two chunks match when their similarity is at least threshold;
+// documents are reported when at least DuplicateMinOverlap of either one's
+// chunks match the other. Pairs are ordered by overlap, highest first.
+func (i *Index) FindDuplicates(threshold float64) ([]DuplicatePair, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.FindDuplicates(threshold)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// FindDuplicates implementation
+func (i *indexImpl) FindDuplicates(threshold float64) ([]DuplicatePair, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, errors.New("threshold must be in (0, 1]")
+	}
+
+	// Map graph nodes to their chunks first, so neighbors can be resolved
+	type chunkRef struct {
+		id  string
+		uri string
+	}
+	refs := make(map[uint64]chunkRef)
+	chunkCounts := make(map[string]int)
+	err := i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		refs[chunk.HNSWId] = chunkRef{id: chunk.ID, uri: chunk.DocumentURI}
+		chunkCounts[chunk.DocumentURI]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dimension := i.embeddingDimension()
+	candidates := make(map[[2]string]*duplicateCandidate)
+	err = i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		if len(chunk.Embedding) != dimension {
+			return nil
+		}
+		hits, err := i.hnswIndex.Search(i.graphVector(chunk.Embedding), duplicateNeighbors+1)
+		if err != nil {
+			return err
+		}
+		for _, hit := range hits {
+			ref, ok := refs[hit.ID]
+			if !ok || ref.uri == chunk.DocumentURI {
+				continue
+			}
+			similarity := i.similarity(hit.Distance)
+			if similarity < threshold {
+				continue
+			}
+
+			a, b := chunkRef{id: chunk.ID, uri: chunk.DocumentURI}, ref
+			if b.uri < a.uri {
+				a, b = b, a
+			}
+			key := [2]string{a.uri, b.uri}
+			c := candidates[key]
+			if c == nil {
+				c = &duplicateCandidate{
+					matchedA: make(map[string]bool),
+					matchedB: make(map[string]bool),
+					pairs:    make(map[[2]string]bool),
+				}
+				candidates[key] = c
+			}
+			// Each chunk pair is usually found from both sides
+			if pair := [2]string{a.id, b.id}; !c.pairs[pair] {
+				c.pairs[pair] = true
+				c.similarity += similarity
+			}
+			c.matchedA[a.id] = true
+			c.matchedB[b.id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []DuplicatePair
+	for key, c := range candidates {
+		pair := DuplicatePair{
+			A:            key[0],
+			B:            key[1],
+			SharedChunks: len(c.pairs),
+			Similarity:   c.similarity / float64(len(c.pairs)),
+			ChunksA:      chunkCounts[key[0]],
+			ChunksB:      chunkCounts[key[1]],
+		}
+		pair.Overlap = max(
+			float64(len(c.matchedA))/float64(pair.ChunksA),
+			float64(len(c.matchedB))/float64(pair.ChunksB),
+		)
+		if pair.Overlap >= DuplicateMinOverlap {
+			duplicates = append(duplicates, pair)
+		}
+	}
+
+	sort.Slice(duplicates, func(a, b int) bool {
+		da, db := duplicates[a], duplicates[b]
+		if da.Overlap != db.Overlap {
+			return da.Overlap > db.Overlap
+		}
+		if da.Similarity != db.Similarity {
+			return da.Similarity > db.Similarity
+		}
+		if da.A != db.A {
+			return da.A < db.A
+		}
+		return da.B < db.B
+	})
+	return duplicates, nil
+}
@@ -0,0 +1,40 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_FindDuplicates(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("dupes")
+	require.NoError(t, err)
+
+	runbook := "restart the payment service and drain the queue"
+	_, err = index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "eng/runbook", Title: "Payments runbook", Content: runbook},
+		{URI: "ops/runbook-copy", Title: "Copy of Payments runbook", Content: runbook},
+		{URI: "hr/holidays", Title: "Holidays", Content: "office holiday calendar"},
+	}, nil)
+	require.NoError(t, err)
+
+	duplicates, err := index.FindDuplicates(DefaultDuplicateThreshold)
+	require.NoError(t, err)
+	require.Len(t, duplicates, 1)
+	d := duplicates[0]
+	assert.Equal(t, "eng/runbook", d.A)
+	assert.Equal(t, "ops/runbook-copy", d.B)
+	assert.InDelta(t, 1.0, d.Overlap, 1e-9)
+	assert.InDelta(t, 1.0, d.Similarity, 1e-5)
+	assert.Equal(t, 1, d.SharedChunks)
+	assert.Equal(t, 1, d.ChunksA)
+	assert.Equal(t, 1, d.ChunksB)
+
+	_, err = index.FindDuplicates(0)
+	assert.Error(t, err)
+	_, err = index.FindDuplicates(1.5)
+	assert.Error(t, err)
+}
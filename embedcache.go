@@ -0,0 +1,153 @@
+package hnswindex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// DefaultEmbeddingCacheEntries is the number of embeddings NewConfig lets
+// the embedding cache keep, about 300 MB of 768-dimensional vectors
+const DefaultEmbeddingCacheEntries = 100000
+
+// ClearEmbeddingCache removes all embeddings from the shared embedding cache.
+// Indexed chunks keep their embeddings; only future ingestion is affected.
+func (im *IndexManager) ClearEmbeddingCache() error {
+	if impl := im.getImpl(); impl != nil {
+		return impl.storage.ClearCachedEmbeddings()
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// EmbeddingCacheSize returns the number of embeddings in the shared cache
+func (im *IndexManager) EmbeddingCacheSize() (int, error) {
+	if impl := im.getImpl(); impl != nil {
+		return impl.storage.CachedEmbeddingCount()
+	}
+	return 0, fmt.Errorf("implementation not available")
+}
+
+// embedTexts generates embeddings for texts. With the embedding cache
+// enabled, texts already embedded by the same embedder (in any index) are
+// read from the cache and only the remaining texts are sent to the embedder.
+// Embeddings are validated, and normalized if configured, before they are
+// returned; an invalid embedding fails the whole call.
+func (im *indexManagerImpl) embedTexts(texts []string) ([][]float32, error) {
+	if !im.config.EmbeddingCache {
+		embeddings, err := im.generateEmbeddings(texts)
+		if err != nil {
+			return nil, err
+		}
+		return im.checkEmbeddings(embeddings)
+	}
+
+	model := im.embeddingCacheModel()
+	hashes := make([]string, len(texts))
+	for idx, text := range texts {
+		hashes[idx] = textHash(text)
+	}
+
+	cached, err := im.storage.GetCachedEmbeddings(model, hashes)
+	if err != nil {
+		slog.Warn("Failed to read embedding cache",
+			"error", err,
+		)
+		cached = make(map[string][]float32)
+	}
+
+	// Discard entries produced by an embedder with a different dimension,
+	// and invalid entries cached before embeddings were validated
+	dimension := im.embedder.Dimension()
+	for hash, embedding := range cached {
+		if validateEmbedding(embedding, dimension) != nil {
+			delete(cached, hash)
+		}
+	}
+
+	// Embed each distinct missing text once
+	var missing []string
+	var missingHashes []string
+	queued := make(map[string]bool)
+	for idx, hash := range hashes {
+		if _, ok := cached[hash]; ok || queued[hash] {
+			continue
+		}
+		queued[hash] = true
+		missing = append(missing, texts[idx])
+		missingHashes = append(missingHashes, hash)
+	}
+
+	if len(missing) > 0 {
+		embeddings, err := im.generateEmbeddings(missing)
+		if err != nil {
+			return nil, err
+		}
+		for idx, embedding := range embeddings {
+			if err := im.checkEmbedding(embedding, dimension); err != nil {
+				return nil, fmt.Errorf("embedding for text %d: %w", idx, err)
+			}
+		}
+
+		fresh := make(map[string][]float32, len(missing))
+		for idx, hash := range missingHashes {
+			fresh[hash] = embeddings[idx]
+			cached[hash] = embeddings[idx]
+		}
+		if err := im.storage.PutCachedEmbeddings(model, fresh, im.config.EmbeddingCacheEntries); err != nil {
+			slog.Warn("Failed to update embedding cache",
+				"error", err,
+			)
+		}
+	}
+
+	slog.Debug("Embeddings resolved",
+		"texts", len(texts),
+		"cache_hits", len(texts)-len(missing),
+		"embedded", len(missing),
+	)
+
+	result := make([][]float32, len(texts))
+	for idx, hash := range hashes {
+		result[idx] = cached[hash]
+		if im.config.NormalizeEmbeddings {
+			result[idx] = normalizeEmbedding(result[idx])
+		}
+	}
+	return result, nil
+}
+
+// embeddingCacheModel identifies the embeddings the manager's embedder
+// produces in the cache: its provider and model, the request options that
+// change the vectors, and for custom embedders their type and dimension
+func (im *indexManagerImpl) embeddingCacheModel() string {
+	provider, _, model := im.embedderDescription()
+	identity := struct {
+		Provider   string         `json:"provider"`
+		Model      string         `json:"model"`
+		Dimensions int            `json:"dimensions,omitempty"`
+		Truncate   *bool          `json:"truncate,omitempty"`
+		Options    map[string]any `json:"options,omitempty"`
+		Type       string         `json:"type,omitempty"`
+		Dimension  int            `json:"dimension,omitempty"`
+	}{
+		Provider:   provider,
+		Model:      model,
+		Dimensions: im.config.EmbedDimensions,
+		Truncate:   im.config.EmbedTruncate,
+		Options:    im.config.EmbedModelOptions,
+	}
+	if provider == "custom" {
+		identity.Type = fmt.Sprintf("%T", im.embedder)
+		identity.Dimension = im.embedder.Dimension()
+	}
+	data, _ := json.Marshal(identity)
+	return textHash(string(data))
+}
+
+// textHash returns the cache key of a chunk text
+func textHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddingCache_SharedAcrossIndexes(t *testing.T) {
+	cfg := NewConfig()
+	cfg.EmbeddingCache = true
+	manager := newMockManager(t, cfg)
+	counter := &countingEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.getImpl().embedder = counter
+
+	docs := []Document{
+		{URI: "doc1", Title: "One", Content: "deploy the service with the pipeline"},
+		{URI: "doc2", Title: "Two", Content: "rotate the credentials every quarter"},
+	}
+
+	first, err := manager.CreateIndex("first")
+	require.NoError(t, err)
+	result, err := first.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	embedded := counter.texts
+	assert.Equal(t, result.ProcessedChunks, embedded)
+
+	// The mirrored index is served entirely from the cache
+	mirror, err := manager.CreateIndex("mirror")
+	require.NoError(t, err)
+	_, err = mirror.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, embedded, counter.texts)
+
+	size, err := manager.EmbeddingCacheSize()
+	require.NoError(t, err)
+	assert.Equal(t, embedded, size)
+
+	results, err := mirror.Search("rotate the credentials every quarter", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc2", results[0].Document.URI)
+
+	require.NoError(t, manager.ClearEmbeddingCache())
+	size, err = manager.EmbeddingCacheSize()
+	require.NoError(t, err)
+	assert.Zero(t, size)
+}
+
+func TestEmbeddingCache_KeyedByRequestOptions(t *testing.T) {
+	cfg := NewConfig()
+	cfg.EmbeddingCache = true
+	manager := newMockManager(t, cfg)
+	counter := &countingEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.getImpl().embedder = counter
+
+	doc := Document{URI: "doc1", Title: "One", Content: "the same text twice"}
+	index, err := manager.CreateIndex("a")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), []Document{doc}, nil)
+	require.NoError(t, err)
+
+	// Embeddings requested with other options are not shared
+	manager.getImpl().config.EmbedDimensions = 256
+	index, err = manager.CreateIndex("b")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), []Document{doc}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, counter.texts)
+
+	// Nor are those of another embedder with the same model name
+	other := &countingEmbedder{MockEmbedder: NewMockEmbedder(384)}
+	manager.getImpl().embedder = other
+	index, err = manager.CreateIndex("c")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), []Document{doc}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, other.texts)
+
+	size, err := manager.EmbeddingCacheSize()
+	require.NoError(t, err)
+	assert.Equal(t, 3, size)
+}
+
+func TestEmbeddingCache_Disabled(t *testing.T) {
+	cfg := NewConfig()
+	cfg.EmbeddingCache = false
+	manager := newMockManager(t, cfg)
+	counter := &countingEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.getImpl().embedder = counter
+
+	doc := Document{URI: "doc1", Title: "One", Content: "the same text twice"}
+	for _, name := range []string{"a", "b"} {
+		index, err := manager.CreateIndex(name)
+		require.NoError(t, err)
+		_, err = index.AddDocumentBatch(context.Background(), []Document{doc}, nil)
+		require.NoError(t, err)
+	}
+
+	size, err := manager.EmbeddingCacheSize()
+	require.NoError(t, err)
+	assert.Zero(t, size)
+	assert.Equal(t, 2, counter.texts)
+}
@@ -0,0 +1,136 @@
+package hnswindex
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex/internal/embedder"
+)
+
+// Embedding backends selectable with Config.EmbedProvider
+const (
+	EmbedProviderOllama = "ollama"
+	EmbedProviderJob    = "job"
+	EmbedProviderCohere = "cohere"
+	EmbedProviderVoyage = "voyage"
+	EmbedProviderONNX   = "onnx"
+)
+
+// Embedder generates embeddings for an index manager. GenerateEmbedding
+// embeds search queries and GenerateEmbeddings embeds document chunks.
+// Dimension may return 0 if it is only known after the first embedding.
+type Embedder interface {
+	GenerateEmbedding(text string) ([]float32, error)
+	GenerateEmbeddings(texts []string) ([][]float32, error)
+	Dimension() int
+}
+
+// SetEmbedder replaces the embedder selected by Config.EmbedProvider, e.g.
+// with an embedtest.Embedder in tests. Call it before creating, filling or
+// searching indexes. Cached embeddings are keyed by Config.EmbedModel and
+// the embedder's type and dimension, so give the model its own name when
+// the embedding cache is enabled.
+func (im *IndexManager) SetEmbedder(e Embedder) {
+	if impl := im.getImpl(); impl != nil {
+		impl.embedder = e
+	}
+}
+
+// embedderDescription returns the provider, URL (or model path) and model
+// of the manager's embedder; embedders passed to SetEmbedder are "custom"
+func (im *indexManagerImpl) embedderDescription() (provider, url, model string) {
+	cfg := im.config
+	provider = "custom"
+	model = cfg.EmbedModel
+	switch e := im.embedder.(type) {
+	case *embedder.OllamaEmbedder:
+		provider = "ollama"
+		url = cfg.OllamaURL
+	case *embedder.JobEmbedder:
+		provider = EmbedProviderJob
+		url = cfg.EmbedURL
+	case *embedder.CohereEmbedder:
+		provider = EmbedProviderCohere
+		url = cfg.EmbedURL
+		model = e.Model()
+	case *embedder.VoyageEmbedder:
+		provider = EmbedProviderVoyage
+		url = cfg.EmbedURL
+		model = e.Model()
+	case *embedder.ONNXEmbedder:
+		provider = EmbedProviderONNX
+		url = cfg.EmbedModelPath
+	}
+	return provider, url, model
+}
+
+// newEmbedder creates the embedder selected by the configuration
+func newEmbedder(config *Config) (embedder.Embedder, error) {
+	request := embedder.RequestOptions{
+		Truncate:   config.EmbedTruncate,
+		KeepAlive:  config.EmbedKeepAlive,
+		Options:    config.EmbedModelOptions,
+		Dimensions: config.EmbedDimensions,
+	}
+	switch config.EmbedProvider {
+	case "", EmbedProviderOllama:
+		e, err := embedder.NewOllamaEmbedder(config.OllamaURL, config.EmbedModel)
+		if err != nil {
+			return nil, err
+		}
+		e.SetRequestOptions(request)
+		return e, nil
+	case EmbedProviderJob:
+		return embedder.NewJobEmbedder(config.EmbedURL, config.EmbedModel, embedder.JobOptions{
+			BatchSize:  config.EmbedBatchSize,
+			APIKey:     config.EmbedAPIKey,
+			Dimensions: config.EmbedDimensions,
+		})
+	case EmbedProviderCohere:
+		e, err := embedder.NewCohereEmbedder(config.EmbedURL, config.EmbedAPIKey, hostedModel(config))
+		if err != nil {
+			return nil, err
+		}
+		e.SetRequestOptions(request)
+		return e, nil
+	case EmbedProviderVoyage:
+		e, err := embedder.NewVoyageEmbedder(config.EmbedURL, config.EmbedAPIKey, hostedModel(config))
+		if err != nil {
+			return nil, err
+		}
+		e.SetRequestOptions(request)
+		return e, nil
+	case EmbedProviderONNX:
+		return embedder.NewONNXEmbedder(config.EmbedModel, embedder.ONNXOptions{
+			ModelDir:    config.EmbedModelPath,
+			LibraryPath: config.ONNXRuntimePath,
+			BatchSize:   config.EmbedBatchSize,
+		})
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q", config.EmbedProvider)
+	}
+}
+
+// hostedModel returns the model for a hosted provider, empty for the
+// provider's default when EmbedModel was left at the Ollama default
+func hostedModel(config *Config) string {
+	if config.EmbedModel == NewConfig().EmbedModel {
+		return ""
+	}
+	return config.EmbedModel
+}
+
+// embedderDimension returns the dimension of the embedder's vectors,
+// embedding a probe text if the model's dimension is not known yet
+func (im *indexManagerImpl) embedderDimension() (int, error) {
+	if dimension := im.embedder.Dimension(); dimension > 0 {
+		return dimension, nil
+	}
+	probe, err := im.embedder.GenerateEmbedding("dimension probe")
+	if err != nil {
+		return 0, fmt.Errorf("failed to detect embedding dimension: %w", err)
+	}
+	if len(probe) == 0 {
+		return 0, fmt.Errorf("failed to detect embedding dimension: embedder returned an empty vector")
+	}
+	return len(probe), nil
+}
@@ -0,0 +1,215 @@
+package hnswindex
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJobServer runs a batch embedding service whose jobs complete at once
+func newJobServer(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	jobs := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == "POST" {
+			var req struct{ Inputs []string }
+			json.NewDecoder(r.Body).Decode(&req)
+			id := fmt.Sprint(len(jobs) + 1)
+			jobs[id] = req.Inputs
+			json.NewEncoder(w).Encode(map[string]string{"id": id, "status": "queued"})
+			return
+		}
+		var embeddings [][]float32
+		for _, text := range jobs[strings.TrimPrefix(r.URL.Path, "/jobs/")] {
+			hash := sha256.Sum256([]byte(text))
+			v := make([]float32, 16)
+			for i := range v {
+				v[i] = float32(hash[i]) / 255
+			}
+			embeddings = append(embeddings, v)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "completed", "embeddings": embeddings})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEmbedProvider_Job(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.EmbedProvider = EmbedProviderJob
+	cfg.EmbedURL = newJobServer(t).URL
+	cfg.EmbedModel = "bge-small"
+	manager, err := NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+
+	// The dimension of an unknown model is detected with a probe
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	effective, err := index.EffectiveConfig()
+	require.NoError(t, err)
+	assert.Equal(t, EmbedProviderJob, effective.Embedder.Provider)
+	assert.Equal(t, 16, effective.Embedder.Dimension)
+
+	addDocuments(t, index,
+		Document{URI: "a", Content: "first document"},
+		Document{URI: "b", Content: "second document"},
+	)
+	results, err := index.Search("second document", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "b", results[0].Document.URI)
+}
+
+func TestEmbedProvider_Unknown(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.EmbedProvider = "openai"
+	_, err := NewIndexManager(cfg)
+	assert.ErrorContains(t, err, `unknown embedding provider "openai"`)
+}
+
+func TestEmbedProvider_Cohere(t *testing.T) {
+	var mu sync.Mutex
+	inputTypes := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model     string
+			Texts     []string
+			InputType string `json:"input_type"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		inputTypes[req.InputType] = true
+		mu.Unlock()
+
+		var embeddings [][]float32
+		for _, text := range req.Texts {
+			hash := sha256.Sum256([]byte(text))
+			v := make([]float32, 1024)
+			for i := range v {
+				v[i] = float32(hash[i%len(hash)]) / 255
+			}
+			embeddings = append(embeddings, v)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": map[string]interface{}{"float": embeddings}})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.EmbedProvider = EmbedProviderCohere
+	cfg.EmbedURL = server.URL
+	cfg.EmbedAPIKey = "key"
+	manager, err := NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+
+	// The Ollama default model gives way to Cohere's
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	effective, err := index.EffectiveConfig()
+	require.NoError(t, err)
+	assert.Equal(t, EmbedProviderCohere, effective.Embedder.Provider)
+	assert.Equal(t, "embed-english-v3.0", effective.Embedder.Model)
+	assert.Equal(t, 1024, effective.Embedder.Dimension)
+
+	addDocuments(t, index, Document{URI: "a", Content: "first document"})
+	results, err := index.Search("first document", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, map[string]bool{"search_document": true, "search_query": true}, inputTypes)
+
+	// Hosted providers need an API key
+	cfg = NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.EmbedProvider = EmbedProviderVoyage
+	_, err = NewIndexManager(cfg)
+	assert.ErrorContains(t, err, "API key")
+}
+
+func TestEmbedProvider_ONNX(t *testing.T) {
+	// Without a model, or in builds without the onnx tag, creation fails
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.EmbedProvider = EmbedProviderONNX
+	_, err := NewIndexManager(cfg)
+	assert.ErrorContains(t, err, "ONNX")
+}
+
+func TestSetEmbedder(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager, err := NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+
+	e := embedtest.NewSemantic(64)
+	manager.SetEmbedder(e)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	addDocuments(t, index,
+		Document{URI: "password", Content: "Reset your password from the account settings page."},
+		Document{URI: "revenue", Content: "Quarterly revenue grew in every region."},
+	)
+	results, err := index.Search("how do I reset my password", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "password", results[0].Document.URI)
+	assert.Equal(t, 3, e.Texts()) // Two chunks and the query
+}
+
+func TestSearch_QueryModel(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager, err := NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+
+	e := embedtest.NewSemantic(64)
+	manager.SetEmbedder(e)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "password", Content: "Reset your password from the account settings page."},
+		Document{URI: "revenue", Content: "Quarterly revenue grew in every region."},
+	)
+
+	query := embedtest.NewSemantic(64)
+	manager.RegisterQueryEmbedder("query-encoder", query)
+	results, err := index.SearchWithOptions("how do I reset my password", 1, SearchOptions{
+		Model:         "query-encoder",
+		NegativeQuery: "revenue",
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "password", results[0].Document.URI)
+	assert.Equal(t, 2, query.Texts()) // The query and the negative query
+	assert.Equal(t, 2, e.Texts())
+
+	// The index model is the default
+	_, err = index.SearchWithOptions("password", 1, SearchOptions{Model: cfg.EmbedModel})
+	require.NoError(t, err)
+	assert.Equal(t, 3, e.Texts())
+
+	manager.RegisterQueryEmbedder("narrow", embedtest.New(32))
+	_, err = index.SearchWithOptions("password", 1, SearchOptions{Model: "narrow"})
+	assert.ErrorContains(t, err, `query model "narrow"`)
+
+	manager.getImpl().config.EmbedProvider = EmbedProviderONNX
+	_, err = index.SearchWithOptions("password", 1, SearchOptions{Model: "unknown"})
+	assert.ErrorContains(t, err, "not registered")
+}
@@ -0,0 +1,107 @@
+package hnswindex
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidEmbedding is returned when an embedder produces a vector that
+// cannot be indexed: wrong dimension, NaN or infinite values, or all zeros
+var ErrInvalidEmbedding = errors.New("invalid embedding")
+
+// ErrDimensionMismatch is returned when the embedder's vectors are not of
+// the dimension its model was declared with, or an index was built with,
+// as when a different variant of the model has been installed. It wraps
+// ErrInvalidEmbedding; nothing is written to the index.
+var ErrDimensionMismatch = fmt.Errorf("%w: dimension mismatch", ErrInvalidEmbedding)
+
+// validateEmbedding checks a vector before it is indexed. A dimension of 0
+// skips the dimension check.
+func validateEmbedding(v []float32, dimension int) error {
+	if len(v) == 0 {
+		return fmt.Errorf("%w: empty vector", ErrInvalidEmbedding)
+	}
+	if dimension > 0 && len(v) != dimension {
+		return fmt.Errorf("%w: dimension %d, expected %d", ErrInvalidEmbedding, len(v), dimension)
+	}
+	for idx, x := range v {
+		if math.IsNaN(float64(x)) {
+			return fmt.Errorf("%w: NaN at position %d", ErrInvalidEmbedding, idx)
+		}
+		if math.IsInf(float64(x), 0) {
+			return fmt.Errorf("%w: infinite value at position %d", ErrInvalidEmbedding, idx)
+		}
+	}
+	if vectorNorm(v) == 0 {
+		return fmt.Errorf("%w: zero vector", ErrInvalidEmbedding)
+	}
+	return nil
+}
+
+// checkEmbeddings validates embeddings generated for texts and, with
+// Config.NormalizeEmbeddings, scales them to unit length. The returned
+// slice holds copies; the input is not modified.
+func (im *indexManagerImpl) checkEmbeddings(embeddings [][]float32) ([][]float32, error) {
+	dimension := im.embedder.Dimension()
+	if dimension == 0 && len(embeddings) > 0 {
+		dimension = len(embeddings[0]) // Unknown model: vectors must agree
+	}
+
+	result := make([][]float32, len(embeddings))
+	for idx, v := range embeddings {
+		if err := im.checkEmbedding(v, dimension); err != nil {
+			return nil, fmt.Errorf("embedding %d: %w", idx, err)
+		}
+		if im.config.NormalizeEmbeddings {
+			v = normalizeEmbedding(v)
+		}
+		result[idx] = v
+	}
+	return result, nil
+}
+
+// checkEmbedding validates a vector from the embedder, failing with an
+// actionable ErrDimensionMismatch if it is not of the expected dimension
+func (im *indexManagerImpl) checkEmbedding(v []float32, dimension int) error {
+	if dimension > 0 && len(v) > 0 && len(v) != dimension {
+		return im.dimensionError(len(v), dimension, "")
+	}
+	return validateEmbedding(v, dimension)
+}
+
+// checkDimension fails with ErrDimensionMismatch unless the embedder's
+// vectors, of the given dimension, fit the index's graph
+func (i *indexImpl) checkDimension(dimension int) error {
+	if expected := i.embeddingDimension(); dimension != expected {
+		return i.manager.dimensionError(dimension, expected, i.name)
+	}
+	return nil
+}
+
+// dimensionError describes vectors of the wrong dimension from the
+// configured model and how to recover: the index is named if its graph
+// is what they do not fit
+func (im *indexManagerImpl) dimensionError(got, expected int, index string) error {
+	model := im.config.EmbedModel
+	if index == "" {
+		return fmt.Errorf("%w: model %q returned %d-dimension vectors, expected %d; "+
+			"check that the installed model is the one the configuration names, "+
+			"or rebuild the indexes (BeginRebuild) to re-embed them with it",
+			ErrDimensionMismatch, model, got, expected)
+	}
+	return fmt.Errorf("%w: model %q returned %d-dimension vectors, but index '%s' holds %d-dimension vectors; "+
+		"install the model variant the index was built with, "+
+		"or rebuild the index (BeginRebuild) to re-embed it with the current model",
+		ErrDimensionMismatch, model, got, index, expected)
+}
+
+// normalizeEmbedding returns v scaled to unit length
+func normalizeEmbedding(v []float32) []float32 {
+	norm := vectorNorm(v)
+	out := make([]float32, len(v))
+	for idx, x := range v {
+		out[idx] = float32(float64(x) / norm)
+	}
+	return out
+}
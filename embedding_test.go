@@ -0,0 +1,157 @@
+package hnswindex
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// poisonEmbedder returns NaN embeddings for texts containing "POISON"
+type poisonEmbedder struct {
+	*MockEmbedder
+}
+
+func (p *poisonEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	embeddings, err := p.MockEmbedder.GenerateEmbeddings(texts)
+	if err != nil {
+		return nil, err
+	}
+	for idx, text := range texts {
+		if strings.Contains(text, "POISON") {
+			embeddings[idx][0] = float32(math.NaN())
+		}
+	}
+	return embeddings, nil
+}
+
+func TestValidateEmbedding(t *testing.T) {
+	assert.NoError(t, validateEmbedding([]float32{0.1, 0.2, 0.3}, 3))
+	assert.NoError(t, validateEmbedding([]float32{0.1, 0.2, 0.3}, 0))
+
+	for name, v := range map[string][]float32{
+		"empty":     {},
+		"dimension": {0.1, 0.2},
+		"nan":       {0.1, float32(math.NaN()), 0.3},
+		"inf":       {0.1, float32(math.Inf(1)), 0.3},
+		"zero":      {0, 0, 0},
+	} {
+		err := validateEmbedding(v, 3)
+		assert.ErrorIs(t, err, ErrInvalidEmbedding, name)
+	}
+}
+
+func TestIngest_RejectsInvalidEmbeddings(t *testing.T) {
+	for _, cache := range []bool{false, true} {
+		cfg := NewConfig()
+		cfg.EmbeddingCache = cache
+		manager := newMockManager(t, cfg)
+		manager.getImpl().embedder = &poisonEmbedder{NewMockEmbedder(768)}
+
+		index, err := manager.CreateIndex("validate")
+		require.NoError(t, err)
+
+		result, err := index.AddDocumentBatch(context.Background(), []Document{
+			{URI: "good", Title: "Good", Content: "Healthy content"},
+			{URI: "bad", Title: "Bad", Content: "POISON content"},
+		}, nil)
+		require.NoError(t, err)
+		assert.Contains(t, result.FailedURIs["bad"], "NaN")
+
+		// The invalid vector never reaches the graph
+		chunks := 0
+		for range index.Chunks("bad") {
+			chunks++
+		}
+		assert.Zero(t, chunks)
+		results, err := index.Search("Healthy content", 5)
+		require.NoError(t, err)
+		require.NotEmpty(t, results)
+		assert.Equal(t, "good", results[0].Document.URI)
+
+		// Group commits are atomic, so the whole batch fails
+		_, err = manager.Batch(func(tx *ManagerTx) error {
+			return tx.Add("validate", Document{URI: "bad2", Title: "Bad", Content: "POISON again"})
+		})
+		assert.ErrorIs(t, err, ErrInvalidEmbedding)
+	}
+}
+
+// variantEmbedder declares one dimension but returns vectors of another,
+// as a different variant of a model installed under the same name would
+type variantEmbedder struct {
+	*MockEmbedder
+	declared int
+}
+
+func (v *variantEmbedder) Dimension() int {
+	return v.declared
+}
+
+func TestIngest_DimensionMismatch(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	docs := []Document{{URI: "doc", Title: "Doc", Content: "Some content to embed"}}
+	for name, e := range map[string]Embedder{
+		"declared": &variantEmbedder{NewMockEmbedder(1024), 768},
+		"index":    NewMockEmbedder(1024),
+	} {
+		manager.getImpl().embedder = e
+
+		result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+		require.NoError(t, err, name)
+		assert.Contains(t, result.FailedURIs["doc"], "1024-dimension", name)
+		assert.Contains(t, result.FailedURIs["doc"], "BeginRebuild", name)
+		for range index.Chunks("doc") {
+			t.Errorf("%s: chunk stored with the wrong dimension", name)
+		}
+
+		_, err = manager.Batch(func(tx *ManagerTx) error {
+			return tx.Add("kb", docs[0])
+		})
+		assert.ErrorIs(t, err, ErrDimensionMismatch, name)
+		assert.ErrorIs(t, err, ErrInvalidEmbedding, name)
+
+		_, err = index.Search("Some content", 1)
+		assert.ErrorIs(t, err, ErrDimensionMismatch, name)
+	}
+
+	// A rebuild creates the graph with the new dimension
+	manager.getImpl().embedder = NewMockEmbedder(1024)
+	staging, err := index.BeginRebuild()
+	require.NoError(t, err)
+	result, err := staging.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.FailedURIs)
+	require.NoError(t, staging.CommitRebuild())
+	results, err := index.Search("Some content to embed", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+}
+
+func TestIngest_NormalizeEmbeddings(t *testing.T) {
+	cfg := NewConfig()
+	cfg.NormalizeEmbeddings = true
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("normalized")
+	require.NoError(t, err)
+
+	_, err = index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "doc", Title: "Doc", Content: "Some content to embed"},
+	}, nil)
+	require.NoError(t, err)
+
+	for chunk := range index.Chunks("doc") {
+		assert.InDelta(t, 1.0, vectorNorm(chunk.Embedding), 1e-5)
+	}
+
+	results, err := index.Search("Some content to embed", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-4)
+}
@@ -0,0 +1,152 @@
+// Package embedtest provides deterministic embedders for tests, so code
+// using hnswindex can be tested without an embedding server:
+//
+//	manager, err := hnswindex.NewIndexManager(config)
+//	...
+//	manager.SetEmbedder(embedtest.NewSemantic(384))
+package embedtest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Embedder implements hnswindex.Embedder by hashing text. The same text
+// always gets the same embedding, across runs and machines.
+type Embedder struct {
+	dimension int
+	semantic  bool
+
+	mu    sync.Mutex
+	calls int
+	texts int
+}
+
+// New creates an embedder that hashes each text as a whole: value i of an
+// embedding is byte i%32 of the text's SHA-256 scaled to [0, 1]. Different
+// texts get unrelated embeddings, so only exact matches rank reliably.
+func New(dimension int) *Embedder {
+	return &Embedder{dimension: dimension}
+}
+
+// NewSemantic creates an embedder that sums a hashed unit vector per
+// lowercased word and normalizes the result, so texts sharing words are
+// similar and word order does not matter. Texts without words are hashed
+// as a whole, as by New.
+func NewSemantic(dimension int) *Embedder {
+	return &Embedder{dimension: dimension, semantic: true}
+}
+
+// GenerateEmbedding embeds a single text
+func (e *Embedder) GenerateEmbedding(text string) ([]float32, error) {
+	e.mu.Lock()
+	e.calls++
+	e.texts++
+	e.mu.Unlock()
+	return e.embed(text), nil
+}
+
+// GenerateEmbeddings embeds texts in order
+func (e *Embedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.calls++
+	e.texts += len(texts)
+	e.mu.Unlock()
+
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = e.embed(text)
+	}
+	return embeddings, nil
+}
+
+// Dimension returns the dimension of the embeddings
+func (e *Embedder) Dimension() int {
+	return e.dimension
+}
+
+// Calls returns how many times GenerateEmbedding and GenerateEmbeddings
+// have been called
+func (e *Embedder) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+// Texts returns how many texts have been embedded
+func (e *Embedder) Texts() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.texts
+}
+
+func (e *Embedder) embed(text string) []float32 {
+	if e.semantic {
+		if words := strings.FieldsFunc(strings.ToLower(text), isSeparator); len(words) > 0 {
+			return e.embedWords(words)
+		}
+	}
+
+	hash := sha256.Sum256([]byte(text))
+	embedding := make([]float32, e.dimension)
+	for i := range embedding {
+		embedding[i] = float32(hash[i%len(hash)]) / 255.0
+	}
+	return embedding
+}
+
+// embedWords sums the word vectors and normalizes the sum
+func (e *Embedder) embedWords(words []string) []float32 {
+	sum := make([]float64, e.dimension)
+	for _, word := range words {
+		for i, value := range wordVector(word, e.dimension) {
+			sum[i] += value
+		}
+	}
+
+	var norm float64
+	for _, value := range sum {
+		norm += value * value
+	}
+	norm = math.Sqrt(norm)
+
+	embedding := make([]float32, e.dimension)
+	for i, value := range sum {
+		if norm > 0 {
+			embedding[i] = float32(value / norm)
+		}
+	}
+	return embedding
+}
+
+// wordVector returns the unit vector of a word, with values drawn from
+// SHA-256 hashes of the word and a block counter
+func wordVector(word string, dimension int) []float64 {
+	v := make([]float64, dimension)
+	var block [sha256.Size]byte
+	var norm float64
+	for i := range v {
+		if i%sha256.Size == 0 {
+			counter := make([]byte, 4)
+			binary.BigEndian.PutUint32(counter, uint32(i/sha256.Size))
+			block = sha256.Sum256(append([]byte(word), counter...))
+		}
+		v[i] = float64(block[i%sha256.Size])/127.5 - 1
+		norm += v[i] * v[i]
+	}
+	norm = math.Sqrt(norm)
+	for i := range v {
+		if norm > 0 {
+			v[i] /= norm
+		}
+	}
+	return v
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,74 @@
+package embedtest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func TestNew(t *testing.T) {
+	e := New(64)
+	a, err := e.GenerateEmbedding("hello")
+	require.NoError(t, err)
+	require.Len(t, a, 64)
+	assert.Equal(t, a[:32], a[32:])
+
+	batch, err := e.GenerateEmbeddings([]string{"hello", "world"})
+	require.NoError(t, err)
+	assert.Equal(t, a, batch[0])
+	assert.NotEqual(t, a, batch[1])
+	assert.Equal(t, 2, e.Calls())
+	assert.Equal(t, 3, e.Texts())
+}
+
+func TestNewSemantic(t *testing.T) {
+	e := NewSemantic(256)
+	embed := func(text string) []float32 {
+		v, err := e.GenerateEmbedding(text)
+		require.NoError(t, err)
+		return v
+	}
+
+	query := embed("reset password")
+	assert.InDelta(t, 1.0, cosine(query, embed("Password, RESET!")), 1e-6)
+	related := cosine(query, embed("how to reset your password"))
+	unrelated := cosine(query, embed("quarterly revenue report"))
+	assert.Greater(t, related, 0.5)
+	assert.Less(t, unrelated, 0.3)
+
+	var norm float64
+	for _, value := range query {
+		norm += float64(value) * float64(value)
+	}
+	assert.InDelta(t, 1.0, norm, 1e-5)
+
+	// Texts without words fall back to hashing the whole text
+	assert.Equal(t, embed("---"), embed("---"))
+	assert.NotEqual(t, embed("---"), embed("..."))
+}
+
+func TestSparse(t *testing.T) {
+	s := NewSparse()
+	docs, err := s.SparseDocuments([]string{"Reset the password, password!", ""})
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, float32(1), docs[0]["reset"])
+	assert.InDelta(t, 1+math.Ln2, docs[0]["password"], 1e-6)
+	assert.Empty(t, docs[1])
+
+	query, err := s.SparseQuery("Password reset")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float32{"password": 1, "reset": 1}, query)
+}
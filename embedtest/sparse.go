@@ -0,0 +1,42 @@
+package embedtest
+
+import (
+	"math"
+	"strings"
+)
+
+// Sparse implements hnswindex.SparseEmbedder with term frequencies: each
+// lowercased word of a document weighs 1 + ln(count), and each word of a
+// query weighs 1, so documents sharing more query words score higher.
+type Sparse struct{}
+
+// NewSparse creates a sparse embedder
+func NewSparse() *Sparse {
+	return &Sparse{}
+}
+
+// SparseDocuments embeds document texts in order
+func (s *Sparse) SparseDocuments(texts []string) ([]map[string]float32, error) {
+	vectors := make([]map[string]float32, len(texts))
+	for i, text := range texts {
+		counts := make(map[string]int)
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), isSeparator) {
+			counts[word]++
+		}
+		vector := make(map[string]float32, len(counts))
+		for word, count := range counts {
+			vector[word] = float32(1 + math.Log(float64(count)))
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// SparseQuery embeds a query
+func (s *Sparse) SparseQuery(text string) (map[string]float32, error) {
+	vector := make(map[string]float32)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isSeparator) {
+		vector[word] = 1
+	}
+	return vector, nil
+}
@@ -0,0 +1,178 @@
+package hnswindex
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// EventHandler receives index lifecycle events, e.g. for audit logs, cache
+// invalidation, or "index updated" notifications. Handlers are called
+// synchronously after a change is committed, on the goroutine that made it,
+// so they should return quickly and hand slow work off to a goroutine.
+// AddDocumentBatch indexes documents on several workers; their
+// OnDocumentIndexed calls are made one at a time, in completion order.
+type EventHandler interface {
+	OnDocumentIndexed(event DocumentEvent)
+	OnDocumentDeleted(event DocumentEvent)
+	OnIndexSaved(event IndexEvent)
+	OnSearch(event SearchEvent)
+	OnMaintenance(report MaintenanceReport)
+}
+
+// DocumentEvent describes a document that was indexed or deleted
+type DocumentEvent struct {
+	Index   string
+	URI     string
+	Title   string // Empty for deletions
+	Chunks  int    // Chunks stored for the document (indexing only)
+	Created bool   // The document was not in the index before
+}
+
+// IndexEvent describes a change to a whole index
+type IndexEvent struct {
+	Index string
+}
+
+// SearchEvent describes a completed search
+type SearchEvent struct {
+	Index    string
+	Query    string
+	Limit    int
+	Results  int
+	Duration time.Duration
+}
+
+// EventHandlerFuncs adapts optional functions to the EventHandler
+// interface, so consumers only handle the events they need
+type EventHandlerFuncs struct {
+	DocumentIndexed func(DocumentEvent)
+	DocumentDeleted func(DocumentEvent)
+	IndexSaved      func(IndexEvent)
+	Search          func(SearchEvent)
+	Maintenance     func(MaintenanceReport)
+}
+
+// OnDocumentIndexed calls DocumentIndexed if set
+func (f EventHandlerFuncs) OnDocumentIndexed(event DocumentEvent) {
+	if f.DocumentIndexed != nil {
+		f.DocumentIndexed(event)
+	}
+}
+
+// OnDocumentDeleted calls DocumentDeleted if set
+func (f EventHandlerFuncs) OnDocumentDeleted(event DocumentEvent) {
+	if f.DocumentDeleted != nil {
+		f.DocumentDeleted(event)
+	}
+}
+
+// OnIndexSaved calls IndexSaved if set
+func (f EventHandlerFuncs) OnIndexSaved(event IndexEvent) {
+	if f.IndexSaved != nil {
+		f.IndexSaved(event)
+	}
+}
+
+// OnSearch calls Search if set
+func (f EventHandlerFuncs) OnSearch(event SearchEvent) {
+	if f.Search != nil {
+		f.Search(event)
+	}
+}
+
+// OnMaintenance calls Maintenance if set
+func (f EventHandlerFuncs) OnMaintenance(report MaintenanceReport) {
+	if f.Maintenance != nil {
+		f.Maintenance(report)
+	}
+}
+
+// subscription is a registered event handler
+type subscription struct {
+	id      uint64
+	handler EventHandler
+}
+
+// Subscribe registers a handler for the events of all indexes of the
+// manager. The returned function removes it.
+func (im *IndexManager) Subscribe(handler EventHandler) (unsubscribe func()) {
+	impl := im.getImpl()
+	if impl == nil {
+		return func() {}
+	}
+
+	impl.mu.Lock()
+	impl.nextSubscription++
+	id := impl.nextSubscription
+	impl.subscriptions = append(impl.subscriptions, subscription{id: id, handler: handler})
+	impl.mu.Unlock()
+
+	return func() {
+		impl.mu.Lock()
+		defer impl.mu.Unlock()
+		for idx, sub := range impl.subscriptions {
+			if sub.id == id {
+				impl.subscriptions = append(impl.subscriptions[:idx:idx], impl.subscriptions[idx+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit calls fn for every subscribed handler. A panicking handler is
+// logged and does not affect the operation that raised the event.
+func (im *indexManagerImpl) emit(event string, fn func(EventHandler)) {
+	im.mu.RLock()
+	subs := im.subscriptions
+	im.mu.RUnlock()
+
+	for _, sub := range subs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Error("Event handler panicked",
+						"event", event,
+						"panic", r,
+					)
+				}
+			}()
+			fn(sub.handler)
+		}()
+	}
+}
+
+func (im *indexManagerImpl) emitDocumentIndexed(event DocumentEvent) {
+	im.emit("document_indexed", func(h EventHandler) { h.OnDocumentIndexed(event) })
+}
+
+func (im *indexManagerImpl) emitDocumentDeleted(event DocumentEvent) {
+	im.emit("document_deleted", func(h EventHandler) { h.OnDocumentDeleted(event) })
+}
+
+// indexSaved records when an index's graph was saved and raises OnIndexSaved
+func (im *indexManagerImpl) indexSaved(name string) {
+	err := im.storage.UpdateIndexMetadata(name, func(metadata *storage.IndexMetadata) {
+		metadata.LastSavedAt = time.Now().UTC()
+	})
+	if err != nil {
+		slog.Warn("Failed to record index save time",
+			"index", name,
+			"error", err,
+		)
+	}
+	im.emitIndexSaved(name)
+}
+
+func (im *indexManagerImpl) emitIndexSaved(name string) {
+	im.emit("index_saved", func(h EventHandler) { h.OnIndexSaved(IndexEvent{Index: name}) })
+}
+
+func (im *indexManagerImpl) emitSearch(event SearchEvent) {
+	im.emit("search", func(h EventHandler) { h.OnSearch(event) })
+}
+
+func (im *indexManagerImpl) emitMaintenance(report MaintenanceReport) {
+	im.emit("maintenance", func(h EventHandler) { h.OnMaintenance(report) })
+}
@@ -0,0 +1,150 @@
+package hnswindex
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventRecorder records received events
+type eventRecorder struct {
+	mu       sync.Mutex
+	indexed  []DocumentEvent
+	deleted  []DocumentEvent
+	saved    []IndexEvent
+	searches []SearchEvent
+	reports  []MaintenanceReport
+}
+
+func (r *eventRecorder) OnDocumentIndexed(e DocumentEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.indexed = append(r.indexed, e)
+}
+
+func (r *eventRecorder) OnDocumentDeleted(e DocumentEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted = append(r.deleted, e)
+}
+
+func (r *eventRecorder) OnIndexSaved(e IndexEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, e)
+}
+
+func (r *eventRecorder) OnSearch(e SearchEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.searches = append(r.searches, e)
+}
+
+func (r *eventRecorder) OnMaintenance(report MaintenanceReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+}
+
+func TestEvents_IndexDeleteSearch(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("events")
+	require.NoError(t, err)
+
+	rec := &eventRecorder{}
+	manager.Subscribe(rec)
+
+	docs := []Document{
+		{URI: "doc1", Title: "One", Content: "First document content"},
+		{URI: "doc2", Title: "Two", Content: "Second document content"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	// Events follow commit order, which need not be batch order
+	require.Len(t, rec.indexed, 2)
+	first := rec.indexed[0]
+	if first.URI != "doc1" {
+		first = rec.indexed[1]
+	}
+	assert.Equal(t, "events", first.Index)
+	assert.Equal(t, "doc1", first.URI)
+	assert.Equal(t, "One", first.Title)
+	assert.True(t, first.Created)
+	assert.Greater(t, first.Chunks, 0)
+	assert.Equal(t, []IndexEvent{{Index: "events"}}, rec.saved)
+
+	// Updated documents are not created; unchanged ones raise no event
+	docs[0].Content = "Changed content"
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	require.Len(t, rec.indexed, 3)
+	assert.False(t, rec.indexed[2].Created)
+
+	_, err = index.Search("document", 5)
+	require.NoError(t, err)
+	require.Len(t, rec.searches, 1)
+	assert.Equal(t, "document", rec.searches[0].Query)
+	assert.Equal(t, 5, rec.searches[0].Limit)
+	assert.Equal(t, 2, rec.searches[0].Results)
+
+	// Stopping an iterator early reports the results consumed
+	for range index.SearchIter("document", 5) {
+		break
+	}
+	require.Len(t, rec.searches, 2)
+	assert.Equal(t, 1, rec.searches[1].Results)
+
+	require.NoError(t, index.DeleteDocument("doc2"))
+	assert.Equal(t, []DocumentEvent{{Index: "events", URI: "doc2"}}, rec.deleted)
+}
+
+func TestEvents_Batch(t *testing.T) {
+	manager := newMockManager(t, nil)
+	_, err := manager.CreateIndex("a")
+	require.NoError(t, err)
+	_, err = manager.CreateIndex("b")
+	require.NoError(t, err)
+
+	rec := &eventRecorder{}
+	manager.Subscribe(rec)
+
+	doc := Document{URI: "shared", Title: "Shared", Content: "Shared content"}
+	_, err = manager.Batch(func(tx *ManagerTx) error {
+		require.NoError(t, tx.Add("a", doc))
+		return tx.Add("b", doc)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, rec.indexed, 2)
+	assert.ElementsMatch(t, []string{"a", "b"}, []string{rec.indexed[0].Index, rec.indexed[1].Index})
+	assert.True(t, rec.indexed[0].Created)
+	assert.Len(t, rec.saved, 2)
+}
+
+func TestEvents_UnsubscribeAndPanics(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("events")
+	require.NoError(t, err)
+
+	var searches int
+	manager.Subscribe(EventHandlerFuncs{
+		Search: func(SearchEvent) { panic("handler bug") },
+	})
+	unsubscribe := manager.Subscribe(EventHandlerFuncs{
+		Search: func(SearchEvent) { searches++ },
+	})
+
+	// A panicking handler neither fails the search nor skips other handlers
+	_, err = index.Search("query", 5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, searches)
+
+	unsubscribe()
+	_, err = index.Search("query", 5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, searches)
+}
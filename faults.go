@@ -0,0 +1,47 @@
+package hnswindex
+
+// FaultPoint names a place where a FaultInjector can fail or slow down an
+// index manager
+type FaultPoint string
+
+const (
+	// FaultCommit is reached before documents are committed to storage
+	FaultCommit FaultPoint = "commit"
+	// FaultGraphAdd is reached before committed chunks are added to a
+	// graph. The documents stay committed, as after a crash before the
+	// graph is saved.
+	FaultGraphAdd FaultPoint = "graph_add"
+	// FaultGraphSearch is reached before a graph is searched
+	FaultGraphSearch FaultPoint = "graph_search"
+	// FaultSave is reached before a graph is saved after a batch
+	FaultSave FaultPoint = "save"
+)
+
+// FaultInjector lets tests exercise failure handling. Inject is called at
+// each fault point with the index concerned; it may sleep to simulate a
+// slow dependency, and an error fails the operation as the real failure
+// would. See the testkit package for a configurable implementation.
+type FaultInjector interface {
+	Inject(point FaultPoint, index string) error
+}
+
+// SetFaultInjector installs a fault injector, or removes it when f is nil.
+// It is meant for tests only.
+func (im *IndexManager) SetFaultInjector(f FaultInjector) {
+	if impl := im.getImpl(); impl != nil {
+		impl.mu.Lock()
+		impl.faults = f
+		impl.mu.Unlock()
+	}
+}
+
+// injectFault runs the fault injector, if any, at a fault point
+func (im *indexManagerImpl) injectFault(point FaultPoint, index string) error {
+	im.mu.RLock()
+	f := im.faults
+	im.mu.RUnlock()
+	if f == nil {
+		return nil
+	}
+	return f.Inject(point, index)
+}
@@ -0,0 +1,166 @@
+package hnswindex
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FusionMethod selects how SearchMulti combines the result lists of its
+// queries
+type FusionMethod string
+
+const (
+	// FusionRRF scores a chunk by reciprocal rank fusion: the sum of
+	// 1/(RRFK + rank) over every query that found it. Chunks found by
+	// several phrasings rise to the top regardless of raw score scales.
+	FusionRRF FusionMethod = "rrf"
+	// FusionMax scores a chunk by its best score across the queries
+	FusionMax FusionMethod = "max"
+)
+
+// RRFK is the rank offset of reciprocal rank fusion. Larger values flatten
+// the difference between top and lower ranks.
+const RRFK = 60
+
+// fusionOversample is how many hits are fetched per query for each
+// requested result, so chunks ranked lower by one phrasing can still be
+// lifted by the others
+const fusionOversample = 3
+
+// fusedHit accumulates the fused score of a chunk across queries
+type fusedHit struct {
+	result  SearchResult
+	score   float64
+	queries []string // Queries that found the chunk, in query order
+}
+
+// SearchMulti searches the index with several phrasings of the same
+// question and fuses their results into one list. An empty fusion method
+// means FusionRRF.
+func (i *Index) SearchMulti(queries []string, limit int, fusion FusionMethod) ([]SearchResult, error) {
+	return i.SearchMultiWithOptions(queries, limit, fusion, SearchOptions{})
+}
+
+// SearchMultiWithOptions is SearchMulti with search options. Filters apply
+// to every query; grouping applies to the fused list. With Explain, each
+// result records its fusion score as a "fusion" adjustment.
+func (i *Index) SearchMultiWithOptions(queries []string, limit int, fusion FusionMethod, options SearchOptions) ([]SearchResult, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.SearchMultiWithOptions(queries, limit, fusion, options)
+	}
+	return []SearchResult{}, fmt.Errorf("implementation not available")
+}
+
+// SearchMultiWithOptions implementation
+func (i *indexImpl) SearchMultiWithOptions(queries []string, limit int, fusion FusionMethod, options SearchOptions) ([]SearchResult, error) {
+	if len(queries) == 0 {
+		return nil, errors.New("no queries to search")
+	}
+	switch fusion {
+	case "":
+		fusion = FusionRRF
+	case FusionRRF, FusionMax:
+	default:
+		return nil, fmt.Errorf("unknown fusion method %q", fusion)
+	}
+
+	start := time.Now()
+	graphLimit := options.graphLimit(limit) * fusionOversample
+	options.IgnoreBoostRules = true
+
+	fused := make(map[string]*fusedHit)
+	for _, query := range queries {
+		var timing SearchTiming
+		hnswResults, scores, err := i.searchHits(query, graphLimit, options, &timing)
+		if err != nil {
+			return nil, fmt.Errorf("query %q: %w", query, err)
+		}
+
+		rank := 0
+		for n, hr := range hnswResults {
+			result, ok := i.hydrateHit(hr, n, options, scores)
+			if !ok {
+				continue
+			}
+			rank++
+
+			var score float64
+			if fusion == FusionRRF {
+				score = 1 / float64(RRFK+rank)
+			} else {
+				score = result.Score
+			}
+
+			hit, seen := fused[result.ChunkID]
+			if !seen {
+				fused[result.ChunkID] = &fusedHit{result: result, score: score, queries: []string{query}}
+				continue
+			}
+			if fusion == FusionRRF {
+				hit.score += score
+			} else if score > hit.score {
+				hit.score = score
+				hit.result = result
+			}
+			hit.queries = append(hit.queries, query)
+		}
+	}
+
+	hits := make([]*fusedHit, 0, len(fused))
+	for _, hit := range fused {
+		hits = append(hits, hit)
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].score != hits[b].score {
+			return hits[a].score > hits[b].score
+		}
+		return hits[a].result.ChunkID < hits[b].result.ChunkID
+	})
+
+	results := make([]SearchResult, 0, min(limit, len(hits)))
+	limiter := newResultLimiter(limit, options)
+	for _, hit := range hits {
+		if limiter.done() {
+			break
+		}
+		result := hit.result
+		if options.Explain {
+			result.Explain.Adjustments = append(result.Explain.Adjustments, ScoreAdjustment{
+				Stage:  "fusion",
+				Delta:  hit.score - result.Score,
+				Reason: fmt.Sprintf("%s over %d of %d queries", fusion, len(hit.queries), len(queries)),
+			})
+			result.Explain.Score = hit.score
+		}
+		result.Score = hit.score
+		if limiter.accept(&result) {
+			results = append(results, result)
+		}
+	}
+
+	limiter.addMatches(results)
+	addSnippets(results, strings.Join(queries, " "), options)
+
+	duration := time.Since(start)
+	i.manager.emitSearch(SearchEvent{
+		Index:    i.name,
+		Query:    strings.Join(queries, " | "),
+		Limit:    limit,
+		Results:  len(results),
+		Duration: duration,
+	})
+
+	slog.Debug("Multi-query search completed",
+		"index", i.name,
+		"queries", len(queries),
+		"fusion", fusion,
+		"results", len(results),
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	return results, nil
+}
@@ -0,0 +1,58 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchMulti(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("fusion")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "doc1", Title: "Failover", Content: "database failover procedure"},
+		{URI: "doc2", Title: "Backups", Content: "restoring nightly backups"},
+		{URI: "doc3", Title: "Onboarding", Content: "new hire onboarding checklist"},
+		{URI: "doc4", Title: "Oncall", Content: "oncall escalation policy"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	// Each phrasing contributes its best hit
+	results, err := index.SearchMulti([]string{"database failover procedure", "restoring nightly backups"}, 2, FusionMax)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	uris := []string{results[0].Document.URI, results[1].Document.URI}
+	assert.ElementsMatch(t, []string{"doc1", "doc2"}, uris)
+
+	// RRF sums reciprocal ranks, so a chunk ranked first by every query wins
+	queries := []string{"database failover procedure", "database failover procedure"}
+	results, err = index.SearchMultiWithOptions(queries, 3, "", SearchOptions{Explain: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "doc1", results[0].Document.URI)
+	assert.InDelta(t, 2.0/(RRFK+1), results[0].Score, 1e-9)
+	require.NotNil(t, results[0].Explain)
+	require.NotEmpty(t, results[0].Explain.Adjustments)
+	adj := results[0].Explain.Adjustments[len(results[0].Explain.Adjustments)-1]
+	assert.Equal(t, "fusion", adj.Stage)
+	assert.Equal(t, results[0].Score, results[0].Explain.Score)
+
+	seen := make(map[string]bool)
+	for idx, r := range results {
+		assert.False(t, seen[r.ChunkID], "chunk %s returned twice", r.ChunkID)
+		seen[r.ChunkID] = true
+		if idx > 0 {
+			assert.GreaterOrEqual(t, results[idx-1].Score, r.Score)
+		}
+	}
+
+	_, err = index.SearchMulti(nil, 5, FusionRRF)
+	assert.Error(t, err)
+	_, err = index.SearchMulti([]string{"failover"}, 5, "borda")
+	assert.Error(t, err)
+}
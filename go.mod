@@ -7,12 +7,17 @@ toolchain go1.24.4
 require (
 	github.com/JohannesKaufmann/html-to-markdown v1.6.0
 	github.com/coder/hnsw v0.6.1
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/klauspost/compress v1.18.0
 	github.com/pkoukk/tiktoken-go v0.1.7
 	github.com/spf13/cobra v1.10.1
 	github.com/spf13/viper v1.20.0-alpha.6
 	github.com/stretchr/testify v1.11.1
 	github.com/virtomize/confluence-go-api v1.5.1
+	github.com/yalue/onnxruntime_go v1.22.0
 	go.etcd.io/bbolt v1.3.11
+	golang.org/x/text v0.23.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -21,7 +26,6 @@ require (
 	github.com/chewxy/math32 v1.11.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dlclark/regexp2 v1.11.4 // indirect
-	github.com/fsnotify/fsnotify v1.7.0 // indirect
 	github.com/go-viper/mapstructure/v2 v2.0.0 // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/google/renameio v1.0.1 // indirect
@@ -44,6 +48,4 @@ require (
 	golang.org/x/exp v0.0.0-20250218142911-aa4b98e5adaa // indirect
 	golang.org/x/net v0.38.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
-	golang.org/x/text v0.23.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,346 @@
+package hnswindex
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// DefaultGrepLimit is the number of matches returned when GrepOptions.Limit is 0
+const DefaultGrepLimit = 100
+
+// GrepOptions configures Index.Grep
+type GrepOptions struct {
+	Regexp     bool // Treat the pattern as a Go regular expression
+	IgnoreCase bool
+	Limit      int // Maximum matches (default DefaultGrepLimit)
+}
+
+// GrepMatch is a chunk containing the pattern
+type GrepMatch struct {
+	URI      string `json:"uri"`
+	Title    string `json:"title"`
+	ChunkID  string `json:"chunk_id"`
+	Position int    `json:"position"`
+	Line     string `json:"line"`  // Line of the chunk containing the first match
+	Start    int    `json:"start"` // Byte offset of the first match in the chunk text
+	End      int    `json:"end"`
+}
+
+// Grep finds chunks containing an exact string or regular expression, for
+// lookups embeddings handle poorly, such as error codes and config keys.
+// Candidate chunks are selected with an in-memory trigram index that is
+// built on the first call and kept up to date as documents change.
+// Matches are ordered by URI and chunk position.
+func (i *Index) Grep(pattern string, opts GrepOptions) ([]GrepMatch, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Grep(pattern, opts)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Grep implementation
+func (i *indexImpl) Grep(pattern string, opts GrepOptions) ([]GrepMatch, error) {
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultGrepLimit
+	}
+
+	expr := pattern
+	if !opts.Regexp {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	if err := i.trigrams.build(i.manager.storage, i.name); err != nil {
+		return nil, fmt.Errorf("failed to build trigram index: %w", err)
+	}
+
+	literal := pattern
+	if opts.Regexp {
+		literal = requiredLiteral(expr)
+	}
+
+	var candidates []string
+	if len(literal) >= 3 {
+		candidates = i.trigrams.candidates(strings.ToLower(literal))
+	} else {
+		candidates = i.trigrams.all() // No usable literal: verify every chunk
+	}
+
+	var matches []GrepMatch
+	titles := make(map[string]string)
+	for _, id := range candidates {
+		chunk, err := i.manager.storage.GetChunk(i.name, id)
+		if err != nil {
+			continue // Removed since the candidates were selected
+		}
+		loc := re.FindStringIndex(chunk.Text)
+		if loc == nil {
+			continue
+		}
+
+		title, ok := titles[chunk.DocumentURI]
+		if !ok {
+			if doc, err := i.manager.storage.GetDocument(i.name, chunk.DocumentURI); err == nil {
+				title = doc.Title
+			}
+			titles[chunk.DocumentURI] = title
+		}
+
+		matches = append(matches, GrepMatch{
+			URI:      chunk.DocumentURI,
+			Title:    title,
+			ChunkID:  chunk.ID,
+			Position: chunk.Position,
+			Line:     lineAt(chunk.Text, loc[0]),
+			Start:    loc[0],
+			End:      loc[1],
+		})
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].URI != matches[b].URI {
+			return matches[a].URI < matches[b].URI
+		}
+		return matches[a].Position < matches[b].Position
+	})
+
+	// Overlapping chunks repeat the same line; keep its first occurrence
+	deduped := matches[:0]
+	for idx, m := range matches {
+		if idx > 0 && m.URI == matches[idx-1].URI && m.Line == matches[idx-1].Line {
+			continue
+		}
+		deduped = append(deduped, m)
+		if len(deduped) == opts.Limit {
+			break
+		}
+	}
+	return deduped, nil
+}
+
+// updateTrigrams refreshes a document in its index's trigram index
+func (im *indexManagerImpl) updateTrigrams(indexName, uri string) {
+	if idx, ok := im.indexes.get(indexName); ok {
+		idx.trigrams.update(im.storage, indexName, uri)
+	}
+}
+
+// lineAt returns the line of text containing byte offset pos
+func lineAt(text string, pos int) string {
+	start := strings.LastIndexByte(text[:pos], '\n') + 1
+	end := len(text)
+	if n := strings.IndexByte(text[pos:], '\n'); n >= 0 {
+		end = pos + n
+	}
+	return strings.TrimSpace(text[start:end])
+}
+
+// requiredLiteral returns the longest literal string every match of a
+// regular expression must contain, or "" if there is none
+func requiredLiteral(expr string) string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	return longestLiteral(re.Simplify())
+}
+
+func longestLiteral(re *syntax.Regexp) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return string(re.Rune)
+	case syntax.OpCapture:
+		return longestLiteral(re.Sub[0])
+	case syntax.OpPlus:
+		return longestLiteral(re.Sub[0]) // At least one repetition
+	case syntax.OpConcat:
+		var best, run string
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				run += string(sub.Rune)
+				continue
+			}
+			if len(run) > len(best) {
+				best = run
+			}
+			run = ""
+			if lit := longestLiteral(sub); len(lit) > len(best) {
+				best = lit
+			}
+		}
+		if len(run) > len(best) {
+			best = run
+		}
+		return best
+	}
+	return ""
+}
+
+// trigram is three bytes of lowercased chunk text
+type trigram [3]byte
+
+// trigramIndex maps trigrams to the chunks containing them. Removed chunks
+// leave stale ordinals in the posting lists that are filtered at query time
+// until enough accumulate to trigger a rebuild.
+type trigramIndex struct {
+	mu       sync.Mutex
+	built    bool
+	ids      map[string]uint32 // Chunk ID -> ordinal
+	chunkIDs []string          // Ordinal -> chunk ID, "" once removed
+	docs     map[string][]uint32
+	postings map[trigram][]uint32
+	stale    int
+}
+
+// build loads every chunk of the index unless the index is already built
+func (t *trigramIndex) build(s *storage.Storage, indexName string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.built {
+		return nil
+	}
+
+	t.ids = make(map[string]uint32)
+	t.chunkIDs = nil
+	t.docs = make(map[string][]uint32)
+	t.postings = make(map[trigram][]uint32)
+	t.stale = 0
+
+	err := s.ForEachChunk(indexName, func(c storage.Chunk) error {
+		t.add(c.ID, c.DocumentURI, c.Text)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	t.built = true
+	return nil
+}
+
+// add indexes a chunk; t.mu must be held
+func (t *trigramIndex) add(chunkID, uri, text string) {
+	ord := uint32(len(t.chunkIDs))
+	t.chunkIDs = append(t.chunkIDs, chunkID)
+	t.ids[chunkID] = ord
+	t.docs[uri] = append(t.docs[uri], ord)
+
+	seen := make(map[trigram]bool)
+	lower := strings.ToLower(text)
+	for idx := 0; idx+3 <= len(lower); idx++ {
+		tg := trigram{lower[idx], lower[idx+1], lower[idx+2]}
+		if !seen[tg] {
+			seen[tg] = true
+			t.postings[tg] = append(t.postings[tg], ord)
+		}
+	}
+}
+
+// removeDocument drops a document's chunks; t.mu must be held
+func (t *trigramIndex) removeDocument(uri string) {
+	for _, ord := range t.docs[uri] {
+		delete(t.ids, t.chunkIDs[ord])
+		t.chunkIDs[ord] = ""
+		t.stale++
+	}
+	delete(t.docs, uri)
+
+	// Rebuild on next use once most postings are stale
+	if t.stale > 1024 && t.stale > len(t.chunkIDs)/2 {
+		t.built = false
+	}
+}
+
+// update re-indexes a document after it was added, updated, or deleted
+func (t *trigramIndex) update(s *storage.Storage, indexName, uri string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.built {
+		return // Built from storage on first use
+	}
+
+	t.removeDocument(uri)
+	chunks, err := s.GetChunksByDocument(indexName, uri)
+	if err != nil {
+		return // Deleted
+	}
+	for _, c := range chunks {
+		t.add(c.ID, c.DocumentURI, c.Text)
+	}
+}
+
+// candidates returns the chunks containing every trigram of literal
+func (t *trigramIndex) candidates(literal string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var lists [][]uint32
+	for idx := 0; idx+3 <= len(literal); idx++ {
+		list := t.postings[trigram{literal[idx], literal[idx+1], literal[idx+2]}]
+		if len(list) == 0 {
+			return nil
+		}
+		lists = append(lists, list)
+	}
+	sort.Slice(lists, func(a, b int) bool { return len(lists[a]) < len(lists[b]) })
+
+	// Posting lists are sorted by ordinal, so intersect by merging
+	result := lists[0]
+	for _, list := range lists[1:] {
+		var merged []uint32
+		a, b := 0, 0
+		for a < len(result) && b < len(list) {
+			switch {
+			case result[a] < list[b]:
+				a++
+			case result[a] > list[b]:
+				b++
+			default:
+				merged = append(merged, result[a])
+				a++
+				b++
+			}
+		}
+		if len(merged) == 0 {
+			return nil
+		}
+		result = merged
+	}
+
+	ids := make([]string, 0, len(result))
+	for _, ord := range result {
+		if id := t.chunkIDs[ord]; id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// all returns every live chunk ID
+func (t *trigramIndex) all() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := make([]string, 0, len(t.ids))
+	for _, id := range t.chunkIDs {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,108 @@
+package hnswindex
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrep(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("grep")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "doc1", Title: "Errors", Content: "Deploys fail with ERR_CONN_1042 when the proxy is down.\nRetry later."},
+		{URI: "doc2", Title: "Config", Content: "Set max_connections to 200 in postgresql.conf."},
+		{URI: "doc3", Title: "Other", Content: "Nothing relevant here."},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	matches, err := index.Grep("ERR_CONN_1042", GrepOptions{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "doc1", matches[0].URI)
+	assert.Equal(t, "Errors", matches[0].Title)
+	assert.Equal(t, "Deploys fail with ERR_CONN_1042 when the proxy is down.", matches[0].Line)
+
+	// Literal patterns are case-sensitive unless asked otherwise
+	matches, err = index.Grep("MAX_CONNECTIONS", GrepOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+	matches, err = index.Grep("MAX_CONNECTIONS", GrepOptions{IgnoreCase: true})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "doc2", matches[0].URI)
+
+	// Regular expression metacharacters are literal without Regexp
+	matches, err = index.Grep("postgresql.conf", GrepOptions{})
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	matches, err = index.Grep(`ERR_[A-Z]+_\d+`, GrepOptions{Regexp: true})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "ERR_CONN_1042", "Deploys fail with ERR_CONN_1042 when the proxy is down."[matches[0].Start:matches[0].End])
+
+	// Patterns without a usable literal scan every chunk
+	matches, err = index.Grep(`\d{3}`, GrepOptions{Regexp: true})
+	require.NoError(t, err)
+	assert.Len(t, matches, 2)
+
+	matches, err = index.Grep(`\d{3}`, GrepOptions{Regexp: true, Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	_, err = index.Grep("(", GrepOptions{Regexp: true})
+	assert.Error(t, err)
+	_, err = index.Grep("", GrepOptions{})
+	assert.Error(t, err)
+}
+
+func TestGrep_FollowsUpdates(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("grep")
+	require.NoError(t, err)
+
+	_, err = index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "doc1", Title: "One", Content: "Code E1001 means disk full."},
+	}, nil)
+	require.NoError(t, err)
+
+	// Build the trigram index before changing documents
+	matches, err := index.Grep("E1001", GrepOptions{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	_, err = index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "doc1", Title: "One", Content: "Code E2002 means disk full."},
+		{URI: "doc2", Title: "Two", Content: "Code E1001 means quota exceeded."},
+	}, nil)
+	require.NoError(t, err)
+
+	matches, err = index.Grep("E1001", GrepOptions{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "doc2", matches[0].URI)
+
+	matches, err = index.Grep("E2002", GrepOptions{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "doc1", matches[0].URI)
+
+	require.NoError(t, index.DeleteDocument("doc1"))
+	matches, err = index.Grep("E2002", GrepOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestRequiredLiteral(t *testing.T) {
+	assert.Equal(t, "ERR_", requiredLiteral(`ERR_[A-Z]+`))
+	assert.Equal(t, "connection", strings.ToLower(requiredLiteral(`(?i)connection.*timeout`)))
+	assert.Equal(t, "", requiredLiteral(`foo|bar`))
+	assert.Equal(t, "", requiredLiteral(`\d+`))
+}
@@ -0,0 +1,134 @@
+package hnswindex
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultPerGroup is the number of results per group when
+// SearchOptions.PerGroup is 0
+const DefaultPerGroup = 1
+
+// perGroup returns the number of results to keep per group
+func (o SearchOptions) perGroup() int {
+	if o.PerGroup <= 0 {
+		return DefaultPerGroup
+	}
+	return o.PerGroup
+}
+
+// resultLimiter decides which hydrated results a search returns. Without
+// grouping it keeps the first limit results; with grouping it keeps the
+// first perGroup results of each of the first limit groups. Grouping by
+// document keeps the first result of each of the first limit documents and
+// collects the chunks of every result of those documents as its matches.
+type resultLimiter struct {
+	groupBy    string
+	byDocument bool
+	perGroup   int
+	limit      int
+	accepted   int
+	counts     map[string]int // Results kept per group
+
+	documents map[string]int // Position of the result of each document kept
+	best      []SearchResult // Best scoring result of each document kept
+	matches   [][]ChunkMatch // Chunks matched by each document kept
+}
+
+func newResultLimiter(limit int, options SearchOptions) *resultLimiter {
+	return &resultLimiter{
+		groupBy:    options.GroupBy,
+		byDocument: options.GroupBy == "" && options.GroupByDocument,
+		perGroup:   options.perGroup(),
+		limit:      limit,
+		counts:     make(map[string]int),
+		documents:  make(map[string]int),
+	}
+}
+
+// accept reports whether result should be returned, setting its group
+func (l *resultLimiter) accept(result *SearchResult) bool {
+	if l.byDocument {
+		match := ChunkMatch{
+			ChunkID:    result.ChunkID,
+			ChunkText:  result.ChunkText,
+			ChunkTitle: result.ChunkTitle,
+			Score:      result.Score,
+			TimeRange:  result.TimeRange,
+		}
+		if n, ok := l.documents[result.Document.URI]; ok {
+			l.matches[n] = append(l.matches[n], match)
+			if result.Score > l.best[n].Score {
+				l.best[n] = *result
+			}
+			return false
+		}
+		if l.accepted == l.limit {
+			return false
+		}
+		l.documents[result.Document.URI] = l.accepted
+		l.best = append(l.best, *result)
+		l.matches = append(l.matches, []ChunkMatch{match})
+		l.accepted++
+		return true
+	}
+
+	if l.groupBy == "" {
+		if l.accepted == l.limit {
+			return false
+		}
+		l.accepted++
+		return true
+	}
+
+	group := groupValue(result.Document.Metadata[l.groupBy])
+	count, seen := l.counts[group]
+	if (!seen && len(l.counts) == l.limit) || count == l.perGroup {
+		return false
+	}
+	l.counts[group] = count + 1
+	l.accepted++
+	result.Group = group
+	return true
+}
+
+// done reports whether no further result can be accepted
+func (l *resultLimiter) done() bool {
+	if l.byDocument {
+		return false // Later hits may be further matches of kept documents
+	}
+	if l.groupBy == "" {
+		return l.accepted == l.limit
+	}
+	return l.accepted == l.limit*l.perGroup
+}
+
+// addMatches replaces the results kept, in the order they were accepted,
+// by the best scoring result of their document, with its matches best
+// first, when grouping by document. Graph hits are only roughly in score
+// order, so a later chunk of a document may score higher.
+func (l *resultLimiter) addMatches(results []SearchResult) {
+	if !l.byDocument {
+		return
+	}
+	for n := range results {
+		matches := l.matches[n]
+		sort.SliceStable(matches, func(a, b int) bool {
+			return matches[a].Score > matches[b].Score
+		})
+		results[n] = l.best[n]
+		results[n].Matches = matches
+	}
+}
+
+// groupValue formats a metadata value as a group key. Documents without
+// the field are grouped under "".
+func groupValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,155 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch_GroupBy(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("groups")
+	require.NoError(t, err)
+
+	var docs []Document
+	for n, space := range []string{"ENG", "ENG", "ENG", "OPS", "OPS", "HR", ""} {
+		doc := Document{
+			URI:     fmt.Sprintf("doc%d", n),
+			Title:   fmt.Sprintf("Doc %d", n),
+			Content: "database failover procedure",
+		}
+		if space != "" {
+			doc.Metadata = map[string]interface{}{"space_key": space}
+		}
+		docs = append(docs, doc)
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	// One result per group, for every group
+	results, err := index.SearchWithOptions("database failover procedure", 10, SearchOptions{GroupBy: "space_key"})
+	require.NoError(t, err)
+	groups := make(map[string]int)
+	for _, r := range results {
+		groups[r.Group]++
+	}
+	assert.Equal(t, map[string]int{"ENG": 1, "OPS": 1, "HR": 1, "": 1}, groups)
+
+	// limit caps the number of groups
+	results, err = index.SearchWithOptions("database failover procedure", 2, SearchOptions{GroupBy: "space_key", PerGroup: 2})
+	require.NoError(t, err)
+	groups = make(map[string]int)
+	for _, r := range results {
+		groups[r.Group]++
+		assert.Equal(t, r.Document.Metadata["space_key"], groupMeta(r.Group))
+	}
+	assert.Len(t, groups, 2)
+	for group, count := range groups {
+		assert.LessOrEqual(t, count, 2, group)
+	}
+
+	// The iterator applies the same grouping
+	groups = make(map[string]int)
+	for r, err := range index.SearchIterWithOptions("database failover procedure", 10, SearchOptions{GroupBy: "space_key", PerGroup: 2}) {
+		require.NoError(t, err)
+		groups[r.Group]++
+	}
+	assert.Equal(t, map[string]int{"ENG": 2, "OPS": 2, "HR": 1, "": 1}, groups)
+}
+
+// groupMeta returns the metadata value expected for a group
+func groupMeta(group string) interface{} {
+	if group == "" {
+		return nil
+	}
+	return group
+}
+
+func TestSearch_GroupByDocument(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 0
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("docs")
+	require.NoError(t, err)
+
+	long := strings.Repeat("Database failover moves writes to the replica. ", 30)
+	addDocuments(t, index,
+		Document{URI: "long", Title: "Long", Content: long},
+		Document{URI: "short1", Title: "Short 1", Content: "Database failover checklist"},
+		Document{URI: "short2", Title: "Short 2", Content: "Failover of the database"},
+	)
+
+	ungrouped, err := index.SearchWithOptions("database failover", 3, SearchOptions{})
+	require.NoError(t, err)
+	uris := resultURIs(ungrouped)
+	require.Len(t, uris, 3)
+
+	results, err := index.SearchWithOptions("database failover", 3, SearchOptions{GroupByDocument: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"long", "short1", "short2"}, resultURIs(results))
+	for _, r := range results {
+		require.NotEmpty(t, r.Matches, r.Document.URI)
+		assert.Equal(t, r.ChunkID, r.Matches[0].ChunkID)
+		assert.Equal(t, r.Score, r.Matches[0].Score)
+		for n := 1; n < len(r.Matches); n++ {
+			assert.GreaterOrEqual(t, r.Matches[n-1].Score, r.Matches[n].Score)
+		}
+		if r.Document.URI == "long" {
+			assert.Greater(t, len(r.Matches), 1, "several chunks of the long document match")
+		} else {
+			assert.Len(t, r.Matches, 1)
+		}
+	}
+
+	// limit caps the number of documents
+	results, err = index.SearchWithOptions("database failover", 1, SearchOptions{GroupByDocument: true})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// The iterator returns the same documents, with all their matches
+	var iterated []SearchResult
+	for r, err := range index.SearchIterWithOptions("database failover", 3, SearchOptions{GroupByDocument: true}) {
+		require.NoError(t, err)
+		iterated = append(iterated, r)
+	}
+	grouped, err := index.SearchWithOptions("database failover", 3, SearchOptions{GroupByDocument: true})
+	require.NoError(t, err)
+	require.Equal(t, resultURIs(grouped), resultURIs(iterated))
+	for n := range grouped {
+		assert.Len(t, iterated[n].Matches, len(grouped[n].Matches))
+	}
+}
+
+func TestSearch_GroupByDocument_ContentDefinedChunks(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 0
+	cfg.ContentDefinedChunks = true
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("docs")
+	require.NoError(t, err)
+
+	var long strings.Builder
+	for n := range 30 {
+		fmt.Fprintf(&long, "Database failover %d moves writes to replica %d.\n", n, n*7%11)
+	}
+	addDocuments(t, index,
+		Document{URI: "long", Title: "Long", Content: long.String()},
+		Document{URI: "short1", Title: "Short 1", Content: "Database failover checklist"},
+		Document{URI: "short2", Title: "Short 2", Content: "Failover of the database"},
+	)
+
+	results, err := index.SearchWithOptions("database failover", 3, SearchOptions{GroupByDocument: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"long", "short1", "short2"}, resultURIs(results))
+	for _, r := range results {
+		if r.Document.URI == "long" {
+			assert.Greater(t, len(r.Matches), 1, "several chunks of the long document match")
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package hnswindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// DefaultModifiedKeys are the metadata keys checked, in order, for a
+// document's last-modified time
+var DefaultModifiedKeys = []string{"modified_time", "modified_date", "updated_at", "last_modified", "date"}
+
+// modifiedLayouts are the time formats accepted in last-modified metadata
+var modifiedLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000Z0700", // Confluence
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02",
+}
+
+// HealthOptions configures Index.HealthCheck
+type HealthOptions struct {
+	// CheckLinks checks that each document's source still exists: web URLs
+	// (the "url" metadata or an http(s) URI) with an HTTP HEAD request and
+	// file:// URIs on disk
+	CheckLinks bool
+
+	// MaxAge marks documents last modified longer ago as stale (0 disables)
+	MaxAge time.Duration
+
+	// ModifiedKeys overrides DefaultModifiedKeys
+	ModifiedKeys []string
+
+	// PruneDead deletes documents whose source is gone (404/410 or a
+	// missing file). Unreachable sources are reported but never pruned.
+	PruneDead bool
+
+	// PruneStale deletes stale documents
+	PruneStale bool
+
+	Concurrency int           // Parallel link checks (default 8)
+	Timeout     time.Duration // Per-request timeout (default 10s)
+	Client      *http.Client  // Optional HTTP client, e.g. with credentials
+}
+
+// DocumentHealth describes a document flagged by a health check
+type DocumentHealth struct {
+	URI          string
+	Title        string
+	URL          string    // Checked location, if any
+	StatusCode   int       // HTTP status of the link check
+	Error        string    // Why the source could not be checked
+	LastModified time.Time // Zero if unknown
+}
+
+// HealthReport is the result of a health check
+type HealthReport struct {
+	Checked     int              // Documents examined
+	Dead        []DocumentHealth // Source no longer exists
+	Unreachable []DocumentHealth // Source could not be checked (network or server errors)
+	Stale       []DocumentHealth // Not modified within MaxAge
+	NoDate      int              // Documents without a last-modified time
+	Pruned      []string         // URIs deleted by PruneDead/PruneStale
+}
+
+// HealthCheck reports indexed documents whose source is gone or whose
+// content has not been updated within opts.MaxAge, optionally pruning them
+func (i *Index) HealthCheck(ctx context.Context, opts HealthOptions) (*HealthReport, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.HealthCheck(ctx, opts)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// linkStatus is the outcome of checking one document's source
+type linkStatus struct {
+	url        string
+	statusCode int
+	err        error
+	dead       bool
+}
+
+// HealthCheck implementation
+func (i *indexImpl) HealthCheck(ctx context.Context, opts HealthOptions) (*HealthReport, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+	if opts.ModifiedKeys == nil {
+		opts.ModifiedKeys = DefaultModifiedKeys
+	}
+
+	var docs []Document
+	err := forEachStoredDocument(i.manager.storage, i.name, func(stored *storage.Document) error {
+		doc := fromStorageDocument(stored)
+		doc.Content = "" // Not needed; keeps memory bounded for large indexes
+		docs = append(docs, doc)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	report := &HealthReport{Checked: len(docs)}
+	now := time.Now()
+
+	// Check links in parallel
+	statuses := make([]linkStatus, len(docs))
+	if opts.CheckLinks {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, opts.Concurrency)
+		for idx := range docs {
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return nil, ctx.Err()
+			case sem <- struct{}{}:
+			}
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				statuses[idx] = checkLink(ctx, opts.Client, docs[idx])
+			}(idx)
+		}
+		wg.Wait()
+	}
+
+	prune := make(map[string]bool)
+	for idx, doc := range docs {
+		status := statuses[idx]
+		modified, hasDate := metadataTime(doc, opts.ModifiedKeys)
+		if !hasDate {
+			// Fall back to the file's modification time
+			if path, ok := filePath(doc.URI); ok {
+				if info, err := os.Stat(path); err == nil {
+					modified, hasDate = info.ModTime(), true
+				}
+			}
+		}
+		if !hasDate {
+			report.NoDate++
+		}
+
+		health := DocumentHealth{
+			URI:          doc.URI,
+			Title:        doc.Title,
+			URL:          status.url,
+			StatusCode:   status.statusCode,
+			LastModified: modified,
+		}
+		if status.err != nil {
+			health.Error = status.err.Error()
+		}
+
+		switch {
+		case status.dead:
+			report.Dead = append(report.Dead, health)
+			if opts.PruneDead {
+				prune[doc.URI] = true
+			}
+		case status.err != nil:
+			report.Unreachable = append(report.Unreachable, health)
+		}
+
+		if opts.MaxAge > 0 && hasDate && now.Sub(modified) > opts.MaxAge {
+			report.Stale = append(report.Stale, health)
+			if opts.PruneStale {
+				prune[doc.URI] = true
+			}
+		}
+	}
+
+	for _, doc := range docs {
+		if !prune[doc.URI] {
+			continue
+		}
+		if err := i.DeleteDocument(doc.URI); err != nil {
+			return report, fmt.Errorf("failed to prune %s: %w", doc.URI, err)
+		}
+		report.Pruned = append(report.Pruned, doc.URI)
+	}
+
+	slog.Info("Health check complete",
+		"index", i.name,
+		"checked", report.Checked,
+		"dead", len(report.Dead),
+		"unreachable", len(report.Unreachable),
+		"stale", len(report.Stale),
+		"pruned", len(report.Pruned),
+	)
+
+	return report, nil
+}
+
+// checkLink checks whether a document's source still exists
+func checkLink(ctx context.Context, client *http.Client, doc Document) linkStatus {
+	if path, ok := filePath(doc.URI); ok {
+		status := linkStatus{url: doc.URI}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			status.dead = true
+			status.err = errors.New("file not found")
+		} else if err != nil {
+			status.err = err
+		}
+		return status
+	}
+
+	target := GetMetaOr(doc, "url", "")
+	if !isWebURL(target) {
+		target = doc.URI
+	}
+	if !isWebURL(target) {
+		return linkStatus{} // Nothing to check
+	}
+
+	status := linkStatus{url: target}
+	code, err := probe(ctx, client, http.MethodHead, target)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented) {
+		// Some servers do not support HEAD
+		code, err = probe(ctx, client, http.MethodGet, target)
+	}
+	status.statusCode = code
+
+	switch {
+	case err != nil:
+		status.err = err
+	case code == http.StatusNotFound || code == http.StatusGone:
+		status.dead = true
+		status.err = fmt.Errorf("HTTP %d", code)
+	case code >= 500 || code == http.StatusTooManyRequests:
+		status.err = fmt.Errorf("HTTP %d", code)
+	}
+	// Other 4xx codes (401, 403) mean the page exists behind authentication
+	return status
+}
+
+// probe sends a request and returns the response status code
+func probe(ctx context.Context, client *http.Client, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// metadataTime returns the first parseable time in the document metadata
+// under one of keys, such as its last-modified time
+func metadataTime(doc Document, keys []string) (time.Time, bool) {
+	for _, key := range keys {
+		switch v := doc.Metadata[key].(type) {
+		case string:
+			for _, layout := range modifiedLayouts {
+				if t, err := time.Parse(layout, v); err == nil {
+					return t, true
+				}
+			}
+			if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return time.Unix(secs, 0), true
+			}
+		case float64:
+			return time.Unix(int64(v), 0), true
+		case time.Time:
+			return v, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// filePath returns the path of a file:// URI. Paths are stored unescaped.
+func filePath(uri string) (string, bool) {
+	if !strings.HasPrefix(uri, "file://") {
+		return "", false
+	}
+	return strings.TrimPrefix(uri, "file://"), true
+}
+
+func isWebURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
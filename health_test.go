@@ -0,0 +1,96 @@
+package hnswindex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_HealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/live":
+			w.WriteHeader(http.StatusOK)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/private":
+			w.WriteHeader(http.StatusForbidden)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "present.md")
+	require.NoError(t, os.WriteFile(existing, []byte("here"), 0644))
+
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("health")
+	require.NoError(t, err)
+
+	old := time.Now().AddDate(-2, 0, 0).Format(time.RFC3339)
+	recent := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	docs := []Document{
+		{URI: server.URL + "/live", Title: "Live", Content: "live page", Metadata: map[string]interface{}{"updated_at": recent}},
+		{URI: "confluence://DOCS/1", Title: "Moved", Content: "moved page", Metadata: map[string]interface{}{"url": server.URL + "/gone", "modified_date": recent}},
+		{URI: server.URL + "/no-head", Title: "No HEAD", Content: "get only", Metadata: map[string]interface{}{"updated_at": old}},
+		{URI: server.URL + "/private", Title: "Private", Content: "auth page"},
+		{URI: server.URL + "/broken", Title: "Broken", Content: "server error"},
+		{URI: "file://" + existing, Title: "Present", Content: "present file"},
+		{URI: "file://" + filepath.Join(dir, "deleted.md"), Title: "Deleted", Content: "deleted file"},
+		{URI: "kb://faq/1", Title: "FAQ", Content: "nothing to check"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	report, err := index.HealthCheck(context.Background(), HealthOptions{
+		CheckLinks: true,
+		MaxAge:     365 * 24 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, len(docs), report.Checked)
+	assert.ElementsMatch(t, []string{"confluence://DOCS/1", "file://" + filepath.Join(dir, "deleted.md")}, healthURIs(report.Dead))
+	assert.Equal(t, []string{server.URL + "/broken"}, healthURIs(report.Unreachable))
+	assert.Equal(t, []string{server.URL + "/no-head"}, healthURIs(report.Stale))
+	assert.Equal(t, 4, report.NoDate) // private, broken, deleted file, faq
+	assert.Empty(t, report.Pruned)
+
+	// Pruning removes dead documents only
+	report, err = index.HealthCheck(context.Background(), HealthOptions{CheckLinks: true, PruneDead: true})
+	require.NoError(t, err)
+	assert.Len(t, report.Pruned, 2)
+
+	_, err = index.GetDocument("confluence://DOCS/1")
+	assert.Error(t, err)
+	_, err = index.GetDocument(server.URL + "/broken")
+	assert.NoError(t, err, "unreachable documents are never pruned")
+
+	// Stale pruning works without link checks
+	report, err = index.HealthCheck(context.Background(), HealthOptions{MaxAge: 365 * 24 * time.Hour, PruneStale: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{server.URL + "/no-head"}, report.Pruned)
+	assert.Empty(t, report.Dead)
+}
+
+func healthURIs(list []DocumentHealth) []string {
+	var uris []string
+	for _, h := range list {
+		uris = append(uris, h.URI)
+	}
+	return uris
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 	"go.etcd.io/bbolt"
@@ -17,20 +19,166 @@ type Config struct {
 	EmbedModel   string `mapstructure:"embed_model"`
 	ChunkSize    int    `mapstructure:"chunk_size"`
 	ChunkOverlap int    `mapstructure:"chunk_overlap"`
-	MaxWorkers   int    `mapstructure:"max_workers"`
+	MaxWorkers   int    `mapstructure:"max_workers"` // Documents embedded concurrently by AddDocumentBatch
 	AutoSave     bool   `mapstructure:"auto_save"`
+	QueryLog     bool   `mapstructure:"query_log"` // Record queries for QueryStats
+
+	// ContentDefinedChunks ends chunks at line and sentence ends chosen by
+	// the text before them instead of every ChunkSize tokens, so editing
+	// part of a document only changes the chunks near the edit. Indexes
+	// built without it are re-chunked, and most of their chunks embedded
+	// again, as each document is next written.
+	ContentDefinedChunks bool `mapstructure:"content_defined_chunks"`
+
+	// AddDocumentBatch checks, chunks, embeds and commits documents in
+	// stages connected by queues of PipelineQueue documents (default 64).
+	// ChunkWorkers (default 4) extract and chunk documents and MaxWorkers
+	// embed them.
+	ChunkWorkers  int `mapstructure:"chunk_workers"`
+	PipelineQueue int `mapstructure:"pipeline_queue"`
+
+	// EmbedProvider selects the embedding backend: "ollama" (default) at
+	// OllamaURL, or "job", a remote batch service at EmbedURL that chunks
+	// are submitted to in jobs of up to EmbedBatchSize texts (default 256).
+	// EmbedAPIKey, if set, is sent as a bearer token. "cohere" and "voyage"
+	// use those hosted APIs (EmbedURL overrides their base URL) with the
+	// required EmbedAPIKey; documents and queries are embedded with their
+	// respective input types. Left at "nomic-embed-text", EmbedModel falls
+	// back to the provider's default model. "onnx" runs the model in
+	// EmbedModelPath in-process with the ONNX Runtime library at
+	// ONNXRuntimePath, in builds with the onnx tag.
+	EmbedProvider   string `mapstructure:"embed_provider"`
+	EmbedURL        string `mapstructure:"embed_url"`
+	EmbedAPIKey     string `mapstructure:"embed_api_key"`
+	EmbedBatchSize  int    `mapstructure:"embed_batch_size"`
+	EmbedModelPath  string `mapstructure:"embed_model_path"`
+	ONNXRuntimePath string `mapstructure:"onnxruntime_path"`
+
+	// Options passed through to the embedding API with every request, for
+	// models whose defaults do not suit; each provider sends those its API
+	// has. EmbedTruncate sets whether inputs longer than the model's
+	// context are cut (nil leaves the API's default), EmbedKeepAlive how
+	// long Ollama keeps the model loaded, and EmbedModelOptions Ollama's
+	// model options, such as num_ctx. EmbedDimensions asks models that can
+	// shorten their embeddings (Ollama, job services, Cohere embed-v4,
+	// Voyage) for that many dimensions; indexes keep the dimension they
+	// were built with, so changing it takes a rebuild.
+	EmbedTruncate     *bool          `mapstructure:"embed_truncate"`
+	EmbedKeepAlive    string         `mapstructure:"embed_keep_alive"`
+	EmbedModelOptions map[string]any `mapstructure:"embed_model_options"`
+	EmbedDimensions   int            `mapstructure:"embed_dimensions"`
+
+	// AdaptiveEmbedding tunes the texts per embedder call (up to
+	// EmbedBatchSize, default 256) and the calls made at once (up to
+	// MaxWorkers) from observed latency and errors: both grow while calls
+	// stay fast and back off when they slow down or fail, so one setting
+	// suits a local GPU and a rate-limited API. See IndexManager.EmbedTuning.
+	AdaptiveEmbedding bool `mapstructure:"adaptive_embedding"`
+
+	// LateInteraction also embeds sub-chunks of LateInteractionWords words
+	// (default DefaultLateInteractionWords) of every chunk longer than
+	// that, and stores their vectors with the chunk, for searches with
+	// SearchOptions.LateInteraction. It about doubles the text embedded
+	// and stores a vector per sub-chunk.
+	LateInteraction      bool `mapstructure:"late_interaction"`
+	LateInteractionWords int  `mapstructure:"late_interaction_words"`
+
+	// EmbeddingCache shares embeddings of identical chunk text across
+	// indexes, keyed by the embedder's provider, model and request options;
+	// NewConfig enables it. The cache keeps up to EmbeddingCacheEntries
+	// embeddings (default DefaultEmbeddingCacheEntries), evicting the
+	// oldest; 0 disables the limit.
+	EmbeddingCache        bool `mapstructure:"embedding_cache"`
+	EmbeddingCacheEntries int  `mapstructure:"embedding_cache_entries"`
+
+	// NormalizeEmbeddings scales embeddings to unit length before they are
+	// indexed and searched. Embeddings are always checked for the expected
+	// dimension, NaN and infinite values, and zero vectors.
+	NormalizeEmbeddings bool `mapstructure:"normalize_embeddings"`
+
+	// Limits that keep a misbehaving connector from exhausting memory.
+	// Larger batches passed to AddDocumentBatch are split and processed in
+	// order; oversized documents fail with ErrLimitExceeded. 0 disables a
+	// limit.
+	MaxBatchDocuments    int `mapstructure:"max_batch_documents"`
+	MaxChunksPerDocument int `mapstructure:"max_chunks_per_document"`
+	MaxContentBytes      int `mapstructure:"max_content_bytes"`
+
+	// Limits on document metadata, which connectors sometimes fill with
+	// whole pages of HTML: MaxMetadataBytes on its JSON encoding,
+	// MaxMetadataDepth on the nesting of maps and lists (flat metadata has
+	// depth 1) and MaxMetadataValueBytes on each string value. Documents
+	// over a limit fail with ErrLimitExceeded, naming the field; with
+	// MetadataOverflow "truncate", long string values are cut to
+	// MaxMetadataValueBytes instead. 0 disables a limit.
+	MaxMetadataBytes      int    `mapstructure:"max_metadata_bytes"`
+	MaxMetadataDepth      int    `mapstructure:"max_metadata_depth"`
+	MaxMetadataValueBytes int    `mapstructure:"max_metadata_value_bytes"`
+	MetadataOverflow      string `mapstructure:"metadata_overflow"` // "reject" (default) or "truncate"
+
+	// BlobThreshold is the content size in bytes from which document bodies
+	// are stored once, by content hash, and shared between indexes. 0 stores
+	// all content inline.
+	BlobThreshold int `mapstructure:"blob_threshold"`
+
+	// Compression compresses stored document content and chunk text:
+	// "none" (default) or "zstd". Databases may mix compressed and
+	// uncompressed values, so it can be enabled at any time.
+	Compression string `mapstructure:"compression"`
+
+	// Quantization stores chunk embeddings in fewer bits: "none" (default)
+	// or "int8", one byte per component instead of four, at a small loss
+	// of precision. New indexes hold int8 vectors in their graph, a quarter
+	// of the memory; existing indexes keep their graph until rebuilt.
+	// Chunks written meanwhile store int8 embeddings in any index, and
+	// stored float32 embeddings are read alongside them.
+	Quantization string `mapstructure:"quantization"`
+
+	// StorageLayout decides where new indexes keep their documents and
+	// chunks: "shared" (default) uses buckets in indexes.db, "per_index"
+	// gives each index its own indexes/<name>/index.db so deleting or
+	// backing up an index is a file operation and writes to different
+	// indexes do not contend. Existing indexes keep their layout.
+	StorageLayout string `mapstructure:"storage_layout"`
+
+	// SnapshotStore persists the data directory to object storage for
+	// stateless deployments. At startup an empty data directory is restored
+	// from the snapshot under SnapshotKey (default DefaultSnapshotKey); with
+	// SnapshotOnSave a new snapshot is uploaded after every index save.
+	SnapshotStore  ObjectStore `mapstructure:"-"`
+	SnapshotKey    string      `mapstructure:"snapshot_key"`
+	SnapshotOnSave bool        `mapstructure:"snapshot_on_save"`
+
+	// SnapshotCompression compresses snapshots: "zstd" (default) or
+	// "gzip". Snapshots are restored whichever codec wrote them.
+	SnapshotCompression string `mapstructure:"snapshot_compression"`
+
+	// SnapshotPartSize is the size of the parts UploadSnapshot streams a
+	// snapshot in (default DefaultSnapshotPartSize). A failed part is
+	// uploaded again without starting over, and at most one part is
+	// buffered on disk.
+	SnapshotPartSize int64 `mapstructure:"snapshot_part_size"`
+
+	// MaintenanceInterval runs IndexManager.RunMaintenance in the
+	// background at this interval, such as every hour: each index is
+	// verified and repaired, expired documents are pruned and graphs with
+	// many tombstones are compacted. 0 disables it.
+	MaintenanceInterval time.Duration `mapstructure:"maintenance_interval"`
 }
 
 // NewConfig returns a new configuration with default values
 func NewConfig() *Config {
 	return &Config{
-		DataPath:     "./hnswdata",
-		OllamaURL:    "http://localhost:11434",
-		EmbedModel:   "nomic-embed-text",
-		ChunkSize:    512,
-		ChunkOverlap: 50,
-		MaxWorkers:   8,
-		AutoSave:     true,
+		DataPath:              "./hnswdata",
+		OllamaURL:             "http://localhost:11434",
+		EmbedModel:            "nomic-embed-text",
+		ChunkSize:             512,
+		ChunkOverlap:          50,
+		MaxWorkers:            8,
+		AutoSave:              true,
+		EmbeddingCache:        true,
+		EmbeddingCacheEntries: DefaultEmbeddingCacheEntries,
+		BlobThreshold:         32 * 1024,
 	}
 }
 
@@ -51,6 +199,33 @@ type Document struct {
 	Title    string                 `json:"title"`
 	Content  string                 `json:"content"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Source loads the content lazily when Content is empty, e.g.
+	// FileContent(path). Unchanged documents are detected by streaming the
+	// source through the hash; the content is only read into memory when
+	// the document is indexed.
+	Source ContentSource `json:"-"`
+
+	// Data holds binary content (images, scanned PDFs) that is converted to
+	// text by the manager's BinaryExtractor before indexing
+	Data     []byte `json:"data,omitempty"`
+	MIMEType string `json:"mime_type,omitempty"`
+
+	// Segments holds a timed transcript. It is chunked along segment
+	// boundaries and becomes the content if Content is empty.
+	Segments []Segment `json:"segments,omitempty"`
+}
+
+// Chunk represents a stored piece of a document with its embedding
+type Chunk struct {
+	ID          string                 `json:"id"`
+	DocumentURI string                 `json:"document_uri"`
+	Text        string                 `json:"text"`
+	Position    int                    `json:"position"`
+	Embedding   []float32              `json:"embedding,omitempty"`
+	Vectors     [][]float32            `json:"vectors,omitempty"` // Sub-chunk vectors (Config.LateInteraction)
+	Sparse      map[string]float32     `json:"sparse,omitempty"`  // Term weights (IndexManager.SetSparseEmbedder)
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // SearchResult represents a search result
@@ -60,6 +235,45 @@ type SearchResult struct {
 	ChunkID   string   `json:"chunk_id"`
 	ChunkText string   `json:"chunk_text"`
 	IndexName string   `json:"index_name"`
+
+	// ChunkTitle is the title given to the matched chunk (chunk titling only)
+	ChunkTitle string `json:"chunk_title,omitempty"`
+
+	// TimeRange locates the matched chunk in a transcript (transcripts only)
+	TimeRange *TimeRange `json:"time_range,omitempty"`
+
+	// QueryID identifies the logged query for RecordFeedback (query logging only)
+	QueryID uint64 `json:"query_id,omitempty"`
+
+	// Group is the value of the SearchOptions.GroupBy field (grouped searches only)
+	Group string `json:"group,omitempty"`
+
+	// Pinned is set on the result placed first by a pinning boost rule
+	Pinned bool `json:"pinned,omitempty"`
+
+	// Matches are the chunks of the document that matched, best first, the
+	// first being the result's own (SearchOptions.GroupByDocument only)
+	Matches []ChunkMatch `json:"matches,omitempty"`
+
+	// Snippet is the part of ChunkText that best matches the query, on one
+	// line, and SnippetHighlights and ChunkHighlights locate the query
+	// keywords in it and in ChunkText (SearchOptions.Snippets only)
+	Snippet           string `json:"snippet,omitempty"`
+	SnippetHighlights []Span `json:"snippet_highlights,omitempty"`
+	ChunkHighlights   []Span `json:"chunk_highlights,omitempty"`
+
+	// Explain is only populated when SearchOptions.Explain is set
+	Explain *SearchExplain `json:"explain,omitempty"`
+}
+
+// ChunkMatch is a chunk of a document matched by a search grouped by
+// document
+type ChunkMatch struct {
+	ChunkID    string     `json:"chunk_id"`
+	ChunkText  string     `json:"chunk_text"`
+	ChunkTitle string     `json:"chunk_title,omitempty"`
+	Score      float64    `json:"score"`
+	TimeRange  *TimeRange `json:"time_range,omitempty"`
 }
 
 // BatchResult represents the result of batch document processing
@@ -70,24 +284,47 @@ type BatchResult struct {
 	UnchangedDocuments int               `json:"unchanged_documents"`
 	ProcessedChunks    int               `json:"processed_chunks"`
 	FailedURIs         map[string]string `json:"failed_uris,omitempty"`
+
+	// Timing and throughput, so ingest performance can be tracked across
+	// runs. DurationEmbedding and DurationStorage sum the time spent in the
+	// embed and commit stages across workers, so with several embed workers
+	// DurationEmbedding can exceed DurationTotal. DurationStorage includes
+	// saving the graph. TokensEmbedded counts the tokens of the chunks
+	// embedded; chunks whose embeddings were reused are not counted. In a
+	// ManagerTx batch, embedding and the commit are shared, so each index
+	// reports their whole duration.
+	DurationTotal     time.Duration `json:"duration_total"`
+	DurationEmbedding time.Duration `json:"duration_embedding"`
+	DurationStorage   time.Duration `json:"duration_storage"`
+	ChunksPerSecond   float64       `json:"chunks_per_second"`
+	TokensEmbedded    int           `json:"tokens_embedded"`
 }
 
 // ProgressUpdate represents a progress update during batch processing
 type ProgressUpdate struct {
-	Stage   string  `json:"stage"`   // "checking", "processing", "embedding", "saving"
+	Stage   string  `json:"stage"`   // "checking", "extracting", "processing", "embedding", "saving"
 	Current int     `json:"current"` // Current item number
 	Total   int     `json:"total"`   // Total items
 	Message string  `json:"message"` // Human-readable message
 	URI     string  `json:"uri,omitempty"` // Optional: current document URI
 }
 
-// IndexStats represents statistics for an index
+// IndexStats represents statistics for an index. Times are UTC, encoded
+// in JSON as RFC 3339 strings and omitted when unknown.
 type IndexStats struct {
-	Name          string `json:"name"`
-	DocumentCount int    `json:"document_count"`
-	ChunkCount    int    `json:"chunk_count"`
-	LastUpdated   string `json:"last_updated"`
-	SizeBytes     int64  `json:"size_bytes"`
+	Name          string            `json:"name"`
+	Description   string            `json:"description,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	DocumentCount int       `json:"document_count"`
+	ChunkCount    int       `json:"chunk_count"`
+	LastUpdated   time.Time `json:"last_updated,omitzero"`  // Documents last added, updated or cleared
+	CreatedAt     time.Time `json:"created_at,omitzero"`    // Unknown for indexes from before this was recorded
+	LastSavedAt   time.Time `json:"last_saved_at,omitzero"` // HNSW graph last written to disk
+	LastSyncAt    time.Time `json:"last_sync_at,omitzero"`  // A connector last called SetSyncCursor
+	SizeBytes     int64     `json:"size_bytes"`
+
+	// Sealed is the seal of a sealed index (see Index.Seal)
+	Sealed *SealInfo `json:"sealed,omitempty"`
 }
 
 // AddOptions configures document addition behavior
@@ -160,6 +397,29 @@ func (im *IndexManager) ListIndexes() ([]string, error) {
 
 // Close closes the index manager and all resources
 func (im *IndexManager) Close() error {
+	// Let snapshot uploads triggered by the last saves finish
+	if impl := im.getImpl(); impl != nil && impl.maintenance != nil {
+		impl.maintenance.close()
+	}
+	if impl := im.getImpl(); impl != nil && impl.snapshots != nil {
+		impl.snapshots.wait()
+	}
+	if impl := im.getImpl(); impl != nil && impl.migration != nil {
+		impl.migration.close()
+	}
+	if impl := im.getImpl(); impl != nil {
+		// A snapshot opened read-only shares the embedder of its parent
+		if closer, ok := impl.embedder.(io.Closer); ok && impl.readOnly == nil {
+			closer.Close()
+		}
+		impl.closeQueryEmbedders()
+	}
+	if impl := im.getImpl(); impl != nil && impl.readOnly != nil {
+		if err := impl.readOnly.close(impl); err != nil {
+			return err
+		}
+	}
+
 	im.mu.Lock()
 	defer im.mu.Unlock()
 
@@ -216,12 +476,28 @@ func (i *Index) AddDocumentBatchWithOptions(ctx context.Context, docs []Document
 
 // Search performs a semantic search on the index
 func (i *Index) Search(query string, limit int) ([]SearchResult, error) {
+	return i.SearchWithOptions(query, limit, SearchOptions{})
+}
+
+// SearchWithOptions performs a semantic search on the index with options
+// The options allow attaching scoring details to each result for debugging.
+func (i *Index) SearchWithOptions(query string, limit int, options SearchOptions) ([]SearchResult, error) {
 	if impl := i.getImpl(); impl != nil {
-		return impl.Search(query, limit)
+		return impl.SearchWithOptions(query, limit, options)
 	}
 	return []SearchResult{}, fmt.Errorf("implementation not available")
 }
 
+// WarmUp searches the index for query like Search, which loads the
+// embedding model and pages the graph and stored chunks in, without
+// recording the query in the query log or emitting a search event
+func (i *Index) WarmUp(query string, limit int) error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.WarmUp(query, limit)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
 // GetDocument retrieves a document by URI
 func (i *Index) GetDocument(uri string) (*Document, error) {
 	if impl := i.getImpl(); impl != nil {
@@ -1,7 +1,11 @@
 package hnswindex
 
 import (
+	"context"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -84,7 +88,7 @@ func TestIndexStats(t *testing.T) {
 		Name:          "test-index",
 		DocumentCount: 100,
 		ChunkCount:    500,
-		LastUpdated:   "2024-01-01T00:00:00Z",
+		LastUpdated:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
 		SizeBytes:     1024000,
 	}
 
@@ -113,4 +117,46 @@ func TestNewIndexManager_ValidConfig(t *testing.T) {
 	
 	err = manager.Close()
 	assert.NoError(t, err)
-}
\ No newline at end of file
+}
+func TestCreateIndex_InvalidName(t *testing.T) {
+	manager := newMockManager(t, nil)
+
+	for _, name := range []string{"", "_config", "../escape", "with space"} {
+		_, err := manager.CreateIndex(name)
+		assert.ErrorIs(t, err, ErrInvalidIndexName, name)
+	}
+
+	names, err := manager.ListIndexes()
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestCreateIndex_NamesDifferingByCase(t *testing.T) {
+	cfg := NewConfig()
+	cfg.StorageLayout = "per_index"
+	manager := newMockManager(t, cfg)
+
+	names := []string{"docs", "Docs", "Team/Docs", "team/docs", "con"}
+	for _, name := range names {
+		index, err := manager.CreateIndex(name)
+		require.NoError(t, err, name)
+		_, err = index.AddDocumentBatch(context.Background(), []Document{{URI: "doc", Title: name, Content: "content of " + name}}, nil)
+		require.NoError(t, err, name)
+	}
+
+	// Each index keeps its own directory and graph
+	dirs := make(map[string]bool)
+	for _, name := range names {
+		path := manager.getImpl().graphPath(name)
+		assert.FileExists(t, path)
+		dir := strings.ToLower(filepath.Dir(path))
+		assert.False(t, dirs[dir], name)
+		dirs[dir] = true
+
+		index, err := manager.GetIndex(name)
+		require.NoError(t, err)
+		doc, err := index.GetDocument("doc")
+		require.NoError(t, err)
+		assert.Equal(t, name, doc.Title)
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/riclib/hnswindex/internal/chunker"
@@ -19,13 +22,28 @@ import (
 
 // Ensure IndexManager is properly implemented
 type indexManagerImpl struct {
-	config   *Config
-	storage  *storage.Storage
-	embedder embedder.Embedder
-	chunker  *chunker.Chunker
-	indexes  map[string]*indexImpl
-	mu       sync.RWMutex
-	wrapper  *IndexManager // Reference to wrapper for callbacks
+	config    *Config
+	storage   *storage.Storage
+	embedder  embedder.Embedder
+	chunker   *chunker.Chunker
+	indexes   indexRegistry // Open indexes, read without locking
+	extractor BinaryExtractor // Converts binary document content to text
+	titler    ChunkTitler // Titles chunks while indexing
+	faults    FaultInjector // Set by tests to fail or slow down operations
+	tuner     *embedTuner // Adapts embedding batches and concurrency (AdaptiveEmbedding only)
+	mu        sync.RWMutex // Guards extractor, titler, faults, subscriptions and queryEmbedders
+	wrapper   *IndexManager // Reference to wrapper for callbacks
+
+	subscriptions    []subscription // Event handlers, replaced on change
+	nextSubscription uint64
+
+	queryEmbedders map[string]Embedder // Query encoders of SearchOptions.Model, by model name
+	sparse         SparseEmbedder      // Embeds the lexical leg of hybrid search, if set
+
+	snapshots *snapshotter // Uploads snapshots after saves (SnapshotOnSave only)
+	migration *docChunksMigration
+	maintenance *maintenanceLoop // Runs maintenance in the background (MaintenanceInterval only)
+	readOnly *readOnlySnapshot // Set on managers opened with OpenSnapshot
 }
 
 // Ensure Index is properly implemented
@@ -33,23 +51,68 @@ type indexImpl struct {
 	name     string
 	manager  *indexManagerImpl
 	hnswIndex *indexer.HNSWIndex
-	mu       sync.RWMutex
+	mu       sync.RWMutex // Held shared by writes, exclusively by DeleteIndex
+	deleted  bool         // Set by DeleteIndex and CloseIndex under mu
+	trigrams trigramIndex // Substring index for Grep, built on first use
+	suggestions suggestIndex // Prefix index for Suggest, built on first use
+	distribution distributionCache // Last result of Distribution
+	commitMu sync.Mutex   // Orders storage commits with their graph changes
+	settingsMu sync.Mutex // Serializes changes to settings read back before they are written
+	sealed   atomic.Pointer[sealedIndex] // Set while the index is sealed
+
+	reduction *Reduction // Applied to embeddings entering the graph, if any
 }
 
 // NewIndexManagerImpl creates the actual implementation
 func NewIndexManagerImpl(config *Config) (*IndexManager, error) {
+	return newIndexManager(config, nil)
+}
+
+// newIndexManager creates the implementation, embedding with emb, or with
+// an embedder created from config if emb is nil
+func newIndexManager(config *Config, emb embedder.Embedder) (*IndexManager, error) {
+	if err := checkSnapshotCompression(config.SnapshotCompression); err != nil {
+		return nil, err
+	}
+
+	// Hydrate an empty data directory from object storage
+	snapshotKey := config.SnapshotKey
+	if snapshotKey == "" {
+		snapshotKey = DefaultSnapshotKey
+	}
+	if config.SnapshotStore != nil {
+		if _, err := DownloadSnapshot(context.Background(), config.SnapshotStore, snapshotKey, config.DataPath); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create storage
 	dbPath := filepath.Join(config.DataPath, "indexes.db")
 	store, err := storage.NewStorage(dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage: %w", err)
 	}
+	store.SetBlobThreshold(config.BlobThreshold)
+	if err := store.SetCompression(config.Compression); err != nil {
+		store.Close()
+		return nil, err
+	}
+	if err := store.SetQuantization(config.Quantization); err != nil {
+		store.Close()
+		return nil, err
+	}
+	if err := store.SetLayout(config.StorageLayout); err != nil {
+		store.Close()
+		return nil, err
+	}
 
 	// Create embedder
-	emb, err := embedder.NewOllamaEmbedder(config.OllamaURL, config.EmbedModel)
-	if err != nil {
-		store.Close()
-		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	if emb == nil {
+		emb, err = newEmbedder(config)
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("failed to create embedder: %w", err)
+		}
 	}
 
 	// Create chunker
@@ -58,13 +121,16 @@ func NewIndexManagerImpl(config *Config) (*IndexManager, error) {
 		store.Close()
 		return nil, fmt.Errorf("failed to create chunker: %w", err)
 	}
+	chunk.SetContentDefined(config.ContentDefinedChunks)
 
 	impl := &indexManagerImpl{
 		config:   config,
 		storage:  store,
 		embedder: emb,
 		chunker:  chunk,
-		indexes:  make(map[string]*indexImpl),
+	}
+	if config.AdaptiveEmbedding {
+		impl.tuner = newEmbedTuner(config.MaxWorkers, config.EmbedBatchSize)
 	}
 
 	// Create wrapper first
@@ -86,13 +152,37 @@ func NewIndexManagerImpl(config *Config) (*IndexManager, error) {
 	}
 
 	// Wrap existing indexes
-	for name := range impl.indexes {
-		manager.indexes[name] = &Index{
-			name:    name,
+	for _, idx := range impl.indexes.all() {
+		manager.indexes[idx.name] = &Index{
+			name:    idx.name,
 			manager: manager,
 		}
 	}
 
+	// Keep Grep's trigram and Suggest's prefix indexes current
+	manager.Subscribe(EventHandlerFuncs{
+		DocumentIndexed: func(e DocumentEvent) {
+			impl.updateTrigrams(e.Index, e.URI)
+			impl.updateSuggestions(e.Index, e.URI)
+		},
+		DocumentDeleted: func(e DocumentEvent) {
+			impl.updateTrigrams(e.Index, e.URI)
+			impl.updateSuggestions(e.Index, e.URI)
+		},
+	})
+
+	impl.migration = impl.startDocChunksMigration()
+	if config.MaintenanceInterval > 0 {
+		impl.maintenance = impl.startMaintenance(config.MaintenanceInterval)
+	}
+
+	if config.SnapshotStore != nil && config.SnapshotOnSave {
+		impl.snapshots = &snapshotter{manager: impl, store: config.SnapshotStore, key: snapshotKey}
+		manager.Subscribe(EventHandlerFuncs{
+			IndexSaved: func(IndexEvent) { impl.snapshots.schedule() },
+		})
+	}
+
 	return manager, nil
 }
 
@@ -104,35 +194,78 @@ func (im *indexManagerImpl) loadIndexes() error {
 	}
 
 	for _, name := range indexNames {
-		// Get embedding dimension from config or default
-		dimension := 768 // Default for nomic-embed-text
-		
-		// Create HNSW index path
-		indexPath := filepath.Join(im.config.DataPath, "indexes", name, "index.hnsw")
-		
-		// Ensure directory exists
-		indexDir := filepath.Dir(indexPath)
-		if err := ensureDir(indexDir); err != nil {
-			return fmt.Errorf("failed to create index directory: %w", err)
+		// A rebuild does not survive a restart
+		if isRebuildIndex(name) {
+			slog.Warn("Discarding unfinished index rebuild",
+				"index", strings.TrimPrefix(name, rebuildPrefix),
+			)
+			if err := im.storage.DeleteIndex(name); err != nil {
+				return fmt.Errorf("failed to discard staging index %s: %w", name, err)
+			}
+			os.RemoveAll(filepath.Dir(im.graphPath(name)))
+			continue
 		}
 
-		// Load or create HNSW index
-		hnswCfg := indexer.DefaultConfig()
-		hnswIdx, err := indexer.NewHNSWIndex(indexPath, dimension, hnswCfg)
+		impl, err := im.loadIndex(name)
 		if err != nil {
-			return fmt.Errorf("failed to load HNSW index for %s: %w", name, err)
+			return err
 		}
+		im.indexes.put(impl)
 
-		im.indexes[name] = &indexImpl{
-			name:      name,
-			manager:   im,
-			hnswIndex: hnswIdx,
-		}
+		// Warn if this process would build the index differently
+		impl.verifyConfig()
 	}
 
 	return nil
 }
 
+// loadIndex loads the graph of a stored index
+func (im *indexManagerImpl) loadIndex(name string) (*indexImpl, error) {
+	// Get embedding dimension from config or default
+	dimension, err := im.loadDimension(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config of %s: %w", name, err)
+	}
+	reduction, err := im.loadReduction(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reduction of %s: %w", name, err)
+	}
+	if reduction != nil {
+		dimension = reduction.Dimension
+	}
+	quantization, err := im.loadQuantization(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load quantization of %s: %w", name, err)
+	}
+	
+	// Create HNSW index path
+	indexPath := im.graphPath(name)
+	
+	// Ensure directory exists
+	indexDir := filepath.Dir(indexPath)
+	if err := ensureDir(indexDir); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	// Load or create HNSW index
+	hnswCfg := graphConfig(quantization)
+	hnswIdx, err := indexer.NewHNSWIndex(indexPath, dimension, hnswCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load HNSW index for %s: %w", name, err)
+	}
+
+	impl := &indexImpl{
+		name:      name,
+		manager:   im,
+		hnswIndex: hnswIdx,
+		reduction: reduction,
+	}
+	if err := impl.loadSeal(); err != nil {
+		return nil, fmt.Errorf("failed to load seal of %s: %w", name, err)
+	}
+	return impl, nil
+}
+
 // Extend the existing IndexManager methods to use the implementation
 
 func (im *IndexManager) getImpl() *indexManagerImpl {
@@ -142,13 +275,26 @@ func (im *IndexManager) getImpl() *indexManagerImpl {
 	return nil
 }
 
+// ErrInvalidIndexName is returned by CreateIndex for names that are empty,
+// longer than 64 characters, contain characters other than ASCII letters,
+// digits, '-', '_', '.', and '/', do not start with a letter or digit, or whose
+// storage buckets would collide with another index's
+var ErrInvalidIndexName = storage.ErrInvalidIndexName
+
 // CreateIndex creates a new index
 func (im *indexManagerImpl) CreateIndex(name string) (*Index, error) {
-	im.mu.Lock()
-	defer im.mu.Unlock()
+	if im.readOnly != nil {
+		return nil, ErrReadOnly
+	}
+	if err := storage.ValidateIndexName(name); err != nil {
+		return nil, err
+	}
+
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
 
 	// Check if index already exists
-	if _, exists := im.indexes[name]; exists {
+	if _, exists := im.indexes.get(name); exists {
 		// Return wrapped Index
 		return &Index{
 			name:    name,
@@ -161,14 +307,40 @@ func (im *indexManagerImpl) CreateIndex(name string) (*Index, error) {
 		return nil, fmt.Errorf("failed to create index: %w", err)
 	}
 
+	impl, err := im.newIndexImpl(name)
+	if err != nil {
+		return nil, err
+	}
+	im.indexes.put(impl)
+
+	// Record the pipeline configuration that will build this index
+	if err := impl.recordConfig(); err != nil {
+		slog.Warn("Failed to record index configuration",
+			"index", name,
+			"error", err,
+		)
+	}
+
+	// Return wrapped Index
+	return &Index{
+		name:    name,
+		manager: im.wrapperManager(),
+	}, nil
+}
+
+// newIndexImpl creates the graph of a new index
+func (im *indexManagerImpl) newIndexImpl(name string) (*indexImpl, error) {
 	// Get embedding dimension
 	dimension := 768 // Default for nomic-embed-text
 	if im.embedder != nil {
-		dimension = im.embedder.Dimension()
+		var err error
+		if dimension, err = im.embedderDimension(); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create HNSW index path
-	indexPath := filepath.Join(im.config.DataPath, "indexes", name, "index.hnsw")
+	indexPath := im.graphPath(name)
 	
 	// Ensure directory exists
 	indexDir := filepath.Dir(indexPath)
@@ -177,26 +349,28 @@ func (im *indexManagerImpl) CreateIndex(name string) (*Index, error) {
 	}
 
 	// Create HNSW index
-	hnswCfg := indexer.DefaultConfig()
+	quantization, err := im.recordQuantization(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record quantization: %w", err)
+	}
+	hnswCfg := graphConfig(quantization)
 	hnswIdx, err := indexer.NewHNSWIndex(indexPath, dimension, hnswCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HNSW index: %w", err)
 	}
 
-	// Store implementation
-	im.indexes[name] = &indexImpl{
+	return &indexImpl{
 		name:      name,
 		manager:   im,
 		hnswIndex: hnswIdx,
-	}
-
-	// Return wrapped Index
-	return &Index{
-		name:    name,
-		manager: im.wrapperManager(),
 	}, nil
 }
 
+// graphPath returns the path of an index's HNSW graph file
+func (im *indexManagerImpl) graphPath(name string) string {
+	return filepath.Join(im.config.DataPath, "indexes", storage.IndexDir(name), "index.hnsw")
+}
+
 // wrapperManager returns the wrapper IndexManager 
 func (im *indexManagerImpl) wrapperManager() *IndexManager {
 	return im.wrapper
@@ -204,12 +378,8 @@ func (im *indexManagerImpl) wrapperManager() *IndexManager {
 
 // GetIndex retrieves an existing index
 func (im *indexManagerImpl) GetIndex(name string) (*Index, error) {
-	im.mu.RLock()
-	defer im.mu.RUnlock()
-	
-	_, exists := im.indexes[name]
-	if !exists {
-		return nil, fmt.Errorf("index '%s' not found", name)
+	if _, err := im.openIndex(name); err != nil {
+		return nil, err
 	}
 	
 	// Return wrapped Index
@@ -221,17 +391,42 @@ func (im *indexManagerImpl) GetIndex(name string) (*Index, error) {
 
 // DeleteIndex deletes an index
 func (im *indexManagerImpl) DeleteIndex(name string) error {
-	im.mu.Lock()
-	defer im.mu.Unlock()
-	
-	impl, exists := im.indexes[name]
-	if !exists {
+	if im.readOnly != nil {
+		return ErrReadOnly
+	}
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
+
+	if err := im.deleteIndex(name); err != nil {
+		return err
+	}
+
+	// Discard an unfinished rebuild
+	if _, exists := im.indexes.get(rebuildIndexName(name)); exists {
+		return im.deleteIndex(rebuildIndexName(name))
+	}
+	return nil
+}
+
+// deleteIndex deletes an index; im.indexes.mu must be held
+func (im *indexManagerImpl) deleteIndex(name string) error {
+	impl, exists := im.indexes.get(name)
+	if !exists && !im.storedIndex(name) {
 		return fmt.Errorf("index '%s' not found", name)
 	}
-	
-	// Close HNSW index
-	if impl.hnswIndex != nil {
-		impl.hnswIndex.Close()
+
+	// A closed index has no graph in memory
+	if exists {
+		// Wait for writes to this index to finish and refuse new ones
+		impl.mu.Lock()
+		impl.deleted = true
+		impl.mu.Unlock()
+		im.indexes.remove(name)
+
+		// Close HNSW index
+		if impl.hnswIndex != nil {
+			impl.hnswIndex.Close()
+		}
 	}
 	
 	// Delete from storage
@@ -240,30 +435,36 @@ func (im *indexManagerImpl) DeleteIndex(name string) error {
 	}
 	
 	// Remove HNSW file
-	indexPath := filepath.Join(im.config.DataPath, "indexes", name, "index.hnsw")
+	indexPath := im.graphPath(name)
 	os.Remove(indexPath)
 	
-	// Remove from memory
-	delete(im.indexes, name)
-	
 	return nil
 }
 
 // ListIndexes returns all index names
 func (im *indexManagerImpl) ListIndexes() ([]string, error) {
-	im.mu.RLock()
-	defer im.mu.RUnlock()
-	
 	// Get from storage to ensure we have the latest list
-	return im.storage.ListIndexes()
+	names, err := im.storage.ListIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Staging indexes of rebuilds are not listed
+	visible := names[:0]
+	for _, name := range names {
+		if !isRebuildIndex(name) {
+			visible = append(visible, name)
+		}
+	}
+	return visible, nil
 }
 
 // Index implementation methods
 
 func (i *Index) getImpl() *indexImpl {
-	// Get implementation from manager
+	// Get implementation from manager, opening a closed index
 	if mgr := i.manager.getImpl(); mgr != nil {
-		if impl, ok := mgr.indexes[i.name]; ok {
+		if impl, err := mgr.openIndex(i.name); err == nil {
 			return impl
 		}
 	}
@@ -275,8 +476,27 @@ func (i *indexImpl) AddDocumentBatch(ctx context.Context, docs []Document, progr
 	return i.AddDocumentBatchWithOptions(ctx, docs, progress, AddOptions{})
 }
 
-// AddDocumentBatchWithOptions implementation with full processing pipeline and options
+// AddDocumentBatchWithOptions implementation, splitting batches larger than
+// Config.MaxBatchDocuments
 func (i *indexImpl) AddDocumentBatchWithOptions(ctx context.Context, docs []Document, progress chan<- ProgressUpdate, options AddOptions) (*BatchResult, error) {
+	if size := i.manager.config.MaxBatchDocuments; size > 0 && len(docs) > size {
+		return i.addInBatches(ctx, docs, progress, options)
+	}
+	return i.addDocumentBatch(ctx, docs, progress, options)
+}
+
+// addDocumentBatch implementation with full processing pipeline and options
+func (i *indexImpl) addDocumentBatch(ctx context.Context, docs []Document, progress chan<- ProgressUpdate, options AddOptions) (*BatchResult, error) {
+	release, err := i.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := i.writable(); err != nil {
+		return nil, err
+	}
+	started := time.Now()
+
 	slog.Info("Starting batch document processing",
 		"index", i.name,
 		"document_count", len(docs),
@@ -286,6 +506,13 @@ func (i *indexImpl) AddDocumentBatchWithOptions(ctx context.Context, docs []Docu
 		TotalDocuments: len(docs),
 		FailedURIs:     make(map[string]string),
 	}
+	defer result.finish(started)
+
+	// Load the metadata schema documents are validated against
+	schema, err := i.MetadataSchema()
+	if err != nil {
+		return result, fmt.Errorf("failed to load metadata schema: %w", err)
+	}
 	
 	// Helper function to send progress updates if channel is provided
 	sendProgress := func(update ProgressUpdate) {
@@ -300,135 +527,19 @@ func (i *indexImpl) AddDocumentBatchWithOptions(ctx context.Context, docs []Docu
 		}
 	}
 
-	// Phase 1: Analyze what needs updating
-	var toProcess []Document
-	for idx, doc := range docs {
-		// Check for cancellation
-		select {
-		case <-ctx.Done():
-			return result, ctx.Err()
-		default:
-		}
-		
-		// Send progress for checking phase
-		sendProgress(ProgressUpdate{
-			Stage:   "checking",
-			Current: idx + 1,
-			Total:   len(docs),
-			Message: fmt.Sprintf("Checking document: %s", doc.Title),
-			URI:     doc.URI,
-		})
-		
-		// Compute content hash
-		hash := computeDocumentHash(doc)
-		
-		slog.Debug("Checking document",
-			"uri", doc.URI,
-			"title", doc.Title,
-			"hash", hash[:16],
-		)
-		
-		// Check if document has changed (unless force update is set)
-		if options.ForceUpdate {
-			// Force update requested, always process
-			slog.Debug("Force update requested, processing document",
-				"uri", doc.URI,
-				"title", doc.Title,
-			)
-			result.UpdatedDocuments++
-			toProcess = append(toProcess, doc)
-		} else {
-			existingHash, err := i.manager.storage.GetDocumentHash(i.name, doc.URI)
-			if err != nil {
-				// Document doesn't exist
-				slog.Debug("Document is new",
-					"uri", doc.URI,
-				)
-				result.NewDocuments++
-				toProcess = append(toProcess, doc)
-			} else if existingHash != hash {
-				// Document has changed
-				slog.Debug("Document has changed",
-					"uri", doc.URI,
-					"old_hash", existingHash[:16],
-					"new_hash", hash[:16],
-				)
-				result.UpdatedDocuments++
-				toProcess = append(toProcess, doc)
-			} else {
-				// Document unchanged
-				slog.Debug("Document unchanged",
-					"uri", doc.URI,
-				)
-				result.UnchangedDocuments++
-			}
-		}
+	// Phase 1: check, chunk, embed and commit documents in a pipeline
+	toProcess, err := i.runPipeline(ctx, docs, options, schema, result, sendProgress)
+	if err != nil {
+		return result, err
 	}
 
-	slog.Info("Document analysis complete",
-		"new", result.NewDocuments,
-		"updated", result.UpdatedDocuments,
-		"unchanged", result.UnchangedDocuments,
-		"to_process", len(toProcess),
-	)
-
 	// Early return if nothing to process
-	if len(toProcess) == 0 {
+	if toProcess == 0 {
 		slog.Info("No documents to process")
 		return result, nil
 	}
 
-	// Send status update after checking phase
-	sendProgress(ProgressUpdate{
-		Stage:   "checking",
-		Current: len(docs),
-		Total:   len(docs),
-		Message: fmt.Sprintf("Found %d new, %d updated documents to process", result.NewDocuments, result.UpdatedDocuments),
-	})
-
-	// Phase 2: Process documents
-	for idx, doc := range toProcess {
-		// Check for cancellation
-		select {
-		case <-ctx.Done():
-			return result, ctx.Err()
-		default:
-		}
-		
-		sendProgress(ProgressUpdate{
-			Stage:   "processing",
-			Current: idx + 1,
-			Total:   len(toProcess),
-			Message: fmt.Sprintf("Processing: %s", doc.Title),
-			URI:     doc.URI,
-		})
-		
-		slog.Debug("Processing document",
-			"uri", doc.URI,
-			"content_length", len(doc.Content),
-		)
-		
-		if err := i.processDocument(doc); err != nil {
-			slog.Error("Failed to process document",
-				"uri", doc.URI,
-				"error", err,
-			)
-			result.FailedURIs[doc.URI] = err.Error()
-			continue
-		}
-		
-		// Count chunks for this document
-		chunks, err := i.manager.storage.GetChunksByDocument(i.name, doc.URI)
-		if err == nil {
-			result.ProcessedChunks += len(chunks)
-			slog.Debug("Document processed",
-				"uri", doc.URI,
-				"chunks", len(chunks),
-			)
-		}
-	}
-
-	// Phase 3: Save HNSW index if auto-save is enabled
+	// Phase 2: Save HNSW index if auto-save is enabled
 	if i.manager.config.AutoSave {
 		// Check for cancellation before saving
 		select {
@@ -445,30 +556,44 @@ func (i *indexImpl) AddDocumentBatchWithOptions(ctx context.Context, docs []Docu
 		})
 		
 		slog.Debug("Saving HNSW index")
-		if err := i.hnswIndex.Save(); err != nil {
+		saveStart := time.Now()
+		err := i.manager.injectFault(FaultSave, i.name)
+		if err == nil {
+			err = i.hnswIndex.Save()
+		}
+		result.DurationStorage += time.Since(saveStart)
+		if err != nil {
 			slog.Error("Failed to save HNSW index",
 				"error", err,
 			)
 			return result, fmt.Errorf("failed to save HNSW index: %w", err)
 		}
 		slog.Debug("HNSW index saved")
+		i.manager.indexSaved(i.name)
+	}
+
+	// Record the pipeline configuration for indexes created before it was persisted
+	if err := i.recordConfig(); err != nil {
+		slog.Warn("Failed to record index configuration",
+			"index", i.name,
+			"error", err,
+		)
 	}
 
 	// Update index metadata
-	metadata, _ := i.manager.storage.GetIndexMetadata(i.name)
-	if metadata != nil {
-		metadata.LastUpdated = time.Now().Format(time.RFC3339)
+	i.manager.storage.UpdateIndexMetadata(i.name, func(metadata *storage.IndexMetadata) {
+		metadata.LastUpdated = time.Now().UTC()
 		metadata.DocumentCount = result.NewDocuments + result.UpdatedDocuments
 		metadata.ChunkCount = result.ProcessedChunks
-		i.manager.storage.SetIndexMetadata(i.name, *metadata)
-	}
+	})
+	i.recordBatchStats(result, started)
 
 	// Send completion message
 	sendProgress(ProgressUpdate{
 		Stage:   "complete",
-		Current: len(toProcess),
-		Total:   len(toProcess),
-		Message: fmt.Sprintf("Complete! Indexed %d documents with %d chunks", len(toProcess), result.ProcessedChunks),
+		Current: toProcess,
+		Total:   toProcess,
+		Message: fmt.Sprintf("Complete! Indexed %d documents with %d chunks", toProcess, result.ProcessedChunks),
 	})
 
 	slog.Info("Batch processing complete",
@@ -480,155 +605,44 @@ func (i *indexImpl) AddDocumentBatchWithOptions(ctx context.Context, docs []Docu
 	return result, nil
 }
 
-// processDocument processes a single document
-func (i *indexImpl) processDocument(doc Document) error {
-	// Store document with hash
-	hash := computeDocumentHash(doc)
-	storageDoc := storage.Document{
-		URI:      doc.URI,
-		Title:    doc.Title,
-		Content:  doc.Content,
-		Hash:     hash,
-		Metadata: doc.Metadata,
-	}
-	
-	if err := i.manager.storage.StoreDocument(i.name, storageDoc); err != nil {
-		return fmt.Errorf("failed to store document: %w", err)
-	}
-
-	// Delete existing chunks if updating
-	if err := i.manager.storage.DeleteChunksByDocument(i.name, doc.URI); err != nil {
-		// Ignore error if no chunks exist
-	}
-
-	// Chunk the document
-	chunks, err := i.manager.chunker.ChunkDocument(doc.URI, doc.Content)
-	if err != nil {
-		return fmt.Errorf("failed to chunk document: %w", err)
-	}
-
-	// Process chunks with embeddings
-	if err := i.processChunks(doc.URI, chunks, doc.Metadata); err != nil {
-		return fmt.Errorf("failed to process chunks: %w", err)
-	}
-
-	return nil
+// finish records the total duration of a batch started at started and its
+// throughput
+func (r *BatchResult) finish(started time.Time) {
+	r.DurationTotal = time.Since(started)
+	r.throughput()
 }
 
-// processChunks generates embeddings and stores chunks
-func (i *indexImpl) processChunks(docURI string, chunks []chunker.Chunk, metadata map[string]interface{}) error {
-	// Extract texts for embedding
-	texts := make([]string, len(chunks))
-	for idx, chunk := range chunks {
-		texts[idx] = chunk.Text
-	}
-
-	// Generate embeddings (could be done concurrently)
-	embeddings, err := i.manager.embedder.GenerateEmbeddings(texts)
-	if err != nil {
-		return fmt.Errorf("failed to generate embeddings: %w", err)
+// throughput sets ChunksPerSecond from ProcessedChunks and DurationTotal
+func (r *BatchResult) throughput() {
+	r.ChunksPerSecond = 0
+	if r.DurationTotal > 0 {
+		r.ChunksPerSecond = float64(r.ProcessedChunks) / r.DurationTotal.Seconds()
 	}
-
-	// Store chunks with embeddings
-	for idx, chunk := range chunks {
-		// Get next HNSW ID
-		hnswID, err := i.manager.storage.GetNextHNSWId(i.name)
-		if err != nil {
-			return fmt.Errorf("failed to get HNSW ID: %w", err)
-		}
-
-		// Store chunk
-		storageChunk := storage.Chunk{
-			ID:          chunk.ID,
-			HNSWId:      hnswID,
-			DocumentURI: docURI,
-			Text:        chunk.Text,
-			Embedding:   embeddings[idx],
-			Position:    chunk.Position,
-			Metadata:    metadata,
-		}
-
-		if err := i.manager.storage.StoreChunk(i.name, storageChunk); err != nil {
-			return fmt.Errorf("failed to store chunk: %w", err)
-		}
-
-		// Add to HNSW index
-		if err := i.hnswIndex.Add(embeddings[idx], hnswID); err != nil {
-			return fmt.Errorf("failed to add to HNSW index: %w", err)
-		}
-	}
-
-	return nil
 }
 
 // Search implementation
 func (i *indexImpl) Search(query string, limit int) ([]SearchResult, error) {
-	// Generate query embedding
-	embedding, err := i.manager.embedder.GenerateEmbedding(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
-	}
-
-	// Search in HNSW index
-	hnswResults, err := i.hnswIndex.Search(embedding, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search HNSW index: %w", err)
-	}
-
-	// Convert results
-	results := make([]SearchResult, 0, len(hnswResults))
-	for _, hr := range hnswResults {
-		// Find chunk by HNSW ID
-		chunk, doc := i.findChunkAndDocument(hr.ID)
-		if chunk == nil || doc == nil {
-			continue
-		}
+	return i.SearchWithOptions(query, limit, SearchOptions{})
+}
 
-		result := SearchResult{
-			Document: Document{
-				URI:      doc.URI,
-				Title:    doc.Title,
-				Content:  doc.Content,
-				Metadata: doc.Metadata,
-			},
-			Score:     float64(hr.Score),
-			ChunkID:   chunk.ID,
-			ChunkText: chunk.Text,
-			IndexName: i.name,
-		}
-		results = append(results, result)
+// findChunkAndDocument finds chunk and document by HNSW ID. Both are read
+// in one transaction, so a hit never pairs a chunk with another version of
+// its document.
+func (i *indexImpl) findChunkAndDocument(hnswID uint64) (*storage.Chunk, *storage.Document) {
+	if s := i.sealed.Load(); s != nil {
+		return s.findHit(hnswID, i.loadChunkAndDocument)
 	}
-
-	return results, nil
+	return i.loadChunkAndDocument(hnswID)
 }
 
-// findChunkAndDocument finds chunk and document by HNSW ID
-func (i *indexImpl) findChunkAndDocument(hnswID uint64) (*storage.Chunk, *storage.Document) {
-	// This is inefficient - in production, we'd maintain a mapping
-	// For now, scan all chunks to find the one with matching HNSW ID
-	docs, err := i.manager.storage.ListDocuments(i.name)
+// loadChunkAndDocument reads the chunk with an HNSW ID and its document
+// from storage
+func (i *indexImpl) loadChunkAndDocument(hnswID uint64) (*storage.Chunk, *storage.Document) {
+	chunk, doc, err := i.manager.storage.GetChunkByHNSWId(i.name, hnswID)
 	if err != nil {
 		return nil, nil
 	}
-
-	for _, docURI := range docs {
-		chunks, err := i.manager.storage.GetChunksByDocument(i.name, docURI)
-		if err != nil {
-			continue
-		}
-
-		for idx := range chunks {
-			if chunks[idx].HNSWId == hnswID {
-				doc, err := i.manager.storage.GetDocument(i.name, docURI)
-				if err != nil {
-					return nil, nil
-				}
-				return &chunks[idx], doc
-			}
-		}
-	}
-
-	return nil, nil
+	return chunk, doc
 }
 
 // GetDocument implementation
@@ -638,16 +652,41 @@ func (i *indexImpl) GetDocument(uri string) (*Document, error) {
 		return nil, err
 	}
 
-	return &Document{
-		URI:      doc.URI,
-		Title:    doc.Title,
-		Content:  doc.Content,
-		Metadata: doc.Metadata,
-	}, nil
+	d := fromStorageDocument(doc)
+	return &d, nil
 }
 
 // DeleteDocument implementation
 func (i *indexImpl) DeleteDocument(uri string) error {
+	release, err := i.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := i.removeDocument(uri); err != nil {
+		return err
+	}
+	i.recordStats(storage.StatsSnapshot{Time: time.Now(), DeletedDocuments: 1})
+
+	// Save HNSW if auto-save
+	if i.manager.config.AutoSave {
+		if err := i.hnswIndex.Save(); err == nil {
+			i.manager.indexSaved(i.name)
+		}
+	}
+
+	return nil
+}
+
+// removeDocument deletes a document and its chunks without saving the graph
+func (i *indexImpl) removeDocument(uri string) error {
+	i.commitMu.Lock()
+	defer i.commitMu.Unlock()
+	if err := i.writable(); err != nil {
+		return err
+	}
+
 	// Get chunks to remove from HNSW
 	chunks, err := i.manager.storage.GetChunksByDocument(i.name, uri)
 	if err == nil {
@@ -656,21 +695,17 @@ func (i *indexImpl) DeleteDocument(uri string) error {
 		}
 	}
 
-	// Delete from storage
-	if err := i.manager.storage.DeleteDocument(i.name, uri); err != nil {
-		return err
-	}
-
-	// Delete chunks
+	// Delete chunks first: deleting the document drops the mapping to them
 	if err := i.manager.storage.DeleteChunksByDocument(i.name, uri); err != nil {
 		return err
 	}
 
-	// Save HNSW if auto-save
-	if i.manager.config.AutoSave {
-		i.hnswIndex.Save()
+	// Delete from storage
+	if err := i.manager.storage.DeleteDocument(i.name, uri); err != nil {
+		return err
 	}
 
+	i.manager.emitDocumentDeleted(DocumentEvent{Index: i.name, URI: uri})
 	return nil
 }
 
@@ -684,17 +719,47 @@ func (i *indexImpl) Stats() (IndexStats, error) {
 	// Get document count
 	docs, _ := i.manager.storage.ListDocuments(i.name)
 	
+	description, err := i.Description()
+	if err != nil {
+		return IndexStats{Name: i.name}, err
+	}
+	var size int64
+	if summary, err := i.manager.storage.IndexSummary(i.name); err == nil {
+		size = summary.Bytes
+	}
+	labels, err := i.Labels()
+	if err != nil {
+		return IndexStats{Name: i.name}, err
+	}
+
 	return IndexStats{
 		Name:          i.name,
+		Description:   description,
+		Labels:        labels,
 		DocumentCount: len(docs),
 		ChunkCount:    metadata.ChunkCount,
 		LastUpdated:   metadata.LastUpdated,
-		SizeBytes:     0, // Would need to calculate actual size
+		CreatedAt:     i.createdAt(metadata),
+		LastSavedAt:   metadata.LastSavedAt,
+		LastSyncAt:    metadata.LastSyncAt,
+		SizeBytes:     size,
+		Sealed:        i.sealInfo(),
 	}, nil
 }
 
 // Clear implementation
 func (i *indexImpl) Clear() error {
+	release, err := i.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	i.commitMu.Lock()
+	defer i.commitMu.Unlock()
+	if err := i.writable(); err != nil {
+		return err
+	}
+
 	// Clear HNSW index
 	if err := i.hnswIndex.Clear(); err != nil {
 		return err
@@ -707,8 +772,9 @@ func (i *indexImpl) Clear() error {
 	}
 
 	for _, uri := range docs {
-		i.manager.storage.DeleteDocument(i.name, uri)
 		i.manager.storage.DeleteChunksByDocument(i.name, uri)
+		i.manager.storage.DeleteDocument(i.name, uri)
+		i.manager.emitDocumentDeleted(DocumentEvent{Index: i.name, URI: uri})
 	}
 
 	// Clear all document hashes to force re-indexing
@@ -716,14 +782,17 @@ func (i *indexImpl) Clear() error {
 		return fmt.Errorf("failed to clear document hashes: %w", err)
 	}
 
-	// Reset metadata
-	metadata := storage.IndexMetadata{
-		NextHNSWId:    1,
-		DocumentCount: 0,
-		ChunkCount:    0,
-		LastUpdated:   time.Now().Format(time.RFC3339),
-	}
-	i.manager.storage.SetIndexMetadata(i.name, metadata)
+	// Reset metadata, keeping when the index was created, saved and synced
+	i.manager.storage.UpdateIndexMetadata(i.name, func(metadata *storage.IndexMetadata) {
+		*metadata = storage.IndexMetadata{
+			NextHNSWId:  1,
+			LastUpdated: time.Now().UTC(),
+			CreatedAt:   metadata.CreatedAt,
+			LastSavedAt: metadata.LastSavedAt,
+			LastSyncAt:  metadata.LastSyncAt,
+		}
+	})
+	i.recordStats(storage.StatsSnapshot{Time: time.Now(), DeletedDocuments: len(docs)})
 
 	return nil
 }
@@ -734,11 +803,44 @@ func computeDocumentHash(doc Document) string {
 	h.Write([]byte(doc.URI))      // Include URI in hash to detect URI changes
 	h.Write([]byte(doc.Title))
 	h.Write([]byte(doc.Content))
+	writeHashTail(h, doc)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeHashTail hashes the document fields that follow the content
+func writeHashTail(h hash.Hash, doc Document) {
 	// Include relevant metadata in hash
 	if doc.Metadata != nil {
 		h.Write([]byte(fmt.Sprintf("%v", doc.Metadata)))
 	}
-	return hex.EncodeToString(h.Sum(nil))
+	h.Write(doc.Data)
+	for _, seg := range doc.Segments {
+		h.Write([]byte(fmt.Sprintf("%g-%g:%s", seg.Start, seg.End, seg.Text)))
+	}
+}
+
+// fromStorageDocument converts a stored document to the public type
+func fromStorageDocument(doc *storage.Document) Document {
+	return Document{
+		URI:      doc.URI,
+		Title:    doc.Title,
+		Content:  doc.Content,
+		Metadata: doc.Metadata,
+	}
+}
+
+// fromStorageChunk converts a stored chunk to the public type
+func fromStorageChunk(chunk *storage.Chunk) Chunk {
+	return Chunk{
+		ID:          chunk.ID,
+		DocumentURI: chunk.DocumentURI,
+		Text:        chunk.Text,
+		Position:    chunk.Position,
+		Embedding:   chunk.Embedding,
+		Vectors:     chunk.Vectors,
+		Sparse:      chunk.Sparse,
+		Metadata:    chunk.Metadata,
+	}
 }
 
 // ensureDir ensures a directory exists
@@ -2,7 +2,10 @@ package hnswindex
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -234,4 +237,84 @@ func TestIntegration_ComplexURIScenario(t *testing.T) {
 	// Old URIs should not exist
 	_, err = index.GetDocument("confluence://SPACE_KEY/655361")
 	assert.Error(t, err, "Old URI should not exist after rebuild")
-}
\ No newline at end of file
+}
+
+// slowEmbedder records how many embedding calls overlap
+type slowEmbedder struct {
+	*MockEmbedder
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (s *slowEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	s.mu.Lock()
+	s.inFlight++
+	s.peak = max(s.peak, s.inFlight)
+	s.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	return s.MockEmbedder.GenerateEmbeddings(texts)
+}
+
+func TestAddDocumentBatch_Parallel(t *testing.T) {
+	cfg := NewConfig()
+	cfg.MaxWorkers = 4
+	manager := newMockManager(t, cfg)
+	slow := &slowEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.SetEmbedder(slow)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	var docs []Document
+	for n := 0; n < 12; n++ {
+		docs = append(docs, Document{URI: fmt.Sprintf("doc-%d", n), Content: fmt.Sprintf("document number %d", n)})
+	}
+	// A repeated URI keeps its last version
+	docs = append(docs, Document{URI: "doc-0", Content: "document number 0, revised"})
+
+	var indexed []string
+	var mu sync.Mutex
+	manager.Subscribe(EventHandlerFuncs{DocumentIndexed: func(e DocumentEvent) {
+		mu.Lock()
+		indexed = append(indexed, e.URI)
+		mu.Unlock()
+	}})
+
+	result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.FailedURIs)
+	assert.Equal(t, 12, result.ProcessedChunks)
+	assert.Len(t, indexed, 12) // The superseded version is not indexed
+	assert.Greater(t, slow.peak, 1)
+	assert.LessOrEqual(t, slow.peak, 4)
+
+	doc, err := index.GetDocument("doc-0")
+	require.NoError(t, err)
+	assert.Equal(t, "document number 0, revised", doc.Content)
+
+	results, err := index.Search("document number 7", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc-7", results[0].Document.URI)
+}
+
+func TestAddDocumentBatch_ParallelCancel(t *testing.T) {
+	cfg := NewConfig()
+	cfg.MaxWorkers = 2
+	manager := newMockManager(t, cfg)
+	manager.SetEmbedder(&slowEmbedder{MockEmbedder: NewMockEmbedder(768)})
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	var docs []Document
+	for n := 0; n < 20; n++ {
+		docs = append(docs, Document{URI: fmt.Sprintf("doc-%d", n), Content: fmt.Sprintf("document number %d", n)})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err = index.AddDocumentBatch(ctx, docs, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
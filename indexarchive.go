@@ -0,0 +1,268 @@
+package hnswindex
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// IndexArchiveFormat identifies the layout of index archives written by
+// ExportIndex. ImportIndex rejects archives of other formats.
+const IndexArchiveFormat = "hnswindex-index/1"
+
+// Entries of an index archive
+const (
+	archiveDatabaseName = "index.db"
+	archiveGraphName    = "index.hnsw"
+)
+
+// IndexArchiveManifest describes an index archive. It is written as the
+// last entry, so a truncated transfer lacks it.
+type IndexArchiveManifest struct {
+	Format  string                  `json:"format"`
+	Index   string                  `json:"index"` // Name of the exported index
+	Created time.Time               `json:"created"`
+	Config  *IndexConfig            `json:"config,omitempty"` // Pipeline that built the index, if recorded
+	Files   map[string]SnapshotFile `json:"files"`            // By entry name
+
+	// Documents holds the checksum of every document, with its chunks, by
+	// URI
+	Documents map[string]string `json:"documents"`
+}
+
+// ExportIndex writes a single index — its HNSW graph, documents, chunks,
+// metadata and settings — to w as a gzipped tar archive ending with an
+// IndexArchiveManifest, for ImportIndex to load into another data
+// directory. The graph is exported from memory, so unsaved changes are
+// included. Writes to the index wait until the export finishes; searches
+// do not.
+func (im *IndexManager) ExportIndex(name string, w io.Writer) error {
+	if impl := im.getImpl(); impl != nil {
+		return impl.ExportIndex(name, w)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// ImportIndex creates an index from an archive written by ExportIndex,
+// under name, or under the name it was exported with if name is empty. The
+// archive is checked against its manifest, then the checksum of every
+// document, before anything is written; one that does not match returns
+// ErrSnapshotCorrupt. The index must not exist. Its documents are not
+// re-embedded, so queries must be embedded with the model that built it.
+func (im *IndexManager) ImportIndex(name string, r io.Reader) (*Index, error) {
+	if impl := im.getImpl(); impl != nil {
+		return impl.ImportIndex(name, r)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// ExportIndex implementation
+func (im *indexManagerImpl) ExportIndex(name string, w io.Writer) error {
+	impl, err := im.openIndex(name)
+	if err != nil {
+		return err
+	}
+	release, err := impl.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Hold commits back so the database and the graph match
+	impl.commitMu.Lock()
+	defer impl.commitMu.Unlock()
+
+	config, err := impl.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read index config: %w", err)
+	}
+
+	now := time.Now()
+	zw := gzip.NewWriter(w)
+	sw := newSnapshotWriter(tar.NewWriter(zw), now)
+	sums, err := im.storage.ExportIndex(name, sw, func(size int64) error {
+		return sw.WriteHeader(&tar.Header{Name: archiveDatabaseName, Mode: 0600, Size: size, ModTime: now})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export database of '%s': %w", name, err)
+	}
+	if err := writeGraph(sw, archiveGraphName, impl, now); err != nil {
+		return fmt.Errorf("failed to export graph of '%s': %w", name, err)
+	}
+	sw.finishEntry()
+
+	manifest := IndexArchiveManifest{
+		Format:    IndexArchiveFormat,
+		Index:     name,
+		Created:   now.UTC(),
+		Config:    config,
+		Files:     sw.manifest.Files,
+		Documents: sums[name],
+	}
+	if manifest.Documents == nil {
+		manifest.Documents = make(map[string]string)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode archive manifest: %w", err)
+	}
+	if err := sw.tw.WriteHeader(&tar.Header{Name: snapshotManifestName, Mode: 0644, Size: int64(len(data)), ModTime: now}); err != nil {
+		return err
+	}
+	if _, err := sw.tw.Write(data); err != nil {
+		return err
+	}
+	if err := sw.tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ImportIndex implementation
+func (im *indexManagerImpl) ImportIndex(name string, r io.Reader) (*Index, error) {
+	if im.readOnly != nil {
+		return nil, ErrReadOnly
+	}
+	start := time.Now()
+
+	zr, err := decompressSnapshot(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index archive: %w", err)
+	}
+	defer zr.Close()
+
+	if err := ensureDir(im.config.DataPath); err != nil {
+		return nil, err
+	}
+	staging, err := os.MkdirTemp(im.config.DataPath, ".import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, err := readIndexArchive(zr, staging)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = manifest.Index
+	}
+	if err := storage.ValidateIndexName(name); err != nil {
+		return nil, err
+	}
+
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
+	if _, exists := im.indexes.get(name); exists || im.storedIndex(name) {
+		return nil, fmt.Errorf("index '%s' already exists", name)
+	}
+
+	want := SnapshotManifest{Documents: map[string]map[string]string{manifest.Index: manifest.Documents}}
+	err = im.storage.ImportIndex(name, filepath.Join(staging, archiveDatabaseName), manifest.Index, func(sums storage.DocumentChecksums) error {
+		return want.compareDocuments(sums)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	graphPath := im.graphPath(name)
+	err = ensureDir(filepath.Dir(graphPath))
+	if err == nil {
+		err = os.Rename(filepath.Join(staging, archiveGraphName), graphPath)
+	}
+	// The imported settings hold the config the archive's manifest
+	// describes, which gives the graph's embedding dimension
+	var impl *indexImpl
+	if err == nil {
+		impl, err = im.loadIndex(name)
+	}
+	if err != nil {
+		im.storage.DeleteIndex(name)
+		os.RemoveAll(filepath.Dir(graphPath))
+		return nil, fmt.Errorf("failed to import index '%s': %w", name, err)
+	}
+	im.indexes.put(impl)
+
+	// Warn if this process would build the index differently
+	impl.verifyConfig()
+
+	slog.Info("Index imported",
+		"index", name,
+		"from", manifest.Index,
+		"documents", len(manifest.Documents),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return &Index{
+		name:    name,
+		manager: im.wrapperManager(),
+	}, nil
+}
+
+// readIndexArchive extracts an index archive into dir and checks its files
+// against the manifest
+func readIndexArchive(r io.Reader, dir string) (*IndexArchiveManifest, error) {
+	var manifest *IndexArchiveManifest
+	files := make(map[string]SnapshotFile)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSnapshotCorrupt, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch hdr.Name {
+		case snapshotManifestName:
+			manifest = &IndexArchiveManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("%w: invalid manifest: %w", ErrSnapshotCorrupt, err)
+			}
+			continue
+		case archiveDatabaseName, archiveGraphName:
+		default:
+			return nil, fmt.Errorf("invalid index archive entry %q", hdr.Name)
+		}
+
+		h := sha256.New()
+		if err := writeFileAtomic(filepath.Join(dir, hdr.Name), io.TeeReader(tr, h)); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = fmt.Errorf("%w: %w", ErrSnapshotCorrupt, err)
+			}
+			return nil, fmt.Errorf("failed to extract %s: %w", hdr.Name, err)
+		}
+		files[hdr.Name] = SnapshotFile{Size: hdr.Size, SHA256: hex.EncodeToString(h.Sum(nil))}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: index archive has no manifest", ErrSnapshotCorrupt)
+	}
+	if manifest.Format != IndexArchiveFormat {
+		return nil, fmt.Errorf("unsupported index archive format %q", manifest.Format)
+	}
+	if err := (&SnapshotManifest{Files: manifest.Files}).verifyFiles(files); err != nil {
+		return nil, err
+	}
+	if _, ok := files[archiveDatabaseName]; !ok {
+		return nil, fmt.Errorf("%w: index archive has no database", ErrSnapshotCorrupt)
+	}
+	if _, ok := files[archiveGraphName]; !ok {
+		return nil, fmt.Errorf("%w: index archive has no graph", ErrSnapshotCorrupt)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,103 @@
+package hnswindex
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportIndex(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	ci := newMockManager(t, cfg)
+	index, err := ci.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "doc1", Title: "One", Content: "First document content", Metadata: map[string]interface{}{"space": "ENG"}},
+		Document{URI: "doc2", Title: "Two", Content: "Second document content"},
+	)
+	_, err = ci.CreateIndex("other")
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	require.NoError(t, ci.ExportIndex("kb", &archive))
+	assert.Error(t, ci.ExportIndex("missing", &bytes.Buffer{}))
+
+	cfg = NewConfig()
+	cfg.DataPath = t.TempDir()
+	prod := newMockManager(t, cfg)
+	imported, err := prod.ImportIndex("kb-2024", bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "kb-2024", imported.Name())
+
+	results, err := imported.Search("Second document content", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doc2"}, resultURIs(results))
+	doc, err := imported.GetDocument("doc1")
+	require.NoError(t, err)
+	assert.Equal(t, "ENG", doc.Metadata["space"])
+	names, err := prod.ListIndexes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kb-2024"}, names, "other indexes stay behind")
+
+	// The name defaults to the exported one, and existing indexes are not
+	// replaced
+	_, err = prod.ImportIndex("", bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	_, err = prod.GetIndex("kb")
+	require.NoError(t, err)
+	_, err = prod.ImportIndex("kb-2024", bytes.NewReader(archive.Bytes()))
+	assert.ErrorContains(t, err, "already exists")
+
+	// The imported index survives a restart
+	require.NoError(t, prod.Close())
+	require.NoError(t, prod.getImpl().storage.Close())
+	prod = newMockManager(t, cfg)
+	reopened, err := prod.GetIndex("kb-2024")
+	require.NoError(t, err)
+	results, err = reopened.Search("First document content", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doc1"}, resultURIs(results))
+
+	// Damaged and truncated archives write nothing
+	damaged := bytes.Clone(archive.Bytes())
+	damaged[len(damaged)/2] ^= 0xff
+	_, err = prod.ImportIndex("broken", bytes.NewReader(damaged))
+	assert.Error(t, err)
+	_, err = prod.ImportIndex("broken", bytes.NewReader(archive.Bytes()[:archive.Len()-100]))
+	assert.ErrorIs(t, err, ErrSnapshotCorrupt)
+	_, err = prod.GetIndex("broken")
+	assert.Error(t, err)
+}
+
+func TestExportImportIndex_EmbedderDimension(t *testing.T) {
+	newManager := func() *IndexManager {
+		cfg := NewConfig()
+		cfg.DataPath = t.TempDir()
+		manager, err := NewIndexManager(cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { manager.Close() })
+		manager.SetEmbedder(embedtest.NewSemantic(64))
+		return manager
+	}
+
+	ci := newManager()
+	index, err := ci.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "password", Content: "Reset your password from the account settings page."},
+		Document{URI: "revenue", Content: "Quarterly revenue grew in every region."},
+	)
+	var archive bytes.Buffer
+	require.NoError(t, ci.ExportIndex("kb", &archive))
+
+	// The graph is loaded with the dimension the archive's config records
+	imported, err := newManager().ImportIndex("", &archive)
+	require.NoError(t, err)
+	results, err := imported.Search("how do I reset my password", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password"}, resultURIs(results))
+}
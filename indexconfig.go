@@ -0,0 +1,248 @@
+package hnswindex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// configSettingKey is the index setting holding the persisted pipeline configuration
+const configSettingKey = "config"
+
+// IndexConfig is the pipeline configuration that built an index's data.
+// It is recorded when the index is created (or first written, for indexes
+// created by older versions) so later processes can verify they use a
+// compatible chunker, embedder, and graph.
+type IndexConfig struct {
+	Chunker   ChunkerConfig   `json:"chunker"`
+	Embedder  EmbedderConfig  `json:"embedder"`
+	HNSW      HNSWParams      `json:"hnsw"`
+	CreatedAt time.Time       `json:"created_at,omitzero"`
+	Schema    *MetadataSchema `json:"schema,omitempty"` // Current metadata schema, if any
+}
+
+// ChunkerConfig describes how documents were split into chunks
+type ChunkerConfig struct {
+	Tokenizer      string `json:"tokenizer"`
+	ChunkSize      int    `json:"chunk_size"`
+	ChunkOverlap   int    `json:"chunk_overlap"`
+	ContentDefined bool   `json:"content_defined,omitempty"` // Chunks end at content-defined breakpoints
+}
+
+// EmbedderConfig describes how chunk embeddings were generated
+type EmbedderConfig struct {
+	Provider  string `json:"provider"` // "ollama", "job", "cohere", "voyage", "onnx", or "custom"
+	URL       string `json:"url,omitempty"`
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"`
+}
+
+// HNSWParams describes the graph parameters of the index
+type HNSWParams struct {
+	M              int    `json:"m"`
+	EfConstruction int    `json:"ef_construction"`
+	Ef             int    `json:"ef"`
+	DistanceType   string `json:"distance_type"`
+	Seed           int64  `json:"seed"`
+	Quantization   string `json:"quantization,omitempty"` // "int8" for a quantized graph
+}
+
+// Diff lists the pipeline settings that differ between two configurations.
+// Creation time and schema are ignored; an empty result means the
+// configurations produce compatible data.
+func (c IndexConfig) Diff(other IndexConfig) []string {
+	var diffs []string
+	add := func(name string, a, b interface{}) {
+		if a != b {
+			diffs = append(diffs, fmt.Sprintf("%s: %v != %v", name, a, b))
+		}
+	}
+
+	add("chunker.tokenizer", c.Chunker.Tokenizer, other.Chunker.Tokenizer)
+	add("chunker.chunk_size", c.Chunker.ChunkSize, other.Chunker.ChunkSize)
+	add("chunker.chunk_overlap", c.Chunker.ChunkOverlap, other.Chunker.ChunkOverlap)
+	add("chunker.content_defined", c.Chunker.ContentDefined, other.Chunker.ContentDefined)
+	add("embedder.provider", c.Embedder.Provider, other.Embedder.Provider)
+	add("embedder.model", c.Embedder.Model, other.Embedder.Model)
+	add("embedder.dimension", c.Embedder.Dimension, other.Embedder.Dimension)
+	add("hnsw.m", c.HNSW.M, other.HNSW.M)
+	add("hnsw.ef_construction", c.HNSW.EfConstruction, other.HNSW.EfConstruction)
+	add("hnsw.ef", c.HNSW.Ef, other.HNSW.Ef)
+	add("hnsw.distance_type", c.HNSW.DistanceType, other.HNSW.DistanceType)
+	add("hnsw.seed", c.HNSW.Seed, other.HNSW.Seed)
+	add("hnsw.quantization", c.HNSW.Quantization, other.HNSW.Quantization)
+
+	return diffs
+}
+
+// Config returns the persisted pipeline configuration of the index.
+// It returns nil if the index predates configuration persistence and has
+// not been written to since.
+func (i *Index) Config() (*IndexConfig, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Config()
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// EffectiveConfig returns the pipeline configuration this process would use
+// to add documents to the index
+func (i *Index) EffectiveConfig() (IndexConfig, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.effectiveConfig(), nil
+	}
+	return IndexConfig{}, fmt.Errorf("implementation not available")
+}
+
+// Config implementation
+func (i *indexImpl) Config() (*IndexConfig, error) {
+	data, err := i.manager.storage.GetIndexSetting(i.name, configSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var cfg IndexConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode index config: %w", err)
+	}
+
+	cfg.Schema, err = i.MetadataSchema()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadDimension returns the embedding dimension recorded in an index's
+// configuration. Indexes created before the configuration was recorded
+// take it from their stored embeddings, so recordConfig records the
+// dimension that built them rather than that of the current embedder.
+func (im *indexManagerImpl) loadDimension(name string) (int, error) {
+	data, err := im.storage.GetIndexSetting(name, configSettingKey)
+	if err != nil {
+		return 0, err
+	}
+	if data == nil {
+		return im.storedDimension(name)
+	}
+
+	var cfg IndexConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return 0, fmt.Errorf("failed to decode index config: %w", err)
+	}
+	if cfg.Embedder.Dimension <= 0 {
+		return im.storedDimension(name)
+	}
+	return cfg.Embedder.Dimension, nil
+}
+
+// errDimensionFound stops the chunk scan of storedDimension
+var errDimensionFound = errors.New("dimension found")
+
+// storedDimension returns the dimension of the first stored chunk
+// embedding of an index, or 768 (nomic-embed-text) if it has none
+func (im *indexManagerImpl) storedDimension(name string) (int, error) {
+	dimension := 768
+	err := im.storage.ForEachChunk(name, func(chunk storage.Chunk) error {
+		if len(chunk.Embedding) == 0 {
+			return nil
+		}
+		dimension = len(chunk.Embedding)
+		return errDimensionFound
+	})
+	if err != nil && !errors.Is(err, errDimensionFound) {
+		return 0, fmt.Errorf("failed to read chunk embeddings: %w", err)
+	}
+	if dimension != 768 {
+		slog.Info("Took embedding dimension of index from its stored chunks",
+			"index", name,
+			"dimension", dimension,
+		)
+	}
+	return dimension, nil
+}
+
+// effectiveConfig describes the pipeline of the running process
+func (i *indexImpl) effectiveConfig() IndexConfig {
+	cfg := i.manager.config
+	hnswCfg := i.hnswIndex.Config()
+
+	provider, url, model := i.manager.embedderDescription()
+
+	return IndexConfig{
+		Chunker: ChunkerConfig{
+			Tokenizer:      "cl100k_base",
+			ChunkSize:      cfg.ChunkSize,
+			ChunkOverlap:   cfg.ChunkOverlap,
+			ContentDefined: cfg.ContentDefinedChunks,
+		},
+		Embedder: EmbedderConfig{
+			Provider:  provider,
+			URL:       url,
+			Model:     model,
+			Dimension: i.embeddingDimension(),
+		},
+		HNSW: HNSWParams{
+			M:              hnswCfg.M,
+			EfConstruction: hnswCfg.EfConstruction,
+			Ef:             hnswCfg.Ef,
+			DistanceType:   hnswCfg.DistanceType,
+			Seed:           hnswCfg.Seed,
+			Quantization:   hnswCfg.Quantization,
+		},
+	}
+}
+
+// createdAt returns when the index was created, falling back to when its
+// configuration was recorded for indexes created by older versions
+func (i *indexImpl) createdAt(metadata *storage.IndexMetadata) time.Time {
+	if !metadata.CreatedAt.IsZero() {
+		return metadata.CreatedAt
+	}
+	if cfg, err := i.Config(); err == nil && cfg != nil {
+		return cfg.CreatedAt
+	}
+	return time.Time{}
+}
+
+// recordConfig persists the effective configuration if none is stored yet
+func (i *indexImpl) recordConfig() error {
+	existing, err := i.manager.storage.GetIndexSetting(i.name, configSettingKey)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	cfg := i.effectiveConfig()
+	cfg.CreatedAt = time.Now().UTC()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode index config: %w", err)
+	}
+	return i.manager.storage.SetIndexSetting(i.name, configSettingKey, data)
+}
+
+// verifyConfig logs a warning for every setting where the running process
+// differs from the pipeline that built the index
+func (i *indexImpl) verifyConfig() {
+	stored, err := i.Config()
+	if err != nil || stored == nil {
+		return
+	}
+
+	for _, diff := range stored.Diff(i.effectiveConfig()) {
+		slog.Warn("Index configuration differs from the pipeline that built it",
+			"index", i.name,
+			"setting", diff,
+		)
+	}
+}
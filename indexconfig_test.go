@@ -0,0 +1,112 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexConfig_Persisted(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 128
+	cfg.ChunkOverlap = 16
+	manager := newMockManager(t, cfg)
+
+	index, err := manager.CreateIndex("configured")
+	require.NoError(t, err)
+
+	stored, err := index.Config()
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, 128, stored.Chunker.ChunkSize)
+	assert.Equal(t, 16, stored.Chunker.ChunkOverlap)
+	assert.Equal(t, "custom", stored.Embedder.Provider)
+	assert.Equal(t, 768, stored.Embedder.Dimension)
+	assert.Equal(t, "cosine", stored.HNSW.DistanceType)
+	assert.NotEmpty(t, stored.CreatedAt)
+	assert.Nil(t, stored.Schema)
+
+	effective, err := index.EffectiveConfig()
+	require.NoError(t, err)
+	assert.Empty(t, stored.Diff(effective))
+
+	require.NoError(t, index.SetMetadataSchema(&MetadataSchema{
+		Fields: map[string]FieldSchema{"source": {Type: MetaString}},
+	}))
+	stored, err = index.Config()
+	require.NoError(t, err)
+	require.NotNil(t, stored.Schema)
+	assert.Contains(t, stored.Schema.Fields, "source")
+}
+
+func TestIndexConfig_RecordedOnFirstWrite(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("legacy")
+	require.NoError(t, err)
+
+	// Simulate an index created before configuration was persisted
+	require.NoError(t, manager.getImpl().storage.SetIndexSetting("legacy", configSettingKey, nil))
+	stored, err := index.Config()
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	_, err = index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "doc1", Title: "Doc", Content: "some content to index"},
+	}, nil)
+	require.NoError(t, err)
+
+	stored, err = index.Config()
+	require.NoError(t, err)
+	assert.NotNil(t, stored)
+}
+
+func TestIndexConfig_LegacyDimensionFromEmbeddings(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager, err := NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+
+	manager.SetEmbedder(embedtest.NewSemantic(64))
+	index, err := manager.CreateIndex("legacy")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "password", Content: "Reset your password from the account settings page."},
+	)
+
+	// Simulate an index created before configuration was persisted; the
+	// reloaded graph takes its dimension from the stored embeddings
+	require.NoError(t, manager.getImpl().storage.SetIndexSetting("legacy", configSettingKey, nil))
+	require.NoError(t, index.Close())
+	addDocuments(t, index,
+		Document{URI: "revenue", Content: "Quarterly revenue grew in every region."},
+	)
+
+	stored, err := index.Config()
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, 64, stored.Embedder.Dimension)
+}
+
+func TestIndexConfig_Diff(t *testing.T) {
+	a := IndexConfig{
+		Chunker:  ChunkerConfig{Tokenizer: "cl100k_base", ChunkSize: 512, ChunkOverlap: 50},
+		Embedder: EmbedderConfig{Provider: "ollama", Model: "nomic-embed-text", Dimension: 768},
+		HNSW:     HNSWParams{M: 16, Ef: 20, DistanceType: "cosine"},
+	}
+	b := a
+	b.CreatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.Embedder.URL = "http://other:11434"
+	assert.Empty(t, a.Diff(b))
+
+	b.Chunker.ChunkSize = 256
+	b.Embedder.Model = "mxbai-embed-large"
+	assert.Equal(t, []string{
+		"chunker.chunk_size: 512 != 256",
+		"embedder.model: nomic-embed-text != mxbai-embed-large",
+	}, a.Diff(b))
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/riclib/hnswindex/embedtest"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -168,48 +169,29 @@ func TestIntegration_ConcurrentOperations(t *testing.T) {
 }
 
 // MockEmbedder for testing without Ollama
-type MockEmbedder struct {
-	dimension int
-	mu        sync.Mutex
-	embeddings map[string][]float32
-}
+type MockEmbedder = embedtest.Embedder
 
 func NewMockEmbedder(dimension int) *MockEmbedder {
-	return &MockEmbedder{
-		dimension: dimension,
-		embeddings: make(map[string][]float32),
-	}
+	return embedtest.New(dimension)
 }
 
-func (m *MockEmbedder) GenerateEmbedding(text string) ([]float32, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Generate deterministic fake embedding based on text hash
-	hash := sha256.Sum256([]byte(text))
-	embedding := make([]float32, m.dimension)
-	for i := 0; i < m.dimension; i++ {
-		embedding[i] = float32(hash[i%32]) / 255.0
+// newMockManager creates an index manager whose implementation uses a
+// MockEmbedder, so tests can run without Ollama
+func newMockManager(t *testing.T, cfg *Config) *IndexManager {
+	t.Helper()
+	if cfg == nil {
+		cfg = NewConfig()
 	}
-	
-	m.embeddings[text] = embedding
-	return embedding, nil
-}
-
-func (m *MockEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
-	embeddings := make([][]float32, len(texts))
-	for i, text := range texts {
-		emb, err := m.GenerateEmbedding(text)
-		if err != nil {
-			return nil, err
-		}
-		embeddings[i] = emb
+	if cfg.DataPath == "" || cfg.DataPath == NewConfig().DataPath {
+		cfg.DataPath = t.TempDir()
 	}
-	return embeddings, nil
-}
 
-func (m *MockEmbedder) Dimension() int {
-	return m.dimension
+	manager, err := NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+
+	manager.SetEmbedder(NewMockEmbedder(768))
+	return manager
 }
 
 // TestIntegration_BatchProcessingWithMock tests batch processing without Ollama
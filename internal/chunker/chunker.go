@@ -2,6 +2,7 @@ package chunker
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -22,9 +23,10 @@ type Chunk struct {
 
 // Chunker handles text chunking with tiktoken
 type Chunker struct {
-	chunkSize   int
-	overlapSize int
-	encoder     *tiktoken.Tiktoken
+	chunkSize      int
+	overlapSize    int
+	contentDefined bool
+	encoder        *tiktoken.Tiktoken
 }
 
 // NewChunker creates a new chunker with specified chunk and overlap sizes
@@ -52,7 +54,16 @@ func NewChunker(chunkSize, overlapSize int) (*Chunker, error) {
 	}, nil
 }
 
-// Chunk splits text into chunks with overlap
+// SetContentDefined makes chunks end at line and sentence ends picked by
+// the text around them (see contentCuts) instead of every chunkSize tokens.
+// Chunks then change only near an edit, but texts chunked both ways get
+// different chunks.
+func (c *Chunker) SetContentDefined(enabled bool) {
+	c.contentDefined = enabled
+}
+
+// Chunk splits text into chunks of at most chunkSize tokens, each starting
+// with the last overlapSize tokens of the previous one
 func (c *Chunker) Chunk(text string) ([]Chunk, error) {
 	if text == "" {
 		slog.Debug("Empty text provided to chunker")
@@ -85,24 +96,23 @@ func (c *Chunker) Chunk(text string) ([]Chunk, error) {
 		}, nil
 	}
 
-	chunks := []Chunk{}
-	position := 0
-	stride := c.chunkSize - c.overlapSize
-
-	slog.Debug("Chunking with stride",
-		"stride", stride,
-		"expected_chunks", (tokenCount-c.overlapSize)/stride+1,
-	)
-
-	for i := 0; i < len(tokens); i += stride {
-		end := i + c.chunkSize
-		if end > len(tokens) {
-			end = len(tokens)
-		}
+	// Decode each token once; a chunk's text is the join of its tokens'
+	pieces := make([]string, tokenCount)
+	for i := range tokens {
+		pieces[i] = c.encoder.Decode(tokens[i : i+1])
+	}
+	cuts := fixedCuts(len(pieces), c.chunkSize, c.overlapSize)
+	if c.contentDefined {
+		cuts = contentCuts(pieces, c.chunkSize-c.overlapSize)
+	}
 
-		// Decode the chunk tokens back to text
-		chunkTokens := tokens[i:end]
-		chunkText := c.encoder.Decode(chunkTokens)
+	chunks := make([]Chunk, 0, len(cuts)-1)
+	start := 0
+	for position := 0; position+1 < len(cuts); position++ {
+		// Overlap the previous chunk, but never start before it
+		start = max(cuts[position]-c.overlapSize, start)
+		end := cuts[position+1]
+		chunkText := strings.Join(pieces[start:end], "")
 
 		chunk := Chunk{
 			ID:       generateChunkID(chunkText, position),
@@ -113,18 +123,11 @@ func (c *Chunker) Chunk(text string) ([]Chunk, error) {
 		
 		slog.Debug("Created chunk",
 			"position", position,
-			"token_start", i,
+			"token_start", start,
 			"token_end", end,
 			"chunk_length", len(chunkText),
 			"chunk_id", chunk.ID[:8],
 		)
-		
-		position++
-
-		// If we've reached the end, break
-		if end == len(tokens) {
-			break
-		}
 	}
 
 	slog.Info("Text chunked successfully",
@@ -136,6 +139,102 @@ func (c *Chunker) Chunk(text string) ([]Chunk, error) {
 	return chunks, nil
 }
 
+// Kinds of token boundaries chunks may end at
+const (
+	noBoundary = iota
+	sentenceBoundary
+	lineBoundary
+	paragraphBoundary
+)
+
+// fixedCuts returns the token offsets chunks of tokenCount tokens end at
+// when every chunk but the last holds chunkSize tokens
+func fixedCuts(tokenCount, chunkSize, overlapSize int) []int {
+	cuts := []int{0}
+	for end := chunkSize; end < tokenCount; end += chunkSize - overlapSize {
+		cuts = append(cuts, end)
+	}
+	return append(cuts, tokenCount)
+}
+
+// breakpointWindow is the number of tokens before a boundary whose text
+// decides whether it is a breakpoint
+const breakpointWindow = 8
+
+// contentCuts returns the token offsets chunks of the decoded tokens pieces
+// end at, starting with 0 and ending with len(pieces), at most maxTokens
+// apart. A line or sentence end is a breakpoint or not depending only on
+// the tokens before it, so a chunk ends at the first breakpoint at least
+// half of maxTokens in, or else at the last line or sentence end that fits,
+// or else after maxTokens tokens. Cuts before and after an edited passage
+// land on the same breakpoints as before the edit.
+func contentCuts(pieces []string, maxTokens int) []int {
+	// Breakpoints are a quarter of maxTokens apart on average, weighted by
+	// the tokens since the previous boundary and towards paragraph ends
+	minTokens := max(maxTokens/2, 1)
+	spacing := float64(maxTokens) / 4
+	kinds := make([]int, len(pieces)+1)
+	breakpoints := make([]bool, len(pieces)+1)
+	previous := 0
+	for j := 1; j < len(pieces); j++ {
+		kinds[j] = boundaryKind(pieces[j-1], pieces[j])
+		if kinds[j] == noBoundary {
+			continue
+		}
+		weight := float64(j-previous) / spacing
+		if kinds[j] == paragraphBoundary {
+			weight *= 4
+		}
+		previous = j
+
+		sum := sha256.Sum256([]byte(strings.Join(pieces[max(j-breakpointWindow, 0):j], "")))
+		breakpoints[j] = float64(binary.BigEndian.Uint64(sum[:8])>>11)/(1<<53) < weight
+	}
+
+	cuts := []int{0}
+	for start := 0; len(pieces)-start > maxTokens; {
+		end, fallback := 0, 0
+		for j := start + 1; j <= start+maxTokens; j++ {
+			if kinds[j] == noBoundary {
+				continue
+			}
+			if j-start >= minTokens && breakpoints[j] {
+				end = j
+				break
+			}
+			if j-start >= minTokens/2 {
+				fallback = j
+			}
+		}
+		if end == 0 {
+			end = fallback
+		}
+		if end == 0 {
+			end = start + maxTokens
+		}
+		cuts = append(cuts, end)
+		start = end
+	}
+	return append(cuts, len(pieces))
+}
+
+// boundaryKind classifies the boundary between two decoded tokens
+func boundaryKind(before, after string) int {
+	switch {
+	case strings.Contains(before, "\n\n"):
+		return paragraphBoundary
+	case strings.HasSuffix(before, "\n"):
+		return lineBoundary
+	case strings.HasSuffix(strings.TrimRight(before, "\"')"), ".") ||
+		strings.HasSuffix(before, "!") || strings.HasSuffix(before, "?"):
+		// Not a decimal point or an abbreviation inside a word
+		if after != "" && (after[0] == ' ' || after[0] == '\n') {
+			return sentenceBoundary
+		}
+	}
+	return noBoundary
+}
+
 // ChunkWithMetadata chunks text and adds metadata to each chunk
 func (c *Chunker) ChunkWithMetadata(text string, metadata map[string]interface{}) ([]Chunk, error) {
 	chunks, err := c.Chunk(text)
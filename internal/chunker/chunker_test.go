@@ -1,6 +1,7 @@
 package chunker
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -175,6 +176,45 @@ func TestChunkDocument(t *testing.T) {
 	}
 }
 
+func TestChunk_EditKeepsOtherChunks(t *testing.T) {
+	c, err := NewChunker(100, 20)
+	require.NoError(t, err)
+	c.SetContentDefined(true)
+
+	paragraphs := make([]string, 60)
+	for i := range paragraphs {
+		paragraphs[i] = fmt.Sprintf("Section %d covers step %d of the runbook. Operators check the %d alerts, "+
+			"restart the affected service and confirm that %d requests succeed.\nEscalate after %d minutes.",
+			i, i*7%13, i*3%11, i*17%29, i%5+1)
+	}
+	original := strings.Join(paragraphs, "\n\n")
+	before, err := c.Chunk(original)
+	require.NoError(t, err)
+	require.Greater(t, len(before), 20)
+
+	paragraphs[30] = "This paragraph was rewritten. It is longer than before and says something else entirely, " +
+		"so every token from here on moves."
+	after, err := c.Chunk(strings.Join(paragraphs, "\n\n"))
+	require.NoError(t, err)
+
+	texts := make(map[string]int)
+	for _, chunk := range before {
+		texts[chunk.Text]++
+	}
+	kept := 0
+	for _, chunk := range after {
+		if texts[chunk.Text] > 0 {
+			texts[chunk.Text]--
+			kept++
+		}
+	}
+	assert.GreaterOrEqual(t, kept, len(after)-4, "only the chunks around the edit change")
+
+	for _, chunk := range after {
+		assert.LessOrEqual(t, c.CountTokens(chunk.Text), 100)
+	}
+}
+
 // Helper function to check if two strings have overlapping content
 func hasOverlap(text1, text2 string) bool {
 	// Check if the end of text1 overlaps with the beginning of text2
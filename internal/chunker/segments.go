@@ -0,0 +1,125 @@
+package chunker
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Segment is a timed piece of a transcript
+type Segment struct {
+	Start float64 // Start time in seconds
+	End   float64 // End time in seconds
+	Text  string
+}
+
+// Metadata keys holding the time range of a segment chunk
+const (
+	StartSecondsKey = "start_seconds"
+	EndSecondsKey   = "end_seconds"
+)
+
+// ChunkSegments chunks a transcript along segment boundaries. Consecutive
+// segments are grouped until the chunk size is reached and the overlap is
+// made of whole trailing segments. A segment longer than the chunk size is
+// split on its own, each piece keeping the segment's time range. Every chunk
+// records its time range in its metadata.
+func (c *Chunker) ChunkSegments(documentURI string, segments []Segment) ([]Chunk, error) {
+	var chunks []Chunk
+	var current []Segment
+	var currentTokens []int
+
+	add := func(text string, start, end float64) {
+		position := len(chunks)
+		chunks = append(chunks, Chunk{
+			ID:          fmt.Sprintf("%s_%s", documentURI, generateChunkID(text, position)),
+			DocumentURI: documentURI,
+			Text:        text,
+			Position:    position,
+			Metadata: map[string]interface{}{
+				StartSecondsKey: start,
+				EndSecondsKey:   end,
+			},
+		})
+	}
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		texts := make([]string, len(current))
+		for i, seg := range current {
+			texts[i] = seg.Text
+		}
+		add(strings.Join(texts, " "), current[0].Start, current[len(current)-1].End)
+
+		// Carry whole trailing segments that fit in the overlap
+		carried := 0
+		keep := len(current)
+		for keep > 0 && carried+currentTokens[keep-1] <= c.overlapSize {
+			keep--
+			carried += currentTokens[keep]
+		}
+		current = append([]Segment(nil), current[keep:]...)
+		currentTokens = append([]int(nil), currentTokens[keep:]...)
+	}
+
+	total := func() int {
+		sum := 0
+		for _, n := range currentTokens {
+			sum += n
+		}
+		return sum
+	}
+
+	for _, seg := range segments {
+		seg.Text = strings.TrimSpace(seg.Text)
+		if seg.Text == "" {
+			continue
+		}
+		n := c.CountTokens(seg.Text)
+
+		if n > c.chunkSize {
+			// Oversized segment: flush what we have and split the segment alone
+			flush()
+			current, currentTokens = nil, nil
+			pieces, err := c.Chunk(seg.Text)
+			if err != nil {
+				return nil, err
+			}
+			for _, piece := range pieces {
+				add(piece.Text, seg.Start, seg.End)
+			}
+			continue
+		}
+
+		if total()+n > c.chunkSize {
+			flush()
+			// Drop the overlap if the segment would not fit next to it
+			if total()+n > c.chunkSize {
+				current, currentTokens = nil, nil
+			}
+		}
+		current = append(current, seg)
+		currentTokens = append(currentTokens, n)
+	}
+
+	// Emit the tail unless it only holds overlap already emitted
+	if len(current) > 0 && (len(chunks) == 0 || !endsWith(chunks[len(chunks)-1], current[len(current)-1])) {
+		flush()
+	}
+
+	slog.Debug("Transcript chunked",
+		"document_uri", documentURI,
+		"segments", len(segments),
+		"chunks_created", len(chunks),
+	)
+
+	return chunks, nil
+}
+
+// endsWith reports whether a chunk already ends with the given segment
+func endsWith(chunk Chunk, seg Segment) bool {
+	end, _ := chunk.Metadata[EndSecondsKey].(float64)
+	return end == seg.End && strings.HasSuffix(chunk.Text, seg.Text)
+}
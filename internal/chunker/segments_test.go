@@ -0,0 +1,67 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunker_ChunkSegments(t *testing.T) {
+	c, err := NewChunker(60, 20)
+	require.NoError(t, err)
+
+	var segments []Segment
+	for i := 0; i < 10; i++ {
+		segments = append(segments, Segment{
+			Start: float64(i * 5),
+			End:   float64(i*5 + 5),
+			Text:  strings.Repeat("word ", 3) + string(rune('a'+i)),
+		})
+	}
+
+	chunks, err := c.ChunkSegments("media://talk", segments)
+	require.NoError(t, err)
+	require.Greater(t, len(chunks), 1)
+
+	for idx, chunk := range chunks {
+		assert.Equal(t, idx, chunk.Position)
+		assert.Equal(t, "media://talk", chunk.DocumentURI)
+		assert.True(t, strings.HasPrefix(chunk.ID, "media://talk_"))
+		assert.LessOrEqual(t, c.CountTokens(chunk.Text), 60)
+
+		start := chunk.Metadata[StartSecondsKey].(float64)
+		end := chunk.Metadata[EndSecondsKey].(float64)
+		assert.Less(t, start, end)
+	}
+
+	// Chunks start on segment boundaries and cover the whole transcript
+	assert.Equal(t, 0.0, chunks[0].Metadata[StartSecondsKey])
+	assert.Equal(t, 50.0, chunks[len(chunks)-1].Metadata[EndSecondsKey])
+
+	// Consecutive chunks overlap by whole segments
+	assert.Less(t, chunks[1].Metadata[StartSecondsKey].(float64), chunks[0].Metadata[EndSecondsKey].(float64))
+}
+
+func TestChunker_ChunkSegments_LongSegment(t *testing.T) {
+	c, err := NewChunker(50, 0)
+	require.NoError(t, err)
+
+	chunks, err := c.ChunkSegments("media://long", []Segment{
+		{Start: 0, End: 2, Text: "intro"},
+		{Start: 2, End: 90, Text: strings.Repeat("x", 120)},
+	})
+	require.NoError(t, err)
+	require.Len(t, chunks, 4)
+
+	assert.Equal(t, "intro", chunks[0].Text)
+	for _, chunk := range chunks[1:] {
+		assert.Equal(t, 2.0, chunk.Metadata[StartSecondsKey])
+		assert.Equal(t, 90.0, chunk.Metadata[EndSecondsKey])
+	}
+
+	chunks, err = c.ChunkSegments("media://empty", []Segment{{Text: "  "}})
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+}
@@ -0,0 +1,110 @@
+package embedder
+
+import (
+	"context"
+)
+
+// DefaultCohereURL is the base URL of the Cohere API
+const DefaultCohereURL = "https://api.cohere.com"
+
+// cohereBatchSize is the most texts Cohere embeds per request
+const cohereBatchSize = 96
+
+// Cohere input types
+const (
+	cohereSearchDocument = "search_document"
+	cohereSearchQuery    = "search_query"
+)
+
+// cohereRequest represents the request to Cohere's v2 embed API
+type cohereRequest struct {
+	Model           string   `json:"model"`
+	Texts           []string `json:"texts"`
+	InputType       string   `json:"input_type"`
+	EmbeddingTypes  []string `json:"embedding_types"`
+	Truncate        string   `json:"truncate,omitempty"`
+	OutputDimension int      `json:"output_dimension,omitempty"`
+}
+
+// cohereResponse represents the response from Cohere's v2 embed API
+type cohereResponse struct {
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+}
+
+// CohereEmbedder implements Embedder with the Cohere embed API. Documents
+// are embedded as "search_document" and queries as "search_query", which
+// Cohere's v3 and later models need for good retrieval.
+type CohereEmbedder struct {
+	hostedClient
+	model   string
+	request RequestOptions
+	dimensionTracker
+}
+
+// NewCohereEmbedder creates a Cohere embedder. An empty baseURL uses
+// DefaultCohereURL.
+func NewCohereEmbedder(baseURL, apiKey, model string) (*CohereEmbedder, error) {
+	if baseURL == "" {
+		baseURL = DefaultCohereURL
+	}
+	if model == "" {
+		model = "embed-english-v3.0"
+	}
+	client, err := newHostedClient("Cohere", baseURL, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return &CohereEmbedder{
+		hostedClient:     client,
+		model:            model,
+		dimensionTracker: newDimensionTracker(model),
+	}, nil
+}
+
+// SetRequestOptions sets the options sent with every request. Call it
+// before generating embeddings.
+func (c *CohereEmbedder) SetRequestOptions(opts RequestOptions) {
+	c.request = opts
+	if opts.Dimensions > 0 {
+		c.setDimension(opts.Dimensions)
+	}
+}
+
+// GenerateEmbedding embeds a search query
+func (c *CohereEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	embeddings, err := c.embed([]string{text}, cohereSearchQuery)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings embeds documents
+func (c *CohereEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	return embedInBatches(texts, cohereBatchSize, func(batch []string) ([][]float32, error) {
+		return c.embed(batch, cohereSearchDocument)
+	})
+}
+
+// embed sends one request to the embed API
+func (c *CohereEmbedder) embed(texts []string, inputType string) ([][]float32, error) {
+	var resp cohereResponse
+	err := c.post(context.Background(), "/v2/embed", cohereRequest{
+		Model:           c.model,
+		Texts:           texts,
+		InputType:       inputType,
+		EmbeddingTypes:  []string{"float"},
+		Truncate:        c.request.cohereTruncate(),
+		OutputDimension: c.request.Dimensions,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings.Float) == 0 {
+		return nil, errNoEmbedding
+	}
+	c.detect(resp.Embeddings.Float[0])
+	return resp.Embeddings.Float, nil
+}
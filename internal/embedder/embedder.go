@@ -14,7 +14,9 @@ import (
 	"time"
 )
 
-// Embedder interface for generating text embeddings
+// Embedder interface for generating text embeddings. GenerateEmbedding
+// embeds search queries and GenerateEmbeddings embeds document chunks, so
+// models that embed the two differently (Cohere, Voyage) can tell them apart.
 type Embedder interface {
 	GenerateEmbedding(text string) ([]float32, error)
 	GenerateEmbeddings(texts []string) ([][]float32, error)
@@ -23,8 +25,12 @@ type Embedder interface {
 
 // embedRequest represents the request to Ollama's embed API
 type embedRequest struct {
-	Model string `json:"model"`
-	Input any    `json:"input"`
+	Model      string         `json:"model"`
+	Input      any            `json:"input"`
+	Truncate   *bool          `json:"truncate,omitempty"`
+	KeepAlive  string         `json:"keep_alive,omitempty"`
+	Options    map[string]any `json:"options,omitempty"`
+	Dimensions int            `json:"dimensions,omitempty"`
 }
 
 // embedResponse represents the response from Ollama's embed API
@@ -38,6 +44,7 @@ type OllamaEmbedder struct {
 	baseURL   string
 	client    *http.Client
 	model     string
+	request   RequestOptions
 	dimension int
 	mu        sync.RWMutex
 }
@@ -73,6 +80,17 @@ func NewOllamaEmbedder(ollamaURL string, model string) (*OllamaEmbedder, error)
 	return embedder, nil
 }
 
+// SetRequestOptions sets the options sent with every request. Call it
+// before generating embeddings.
+func (o *OllamaEmbedder) SetRequestOptions(opts RequestOptions) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.request = opts
+	if opts.Dimensions > 0 {
+		o.dimension = opts.Dimensions
+	}
+}
+
 // GenerateEmbedding generates an embedding for a single text
 func (o *OllamaEmbedder) GenerateEmbedding(text string) ([]float32, error) {
 	start := time.Now()
@@ -89,8 +107,12 @@ func (o *OllamaEmbedder) GenerateEmbedding(text string) ([]float32, error) {
 	
 	// Create request
 	req := embedRequest{
-		Model: o.model,
-		Input: text,
+		Model:      o.model,
+		Input:      text,
+		Truncate:   o.request.Truncate,
+		KeepAlive:  o.request.KeepAlive,
+		Options:    o.request.Options,
+		Dimensions: o.request.Dimensions,
 	}
 	
 	// Marshal request to JSON
@@ -276,6 +298,20 @@ func getDimensionForModel(model string) int {
 		"nomic-embed-text-v1.5": 768,
 		"mxbai-embed-large":    1024,
 		"all-minilm":          384,
+
+		// Cohere
+		"embed-english-v3.0":            1024,
+		"embed-multilingual-v3.0":       1024,
+		"embed-english-light-v3.0":      384,
+		"embed-multilingual-light-v3.0": 384,
+		"embed-v4.0":                    1536,
+
+		// Voyage AI
+		"voyage-3":       1024,
+		"voyage-3-large": 1024,
+		"voyage-3-lite":  512,
+		"voyage-3.5":     1024,
+		"voyage-code-3":  1024,
 	}
 	
 	if dim, ok := dimensions[model]; ok {
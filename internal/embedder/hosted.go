@@ -0,0 +1,154 @@
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retries of hosted API requests that were rate limited or hit a server error
+const (
+	hostedRetries    = 3
+	hostedRetryDelay = time.Second // Doubled on every retry unless Retry-After says otherwise
+)
+
+// dimensionTracker holds the embedding dimension of a model, detected from
+// the first embedding if the model is not known
+type dimensionTracker struct {
+	model     string
+	mu        sync.RWMutex
+	dimension int
+}
+
+func newDimensionTracker(model string) dimensionTracker {
+	return dimensionTracker{model: model, dimension: getDimensionForModel(model)}
+}
+
+// Dimension returns the embedding dimension, 0 until known
+func (d *dimensionTracker) Dimension() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.dimension
+}
+
+// Model returns the name of the embedding model
+func (d *dimensionTracker) Model() string {
+	return d.model
+}
+
+// detect records the dimension of the first embedding seen
+func (d *dimensionTracker) detect(embedding []float32) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dimension == 0 && len(embedding) > 0 {
+		d.dimension = len(embedding)
+		slog.Info("Embedder dimension detected",
+			"dimension", d.dimension,
+			"model", d.model,
+		)
+	}
+}
+
+// hostedClient calls a hosted embedding API with an API key
+type hostedClient struct {
+	name    string // For error messages
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func newHostedClient(name, baseURL, apiKey string) (hostedClient, error) {
+	if apiKey == "" {
+		return hostedClient{}, fmt.Errorf("%s API key cannot be empty", name)
+	}
+	return hostedClient{
+		name:    name,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// post sends a JSON request and decodes the JSON response, retrying rate
+// limited requests and server errors
+func (h *hostedClient) post(ctx context.Context, path string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	delay := hostedRetryDelay
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", h.baseURL+path, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+
+		resp, err := h.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			err := json.NewDecoder(resp.Body).Decode(out)
+			resp.Body.Close()
+			if err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+			return nil
+		}
+
+		msg, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable || attempt == hostedRetries {
+			return fmt.Errorf("%s embedding request failed with status %d: %s", h.name, resp.StatusCode, string(msg))
+		}
+
+		wait := delay
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		}
+		slog.Warn("Retrying embedding request",
+			"provider", h.name,
+			"status", resp.StatusCode,
+			"wait_ms", wait.Milliseconds(),
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// embedInBatches embeds texts with calls of at most size texts each
+func embedInBatches(texts []string, size int, embed func([]string) ([][]float32, error)) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		batch := texts[start:min(len(texts), start+size)]
+		vectors, err := embed(batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(batch) {
+			return nil, fmt.Errorf("got %d embeddings for %d texts", len(vectors), len(batch))
+		}
+		embeddings = append(embeddings, vectors...)
+	}
+	return embeddings, nil
+}
+
+// errNoEmbedding is returned when an API answers without an embedding
+var errNoEmbedding = errors.New("no embedding returned")
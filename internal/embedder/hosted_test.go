@@ -0,0 +1,126 @@
+package embedder
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCohereEmbedder(t *testing.T) {
+	var inputTypes []string
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/embed", r.URL.Path)
+		assert.Equal(t, "Bearer co-key", r.Header.Get("Authorization"))
+
+		var req cohereRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "embed-english-v3.0", req.Model)
+		assert.Equal(t, []string{"float"}, req.EmbeddingTypes)
+		inputTypes = append(inputTypes, req.InputType)
+		sizes = append(sizes, len(req.Texts))
+
+		var resp cohereResponse
+		for _, text := range req.Texts {
+			resp.Embeddings.Float = append(resp.Embeddings.Float, []float32{float32(len(text)), 1})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	c, err := NewCohereEmbedder(server.URL, "co-key", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1024, c.Dimension())
+
+	_, err = c.GenerateEmbedding("query")
+	require.NoError(t, err)
+
+	texts := make([]string, 100)
+	for i := range texts {
+		texts[i] = string(make([]byte, i))
+	}
+	embeddings, err := c.GenerateEmbeddings(texts)
+	require.NoError(t, err)
+	require.Len(t, embeddings, 100)
+	assert.Equal(t, float32(99), embeddings[99][0])
+
+	assert.Equal(t, []string{"search_query", "search_document", "search_document"}, inputTypes)
+	assert.Equal(t, []int{1, 96, 4}, sizes)
+}
+
+func TestVoyageEmbedder(t *testing.T) {
+	var inputTypes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer vo-key", r.Header.Get("Authorization"))
+
+		var req voyageRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "voyage-3-lite", req.Model)
+		inputTypes = append(inputTypes, req.InputType)
+
+		// Answer out of order; the index field places each embedding
+		var resp voyageResponse
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, struct {
+				Embedding []float32 `json:"embedding"`
+				Index     int       `json:"index"`
+			}{Embedding: []float32{float32(len(req.Input[i])), 1}, Index: i})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	v, err := NewVoyageEmbedder(server.URL, "vo-key", "voyage-3-lite")
+	require.NoError(t, err)
+	assert.Equal(t, 512, v.Dimension())
+
+	embeddings, err := v.GenerateEmbeddings([]string{"a", "bbb"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 1}, {3, 1}}, embeddings)
+
+	embedding, err := v.GenerateEmbedding("query")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{5, 1}, embedding)
+
+	assert.Equal(t, []string{"document", "query"}, inputTypes)
+}
+
+func TestHostedEmbedder_Errors(t *testing.T) {
+	_, err := NewCohereEmbedder("", "", "")
+	assert.ErrorContains(t, err, "API key")
+	_, err = NewVoyageEmbedder("", "", "")
+	assert.ErrorContains(t, err, "API key")
+
+	// Rate limited requests are retried after Retry-After
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		if r.URL.Path == "/v2/embed" {
+			http.Error(w, "invalid api token", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(voyageResponse{})
+	}))
+	defer server.Close()
+
+	c, err := NewCohereEmbedder(server.URL, "bad", "")
+	require.NoError(t, err)
+	_, err = c.GenerateEmbedding("query")
+	assert.ErrorContains(t, err, "status 401")
+	assert.Equal(t, int32(2), calls.Load())
+
+	v, err := NewVoyageEmbedder(server.URL, "key", "")
+	require.NoError(t, err)
+	_, err = v.GenerateEmbedding("query")
+	assert.ErrorIs(t, err, errNoEmbedding)
+}
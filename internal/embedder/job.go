@@ -0,0 +1,316 @@
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for JobOptions
+const (
+	DefaultJobBatchSize    = 256
+	DefaultJobLinger       = 200 * time.Millisecond
+	DefaultJobPollInterval = 2 * time.Second
+	DefaultJobTimeout      = 30 * time.Minute
+
+	firstPollDelay = 50 * time.Millisecond // Polls back off from here to PollInterval
+)
+
+// Job states reported by a batch embedding service
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// JobOptions tunes a JobEmbedder. Zero values use the defaults.
+type JobOptions struct {
+	BatchSize    int           // Most texts per job
+	Linger       time.Duration // How long a partial batch waits for more texts
+	PollInterval time.Duration // Longest wait between status checks
+	Timeout      time.Duration // Longest a job may take, including its queue time
+	APIKey       string        // Sent as a bearer token, if set
+	Dimensions   int           // Requested embedding dimension, 0 for the model's
+}
+
+// jobSubmitRequest is the body of a job submission
+type jobSubmitRequest struct {
+	Model      string   `json:"model"`
+	Inputs     []string `json:"inputs"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+// jobStatus is the state of a submitted job. Embeddings are present once
+// the job has completed, in input order.
+type jobStatus struct {
+	ID         string      `json:"id"`
+	Status     string      `json:"status"`
+	Embeddings [][]float32 `json:"embeddings,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// jobRequest is a caller's share of a job
+type jobRequest struct {
+	texts []string
+	done  chan jobResult
+}
+
+type jobResult struct {
+	embeddings [][]float32
+	err        error
+}
+
+// JobEmbedder implements Embedder with a remote batch embedding service,
+// such as a GPU worker pool behind a queue. Texts from concurrent calls are
+// collected into jobs of up to BatchSize texts, so chunking and embedding
+// proceed independently during large ingests. Each job is submitted with
+//
+//	POST {url}/jobs  {"model": "...", "inputs": ["...", ...]}
+//
+// plus "dimensions" if JobOptions.Dimensions is set, which answers
+// {"id": "..."}, and then polled with
+//
+//	GET {url}/jobs/{id}
+//
+// until it answers {"status": "completed", "embeddings": [[...], ...]} or
+// {"status": "failed", "error": "..."}. Queries are submitted as jobs of
+// their own without waiting for a batch.
+type JobEmbedder struct {
+	baseURL string
+	client  *http.Client
+	model   string
+	opts    JobOptions
+	dimensionTracker
+
+	mu     sync.Mutex
+	queue  []*jobRequest
+	queued int // Texts in queue
+	timer  *time.Timer
+}
+
+// NewJobEmbedder creates an embedder for the batch service at serviceURL
+func NewJobEmbedder(serviceURL, model string, opts JobOptions) (*JobEmbedder, error) {
+	if serviceURL == "" {
+		return nil, errors.New("embedding service URL cannot be empty")
+	}
+	if _, err := url.Parse(serviceURL); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding service URL: %w", err)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultJobBatchSize
+	}
+	if opts.Linger <= 0 {
+		opts.Linger = DefaultJobLinger
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultJobPollInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultJobTimeout
+	}
+
+	j := &JobEmbedder{
+		baseURL:          strings.TrimSuffix(serviceURL, "/"),
+		client:           &http.Client{Timeout: 30 * time.Second}, // Per request; jobs take longer
+		model:            model,
+		opts:             opts,
+		dimensionTracker: newDimensionTracker(model),
+	}
+	if opts.Dimensions > 0 {
+		j.setDimension(opts.Dimensions)
+	}
+	return j, nil
+}
+
+// GenerateEmbedding embeds a single text in a job of its own
+func (j *JobEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	embeddings, err := j.runJob([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings embeds texts, sharing jobs with concurrent calls
+func (j *JobEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	var requests []*jobRequest
+	for start := 0; start < len(texts); start += j.opts.BatchSize {
+		requests = append(requests, j.enqueue(texts[start:min(len(texts), start+j.opts.BatchSize)]))
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for _, r := range requests {
+		result := <-r.done
+		if result.err != nil {
+			return nil, result.err
+		}
+		embeddings = append(embeddings, result.embeddings...)
+	}
+	return embeddings, nil
+}
+
+// enqueue adds texts, at most BatchSize of them, to the next job
+func (j *JobEmbedder) enqueue(texts []string) *jobRequest {
+	r := &jobRequest{texts: texts, done: make(chan jobResult, 1)}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.queued+len(texts) > j.opts.BatchSize {
+		j.flushLocked()
+	}
+	j.queue = append(j.queue, r)
+	j.queued += len(texts)
+
+	if j.queued >= j.opts.BatchSize {
+		j.flushLocked()
+	} else if j.timer == nil {
+		var t *time.Timer
+		t = time.AfterFunc(j.opts.Linger, func() {
+			j.mu.Lock()
+			defer j.mu.Unlock()
+			if j.timer == t {
+				j.flushLocked()
+			}
+		})
+		j.timer = t
+	}
+	return r
+}
+
+// flushLocked submits the queued texts as a job; j.mu must be held
+func (j *JobEmbedder) flushLocked() {
+	if j.timer != nil {
+		j.timer.Stop()
+		j.timer = nil
+	}
+	if len(j.queue) == 0 {
+		return
+	}
+	batch := j.queue
+	j.queue, j.queued = nil, 0
+
+	go func() {
+		var texts []string
+		for _, r := range batch {
+			texts = append(texts, r.texts...)
+		}
+		embeddings, err := j.runJob(texts)
+		for _, r := range batch {
+			if err != nil {
+				r.done <- jobResult{err: err}
+				continue
+			}
+			r.done <- jobResult{embeddings: embeddings[:len(r.texts)]}
+			embeddings = embeddings[len(r.texts):]
+		}
+	}()
+}
+
+// runJob submits texts as one job and waits for its embeddings
+func (j *JobEmbedder) runJob(texts []string) ([][]float32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), j.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	var submitted jobStatus
+	if err := j.do(ctx, "POST", "/jobs", jobSubmitRequest{Model: j.model, Inputs: texts, Dimensions: j.opts.Dimensions}, &submitted); err != nil {
+		return nil, fmt.Errorf("failed to submit embedding job: %w", err)
+	}
+	if submitted.ID == "" {
+		return nil, errors.New("embedding service returned no job ID")
+	}
+	slog.Debug("Embedding job submitted",
+		"job", submitted.ID,
+		"texts", len(texts),
+		"model", j.model,
+	)
+
+	delay := firstPollDelay
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("embedding job %s: %w", submitted.ID, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, j.opts.PollInterval)
+
+		var status jobStatus
+		if err := j.do(ctx, "GET", "/jobs/"+url.PathEscape(submitted.ID), nil, &status); err != nil {
+			return nil, fmt.Errorf("failed to poll embedding job %s: %w", submitted.ID, err)
+		}
+
+		switch status.Status {
+		case JobQueued, JobRunning:
+			continue
+		case JobFailed:
+			return nil, fmt.Errorf("embedding job %s failed: %s", submitted.ID, status.Error)
+		case JobCompleted:
+		default:
+			return nil, fmt.Errorf("embedding job %s has unknown status %q", submitted.ID, status.Status)
+		}
+
+		if len(status.Embeddings) != len(texts) {
+			return nil, fmt.Errorf("embedding job %s returned %d embeddings for %d texts",
+				submitted.ID, len(status.Embeddings), len(texts))
+		}
+		j.detect(status.Embeddings[0])
+		slog.Info("Embedding job completed",
+			"job", submitted.ID,
+			"texts", len(texts),
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+		return status.Embeddings, nil
+	}
+}
+
+// do sends a request to the service and decodes its JSON response
+func (j *JobEmbedder) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, j.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if j.opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+j.opts.APIKey)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package embedder
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jobService is an in-memory batch embedding service. Each job reports
+// "running" on its first poll and completes on the second.
+type jobService struct {
+	mu     sync.Mutex
+	jobs   map[string][]string
+	polls  map[string]int
+	sizes  []int
+	failOn string // Jobs containing this text fail
+}
+
+func newJobService(t *testing.T) (*jobService, *httptest.Server) {
+	s := &jobService{jobs: make(map[string][]string), polls: make(map[string]int)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.Method == "POST" && r.URL.Path == "/jobs" {
+			var req jobSubmitRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "e5", req.Model)
+			id := fmt.Sprintf("job-%d", len(s.jobs)+1)
+			s.jobs[id] = req.Inputs
+			s.sizes = append(s.sizes, len(req.Inputs))
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(jobStatus{ID: id, Status: JobQueued})
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+		inputs, ok := s.jobs[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.polls[id]++
+		status := jobStatus{ID: id, Status: JobRunning}
+		if s.polls[id] > 1 {
+			status.Status = JobCompleted
+			for _, text := range inputs {
+				if s.failOn != "" && text == s.failOn {
+					status = jobStatus{ID: id, Status: JobFailed, Error: "out of memory"}
+					break
+				}
+				status.Embeddings = append(status.Embeddings, []float32{float32(len(text)), 1, 0})
+			}
+		}
+		json.NewEncoder(w).Encode(status)
+	}))
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func TestJobEmbedder_Batches(t *testing.T) {
+	service, server := newJobService(t)
+	e, err := NewJobEmbedder(server.URL, "e5", JobOptions{BatchSize: 6, Linger: time.Second, PollInterval: 10 * time.Millisecond, APIKey: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, e.Dimension())
+
+	// Three concurrent callers fill one job
+	var wg sync.WaitGroup
+	results := make([][][]float32, 3)
+	for n := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			texts := []string{strings.Repeat("a", n+1), strings.Repeat("b", n+10)}
+			embeddings, err := e.GenerateEmbeddings(texts)
+			assert.NoError(t, err)
+			results[n] = embeddings
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []int{6}, service.sizes)
+	for n, embeddings := range results {
+		require.Len(t, embeddings, 2)
+		assert.Equal(t, float32(n+1), embeddings[0][0])
+		assert.Equal(t, float32(n+10), embeddings[1][0])
+	}
+	assert.Equal(t, 3, e.Dimension())
+
+	// Large calls are split into jobs of BatchSize texts, in order
+	texts := make([]string, 14)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i+1)
+	}
+	embeddings, err := e.GenerateEmbeddings(texts)
+	require.NoError(t, err)
+	require.Len(t, embeddings, 14)
+	for i, v := range embeddings {
+		assert.Equal(t, float32(i+1), v[0])
+	}
+	assert.Equal(t, []int{6, 6, 6, 2}, service.sizes)
+}
+
+func TestJobEmbedder_Query(t *testing.T) {
+	service, server := newJobService(t)
+	e, err := NewJobEmbedder(server.URL, "e5", JobOptions{Linger: time.Hour, PollInterval: 10 * time.Millisecond, APIKey: "secret"})
+	require.NoError(t, err)
+
+	// Queries do not wait for a batch to fill
+	embedding, err := e.GenerateEmbedding("query")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{5, 1, 0}, embedding)
+	assert.Equal(t, []int{1}, service.sizes)
+}
+
+func TestJobEmbedder_Errors(t *testing.T) {
+	_, err := NewJobEmbedder("", "e5", JobOptions{})
+	assert.Error(t, err)
+
+	service, server := newJobService(t)
+	service.failOn = "bad"
+	e, err := NewJobEmbedder(server.URL, "e5", JobOptions{Linger: time.Millisecond, PollInterval: 10 * time.Millisecond, APIKey: "secret"})
+	require.NoError(t, err)
+
+	_, err = e.GenerateEmbeddings([]string{"good", "bad"})
+	assert.ErrorContains(t, err, "out of memory")
+
+	e, err = NewJobEmbedder(server.URL, "e5", JobOptions{Linger: time.Millisecond, APIKey: "wrong"})
+	require.NoError(t, err)
+	_, err = e.GenerateEmbedding("text")
+	assert.ErrorContains(t, err, "401")
+}
@@ -0,0 +1,221 @@
+//go:build onnx
+
+package embedder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// ortMu guards the process-wide ONNX Runtime environment
+var ortMu sync.Mutex
+
+// ONNXEmbedder implements Embedder with a sentence-transformer model run
+// in-process by ONNX Runtime, so no embedding server is needed. Token
+// embeddings are mean-pooled over the attention mask and normalized, as
+// sentence-transformers does for models like all-MiniLM-L6-v2.
+type ONNXEmbedder struct {
+	opts      ONNXOptions
+	tokenizer *wordPiece
+	session   *ort.DynamicAdvancedSession
+	inputs    []string // Input names in the order tensors are passed
+	pooled    bool     // The output is already one embedding per text
+	dimensionTracker
+
+	mu sync.Mutex // Serializes runs on the session
+}
+
+// NewONNXEmbedder loads the model.onnx and vocab.txt in opts.ModelDir
+func NewONNXEmbedder(model string, opts ONNXOptions) (*ONNXEmbedder, error) {
+	if opts.ModelDir == "" {
+		return nil, errors.New("ONNX model directory cannot be empty")
+	}
+	opts = opts.withDefaults()
+
+	tokenizer, err := loadWordPiece(filepath.Join(opts.ModelDir, "vocab.txt"))
+	if err != nil {
+		return nil, err
+	}
+	if err := initONNXRuntime(opts.LibraryPath); err != nil {
+		return nil, err
+	}
+
+	modelPath := filepath.Join(opts.ModelDir, "model.onnx")
+	inputInfo, outputInfo, err := ort.GetInputOutputInfo(modelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ONNX model: %w", err)
+	}
+	if len(outputInfo) == 0 {
+		return nil, fmt.Errorf("ONNX model %s has no outputs", modelPath)
+	}
+
+	// Models exported by sentence-transformers may add a pooled output
+	output := outputInfo[0]
+	for _, info := range outputInfo {
+		if info.Name == "sentence_embedding" {
+			output = info
+		}
+	}
+
+	e := &ONNXEmbedder{
+		opts:             opts,
+		tokenizer:        tokenizer,
+		pooled:           len(output.Dimensions) == 2,
+		dimensionTracker: newDimensionTracker(model),
+	}
+	for _, info := range inputInfo {
+		switch info.Name {
+		case "input_ids", "attention_mask", "token_type_ids":
+			e.inputs = append(e.inputs, info.Name)
+		default:
+			return nil, fmt.Errorf("ONNX model %s has unsupported input %q", modelPath, info.Name)
+		}
+	}
+	if !slices.Contains(e.inputs, "input_ids") {
+		return nil, fmt.Errorf("ONNX model %s has no input_ids input", modelPath)
+	}
+	if dims := output.Dimensions; len(dims) > 0 && dims[len(dims)-1] > 0 {
+		e.dimension = int(dims[len(dims)-1])
+	}
+
+	e.session, err = ort.NewDynamicAdvancedSession(modelPath, e.inputs, []string{output.Name}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ONNX model: %w", err)
+	}
+	return e, nil
+}
+
+// initONNXRuntime loads the ONNX Runtime shared library once per process
+func initONNXRuntime(libraryPath string) error {
+	ortMu.Lock()
+	defer ortMu.Unlock()
+	if ort.IsInitialized() {
+		return nil
+	}
+	if libraryPath != "" {
+		ort.SetSharedLibraryPath(libraryPath)
+	}
+	if err := ort.InitializeEnvironment(); err != nil {
+		return fmt.Errorf("failed to initialize ONNX Runtime: %w", err)
+	}
+	return nil
+}
+
+// GenerateEmbedding embeds a single text
+func (e *ONNXEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	embeddings, err := e.run([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings embeds texts in batches of opts.BatchSize
+func (e *ONNXEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	return embedInBatches(texts, e.opts.BatchSize, e.run)
+}
+
+// Close releases the model's session
+func (e *ONNXEmbedder) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.session == nil {
+		return nil
+	}
+	err := e.session.Destroy()
+	e.session = nil
+	return err
+}
+
+// run embeds one batch of texts
+func (e *ONNXEmbedder) run(texts []string) ([][]float32, error) {
+	ids, mask, types, length := e.tokenizer.encode(texts, e.opts.MaxTokens)
+	shape := ort.NewShape(int64(len(texts)), int64(length))
+
+	data := map[string][]int64{"input_ids": ids, "attention_mask": mask, "token_type_ids": types}
+	inputs := make([]ort.Value, len(e.inputs))
+	for n, name := range e.inputs {
+		tensor, err := ort.NewTensor(shape, data[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s tensor: %w", name, err)
+		}
+		defer tensor.Destroy()
+		inputs[n] = tensor
+	}
+
+	outputs := []ort.Value{nil}
+	e.mu.Lock()
+	if e.session == nil {
+		e.mu.Unlock()
+		return nil, errors.New("ONNX embedder is closed")
+	}
+	err := e.session.Run(inputs, outputs)
+	e.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ONNX model: %w", err)
+	}
+	defer outputs[0].Destroy()
+
+	output, ok := outputs[0].(*ort.Tensor[float32])
+	if !ok {
+		return nil, errors.New("ONNX model output is not a float32 tensor")
+	}
+	dims := output.GetShape()
+	values := output.GetData()
+	dimension := int(dims[len(dims)-1])
+
+	embeddings := make([][]float32, len(texts))
+	for i := range texts {
+		if e.pooled {
+			embeddings[i] = append([]float32(nil), values[i*dimension:(i+1)*dimension]...)
+		} else {
+			embeddings[i] = meanPool(values[i*length*dimension:(i+1)*length*dimension], mask[i*length:(i+1)*length], dimension)
+		}
+		normalizeVector(embeddings[i])
+	}
+	e.detect(embeddings[0])
+	return embeddings, nil
+}
+
+// meanPool averages the token embeddings of one sequence over its mask
+func meanPool(tokens []float32, mask []int64, dimension int) []float32 {
+	sum := make([]float32, dimension)
+	var count float32
+	for t, m := range mask {
+		if m == 0 {
+			continue
+		}
+		count++
+		for d, value := range tokens[t*dimension : (t+1)*dimension] {
+			sum[d] += value
+		}
+	}
+	for d := range sum {
+		sum[d] /= max(count, 1)
+	}
+	return sum
+}
+
+// normalizeVector scales v to unit length in place
+func normalizeVector(v []float32) {
+	var norm float64
+	for _, value := range v {
+		norm += float64(value) * float64(value)
+	}
+	if norm == 0 {
+		return
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !onnx
+
+package embedder
+
+import "errors"
+
+// errONNXUnavailable is returned by builds without the onnx tag, which
+// leave out ONNX Runtime and its cgo dependency
+var errONNXUnavailable = errors.New("ONNX embedder not available: build with -tags onnx")
+
+// ONNXEmbedder runs sentence-transformer models in-process. This build
+// does not include it; build with -tags onnx.
+type ONNXEmbedder struct {
+	dimensionTracker
+}
+
+// NewONNXEmbedder fails in builds without the onnx tag
+func NewONNXEmbedder(model string, opts ONNXOptions) (*ONNXEmbedder, error) {
+	return nil, errONNXUnavailable
+}
+
+// GenerateEmbedding fails in builds without the onnx tag
+func (e *ONNXEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	return nil, errONNXUnavailable
+}
+
+// GenerateEmbeddings fails in builds without the onnx tag
+func (e *ONNXEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	return nil, errONNXUnavailable
+}
+
+// Close does nothing in builds without the onnx tag
+func (e *ONNXEmbedder) Close() error {
+	return nil
+}
@@ -0,0 +1,25 @@
+package embedder
+
+// Defaults for ONNXOptions
+const (
+	DefaultONNXMaxTokens = 256
+	DefaultONNXBatchSize = 32
+)
+
+// ONNXOptions configures an ONNXEmbedder. Zero values use the defaults.
+type ONNXOptions struct {
+	ModelDir    string // Holds model.onnx and its WordPiece vocab.txt
+	LibraryPath string // ONNX Runtime shared library; empty uses the platform default name
+	MaxTokens   int    // Texts are truncated to this many tokens
+	BatchSize   int    // Most texts per model run
+}
+
+func (o ONNXOptions) withDefaults() ONNXOptions {
+	if o.MaxTokens <= 2 {
+		o.MaxTokens = DefaultONNXMaxTokens
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultONNXBatchSize
+	}
+	return o
+}
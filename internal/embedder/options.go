@@ -0,0 +1,44 @@
+package embedder
+
+// RequestOptions are passed through to the embedding API with every
+// request, for models whose defaults do not suit. Each provider sends the
+// options its API has and ignores the others.
+type RequestOptions struct {
+	// Truncate controls whether inputs longer than the model's context are
+	// cut to fit or rejected: Ollama "truncate", Cohere "truncate" ("END"
+	// or "NONE") and Voyage "truncation". Nil leaves the API's default.
+	Truncate *bool
+
+	// KeepAlive is how long Ollama keeps the model loaded after a request,
+	// e.g. "10m"; a negative duration keeps it loaded
+	KeepAlive string
+
+	// Options are Ollama model options, such as num_ctx
+	Options map[string]any
+
+	// Dimensions asks models that can shorten their embeddings for this
+	// many dimensions: Ollama "dimensions", as OpenAI's API has it, and
+	// Cohere and Voyage "output_dimension". 0 leaves the model's own.
+	// JobOptions.Dimensions does the same for batch services.
+	Dimensions int
+}
+
+// cohereTruncate returns Cohere's truncate value for the option
+func (o RequestOptions) cohereTruncate() string {
+	switch {
+	case o.Truncate == nil:
+		return ""
+	case *o.Truncate:
+		return "END"
+	default:
+		return "NONE"
+	}
+}
+
+// setDimension declares the dimension of the model's embeddings, as
+// requested with RequestOptions.Dimensions
+func (d *dimensionTracker) setDimension(dimension int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dimension = dimension
+}
@@ -0,0 +1,85 @@
+package embedder
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestOptions_Ollama(t *testing.T) {
+	var req map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		json.NewEncoder(w).Encode(embedResponse{Embeddings: [][]float32{make([]float32, 256)}})
+	}))
+	defer server.Close()
+
+	e, err := NewOllamaEmbedder(server.URL, "nomic-embed-text")
+	require.NoError(t, err)
+
+	// Unset options are left out
+	_, err = e.GenerateEmbedding("text")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"model": "nomic-embed-text", "input": "text"}, req)
+
+	truncate := false
+	e.SetRequestOptions(RequestOptions{
+		Truncate:   &truncate,
+		KeepAlive:  "30m",
+		Options:    map[string]any{"num_ctx": 8192},
+		Dimensions: 256,
+	})
+	assert.Equal(t, 256, e.Dimension())
+	_, err = e.GenerateEmbedding("text")
+	require.NoError(t, err)
+	assert.Equal(t, false, req["truncate"])
+	assert.Equal(t, "30m", req["keep_alive"])
+	assert.Equal(t, map[string]any{"num_ctx": float64(8192)}, req["options"])
+	assert.Equal(t, float64(256), req["dimensions"])
+}
+
+func TestRequestOptions_Hosted(t *testing.T) {
+	var req map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		embedding := make([]float32, 512)
+		embedding[0] = 1
+		if r.URL.Path == "/v2/embed" {
+			var resp cohereResponse
+			resp.Embeddings.Float = [][]float32{embedding}
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]any{{"embedding": embedding, "index": 0}},
+		})
+	}))
+	defer server.Close()
+
+	truncate := true
+	opts := RequestOptions{Truncate: &truncate, KeepAlive: "30m", Dimensions: 512}
+
+	c, err := NewCohereEmbedder(server.URL, "key", "embed-v4.0")
+	require.NoError(t, err)
+	c.SetRequestOptions(opts)
+	assert.Equal(t, 512, c.Dimension())
+	_, err = c.GenerateEmbedding("query")
+	require.NoError(t, err)
+	assert.Equal(t, "END", req["truncate"])
+	assert.Equal(t, float64(512), req["output_dimension"])
+	assert.NotContains(t, req, "keep_alive")
+
+	v, err := NewVoyageEmbedder(server.URL, "key", "voyage-3.5")
+	require.NoError(t, err)
+	v.SetRequestOptions(opts)
+	assert.Equal(t, 512, v.Dimension())
+	_, err = v.GenerateEmbedding("query")
+	require.NoError(t, err)
+	assert.Equal(t, true, req["truncation"])
+	assert.Equal(t, float64(512), req["output_dimension"])
+}
@@ -0,0 +1,86 @@
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Pinger is implemented by embedders that can check their backend without
+// generating an embedding
+type Pinger interface {
+	// Ping checks that the backend is reachable and the model is available
+	Ping(ctx context.Context) error
+	// ModelLoaded reports whether the model is loaded in memory, so the
+	// next embedding does not wait for it to load
+	ModelLoaded(ctx context.Context) (bool, error)
+}
+
+// Ping checks that Ollama is reachable and has the model pulled
+func (o *OllamaEmbedder) Ping(ctx context.Context) error {
+	body, err := json.Marshal(map[string]string{"model": o.model})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("model '%s' is not available in ollama", o.model)
+	default:
+		return fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
+}
+
+// ModelLoaded reports whether Ollama has the model in memory. Ollama
+// unloads idle models, so a model that is not loaded is not an error.
+func (o *OllamaEmbedder) ModelLoaded(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/api/ps", nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("ollama unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
+
+	var running struct {
+		Models []struct {
+			Name  string `json:"name"`
+			Model string `json:"model"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&running); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	for _, m := range running.Models {
+		for _, name := range []string{m.Name, m.Model} {
+			if name == o.model || strings.TrimSuffix(name, ":latest") == o.model {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,68 @@
+package embedder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeOllama(t *testing.T, models []string, running []string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/show", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, m := range models {
+			if m == req.Model {
+				w.Write([]byte(`{}`))
+				return
+			}
+		}
+		http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /api/ps", func(w http.ResponseWriter, r *http.Request) {
+		var resp struct {
+			Models []map[string]string `json:"models"`
+		}
+		for _, m := range running {
+			resp.Models = append(resp.Models, map[string]string{"name": m, "model": m})
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestOllamaEmbedder_Ping(t *testing.T) {
+	ts := newFakeOllama(t, []string{"nomic-embed-text"}, []string{"nomic-embed-text:latest"})
+	ctx := context.Background()
+
+	emb, err := NewOllamaEmbedder(ts.URL, "nomic-embed-text")
+	require.NoError(t, err)
+	var _ Pinger = emb
+
+	require.NoError(t, emb.Ping(ctx))
+	loaded, err := emb.ModelLoaded(ctx)
+	require.NoError(t, err)
+	assert.True(t, loaded)
+
+	missing, err := NewOllamaEmbedder(ts.URL, "mxbai-embed-large")
+	require.NoError(t, err)
+	err = missing.Ping(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not available")
+	loaded, err = missing.ModelLoaded(ctx)
+	require.NoError(t, err)
+	assert.False(t, loaded)
+
+	ts.Close()
+	assert.Error(t, emb.Ping(ctx))
+}
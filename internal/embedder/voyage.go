@@ -0,0 +1,124 @@
+package embedder
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultVoyageURL is the base URL of the Voyage AI API
+const DefaultVoyageURL = "https://api.voyageai.com"
+
+// voyageBatchSize is the most texts sent to Voyage per request
+const voyageBatchSize = 128
+
+// Voyage input types
+const (
+	voyageDocument = "document"
+	voyageQuery    = "query"
+)
+
+// voyageRequest represents the request to Voyage's embeddings API
+type voyageRequest struct {
+	Input           []string `json:"input"`
+	Model           string   `json:"model"`
+	InputType       string   `json:"input_type"`
+	Truncation      *bool    `json:"truncation,omitempty"`
+	OutputDimension int      `json:"output_dimension,omitempty"`
+}
+
+// voyageResponse represents the response from Voyage's embeddings API
+type voyageResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+}
+
+// VoyageEmbedder implements Embedder with the Voyage AI embeddings API.
+// Documents are embedded with input type "document" and queries with
+// "query", so Voyage prepends the retrieval prompts its models expect.
+type VoyageEmbedder struct {
+	hostedClient
+	model   string
+	request RequestOptions
+	dimensionTracker
+}
+
+// NewVoyageEmbedder creates a Voyage embedder. An empty baseURL uses
+// DefaultVoyageURL.
+func NewVoyageEmbedder(baseURL, apiKey, model string) (*VoyageEmbedder, error) {
+	if baseURL == "" {
+		baseURL = DefaultVoyageURL
+	}
+	if model == "" {
+		model = "voyage-3"
+	}
+	client, err := newHostedClient("Voyage", baseURL, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return &VoyageEmbedder{
+		hostedClient:     client,
+		model:            model,
+		dimensionTracker: newDimensionTracker(model),
+	}, nil
+}
+
+// SetRequestOptions sets the options sent with every request. Call it
+// before generating embeddings.
+func (v *VoyageEmbedder) SetRequestOptions(opts RequestOptions) {
+	v.request = opts
+	if opts.Dimensions > 0 {
+		v.setDimension(opts.Dimensions)
+	}
+}
+
+// GenerateEmbedding embeds a search query
+func (v *VoyageEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	embeddings, err := v.embed([]string{text}, voyageQuery)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings embeds documents
+func (v *VoyageEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	return embedInBatches(texts, voyageBatchSize, func(batch []string) ([][]float32, error) {
+		return v.embed(batch, voyageDocument)
+	})
+}
+
+// embed sends one request to the embeddings API
+func (v *VoyageEmbedder) embed(texts []string, inputType string) ([][]float32, error) {
+	var resp voyageResponse
+	err := v.post(context.Background(), "/v1/embeddings", voyageRequest{
+		Input:           texts,
+		Model:           v.model,
+		InputType:       inputType,
+		Truncation:      v.request.Truncate,
+		OutputDimension: v.request.Dimensions,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, errNoEmbedding
+	}
+
+	// Results carry their input position
+	embeddings := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	for idx, e := range embeddings {
+		if e == nil {
+			return nil, fmt.Errorf("no embedding returned for text %d", idx)
+		}
+	}
+	v.detect(embeddings[0])
+	return embeddings, nil
+}
@@ -0,0 +1,167 @@
+package embedder
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxWordRunes is the longest word WordPiece splits; longer ones are unknown
+const maxWordRunes = 100
+
+// wordPiece is the uncased BERT tokenizer used by sentence-transformer
+// models such as all-MiniLM-L6-v2
+type wordPiece struct {
+	vocab              map[string]int64
+	unk, cls, sep, pad int64
+}
+
+// loadWordPiece reads a vocab.txt with one token per line, the line number
+// being the token's ID
+func loadWordPiece(path string) (*wordPiece, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary: %w", err)
+	}
+	defer f.Close()
+
+	w := &wordPiece{vocab: make(map[string]int64)}
+	scanner := bufio.NewScanner(f)
+	for id := int64(0); scanner.Scan(); id++ {
+		w.vocab[strings.TrimRight(scanner.Text(), "\r")] = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+
+	for token, id := range map[string]*int64{"[UNK]": &w.unk, "[CLS]": &w.cls, "[SEP]": &w.sep, "[PAD]": &w.pad} {
+		var ok bool
+		if *id, ok = w.vocab[token]; !ok {
+			return nil, fmt.Errorf("vocabulary %s has no %s token", path, token)
+		}
+	}
+	return w, nil
+}
+
+// tokenize returns the token IDs of text, without special tokens
+func (w *wordPiece) tokenize(text string) []int64 {
+	var ids []int64
+	for _, word := range basicTokens(text) {
+		ids = append(ids, w.wordPieces(word)...)
+	}
+	return ids
+}
+
+// wordPieces splits a word into the longest vocabulary pieces, left to
+// right, with continuing pieces prefixed by "##"
+func (w *wordPiece) wordPieces(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > maxWordRunes {
+		return []int64{w.unk}
+	}
+
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		found := false
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if id, ok := w.vocab[piece]; ok {
+				ids = append(ids, id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []int64{w.unk}
+		}
+		start = end
+	}
+	return ids
+}
+
+// encode tokenizes texts into a batch padded to its longest sequence of at
+// most maxTokens, returning input IDs, attention mask, token type IDs and
+// the sequence length
+func (w *wordPiece) encode(texts []string, maxTokens int) (ids, mask, types []int64, length int) {
+	sequences := make([][]int64, len(texts))
+	for i, text := range texts {
+		tokens := w.tokenize(text)
+		if len(tokens) > maxTokens-2 {
+			tokens = tokens[:maxTokens-2]
+		}
+		sequence := append([]int64{w.cls}, tokens...)
+		sequences[i] = append(sequence, w.sep)
+		length = max(length, len(sequences[i]))
+	}
+
+	ids = make([]int64, len(texts)*length)
+	mask = make([]int64, len(texts)*length)
+	types = make([]int64, len(texts)*length)
+	for i, sequence := range sequences {
+		row := ids[i*length : (i+1)*length]
+		for j := range row {
+			if j < len(sequence) {
+				row[j] = sequence[j]
+				mask[i*length+j] = 1
+			} else {
+				row[j] = w.pad
+			}
+		}
+	}
+	return ids, mask, types, length
+}
+
+// basicTokens lowercases text, strips accents, and splits it on whitespace
+// and punctuation, with each punctuation mark and CJK character a token
+func basicTokens(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || unicode.Is(unicode.Mn, r):
+			// Dropped, as are accents after decomposition
+		case unicode.IsSpace(r) || unicode.IsControl(r):
+			flush()
+		case isPunctuation(r) || isCJK(r):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// isPunctuation reports whether BERT treats r as punctuation, which
+// includes all non-alphanumeric ASCII symbols
+func isPunctuation(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+// isCJK reports whether r is a CJK ideograph, which BERT splits into
+// single-character tokens
+func isCJK(r rune) bool {
+	return (r >= 0x4E00 && r <= 0x9FFF) || (r >= 0x3400 && r <= 0x4DBF) ||
+		(r >= 0x20000 && r <= 0x2A6DF) || (r >= 0x2A700 && r <= 0x2B73F) ||
+		(r >= 0x2B740 && r <= 0x2B81F) || (r >= 0x2B820 && r <= 0x2CEAF) ||
+		(r >= 0xF900 && r <= 0xFAFF) || (r >= 0x2F800 && r <= 0x2FA1F)
+}
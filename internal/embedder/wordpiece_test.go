@@ -0,0 +1,51 @@
+package embedder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeVocab(t *testing.T, tokens ...string) string {
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(tokens, "\n")+"\n"), 0644))
+	return path
+}
+
+func TestWordPiece(t *testing.T) {
+	// IDs are line numbers
+	path := writeVocab(t, "[PAD]", "[UNK]", "[CLS]", "[SEP]", "cafe", "un", "##aff", "##able", "!", ",", "中", "the")
+	w, err := loadWordPiece(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cafe", ",", "unaffable", "!"}, basicTokens("  Café,\tUNAFFABLE!"))
+	assert.Equal(t, []string{"中", "文"}, basicTokens("中文"))
+
+	assert.Equal(t, []int64{4, 9, 5, 6, 7, 8}, w.tokenize("Café, unaffable!"))
+	assert.Equal(t, []int64{1, 10, 1}, w.tokenize("unknown 中文"))
+	assert.Equal(t, []int64{1}, w.tokenize(strings.Repeat("a", maxWordRunes+1)))
+
+	ids, mask, types, length := w.encode([]string{"the cafe", "un the the the the"}, 5)
+	assert.Equal(t, 5, length)
+	assert.Equal(t, []int64{2, 11, 4, 3, 0, 2, 5, 11, 11, 3}, ids)
+	assert.Equal(t, []int64{1, 1, 1, 1, 0, 1, 1, 1, 1, 1}, mask)
+	assert.Equal(t, make([]int64, 10), types)
+}
+
+func TestWordPiece_MissingSpecialToken(t *testing.T) {
+	_, err := loadWordPiece(writeVocab(t, "[PAD]", "[UNK]", "[CLS]"))
+	assert.ErrorContains(t, err, "[SEP]")
+
+	_, err = loadWordPiece(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestONNXEmbedder_Unavailable(t *testing.T) {
+	if _, err := NewONNXEmbedder("minilm", ONNXOptions{}); err == nil {
+		t.Fatal("expected an error without a model directory")
+	}
+}
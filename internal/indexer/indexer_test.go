@@ -1,9 +1,12 @@
 package indexer
 
 import (
+	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -248,4 +251,294 @@ func TestHNSWIndex_InvalidDimension(t *testing.T) {
 	_, err = index.Search([]float32{0.1, 0.2, 0.3, 0.4}, 1) // 4D instead of 3D
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "dimension")
-}
\ No newline at end of file
+}
+func TestHNSWIndex_SearchDuringBatchAdd(t *testing.T) {
+	index, err := NewHNSWIndex("", 3, DefaultConfig())
+	require.NoError(t, err)
+	defer index.Close()
+	require.NoError(t, index.Add([]float32{1, 0, 0}, 1))
+
+	vectors := make([][]float32, 500)
+	for i := range vectors {
+		vectors[i] = []float32{float32(i%7) + 1, float32(i%5) + 1, float32(i%3) + 1}
+	}
+
+	done := make(chan error)
+	go func() { done <- index.AddBatch(vectors, sequentialIDs(1, len(vectors))) }()
+
+	// Searches see the graph between insert steps
+	for {
+		results, err := index.Search([]float32{1, 0, 0}, 1)
+		require.NoError(t, err)
+		require.NotEmpty(t, results)
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+			assert.Equal(t, len(vectors)+1, index.Size())
+			return
+		default:
+		}
+	}
+}
+
+// BenchmarkSearchDuringIngest measures search latency while AddBatch
+// inserts large batches into the same index. Each iteration ingests
+// 8000 vectors into an index of 2000 and searches until ingestion ends.
+func BenchmarkSearchDuringIngest(b *testing.B) {
+	const dimension = 64
+	const batch = 2000
+	rng := rand.New(rand.NewSource(1))
+	batches := make([][][]float32, 5)
+	for n := range batches {
+		batches[n] = make([][]float32, batch)
+		for i := range batches[n] {
+			v := make([]float32, dimension)
+			for j := range v {
+				v[j] = rng.Float32()
+			}
+			batches[n][i] = v
+		}
+	}
+	query := batches[0][0]
+
+	var latencies []time.Duration
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		index, err := NewHNSWIndex("", dimension, DefaultConfig())
+		require.NoError(b, err)
+		require.NoError(b, index.AddBatch(batches[0], sequentialIDs(0, batch)))
+		b.StartTimer()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i, vectors := range batches[1:] {
+				index.AddBatch(vectors, sequentialIDs(uint64((i+1)*batch), batch))
+			}
+		}()
+
+	search:
+		for {
+			select {
+			case <-done:
+				break search
+			default:
+			}
+			start := time.Now()
+			_, err := index.Search(query, 10)
+			latencies = append(latencies, time.Since(start))
+			require.NoError(b, err)
+		}
+	}
+
+	sort.Slice(latencies, func(a, c int) bool { return latencies[a] < latencies[c] })
+	b.ReportMetric(float64(len(latencies))/float64(b.N), "searches/op")
+	b.ReportMetric(float64(latencies[len(latencies)*99/100].Microseconds()), "p99-µs")
+	b.ReportMetric(float64(latencies[len(latencies)-1].Microseconds()), "max-µs")
+}
+
+func sequentialIDs(first uint64, n int) []uint64 {
+	ids := make([]uint64, n)
+	for i := range ids {
+		ids[i] = first + uint64(i) + 1
+	}
+	return ids
+}
+
+func TestHNSWIndex_LookupAndDistance(t *testing.T) {
+	index, err := NewHNSWIndex("", 3, DefaultConfig())
+	require.NoError(t, err)
+	defer index.Close()
+
+	require.NoError(t, index.Add([]float32{1, 0, 0}, 1))
+	require.NoError(t, index.Add([]float32{0, 1, 0}, 2))
+
+	vector, ok := index.Lookup(1)
+	require.True(t, ok)
+	assert.Equal(t, []float32{1, 0, 0}, vector)
+	_, ok = index.Lookup(3)
+	assert.False(t, ok)
+
+	same, ok := index.Distance([]float32{1, 0, 0}, 1)
+	require.True(t, ok)
+	assert.InDelta(t, 0.0, same, 1e-6)
+	orthogonal, ok := index.Distance([]float32{1, 0, 0}, 2)
+	require.True(t, ok)
+	assert.InDelta(t, 1.0, orthogonal, 1e-6)
+	_, ok = index.Distance([]float32{1, 0, 0}, 3)
+	assert.False(t, ok)
+}
+
+func TestHNSWIndex_Replace(t *testing.T) {
+	index, err := NewHNSWIndex("", 3, DefaultConfig())
+	require.NoError(t, err)
+	defer index.Close()
+
+	require.NoError(t, index.Add([]float32{1, 0, 0}, 1))
+	require.NoError(t, index.Add([]float32{0, 1, 0}, 2))
+
+	err = index.Replace([]uint64{1}, [][]float32{{0, 0, 1}, {1, 1, 0}}, []uint64{3, 4})
+	require.NoError(t, err)
+	assert.Equal(t, 3, index.Size())
+	_, ok := index.Lookup(1)
+	assert.False(t, ok)
+	_, ok = index.Lookup(3)
+	assert.True(t, ok)
+
+	err = index.Replace(nil, [][]float32{{1, 0}}, []uint64{5})
+	assert.Error(t, err)
+	assert.Equal(t, 3, index.Size())
+}
+
+func TestHNSWIndex_ReuseAfterDeletingLastNode(t *testing.T) {
+	index, err := NewHNSWIndex("", 3, DefaultConfig())
+	require.NoError(t, err)
+	defer index.Close()
+
+	require.NoError(t, index.Add([]float32{1, 0, 0}, 1))
+	require.NoError(t, index.Delete(1))
+	require.NoError(t, index.Add([]float32{0, 1, 0}, 2))
+
+	require.NoError(t, index.Replace([]uint64{2}, [][]float32{{0, 0, 1}}, []uint64{3}))
+	results, err := index.Search([]float32{0, 0, 1}, 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, uint64(3), results[0].ID)
+}
+
+func TestHNSWIndex_DeletedSurviveSaveAndLoad(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "test.hnsw")
+	index, err := NewHNSWIndex(indexPath, 3, DefaultConfig())
+	require.NoError(t, err)
+
+	for id := uint64(1); id <= 20; id++ {
+		require.NoError(t, index.Add([]float32{float32(id), 1, 0}, id))
+	}
+	for id := uint64(1); id <= 10; id++ {
+		require.NoError(t, index.Delete(id))
+	}
+	assert.Equal(t, 10, index.Size())
+	require.NoError(t, index.Save())
+
+	loaded, err := NewHNSWIndex(indexPath, 3, DefaultConfig())
+	require.NoError(t, err)
+	assert.Equal(t, 10, loaded.Size())
+	_, ok := loaded.Lookup(1)
+	assert.False(t, ok)
+
+	results, err := loaded.Search([]float32{1, 1, 0}, 5)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	for _, r := range results {
+		assert.Greater(t, r.ID, uint64(10))
+	}
+}
+
+func TestHNSWIndex_SearchEf(t *testing.T) {
+	index, err := NewHNSWIndex("", 16, DefaultConfig())
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(7))
+	randomVector := func() []float32 {
+		v := make([]float32, 16)
+		for d := range v {
+			v[d] = rng.Float32()*2 - 1
+		}
+		return v
+	}
+	vectors := make([][]float32, 2000)
+	ids := make([]uint64, len(vectors))
+	for n := range vectors {
+		vectors[n], ids[n] = randomVector(), uint64(n)
+	}
+	require.NoError(t, index.AddBatch(vectors, ids))
+
+	queries := make([][]float32, 20)
+	for q := range queries {
+		queries[q] = randomVector()
+	}
+
+	// Share of the true 10 nearest neighbors found over the queries
+	recall := func(ef int) float64 {
+		found := 0
+		for _, query := range queries {
+			order := make([]int, len(vectors))
+			for n := range order {
+				order[n] = n
+			}
+			sort.Slice(order, func(a, b int) bool {
+				return index.graph.Distance(query, vectors[order[a]]) < index.graph.Distance(query, vectors[order[b]])
+			})
+			truth := make(map[uint64]bool)
+			for _, n := range order[:10] {
+				truth[uint64(n)] = true
+			}
+
+			results, err := index.SearchEf(query, 10, ef)
+			require.NoError(t, err)
+			for _, r := range results {
+				if truth[r.ID] {
+					found++
+				}
+			}
+		}
+		return float64(found) / 200
+	}
+
+	low, high := recall(0), recall(400)
+	assert.Greater(t, high, low, "a wider ef finds more neighbors")
+	assert.GreaterOrEqual(t, recall(len(vectors)), 0.99, "an exhaustive ef finds nearly every neighbor")
+	assert.Equal(t, 20, index.graph.EfSearch, "the configured ef is unchanged")
+}
+
+func TestHNSWIndex_Quantization(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "quantized.hnsw")
+	config := DefaultConfig()
+	config.Quantization = "int8"
+	index, err := NewHNSWIndex(indexPath, 16, config)
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(3))
+	vectors := make([][]float32, 200)
+	ids := make([]uint64, len(vectors))
+	for n := range vectors {
+		vectors[n] = make([]float32, 16)
+		for d := range vectors[n] {
+			vectors[n][d] = rng.Float32()*2 - 1
+		}
+		ids[n] = uint64(n + 1)
+	}
+	require.NoError(t, index.AddBatch(vectors, ids))
+
+	// Vectors find themselves despite the lost precision
+	results, err := index.Search(vectors[42], 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, uint64(43), results[0].ID)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-3)
+
+	stored, ok := index.Lookup(43)
+	require.True(t, ok)
+	assert.InDeltaSlice(t, vectors[42], stored, 1.0/127)
+	distance, ok := index.Distance(vectors[42], 43)
+	require.True(t, ok)
+	assert.InDelta(t, 0, distance, 1e-3)
+
+	require.NoError(t, index.Save())
+	loaded, err := NewHNSWIndex(indexPath, 16, config)
+	require.NoError(t, err)
+	assert.Equal(t, 200, loaded.Size())
+	results, err = loaded.Search(vectors[7], 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, uint64(8), results[0].ID)
+
+	// A graph is not loaded with another quantization
+	plain, err := NewHNSWIndex(indexPath, 16, DefaultConfig())
+	require.NoError(t, err)
+	assert.Equal(t, 0, plain.Size())
+
+	config.Quantization = "pq"
+	_, err = NewHNSWIndex("", 16, config)
+	assert.ErrorContains(t, err, "unsupported quantization")
+}
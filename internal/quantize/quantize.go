@@ -0,0 +1,124 @@
+// Package quantize stores embeddings in fewer bits. Scalar quantization
+// keeps one signed byte per component and a float32 scale per vector, a
+// quarter of float32 storage, at a small loss of precision.
+package quantize
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Quantization codecs
+const (
+	None = ""
+	Int8 = "int8"
+)
+
+// Check returns the codec for a configured quantization, "none" being None
+func Check(codec string) (string, error) {
+	switch codec {
+	case None, "none":
+		return None, nil
+	case Int8:
+		return Int8, nil
+	}
+	return "", fmt.Errorf("unsupported quantization %q (supported: none, int8)", codec)
+}
+
+// Encode quantizes v to a little-endian float32 scale followed by one
+// int8 per component, component n standing for scale times its int8
+func Encode(v []float32) []byte {
+	var peak float64
+	for _, x := range v {
+		peak = max(peak, math.Abs(float64(x)))
+	}
+	scale := float32(peak / 127)
+
+	data := make([]byte, 4+len(v))
+	binary.LittleEndian.PutUint32(data, math.Float32bits(scale))
+	if scale == 0 {
+		return data
+	}
+	for n, x := range v {
+		data[4+n] = byte(int8(math.Round(float64(x / scale))))
+	}
+	return data
+}
+
+// Decode restores the approximate vector quantized by Encode
+func Decode(data []byte) ([]float32, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("quantized vector of %d bytes is too short", len(data))
+	}
+	scale := math.Float32frombits(binary.LittleEndian.Uint32(data))
+	v := make([]float32, len(data)-4)
+	for n, b := range data[4:] {
+		v[n] = scale * float32(int8(b))
+	}
+	return v, nil
+}
+
+// Pack quantizes v like Encode into float32 words for an HNSW graph,
+// whose nodes hold []float32: the scale, then four int8 components per
+// word by their bits. Packed vectors are only compared with
+// CosineDistance and EuclideanDistance, and are copied bit for bit.
+func Pack(v []float32) []float32 {
+	data := Encode(v)
+	packed := make([]float32, 1+(len(v)+3)/4)
+	packed[0] = math.Float32frombits(binary.LittleEndian.Uint32(data))
+	words := data[4:]
+	for n := 1; len(words) > 0; n++ {
+		var word [4]byte
+		copy(word[:], words)
+		packed[n] = math.Float32frombits(binary.LittleEndian.Uint32(word[:]))
+		words = words[min(4, len(words)):]
+	}
+	return packed
+}
+
+// Unpack restores the first dimension components of a vector packed by
+// Pack
+func Unpack(packed []float32, dimension int) []float32 {
+	v := make([]float32, 0, dimension)
+	for _, word := range packed[1:] {
+		bits := math.Float32bits(word)
+		for b := 0; b < 4 && len(v) < dimension; b++ {
+			v = append(v, packed[0]*float32(int8(bits>>(8*b))))
+		}
+	}
+	return v
+}
+
+// CosineDistance is 1 minus the cosine similarity of two packed vectors,
+// as hnsw.CosineDistance is of unpacked ones
+func CosineDistance(a, b []float32) float32 {
+	var dot, normA, normB int64
+	for n := 1; n < len(a); n++ {
+		wa, wb := math.Float32bits(a[n]), math.Float32bits(b[n])
+		for shift := 0; shift < 32; shift += 8 {
+			xa, xb := int64(int8(wa>>shift)), int64(int8(wb>>shift))
+			dot += xa * xb
+			normA += xa * xa
+			normB += xb * xb
+		}
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return float32(1 - float64(dot)/math.Sqrt(float64(normA)*float64(normB)))
+}
+
+// EuclideanDistance is the L2 distance of two packed vectors
+func EuclideanDistance(a, b []float32) float32 {
+	sa, sb := float64(a[0]), float64(b[0])
+	var sum float64
+	for n := 1; n < len(a); n++ {
+		wa, wb := math.Float32bits(a[n]), math.Float32bits(b[n])
+		for shift := 0; shift < 32; shift += 8 {
+			diff := sa*float64(int8(wa>>shift)) - sb*float64(int8(wb>>shift))
+			sum += diff * diff
+		}
+	}
+	return float32(math.Sqrt(sum))
+}
@@ -0,0 +1,78 @@
+package quantize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/coder/hnsw"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomVector(rng *rand.Rand, dimension int) []float32 {
+	v := make([]float32, dimension)
+	for n := range v {
+		v[n] = rng.Float32()*2 - 1
+	}
+	return v
+}
+
+func TestCheck(t *testing.T) {
+	for _, codec := range []string{"", "none"} {
+		got, err := Check(codec)
+		require.NoError(t, err)
+		assert.Equal(t, None, got)
+	}
+	got, err := Check("int8")
+	require.NoError(t, err)
+	assert.Equal(t, Int8, got)
+	_, err = Check("pq")
+	assert.ErrorContains(t, err, "unsupported quantization")
+}
+
+func TestEncodeDecode(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	v := randomVector(rng, 10)
+	data := Encode(v)
+	assert.Len(t, data, 4+len(v))
+
+	decoded, err := Decode(data)
+	require.NoError(t, err)
+	require.Len(t, decoded, len(v))
+	for n := range v {
+		assert.InDelta(t, v[n], decoded[n], 1.0/127)
+	}
+
+	// A zero vector survives
+	decoded, err = Decode(Encode(make([]float32, 3)))
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0, 0, 0}, decoded)
+
+	_, err = Decode([]byte{1})
+	assert.Error(t, err)
+}
+
+func TestPackDistances(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, dimension := range []int{7, 64} {
+		a, b := randomVector(rng, dimension), randomVector(rng, dimension)
+		pa, pb := Pack(a), Pack(b)
+		assert.Len(t, pa, 1+(dimension+3)/4)
+
+		unpacked := Unpack(pa, dimension)
+		require.Len(t, unpacked, dimension)
+		decoded, err := Decode(Encode(a))
+		require.NoError(t, err)
+		assert.Equal(t, decoded, unpacked)
+
+		assert.InDelta(t, hnsw.CosineDistance(a, b), CosineDistance(pa, pb), 0.02)
+		assert.InDelta(t, hnsw.EuclideanDistance(a, b), EuclideanDistance(pa, pb), 0.05)
+		assert.InDelta(t, 0, CosineDistance(pa, pa), 1e-6)
+		assert.Zero(t, EuclideanDistance(pa, pa))
+	}
+
+	// Components of any sign and magnitude keep their bits through packing
+	v := []float32{-1, 1, -0.5, 0.25, float32(math.Pi)}
+	assert.InDeltaSlice(t, v, Unpack(Pack(v), len(v)), float64(math.Pi)/127)
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"go.etcd.io/bbolt"
+)
+
+// archiveSuffixes are the buckets of an index ExportIndex copies. The
+// query log, change log, and stats history describe the index where it
+// was built and stay there.
+var archiveSuffixes = replacedSuffixes
+
+// ExportIndex writes a database file holding a consistent copy of an
+// index's documents, chunks, and settings, with the content blobs its
+// documents reference, to w, whatever the index's layout, for ImportIndex
+// to read into another data directory. header is called with the size of
+// the file before it is written. It returns the checksums of the
+// documents exported.
+func (s *Storage) ExportIndex(name string, w io.Writer, header func(size int64) error) (DocumentChecksums, error) {
+	exists, err := s.IndexExists(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("index '%s' not found", name)
+	}
+
+	tmp, err := os.CreateTemp("", "hnswindex-export-*.db")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	out, err := bbolt.Open(tmp.Name(), 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	sums := make(DocumentChecksums)
+	err = s.indexDB(name).View(func(src *bbolt.Tx) error {
+		return out.Update(func(dst *bbolt.Tx) error {
+			for _, suffix := range archiveSuffixes {
+				bucket := fmt.Sprintf("%s_%s", name, suffix)
+				if err := copyBucket(dst, src, bucket, bucket); err != nil {
+					return fmt.Errorf("failed to copy bucket %s: %w", bucket, err)
+				}
+			}
+			if err := copyDocumentBlobs(dst, src, name); err != nil {
+				return err
+			}
+			return checksumIndexes(dst, []string{name}, sums)
+		})
+	})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := header(info.Size()); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(w, file); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// ImportIndex creates index name, in the current layout, from a database
+// file written by ExportIndex for index source. verify is called with the
+// checksums of the documents in the file before anything is written; an
+// error from it fails the import. Every imported document is added to the
+// change log.
+func (s *Storage) ImportIndex(name, path, source string, verify func(DocumentChecksums) error) error {
+	in, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to open archive database: %w", err)
+	}
+	defer in.Close()
+
+	return in.View(func(src *bbolt.Tx) error {
+		if src.Bucket([]byte(fmt.Sprintf("%s_documents", source))) == nil {
+			return fmt.Errorf("archive database holds no index '%s'", source)
+		}
+		sums := make(DocumentChecksums)
+		if err := checksumIndexes(src, []string{source}, sums); err != nil {
+			return err
+		}
+		if err := verify(sums); err != nil {
+			return err
+		}
+
+		if err := s.CreateIndex(name); err != nil {
+			return err
+		}
+		err := s.indexDB(name).Update(func(dst *bbolt.Tx) error {
+			for _, suffix := range archiveSuffixes {
+				target := fmt.Sprintf("%s_%s", name, suffix)
+				if err := copyBucket(dst, src, target, fmt.Sprintf("%s_%s", source, suffix)); err != nil {
+					return fmt.Errorf("failed to copy bucket %s: %w", target, err)
+				}
+			}
+			if err := retainDocumentBlobs(dst, src, name); err != nil {
+				return err
+			}
+			return dst.Bucket([]byte(fmt.Sprintf("%s_hashes", name))).ForEach(func(k, v []byte) error {
+				return appendChange(dst, name, ChangeUpsert, string(k), string(v))
+			})
+		})
+		if err != nil {
+			s.DeleteIndex(name)
+			return fmt.Errorf("failed to import index '%s': %w", name, err)
+		}
+		return nil
+	})
+}
+
+// documentBlobRefs calls fn with the content blob reference of each
+// document of an index that has one
+func documentBlobRefs(tx *bbolt.Tx, name string, fn func(ref string) error) error {
+	bucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", name)))
+	if bucket == nil {
+		return nil
+	}
+	return bucket.ForEach(func(k, v []byte) error {
+		var stored struct {
+			ContentRef string `json:"content_ref"`
+		}
+		if err := decodeValue(v, &stored); err != nil {
+			return fmt.Errorf("failed to decode document '%s': %w", k, err)
+		}
+		if stored.ContentRef == "" {
+			return nil
+		}
+		return fn(stored.ContentRef)
+	})
+}
+
+// copyDocumentBlobs copies the blobs the documents of an index reference
+// from src to dst, as they are stored
+func copyDocumentBlobs(dst, src *bbolt.Tx, name string) error {
+	from := src.Bucket([]byte(blobsBucket))
+	to, err := dst.CreateBucketIfNotExists([]byte(blobsBucket))
+	if err != nil {
+		return err
+	}
+	return documentBlobRefs(dst, name, func(ref string) error {
+		if from == nil || from.Get([]byte(ref)) == nil {
+			return fmt.Errorf("content blob %s is missing", ref)
+		}
+		return to.Put([]byte(ref), append([]byte(nil), from.Get([]byte(ref))...))
+	})
+}
+
+// retainDocumentBlobs adds a reference to the blob of each document of an
+// index in dst, copying blobs dst does not hold yet from src
+func retainDocumentBlobs(dst, src *bbolt.Tx, name string) error {
+	from := src.Bucket([]byte(blobsBucket))
+	to, err := dst.CreateBucketIfNotExists([]byte(blobsBucket))
+	if err != nil {
+		return err
+	}
+	return documentBlobRefs(dst, name, func(ref string) error {
+		value := append([]byte(nil), to.Get([]byte(ref))...)
+		if len(value) < 8 {
+			if from == nil || len(from.Get([]byte(ref))) < 8 {
+				return fmt.Errorf("content blob %s is missing", ref)
+			}
+			value = append([]byte(nil), from.Get([]byte(ref))...)
+			binary.BigEndian.PutUint64(value[:8], 0)
+		}
+		binary.BigEndian.PutUint64(value[:8], binary.BigEndian.Uint64(value[:8])+1)
+		return to.Put([]byte(ref), value)
+	})
+}
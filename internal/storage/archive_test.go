@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_ExportImportIndex(t *testing.T) {
+	for _, layouts := range [][2]string{{LayoutShared, LayoutPerIndex}, {LayoutPerIndex, LayoutShared}} {
+		t.Run(layouts[0]+"_to_"+layouts[1], func(t *testing.T) {
+			src, err := NewStorage(filepath.Join(t.TempDir(), "src.db"))
+			require.NoError(t, err)
+			defer src.Close()
+			src.SetBlobThreshold(100)
+			require.NoError(t, src.SetLayout(layouts[0]))
+
+			body := strings.Repeat("long body ", 20)
+			require.NoError(t, src.CreateIndex("kb"))
+			require.NoError(t, src.CreateIndex("other"))
+			require.NoError(t, src.StoreDocument("kb", Document{URI: "doc://1", Content: body, Hash: "h1"}))
+			require.NoError(t, src.StoreDocument("kb", Document{URI: "doc://2", Content: "short", Hash: "h2"}))
+			require.NoError(t, src.StoreChunk("kb", Chunk{ID: "c1", DocumentURI: "doc://1", Text: "long body", HNSWId: 7}))
+			require.NoError(t, src.StoreDocument("other", Document{URI: "doc://3", Content: body, Hash: "h3"}))
+			require.NoError(t, src.SetIndexSetting("kb", "schema", []byte(`{}`)))
+
+			path := filepath.Join(t.TempDir(), "kb.db")
+			file, err := os.Create(path)
+			require.NoError(t, err)
+			var size int64
+			sums, err := src.ExportIndex("kb", file, func(n int64) error {
+				size = n
+				return nil
+			})
+			require.NoError(t, err)
+			require.NoError(t, file.Close())
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, size, info.Size())
+			assert.Len(t, sums["kb"], 2)
+
+			dst, err := NewStorage(filepath.Join(t.TempDir(), "dst.db"))
+			require.NoError(t, err)
+			defer dst.Close()
+			dst.SetBlobThreshold(100)
+			require.NoError(t, dst.SetLayout(layouts[1]))
+			require.NoError(t, dst.CreateIndex("prod"))
+			require.NoError(t, dst.StoreDocument("prod", Document{URI: "doc://1", Content: body, Hash: "h1"}))
+
+			// A failed verification writes nothing
+			err = dst.ImportIndex("kb-2024", path, "kb", func(got DocumentChecksums) error {
+				return errors.New("mismatch")
+			})
+			assert.ErrorContains(t, err, "mismatch")
+			exists, err := dst.IndexExists("kb-2024")
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			err = dst.ImportIndex("kb-2024", path, "kb", func(got DocumentChecksums) error {
+				assert.Equal(t, sums, got)
+				return nil
+			})
+			require.NoError(t, err)
+
+			doc, err := dst.GetDocument("kb-2024", "doc://1")
+			require.NoError(t, err)
+			assert.Equal(t, body, doc.Content)
+			chunk, _, err := dst.GetChunkByHNSWId("kb-2024", 7)
+			require.NoError(t, err)
+			assert.Equal(t, "c1", chunk.ID)
+			setting, err := dst.GetIndexSetting("kb-2024", "schema")
+			require.NoError(t, err)
+			assert.Equal(t, `{}`, string(setting))
+			changes, err := dst.ListChanges("kb-2024", 0, 0)
+			require.NoError(t, err)
+			assert.Len(t, changes, 2)
+			_, err = dst.GetDocument("kb-2024", "doc://3")
+			assert.Error(t, err, "other indexes stay behind")
+
+			// The blob is shared with the document already stored, and
+			// survives its deletion
+			require.NoError(t, dst.DeleteIndex("prod"))
+			doc, err = dst.GetDocument("kb-2024", "doc://1")
+			require.NoError(t, err)
+			assert.Equal(t, body, doc.Content)
+
+			assert.Error(t, dst.ImportIndex("kb-2024", path, "kb", func(DocumentChecksums) error { return nil }), "the index exists")
+			assert.ErrorContains(t, dst.ImportIndex("x", path, "missing", func(DocumentChecksums) error { return nil }), "holds no index")
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"go.etcd.io/bbolt"
+)
+
+// DocumentWrite replaces a document and all of its chunks in one index
+type DocumentWrite struct {
+	Index    string
+	Document Document
+	Chunks   []Chunk // HNSWId is assigned by WriteDocuments unless kept
+
+	// ReplacedHNSWIds lists the graph IDs of the chunks that were replaced,
+	// filled in by WriteDocuments so callers can remove them from the graph
+	ReplacedHNSWIds []uint64
+
+	// KeptHNSWIds lists the graph IDs carried over from the previous
+	// version: a chunk set to the HNSWId of one of the document's current
+	// chunks keeps it, so its vector can stay in the graph. Filled in by
+	// WriteDocuments.
+	KeptHNSWIds []uint64
+}
+
+// WriteDocuments stores documents and their chunks across one or more indexes
+// in a single transaction. Existing chunks of each document are removed and
+// HNSW IDs are allocated for the new chunks; either every write is committed
+// or none is. Writes to indexes with their own database file (LayoutPerIndex)
+// are committed in one transaction per file, so they are only atomic per
+// index.
+func (s *Storage) WriteDocuments(writes []DocumentWrite) error {
+	slog.Debug("Writing document group",
+		"documents", len(writes),
+	)
+
+	var order []*bbolt.DB
+	groups := make(map[*bbolt.DB][]*DocumentWrite)
+	for idx := range writes {
+		db := s.indexDB(writes[idx].Index)
+		if _, ok := groups[db]; !ok {
+			order = append(order, db)
+		}
+		groups[db] = append(groups[db], &writes[idx])
+	}
+
+	for _, db := range order {
+		err := db.Update(func(tx *bbolt.Tx) error {
+			return s.writeDocuments(tx, groups[db])
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDocuments applies writes inside a transaction
+func (s *Storage) writeDocuments(tx *bbolt.Tx, writes []*DocumentWrite) error {
+	metadata := make(map[string]*IndexMetadata)
+
+	for _, w := range writes {
+		m, ok := metadata[w.Index]
+		if !ok {
+			var err error
+			m, err = readIndexMetadata(tx, w.Index)
+			if err != nil {
+				return err
+			}
+			metadata[w.Index] = m
+		}
+
+		replaced, err := deleteDocumentChunks(tx, w.Index, w.Document.URI)
+		if err != nil {
+			return fmt.Errorf("failed to replace chunks of '%s': %w", w.Document.URI, err)
+		}
+		current := make(map[uint64]bool, len(replaced))
+		for _, id := range replaced {
+			current[id] = true
+		}
+
+		if err := s.putDocument(tx, w.Index, w.Document); err != nil {
+			return fmt.Errorf("failed to store document '%s': %w", w.Document.URI, err)
+		}
+
+		chunkIDs := make([]string, 0, len(w.Chunks))
+		w.ReplacedHNSWIds, w.KeptHNSWIds = nil, nil
+		for c := range w.Chunks {
+			if id := w.Chunks[c].HNSWId; current[id] {
+				delete(current, id)
+				w.KeptHNSWIds = append(w.KeptHNSWIds, id)
+			} else {
+				w.Chunks[c].HNSWId = m.NextHNSWId
+				m.NextHNSWId++
+			}
+			if err := s.putChunk(tx, w.Index, w.Chunks[c]); err != nil {
+				return fmt.Errorf("failed to store chunk '%s': %w", w.Chunks[c].ID, err)
+			}
+			chunkIDs = append(chunkIDs, w.Chunks[c].ID)
+		}
+		for _, id := range replaced {
+			if current[id] {
+				w.ReplacedHNSWIds = append(w.ReplacedHNSWIds, id)
+			}
+		}
+
+		docChunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_doc_chunks", w.Index)))
+		if err := putDocChunkIDs(docChunkBucket, w.Document.URI, chunkIDs); err != nil {
+			return err
+		}
+	}
+
+	// Persist the advanced HNSW ID counters
+	for name, m := range metadata {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", name)))
+		if err := metadataBucket.Put([]byte("metadata"), data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readIndexMetadata reads the metadata of an index inside a transaction
+func readIndexMetadata(tx *bbolt.Tx, indexName string) (*IndexMetadata, error) {
+	metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName)))
+	if metadataBucket == nil {
+		return nil, fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	data := metadataBucket.Get([]byte("metadata"))
+	if data == nil {
+		return nil, errors.New("metadata not found")
+	}
+
+	var m IndexMetadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// deleteDocumentChunks removes all chunks of a document inside a transaction
+// and returns their HNSW IDs
+func deleteDocumentChunks(tx *bbolt.Tx, indexName, documentURI string) ([]uint64, error) {
+	docChunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_doc_chunks", indexName)))
+	chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
+	if docChunkBucket == nil || chunkBucket == nil {
+		return nil, fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	chunkIDs, err := readDocChunkIDs(docChunkBucket, documentURI)
+	if err != nil {
+		return nil, err
+	}
+
+	var hnswIDs []uint64
+	for _, id := range chunkIDs {
+		if data := chunkBucket.Get([]byte(id)); data != nil {
+			var chunk Chunk
+			if err := decodeValue(data, &chunk); err == nil {
+				hnswIDs = append(hnswIDs, chunk.HNSWId)
+				if err := unmapHNSWId(tx, indexName, chunk); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := chunkBucket.Delete([]byte(id)); err != nil {
+			return nil, err
+		}
+		if err := deleteSparse(tx, indexName, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return hnswIDs, deleteDocChunkIDs(docChunkBucket, documentURI)
+}
+
+// putDocument stores a document and its hash inside a transaction
+func (s *Storage) putDocument(tx *bbolt.Tx, indexName string, doc Document) error {
+	docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", indexName)))
+	if docBucket == nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	if err := releaseStoredDocument(tx, docBucket, doc.URI); err != nil {
+		return err
+	}
+	data, err := s.encodeDocument(tx, doc)
+	if err != nil {
+		return err
+	}
+	if err := docBucket.Put([]byte(doc.URI), data); err != nil {
+		return err
+	}
+
+	if doc.Hash != "" {
+		hashBucket := tx.Bucket([]byte(fmt.Sprintf("%s_hashes", indexName)))
+		if err := hashBucket.Put([]byte(doc.URI), []byte(doc.Hash)); err != nil {
+			return err
+		}
+	}
+	return appendChange(tx, indexName, ChangeUpsert, doc.URI, doc.Hash)
+}
+
+// putChunk stores a chunk inside a transaction without touching the
+// document-chunk mapping
+func (s *Storage) putChunk(tx *bbolt.Tx, indexName string, chunk Chunk) error {
+	chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
+	if chunkBucket == nil {
+		return fmt.Errorf("index '%s' not found", indexName)
+	}
+
+	data, err := s.encodeChunk(chunk)
+	if err != nil {
+		return err
+	}
+	if err := replaceChunk(tx, indexName, chunkBucket, chunk.ID); err != nil {
+		return err
+	}
+	if err := chunkBucket.Put([]byte(chunk.ID), data); err != nil {
+		return err
+	}
+	if err := mapHNSWId(tx, indexName, chunk); err != nil {
+		return err
+	}
+	return putSparse(tx, indexName, chunk.ID, chunk.Sparse)
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_WriteDocuments(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("team"))
+	require.NoError(t, store.CreateIndex("global"))
+
+	writes := []DocumentWrite{
+		{
+			Index:    "team",
+			Document: Document{URI: "doc://1", Title: "One", Hash: "h1"},
+			Chunks: []Chunk{
+				{ID: "team-c1", DocumentURI: "doc://1", Text: "first", Position: 0},
+				{ID: "team-c2", DocumentURI: "doc://1", Text: "second", Position: 1},
+			},
+		},
+		{
+			Index:    "global",
+			Document: Document{URI: "doc://1", Title: "One", Hash: "h1"},
+			Chunks: []Chunk{
+				{ID: "global-c1", DocumentURI: "doc://1", Text: "first", Position: 0},
+			},
+		},
+	}
+	require.NoError(t, store.WriteDocuments(writes))
+
+	// IDs are allocated per index
+	assert.Equal(t, uint64(1), writes[0].Chunks[0].HNSWId)
+	assert.Equal(t, uint64(2), writes[0].Chunks[1].HNSWId)
+	assert.Equal(t, uint64(1), writes[1].Chunks[0].HNSWId)
+
+	chunks, err := store.GetChunksByDocument("team", "doc://1")
+	require.NoError(t, err)
+	assert.Len(t, chunks, 2)
+
+	hash, err := store.GetDocumentHash("global", "doc://1")
+	require.NoError(t, err)
+	assert.Equal(t, "h1", hash)
+
+	next, err := store.GetNextHNSWId("team")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), next)
+
+	// Rewriting replaces the previous chunks
+	rewrite := []DocumentWrite{{
+		Index:    "team",
+		Document: Document{URI: "doc://1", Title: "One", Hash: "h2"},
+		Chunks:   []Chunk{{ID: "team-c3", DocumentURI: "doc://1", Text: "third"}},
+	}}
+	require.NoError(t, store.WriteDocuments(rewrite))
+	assert.ElementsMatch(t, []uint64{1, 2}, rewrite[0].ReplacedHNSWIds)
+
+	chunks, err = store.GetChunksByDocument("team", "doc://1")
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "team-c3", chunks[0].ID)
+
+	// A write to a missing index rolls back the whole group
+	failing := []DocumentWrite{
+		{Index: "team", Document: Document{URI: "doc://2"}},
+		{Index: "missing", Document: Document{URI: "doc://2"}},
+	}
+	assert.Error(t, store.WriteDocuments(failing))
+	_, err = store.GetDocument("team", "doc://2")
+	assert.Error(t, err)
+}
+
+func TestStorage_GetChunkByHNSWId(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("docs"))
+	writes := []DocumentWrite{{
+		Index:    "docs",
+		Document: Document{URI: "doc://1", Title: "One", Content: "first second"},
+		Chunks: []Chunk{
+			{ID: "c1", DocumentURI: "doc://1", Text: "first"},
+			{ID: "c2", DocumentURI: "doc://1", Text: "second", Position: 1},
+		},
+	}}
+	require.NoError(t, store.WriteDocuments(writes))
+
+	chunk, doc, err := store.GetChunkByHNSWId("docs", writes[0].Chunks[1].HNSWId)
+	require.NoError(t, err)
+	require.NotNil(t, chunk)
+	assert.Equal(t, "c2", chunk.ID)
+	assert.Equal(t, "One", doc.Title)
+
+	chunk, doc, err = store.GetChunkByHNSWId("docs", 99)
+	require.NoError(t, err)
+	assert.Nil(t, chunk)
+	assert.Nil(t, doc)
+
+	_, _, err = store.GetChunkByHNSWId("missing", 1)
+	assert.Error(t, err)
+}
+
+func TestStorage_WriteDocumentsKeepsHNSWIds(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.CreateIndex("kb"))
+
+	first := []DocumentWrite{{
+		Index:    "kb",
+		Document: Document{URI: "doc://1"},
+		Chunks: []Chunk{
+			{ID: "c1", DocumentURI: "doc://1", Text: "first", Position: 0},
+			{ID: "c2", DocumentURI: "doc://1", Text: "second", Position: 1},
+		},
+	}}
+	require.NoError(t, store.WriteDocuments(first))
+
+	// The first chunk is unchanged; an unknown ID is not kept
+	second := []DocumentWrite{{
+		Index:    "kb",
+		Document: Document{URI: "doc://1"},
+		Chunks: []Chunk{
+			{ID: "c1", HNSWId: 1, DocumentURI: "doc://1", Text: "first", Position: 0},
+			{ID: "c3", HNSWId: 99, DocumentURI: "doc://1", Text: "changed", Position: 1},
+		},
+	}}
+	require.NoError(t, store.WriteDocuments(second))
+	assert.Equal(t, []uint64{1}, second[0].KeptHNSWIds)
+	assert.Equal(t, []uint64{2}, second[0].ReplacedHNSWIds)
+	assert.Equal(t, uint64(1), second[0].Chunks[0].HNSWId)
+	assert.Equal(t, uint64(3), second[0].Chunks[1].HNSWId)
+}
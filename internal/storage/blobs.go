@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// blobsBucket is the global content-addressed bucket holding large document
+// bodies. Each value is an 8-byte reference count followed by the content.
+// Indexes in their own database file have a blob bucket of their own, so
+// their content is not shared with other indexes.
+const blobsBucket = "_blobs"
+
+// SetBlobThreshold sets the content size in bytes from which document
+// content is stored once in the shared blob bucket, referenced by hash.
+// Zero stores all content inline. Existing documents are not migrated.
+func (s *Storage) SetBlobThreshold(bytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobThreshold = bytes
+}
+
+// BlobStats returns the number of stored blobs and their total stored size
+// (after compression)
+func (s *Storage) BlobStats() (int, int64, error) {
+	// Indexes in their own file keep their blobs there
+	dbs := []*bbolt.DB{s.db}
+	s.dbsMu.RLock()
+	for _, db := range s.indexDBs {
+		dbs = append(dbs, db)
+	}
+	s.dbsMu.RUnlock()
+
+	var count int
+	var size int64
+	for _, db := range dbs {
+		err := db.View(func(tx *bbolt.Tx) error {
+			bucket := tx.Bucket([]byte(blobsBucket))
+			if bucket == nil {
+				return nil
+			}
+			return bucket.ForEach(func(k, v []byte) error {
+				count++
+				size += int64(len(v) - 8)
+				return nil
+			})
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return count, size, nil
+}
+
+// encodeDocument serializes a document for the documents bucket, moving
+// large content into the blob bucket
+func (s *Storage) encodeDocument(tx *bbolt.Tx, doc Document) ([]byte, error) {
+	s.mu.RLock()
+	threshold := s.blobThreshold
+	s.mu.RUnlock()
+
+	if threshold > 0 && len(doc.Content) >= threshold {
+		sum := sha256.Sum256([]byte(doc.Content))
+		ref := hex.EncodeToString(sum[:])
+		if err := s.retainBlob(tx, ref, doc.Content); err != nil {
+			return nil, fmt.Errorf("failed to store content blob: %w", err)
+		}
+		doc.ContentRef = ref
+		doc.Content = ""
+	}
+
+	return s.encodeValue(doc)
+}
+
+// decodeDocument deserializes a stored document, loading blob content
+func decodeDocument(tx *bbolt.Tx, data []byte) (Document, error) {
+	var doc Document
+	if err := decodeValue(data, &doc); err != nil {
+		return doc, err
+	}
+
+	if doc.ContentRef != "" {
+		bucket := tx.Bucket([]byte(blobsBucket))
+		if bucket == nil {
+			return doc, fmt.Errorf("content blob %s not found", doc.ContentRef)
+		}
+		blob := bucket.Get([]byte(doc.ContentRef))
+		if len(blob) < 8 {
+			return doc, fmt.Errorf("content blob %s not found", doc.ContentRef)
+		}
+		content, err := decompress(blob[8:])
+		if err != nil {
+			return doc, fmt.Errorf("failed to read content blob %s: %w", doc.ContentRef, err)
+		}
+		doc.Content = string(content)
+		doc.ContentRef = ""
+	}
+	return doc, nil
+}
+
+// releaseStoredDocument drops the blob reference of the document currently
+// stored under uri, if any. Call before overwriting or deleting a document.
+func releaseStoredDocument(tx *bbolt.Tx, docBucket *bbolt.Bucket, uri string) error {
+	data := docBucket.Get([]byte(uri))
+	if data == nil {
+		return nil
+	}
+
+	var stored struct {
+		ContentRef string `json:"content_ref"`
+	}
+	if err := decodeValue(data, &stored); err != nil || stored.ContentRef == "" {
+		return nil
+	}
+	return releaseBlob(tx, stored.ContentRef)
+}
+
+// retainBlob stores content under ref or increments its reference count
+func (s *Storage) retainBlob(tx *bbolt.Tx, ref, content string) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(blobsBucket))
+	if err != nil {
+		return err
+	}
+
+	existing := bucket.Get([]byte(ref))
+	if len(existing) >= 8 {
+		// Keep the stored content, whatever codec it was written with
+		value := append([]byte(nil), existing...)
+		binary.BigEndian.PutUint64(value[:8], binary.BigEndian.Uint64(existing[:8])+1)
+		return bucket.Put([]byte(ref), value)
+	}
+
+	data := s.compress([]byte(content))
+	value := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(value[:8], 1)
+	copy(value[8:], data)
+	return bucket.Put([]byte(ref), value)
+}
+
+// releaseBlob decrements the reference count of a blob, deleting it when
+// no documents reference it
+func releaseBlob(tx *bbolt.Tx, ref string) error {
+	bucket := tx.Bucket([]byte(blobsBucket))
+	if bucket == nil {
+		return nil
+	}
+
+	existing := bucket.Get([]byte(ref))
+	if len(existing) < 8 {
+		return nil
+	}
+
+	refs := binary.BigEndian.Uint64(existing[:8])
+	if refs <= 1 {
+		return bucket.Delete([]byte(ref))
+	}
+
+	value := append([]byte(nil), existing...)
+	binary.BigEndian.PutUint64(value[:8], refs-1)
+	return bucket.Put([]byte(ref), value)
+}
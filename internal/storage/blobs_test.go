@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_ContentBlobs(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	store.SetBlobThreshold(100)
+
+	require.NoError(t, store.CreateIndex("team"))
+	require.NoError(t, store.CreateIndex("global"))
+
+	large := strings.Repeat("shared runbook content ", 20)
+	require.NoError(t, store.StoreDocument("team", Document{URI: "doc://1", Title: "Runbook", Content: large}))
+	require.NoError(t, store.StoreDocument("global", Document{URI: "doc://1", Title: "Runbook", Content: large}))
+	require.NoError(t, store.StoreDocument("team", Document{URI: "doc://small", Content: "short"}))
+
+	// Identical bodies are stored once; small bodies stay inline
+	count, size, err := store.BlobStats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int64(len(large)), size)
+
+	doc, err := store.GetDocument("global", "doc://1")
+	require.NoError(t, err)
+	assert.Equal(t, large, doc.Content)
+	assert.Empty(t, doc.ContentRef)
+
+	page, err := store.ListDocumentsPage("team", "", 10)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, large, page[0].Content)
+
+	// Replacing a document releases its old blob only when unreferenced
+	edited := large + "edited"
+	require.NoError(t, store.StoreDocument("team", Document{URI: "doc://1", Content: edited}))
+	count, _, err = store.BlobStats()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	require.NoError(t, store.DeleteIndex("global"))
+	count, _, err = store.BlobStats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "blob of the deleted index is released")
+
+	// Group writes use the blob store too
+	require.NoError(t, store.WriteDocuments([]DocumentWrite{{
+		Index:    "team",
+		Document: Document{URI: "doc://1", Content: large},
+	}}))
+	doc, err = store.GetDocument("team", "doc://1")
+	require.NoError(t, err)
+	assert.Equal(t, large, doc.Content)
+
+	require.NoError(t, store.DeleteDocument("team", "doc://1"))
+	count, _, err = store.BlobStats()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Change log operations
+const (
+	ChangeUpsert = "upsert"
+	ChangeDelete = "delete"
+)
+
+// ChangeEntry records a document upsert or delete in an index's change log
+type ChangeEntry struct {
+	Seq       uint64    `json:"seq"`
+	Op        string    `json:"op"`
+	URI       string    `json:"uri"`
+	Hash      string    `json:"hash,omitempty"` // Content hash after an upsert
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// changeLogBucket returns the name of an index's change log bucket
+func changeLogBucket(indexName string) []byte {
+	return []byte(fmt.Sprintf("%s_changelog", indexName))
+}
+
+// appendChange records a change inside the transaction that makes it, so
+// the log never misses or invents a committed change
+func appendChange(tx *bbolt.Tx, indexName, op, uri, hash string) error {
+	// Indexes created before the change log existed lack the bucket
+	bucket, err := tx.CreateBucketIfNotExists(changeLogBucket(indexName))
+	if err != nil {
+		return err
+	}
+
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(ChangeEntry{
+		Seq:       seq,
+		Op:        op,
+		URI:       uri,
+		Hash:      hash,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return bucket.Put(sequenceKey(seq), data)
+}
+
+// ListChanges returns up to limit change log entries with a sequence number
+// greater than since, oldest first. A limit of 0 returns all of them.
+func (s *Storage) ListChanges(indexName string, since uint64, limit int) ([]ChangeEntry, error) {
+	var entries []ChangeEntry
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName))) == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+		bucket := tx.Bucket(changeLogBucket(indexName))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, v := c.Seek(sequenceKey(since + 1)); k != nil; k, v = c.Next() {
+			if limit > 0 && len(entries) >= limit {
+				break
+			}
+			var entry ChangeEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("failed to decode change log entry: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// LatestChangeSeq returns the sequence number of the last recorded change,
+// or 0 if there is none
+func (s *Storage) LatestChangeSeq(indexName string) (uint64, error) {
+	var seq uint64
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName))) == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+		if bucket := tx.Bucket(changeLogBucket(indexName)); bucket != nil {
+			seq = bucket.Sequence()
+		}
+		return nil
+	})
+	return seq, err
+}
+
+// TrimChanges deletes change log entries with a sequence number up to and
+// including through, returning the number removed. Sequence numbers are
+// never reused.
+func (s *Storage) TrimChanges(indexName string, through uint64) (int, error) {
+	var removed int
+	err := s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(changeLogBucket(indexName))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.First() {
+			if bytes.Compare(k, sequenceKey(through)) > 0 {
+				break
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
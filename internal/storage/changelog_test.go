@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_ChangeLog(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.CreateIndex("test-index"))
+
+	require.NoError(t, store.StoreDocument("test-index", Document{URI: "doc://1", Hash: "h1"}))
+	require.NoError(t, store.WriteDocuments([]DocumentWrite{{
+		Index:    "test-index",
+		Document: Document{URI: "doc://2", Hash: "h2"},
+	}}))
+	require.NoError(t, store.DeleteDocument("test-index", "doc://1"))
+	// Deleting a missing document is not a change
+	require.NoError(t, store.DeleteDocument("test-index", "doc://missing"))
+
+	changes, err := store.ListChanges("test-index", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, ChangeEntry{Seq: 1, Op: ChangeUpsert, URI: "doc://1", Hash: "h1", Timestamp: changes[0].Timestamp}, changes[0])
+	assert.Equal(t, "doc://2", changes[1].URI)
+	assert.Equal(t, ChangeDelete, changes[2].Op)
+	assert.Equal(t, uint64(3), changes[2].Seq)
+
+	changes, err = store.ListChanges("test-index", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, uint64(2), changes[0].Seq)
+
+	latest, err := store.LatestChangeSeq("test-index")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), latest)
+
+	removed, err := store.TrimChanges("test-index", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	changes, err = store.ListChanges("test-index", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, uint64(3), changes[0].Seq)
+
+	// Sequence numbers are not reused after trimming
+	require.NoError(t, store.StoreDocument("test-index", Document{URI: "doc://3"}))
+	latest, err = store.LatestChangeSeq("test-index")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), latest)
+
+	_, err = store.ListChanges("missing", 0, 0)
+	assert.Error(t, err)
+}
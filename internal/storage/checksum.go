@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"go.etcd.io/bbolt"
+)
+
+// DocumentChecksums maps index names to the checksums of their documents,
+// by URI
+type DocumentChecksums map[string]map[string]string
+
+// documentChecksum hashes the stored record of a document, its content
+// blob, and its chunks in position order, so any of them being lost or
+// damaged changes the checksum
+func documentChecksum(tx *bbolt.Tx, indexName, uri string, data []byte) (string, error) {
+	h := sha256.New()
+	writeField(h, []byte(uri))
+	writeField(h, data)
+
+	var doc Document
+	if err := decodeValue(data, &doc); err != nil {
+		return "", fmt.Errorf("failed to decode document '%s': %w", uri, err)
+	}
+	if doc.ContentRef != "" {
+		var blob []byte
+		if bucket := tx.Bucket([]byte(blobsBucket)); bucket != nil {
+			blob = bucket.Get([]byte(doc.ContentRef))
+		}
+		writeField(h, blob)
+	}
+
+	var ids []string
+	if bucket := tx.Bucket([]byte(fmt.Sprintf("%s_doc_chunks", indexName))); bucket != nil {
+		var err error
+		if ids, err = readDocChunkIDs(bucket, uri); err != nil {
+			return "", err
+		}
+	}
+	chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
+	for _, id := range ids {
+		writeField(h, []byte(id))
+		var chunk []byte
+		if chunkBucket != nil {
+			chunk = chunkBucket.Get([]byte(id))
+		}
+		writeField(h, chunk)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeField writes a length-prefixed field to a hash, so adjacent fields
+// cannot run into each other
+func writeField(w io.Writer, data []byte) {
+	fmt.Fprintf(w, "%d:", len(data))
+	w.Write(data)
+}
+
+// checksumIndexes adds the checksums of the documents of the named indexes
+// stored in tx
+func checksumIndexes(tx *bbolt.Tx, names []string, sums DocumentChecksums) error {
+	for _, name := range names {
+		docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", name)))
+		if docBucket == nil {
+			continue
+		}
+		docs := make(map[string]string)
+		err := docBucket.ForEach(func(k, v []byte) error {
+			sum, err := documentChecksum(tx, name, string(k), v)
+			if err != nil {
+				return err
+			}
+			docs[string(k)] = sum
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to checksum index '%s': %w", name, err)
+		}
+		sums[name] = docs
+	}
+	return nil
+}
+
+// sharedIndexes returns the names of the indexes registered in tx of the
+// main database that are stored in it rather than in a file of their own
+func sharedIndexes(tx *bbolt.Tx) []string {
+	var names []string
+	if bucket := tx.Bucket([]byte("_indexes")); bucket != nil {
+		bucket.ForEach(func(k, v []byte) error {
+			if string(v) != registryFile {
+				names = append(names, string(k))
+			}
+			return nil
+		})
+	}
+	return names
+}
+
+// DocumentChecksums returns the checksums of the documents of every index,
+// for comparison with those recorded by Backup and BackupIndex
+func (s *Storage) DocumentChecksums() (DocumentChecksums, error) {
+	sums := make(DocumentChecksums)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return checksumIndexes(tx, sharedIndexes(tx), sums)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range s.IndexFiles() {
+		err := s.indexDB(name).View(func(tx *bbolt.Tx) error {
+			return checksumIndexes(tx, []string{name}, sums)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sums, nil
+}
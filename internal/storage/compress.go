@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression codecs for stored document and chunk values
+const (
+	CompressionNone = "none"
+	CompressionZstd = "zstd"
+)
+
+// compressedMagic prefixes compressed values, followed by a codec byte.
+// Uncompressed values are JSON objects or plain text, which never start
+// with a NUL byte, so old and new values can be read side by side.
+var compressedMagic = []byte{0x00, 'h', 'z'}
+
+// codecZstd is the codec byte of zstd-compressed values
+const codecZstd byte = 1
+
+// minCompressSize is the value size below which compression is skipped
+const minCompressSize = 256
+
+// zstdEncoder and zstdDecoder are shared by all storages; EncodeAll and
+// DecodeAll may be called concurrently
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return e
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		d, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+		return d
+	})
+)
+
+// SetCompression selects the codec for newly written documents, chunks,
+// and content blobs: "zstd", or "none" (or "") to store them uncompressed.
+// Existing values are read regardless of the codec they were written with.
+func (s *Storage) SetCompression(codec string) error {
+	switch codec {
+	case "":
+		codec = CompressionNone
+	case CompressionNone, CompressionZstd:
+	default:
+		return fmt.Errorf("unsupported compression %q (supported: zstd, none)", codec)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.compression = codec
+	return nil
+}
+
+// encodeValue marshals v to JSON and compresses it with the configured codec
+func (s *Storage) encodeValue(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return s.compress(data), nil
+}
+
+// decodeValue decompresses data if needed and unmarshals it into v
+func decodeValue(data []byte, v interface{}) error {
+	data, err := decompress(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// compress compresses data with the configured codec. Values that are small
+// or do not shrink are returned unchanged.
+func (s *Storage) compress(data []byte) []byte {
+	s.mu.RLock()
+	codec := s.compression
+	s.mu.RUnlock()
+
+	if codec != CompressionZstd || len(data) < minCompressSize {
+		return data
+	}
+
+	header := len(compressedMagic) + 1
+	out := make([]byte, header, header+len(data)/2)
+	copy(out, compressedMagic)
+	out[len(compressedMagic)] = codecZstd
+	out = zstdEncoder().EncodeAll(data, out)
+
+	if len(out) >= len(data) {
+		return data
+	}
+	return out
+}
+
+// decompress returns the original bytes of a possibly compressed value
+func decompress(data []byte) ([]byte, error) {
+	header := len(compressedMagic) + 1
+	if len(data) < header || !bytes.HasPrefix(data, compressedMagic) {
+		return data, nil
+	}
+
+	switch codec := data[len(compressedMagic)]; codec {
+	case codecZstd:
+		out, err := zstdDecoder().DecodeAll(data[header:], nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress value: %w", err)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unknown compression codec %d", codec)
+	}
+}
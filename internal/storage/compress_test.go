@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestStorage_Compression(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.CreateIndex("docs"))
+
+	content := strings.Repeat("| Column | Value |\n|---|---|\n| a | b |\n", 50)
+
+	// Written before compression was enabled
+	require.NoError(t, store.StoreDocument("docs", Document{URI: "doc://plain", Content: content}))
+
+	require.NoError(t, store.SetCompression(CompressionZstd))
+	require.NoError(t, store.StoreDocument("docs", Document{URI: "doc://packed", Content: content}))
+	require.NoError(t, store.StoreChunk("docs", Chunk{ID: "c1", DocumentURI: "doc://packed", Text: content, Embedding: []float32{0.5, 1}}))
+
+	// Compressed values are smaller on disk
+	var plainSize, packedSize int
+	require.NoError(t, store.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte("docs_documents"))
+		plainSize = len(bucket.Get([]byte("doc://plain")))
+		packedSize = len(bucket.Get([]byte("doc://packed")))
+		return nil
+	}))
+	assert.Less(t, packedSize, plainSize/4)
+
+	// Both forms read back transparently
+	for _, uri := range []string{"doc://plain", "doc://packed"} {
+		doc, err := store.GetDocument("docs", uri)
+		require.NoError(t, err)
+		assert.Equal(t, content, doc.Content)
+	}
+
+	chunk, err := store.GetChunk("docs", "c1")
+	require.NoError(t, err)
+	assert.Equal(t, content, chunk.Text)
+	assert.Equal(t, []float32{0.5, 1}, chunk.Embedding)
+
+	chunks, err := store.GetChunksByDocument("docs", "doc://packed")
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+
+	// Compressed content blobs
+	store.SetBlobThreshold(100)
+	require.NoError(t, store.StoreDocument("docs", Document{URI: "doc://blob", Content: content}))
+	doc, err := store.GetDocument("docs", "doc://blob")
+	require.NoError(t, err)
+	assert.Equal(t, content, doc.Content)
+	_, size, err := store.BlobStats()
+	require.NoError(t, err)
+	assert.Less(t, size, int64(len(content)/4))
+
+	assert.Error(t, store.SetCompression("flate"))
+	assert.Error(t, store.SetCompression("lz4"))
+}
+
+func TestStorage_CompressionRoundTrip(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.SetCompression(CompressionZstd))
+
+	large := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 40))
+	small := []byte("short value")
+
+	packed := store.compress(large)
+	require.True(t, bytes.HasPrefix(packed, compressedMagic))
+	assert.Equal(t, codecZstd, packed[len(compressedMagic)])
+	assert.Less(t, len(packed), len(large))
+
+	out, err := decompress(packed)
+	require.NoError(t, err)
+	assert.Equal(t, large, out)
+
+	// Small values are stored as-is
+	assert.Equal(t, small, store.compress(small))
+	out, err = decompress(small)
+	require.NoError(t, err)
+	assert.Equal(t, small, out)
+
+	// Unknown codecs are rejected
+	_, err = decompress(append(append([]byte{}, compressedMagic...), 9, 0))
+	assert.Error(t, err)
+
+	// With compression off, values are written uncompressed
+	require.NoError(t, store.SetCompression(CompressionNone))
+	assert.Equal(t, large, store.compress(large))
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"go.etcd.io/bbolt"
+)
+
+// The doc_chunks bucket of an index maps each document to its chunks. A
+// document has a sub-bucket named by its URI holding position -> chunk ID,
+// with positions as 8-byte big-endian keys so a cursor walks the chunks in
+// order and adding one is a single put. Databases written before the
+// sub-buckets store a JSON array of chunk IDs under the URI instead.
+// Readers accept both; writes and MigrateDocChunks convert documents to
+// sub-buckets.
+
+// docChunkKey returns the sub-bucket key of a chunk position
+func docChunkKey(position int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(position))
+	return key
+}
+
+// readDocChunkIDs returns the chunk IDs mapped to a document in position
+// order, or nil if it has none
+func readDocChunkIDs(bucket *bbolt.Bucket, uri string) ([]string, error) {
+	if sub := bucket.Bucket([]byte(uri)); sub != nil {
+		var ids []string
+		err := sub.ForEach(func(k, v []byte) error {
+			ids = append(ids, string(v))
+			return nil
+		})
+		return ids, err
+	}
+
+	data := bucket.Get([]byte(uri))
+	if data == nil {
+		return nil, nil
+	}
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("failed to decode chunk mapping for '%s': %w", uri, err)
+	}
+	return ids, nil
+}
+
+// putDocChunkIDs replaces the chunks mapped to a document, the position of
+// each being its place in ids
+func putDocChunkIDs(bucket *bbolt.Bucket, uri string, ids []string) error {
+	if err := deleteDocChunkIDs(bucket, uri); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	sub, err := bucket.CreateBucket([]byte(uri))
+	if err != nil {
+		return err
+	}
+	for position, id := range ids {
+		if err := sub.Put(docChunkKey(position), []byte(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addDocChunkID maps a chunk to a document at position, converting a JSON
+// mapping first. A chunk already mapped at another position is moved.
+func addDocChunkID(bucket *bbolt.Bucket, uri string, position int, id string) error {
+	sub := bucket.Bucket([]byte(uri))
+	if sub == nil {
+		ids, err := readDocChunkIDs(bucket, uri)
+		if err != nil {
+			return err
+		}
+		if err := putDocChunkIDs(bucket, uri, ids); err != nil {
+			return err
+		}
+		if sub, err = bucket.CreateBucketIfNotExists([]byte(uri)); err != nil {
+			return err
+		}
+	}
+
+	c := sub.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if string(v) == id {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+	}
+	return sub.Put(docChunkKey(position), []byte(id))
+}
+
+// deleteDocChunkIDs removes the chunk mapping of a document in either
+// format
+func deleteDocChunkIDs(bucket *bbolt.Bucket, uri string) error {
+	if bucket.Bucket([]byte(uri)) != nil {
+		return bucket.DeleteBucket([]byte(uri))
+	}
+	return bucket.Delete([]byte(uri))
+}
+
+// MigrateDocChunks converts the JSON chunk mappings among the next limit
+// documents after the URI after to sub-buckets, in one transaction. It
+// returns the URI to continue after, or "" once the end is reached, so an
+// index can be migrated in small steps while it stays in use.
+func (s *Storage) MigrateDocChunks(indexName, after string, limit int) (string, error) {
+	next := ""
+	migrated := 0
+	err := s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(fmt.Sprintf("%s_doc_chunks", indexName)))
+		if bucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		// Collect first: creating buckets while iterating moves the cursor
+		var uris []string
+		scanned := 0
+		c := bucket.Cursor()
+		var k, v []byte
+		if after == "" {
+			k, v = c.First()
+		} else {
+			k, v = c.Seek([]byte(after))
+			if k != nil && string(k) == after {
+				k, v = c.Next()
+			}
+		}
+		for ; k != nil && scanned < limit; k, v = c.Next() {
+			scanned++
+			next = string(k)
+			if v != nil {
+				uris = append(uris, string(k))
+			}
+		}
+		if k == nil {
+			next = ""
+		}
+
+		for _, uri := range uris {
+			ids, err := readDocChunkIDs(bucket, uri)
+			if err != nil {
+				return err
+			}
+			if err := putDocChunkIDs(bucket, uri, ids); err != nil {
+				return err
+			}
+		}
+		migrated = len(uris)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if migrated > 0 {
+		slog.Debug("Migrated document chunk mappings",
+			"index", indexName,
+			"documents", migrated,
+		)
+	}
+	return next, nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+// putLegacyDocChunks stores a document's chunks with a JSON chunk mapping,
+// as databases written before sub-buckets have them
+func putLegacyDocChunks(t *testing.T, store *Storage, index, uri string, n int) {
+	t.Helper()
+	for pos := 0; pos < n; pos++ {
+		require.NoError(t, store.StoreChunk(index, Chunk{
+			ID: fmt.Sprintf("%s-%d", uri, pos), HNSWId: uint64(pos + 1), DocumentURI: uri, Position: pos,
+		}))
+	}
+	err := store.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(index + "_doc_chunks"))
+		if err := bucket.DeleteBucket([]byte(uri)); err != nil {
+			return err
+		}
+		var ids string
+		for pos := 0; pos < n; pos++ {
+			if pos > 0 {
+				ids += ","
+			}
+			ids += fmt.Sprintf("%q", fmt.Sprintf("%s-%d", uri, pos))
+		}
+		return bucket.Put([]byte(uri), []byte("["+ids+"]"))
+	})
+	require.NoError(t, err)
+}
+
+func TestStorage_LegacyDocChunks(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.CreateIndex("kb"))
+	require.NoError(t, store.StoreDocument("kb", Document{URI: "doc1"}))
+	putLegacyDocChunks(t, store, "kb", "doc1", 3)
+
+	chunks, err := store.GetChunksByDocument("kb", "doc1")
+	require.NoError(t, err)
+	assert.Len(t, chunks, 3)
+	counts, err := store.CountChunksByDocument("kb")
+	require.NoError(t, err)
+	assert.Equal(t, 3, counts["doc1"])
+
+	// Adding a chunk converts the mapping
+	require.NoError(t, store.StoreChunk("kb", Chunk{ID: "doc1-3", DocumentURI: "doc1", Position: 3}))
+	chunks, err = store.GetChunksByDocument("kb", "doc1")
+	require.NoError(t, err)
+	require.Len(t, chunks, 4)
+	assert.Equal(t, "doc1-3", chunks[3].ID)
+
+	require.NoError(t, store.DeleteChunksByDocument("kb", "doc1"))
+	chunks, err = store.GetChunksByDocument("kb", "doc1")
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+}
+
+func TestStorage_MigrateDocChunks(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.CreateIndex("kb"))
+	for n := 0; n < 5; n++ {
+		putLegacyDocChunks(t, store, "kb", fmt.Sprintf("doc%d", n), 2)
+	}
+
+	after, steps := "", 0
+	for {
+		after, err = store.MigrateDocChunks("kb", after, 2)
+		require.NoError(t, err)
+		steps++
+		if after == "" {
+			break
+		}
+	}
+	assert.Equal(t, 3, steps)
+
+	err = store.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte("kb_doc_chunks"))
+		return bucket.ForEach(func(k, v []byte) error {
+			assert.Nil(t, v, "%s still has a JSON mapping", k)
+			return nil
+		})
+	})
+	require.NoError(t, err)
+
+	counts, err := store.CountChunksByDocument("kb")
+	require.NoError(t, err)
+	for n := 0; n < 5; n++ {
+		assert.Equal(t, 2, counts[fmt.Sprintf("doc%d", n)])
+	}
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"go.etcd.io/bbolt"
+)
+
+// embeddingsBucket is the global bucket caching embeddings across indexes
+const embeddingsBucket = "_embeddings"
+
+// embeddingsOrderBucket lists the cached embeddings by insertion sequence,
+// so the oldest are evicted first when the cache is full
+const embeddingsOrderBucket = "_embeddings_order"
+
+// GetCachedEmbeddings looks up cached embeddings for the given text hashes.
+// Only hashes with a cached embedding are present in the returned map.
+func (s *Storage) GetCachedEmbeddings(model string, hashes []string) (map[string][]float32, error) {
+	found := make(map[string][]float32)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(embeddingsBucket))
+		if bucket == nil {
+			return nil
+		}
+
+		for _, hash := range hashes {
+			if data := bucket.Get(embeddingKey(model, hash)); data != nil {
+				found[hash] = decodeEmbedding(data)
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+// PutCachedEmbeddings stores embeddings keyed by text hash for a model.
+// With limit above 0, the oldest entries are evicted to keep at most limit
+// embeddings cached.
+func (s *Storage) PutCachedEmbeddings(model string, embeddings map[string][]float32, limit int) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(embeddingsBucket))
+		if err != nil {
+			return err
+		}
+		order, err := tx.CreateBucketIfNotExists([]byte(embeddingsOrderBucket))
+		if err != nil {
+			return err
+		}
+
+		for hash, embedding := range embeddings {
+			key := embeddingKey(model, hash)
+			if bucket.Get(key) != nil {
+				continue
+			}
+			if err := bucket.Put(key, encodeEmbedding(embedding)); err != nil {
+				return fmt.Errorf("failed to cache embedding: %w", err)
+			}
+			seq, err := order.NextSequence()
+			if err != nil {
+				return err
+			}
+			if err := order.Put(sequenceKey(seq), key); err != nil {
+				return fmt.Errorf("failed to cache embedding: %w", err)
+			}
+		}
+		if limit <= 0 {
+			return nil
+		}
+
+		// Entries are evicted from the front, so the sequences in the order
+		// bucket are contiguous
+		c := order.Cursor()
+		first, _ := c.First()
+		last, _ := c.Last()
+		if first == nil {
+			return nil
+		}
+		excess := int(binary.BigEndian.Uint64(last)-binary.BigEndian.Uint64(first)) + 1 - limit
+		for k, key := c.First(); k != nil && excess > 0; k, key = c.First() {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			excess--
+		}
+		return nil
+	})
+}
+
+// ClearCachedEmbeddings removes all cached embeddings
+func (s *Storage) ClearCachedEmbeddings() error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range []string{embeddingsBucket, embeddingsOrderBucket} {
+			if tx.Bucket([]byte(name)) != nil {
+				if err := tx.DeleteBucket([]byte(name)); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err := tx.CreateBucket([]byte(embeddingsOrderBucket)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket([]byte(embeddingsBucket))
+		return err
+	})
+}
+
+// CachedEmbeddingCount returns the number of cached embeddings
+func (s *Storage) CachedEmbeddingCount() (int, error) {
+	var count int
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		if bucket := tx.Bucket([]byte(embeddingsBucket)); bucket != nil {
+			count = bucket.Stats().KeyN
+		}
+		return nil
+	})
+	return count, err
+}
+
+// embeddingKey builds the cache key for a model and text hash
+func embeddingKey(model, hash string) []byte {
+	return []byte(model + "\x00" + hash)
+}
+
+// encodeEmbedding encodes a vector as little-endian float32 values
+func encodeEmbedding(embedding []float32) []byte {
+	data := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(v))
+	}
+	return data
+}
+
+// decodeEmbedding decodes a vector encoded by encodeEmbedding
+func decodeEmbedding(data []byte) []float32 {
+	embedding := make([]float32, len(data)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return embedding
+}
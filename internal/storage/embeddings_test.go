@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_CachedEmbeddings(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.PutCachedEmbeddings("model-a", map[string][]float32{
+		"h1": {0.5, -1.25, 3},
+	}, 0))
+
+	found, err := store.GetCachedEmbeddings("model-a", []string{"h1", "h2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]float32{"h1": {0.5, -1.25, 3}}, found)
+
+	// Embeddings are keyed by model
+	found, err = store.GetCachedEmbeddings("model-b", []string{"h1"})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	count, err := store.CachedEmbeddingCount()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, store.ClearCachedEmbeddings())
+	count, err = store.CachedEmbeddingCount()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestStorage_CachedEmbeddingsLimit(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	for _, hash := range []string{"h1", "h2", "h3"} {
+		require.NoError(t, store.PutCachedEmbeddings("model", map[string][]float32{hash: {1}}, 2))
+	}
+	// Caching an embedding again does not make it newer
+	require.NoError(t, store.PutCachedEmbeddings("model", map[string][]float32{"h2": {1}}, 2))
+
+	// The oldest entry was evicted
+	found, err := store.GetCachedEmbeddings("model", []string{"h1", "h2", "h3"})
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.NotContains(t, found, "h1")
+	count, err := store.CachedEmbeddingCount()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	require.NoError(t, store.PutCachedEmbeddings("model", map[string][]float32{"h4": {1}, "h5": {1}}, 2))
+	found, err = store.GetCachedEmbeddings("model", []string{"h2", "h3", "h4", "h5"})
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Contains(t, found, "h4")
+	assert.Contains(t, found, "h5")
+}
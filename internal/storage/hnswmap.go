@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+
+	"go.etcd.io/bbolt"
+)
+
+// The <index>_hnsw_map bucket maps each graph ID, as an 8-byte big-endian
+// key, to the ID of the chunk holding it, so search hits are resolved
+// without scanning the chunks. Indexes created before the bucket existed
+// get it filled when storage is opened.
+
+func hnswMapBucketName(indexName string) []byte {
+	return []byte(fmt.Sprintf("%s_hnsw_map", indexName))
+}
+
+func hnswMapKey(hnswID uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, hnswID)
+	return key
+}
+
+// mapHNSWId maps a chunk's graph ID to the chunk inside a transaction.
+// Chunks without a graph ID are not mapped.
+func mapHNSWId(tx *bbolt.Tx, indexName string, chunk Chunk) error {
+	bucket := tx.Bucket(hnswMapBucketName(indexName))
+	if bucket == nil || chunk.HNSWId == 0 {
+		return nil
+	}
+	return bucket.Put(hnswMapKey(chunk.HNSWId), []byte(chunk.ID))
+}
+
+// unmapHNSWId removes the mapping of a chunk's graph ID inside a
+// transaction, unless the ID has been mapped to another chunk since
+func unmapHNSWId(tx *bbolt.Tx, indexName string, chunk Chunk) error {
+	bucket := tx.Bucket(hnswMapBucketName(indexName))
+	if bucket == nil || chunk.HNSWId == 0 {
+		return nil
+	}
+	key := hnswMapKey(chunk.HNSWId)
+	if string(bucket.Get(key)) != chunk.ID {
+		return nil
+	}
+	return bucket.Delete(key)
+}
+
+// replaceChunk removes the graph ID mapping of the chunk stored under
+// chunkID, if any, inside a transaction, before it is overwritten or
+// deleted
+func replaceChunk(tx *bbolt.Tx, indexName string, chunkBucket *bbolt.Bucket, chunkID string) error {
+	data := chunkBucket.Get([]byte(chunkID))
+	if data == nil {
+		return nil
+	}
+	var old Chunk
+	if err := decodeValue(data, &old); err != nil {
+		return nil // Nothing to unmap that can be read
+	}
+	return unmapHNSWId(tx, indexName, old)
+}
+
+// lookupChunkByHNSWId returns the chunk holding a graph ID inside a
+// transaction, or nil if there is none. Indexes without the map are
+// scanned.
+func lookupChunkByHNSWId(tx *bbolt.Tx, indexName string, chunkBucket *bbolt.Bucket, hnswID uint64) (*Chunk, error) {
+	bucket := tx.Bucket(hnswMapBucketName(indexName))
+	if bucket == nil {
+		var found *Chunk
+		err := scanChunks(chunkBucket, func(c *Chunk) bool {
+			if c.HNSWId == hnswID {
+				found = c
+				return false
+			}
+			return true
+		})
+		return found, err
+	}
+
+	chunkID := bucket.Get(hnswMapKey(hnswID))
+	if chunkID == nil {
+		return nil, nil
+	}
+	data := chunkBucket.Get(chunkID)
+	if data == nil {
+		return nil, nil
+	}
+	var c Chunk
+	if err := decodeChunk(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode chunk '%s': %w", chunkID, err)
+	}
+	if c.HNSWId != hnswID {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// scanChunks decodes the chunks of a bucket in key order until fn returns
+// false
+func scanChunks(chunkBucket *bbolt.Bucket, fn func(c *Chunk) bool) error {
+	cursor := chunkBucket.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		var c Chunk
+		if err := decodeChunk(v, &c); err != nil {
+			return fmt.Errorf("failed to decode chunk '%s': %w", k, err)
+		}
+		if !fn(&c) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// buildHNSWMaps fills the graph ID map of every index that lacks one
+func (s *Storage) buildHNSWMaps() error {
+	names, err := s.ListIndexes()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		mapped := 0
+		err := s.indexDB(name).Update(func(tx *bbolt.Tx) error {
+			chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", name)))
+			if chunkBucket == nil || tx.Bucket(hnswMapBucketName(name)) != nil {
+				return nil
+			}
+			bucket, err := tx.CreateBucket(hnswMapBucketName(name))
+			if err != nil {
+				return err
+			}
+			var putErr error
+			err = scanChunks(chunkBucket, func(c *Chunk) bool {
+				if c.HNSWId == 0 {
+					return true
+				}
+				if putErr = bucket.Put(hnswMapKey(c.HNSWId), []byte(c.ID)); putErr != nil {
+					return false
+				}
+				mapped++
+				return true
+			})
+			if err != nil {
+				return err
+			}
+			return putErr
+		})
+		if err != nil {
+			return fmt.Errorf("failed to build HNSW ID map of index '%s': %w", name, err)
+		}
+		if mapped > 0 {
+			slog.Info("Built HNSW ID map",
+				"index", name,
+				"chunks", mapped,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+// hnswMap returns the graph ID map of an index, nil if it has none
+func hnswMap(t *testing.T, store *Storage, indexName string) map[uint64]string {
+	t.Helper()
+	var mapping map[uint64]string
+	require.NoError(t, store.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(hnswMapBucketName(indexName))
+		if bucket == nil {
+			return nil
+		}
+		mapping = make(map[uint64]string)
+		return bucket.ForEach(func(k, v []byte) error {
+			mapping[binary.BigEndian.Uint64(k)] = string(v)
+			return nil
+		})
+	}))
+	return mapping
+}
+
+func TestStorage_HNSWMap(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.CreateIndex("kb"))
+
+	write := func(uri string, chunks ...Chunk) []Chunk {
+		writes := []DocumentWrite{{Index: "kb", Document: Document{URI: uri}, Chunks: chunks}}
+		require.NoError(t, store.WriteDocuments(writes))
+		return writes[0].Chunks
+	}
+	first := write("doc://1",
+		Chunk{ID: "c1", DocumentURI: "doc://1", Text: "first"},
+		Chunk{ID: "c2", DocumentURI: "doc://1", Text: "second", Position: 1},
+	)
+	write("doc://2", Chunk{ID: "c3", DocumentURI: "doc://2", Text: "third"})
+	assert.Equal(t, map[uint64]string{1: "c1", 2: "c2", 3: "c3"}, hnswMap(t, store, "kb"))
+
+	// Rewriting keeps the IDs of unchanged chunks and drops the others
+	first[1].ID = "c2b"
+	first[1].HNSWId = 0
+	write("doc://1", first...)
+	assert.Equal(t, map[uint64]string{1: "c1", 3: "c3", 4: "c2b"}, hnswMap(t, store, "kb"))
+
+	chunks, err := store.GetChunksByHNSWIds("kb", []uint64{1, 2, 4})
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "c1", chunks[1].ID)
+	assert.Equal(t, "c2b", chunks[4].ID)
+
+	require.NoError(t, store.DeleteChunksByDocument("kb", "doc://2"))
+	require.NoError(t, store.DeleteDocument("kb", "doc://2"))
+	assert.Equal(t, map[uint64]string{1: "c1", 4: "c2b"}, hnswMap(t, store, "kb"))
+}
+
+func TestStorage_BuildHNSWMaps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewStorage(path)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateIndex("kb"))
+	writes := []DocumentWrite{{
+		Index:    "kb",
+		Document: Document{URI: "doc://1", Title: "One"},
+		Chunks: []Chunk{
+			{ID: "c1", DocumentURI: "doc://1", Text: "first"},
+			{ID: "c2", DocumentURI: "doc://1", Text: "second", Position: 1},
+		},
+	}}
+	require.NoError(t, store.WriteDocuments(writes))
+
+	// Indexes written before the map existed are still searchable, by
+	// scanning, and get the map when storage is next opened
+	require.NoError(t, store.db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket(hnswMapBucketName("kb"))
+	}))
+	chunk, _, err := store.GetChunkByHNSWId("kb", 2)
+	require.NoError(t, err)
+	require.NotNil(t, chunk)
+	assert.Equal(t, "c2", chunk.ID)
+	require.NoError(t, store.Close())
+
+	store, err = NewStorage(path)
+	require.NoError(t, err)
+	defer store.Close()
+	assert.Equal(t, map[uint64]string{1: "c1", 2: "c2"}, hnswMap(t, store, "kb"))
+	chunk, doc, err := store.GetChunkByHNSWId("kb", 2)
+	require.NoError(t, err)
+	require.NotNil(t, chunk)
+	assert.Equal(t, "c2", chunk.ID)
+	assert.Equal(t, "One", doc.Title)
+}
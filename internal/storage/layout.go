@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.etcd.io/bbolt"
+)
+
+// Storage layouts for new indexes
+const (
+	// LayoutShared stores every index in prefix-named buckets of the main
+	// database (the default)
+	LayoutShared = "shared"
+
+	// LayoutPerIndex stores each index in its own database file next to
+	// its HNSW graph, so deleting or backing up an index is a file
+	// operation and writes to different indexes do not contend
+	LayoutPerIndex = "per_index"
+)
+
+// Values of the index registry in the _indexes bucket
+const (
+	registryShared = "active" // Buckets live in the main database
+	registryFile   = "file"   // Buckets live in the index's own file
+)
+
+// SetLayout sets the layout used for indexes created from now on.
+// Existing indexes keep the layout they were created with.
+func (s *Storage) SetLayout(layout string) error {
+	switch layout {
+	case "", LayoutShared:
+		layout = LayoutShared
+	case LayoutPerIndex:
+	default:
+		return fmt.Errorf("unknown storage layout %q (want %q or %q)", layout, LayoutShared, LayoutPerIndex)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.layout = layout
+	return nil
+}
+
+// IndexDBPath returns the path of an index's own database file
+func (s *Storage) IndexDBPath(name string) string {
+	return filepath.Join(s.dir, "indexes", IndexDir(name), "index.db")
+}
+
+// moveIndexDirs moves the directories of indexes created before IndexDir
+// mapped names, which were named after the index, to their IndexDir name.
+// A directory is only moved if its name matches the index name exactly,
+// since on case-insensitive file systems another index's may match.
+func (s *Storage) moveIndexDirs() error {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "indexes"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dirs := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs[entry.Name()] = true
+		}
+	}
+
+	names, err := s.ListIndexes()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		dir := IndexDir(name)
+		if dir == name || !dirs[name] || dirs[dir] {
+			continue
+		}
+		from := filepath.Join(s.dir, "indexes", name)
+		if err := os.Rename(from, filepath.Join(s.dir, "indexes", dir)); err != nil {
+			return fmt.Errorf("failed to move directory of index '%s': %w", name, err)
+		}
+		dirs[dir] = true
+	}
+	return nil
+}
+
+// IndexFiles returns the names of the indexes stored in their own file
+func (s *Storage) IndexFiles() []string {
+	s.dbsMu.RLock()
+	defer s.dbsMu.RUnlock()
+
+	names := make([]string, 0, len(s.indexDBs))
+	for name := range s.indexDBs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// indexDB returns the database holding an index's buckets
+func (s *Storage) indexDB(name string) *bbolt.DB {
+	s.dbsMu.RLock()
+	defer s.dbsMu.RUnlock()
+	if db, ok := s.indexDBs[name]; ok {
+		return db
+	}
+	return s.db
+}
+
+// openIndexDBs opens the files of all registered per-index databases
+func (s *Storage) openIndexDBs() error {
+	var names []string
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("_indexes")).ForEach(func(k, v []byte) error {
+			if string(v) == registryFile {
+				names = append(names, string(k))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		db, err := openIndexFile(s.IndexDBPath(name))
+		if err != nil {
+			return fmt.Errorf("failed to open database of index '%s': %w", name, err)
+		}
+		s.indexDBs[name] = db
+	}
+	return nil
+}
+
+// openIndexFile opens a per-index database, creating the buckets shared by
+// all indexes in the file
+func openIndexFile(path string) (*bbolt.DB, error) {
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	db, err := bbolt.Open(path, 0644, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(blobsBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// createIndexFile creates an index in its own database file and registers
+// it in the main database
+func (s *Storage) createIndexFile(name string) error {
+	exists, err := s.IndexExists(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("index '%s' already exists", name)
+	}
+
+	// Discard a file left behind by an interrupted create or delete
+	path := s.IndexDBPath(name)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	db, err := openIndexFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error { return initIndexBuckets(tx, name) }); err != nil {
+		db.Close()
+		os.Remove(path)
+		return err
+	}
+
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		indexBucket := tx.Bucket([]byte("_indexes"))
+		if indexBucket.Get([]byte(name)) != nil {
+			return fmt.Errorf("index '%s' already exists", name)
+		}
+		return indexBucket.Put([]byte(name), []byte(registryFile))
+	})
+	if err != nil {
+		db.Close()
+		os.Remove(path)
+		return err
+	}
+
+	s.dbsMu.Lock()
+	s.indexDBs[name] = db
+	s.dbsMu.Unlock()
+	return nil
+}
+
+// deleteIndexFile unregisters an index stored in its own file and removes
+// the file
+func (s *Storage) deleteIndexFile(name string) error {
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("_indexes")).Delete([]byte(name))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.dbsMu.Lock()
+	db := s.indexDBs[name]
+	delete(s.indexDBs, name)
+	s.dbsMu.Unlock()
+
+	if db != nil {
+		db.Close()
+	}
+	if err := os.Remove(s.IndexDBPath(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove database file: %w", err)
+	}
+	return nil
+}
+
+// BackupIndex writes a consistent copy of an index's own database file to
+// w, like Backup. It fails for indexes stored in the main database.
+func (s *Storage) BackupIndex(name string, w io.Writer, header func(size int64) error) (DocumentChecksums, error) {
+	s.dbsMu.RLock()
+	db, ok := s.indexDBs[name]
+	s.dbsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("index '%s' has no database file", name)
+	}
+
+	sums := make(DocumentChecksums)
+	err := db.View(func(tx *bbolt.Tx) error {
+		if err := header(tx.Size()); err != nil {
+			return err
+		}
+		if _, err := tx.WriteTo(w); err != nil {
+			return err
+		}
+		return checksumIndexes(tx, []string{name}, sums)
+	})
+	return sums, err
+}
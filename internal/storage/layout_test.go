@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestStorage_PerIndexLayout(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "indexes.db")
+	store, err := NewStorage(dbPath)
+	require.NoError(t, err)
+
+	require.NoError(t, store.CreateIndex("shared"))
+	require.NoError(t, store.SetLayout(LayoutPerIndex))
+	require.NoError(t, store.CreateIndex("own"))
+	assert.Error(t, store.CreateIndex("own"))
+	assert.Error(t, store.SetLayout("sharded"))
+
+	assert.FileExists(t, store.IndexDBPath("own"))
+	assert.NoFileExists(t, store.IndexDBPath("shared"))
+	assert.Equal(t, []string{"own"}, store.IndexFiles())
+
+	names, err := store.ListIndexes()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"shared", "own"}, names)
+
+	require.NoError(t, store.StoreDocument("own", Document{URI: "doc://1", Title: "Own"}))
+	require.NoError(t, store.StoreDocument("shared", Document{URI: "doc://1", Title: "Shared"}))
+	require.NoError(t, store.WriteDocuments([]DocumentWrite{
+		{Index: "own", Document: Document{URI: "doc://2", Title: "Batch"}},
+		{Index: "shared", Document: Document{URI: "doc://2", Title: "Batch"}},
+	}))
+
+	// Buckets of the index do not exist in the main database
+	err = store.db.View(func(tx *bbolt.Tx) error {
+		assert.Nil(t, tx.Bucket([]byte("own_documents")))
+		assert.NotNil(t, tx.Bucket([]byte("shared_documents")))
+		return nil
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = store.BackupIndex("own", &buf, func(int64) error { return nil })
+	require.NoError(t, err)
+	assert.NotZero(t, buf.Len())
+	_, err = store.BackupIndex("shared", &buf, func(int64) error { return nil })
+	assert.Error(t, err)
+
+	// Per-index files are reopened with the main database
+	require.NoError(t, store.Close())
+	store, err = NewStorage(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+
+	doc, err := store.GetDocument("own", "doc://2")
+	require.NoError(t, err)
+	assert.Equal(t, "Batch", doc.Title)
+	doc, err = store.GetDocument("shared", "doc://1")
+	require.NoError(t, err)
+	assert.Equal(t, "Shared", doc.Title)
+
+	require.NoError(t, store.DeleteIndex("own"))
+	_, err = os.Stat(store.IndexDBPath("own"))
+	assert.True(t, os.IsNotExist(err))
+	exists, err := store.IndexExists("own")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Empty(t, store.IndexFiles())
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxIndexNameLength is the longest accepted index name
+const MaxIndexNameLength = 64
+
+// ErrInvalidIndexName is returned when an index name cannot be used to name
+// its buckets and files
+var ErrInvalidIndexName = errors.New("invalid index name")
+
+// ValidateIndexName checks that name can be used as an index name. Names
+// are 1 to MaxIndexNameLength ASCII letters, digits, '-', '_', '.', and '/',
+// starting with a letter or digit. Leading underscores are reserved for
+// global buckets such as _indexes and _config.
+func ValidateIndexName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidIndexName)
+	}
+	if len(name) > MaxIndexNameLength {
+		return fmt.Errorf("%w: '%s' is longer than %d characters", ErrInvalidIndexName, name, MaxIndexNameLength)
+	}
+	if !isAlphanumeric(name[0]) {
+		return fmt.Errorf("%w: '%s' must start with a letter or digit", ErrInvalidIndexName, name)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isAlphanumeric(c) && c != '-' && c != '_' && c != '.' && c != '/' {
+			return fmt.Errorf("%w: '%s' contains %q", ErrInvalidIndexName, name, c)
+		}
+	}
+	return nil
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// IndexDir returns the name of an index's directory under indexes/ in the
+// data directory. Lowercase names that every file system accepts as they
+// are keep their name, so existing layouts do not change. Other names, with
+// capitals, slashes, a trailing dot, or a name Windows reserves such as
+// "con", get a sanitized lowercase name followed by '+' and a hash of the
+// exact name. Index names cannot contain '+', so two indexes never share a
+// directory, even on case-insensitive file systems.
+func IndexDir(name string) string {
+	if plainDirName(name) {
+		return name
+	}
+	sanitized := []byte(strings.ToLower(name))
+	for i, c := range sanitized {
+		if !isAlphanumeric(c) && c != '-' && c != '_' {
+			sanitized[i] = '_'
+		}
+	}
+	sum := sha256.Sum256([]byte(name))
+	return string(sanitized) + "+" + hex.EncodeToString(sum[:4])
+}
+
+// windowsReserved are the device names Windows reserves, with or without
+// an extension
+var windowsReserved = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// plainDirName reports whether a name can be its own directory name
+func plainDirName(name string) bool {
+	if name == "" || strings.HasSuffix(name, ".") {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	base, _, _ := strings.Cut(name, ".")
+	return !windowsReserved[base]
+}
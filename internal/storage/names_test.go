@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIndexName(t *testing.T) {
+	for _, name := range []string{"docs", "team-kb", "v2.1", "a_b", "0", "Team/Docs", strings.Repeat("x", MaxIndexNameLength)} {
+		assert.NoError(t, ValidateIndexName(name), name)
+	}
+	for _, name := range []string{"", "_config", "_indexes", "-x", ".hidden", "..", "/a", "a+b", "a b", "naïve", strings.Repeat("x", MaxIndexNameLength+1)} {
+		assert.ErrorIs(t, ValidateIndexName(name), ErrInvalidIndexName, name)
+	}
+}
+
+func TestStorage_CreateIndexBucketCollision(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("a"))
+	assert.ErrorIs(t, store.CreateIndex("a_doc"), ErrInvalidIndexName, "a_doc_chunks belongs to a")
+	assert.ErrorIs(t, store.CreateIndex("../a"), ErrInvalidIndexName)
+
+	exists, err := store.IndexExists("a_doc")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Separate files cannot collide
+	require.NoError(t, store.SetLayout(LayoutPerIndex))
+	require.NoError(t, store.CreateIndex("b"))
+	require.NoError(t, store.CreateIndex("b_doc"))
+}
+
+func TestIndexDir(t *testing.T) {
+	for _, name := range []string{"docs", "team-kb", "v2.1", "a_b", "console"} {
+		assert.Equal(t, name, IndexDir(name))
+	}
+
+	dirs := make(map[string]string)
+	for _, name := range []string{"Docs", "DOCS", "Team/Docs", "team/docs", "docs.", "con", "CON", "com1.backup", "nul.x"} {
+		dir := IndexDir(name)
+		assert.Equal(t, dir, IndexDir(name), "stable")
+		assert.Regexp(t, `^[a-z0-9_-]+\+[0-9a-f]{8}$`, dir, name)
+		lower := strings.ToLower(dir)
+		assert.NotContains(t, dirs, lower, "%s collides with %s", name, dirs[lower])
+		dirs[lower] = name
+	}
+	assert.True(t, strings.HasPrefix(IndexDir("Team/Docs"), "team_docs+"))
+}
+
+func TestStorage_MoveIndexDirs(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	store, err := NewStorage(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, store.SetLayout(LayoutPerIndex))
+	require.NoError(t, store.CreateIndex("Docs"))
+	require.NoError(t, store.StoreDocument("Docs", Document{URI: "a", Title: "A"}))
+	require.NoError(t, store.Close())
+
+	// Lay the index out as before directories were mapped
+	require.NoError(t, os.Rename(filepath.Join(dir, "indexes", IndexDir("Docs")), filepath.Join(dir, "indexes", "Docs")))
+
+	store, err = NewStorage(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+	assert.FileExists(t, filepath.Join(dir, "indexes", IndexDir("Docs"), "index.db"))
+	doc, err := store.GetDocument("Docs", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "A", doc.Title)
+}
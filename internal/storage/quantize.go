@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/riclib/hnswindex/internal/quantize"
+)
+
+// SetQuantization selects how newly written chunks store their embedding:
+// quantize.None as float32 values, or quantize.Int8 as one byte per
+// component. Existing chunks are read regardless of how they were written.
+func (s *Storage) SetQuantization(codec string) error {
+	codec, err := quantize.Check(codec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quantization = codec
+	return nil
+}
+
+// encodeChunk encodes a chunk, quantizing its embedding with the
+// configured codec
+func (s *Storage) encodeChunk(chunk Chunk) ([]byte, error) {
+	s.mu.RLock()
+	codec := s.quantization
+	s.mu.RUnlock()
+
+	if codec == quantize.Int8 && len(chunk.Embedding) > 0 {
+		chunk.Quantized = quantize.Encode(chunk.Embedding)
+		chunk.Embedding = nil
+	}
+	return s.encodeValue(chunk)
+}
+
+// decodeChunk decodes a chunk encoded by encodeChunk, restoring a
+// quantized embedding
+func decodeChunk(data []byte, c *Chunk) error {
+	if err := decodeValue(data, c); err != nil {
+		return err
+	}
+	if len(c.Quantized) > 0 {
+		embedding, err := quantize.Decode(c.Quantized)
+		if err != nil {
+			return fmt.Errorf("failed to decode embedding: %w", err)
+		}
+		c.Embedding, c.Quantized = embedding, nil
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestStorage_Quantization(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.CreateIndex("docs"))
+
+	embedding := make([]float32, 256)
+	for n := range embedding {
+		embedding[n] = float32(n%17)/17 - 0.4
+	}
+
+	// Written before quantization was enabled
+	require.NoError(t, store.StoreChunk("docs", Chunk{ID: "plain", DocumentURI: "doc://a", HNSWId: 1, Embedding: embedding}))
+
+	require.NoError(t, store.SetQuantization("int8"))
+	require.NoError(t, store.StoreChunk("docs", Chunk{ID: "packed", DocumentURI: "doc://a", HNSWId: 2, Embedding: embedding}))
+
+	// Quantized embeddings are smaller on disk
+	var plainSize, packedSize int
+	require.NoError(t, store.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte("docs_chunks"))
+		plainSize = len(bucket.Get([]byte("plain")))
+		packedSize = len(bucket.Get([]byte("packed")))
+		return nil
+	}))
+	assert.Less(t, packedSize, plainSize/4)
+
+	// Both forms read back with an embedding, the quantized one approximately
+	chunk, err := store.GetChunk("docs", "plain")
+	require.NoError(t, err)
+	assert.Equal(t, embedding, chunk.Embedding)
+
+	chunk, err = store.GetChunk("docs", "packed")
+	require.NoError(t, err)
+	assert.Nil(t, chunk.Quantized)
+	assert.InDeltaSlice(t, embedding, chunk.Embedding, 0.6/127)
+
+	chunks, err := store.GetChunksByHNSWIds("docs", []uint64{2})
+	require.NoError(t, err)
+	assert.Len(t, chunks[2].Embedding, len(embedding))
+
+	require.NoError(t, store.ForEachChunk("docs", func(c Chunk) error {
+		assert.Len(t, c.Embedding, len(embedding), c.ID)
+		return nil
+	}))
+
+	assert.Error(t, store.SetQuantization("pq"))
+}
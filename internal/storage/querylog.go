@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// QueryLogEntry records a single search request
+type QueryLogEntry struct {
+	ID          uint64    `json:"id"`
+	Query       string    `json:"query"`
+	Timestamp   time.Time `json:"timestamp"`
+	LatencyMs   float64   `json:"latency_ms"`
+	ResultCount int       `json:"result_count"`
+	TopScore    float64   `json:"top_score"`
+	ResultURIs  []string  `json:"result_uris,omitempty"`
+	Clicks      []string  `json:"clicks,omitempty"`
+}
+
+// AppendQueryLog stores a query log entry and returns its assigned ID
+func (s *Storage) AppendQueryLog(indexName string, entry QueryLogEntry) (uint64, error) {
+	var id uint64
+	err := s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName))) == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		// Indexes created before query logging existed lack the bucket
+		logBucket, err := tx.CreateBucketIfNotExists([]byte(fmt.Sprintf("%s_querylog", indexName)))
+		if err != nil {
+			return err
+		}
+
+		id, err = logBucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		entry.ID = id
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return logBucket.Put(sequenceKey(id), data)
+	})
+	return id, err
+}
+
+// AddQueryClick records that a result of a logged query was used
+func (s *Storage) AddQueryClick(indexName string, queryID uint64, uri string) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
+		logBucket := tx.Bucket([]byte(fmt.Sprintf("%s_querylog", indexName)))
+		if logBucket == nil {
+			return fmt.Errorf("query %d not found", queryID)
+		}
+
+		data := logBucket.Get(sequenceKey(queryID))
+		if data == nil {
+			return fmt.Errorf("query %d not found", queryID)
+		}
+
+		var entry QueryLogEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+		for _, clicked := range entry.Clicks {
+			if clicked == uri {
+				return nil
+			}
+		}
+		entry.Clicks = append(entry.Clicks, uri)
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return logBucket.Put(sequenceKey(queryID), data)
+	})
+}
+
+// ForEachQueryLog calls fn for every logged query in insertion order
+func (s *Storage) ForEachQueryLog(indexName string, fn func(QueryLogEntry) error) error {
+	return s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		logBucket := tx.Bucket([]byte(fmt.Sprintf("%s_querylog", indexName)))
+		if logBucket == nil {
+			return nil
+		}
+
+		return logBucket.ForEach(func(k, v []byte) error {
+			var entry QueryLogEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("failed to decode query log entry: %w", err)
+			}
+			return fn(entry)
+		})
+	})
+}
+
+// sequenceKey encodes a sequence number as a sortable bucket key
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
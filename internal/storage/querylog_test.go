@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_QueryLog(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("test-index"))
+
+	id1, err := store.AppendQueryLog("test-index", QueryLogEntry{
+		Query:       "first",
+		Timestamp:   time.Now(),
+		ResultCount: 2,
+		ResultURIs:  []string{"doc1", "doc2"},
+	})
+	require.NoError(t, err)
+	id2, err := store.AppendQueryLog("test-index", QueryLogEntry{Query: "second"})
+	require.NoError(t, err)
+	assert.Greater(t, id2, id1)
+
+	require.NoError(t, store.AddQueryClick("test-index", id1, "doc2"))
+	require.NoError(t, store.AddQueryClick("test-index", id1, "doc2"))
+	assert.Error(t, store.AddQueryClick("test-index", 999, "doc1"))
+
+	var entries []QueryLogEntry
+	err = store.ForEachQueryLog("test-index", func(e QueryLogEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "first", entries[0].Query)
+	assert.Equal(t, []string{"doc2"}, entries[0].Clicks)
+	assert.Equal(t, "second", entries[1].Query)
+
+	_, err = store.AppendQueryLog("missing", QueryLogEntry{Query: "x"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// replacedSuffixes are the buckets ReplaceIndex takes from the staging
+// index. The query log and change log stay with the index.
+var replacedSuffixes = []string{"documents", "chunks", "doc_chunks", "hashes", "metadata", "sparse", "hnsw_map"}
+
+// CreateStagingIndex creates staging as an empty index to rebuild name
+// into. It uses name's layout and settings, and allocates HNSW IDs after
+// name's so chunks of the two never share an ID.
+func (s *Storage) CreateStagingIndex(name, staging string) error {
+	exists, err := s.IndexExists(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("index '%s' not found", name)
+	}
+
+	s.dbsMu.RLock()
+	_, ownFile := s.indexDBs[name]
+	s.dbsMu.RUnlock()
+	layout := LayoutShared
+	if ownFile {
+		layout = LayoutPerIndex
+	}
+	if err := s.createIndex(staging, layout); err != nil {
+		return err
+	}
+
+	// Both may live in the main database, so read before writing
+	settings := make(map[string][]byte)
+	err = s.indexDB(name).View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(fmt.Sprintf("%s_metadata", name))).ForEach(func(k, v []byte) error {
+			settings[string(k)] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	if err == nil {
+		err = s.indexDB(staging).Update(func(tx *bbolt.Tx) error {
+			target := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", staging)))
+			for key, value := range settings {
+				if key == "metadata" {
+					var metadata IndexMetadata
+					if err := json.Unmarshal(value, &metadata); err != nil {
+						return err
+					}
+					data, err := json.Marshal(IndexMetadata{
+						NextHNSWId: metadata.NextHNSWId,
+						CreatedAt:  metadata.CreatedAt,
+						LastSyncAt: metadata.LastSyncAt,
+					})
+					if err != nil {
+						return err
+					}
+					value = data
+				}
+				if err := target.Put([]byte(key), value); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		s.DeleteIndex(staging)
+		return fmt.Errorf("failed to initialize staging index: %w", err)
+	}
+	return nil
+}
+
+// ReplaceIndex replaces the documents, chunks, and metadata of index name
+// with those of staging in one transaction, then deletes staging. Readers
+// see either the old or the new contents. The differences are appended to
+// name's change log and returned.
+func (s *Storage) ReplaceIndex(name, staging string) ([]ChangeEntry, error) {
+	s.dbsMu.RLock()
+	liveDB, liveFile := s.indexDBs[name]
+	stagingDB, stagingFile := s.indexDBs[staging]
+	s.dbsMu.RUnlock()
+	if liveFile != stagingFile {
+		return nil, fmt.Errorf("indexes '%s' and '%s' use different storage layouts", name, staging)
+	}
+
+	var changes []ChangeEntry
+	if !liveFile {
+		err := s.db.Update(func(tx *bbolt.Tx) error {
+			indexBucket := tx.Bucket([]byte("_indexes"))
+			if indexBucket.Get([]byte(name)) == nil {
+				return fmt.Errorf("index '%s' not found", name)
+			}
+			if indexBucket.Get([]byte(staging)) == nil {
+				return fmt.Errorf("index '%s' not found", staging)
+			}
+
+			// Old documents give up their blobs; staging's references
+			// move with the documents
+			if docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", name))); docBucket != nil {
+				err := docBucket.ForEach(func(k, v []byte) error {
+					return releaseStoredDocument(tx, docBucket, string(k))
+				})
+				if err != nil {
+					return fmt.Errorf("failed to release content blobs: %w", err)
+				}
+			}
+
+			var err error
+			if changes, err = replaceBuckets(tx, tx, name, staging); err != nil {
+				return err
+			}
+			for _, bucketName := range indexBucketNames(staging) {
+				if err := tx.DeleteBucket([]byte(bucketName)); err != nil && err != bbolt.ErrBucketNotFound {
+					return err
+				}
+			}
+			return indexBucket.Delete([]byte(staging))
+		})
+		return changes, err
+	}
+
+	// A file's blobs belong to its index alone, so they are replaced too
+	err := liveDB.Update(func(tx *bbolt.Tx) error {
+		return stagingDB.View(func(stx *bbolt.Tx) error {
+			var err error
+			if changes, err = replaceBuckets(tx, stx, name, staging); err != nil {
+				return err
+			}
+			return copyBucket(tx, stx, blobsBucket, blobsBucket)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, s.deleteIndexFile(staging)
+}
+
+// replaceBuckets copies staging's buckets from src over name's in dst and
+// logs the documents that were added, changed, or removed
+func replaceBuckets(dst, src *bbolt.Tx, name, staging string) ([]ChangeEntry, error) {
+	oldHashes := make(map[string]string)
+	if bucket := dst.Bucket([]byte(fmt.Sprintf("%s_hashes", name))); bucket != nil {
+		bucket.ForEach(func(k, v []byte) error {
+			oldHashes[string(k)] = string(v)
+			return nil
+		})
+	}
+
+	var changes []ChangeEntry
+	if bucket := src.Bucket([]byte(fmt.Sprintf("%s_hashes", staging))); bucket != nil {
+		err := bucket.ForEach(func(k, v []byte) error {
+			uri, hash := string(k), string(v)
+			old, existed := oldHashes[uri]
+			delete(oldHashes, uri)
+			if existed && old == hash {
+				return nil
+			}
+			changes = append(changes, ChangeEntry{Op: ChangeUpsert, URI: uri, Hash: hash})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for uri := range oldHashes {
+		changes = append(changes, ChangeEntry{Op: ChangeDelete, URI: uri})
+	}
+	for _, c := range changes {
+		if err := appendChange(dst, name, c.Op, c.URI, c.Hash); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, suffix := range replacedSuffixes {
+		target := fmt.Sprintf("%s_%s", name, suffix)
+		source := fmt.Sprintf("%s_%s", staging, suffix)
+		if err := copyBucket(dst, src, target, source); err != nil {
+			return nil, fmt.Errorf("failed to replace bucket %s: %w", target, err)
+		}
+	}
+	return changes, nil
+}
+
+// copyBucket replaces bucket target in dst with a copy of bucket source
+// in src
+func copyBucket(dst, src *bbolt.Tx, target, source string) error {
+	if err := dst.DeleteBucket([]byte(target)); err != nil && err != bbolt.ErrBucketNotFound {
+		return err
+	}
+	to, err := dst.CreateBucket([]byte(target))
+	if err != nil {
+		return err
+	}
+	from := src.Bucket([]byte(source))
+	if from == nil {
+		return nil
+	}
+	return copyBucketContents(to, from)
+}
+
+// copyBucketContents copies the keys and nested buckets of from into to
+func copyBucketContents(to, from *bbolt.Bucket) error {
+	return from.ForEach(func(k, v []byte) error {
+		if v == nil {
+			sub, err := to.CreateBucket(append([]byte(nil), k...))
+			if err != nil {
+				return err
+			}
+			return copyBucketContents(sub, from.Bucket(k))
+		}
+		return to.Put(append([]byte(nil), k...), append([]byte(nil), v...))
+	})
+}
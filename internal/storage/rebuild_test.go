@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_ReplaceIndex(t *testing.T) {
+	for _, layout := range []string{LayoutShared, LayoutPerIndex} {
+		t.Run(layout, func(t *testing.T) {
+			store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+			require.NoError(t, err)
+			defer store.Close()
+			store.SetBlobThreshold(100)
+			require.NoError(t, store.SetLayout(layout))
+
+			oldBody := strings.Repeat("old body ", 20)
+			newBody := strings.Repeat("new body ", 20)
+			require.NoError(t, store.CreateIndex("kb"))
+			require.NoError(t, store.StoreDocument("kb", Document{URI: "doc://1", Content: oldBody, Hash: "h1"}))
+			require.NoError(t, store.StoreDocument("kb", Document{URI: "doc://gone", Content: "short", Hash: "h2"}))
+			require.NoError(t, store.SetIndexSetting("kb", "schema", []byte(`{}`)))
+			next, err := store.GetNextHNSWId("kb")
+			require.NoError(t, err)
+
+			require.NoError(t, store.CreateStagingIndex("kb", "_rebuild_kb"))
+			setting, err := store.GetIndexSetting("_rebuild_kb", "schema")
+			require.NoError(t, err)
+			assert.Equal(t, `{}`, string(setting))
+			stagingNext, err := store.GetNextHNSWId("_rebuild_kb")
+			require.NoError(t, err)
+			assert.Greater(t, stagingNext, next, "IDs continue after the index's")
+
+			require.NoError(t, store.StoreDocument("_rebuild_kb", Document{URI: "doc://1", Content: newBody, Hash: "h3"}))
+
+			changes, err := store.ReplaceIndex("kb", "_rebuild_kb")
+			require.NoError(t, err)
+			require.Len(t, changes, 2)
+
+			doc, err := store.GetDocument("kb", "doc://1")
+			require.NoError(t, err)
+			assert.Equal(t, newBody, doc.Content)
+			_, err = store.GetDocument("kb", "doc://gone")
+			assert.Error(t, err)
+
+			count, _, err := store.BlobStats()
+			require.NoError(t, err)
+			assert.Equal(t, 1, count, "the old body is released")
+
+			exists, err := store.IndexExists("_rebuild_kb")
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			logged, err := store.ListChanges("kb", 0, 0)
+			require.NoError(t, err)
+			last := logged[len(logged)-2:]
+			assert.ElementsMatch(t, []string{"upsert doc://1", "delete doc://gone"},
+				[]string{last[0].Op + " " + last[0].URI, last[1].Op + " " + last[1].URI})
+		})
+	}
+}
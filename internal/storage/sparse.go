@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// Sparse vectors of chunks are kept in an inverted index in the
+// <index>_sparse bucket: a posting per term and chunk, "t" term 0 chunk ID
+// holding the weight, and the terms of each chunk, "c" chunk ID holding the
+// terms separated by 0, so a chunk's postings can be removed.
+const (
+	sparsePostingPrefix = 't'
+	sparseChunkPrefix   = 'c'
+)
+
+// SparseHit is a chunk matched by a sparse query
+type SparseHit struct {
+	ChunkID string
+	HNSWId  uint64
+	Score   float64 // Dot product of the query and chunk sparse vectors
+}
+
+func sparseBucketName(indexName string) []byte {
+	return []byte(fmt.Sprintf("%s_sparse", indexName))
+}
+
+func sparsePostingKey(term, chunkID string) []byte {
+	return []byte(string(sparsePostingPrefix) + term + "\x00" + chunkID)
+}
+
+func sparseChunkKey(chunkID string) []byte {
+	return []byte(string(sparseChunkPrefix) + chunkID)
+}
+
+// validSparseTerm reports whether a term can be stored; the separator
+// cannot appear in it
+func validSparseTerm(term string) bool {
+	return term != "" && !strings.ContainsRune(term, 0)
+}
+
+// putSparse replaces the postings of a chunk inside a transaction
+func putSparse(tx *bbolt.Tx, indexName, chunkID string, vector map[string]float32) error {
+	if err := deleteSparse(tx, indexName, chunkID); err != nil {
+		return err
+	}
+	if len(vector) == 0 {
+		return nil
+	}
+
+	// Indexes created before sparse embeddings existed lack the bucket
+	bucket, err := tx.CreateBucketIfNotExists(sparseBucketName(indexName))
+	if err != nil {
+		return err
+	}
+	var terms []string
+	for term, weight := range vector {
+		if !validSparseTerm(term) || weight == 0 {
+			continue
+		}
+		value := make([]byte, 4)
+		binary.LittleEndian.PutUint32(value, math.Float32bits(weight))
+		if err := bucket.Put(sparsePostingKey(term, chunkID), value); err != nil {
+			return err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return bucket.Put(sparseChunkKey(chunkID), []byte(strings.Join(terms, "\x00")))
+}
+
+// deleteSparse removes the postings of a chunk inside a transaction
+func deleteSparse(tx *bbolt.Tx, indexName, chunkID string) error {
+	bucket := tx.Bucket(sparseBucketName(indexName))
+	if bucket == nil {
+		return nil
+	}
+	terms := bucket.Get(sparseChunkKey(chunkID))
+	if terms == nil {
+		return nil
+	}
+	for _, term := range strings.Split(string(terms), "\x00") {
+		if err := bucket.Delete(sparsePostingKey(term, chunkID)); err != nil {
+			return err
+		}
+	}
+	return bucket.Delete(sparseChunkKey(chunkID))
+}
+
+// SearchSparse scores the chunks sharing terms with a sparse query by the
+// dot product of their sparse vectors and returns the best limit, highest
+// score first
+func (s *Storage) SearchSparse(indexName string, query map[string]float32, limit int) ([]SparseHit, error) {
+	var hits []SparseHit
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
+		if chunkBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+		bucket := tx.Bucket(sparseBucketName(indexName))
+		if bucket == nil {
+			return nil
+		}
+
+		scores := make(map[string]float64)
+		cursor := bucket.Cursor()
+		for term, weight := range query {
+			if !validSparseTerm(term) || weight == 0 {
+				continue
+			}
+			prefix := sparsePostingKey(term, "")
+			for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+				chunkID := string(k[len(prefix):])
+				scores[chunkID] += float64(weight) * float64(math.Float32frombits(binary.LittleEndian.Uint32(v)))
+			}
+		}
+
+		for chunkID, score := range scores {
+			hits = append(hits, SparseHit{ChunkID: chunkID, Score: score})
+		}
+		sort.Slice(hits, func(a, b int) bool {
+			if hits[a].Score != hits[b].Score {
+				return hits[a].Score > hits[b].Score
+			}
+			return hits[a].ChunkID < hits[b].ChunkID
+		})
+		if len(hits) > limit {
+			hits = hits[:limit]
+		}
+
+		// Only the chunks returned are decoded for their HNSW IDs
+		found := hits[:0]
+		for _, hit := range hits {
+			data := chunkBucket.Get([]byte(hit.ChunkID))
+			if data == nil {
+				continue
+			}
+			var chunk Chunk
+			if err := decodeValue(data, &chunk); err != nil {
+				return fmt.Errorf("failed to decode chunk '%s': %w", hit.ChunkID, err)
+			}
+			hit.HNSWId = chunk.HNSWId
+			found = append(found, hit)
+		}
+		hits = found
+		return nil
+	})
+	return hits, err
+}
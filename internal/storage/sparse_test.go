@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_SearchSparse(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "indexes.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.CreateIndex("kb"))
+
+	write := func(uri string, chunks ...Chunk) {
+		t.Helper()
+		for i := range chunks {
+			chunks[i].DocumentURI = uri
+		}
+		require.NoError(t, store.WriteDocuments([]DocumentWrite{{
+			Index:    "kb",
+			Document: Document{URI: uri},
+			Chunks:   chunks,
+		}}))
+	}
+	write("doc://a",
+		Chunk{ID: "a0", Sparse: map[string]float32{"password": 2, "reset": 1}},
+		Chunk{ID: "a1", Sparse: map[string]float32{"invoice": 1}},
+	)
+	write("doc://b", Chunk{ID: "b0", Sparse: map[string]float32{"password": 1, "bad\x00term": 5}})
+
+	hits, err := store.SearchSparse("kb", map[string]float32{"password": 1, "reset": 2}, 10)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "a0", hits[0].ChunkID)
+	assert.InDelta(t, 4.0, hits[0].Score, 1e-6)
+	assert.NotZero(t, hits[0].HNSWId)
+	assert.Equal(t, "b0", hits[1].ChunkID)
+
+	hits, err = store.SearchSparse("kb", map[string]float32{"password": 1}, 1)
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	// Replacing and deleting chunks removes their postings
+	write("doc://a", Chunk{ID: "a0", Sparse: map[string]float32{"invoice": 1}})
+	require.NoError(t, store.DeleteChunksByDocument("kb", "doc://b"))
+	hits, err = store.SearchSparse("kb", map[string]float32{"password": 1, "invoice": 1}, 10)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "a0", hits[0].ChunkID)
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// MaxStatsSnapshots is the number of stats snapshots kept per index; older
+// snapshots are dropped as new ones are recorded
+const MaxStatsSnapshots = 1000
+
+// StatsSnapshot records the size of an index after a change and what the
+// change did
+type StatsSnapshot struct {
+	Time             time.Time `json:"time"`
+	Documents        int       `json:"documents"` // Filled in by RecordStats
+	Chunks           int       `json:"chunks"`    // Filled in by RecordStats
+	NewDocuments     int       `json:"new_documents,omitempty"`
+	UpdatedDocuments int       `json:"updated_documents,omitempty"`
+	DeletedDocuments int       `json:"deleted_documents,omitempty"`
+	ProcessedChunks  int       `json:"processed_chunks,omitempty"`
+	BatchMs          float64   `json:"batch_ms,omitempty"` // Zero for changes outside a batch
+}
+
+func statsBucketName(indexName string) []byte {
+	return []byte(fmt.Sprintf("%s_stats", indexName))
+}
+
+// RecordStats stores a snapshot with the current document and chunk counts
+// of the index. If mergeWithin is positive and the latest snapshot is not
+// a batch and was recorded less than mergeWithin before snap, snap's
+// changes are added to it instead, so deleting documents one at a time does
+// not flood the history.
+func (s *Storage) RecordStats(indexName string, snap StatsSnapshot, mergeWithin time.Duration) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
+		docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", indexName)))
+		chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
+		if docBucket == nil || chunkBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+		snap.Documents = docBucket.Stats().KeyN
+		snap.Chunks = chunkBucket.Stats().KeyN
+
+		// Indexes created before stats history existed lack the bucket
+		statsBucket, err := tx.CreateBucketIfNotExists(statsBucketName(indexName))
+		if err != nil {
+			return err
+		}
+
+		if mergeWithin > 0 && snap.BatchMs == 0 {
+			if k, v := statsBucket.Cursor().Last(); k != nil {
+				var last StatsSnapshot
+				if err := json.Unmarshal(v, &last); err != nil {
+					return fmt.Errorf("failed to decode stats snapshot: %w", err)
+				}
+				if last.BatchMs == 0 && snap.Time.Sub(last.Time) < mergeWithin {
+					last.Documents = snap.Documents
+					last.Chunks = snap.Chunks
+					last.NewDocuments += snap.NewDocuments
+					last.UpdatedDocuments += snap.UpdatedDocuments
+					last.DeletedDocuments += snap.DeletedDocuments
+					last.ProcessedChunks += snap.ProcessedChunks
+					data, err := json.Marshal(last)
+					if err != nil {
+						return err
+					}
+					return statsBucket.Put(k, data)
+				}
+			}
+		}
+
+		seq, err := statsBucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(snap)
+		if err != nil {
+			return err
+		}
+		if err := statsBucket.Put(sequenceKey(seq), data); err != nil {
+			return err
+		}
+
+		// Drop the snapshots beyond the limit
+		if seq > MaxStatsSnapshots {
+			oldest := sequenceKey(seq - MaxStatsSnapshots)
+			c := statsBucket.Cursor()
+			for k, _ := c.First(); k != nil && string(k) <= string(oldest); k, _ = c.First() {
+				if err := statsBucket.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// StatsHistory returns the recorded stats snapshots of an index, oldest
+// first
+func (s *Storage) StatsHistory(indexName string) ([]StatsSnapshot, error) {
+	var snapshots []StatsSnapshot
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName))) == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+		statsBucket := tx.Bucket(statsBucketName(indexName))
+		if statsBucket == nil {
+			return nil
+		}
+		return statsBucket.ForEach(func(k, v []byte) error {
+			var snap StatsSnapshot
+			if err := json.Unmarshal(v, &snap); err != nil {
+				return fmt.Errorf("failed to decode stats snapshot: %w", err)
+			}
+			snapshots = append(snapshots, snap)
+			return nil
+		})
+	})
+	return snapshots, err
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_StatsHistory(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("test-index"))
+
+	history, err := store.StatsHistory("test-index")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	require.NoError(t, store.StoreDocument("test-index", Document{URI: "doc://1", Hash: "h1"}))
+	require.NoError(t, store.StoreDocument("test-index", Document{URI: "doc://2", Hash: "h2"}))
+	require.NoError(t, store.StoreChunk("test-index", Chunk{ID: "c1", DocumentURI: "doc://1"}))
+
+	start := time.Now()
+	require.NoError(t, store.RecordStats("test-index", StatsSnapshot{
+		Time:         start,
+		NewDocuments: 2,
+		BatchMs:      12.5,
+	}, time.Minute))
+
+	// Deletions close together are merged, but never into a batch
+	require.NoError(t, store.RecordStats("test-index", StatsSnapshot{Time: start.Add(time.Second), DeletedDocuments: 1}, time.Minute))
+	require.NoError(t, store.RecordStats("test-index", StatsSnapshot{Time: start.Add(2 * time.Second), DeletedDocuments: 1}, time.Minute))
+	require.NoError(t, store.RecordStats("test-index", StatsSnapshot{Time: start.Add(2 * time.Minute), DeletedDocuments: 1}, time.Minute))
+
+	history, err = store.StatsHistory("test-index")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, 2, history[0].Documents)
+	assert.Equal(t, 1, history[0].Chunks)
+	assert.Equal(t, 2, history[0].NewDocuments)
+	assert.Equal(t, 12.5, history[0].BatchMs)
+	assert.Equal(t, 2, history[1].DeletedDocuments)
+	assert.Equal(t, 1, history[2].DeletedDocuments)
+
+	_, err = store.StatsHistory("missing")
+	assert.Error(t, err)
+}
+
+func TestStorage_StatsHistoryLimit(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("test-index"))
+
+	start := time.Now()
+	for n := 0; n < MaxStatsSnapshots+5; n++ {
+		require.NoError(t, store.RecordStats("test-index", StatsSnapshot{
+			Time:            start.Add(time.Duration(n) * time.Second),
+			ProcessedChunks: n,
+		}, 0))
+	}
+
+	history, err := store.StatsHistory("test-index")
+	require.NoError(t, err)
+	require.Len(t, history, MaxStatsSnapshots)
+	assert.Equal(t, 5, history[0].ProcessedChunks)
+	assert.Equal(t, MaxStatsSnapshots+4, history[len(history)-1].ProcessedChunks)
+}
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"go.etcd.io/bbolt"
 )
@@ -19,6 +21,11 @@ type Document struct {
 	Content  string                 `json:"content"`
 	Hash     string                 `json:"hash"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// ContentRef is the blob hash of content stored in the blob bucket.
+	// It is only set in the stored form; documents returned by Storage
+	// always carry their Content.
+	ContentRef string `json:"content_ref,omitempty"`
 }
 
 // Chunk represents a stored chunk with embedding
@@ -28,22 +35,62 @@ type Chunk struct {
 	DocumentURI string                 `json:"document_uri"`
 	Text        string                 `json:"text"`
 	Embedding   []float32              `json:"embedding"`
+	Vectors     [][]float32            `json:"vectors,omitempty"` // Sub-chunk embeddings for late interaction
+	Sparse      map[string]float32     `json:"sparse,omitempty"`  // Term weights, also kept in the sparse index
 	Position    int                    `json:"position"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+
+	// Quantized is the embedding quantized by quantize.Encode. It is only
+	// set in the stored form; chunks returned by Storage always carry
+	// their Embedding.
+	Quantized []byte `json:"quantized,omitempty"`
 }
 
-// IndexMetadata stores metadata about an index
+// IndexMetadata stores metadata about an index. Unset times are omitted.
 type IndexMetadata struct {
-	NextHNSWId    uint64 `json:"next_hnsw_id"`
-	DocumentCount int    `json:"document_count"`
-	ChunkCount    int    `json:"chunk_count"`
-	LastUpdated   string `json:"last_updated"`
+	NextHNSWId    uint64    `json:"next_hnsw_id"`
+	DocumentCount int       `json:"document_count"`
+	ChunkCount    int       `json:"chunk_count"`
+	LastUpdated   time.Time `json:"last_updated,omitzero"`
+	CreatedAt     time.Time `json:"created_at,omitzero"`    // Zero for indexes created by older versions
+	LastSavedAt   time.Time `json:"last_saved_at,omitzero"` // HNSW graph last written to disk
+	LastSyncAt    time.Time `json:"last_sync_at,omitzero"`  // A connector last saved a sync cursor
+}
+
+// UnmarshalJSON reads metadata written before times were time.Time, which
+// stored an unset LastUpdated as an empty string
+func (m *IndexMetadata) UnmarshalJSON(data []byte) error {
+	type plain IndexMetadata
+	var raw struct {
+		plain
+		LastUpdated string `json:"last_updated"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = IndexMetadata(raw.plain)
+	if raw.LastUpdated != "" {
+		t, err := time.Parse(time.RFC3339, raw.LastUpdated)
+		if err != nil {
+			return fmt.Errorf("invalid last_updated: %w", err)
+		}
+		m.LastUpdated = t
+	}
+	return nil
 }
 
 // Storage manages bbolt database operations
 type Storage struct {
-	db *bbolt.DB
-	mu sync.RWMutex
+	db            *bbolt.DB
+	dir           string // Directory of the main database
+	mu            sync.RWMutex
+	blobThreshold int    // Content size from which bodies go to the blob bucket
+	compression   string // Codec for document, chunk, and blob values
+	quantization  string // Codec for chunk embeddings
+	layout        string // Layout of newly created indexes
+
+	dbsMu    sync.RWMutex
+	indexDBs map[string]*bbolt.DB // Indexes stored in their own file
 }
 
 // NewStorage creates a new storage instance
@@ -66,6 +113,14 @@ func NewStorage(dbPath string) (*Storage, error) {
 			return err
 		}
 		_, err = tx.CreateBucketIfNotExists([]byte("_config"))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(embeddingsBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(blobsBucket))
 		return err
 	})
 	if err != nil {
@@ -73,19 +128,113 @@ func NewStorage(dbPath string) (*Storage, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
-	return &Storage{db: db}, nil
+	s := &Storage{
+		db:       db,
+		dir:      dir,
+		layout:   LayoutShared,
+		indexDBs: make(map[string]*bbolt.DB),
+	}
+	if err := s.moveIndexDirs(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err := s.openIndexDBs(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err := s.buildHNSWMaps(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
 }
 
-// Close closes the database
+// Close closes the database and the files of per-index databases
 func (s *Storage) Close() error {
+	s.dbsMu.Lock()
+	for name, db := range s.indexDBs {
+		db.Close()
+		delete(s.indexDBs, name)
+	}
+	s.dbsMu.Unlock()
+
 	if s.db != nil {
 		return s.db.Close()
 	}
 	return nil
 }
 
-// CreateIndex creates a new index with its buckets
+// Sync flushes the main database and the per-index databases to disk.
+// Commits are synced already unless the file system or bbolt options
+// defer it; Sync makes sure before a planned shutdown.
+func (s *Storage) Sync() error {
+	if err := s.db.Sync(); err != nil {
+		return fmt.Errorf("failed to sync database: %w", err)
+	}
+	s.dbsMu.RLock()
+	defer s.dbsMu.RUnlock()
+	for name, db := range s.indexDBs {
+		if err := db.Sync(); err != nil {
+			return fmt.Errorf("failed to sync database of index '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Backup writes a consistent copy of the main database to w from a read
+// transaction, so writers are not blocked. header is called first with the
+// size of the copy. Indexes in their own file are backed up with
+// BackupIndex. It returns the checksums of the documents in the copy.
+func (s *Storage) Backup(w io.Writer, header func(size int64) error) (DocumentChecksums, error) {
+	sums := make(DocumentChecksums)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		if err := header(tx.Size()); err != nil {
+			return err
+		}
+		if _, err := tx.WriteTo(w); err != nil {
+			return err
+		}
+		return checksumIndexes(tx, sharedIndexes(tx), sums)
+	})
+	return sums, err
+}
+
+// indexBucketNames returns the names of all buckets belonging to an index
+func indexBucketNames(name string) []string {
+	return []string{
+		fmt.Sprintf("%s_documents", name),
+		fmt.Sprintf("%s_chunks", name),
+		fmt.Sprintf("%s_doc_chunks", name),
+		fmt.Sprintf("%s_hashes", name),
+		fmt.Sprintf("%s_metadata", name),
+		fmt.Sprintf("%s_querylog", name),
+		fmt.Sprintf("%s_changelog", name),
+		fmt.Sprintf("%s_stats", name),
+		fmt.Sprintf("%s_sparse", name),
+		fmt.Sprintf("%s_hnsw_map", name),
+	}
+}
+
+// CreateIndex creates a new index with its buckets, in the main database
+// or, with LayoutPerIndex, in a database file of its own
 func (s *Storage) CreateIndex(name string) error {
+	if err := ValidateIndexName(name); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	layout := s.layout
+	s.mu.RUnlock()
+	return s.createIndex(name, layout)
+}
+
+// createIndex creates an index with the given layout without validating
+// its name
+func (s *Storage) createIndex(name, layout string) error {
+	if layout == LayoutPerIndex {
+		return s.createIndexFile(name)
+	}
+
 	return s.db.Update(func(tx *bbolt.Tx) error {
 		// Check if index already exists
 		indexBucket := tx.Bucket([]byte("_indexes"))
@@ -93,43 +242,58 @@ func (s *Storage) CreateIndex(name string) error {
 			return fmt.Errorf("index '%s' already exists", name)
 		}
 
+		// Bucket names are not unambiguous ("a" owns "a_doc_chunks", which
+		// would also be the chunks bucket of "a_doc"), so refuse names whose
+		// buckets are taken
+		for _, bucketName := range indexBucketNames(name) {
+			if tx.Bucket([]byte(bucketName)) != nil {
+				return fmt.Errorf("%w: bucket %s of '%s' is used by another index", ErrInvalidIndexName, bucketName, name)
+			}
+		}
+
 		// Create index entry
-		if err := indexBucket.Put([]byte(name), []byte("active")); err != nil {
+		if err := indexBucket.Put([]byte(name), []byte(registryShared)); err != nil {
 			return err
 		}
 
-		// Create index-specific buckets
-		bucketNames := []string{
-			fmt.Sprintf("%s_documents", name),
-			fmt.Sprintf("%s_chunks", name),
-			fmt.Sprintf("%s_doc_chunks", name),
-			fmt.Sprintf("%s_hashes", name),
-			fmt.Sprintf("%s_metadata", name),
-		}
+		return initIndexBuckets(tx, name)
+	})
+}
 
-		for _, bucketName := range bucketNames {
-			if _, err := tx.CreateBucketIfNotExists([]byte(bucketName)); err != nil {
-				return fmt.Errorf("failed to create bucket %s: %w", bucketName, err)
-			}
+// initIndexBuckets creates the buckets of an index and its initial metadata
+func initIndexBuckets(tx *bbolt.Tx, name string) error {
+	// Create index-specific buckets
+	for _, bucketName := range indexBucketNames(name) {
+		if _, err := tx.CreateBucketIfNotExists([]byte(bucketName)); err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", bucketName, err)
 		}
+	}
 
-		// Initialize metadata
-		metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", name)))
-		metadata := IndexMetadata{
-			NextHNSWId:    1,
-			DocumentCount: 0,
-			ChunkCount:    0,
-		}
-		data, err := json.Marshal(metadata)
-		if err != nil {
-			return err
-		}
-		return metadataBucket.Put([]byte("metadata"), data)
-	})
+	// Initialize metadata
+	metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", name)))
+	metadata := IndexMetadata{
+		NextHNSWId:    1,
+		DocumentCount: 0,
+		ChunkCount:    0,
+		CreatedAt:     time.Now().UTC(),
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return metadataBucket.Put([]byte("metadata"), data)
 }
 
-// DeleteIndex deletes an index and all its data
+// DeleteIndex deletes an index and all its data. An index in its own file
+// is deleted by removing the file.
 func (s *Storage) DeleteIndex(name string) error {
+	s.dbsMu.RLock()
+	_, ownFile := s.indexDBs[name]
+	s.dbsMu.RUnlock()
+	if ownFile {
+		return s.deleteIndexFile(name)
+	}
+
 	return s.db.Update(func(tx *bbolt.Tx) error {
 		// Check if index exists
 		indexBucket := tx.Bucket([]byte("_indexes"))
@@ -142,16 +306,18 @@ func (s *Storage) DeleteIndex(name string) error {
 			return err
 		}
 
-		// Delete index-specific buckets
-		bucketNames := []string{
-			fmt.Sprintf("%s_documents", name),
-			fmt.Sprintf("%s_chunks", name),
-			fmt.Sprintf("%s_doc_chunks", name),
-			fmt.Sprintf("%s_hashes", name),
-			fmt.Sprintf("%s_metadata", name),
+		// Release the content blobs of the index's documents
+		if docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", name))); docBucket != nil {
+			err := docBucket.ForEach(func(k, v []byte) error {
+				return releaseStoredDocument(tx, docBucket, string(k))
+			})
+			if err != nil {
+				return fmt.Errorf("failed to release content blobs: %w", err)
+			}
 		}
 
-		for _, bucketName := range bucketNames {
+		// Delete index-specific buckets
+		for _, bucketName := range indexBucketNames(name) {
 			if err := tx.DeleteBucket([]byte(bucketName)); err != nil && err != bbolt.ErrBucketNotFound {
 				return fmt.Errorf("failed to delete bucket %s: %w", bucketName, err)
 			}
@@ -192,14 +358,17 @@ func (s *Storage) ListIndexes() ([]string, error) {
 
 // StoreDocument stores a document in the index
 func (s *Storage) StoreDocument(indexName string, doc Document) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
 		// Store document
 		docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", indexName)))
 		if docBucket == nil {
 			return fmt.Errorf("index '%s' not found", indexName)
 		}
 
-		data, err := json.Marshal(doc)
+		if err := releaseStoredDocument(tx, docBucket, doc.URI); err != nil {
+			return err
+		}
+		data, err := s.encodeDocument(tx, doc)
 		if err != nil {
 			return err
 		}
@@ -216,14 +385,14 @@ func (s *Storage) StoreDocument(indexName string, doc Document) error {
 			}
 		}
 
-		return nil
+		return appendChange(tx, indexName, ChangeUpsert, doc.URI, doc.Hash)
 	})
 }
 
 // GetDocument retrieves a document from the index
 func (s *Storage) GetDocument(indexName, uri string) (*Document, error) {
 	var doc *Document
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
 		docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", indexName)))
 		if docBucket == nil {
 			return fmt.Errorf("index '%s' not found", indexName)
@@ -234,8 +403,8 @@ func (s *Storage) GetDocument(indexName, uri string) (*Document, error) {
 			return fmt.Errorf("document '%s' not found", uri)
 		}
 
-		var d Document
-		if err := json.Unmarshal(data, &d); err != nil {
+		d, err := decodeDocument(tx, data)
+		if err != nil {
 			return err
 		}
 		doc = &d
@@ -246,12 +415,16 @@ func (s *Storage) GetDocument(indexName, uri string) (*Document, error) {
 
 // DeleteDocument deletes a document from the index
 func (s *Storage) DeleteDocument(indexName, uri string) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
 		// Delete from documents bucket
 		docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", indexName)))
 		if docBucket == nil {
 			return fmt.Errorf("index '%s' not found", indexName)
 		}
+		existed := docBucket.Get([]byte(uri)) != nil
+		if err := releaseStoredDocument(tx, docBucket, uri); err != nil {
+			return err
+		}
 		if err := docBucket.Delete([]byte(uri)); err != nil {
 			return err
 		}
@@ -265,60 +438,49 @@ func (s *Storage) DeleteDocument(indexName, uri string) error {
 		// Delete document-chunk mappings
 		docChunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_doc_chunks", indexName)))
 		if docChunkBucket != nil {
-			docChunkBucket.Delete([]byte(uri))
+			if err := deleteDocChunkIDs(docChunkBucket, uri); err != nil {
+				return err
+			}
 		}
 
-		return nil
+		if !existed {
+			return nil
+		}
+		return appendChange(tx, indexName, ChangeDelete, uri, "")
 	})
 }
 
 // StoreChunk stores a chunk in the index
 func (s *Storage) StoreChunk(indexName string, chunk Chunk) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
 		// Store chunk
 		chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
 		if chunkBucket == nil {
 			return fmt.Errorf("index '%s' not found", indexName)
 		}
 
-		data, err := json.Marshal(chunk)
+		data, err := s.encodeChunk(chunk)
 		if err != nil {
 			return err
 		}
 
+		if err := replaceChunk(tx, indexName, chunkBucket, chunk.ID); err != nil {
+			return err
+		}
 		if err := chunkBucket.Put([]byte(chunk.ID), data); err != nil {
 			return err
 		}
+		if err := mapHNSWId(tx, indexName, chunk); err != nil {
+			return err
+		}
+		if err := putSparse(tx, indexName, chunk.ID, chunk.Sparse); err != nil {
+			return err
+		}
 
 		// Update document-chunk mapping
 		if chunk.DocumentURI != "" {
 			docChunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_doc_chunks", indexName)))
-			
-			// Get existing chunk IDs for this document
-			var chunkIDs []string
-			existing := docChunkBucket.Get([]byte(chunk.DocumentURI))
-			if existing != nil {
-				json.Unmarshal(existing, &chunkIDs)
-			}
-
-			// Add new chunk ID if not already present
-			found := false
-			for _, id := range chunkIDs {
-				if id == chunk.ID {
-					found = true
-					break
-				}
-			}
-			if !found {
-				chunkIDs = append(chunkIDs, chunk.ID)
-			}
-
-			// Store updated mapping
-			data, err := json.Marshal(chunkIDs)
-			if err != nil {
-				return err
-			}
-			if err := docChunkBucket.Put([]byte(chunk.DocumentURI), data); err != nil {
+			if err := addDocChunkID(docChunkBucket, chunk.DocumentURI, chunk.Position, chunk.ID); err != nil {
 				return err
 			}
 		}
@@ -330,7 +492,7 @@ func (s *Storage) StoreChunk(indexName string, chunk Chunk) error {
 // GetChunk retrieves a chunk from the index
 func (s *Storage) GetChunk(indexName, chunkID string) (*Chunk, error) {
 	var chunk *Chunk
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
 		chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
 		if chunkBucket == nil {
 			return fmt.Errorf("index '%s' not found", indexName)
@@ -342,7 +504,7 @@ func (s *Storage) GetChunk(indexName, chunkID string) (*Chunk, error) {
 		}
 
 		var c Chunk
-		if err := json.Unmarshal(data, &c); err != nil {
+		if err := decodeChunk(data, &c); err != nil {
 			return err
 		}
 		chunk = &c
@@ -354,20 +516,15 @@ func (s *Storage) GetChunk(indexName, chunkID string) (*Chunk, error) {
 // GetChunksByDocument retrieves all chunks for a document
 func (s *Storage) GetChunksByDocument(indexName, documentURI string) ([]Chunk, error) {
 	var chunks []Chunk
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
 		// Get chunk IDs for document
 		docChunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_doc_chunks", indexName)))
 		if docChunkBucket == nil {
 			return nil
 		}
 
-		chunkIDsData := docChunkBucket.Get([]byte(documentURI))
-		if chunkIDsData == nil {
-			return nil
-		}
-
-		var chunkIDs []string
-		if err := json.Unmarshal(chunkIDsData, &chunkIDs); err != nil {
+		chunkIDs, err := readDocChunkIDs(docChunkBucket, documentURI)
+		if err != nil {
 			return err
 		}
 
@@ -381,7 +538,7 @@ func (s *Storage) GetChunksByDocument(indexName, documentURI string) ([]Chunk, e
 			data := chunkBucket.Get([]byte(id))
 			if data != nil {
 				var chunk Chunk
-				if err := json.Unmarshal(data, &chunk); err != nil {
+				if err := decodeChunk(data, &chunk); err != nil {
 					continue
 				}
 				chunks = append(chunks, chunk)
@@ -405,20 +562,15 @@ func (s *Storage) GetChunksByDocument(indexName, documentURI string) ([]Chunk, e
 
 // DeleteChunksByDocument deletes all chunks for a document
 func (s *Storage) DeleteChunksByDocument(indexName, documentURI string) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
 		// Get chunk IDs for document
 		docChunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_doc_chunks", indexName)))
 		if docChunkBucket == nil {
 			return nil
 		}
 
-		chunkIDsData := docChunkBucket.Get([]byte(documentURI))
-		if chunkIDsData == nil {
-			return nil
-		}
-
-		var chunkIDs []string
-		if err := json.Unmarshal(chunkIDsData, &chunkIDs); err != nil {
+		chunkIDs, err := readDocChunkIDs(docChunkBucket, documentURI)
+		if err != nil {
 			return err
 		}
 
@@ -426,13 +578,40 @@ func (s *Storage) DeleteChunksByDocument(indexName, documentURI string) error {
 		chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
 		if chunkBucket != nil {
 			for _, id := range chunkIDs {
+				if err := replaceChunk(tx, indexName, chunkBucket, id); err != nil {
+					return err
+				}
 				chunkBucket.Delete([]byte(id))
+				if err := deleteSparse(tx, indexName, id); err != nil {
+					return err
+				}
 			}
 		}
 
 		// Delete document-chunk mapping
-		docChunkBucket.Delete([]byte(documentURI))
+		return deleteDocChunkIDs(docChunkBucket, documentURI)
+	})
+}
 
+// DeleteChunks deletes chunks by ID, such as chunks left behind by a
+// document that is no longer stored
+func (s *Storage) DeleteChunks(indexName string, chunkIDs []string) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
+		chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
+		if chunkBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+		for _, id := range chunkIDs {
+			if err := replaceChunk(tx, indexName, chunkBucket, id); err != nil {
+				return err
+			}
+			if err := chunkBucket.Delete([]byte(id)); err != nil {
+				return err
+			}
+			if err := deleteSparse(tx, indexName, id); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 }
@@ -440,7 +619,7 @@ func (s *Storage) DeleteChunksByDocument(indexName, documentURI string) error {
 // GetDocumentHash retrieves the hash for a document
 func (s *Storage) GetDocumentHash(indexName, uri string) (string, error) {
 	var hash string
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
 		hashBucket := tx.Bucket([]byte(fmt.Sprintf("%s_hashes", indexName)))
 		if hashBucket == nil {
 			return fmt.Errorf("index '%s' not found", indexName)
@@ -457,9 +636,26 @@ func (s *Storage) GetDocumentHash(indexName, uri string) (string, error) {
 	return hash, err
 }
 
+// ListDocumentHashes returns the content hash of every document, keyed by URI
+func (s *Storage) ListDocumentHashes(indexName string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		hashBucket := tx.Bucket([]byte(fmt.Sprintf("%s_hashes", indexName)))
+		if hashBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		return hashBucket.ForEach(func(k, v []byte) error {
+			hashes[string(k)] = string(v)
+			return nil
+		})
+	})
+	return hashes, err
+}
+
 // ClearHashes removes all document hashes for an index
 func (s *Storage) ClearHashes(indexName string) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
 		hashBucket := tx.Bucket([]byte(fmt.Sprintf("%s_hashes", indexName)))
 		if hashBucket == nil {
 			// Bucket doesn't exist, nothing to clear
@@ -478,10 +674,22 @@ func (s *Storage) ClearHashes(indexName string) error {
 	})
 }
 
+// ClearDocumentHash removes the hash of one document, so the next add of
+// the document is not skipped as unchanged
+func (s *Storage) ClearDocumentHash(indexName, uri string) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
+		hashBucket := tx.Bucket([]byte(fmt.Sprintf("%s_hashes", indexName)))
+		if hashBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+		return hashBucket.Delete([]byte(uri))
+	})
+}
+
 // GetIndexMetadata retrieves metadata for an index
 func (s *Storage) GetIndexMetadata(indexName string) (*IndexMetadata, error) {
 	var metadata *IndexMetadata
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
 		metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName)))
 		if metadataBucket == nil {
 			return fmt.Errorf("index '%s' not found", indexName)
@@ -512,7 +720,7 @@ func (s *Storage) SetIndexMetadata(indexName string, metadata IndexMetadata) err
 		"last_updated", metadata.LastUpdated,
 	)
 	
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
 		metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName)))
 		if metadataBucket == nil {
 			return fmt.Errorf("index '%s' not found", indexName)
@@ -527,10 +735,37 @@ func (s *Storage) SetIndexMetadata(indexName string, metadata IndexMetadata) err
 	})
 }
 
+// UpdateIndexMetadata changes the metadata of an index in one transaction,
+// so concurrent commits' ID and count updates are not lost
+func (s *Storage) UpdateIndexMetadata(indexName string, update func(*IndexMetadata)) error {
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
+		metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName)))
+		if metadataBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		data := metadataBucket.Get([]byte("metadata"))
+		if data == nil {
+			return errors.New("metadata not found")
+		}
+		var metadata IndexMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return err
+		}
+		update(&metadata)
+
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		return metadataBucket.Put([]byte("metadata"), data)
+	})
+}
+
 // GetNextHNSWId gets the next available HNSW ID for an index
 func (s *Storage) GetNextHNSWId(indexName string) (uint64, error) {
 	var nextID uint64
-	err := s.db.Update(func(tx *bbolt.Tx) error {
+	err := s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
 		metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName)))
 		if metadataBucket == nil {
 			return fmt.Errorf("index '%s' not found", indexName)
@@ -565,7 +800,7 @@ func (s *Storage) GetNextHNSWId(indexName string) (uint64, error) {
 // ListDocuments returns all document URIs in an index
 func (s *Storage) ListDocuments(indexName string) ([]string, error) {
 	var uris []string
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
 		docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", indexName)))
 		if docBucket == nil {
 			return nil
@@ -585,4 +820,216 @@ func ensureDir(dir string) error {
 		return nil
 	}
 	return os.MkdirAll(dir, 0755)
-}
\ No newline at end of file
+}
+// ForEachChunk calls fn for every chunk stored in the index
+func (s *Storage) ForEachChunk(indexName string, fn func(Chunk) error) error {
+	return s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
+		if chunkBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		return chunkBucket.ForEach(func(k, v []byte) error {
+			var chunk Chunk
+			if err := decodeChunk(v, &chunk); err != nil {
+				return fmt.Errorf("failed to decode chunk '%s': %w", k, err)
+			}
+			return fn(chunk)
+		})
+	})
+}
+
+// CountChunksByDocument returns the number of chunks mapped to each document.
+// Documents without any chunks are reported with a count of zero.
+func (s *Storage) CountChunksByDocument(indexName string) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", indexName)))
+		if docBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+		if err := docBucket.ForEach(func(k, v []byte) error {
+			counts[string(k)] = 0
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		docChunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_doc_chunks", indexName)))
+		if docChunkBucket == nil {
+			return nil
+		}
+		return docChunkBucket.ForEach(func(k, v []byte) error {
+			if sub := docChunkBucket.Bucket(k); sub != nil {
+				counts[string(k)] = sub.Stats().KeyN
+				return nil
+			}
+			var chunkIDs []string
+			if err := json.Unmarshal(v, &chunkIDs); err != nil {
+				return fmt.Errorf("failed to decode chunk mapping for '%s': %w", k, err)
+			}
+			counts[string(k)] = len(chunkIDs)
+			return nil
+		})
+	})
+	return counts, err
+}
+
+// ListDocumentsPage returns up to limit documents whose URI sorts after the
+// given URI. An empty after starts at the beginning. Paging keeps read
+// transactions short when iterating over large indexes.
+func (s *Storage) ListDocumentsPage(indexName, after string, limit int) ([]Document, error) {
+	var docs []Document
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", indexName)))
+		if docBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		c := docBucket.Cursor()
+		var k, v []byte
+		if after == "" {
+			k, v = c.First()
+		} else {
+			k, v = c.Seek([]byte(after))
+			if k != nil && string(k) == after {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(docs) < limit; k, v = c.Next() {
+			doc, err := decodeDocument(tx, v)
+			if err != nil {
+				return fmt.Errorf("failed to decode document '%s': %w", k, err)
+			}
+			docs = append(docs, doc)
+		}
+		return nil
+	})
+	return docs, err
+}
+
+// GetIndexSetting returns a raw setting stored alongside the index metadata.
+// It returns nil without error if the setting has never been set.
+func (s *Storage) GetIndexSetting(indexName, key string) ([]byte, error) {
+	var value []byte
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName)))
+		if metadataBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		if data := metadataBucket.Get([]byte(key)); data != nil {
+			value = append([]byte(nil), data...)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// SetIndexSetting stores a raw setting alongside the index metadata.
+// A nil value removes the setting.
+func (s *Storage) SetIndexSetting(indexName, key string, value []byte) error {
+	if key == "metadata" {
+		return errors.New("setting key 'metadata' is reserved")
+	}
+
+	return s.indexDB(indexName).Update(func(tx *bbolt.Tx) error {
+		metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", indexName)))
+		if metadataBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		if value == nil {
+			return metadataBucket.Delete([]byte(key))
+		}
+		return metadataBucket.Put([]byte(key), value)
+	})
+}
+
+// Ping checks that the main database and every per-index database can be
+// read
+func (s *Storage) Ping() error {
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte("_indexes")) == nil {
+			return errors.New("index registry missing")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.dbsMu.RLock()
+	defer s.dbsMu.RUnlock()
+	for name, db := range s.indexDBs {
+		if err := db.View(func(tx *bbolt.Tx) error { return nil }); err != nil {
+			return fmt.Errorf("index '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// GetChunkByHNSWId returns the chunk stored under a graph ID and its
+// document, read in one transaction so they always belong to the same
+// committed write. It returns nil without error if no chunk has the ID or
+// its document is gone.
+func (s *Storage) GetChunkByHNSWId(indexName string, hnswID uint64) (*Chunk, *Document, error) {
+	var chunk *Chunk
+	var doc *Document
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
+		docBucket := tx.Bucket([]byte(fmt.Sprintf("%s_documents", indexName)))
+		if chunkBucket == nil || docBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		var err error
+		if chunk, err = lookupChunkByHNSWId(tx, indexName, chunkBucket, hnswID); err != nil || chunk == nil {
+			return err
+		}
+
+		data := docBucket.Get([]byte(chunk.DocumentURI))
+		if data == nil {
+			chunk = nil
+			return nil
+		}
+		d, err := decodeDocument(tx, data)
+		if err != nil {
+			return err
+		}
+		doc = &d
+		return nil
+	})
+	if err != nil || doc == nil {
+		return nil, nil, err
+	}
+	return chunk, doc, nil
+}
+
+// GetChunksByHNSWIds returns the chunks with the given HNSW IDs, read in
+// one transaction. IDs without a chunk are missing from the map.
+func (s *Storage) GetChunksByHNSWIds(indexName string, hnswIDs []uint64) (map[uint64]*Chunk, error) {
+	chunks := make(map[uint64]*Chunk, len(hnswIDs))
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		chunkBucket := tx.Bucket([]byte(fmt.Sprintf("%s_chunks", indexName)))
+		if chunkBucket == nil {
+			return fmt.Errorf("index '%s' not found", indexName)
+		}
+
+		for _, id := range hnswIDs {
+			c, err := lookupChunkByHNSWId(tx, indexName, chunkBucket, id)
+			if err != nil {
+				return err
+			}
+			if c != nil {
+				chunks[id] = c
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
@@ -1,10 +1,12 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -287,6 +289,24 @@ func TestStorage_GetDocumentHash(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestStorage_ListDocumentHashes(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("test-index"))
+	require.NoError(t, store.StoreDocument("test-index", Document{URI: "doc://1", Hash: "h1"}))
+	require.NoError(t, store.StoreDocument("test-index", Document{URI: "doc://2", Hash: "h2"}))
+	require.NoError(t, store.DeleteDocument("test-index", "doc://2"))
+
+	hashes, err := store.ListDocumentHashes("test-index")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"doc://1": "h1"}, hashes)
+
+	_, err = store.ListDocumentHashes("missing")
+	assert.Error(t, err)
+}
+
 func TestStorage_GetIndexMetadata(t *testing.T) {
 	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
 	require.NoError(t, err)
@@ -300,7 +320,7 @@ func TestStorage_GetIndexMetadata(t *testing.T) {
 		NextHNSWId:    100,
 		DocumentCount: 10,
 		ChunkCount:    50,
-		LastUpdated:   "2024-01-01T00:00:00Z",
+		LastUpdated:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
 	err = store.SetIndexMetadata("test-index", metadata)
 	assert.NoError(t, err)
@@ -311,6 +331,34 @@ func TestStorage_GetIndexMetadata(t *testing.T) {
 	assert.Equal(t, metadata.NextHNSWId, retrieved.NextHNSWId)
 	assert.Equal(t, metadata.DocumentCount, retrieved.DocumentCount)
 	assert.Equal(t, metadata.ChunkCount, retrieved.ChunkCount)
+	assert.True(t, metadata.LastUpdated.Equal(retrieved.LastUpdated))
+
+	// Updates keep the fields they do not touch
+	synced := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.UpdateIndexMetadata("test-index", func(m *IndexMetadata) { m.LastSyncAt = synced }))
+	retrieved, err = store.GetIndexMetadata("test-index")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), retrieved.NextHNSWId)
+	assert.True(t, synced.Equal(retrieved.LastSyncAt))
+	assert.Error(t, store.UpdateIndexMetadata("missing", func(*IndexMetadata) {}))
+}
+
+func TestIndexMetadata_LegacyTimes(t *testing.T) {
+	// Older versions stored RFC3339 strings, empty when unset
+	var m IndexMetadata
+	require.NoError(t, json.Unmarshal([]byte(`{"next_hnsw_id":3,"document_count":1,"chunk_count":2,"last_updated":""}`), &m))
+	assert.Equal(t, uint64(3), m.NextHNSWId)
+	assert.True(t, m.LastUpdated.IsZero())
+
+	require.NoError(t, json.Unmarshal([]byte(`{"next_hnsw_id":3,"last_updated":"2024-01-01T10:00:00+02:00"}`), &m))
+	assert.True(t, m.LastUpdated.Equal(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"last_updated":"yesterday"}`), &m))
+
+	// Unset times are omitted, set ones written as RFC 3339
+	data, err := json.Marshal(IndexMetadata{NextHNSWId: 1, LastUpdated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"next_hnsw_id":1,"document_count":0,"chunk_count":0,"last_updated":"2024-01-01T00:00:00Z"}`, string(data))
 }
 
 func TestStorage_GetNextHNSWId(t *testing.T) {
@@ -356,4 +404,103 @@ func TestStorage_ListDocuments(t *testing.T) {
 	docs, err := store.ListDocuments("test-index")
 	assert.NoError(t, err)
 	assert.Len(t, docs, 3)
-}
\ No newline at end of file
+}
+func TestStorage_ForEachChunkAndCounts(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	err = store.CreateIndex("test-index")
+	require.NoError(t, err)
+
+	require.NoError(t, store.StoreDocument("test-index", Document{URI: "doc1"}))
+	require.NoError(t, store.StoreDocument("test-index", Document{URI: "doc2"}))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.StoreChunk("test-index", Chunk{
+			ID:          fmt.Sprintf("chunk%d", i),
+			DocumentURI: "doc1",
+			Text:        "text",
+			Position:    i,
+		}))
+	}
+
+	var seen int
+	err = store.ForEachChunk("test-index", func(c Chunk) error {
+		seen++
+		assert.Equal(t, "doc1", c.DocumentURI)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, seen)
+
+	counts, err := store.CountChunksByDocument("test-index")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"doc1": 3, "doc2": 0}, counts)
+
+	err = store.ForEachChunk("missing", func(Chunk) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestStorage_ListDocumentsPage(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("test-index"))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.StoreDocument("test-index", Document{URI: fmt.Sprintf("doc%d", i)}))
+	}
+
+	page, err := store.ListDocumentsPage("test-index", "", 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "doc0", page[0].URI)
+	assert.Equal(t, "doc1", page[1].URI)
+
+	page, err = store.ListDocumentsPage("test-index", "doc1", 10)
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	assert.Equal(t, "doc2", page[0].URI)
+
+	page, err = store.ListDocumentsPage("test-index", "doc4", 10)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func TestStorage_IndexSettings(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("test-index"))
+
+	value, err := store.GetIndexSetting("test-index", "schema")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	require.NoError(t, store.SetIndexSetting("test-index", "schema", []byte(`{"fields":{}}`)))
+	value, err = store.GetIndexSetting("test-index", "schema")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"fields":{}}`, string(value))
+
+	require.NoError(t, store.SetIndexSetting("test-index", "schema", nil))
+	value, err = store.GetIndexSetting("test-index", "schema")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	assert.Error(t, store.SetIndexSetting("test-index", "metadata", []byte("{}")))
+	assert.Error(t, store.SetIndexSetting("missing", "schema", []byte("{}")))
+}
+
+func TestStorage_Ping(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStorage(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+
+	require.NoError(t, store.SetLayout(LayoutPerIndex))
+	require.NoError(t, store.CreateIndex("own-file"))
+	assert.NoError(t, store.Ping())
+
+	require.NoError(t, store.Close())
+	assert.Error(t, store.Ping())
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// IndexSummary is the stored state of an index, counted from bucket
+// statistics rather than by reading documents
+type IndexSummary struct {
+	Name      string
+	Documents int
+	Chunks    int
+	Bytes     int64 // Pages allocated to the index's buckets
+	Metadata  IndexMetadata
+	Settings  map[string][]byte // The requested settings that are set
+}
+
+// IndexSummaries summarizes every index, with the given settings, in one
+// read transaction per database: one for all indexes in the main database
+// and one for each index in a file of its own.
+func (s *Storage) IndexSummaries(settings ...string) ([]IndexSummary, error) {
+	var summaries []IndexSummary
+	var own []int // Positions of indexes stored in their own file
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		indexBucket := tx.Bucket([]byte("_indexes"))
+		if indexBucket == nil {
+			return nil
+		}
+		return indexBucket.ForEach(func(k, v []byte) error {
+			summary := IndexSummary{Name: string(k)}
+			if string(v) == registryFile {
+				own = append(own, len(summaries))
+			} else if err := summarizeIndex(tx, &summary, settings); err != nil {
+				return err
+			}
+			summaries = append(summaries, summary)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pos := range own {
+		summary := &summaries[pos]
+		if err := s.indexDB(summary.Name).View(func(tx *bbolt.Tx) error {
+			return summarizeIndex(tx, summary, settings)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return summaries, nil
+}
+
+// IndexSummary summarizes one index
+func (s *Storage) IndexSummary(indexName string, settings ...string) (*IndexSummary, error) {
+	summary := &IndexSummary{Name: indexName}
+	err := s.indexDB(indexName).View(func(tx *bbolt.Tx) error {
+		return summarizeIndex(tx, summary, settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// summarizeIndex fills in a summary from the index's buckets
+func summarizeIndex(tx *bbolt.Tx, summary *IndexSummary, settings []string) error {
+	name := summary.Name
+	metadataBucket := tx.Bucket([]byte(fmt.Sprintf("%s_metadata", name)))
+	if metadataBucket == nil {
+		return fmt.Errorf("index '%s' not found", name)
+	}
+	if data := metadataBucket.Get([]byte("metadata")); data != nil {
+		if err := json.Unmarshal(data, &summary.Metadata); err != nil {
+			return fmt.Errorf("failed to decode metadata of '%s': %w", name, err)
+		}
+	}
+	for _, key := range settings {
+		if data := metadataBucket.Get([]byte(key)); data != nil {
+			if summary.Settings == nil {
+				summary.Settings = make(map[string][]byte)
+			}
+			summary.Settings[key] = append([]byte(nil), data...)
+		}
+	}
+
+	for _, bucketName := range indexBucketNames(name) {
+		bucket := tx.Bucket([]byte(bucketName))
+		if bucket == nil {
+			continue
+		}
+		stats := bucket.Stats()
+		summary.Bytes += int64(stats.BranchAlloc + stats.LeafAlloc + stats.InlineBucketInuse)
+		switch bucketName {
+		case fmt.Sprintf("%s_documents", name):
+			summary.Documents = stats.KeyN
+		case fmt.Sprintf("%s_chunks", name):
+			summary.Chunks = stats.KeyN
+		}
+	}
+	return nil
+}
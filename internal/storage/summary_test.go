@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_IndexSummaries(t *testing.T) {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "indexes.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.CreateIndex("shared"))
+	require.NoError(t, store.SetLayout(LayoutPerIndex))
+	require.NoError(t, store.CreateIndex("own"))
+
+	for i := 0; i < 3; i++ {
+		uri := fmt.Sprintf("doc://%d", i)
+		require.NoError(t, store.StoreDocument("own", Document{URI: uri, Title: "Own"}))
+		for c := 0; c < 2; c++ {
+			require.NoError(t, store.StoreChunk("own", Chunk{ID: fmt.Sprintf("%d-%d", i, c), DocumentURI: uri, Position: c}))
+		}
+	}
+	require.NoError(t, store.StoreDocument("shared", Document{URI: "doc://1", Title: "Shared"}))
+	require.NoError(t, store.SetIndexSetting("own", "labels", []byte(`{"env":"test"}`)))
+
+	summaries, err := store.IndexSummaries("labels", "missing")
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	byName := map[string]IndexSummary{}
+	for _, s := range summaries {
+		byName[s.Name] = s
+	}
+
+	own := byName["own"]
+	assert.Equal(t, 3, own.Documents)
+	assert.Equal(t, 6, own.Chunks)
+	assert.Greater(t, own.Bytes, int64(0))
+	assert.Equal(t, uint64(1), own.Metadata.NextHNSWId)
+	assert.Equal(t, map[string][]byte{"labels": []byte(`{"env":"test"}`)}, own.Settings)
+
+	shared := byName["shared"]
+	assert.Equal(t, 1, shared.Documents)
+	assert.Equal(t, 0, shared.Chunks)
+	assert.Nil(t, shared.Settings)
+
+	one, err := store.IndexSummary("own")
+	require.NoError(t, err)
+	assert.Equal(t, own.Documents, one.Documents)
+	assert.Equal(t, own.Bytes, one.Bytes)
+
+	_, err = store.IndexSummary("missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package titler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultPrompt asks the model for a short title of a chunk. It is
+// formatted with the document title and the chunk text.
+const DefaultPrompt = "Write a short title (at most eight words) for the following " +
+	"passage from the document %q. Reply with the title only.\n\n%s"
+
+// MaxTitleLength caps the length of generated titles, in characters
+const MaxTitleLength = 100
+
+// generateRequest represents the request to Ollama's generate API
+type generateRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+}
+
+// generateResponse represents the response from Ollama's generate API
+type generateResponse struct {
+	Model    string `json:"model"`
+	Response string `json:"response"`
+}
+
+// OllamaTitler generates chunk titles using an Ollama language model
+// such as llama3.2 or qwen2.5
+type OllamaTitler struct {
+	baseURL string
+	client  *http.Client
+	model   string
+	prompt  string
+}
+
+// NewOllamaTitler creates a new Ollama chunk titler.
+// An empty prompt uses DefaultPrompt.
+func NewOllamaTitler(ollamaURL, model, prompt string) (*OllamaTitler, error) {
+	if model == "" {
+		return nil, errors.New("titling model cannot be empty")
+	}
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+
+	return &OllamaTitler{
+		baseURL: strings.TrimSuffix(ollamaURL, "/"),
+		client: &http.Client{
+			// Generation is much slower than embedding
+			Timeout: 2 * time.Minute,
+		},
+		model:  model,
+		prompt: prompt,
+	}, nil
+}
+
+// Name identifies the titler in chunk metadata
+func (o *OllamaTitler) Name() string {
+	return "ollama:" + o.model
+}
+
+// Title returns a short title for a chunk of the document titled docTitle
+func (o *OllamaTitler) Title(ctx context.Context, docTitle, text string) (string, error) {
+	start := time.Now()
+
+	reqBody, err := json.Marshal(generateRequest{
+		Model:  o.model,
+		Prompt: fmt.Sprintf(o.prompt, docTitle, text),
+		Stream: false,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx,
+		"POST", o.baseURL+"/api/generate", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := o.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(httpResp.Body)
+		return "", fmt.Errorf("titling request failed with status %d: %s",
+			httpResp.StatusCode, string(body))
+	}
+
+	var resp generateResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	title := Clean(resp.Response)
+	slog.Debug("Chunk titled",
+		"model", o.model,
+		"title", title,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return title, nil
+}
+
+// Clean normalizes a generated title: the first non-empty line, without
+// surrounding quotes, markdown markers or a trailing period, and at most
+// MaxTitleLength characters
+func Clean(title string) string {
+	for _, line := range strings.Split(title, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "#*- ")
+		line = strings.Trim(line, "\"'`*")
+		line = strings.TrimSuffix(strings.TrimSpace(line), ".")
+		if line == "" {
+			continue
+		}
+		if r := []rune(line); len(r) > MaxTitleLength {
+			line = strings.TrimSpace(string(r[:MaxTitleLength]))
+		}
+		return line
+	}
+	return ""
+}
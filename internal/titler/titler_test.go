@@ -0,0 +1,59 @@
+package titler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaTitler_Title(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+
+		var req generateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "llama3.2", req.Model)
+		assert.Contains(t, req.Prompt, `"Install Guide"`)
+		assert.Contains(t, req.Prompt, "Run make install.")
+		assert.False(t, req.Stream)
+
+		json.NewEncoder(w).Encode(generateResponse{Model: "llama3.2", Response: "\n\"Installing from source.\"\n"})
+	}))
+	defer server.Close()
+
+	o, err := NewOllamaTitler(server.URL, "llama3.2", "")
+	require.NoError(t, err)
+	assert.Equal(t, "ollama:llama3.2", o.Name())
+
+	title, err := o.Title(context.Background(), "Install Guide", "Run make install.")
+	require.NoError(t, err)
+	assert.Equal(t, "Installing from source", title)
+}
+
+func TestOllamaTitler_Errors(t *testing.T) {
+	_, err := NewOllamaTitler("http://localhost:11434", "", "")
+	assert.Error(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	o, err := NewOllamaTitler(server.URL, "missing", "")
+	require.NoError(t, err)
+	_, err = o.Title(context.Background(), "Doc", "text")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestClean(t *testing.T) {
+	assert.Equal(t, "Configuring TLS", Clean("## **Configuring TLS**"))
+	assert.Equal(t, "First line", Clean("\n  First line.\nSecond line"))
+	assert.Equal(t, "", Clean("  \n "))
+	assert.Len(t, []rune(Clean(strings.Repeat("é", 150))), MaxTitleLength)
+}
@@ -0,0 +1,124 @@
+package vision
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultPrompt asks the model to transcribe text and otherwise describe the image
+const DefaultPrompt = "Transcribe all text visible in this image exactly. " +
+	"If the image contains no text, describe it in detail."
+
+// generateRequest represents the request to Ollama's generate API
+type generateRequest struct {
+	Model  string   `json:"model"`
+	Prompt string   `json:"prompt"`
+	Images []string `json:"images"`
+	Stream bool     `json:"stream"`
+}
+
+// generateResponse represents the response from Ollama's generate API
+type generateResponse struct {
+	Model    string `json:"model"`
+	Response string `json:"response"`
+}
+
+// OllamaVision extracts text from images using an Ollama vision model
+// such as llava or llama3.2-vision
+type OllamaVision struct {
+	baseURL string
+	client  *http.Client
+	model   string
+	prompt  string
+}
+
+// NewOllamaVision creates a new Ollama vision extractor.
+// An empty prompt uses DefaultPrompt.
+func NewOllamaVision(ollamaURL, model, prompt string) (*OllamaVision, error) {
+	if model == "" {
+		return nil, errors.New("vision model cannot be empty")
+	}
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+
+	return &OllamaVision{
+		baseURL: strings.TrimSuffix(ollamaURL, "/"),
+		client: &http.Client{
+			// Vision models are much slower than embedding models
+			Timeout: 5 * time.Minute,
+		},
+		model:  model,
+		prompt: prompt,
+	}, nil
+}
+
+// Name identifies the extractor in document metadata
+func (o *OllamaVision) Name() string {
+	return "ollama:" + o.model
+}
+
+// Extract returns the text the model reads from (or describes in) an image
+func (o *OllamaVision) Extract(ctx context.Context, data []byte, mimeType string) (string, error) {
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("unsupported content type %q: vision models accept images only", mimeType)
+	}
+
+	start := time.Now()
+	slog.Debug("Extracting text from image",
+		"model", o.model,
+		"mime_type", mimeType,
+		"size", len(data),
+	)
+
+	reqBody, err := json.Marshal(generateRequest{
+		Model:  o.model,
+		Prompt: o.prompt,
+		Images: []string{base64.StdEncoding.EncodeToString(data)},
+		Stream: false,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx,
+		"POST", o.baseURL+"/api/generate", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := o.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(httpResp.Body)
+		return "", fmt.Errorf("vision request failed with status %d: %s",
+			httpResp.StatusCode, string(body))
+	}
+
+	var resp generateResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	slog.Debug("Image text extracted",
+		"model", o.model,
+		"text_length", len(resp.Response),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+
+	return strings.TrimSpace(resp.Response), nil
+}
@@ -0,0 +1,54 @@
+package vision
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaVision_Extract(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+
+		var req generateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "llava", req.Model)
+		assert.Equal(t, DefaultPrompt, req.Prompt)
+		assert.Equal(t, []string{"aW1n"}, req.Images) // base64("img")
+		assert.False(t, req.Stream)
+
+		json.NewEncoder(w).Encode(generateResponse{Model: "llava", Response: "  INVOICE #42  \n"})
+	}))
+	defer server.Close()
+
+	v, err := NewOllamaVision(server.URL, "llava", "")
+	require.NoError(t, err)
+	assert.Equal(t, "ollama:llava", v.Name())
+
+	text, err := v.Extract(context.Background(), []byte("img"), "image/png")
+	require.NoError(t, err)
+	assert.Equal(t, "INVOICE #42", text)
+
+	_, err = v.Extract(context.Background(), []byte("%PDF"), "application/pdf")
+	assert.Error(t, err)
+}
+
+func TestOllamaVision_Errors(t *testing.T) {
+	_, err := NewOllamaVision("http://localhost:11434", "", "")
+	assert.Error(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	v, err := NewOllamaVision(server.URL, "missing", "")
+	require.NoError(t, err)
+	_, err = v.Extract(context.Background(), []byte("img"), "image/jpeg")
+	assert.ErrorContains(t, err, "404")
+}
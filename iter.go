@@ -0,0 +1,157 @@
+package hnswindex
+
+import (
+	"fmt"
+	"iter"
+	"log/slog"
+	"time"
+)
+
+// documentPageSize is the number of documents read per storage transaction
+// while iterating, keeping memory and transaction lifetime bounded.
+const documentPageSize = 100
+
+// Documents returns an iterator over all documents in the index, ordered by URI.
+// Documents are read from storage in pages, so callers never hold the full
+// corpus in memory. Iteration stops early if storage cannot be read; the
+// error is logged.
+func (i *Index) Documents() iter.Seq[Document] {
+	return func(yield func(Document) bool) {
+		impl := i.getImpl()
+		if impl == nil {
+			return
+		}
+
+		after := ""
+		for {
+			page, err := impl.manager.storage.ListDocumentsPage(i.name, after, documentPageSize)
+			if err != nil {
+				slog.Error("Failed to read documents during iteration",
+					"index", i.name,
+					"after", after,
+					"error", err,
+				)
+				return
+			}
+
+			for idx := range page {
+				if !yield(fromStorageDocument(&page[idx])) {
+					return
+				}
+			}
+
+			if len(page) < documentPageSize {
+				return
+			}
+			after = page[len(page)-1].URI
+		}
+	}
+}
+
+// Chunks returns an iterator over the chunks of a document in position order
+func (i *Index) Chunks(uri string) iter.Seq[Chunk] {
+	return func(yield func(Chunk) bool) {
+		impl := i.getImpl()
+		if impl == nil {
+			return
+		}
+
+		chunks, err := impl.manager.storage.GetChunksByDocument(i.name, uri)
+		if err != nil {
+			slog.Error("Failed to read chunks during iteration",
+				"index", i.name,
+				"uri", uri,
+				"error", err,
+			)
+			return
+		}
+
+		for idx := range chunks {
+			if !yield(fromStorageChunk(&chunks[idx])) {
+				return
+			}
+		}
+	}
+}
+
+// SearchIter performs a semantic search and yields results lazily.
+// The graph search runs up front, but chunks and documents are only loaded
+// as the caller consumes results, so breaking early skips hydration work.
+// If the search itself fails, the error is yielded once with a zero result.
+func (i *Index) SearchIter(query string, limit int) iter.Seq2[SearchResult, error] {
+	return i.SearchIterWithOptions(query, limit, SearchOptions{})
+}
+
+// SearchIterWithOptions is SearchIter with search options
+func (i *Index) SearchIterWithOptions(query string, limit int, options SearchOptions) iter.Seq2[SearchResult, error] {
+	return func(yield func(SearchResult, error) bool) {
+		impl := i.getImpl()
+		if impl == nil {
+			yield(SearchResult{}, fmt.Errorf("implementation not available"))
+			return
+		}
+
+		start := time.Now()
+		var timing SearchTiming
+		hits, scores, err := impl.searchHits(query, options.graphLimit(limit), options, &timing)
+		if err != nil {
+			yield(SearchResult{}, err)
+			return
+		}
+
+		yielded := 0
+		limiter := newResultLimiter(limit, options)
+		defer func() {
+			impl.manager.emitSearch(SearchEvent{
+				Index:    impl.name,
+				Query:    query,
+				Limit:    limit,
+				Results:  yielded,
+				Duration: time.Since(start),
+			})
+		}()
+
+		// Boosts reorder hits, so they are all hydrated up front, and
+		// documents only have all their matches once every hit is
+		next := func(rank int) (SearchResult, bool) {
+			return impl.hydrateHit(hits[rank], rank, options, scores)
+		}
+		if options.GroupBy == "" && options.GroupByDocument {
+			grouped := impl.hydrateResults(query, hits, limit, options, scores)
+			limiter = newResultLimiter(limit, SearchOptions{})
+			next = func(rank int) (SearchResult, bool) {
+				if rank >= len(grouped) {
+					return SearchResult{}, false
+				}
+				return grouped[rank], true
+			}
+		} else if scores.reorders(options) {
+			boosted := impl.hydrateBoosted(query, hits, options, scores)
+			next = func(rank int) (SearchResult, bool) {
+				if rank >= len(boosted) {
+					return SearchResult{}, false
+				}
+				return boosted[rank], true
+			}
+		}
+
+		for rank := range hits {
+			if limiter.done() {
+				return
+			}
+			result, ok := next(rank)
+			if !ok || !limiter.accept(&result) {
+				continue
+			}
+			yielded++
+			addSnippet(&result, query, options)
+			if result.Explain != nil {
+				result.Explain.Timing = timing
+				result.Explain.Timing.Total = time.Since(start)
+			}
+			if !yield(result, nil) {
+				return
+			}
+		}
+	}
+}
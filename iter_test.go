@@ -0,0 +1,87 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_Documents(t *testing.T) {
+	manager := newMockManager(t, nil)
+
+	index, err := manager.CreateIndex("iter")
+	require.NoError(t, err)
+
+	// More documents than a single storage page
+	var docs []Document
+	for n := 0; n < documentPageSize+5; n++ {
+		docs = append(docs, Document{
+			URI:     fmt.Sprintf("doc%03d", n),
+			Title:   fmt.Sprintf("Document %d", n),
+			Content: fmt.Sprintf("Content of document %d", n),
+		})
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	var uris []string
+	for doc := range index.Documents() {
+		uris = append(uris, doc.URI)
+	}
+	require.Len(t, uris, len(docs))
+	assert.Equal(t, "doc000", uris[0])
+	assert.Equal(t, fmt.Sprintf("doc%03d", len(docs)-1), uris[len(uris)-1])
+
+	// Breaking early stops the iteration
+	count := 0
+	for range index.Documents() {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 3, count)
+}
+
+func TestIndex_ChunksAndSearchIter(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 10
+	manager := newMockManager(t, cfg)
+
+	index, err := manager.CreateIndex("iter")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "long", Title: "Long", Content: generateLongText(100)},
+		{URI: "short", Title: "Short", Content: "A short document"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	var positions []int
+	for chunk := range index.Chunks("long") {
+		assert.Equal(t, "long", chunk.DocumentURI)
+		assert.Len(t, chunk.Embedding, 768)
+		positions = append(positions, chunk.Position)
+	}
+	require.Greater(t, len(positions), 1)
+	for n, pos := range positions {
+		assert.Equal(t, n, pos)
+	}
+
+	var results []SearchResult
+	for result, err := range index.SearchIter("A short document", 3) {
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+	eager, err := index.Search("A short document", 3)
+	require.NoError(t, err)
+	require.Len(t, results, len(eager))
+	for n := range eager {
+		assert.Equal(t, eager[n].ChunkID, results[n].ChunkID)
+	}
+}
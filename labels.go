@@ -0,0 +1,165 @@
+package hnswindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Index settings holding the description and labels of an index
+const (
+	descriptionSettingKey = "description"
+	labelsSettingKey      = "labels"
+)
+
+// IndexInfo describes an index for listings and dashboards
+type IndexInfo struct {
+	Name          string            `json:"name"`
+	Description   string            `json:"description,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	DocumentCount int               `json:"document_count"`
+	ChunkCount    int               `json:"chunk_count"`
+	SizeBytes     int64             `json:"size_bytes"` // Database pages used by the index, without its graph file
+	Model         string            `json:"model"`      // Embedding model that built the index
+	LastUpdated   time.Time         `json:"last_updated,omitzero"`
+}
+
+// ListIndexInfo returns every index with its description, labels, counts,
+// size and embedding model, in the order of ListIndexes. Counts come from
+// storage statistics in one read transaction per database, so it is much
+// cheaper than calling Stats on each index.
+func (im *IndexManager) ListIndexInfo() ([]IndexInfo, error) {
+	if impl := im.getImpl(); impl != nil {
+		return impl.ListIndexInfo()
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// SetDescription sets a free-text description of what the index contains;
+// "" removes it
+func (i *Index) SetDescription(description string) error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.SetDescription(description)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// Description returns the description of the index, or "" if none is set
+func (i *Index) Description() (string, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Description()
+	}
+	return "", fmt.Errorf("implementation not available")
+}
+
+// SetLabels replaces the labels of the index, key/value pairs such as
+// "team": "support" or "env": "test"; nil or empty removes them. Keys must
+// not be empty or contain '=', ',' or whitespace.
+func (i *Index) SetLabels(labels map[string]string) error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.SetLabels(labels)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// Labels returns the labels of the index, or nil if none are set
+func (i *Index) Labels() (map[string]string, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Labels()
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// ListIndexInfo implementation
+func (im *indexManagerImpl) ListIndexInfo() ([]IndexInfo, error) {
+	summaries, err := im.storage.IndexSummaries(descriptionSettingKey, labelsSettingKey, configSettingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]IndexInfo, 0, len(summaries))
+	for _, s := range summaries {
+		if isRebuildIndex(s.Name) {
+			continue
+		}
+		info := IndexInfo{
+			Name:          s.Name,
+			Description:   string(s.Settings[descriptionSettingKey]),
+			DocumentCount: s.Documents,
+			ChunkCount:    s.Chunks,
+			SizeBytes:     s.Bytes,
+			Model:         im.config.EmbedModel,
+			LastUpdated:   s.Metadata.LastUpdated,
+		}
+		if data := s.Settings[labelsSettingKey]; data != nil {
+			if err := json.Unmarshal(data, &info.Labels); err != nil {
+				return nil, fmt.Errorf("failed to decode labels of '%s': %w", s.Name, err)
+			}
+		}
+		// The recorded model, or the running one for indexes not written yet
+		if data := s.Settings[configSettingKey]; data != nil {
+			var cfg IndexConfig
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return nil, fmt.Errorf("failed to decode index config of '%s': %w", s.Name, err)
+			}
+			info.Model = cfg.Embedder.Model
+		} else if idx, ok := im.indexes.get(s.Name); ok {
+			info.Model = idx.effectiveConfig().Embedder.Model
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// SetDescription implementation
+func (i *indexImpl) SetDescription(description string) error {
+	if description == "" {
+		return i.manager.storage.SetIndexSetting(i.name, descriptionSettingKey, nil)
+	}
+	return i.manager.storage.SetIndexSetting(i.name, descriptionSettingKey, []byte(description))
+}
+
+// Description implementation
+func (i *indexImpl) Description() (string, error) {
+	data, err := i.manager.storage.GetIndexSetting(i.name, descriptionSettingKey)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// SetLabels implementation
+func (i *indexImpl) SetLabels(labels map[string]string) error {
+	if len(labels) == 0 {
+		return i.manager.storage.SetIndexSetting(i.name, labelsSettingKey, nil)
+	}
+	for key := range labels {
+		if key == "" || strings.ContainsAny(key, "=, \t\n") {
+			return fmt.Errorf("invalid label key %q", key)
+		}
+	}
+
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return fmt.Errorf("failed to encode labels: %w", err)
+	}
+	return i.manager.storage.SetIndexSetting(i.name, labelsSettingKey, data)
+}
+
+// Labels implementation
+func (i *indexImpl) Labels() (map[string]string, error) {
+	data, err := i.manager.storage.GetIndexSetting(i.name, labelsSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("failed to decode labels: %w", err)
+	}
+	return labels, nil
+}
@@ -0,0 +1,95 @@
+package hnswindex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_DescriptionAndLabels(t *testing.T) {
+	manager := newMockManager(t, nil)
+	kb, err := manager.CreateIndex("kb2")
+	require.NoError(t, err)
+	_, err = manager.CreateIndex("tmp-test")
+	require.NoError(t, err)
+
+	description, err := kb.Description()
+	require.NoError(t, err)
+	assert.Empty(t, description)
+	labels, err := kb.Labels()
+	require.NoError(t, err)
+	assert.Nil(t, labels)
+
+	require.NoError(t, kb.SetDescription("Support articles, English"))
+	require.NoError(t, kb.SetLabels(map[string]string{"team": "support", "lang": "en"}))
+	addDocuments(t, kb, Document{URI: "doc1", Title: "One", Content: "First document content"})
+
+	stats, err := kb.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, "Support articles, English", stats.Description)
+	assert.Equal(t, map[string]string{"team": "support", "lang": "en"}, stats.Labels)
+
+	infos, err := manager.ListIndexInfo()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	byName := map[string]IndexInfo{}
+	for _, info := range infos {
+		byName[info.Name] = info
+	}
+	assert.Equal(t, "Support articles, English", byName["kb2"].Description)
+	assert.Equal(t, "support", byName["kb2"].Labels["team"])
+	assert.Equal(t, 1, byName["kb2"].DocumentCount)
+	assert.False(t, byName["kb2"].LastUpdated.IsZero())
+	assert.Empty(t, byName["tmp-test"].Description)
+	assert.Equal(t, 0, byName["tmp-test"].DocumentCount)
+
+	for _, key := range []string{"", "a=b", "a,b", "a b"} {
+		assert.Error(t, kb.SetLabels(map[string]string{key: "x"}), key)
+	}
+
+	// Empty values remove them
+	require.NoError(t, kb.SetDescription(""))
+	require.NoError(t, kb.SetLabels(nil))
+	stats, err = kb.Stats()
+	require.NoError(t, err)
+	assert.Empty(t, stats.Description)
+	assert.Nil(t, stats.Labels)
+}
+
+func TestListIndexInfo(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 10
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = manager.CreateIndex("empty")
+	require.NoError(t, err)
+
+	addDocuments(t, index,
+		Document{URI: "doc1", Title: "Long", Content: generateLongText(200)},
+		Document{URI: "doc2", Title: "Short", Content: "Short document content"},
+	)
+
+	infos, err := manager.ListIndexInfo()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	byName := map[string]IndexInfo{}
+	for _, info := range infos {
+		byName[info.Name] = info
+	}
+
+	chunks, err := manager.getImpl().storage.CountChunksByDocument("kb")
+	require.NoError(t, err)
+	kb := byName["kb"]
+	assert.Equal(t, 2, kb.DocumentCount)
+	assert.Equal(t, chunks["doc1"]+chunks["doc2"], kb.ChunkCount)
+	assert.Greater(t, kb.SizeBytes, byName["empty"].SizeBytes)
+	assert.Equal(t, cfg.EmbedModel, kb.Model)
+	assert.Equal(t, cfg.EmbedModel, byName["empty"].Model)
+
+	stats, err := index.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, kb.SizeBytes, stats.SizeBytes)
+}
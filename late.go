@@ -0,0 +1,195 @@
+package hnswindex
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/indexer"
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// DefaultLateInteractionWords is the sub-chunk size of late interaction
+// when Config.LateInteractionWords is 0
+const DefaultLateInteractionWords = 32
+
+// lateQueryTerms caps the query terms embedded for late interaction
+const lateQueryTerms = 8
+
+// lateWords returns the sub-chunk size in words
+func (im *indexManagerImpl) lateWords() int {
+	if im.config.LateInteractionWords > 0 {
+		return im.config.LateInteractionWords
+	}
+	return DefaultLateInteractionWords
+}
+
+// lateWindows splits chunk text into consecutive sub-chunks of size words.
+// It returns nil for a chunk that fits in one, whose chunk embedding
+// already covers it.
+func lateWindows(text string, size int) []string {
+	words := strings.Fields(text)
+	if len(words) <= size {
+		return nil
+	}
+	windows := make([]string, 0, (len(words)+size-1)/size)
+	for start := 0; start < len(words); start += size {
+		windows = append(windows, strings.Join(words[start:min(start+size, len(words))], " "))
+	}
+	return windows
+}
+
+// lateVectors embeds the sub-chunks of each chunk text in one call. Entries
+// are nil for texts that fit in one sub-chunk, and all are nil unless
+// Config.LateInteraction is set.
+func (im *indexManagerImpl) lateVectors(texts []string) ([][][]float32, error) {
+	vectors := make([][][]float32, len(texts))
+	if !im.config.LateInteraction {
+		return vectors, nil
+	}
+
+	var windows []string
+	counts := make([]int, len(texts))
+	for idx, text := range texts {
+		w := lateWindows(text, im.lateWords())
+		counts[idx] = len(w)
+		windows = append(windows, w...)
+	}
+	if len(windows) == 0 {
+		return vectors, nil
+	}
+
+	embeddings, err := im.embedTexts(windows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed sub-chunks: %w", err)
+	}
+	for idx, n := range counts {
+		if n > 0 {
+			vectors[idx], embeddings = embeddings[:n:n], embeddings[n:]
+		}
+	}
+	return vectors, nil
+}
+
+// hasLateVectors reports whether a stored chunk has the sub-chunk vectors
+// the configuration asks for, so its embeddings can be reused
+func (im *indexManagerImpl) hasLateVectors(c *storage.Chunk) bool {
+	return !im.config.LateInteraction || len(c.Vectors) > 0 || lateWindows(c.Text, im.lateWords()) == nil
+}
+
+// lateInteraction rescores graph hits by MaxSim: the query embedding and
+// the embeddings of up to lateQueryTerms query keywords are each matched
+// with the most similar vector of a chunk, its chunk embedding or one of
+// its sub-chunk vectors, and the similarities are averaged. It reorders
+// the hits and returns the change of each hit's score by graph ID.
+func (i *indexImpl) lateInteraction(query string, embedding []float32, hits []indexer.SearchResult, options SearchOptions, timing *SearchTiming) (map[uint64]float64, error) {
+	start := time.Now()
+	queryVectors, err := i.lateQueryVectors(query, embedding, options.Model)
+	if err != nil {
+		return nil, fmt.Errorf("late interaction: %w", err)
+	}
+	timing.Embed += time.Since(start)
+
+	start = time.Now()
+	ids := make([]uint64, len(hits))
+	for idx, hr := range hits {
+		ids[idx] = hr.ID
+	}
+	chunks, err := i.manager.storage.GetChunksByHNSWIds(i.name, ids)
+	if err != nil {
+		return nil, fmt.Errorf("late interaction: %w", err)
+	}
+	timing.Graph += time.Since(start)
+
+	deltas := make(map[uint64]float64, len(hits))
+	for _, hr := range hits {
+		chunk, ok := chunks[hr.ID]
+		if !ok {
+			continue // Deleted since the search; dropped during hydration
+		}
+		vectors := append([][]float32{chunk.Embedding}, chunk.Vectors...)
+		// Scored like the graph's cosine scores, from 0 to 1
+		score := (1 + maxSim(queryVectors, vectors)) / 2
+		deltas[hr.ID] = score - float64(hr.Score)
+	}
+
+	sort.SliceStable(hits, func(a, b int) bool {
+		return float64(hits[a].Score)+deltas[hits[a].ID] > float64(hits[b].Score)+deltas[hits[b].ID]
+	})
+	return deltas, nil
+}
+
+// lateQueryVectors returns the query embedding followed by the embeddings
+// of the query's keywords, for queries of more than one keyword
+func (i *indexImpl) lateQueryVectors(query string, embedding []float32, model string) ([][]float32, error) {
+	vectors := [][]float32{embedding}
+	var terms []string
+	for term := range keywordTerms(query) {
+		terms = append(terms, term)
+	}
+	if len(terms) < 2 {
+		return vectors, nil
+	}
+	sort.Strings(terms)
+	if len(terms) > lateQueryTerms {
+		terms = terms[:lateQueryTerms]
+	}
+
+	// Terms are embedded as queries, for embedders that tell the two apart
+	for _, term := range terms {
+		v, err := i.embedQuery(term, model)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
+
+// maxSim averages, over the query vectors, the best cosine similarity to
+// any of the chunk vectors
+func maxSim(query, chunk [][]float32) float64 {
+	var total float64
+	for _, q := range query {
+		best := -1.0
+		for _, c := range chunk {
+			best = max(best, cosineSimilarity(q, c))
+		}
+		total += best
+	}
+	return total / float64(len(query))
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, 0 if
+// their lengths differ or either is zero
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for d := range a {
+		dot += float64(a[d]) * float64(b[d])
+		normA += float64(a[d]) * float64(a[d])
+		normB += float64(b[d]) * float64(b[d])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// applyLateInteraction changes the score of a hydrated result to its
+// late interaction score
+func applyLateInteraction(result *SearchResult, delta float64) {
+	result.Score += delta
+	if result.Explain != nil {
+		result.Explain.Score = result.Score
+		result.Explain.Adjustments = append(result.Explain.Adjustments, ScoreAdjustment{
+			Stage:  "late_interaction",
+			Delta:  delta,
+			Reason: "MaxSim over the chunk's sub-chunk vectors",
+		})
+	}
+}
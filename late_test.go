@@ -0,0 +1,79 @@
+package hnswindex
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLateWindows(t *testing.T) {
+	assert.Nil(t, lateWindows("one two three", 3))
+	assert.Equal(t, []string{"one two", "three four", "five"}, lateWindows("one two\nthree  four five", 2))
+}
+
+func TestMaxSim(t *testing.T) {
+	query := [][]float32{{1, 0}, {0, 1}}
+	assert.InDelta(t, 1.0, maxSim(query, [][]float32{{2, 0}, {0, 3}}), 1e-9)
+	assert.InDelta(t, 0.5, maxSim(query, [][]float32{{1, 0}}), 1e-9)
+	assert.Zero(t, cosineSimilarity([]float32{1, 0}, []float32{1, 0, 0}))
+}
+
+func TestSearch_LateInteraction(t *testing.T) {
+	cfg := NewConfig()
+	cfg.LateInteraction = true
+	cfg.LateInteractionWords = 5
+	cfg.ChunkSize = 2048
+	manager := newMockManager(t, cfg)
+	e := embedtest.NewSemantic(256)
+	manager.SetEmbedder(e)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	// The answer is one sentence at the end of a long unrelated chunk
+	var filler []string
+	for n := 0; n < 120; n++ {
+		filler = append(filler, fmt.Sprintf("filler%d", n))
+	}
+	needle := strings.Join(filler, " ") + " reset password account"
+	addDocuments(t, index,
+		Document{URI: "needle", Content: needle},
+		Document{URI: "policy", Content: "password policy requires digits"},
+	)
+
+	var vectors int
+	for chunk := range index.Chunks("needle") {
+		vectors += len(chunk.Vectors)
+	}
+	assert.Equal(t, 25, vectors)
+	for chunk := range index.Chunks("policy") {
+		assert.Empty(t, chunk.Vectors) // Fits in one sub-chunk
+	}
+
+	results, err := index.Search("reset password account", 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "policy", results[0].Document.URI)
+
+	results, err = index.SearchWithOptions("reset password account", 2, SearchOptions{
+		Explain:         true,
+		LateInteraction: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "needle", results[0].Document.URI)
+	assert.Greater(t, results[0].Score, results[1].Score)
+	require.NotEmpty(t, results[0].Explain.Adjustments)
+	assert.Equal(t, "late_interaction", results[0].Explain.Adjustments[0].Stage)
+
+	// Updated chunks get new sub-chunk vectors
+	addDocuments(t, index, Document{URI: "needle", Content: needle + " and the username"})
+	vectors = 0
+	for chunk := range index.Chunks("needle") {
+		vectors += len(chunk.Vectors)
+	}
+	assert.Equal(t, 26, vectors)
+}
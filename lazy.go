@@ -0,0 +1,112 @@
+package hnswindex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ContentSource opens a document's content for reading. It may be called
+// more than once while a document is indexed, so it must return the same
+// content each time.
+type ContentSource func() (io.ReadCloser, error)
+
+// FileContent returns a ContentSource that reads the file at path
+func FileContent(path string) ContentSource {
+	return func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}
+}
+
+// StringContent returns a ContentSource that reads s, mainly for tests
+func StringContent(s string) ContentSource {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(s)), nil
+	}
+}
+
+// lazy reports whether a document's content is loaded from its source
+func (d Document) lazy() bool {
+	return d.Content == "" && d.Source != nil
+}
+
+// hashDocument computes the document hash. Lazily loaded content is
+// streamed through the hash, so unchanged documents are never held in
+// memory. The hash equals the one of the same content given as Content.
+func (im *indexManagerImpl) hashDocument(doc Document) (string, error) {
+	if !doc.lazy() {
+		return computeDocumentHash(doc), nil
+	}
+
+	r, err := doc.Source()
+	if err != nil {
+		return "", fmt.Errorf("failed to open content: %w", err)
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	h.Write([]byte(doc.URI))
+	h.Write([]byte(doc.Title))
+	if _, err := io.Copy(h, im.limitContent(r)); err != nil {
+		return "", fmt.Errorf("failed to read content: %w", err)
+	}
+	writeHashTail(h, doc)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadContent reads lazily loaded content into doc.Content. It is called
+// for new and changed documents only, one document at a time.
+func (im *indexManagerImpl) loadContent(doc Document) (Document, error) {
+	if !doc.lazy() {
+		return doc, nil
+	}
+
+	r, err := doc.Source()
+	if err != nil {
+		return doc, fmt.Errorf("failed to open content: %w", err)
+	}
+	defer r.Close()
+
+	var b strings.Builder
+	if _, err := io.Copy(&b, im.limitContent(r)); err != nil {
+		return doc, fmt.Errorf("failed to read content: %w", err)
+	}
+	doc.Content = b.String()
+	doc.Source = nil
+	return doc, nil
+}
+
+// limitContent wraps r to fail once it yields more than
+// Config.MaxContentBytes, so oversized sources are not read to the end
+func (im *indexManagerImpl) limitContent(r io.Reader) io.Reader {
+	if im.config.MaxContentBytes <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, remaining: int64(im.config.MaxContentBytes), limit: im.config.MaxContentBytes}
+}
+
+// limitedReader is io.LimitReader that reports an error instead of EOF
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w: content is larger than %d bytes", ErrLimitExceeded, l.limit)
+	}
+	// Read one byte past the limit to detect oversized content
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w: content is larger than %d bytes", ErrLimitExceeded, l.limit)
+	}
+	return n, err
+}
@@ -0,0 +1,94 @@
+package hnswindex
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingSource counts how often its content is opened
+func countingSource(content string, opens *int) ContentSource {
+	return func() (io.ReadCloser, error) {
+		*opens++
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+}
+
+func TestLazyContent(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("lazy")
+	require.NoError(t, err)
+
+	content := "Streamed content about database failover"
+	eager := Document{URI: "doc", Title: "Doc", Content: content}
+
+	var opens int
+	lazy := Document{URI: "doc", Title: "Doc", Source: countingSource(content, &opens)}
+
+	// Lazy and eager content hash identically
+	hash, err := manager.getImpl().hashDocument(lazy)
+	require.NoError(t, err)
+	assert.Equal(t, computeDocumentHash(eager), hash)
+	opens = 0
+
+	result, err := index.AddDocumentBatch(context.Background(), []Document{lazy}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.NewDocuments)
+	assert.Equal(t, 2, opens) // Hashed, then loaded
+
+	doc, err := index.GetDocument("doc")
+	require.NoError(t, err)
+	assert.Equal(t, content, doc.Content)
+
+	// Unchanged documents are only streamed through the hash
+	opens = 0
+	result, err = index.AddDocumentBatch(context.Background(), []Document{lazy}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.UnchangedDocuments)
+	assert.Equal(t, 1, opens)
+
+	// Switching to eager content does not reindex
+	result, err = index.AddDocumentBatch(context.Background(), []Document{eager}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.UnchangedDocuments)
+}
+
+func TestLazyContent_Files(t *testing.T) {
+	cfg := NewConfig()
+	cfg.MaxContentBytes = 100
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("lazy")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.md")
+	large := filepath.Join(dir, "large.md")
+	require.NoError(t, os.WriteFile(small, []byte("# Small\nA small file"), 0644))
+	require.NoError(t, os.WriteFile(large, []byte(strings.Repeat("x", 101)), 0644))
+
+	docs := []Document{
+		{URI: "small", Title: "Small", Source: FileContent(small)},
+		{URI: "large", Title: "Large", Source: FileContent(large)},
+		{URI: "missing", Title: "Missing", Source: FileContent(filepath.Join(dir, "missing.md"))},
+	}
+	result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, result.FailedURIs, "small")
+	assert.Contains(t, result.FailedURIs["large"], "larger than 100 bytes")
+	assert.Contains(t, result.FailedURIs["missing"], "failed to open content")
+
+	// Group commits load content the same way
+	_, err = manager.Batch(func(tx *ManagerTx) error {
+		return tx.Add("lazy", Document{URI: "batched", Title: "Batched", Source: StringContent("Batched content")})
+	})
+	require.NoError(t, err)
+	doc, err := index.GetDocument("batched")
+	require.NoError(t, err)
+	assert.Equal(t, "Batched content", doc.Content)
+}
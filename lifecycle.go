@@ -0,0 +1,155 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// Close flushes the index's graph to disk and releases it from memory,
+// without affecting other indexes. The index stays stored and opens again
+// on next use, through this Index or GetIndex. See IndexManager.CloseIndex.
+func (i *Index) Close() error {
+	if mgr := i.manager.getImpl(); mgr != nil {
+		return mgr.CloseIndex(i.name)
+	}
+
+	return fmt.Errorf("implementation not available")
+}
+
+// CloseIndex flushes the named index's graph to disk and releases it from
+// memory, for tools that work through many indexes one at a time. It waits
+// for writes to the index to finish. The index opens again, loading its
+// graph from disk, the next time it is used through an Index or GetIndex.
+// While closed it is still listed, but left out of Diagnostics and
+// RouteQuery. Closing a closed index does nothing.
+func (im *IndexManager) CloseIndex(name string) error {
+	if impl := im.getImpl(); impl != nil {
+		return impl.CloseIndex(name)
+	}
+
+	return fmt.Errorf("implementation not available")
+}
+
+// CloseIndex implementation
+func (im *indexManagerImpl) CloseIndex(name string) error {
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
+
+	impl, exists := im.indexes.get(name)
+	if !exists {
+		if im.storedIndex(name) {
+			return nil
+		}
+		return fmt.Errorf("index '%s' not found", name)
+	}
+	if _, rebuilding := im.indexes.get(rebuildIndexName(name)); rebuilding {
+		return fmt.Errorf("index '%s' is being rebuilt", name)
+	}
+
+	// Wait for writes to this index to finish and refuse new ones
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	if err := impl.saveGraph(); err != nil {
+		return err
+	}
+
+	impl.deleted = true
+	im.indexes.remove(name)
+	if err := impl.hnswIndex.Close(); err != nil {
+		slog.Warn("Failed to close HNSW index",
+			"index", name,
+			"error", err,
+		)
+	}
+
+	slog.Info("Index closed", "index", name)
+	return nil
+}
+
+// openIndex returns the named index, loading the graph of a closed one
+func (im *indexManagerImpl) openIndex(name string) (*indexImpl, error) {
+	if impl, exists := im.indexes.get(name); exists {
+		return impl, nil
+	}
+
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
+	if impl, exists := im.indexes.get(name); exists {
+		return impl, nil
+	}
+	if !im.storedIndex(name) {
+		return nil, fmt.Errorf("index '%s' not found", name)
+	}
+
+	impl, err := im.loadIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	im.indexes.put(impl)
+	slog.Info("Index opened", "index", name)
+	return impl, nil
+}
+
+// storedIndex reports whether an index other than a rebuild's staging
+// index exists in storage, open or closed
+func (im *indexManagerImpl) storedIndex(name string) bool {
+	if isRebuildIndex(name) {
+		return false
+	}
+	names, err := im.storage.ListIndexes()
+	return err == nil && slices.Contains(names, name)
+}
+
+// SaveAll flushes every open index's graph that has unsaved changes to disk
+// and syncs the databases, for planned shutdowns and for deployments that
+// turn AutoSave off. It stops at the first failure or when ctx is done.
+func (im *IndexManager) SaveAll(ctx context.Context) error {
+	if impl := im.getImpl(); impl != nil {
+		return impl.SaveAll(ctx)
+	}
+
+	return fmt.Errorf("implementation not available")
+}
+
+// SaveAll implementation
+func (im *indexManagerImpl) SaveAll(ctx context.Context) error {
+	for _, idx := range im.indexes.all() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := idx.save(); err != nil {
+			return err
+		}
+	}
+	return im.storage.Sync()
+}
+
+// save saves the graph for SaveAll, holding off DeleteIndex and
+// CloseIndex meanwhile. An index already deleted or closed needs no saving.
+func (i *indexImpl) save() error {
+	release, err := i.acquire()
+	if err != nil {
+		return nil
+	}
+	defer release()
+	return i.saveGraph()
+}
+
+// saveGraph saves the graph if it has unsaved changes
+func (i *indexImpl) saveGraph() error {
+	if !i.hnswIndex.IsModified() {
+		return nil
+	}
+	err := i.manager.injectFault(FaultSave, i.name)
+	if err == nil {
+		err = i.hnswIndex.Save()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save HNSW index '%s': %w", i.name, err)
+	}
+	i.manager.indexSaved(i.name)
+	return nil
+}
@@ -0,0 +1,133 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseIndex(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AutoSave = false
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = manager.CreateIndex("other")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), snapshotDocs, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, index.Close())
+	_, open := manager.getImpl().indexes.get("kb")
+	assert.False(t, open)
+	_, open = manager.getImpl().indexes.get("other")
+	assert.True(t, open, "other indexes stay open")
+	require.NoError(t, manager.CloseIndex("kb"), "closing a closed index does nothing")
+
+	names, err := manager.ListIndexes()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"kb", "other"}, names)
+
+	// The unsaved graph was flushed on close and is loaded on next use
+	results, err := index.Search("First document content", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc1", results[0].Document.URI)
+	_, open = manager.getImpl().indexes.get("kb")
+	assert.True(t, open)
+
+	require.NoError(t, manager.CloseIndex("other"))
+	reopened, err := manager.GetIndex("other")
+	require.NoError(t, err)
+	_, err = reopened.AddDocumentBatch(context.Background(), snapshotDocs, nil)
+	require.NoError(t, err)
+
+	assert.Error(t, manager.CloseIndex("missing"))
+}
+
+func TestCloseIndex_EmbedderDimension(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager, err := NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+
+	manager.SetEmbedder(embedtest.NewSemantic(64))
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "password", Content: "Reset your password from the account settings page."},
+		Document{URI: "revenue", Content: "Quarterly revenue grew in every region."},
+	)
+
+	// The graph is reloaded with the dimension of the recorded embedder
+	require.NoError(t, index.Close())
+	results, err := index.Search("how do I reset my password", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "password", results[0].Document.URI)
+}
+
+func TestCloseIndex_Delete(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), snapshotDocs, nil)
+	require.NoError(t, err)
+	require.NoError(t, index.Close())
+
+	require.NoError(t, manager.DeleteIndex("kb"))
+	_, err = manager.GetIndex("kb")
+	assert.Error(t, err)
+	assert.Error(t, manager.DeleteIndex("kb"))
+}
+
+func TestCloseIndex_Snapshot(t *testing.T) {
+	store := NewDirStore(t.TempDir())
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), snapshotDocs, nil)
+	require.NoError(t, err)
+	require.NoError(t, index.Close())
+	require.NoError(t, manager.UploadSnapshot(context.Background(), store, DefaultSnapshotKey))
+
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.SnapshotStore = store
+	restored := newMockManager(t, cfg)
+	index, err = restored.GetIndex("kb")
+	require.NoError(t, err)
+	results, err := index.Search("Second document content", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc2", results[0].Document.URI)
+}
+
+func TestSaveAll(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AutoSave = false
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), snapshotDocs, nil)
+	require.NoError(t, err)
+
+	diag, err := manager.Diagnostics()
+	require.NoError(t, err)
+	require.Len(t, diag.Indexes, 1)
+	assert.True(t, diag.Indexes[0].Unsaved)
+
+	require.NoError(t, manager.SaveAll(context.Background()))
+	diag, err = manager.Diagnostics()
+	require.NoError(t, err)
+	assert.False(t, diag.Indexes[0].Unsaved)
+	assert.False(t, diag.Indexes[0].LastSavedAt.IsZero())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, manager.SaveAll(ctx), context.Canceled)
+}
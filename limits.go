@@ -0,0 +1,198 @@
+package hnswindex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// ErrLimitExceeded is returned when a document or batch exceeds one of the
+// Config limits (MaxBatchDocuments, MaxChunksPerDocument, MaxContentBytes
+// and the metadata limits)
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Values of Config.MetadataOverflow
+const (
+	MetadataOverflowReject   = "reject"
+	MetadataOverflowTruncate = "truncate"
+)
+
+// checkContentSize rejects documents whose content or binary data is larger
+// than Config.MaxContentBytes
+func (im *indexManagerImpl) checkContentSize(doc Document) error {
+	limit := im.config.MaxContentBytes
+	if limit <= 0 {
+		return nil
+	}
+	if len(doc.Content) > limit {
+		return fmt.Errorf("%w: content is %d bytes, maximum is %d", ErrLimitExceeded, len(doc.Content), limit)
+	}
+	if len(doc.Data) > limit {
+		return fmt.Errorf("%w: data is %d bytes, maximum is %d", ErrLimitExceeded, len(doc.Data), limit)
+	}
+	return nil
+}
+
+// limitMetadata enforces the metadata limits of the configuration. With
+// MetadataOverflowTruncate it returns the document with long string values
+// cut, leaving the caller's metadata untouched.
+func (im *indexManagerImpl) limitMetadata(doc Document) (Document, error) {
+	cfg := im.config
+	if len(doc.Metadata) == 0 || cfg.MaxMetadataBytes <= 0 && cfg.MaxMetadataDepth <= 0 && cfg.MaxMetadataValueBytes <= 0 {
+		return doc, nil
+	}
+
+	limited := make(map[string]interface{}, len(doc.Metadata))
+	for key, value := range doc.Metadata {
+		v, err := im.limitMetadataValue(doc.URI, key, value, 1)
+		if err != nil {
+			return doc, err
+		}
+		limited[key] = v
+	}
+	doc.Metadata = limited
+
+	if cfg.MaxMetadataBytes > 0 {
+		data, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return doc, fmt.Errorf("invalid metadata: %w", err)
+		}
+		if len(data) > cfg.MaxMetadataBytes {
+			return doc, fmt.Errorf("%w: metadata is %d bytes, maximum is %d", ErrLimitExceeded, len(data), cfg.MaxMetadataBytes)
+		}
+	}
+	return doc, nil
+}
+
+// limitMetadataValue checks a metadata value at path and depth, returning
+// it with its containers copied and long strings cut if truncating
+func (im *indexManagerImpl) limitMetadataValue(uri, path string, value interface{}, depth int) (interface{}, error) {
+	cfg := im.config
+	nested := func() error {
+		if cfg.MaxMetadataDepth > 0 && depth+1 > cfg.MaxMetadataDepth {
+			return fmt.Errorf("%w: metadata field %q nests deeper than %d levels", ErrLimitExceeded, path, cfg.MaxMetadataDepth)
+		}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		limit := cfg.MaxMetadataValueBytes
+		if limit <= 0 || len(v) <= limit {
+			return v, nil
+		}
+		if cfg.MetadataOverflow != MetadataOverflowTruncate {
+			return nil, fmt.Errorf("%w: metadata field %q is %d bytes, maximum is %d", ErrLimitExceeded, path, len(v), limit)
+		}
+		slog.Warn("Metadata value truncated",
+			"uri", uri,
+			"field", path,
+			"bytes", len(v),
+			"maximum", limit,
+		)
+		return truncateBytes(v, limit), nil
+	case map[string]interface{}:
+		if err := nested(); err != nil {
+			return nil, err
+		}
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			limited, err := im.limitMetadataValue(uri, path+"."+key, item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = limited
+		}
+		return out, nil
+	case []interface{}:
+		if err := nested(); err != nil {
+			return nil, err
+		}
+		out := make([]interface{}, len(v))
+		for idx, item := range v {
+			limited, err := im.limitMetadataValue(uri, fmt.Sprintf("%s[%d]", path, idx), item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[idx] = limited
+		}
+		return out, nil
+	case []string:
+		if err := nested(); err != nil {
+			return nil, err
+		}
+		out := make([]string, len(v))
+		for idx, item := range v {
+			limited, err := im.limitMetadataValue(uri, fmt.Sprintf("%s[%d]", path, idx), item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[idx] = limited.(string)
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// truncateBytes cuts s to at most n bytes without splitting a character
+func truncateBytes(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// checkChunkCount rejects documents that chunk into more than
+// Config.MaxChunksPerDocument chunks
+func (im *indexManagerImpl) checkChunkCount(chunks int) error {
+	limit := im.config.MaxChunksPerDocument
+	if limit > 0 && chunks > limit {
+		return fmt.Errorf("%w: document has %d chunks, maximum is %d", ErrLimitExceeded, chunks, limit)
+	}
+	return nil
+}
+
+// addInBatches splits docs into batches of at most Config.MaxBatchDocuments
+// and adds them one after another, so memory use is bounded by the batch
+// size rather than the number of documents passed in
+func (i *indexImpl) addInBatches(ctx context.Context, docs []Document, progress chan<- ProgressUpdate, options AddOptions) (*BatchResult, error) {
+	size := i.manager.config.MaxBatchDocuments
+	total := &BatchResult{
+		TotalDocuments: len(docs),
+		FailedURIs:     make(map[string]string),
+	}
+
+	for start := 0; start < len(docs); start += size {
+		end := min(start+size, len(docs))
+		slog.Info("Processing batch slice",
+			"index", i.name,
+			"from", start,
+			"to", end,
+			"total", len(docs),
+		)
+
+		result, err := i.addDocumentBatch(ctx, docs[start:end], progress, options)
+		if result != nil {
+			total.NewDocuments += result.NewDocuments
+			total.UpdatedDocuments += result.UpdatedDocuments
+			total.UnchangedDocuments += result.UnchangedDocuments
+			total.ProcessedChunks += result.ProcessedChunks
+			total.DurationTotal += result.DurationTotal
+			total.DurationEmbedding += result.DurationEmbedding
+			total.DurationStorage += result.DurationStorage
+			total.TokensEmbedded += result.TokensEmbedded
+			total.throughput()
+			for uri, msg := range result.FailedURIs {
+				total.FailedURIs[uri] = msg
+			}
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
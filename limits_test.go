@@ -0,0 +1,122 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits_SplitsLargeBatches(t *testing.T) {
+	cfg := NewConfig()
+	cfg.MaxBatchDocuments = 3
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("limits")
+	require.NoError(t, err)
+
+	var docs []Document
+	for n := range 8 {
+		docs = append(docs, Document{
+			URI:     fmt.Sprintf("doc%d", n),
+			Title:   fmt.Sprintf("Doc %d", n),
+			Content: fmt.Sprintf("Content of document %d", n),
+		})
+	}
+
+	result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 8, result.TotalDocuments)
+	assert.Equal(t, 8, result.NewDocuments)
+	assert.Empty(t, result.FailedURIs)
+
+	stats, err := index.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 8, stats.DocumentCount)
+
+	// Group commits cannot be split
+	_, err = manager.Batch(func(tx *ManagerTx) error {
+		return tx.Add("limits", docs...)
+	})
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+}
+
+func TestLimits_RejectsOversizedDocuments(t *testing.T) {
+	cfg := NewConfig()
+	cfg.MaxContentBytes = 1000
+	cfg.MaxChunksPerDocument = 2
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 0
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("limits")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "small", Title: "Small", Content: "A short document"},
+		{URI: "huge", Title: "Huge", Content: strings.Repeat("x", 1001)},
+		{URI: "long", Title: "Long", Content: strings.Repeat("many words in a long document ", 30)},
+	}
+	result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.FailedURIs["huge"], "content is 1001 bytes")
+	assert.Contains(t, result.FailedURIs["long"], "chunks, maximum is 2")
+	assert.NotContains(t, result.FailedURIs, "small")
+
+	// Rejected documents are not stored
+	_, err = index.GetDocument("long")
+	assert.Error(t, err)
+
+	results, err := manager.Batch(func(tx *ManagerTx) error {
+		return tx.Add("limits", docs[1:]...)
+	})
+	require.NoError(t, err)
+	assert.Len(t, results["limits"].FailedURIs, 2)
+}
+
+func TestLimits_Metadata(t *testing.T) {
+	cfg := NewConfig()
+	cfg.MaxMetadataBytes = 2000
+	cfg.MaxMetadataDepth = 2
+	cfg.MaxMetadataValueBytes = 100
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("limits")
+	require.NoError(t, err)
+
+	html := strings.Repeat("<p>é</p>", 50)
+	docs := []Document{
+		{URI: "ok", Content: "Fine", Metadata: map[string]interface{}{"tags": []interface{}{"a", "b"}}},
+		{URI: "html", Content: "Page", Metadata: map[string]interface{}{"page": map[string]interface{}{"body": html}}},
+		{URI: "deep", Content: "Deep", Metadata: map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{"c"}}}},
+		{URI: "big", Content: "Big", Metadata: map[string]interface{}{"list": []string{strings.Repeat("x", 90), strings.Repeat("y", 90)}}},
+	}
+	for n := 0; n < 30; n++ {
+		docs[3].Metadata[fmt.Sprintf("field%d", n)] = strings.Repeat("z", 90)
+	}
+	result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, result.FailedURIs, "ok")
+	assert.Contains(t, result.FailedURIs["html"], `metadata field "page.body" is 450 bytes, maximum is 100`)
+	assert.Contains(t, result.FailedURIs["deep"], `metadata field "a.b" nests deeper than 2 levels`)
+	assert.Contains(t, result.FailedURIs["big"], "maximum is 2000")
+
+	// Truncating keeps the document, with the value cut on a character boundary
+	manager.getImpl().config.MetadataOverflow = MetadataOverflowTruncate
+	result, err = index.AddDocumentBatch(context.Background(), docs[1:2], nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.FailedURIs)
+	doc, err := index.GetDocument("html")
+	require.NoError(t, err)
+	body := doc.Metadata["page"].(map[string]interface{})["body"].(string)
+	assert.LessOrEqual(t, len(body), 100)
+	assert.True(t, utf8.ValidString(body))
+	assert.True(t, strings.HasPrefix(html, body))
+	assert.Len(t, docs[1].Metadata["page"].(map[string]interface{})["body"], len(html))
+
+	// Unchanged on the next sync
+	result, err = index.AddDocumentBatch(context.Background(), docs[1:2], nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.UnchangedDocuments)
+}
@@ -0,0 +1,365 @@
+package hnswindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/indexer"
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// DefaultExpiresKeys are the metadata keys checked, in order, for the time
+// a document expires
+var DefaultExpiresKeys = []string{"expires_at", "expires", "expiry_date", "valid_until"}
+
+// DefaultCompactRatio is the share of a graph's vectors that may be
+// tombstones before maintenance compacts it
+const DefaultCompactRatio = 0.2
+
+// VerifyReport is the result of checking an index's storage against its
+// graph
+type VerifyReport struct {
+	Documents      int      // Documents stored
+	Chunks         int      // Chunks stored
+	GraphNodes     int      // Vectors in the graph, without tombstones
+	Tombstones     int      // Deleted vectors still in the graph
+	MissingVectors []string // Chunks, by ID, whose vector is not in the graph
+	OrphanChunks   []string // Chunks, by ID, whose document is not stored
+	OrphanVectors  int      // Vectors in the graph without a stored chunk
+	Repaired       bool     // The problems found were repaired
+}
+
+// OK reports whether the index is consistent
+func (r *VerifyReport) OK() bool {
+	return len(r.MissingVectors) == 0 && len(r.OrphanChunks) == 0 && r.OrphanVectors == 0
+}
+
+// MaintenanceOptions configures IndexManager.RunMaintenance
+type MaintenanceOptions struct {
+	// Repair repairs what Verify finds: chunks of missing documents are
+	// deleted and the graph is rebuilt from the stored embeddings
+	Repair bool
+
+	// PruneExpired deletes documents whose expiry time, under one of
+	// ExpiresKeys (default DefaultExpiresKeys), has passed
+	PruneExpired bool
+	ExpiresKeys  []string
+
+	// CompactRatio compacts graphs whose tombstones exceed this share of
+	// their vectors (default DefaultCompactRatio); negative never compacts
+	CompactRatio float64
+}
+
+// MaintenanceReport is the result of maintaining one index
+type MaintenanceReport struct {
+	Index     string
+	Started   time.Time
+	Duration  time.Duration
+	Verify    *VerifyReport
+	Expired   []string // URIs of the expired documents deleted
+	Compacted int      // Tombstones dropped by compacting the graph
+	Error     string   // Why maintenance of the index stopped early
+}
+
+// Verify checks that every stored chunk has its vector in the graph and a
+// stored document, and that the graph has no vectors without a chunk. With
+// repair, chunks of missing documents are deleted and the graph is rebuilt
+// from the stored embeddings; chunks stored without an embedding stay
+// missing until their document is indexed again. Writes to the index wait
+// while it runs.
+func (i *Index) Verify(repair bool) (*VerifyReport, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Verify(repair)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Compact rebuilds the graph from the stored embeddings, dropping the
+// tombstones deleted vectors leave behind, and returns how many it
+// dropped. Searches continue on the old graph meanwhile; writes wait.
+func (i *Index) Compact() (int, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Compact()
+	}
+	return 0, fmt.Errorf("implementation not available")
+}
+
+// PruneExpired deletes the documents whose expiry time, under the first of
+// keys present in their metadata (DefaultExpiresKeys if nil), has passed,
+// and returns their URIs
+func (i *Index) PruneExpired(keys []string) ([]string, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.PruneExpired(keys)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// RunMaintenance verifies every index, prunes expired documents and
+// compacts graphs with many tombstones, as configured by opts. It reports
+// each index to the OnMaintenance handlers and returns the reports, with
+// the errors of indexes whose maintenance stopped early.
+// Config.MaintenanceInterval runs it in the background.
+func (im *IndexManager) RunMaintenance(ctx context.Context, opts MaintenanceOptions) ([]MaintenanceReport, error) {
+	if impl := im.getImpl(); impl != nil {
+		return impl.RunMaintenance(ctx, opts)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Verify implementation
+func (i *indexImpl) Verify(repair bool) (*VerifyReport, error) {
+	release, err := i.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	i.commitMu.Lock()
+	defer i.commitMu.Unlock()
+
+	report, err := i.verify()
+	if err != nil || !repair || report.OK() {
+		return report, err
+	}
+	if err := i.writable(); err != nil {
+		return report, err
+	}
+
+	if err := i.manager.storage.DeleteChunks(i.name, report.OrphanChunks); err != nil {
+		return report, fmt.Errorf("failed to delete chunks of missing documents: %w", err)
+	}
+	if _, err := i.compact(); err != nil {
+		return report, err
+	}
+	report.Repaired = true
+
+	slog.Info("Index repaired",
+		"index", i.name,
+		"missing_vectors", len(report.MissingVectors),
+		"orphan_chunks", len(report.OrphanChunks),
+		"orphan_vectors", report.OrphanVectors,
+	)
+	return report, nil
+}
+
+// verify compares storage with the graph. The caller holds commitMu.
+func (i *indexImpl) verify() (*VerifyReport, error) {
+	uris, err := i.manager.storage.ListDocuments(i.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	documents := make(map[string]bool, len(uris))
+	for _, uri := range uris {
+		documents[uri] = true
+	}
+
+	report := &VerifyReport{
+		Documents:  len(uris),
+		GraphNodes: i.hnswIndex.Size(),
+		Tombstones: i.hnswIndex.Tombstones(),
+	}
+	inGraph := 0
+	err = i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		report.Chunks++
+		if !documents[chunk.DocumentURI] {
+			report.OrphanChunks = append(report.OrphanChunks, chunk.ID)
+		}
+		if chunk.HNSWId == 0 {
+			return nil // Never added to the graph
+		}
+		if _, ok := i.hnswIndex.Lookup(chunk.HNSWId); ok {
+			inGraph++
+		} else {
+			report.MissingVectors = append(report.MissingVectors, chunk.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunks: %w", err)
+	}
+	report.OrphanVectors = report.GraphNodes - inGraph
+	return report, nil
+}
+
+// Compact implementation
+func (i *indexImpl) Compact() (int, error) {
+	release, err := i.acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	if err := i.writable(); err != nil {
+		return 0, err
+	}
+	i.commitMu.Lock()
+	defer i.commitMu.Unlock()
+	return i.compact()
+}
+
+// compact rebuilds the graph from the stored embeddings and returns the
+// number of tombstones dropped. The caller holds the index, through
+// acquire, and commitMu.
+func (i *indexImpl) compact() (int, error) {
+	start := time.Now()
+	tombstones := i.hnswIndex.Tombstones()
+	graph, err := indexer.NewHNSWIndex("", i.hnswIndex.Dimension(), i.hnswIndex.Config())
+	if err != nil {
+		return 0, err
+	}
+	if err := i.fillGraph(graph, i.reduction); err != nil {
+		return 0, err
+	}
+	if err := i.hnswIndex.ReplaceGraph(graph); err != nil {
+		return 0, fmt.Errorf("failed to replace graph: %w", err)
+	}
+
+	slog.Info("Index graph compacted",
+		"index", i.name,
+		"tombstones", tombstones,
+		"nodes", graph.Size(),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return tombstones, nil
+}
+
+// needsCompaction reports whether tombstones exceed ratio of the graph's
+// vectors
+func (i *indexImpl) needsCompaction(ratio float64) bool {
+	if ratio < 0 {
+		return false
+	}
+	tombstones := i.hnswIndex.Tombstones()
+	total := i.hnswIndex.Size() + tombstones
+	return tombstones > 0 && float64(tombstones) > ratio*float64(total)
+}
+
+// PruneExpired implementation
+func (i *indexImpl) PruneExpired(keys []string) ([]string, error) {
+	if keys == nil {
+		keys = DefaultExpiresKeys
+	}
+
+	now := time.Now()
+	var expired []string
+	err := forEachStoredDocument(i.manager.storage, i.name, func(stored *storage.Document) error {
+		doc := Document{URI: stored.URI, Metadata: stored.Metadata}
+		if expires, ok := metadataTime(doc, keys); ok && expires.Before(now) {
+			expired = append(expired, stored.URI)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	pruned := make([]string, 0, len(expired))
+	for _, uri := range expired {
+		if err := i.DeleteDocument(uri); err != nil {
+			return pruned, fmt.Errorf("failed to prune %s: %w", uri, err)
+		}
+		pruned = append(pruned, uri)
+	}
+	if len(pruned) > 0 {
+		slog.Info("Expired documents pruned",
+			"index", i.name,
+			"pruned", len(pruned),
+		)
+	}
+	return pruned, nil
+}
+
+// RunMaintenance implementation
+func (im *indexManagerImpl) RunMaintenance(ctx context.Context, opts MaintenanceOptions) ([]MaintenanceReport, error) {
+	if im.readOnly != nil {
+		return nil, ErrReadOnly
+	}
+	if opts.CompactRatio == 0 {
+		opts.CompactRatio = DefaultCompactRatio
+	}
+
+	var reports []MaintenanceReport
+	var errs []error
+	for _, idx := range im.indexes.all() {
+		if isRebuildIndex(idx.name) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return reports, err
+		}
+
+		report := idx.maintain(opts)
+		if report.Error != "" {
+			errs = append(errs, fmt.Errorf("index '%s': %s", idx.name, report.Error))
+		}
+		im.emitMaintenance(report)
+		reports = append(reports, report)
+	}
+	return reports, errors.Join(errs...)
+}
+
+// maintain runs the maintenance of opts on the index
+func (i *indexImpl) maintain(opts MaintenanceOptions) MaintenanceReport {
+	report := MaintenanceReport{Index: i.name, Started: time.Now()}
+	defer func() { report.Duration = time.Since(report.Started) }()
+
+	var err error
+	if report.Verify, err = i.Verify(opts.Repair); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	if opts.PruneExpired && i.sealed.Load() == nil {
+		if report.Expired, err = i.PruneExpired(opts.ExpiresKeys); err != nil {
+			report.Error = err.Error()
+			return report
+		}
+	}
+	if i.needsCompaction(opts.CompactRatio) {
+		if report.Compacted, err = i.Compact(); err != nil {
+			report.Error = err.Error()
+		}
+	}
+	return report
+}
+
+// maintenanceLoop runs RunMaintenance at Config.MaintenanceInterval until
+// the manager is closed
+type maintenanceLoop struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// startMaintenance starts the background maintenance loop
+func (im *indexManagerImpl) startMaintenance(interval time.Duration) *maintenanceLoop {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &maintenanceLoop{cancel: cancel}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			_, err := im.RunMaintenance(ctx, MaintenanceOptions{Repair: true, PruneExpired: true})
+			if err != nil && !errors.Is(err, context.Canceled) {
+				slog.Warn("Maintenance failed",
+					"error", err,
+				)
+			}
+		}
+	}()
+	return l
+}
+
+// close stops the loop and waits for a running maintenance to finish its
+// current index
+func (l *maintenanceLoop) close() {
+	l.cancel()
+	l.wg.Wait()
+}
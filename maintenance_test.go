@@ -0,0 +1,182 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_VerifyAndRepair(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "doc1", Title: "One", Content: "First document content"},
+		Document{URI: "doc2", Title: "Two", Content: "Second document content"},
+		Document{URI: "doc3", Title: "Three", Content: "Third document content"},
+	)
+
+	report, err := index.Verify(false)
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, 3, report.Documents)
+	assert.Equal(t, 3, report.Chunks)
+	assert.Equal(t, 3, report.GraphNodes)
+
+	// Break the index: a vector lost from the graph, one without a chunk,
+	// and a chunk whose document is gone
+	impl := manager.getImpl()
+	idx, ok := impl.indexes.get("kb")
+	require.True(t, ok)
+	chunks, err := impl.storage.GetChunksByDocument("kb", "doc1")
+	require.NoError(t, err)
+	require.NoError(t, idx.hnswIndex.Delete(chunks[0].HNSWId))
+	require.NoError(t, idx.hnswIndex.Add(make768(0.5), 9999))
+	require.NoError(t, impl.storage.DeleteDocument("kb", "doc2"))
+
+	report, err = index.Verify(false)
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, []string{chunks[0].ID}, report.MissingVectors)
+	assert.Len(t, report.OrphanChunks, 1)
+	assert.Equal(t, 1, report.OrphanVectors)
+	assert.False(t, report.Repaired)
+
+	report, err = index.Verify(true)
+	require.NoError(t, err)
+	assert.True(t, report.Repaired)
+
+	report, err = index.Verify(false)
+	require.NoError(t, err)
+	assert.True(t, report.OK(), "%+v", report)
+	assert.Equal(t, 2, report.Chunks)
+	assert.Equal(t, 2, report.GraphNodes)
+	assert.Zero(t, report.Tombstones)
+
+	results, err := index.Search("First document content", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc1", results[0].Document.URI)
+}
+
+// make768 returns a 768-dimensional vector with every value set to v
+func make768(v float32) []float32 {
+	vector := make([]float32, 768)
+	for n := range vector {
+		vector[n] = v
+	}
+	return vector
+}
+
+func TestIndex_Compact(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "doc1", Title: "One", Content: "First document content"},
+		Document{URI: "doc2", Title: "Two", Content: "Second document content"},
+	)
+	require.NoError(t, index.DeleteDocument("doc2"))
+
+	idx, ok := manager.getImpl().indexes.get("kb")
+	require.True(t, ok)
+	assert.Equal(t, 1, idx.hnswIndex.Tombstones())
+	assert.True(t, idx.needsCompaction(DefaultCompactRatio))
+
+	dropped, err := index.Compact()
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	assert.Zero(t, idx.hnswIndex.Tombstones())
+	assert.Equal(t, 1, idx.hnswIndex.Size())
+
+	results, err := index.Search("First document content", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doc1"}, resultURIs(results))
+
+	// A deleted index is neither compacted nor verified
+	require.NoError(t, manager.DeleteIndex("kb"))
+	_, err = idx.Compact()
+	assert.ErrorContains(t, err, "not found")
+	_, err = idx.Verify(true)
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestIndex_PruneExpired(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "expired", Title: "Old offer", Content: "Spring sale",
+			Metadata: map[string]interface{}{"expires_at": time.Now().Add(-time.Hour).Format(time.RFC3339)}},
+		Document{URI: "current", Title: "New offer", Content: "Summer sale",
+			Metadata: map[string]interface{}{"valid_until": time.Now().Add(time.Hour).Format(time.RFC3339)}},
+		Document{URI: "forever", Title: "Terms", Content: "Terms of sale"},
+	)
+
+	pruned, err := index.PruneExpired(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"expired"}, pruned)
+	_, err = index.GetDocument("expired")
+	assert.Error(t, err)
+	_, err = index.GetDocument("current")
+	assert.NoError(t, err)
+}
+
+func TestRunMaintenance(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "expired", Title: "Old offer", Content: "Spring sale",
+			Metadata: map[string]interface{}{"expires": time.Now().Add(-time.Hour).Format(time.RFC3339)}},
+		Document{URI: "current", Title: "New offer", Content: "Summer sale"},
+	)
+	rec := &eventRecorder{}
+	manager.Subscribe(rec)
+
+	reports, err := manager.RunMaintenance(context.Background(), MaintenanceOptions{Repair: true, PruneExpired: true})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	report := reports[0]
+	assert.Equal(t, "kb", report.Index)
+	assert.True(t, report.Verify.OK())
+	assert.Equal(t, []string{"expired"}, report.Expired)
+	assert.Equal(t, 1, report.Compacted, "the expired document's tombstone is half the graph")
+	assert.Empty(t, report.Error)
+
+	reports, err = manager.RunMaintenance(context.Background(), MaintenanceOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, reports[0].Compacted)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	require.Len(t, rec.reports, 2)
+	assert.Equal(t, []string{"expired"}, rec.reports[0].Expired)
+}
+
+func TestMaintenanceInterval(t *testing.T) {
+	cfg := NewConfig()
+	cfg.MaintenanceInterval = 10 * time.Millisecond
+	manager := newMockManager(t, cfg)
+	_, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	reports := make(chan MaintenanceReport, 16)
+	manager.Subscribe(EventHandlerFuncs{Maintenance: func(r MaintenanceReport) {
+		select {
+		case reports <- r:
+		default:
+		}
+	}})
+
+	select {
+	case report := <-reports:
+		assert.Equal(t, "kb", report.Index)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no maintenance report")
+	}
+	require.NoError(t, manager.Close())
+}
@@ -0,0 +1,169 @@
+package hnswindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// GetMeta returns the metadata value stored under key converted to T.
+// Numbers are converted between numeric types (metadata read back from
+// storage holds JSON numbers as float64), RFC3339 strings convert to
+// time.Time, and []interface{} converts to typed slices. The second return
+// value is false if the key is missing or cannot be converted.
+func GetMeta[T any](doc Document, key string) (T, bool) {
+	var zero T
+	raw, ok := doc.Metadata[key]
+	if !ok || raw == nil {
+		return zero, false
+	}
+
+	// Fast path: the stored value already has the requested type
+	if v, ok := raw.(T); ok {
+		return v, true
+	}
+
+	var out T
+	if !convertMeta(raw, &out) {
+		return zero, false
+	}
+	return out, true
+}
+
+// GetMetaOr returns the metadata value under key, or def if it is missing
+// or has an incompatible type
+func GetMetaOr[T any](doc Document, key string, def T) T {
+	if v, ok := GetMeta[T](doc, key); ok {
+		return v
+	}
+	return def
+}
+
+// BindMetadata decodes the document metadata into the struct pointed to by v.
+// Fields are matched using their `json` struct tags, so the same struct can
+// be used with MetadataFrom when building documents.
+func (d Document) BindMetadata(v any) error {
+	data, err := json.Marshal(d.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to bind metadata: %w", err)
+	}
+	return nil
+}
+
+// MetadataFrom encodes a struct into a metadata map using its `json` tags.
+// Values are normalized the same way they are after a storage round trip.
+func MetadataFrom(v any) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("metadata must encode to a JSON object: %w", err)
+	}
+	return metadata, nil
+}
+
+// convertMeta converts a raw metadata value into the value pointed to by out
+func convertMeta(raw interface{}, out interface{}) bool {
+	switch o := out.(type) {
+	case *string:
+		if s, ok := raw.(fmt.Stringer); ok {
+			*o = s.String()
+			return true
+		}
+		return false
+	case *int:
+		f, ok := toFloat(raw)
+		if !ok || f != math.Trunc(f) {
+			return false
+		}
+		*o = int(f)
+		return true
+	case *int64:
+		f, ok := toFloat(raw)
+		if !ok || f != math.Trunc(f) {
+			return false
+		}
+		*o = int64(f)
+		return true
+	case *uint64:
+		f, ok := toFloat(raw)
+		if !ok || f < 0 || f != math.Trunc(f) {
+			return false
+		}
+		*o = uint64(f)
+		return true
+	case *float64:
+		f, ok := toFloat(raw)
+		*o = f
+		return ok
+	case *float32:
+		f, ok := toFloat(raw)
+		*o = float32(f)
+		return ok
+	case *time.Time:
+		s, ok := raw.(string)
+		if !ok {
+			return false
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return false
+		}
+		*o = t
+		return true
+	case *[]string:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return false
+		}
+		list := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return false
+			}
+			list = append(list, s)
+		}
+		*o = list
+		return true
+	}
+
+	// Fall back to a JSON round trip for structs, maps, and other slices
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, out) == nil
+}
+
+// toFloat converts any numeric metadata value to float64
+func toFloat(raw interface{}) (float64, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
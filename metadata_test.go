@@ -0,0 +1,86 @@
+package hnswindex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMeta(t *testing.T) {
+	doc := Document{
+		Metadata: map[string]interface{}{
+			"author":   "alice",
+			"size":     float64(1024), // as decoded from storage
+			"count":    3,
+			"ratio":    0.5,
+			"draft":    true,
+			"modified": "2024-01-02T03:04:05Z",
+			"tags":     []interface{}{"ops", "runbook"},
+		},
+	}
+
+	author, ok := GetMeta[string](doc, "author")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", author)
+
+	size, ok := GetMeta[int](doc, "size")
+	assert.True(t, ok)
+	assert.Equal(t, 1024, size)
+
+	count, ok := GetMeta[float64](doc, "count")
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, count)
+
+	_, ok = GetMeta[int](doc, "ratio")
+	assert.False(t, ok, "fractional numbers must not truncate to int")
+
+	draft, ok := GetMeta[bool](doc, "draft")
+	assert.True(t, ok)
+	assert.True(t, draft)
+
+	modified, ok := GetMeta[time.Time](doc, "modified")
+	assert.True(t, ok)
+	assert.Equal(t, 2024, modified.Year())
+
+	tags, ok := GetMeta[[]string](doc, "tags")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"ops", "runbook"}, tags)
+
+	_, ok = GetMeta[string](doc, "size")
+	assert.False(t, ok)
+	_, ok = GetMeta[string](doc, "missing")
+	assert.False(t, ok)
+
+	assert.Equal(t, "none", GetMetaOr(doc, "missing", "none"))
+	assert.Equal(t, 1024, GetMetaOr(doc, "size", 0))
+}
+
+func TestBindMetadata(t *testing.T) {
+	type pageMeta struct {
+		Space    string    `json:"space_key"`
+		Version  int       `json:"version"`
+		Labels   []string  `json:"labels"`
+		Modified time.Time `json:"modified"`
+	}
+
+	in := pageMeta{
+		Space:    "ENG",
+		Version:  7,
+		Labels:   []string{"howto"},
+		Modified: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	metadata, err := MetadataFrom(in)
+	require.NoError(t, err)
+	assert.Equal(t, "ENG", metadata["space_key"])
+	assert.Equal(t, float64(7), metadata["version"])
+
+	doc := Document{Metadata: metadata}
+	var out pageMeta
+	require.NoError(t, doc.BindMetadata(&out))
+	assert.Equal(t, in, out)
+
+	_, err = MetadataFrom([]string{"not", "an", "object"})
+	assert.Error(t, err)
+}
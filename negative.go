@@ -0,0 +1,66 @@
+package hnswindex
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/indexer"
+)
+
+// DefaultNegativeWeight is the penalty factor of SearchOptions.NegativeQuery
+// when SearchOptions.NegativeWeight is 0
+const DefaultNegativeWeight = 0.5
+
+// negativeWeight returns the penalty factor of the negative query
+func (o SearchOptions) negativeWeight() float64 {
+	if o.NegativeWeight <= 0 {
+		return DefaultNegativeWeight
+	}
+	return o.NegativeWeight
+}
+
+// steerAway embeds the negative query, computes the penalty of each hit,
+// and reorders hits by their changed score. A hit's penalty is the
+// negative weight times its similarity to the negative query; hits no
+// closer to it than unrelated text are not penalized.
+func (i *indexImpl) steerAway(hits []indexer.SearchResult, options SearchOptions, scores *hitScores, timing *SearchTiming) error {
+	start := time.Now()
+	embedding, err := i.embedQuery(options.NegativeQuery, options.Model)
+	if err != nil {
+		return fmt.Errorf("negative query: %w", err)
+	}
+	timing.Embed += time.Since(start)
+	embedding = i.graphVector(embedding)
+
+	weight := options.negativeWeight()
+	penalties := make(map[uint64]float64, len(hits))
+	for _, hr := range hits {
+		distance, ok := i.hnswIndex.Distance(embedding, hr.ID)
+		if !ok {
+			continue // Deleted since the search; dropped during hydration
+		}
+		if similarity := i.similarity(distance); similarity > 0 {
+			penalties[hr.ID] = weight * similarity
+		}
+	}
+
+	scores.penalties = penalties
+	sort.SliceStable(hits, func(a, b int) bool {
+		return scores.score(hits[a]) > scores.score(hits[b])
+	})
+	return nil
+}
+
+// applyNegativePenalty lowers the score of a hydrated result by its penalty
+func applyNegativePenalty(result *SearchResult, penalty float64, negativeQuery string) {
+	result.Score -= penalty
+	if result.Explain != nil {
+		result.Explain.Score = result.Score
+		result.Explain.Adjustments = append(result.Explain.Adjustments, ScoreAdjustment{
+			Stage:  "negative_query",
+			Delta:  -penalty,
+			Reason: fmt.Sprintf("similar to %q", negativeQuery),
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch_NegativeQuery(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("negative")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "v1", Title: "Auth v1", Content: "authenticating with the v1 API"},
+		{URI: "v2", Title: "Auth v2", Content: "authenticating with the v2 API"},
+		{URI: "keys", Title: "Keys", Content: "rotating API keys"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	results, err := index.Search("authenticating with the v1 API", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "v1", results[0].Document.URI)
+
+	// Steering away from the v1 text demotes the exact match
+	results, err = index.SearchWithOptions("authenticating with the v1 API", 3, SearchOptions{
+		Explain:        true,
+		NegativeQuery:  "authenticating with the v1 API",
+		NegativeWeight: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.NotEqual(t, "v1", results[0].Document.URI)
+	for idx, r := range results {
+		if idx > 0 {
+			assert.GreaterOrEqual(t, results[idx-1].Score, r.Score)
+		}
+		require.NotNil(t, r.Explain)
+		assert.Equal(t, r.Score, r.Explain.Score)
+		if r.Document.URI == "v1" {
+			require.Len(t, r.Explain.Adjustments, 1)
+			assert.Equal(t, "negative_query", r.Explain.Adjustments[0].Stage)
+			assert.InDelta(t, -1.0, r.Explain.Adjustments[0].Delta, 1e-6)
+		}
+	}
+
+	// The iterator applies the same ordering
+	var uris []string
+	for r, err := range index.SearchIterWithOptions("authenticating with the v1 API", 3, SearchOptions{
+		NegativeQuery:  "authenticating with the v1 API",
+		NegativeWeight: 1,
+	}) {
+		require.NoError(t, err)
+		uris = append(uris, r.Document.URI)
+	}
+	require.NotEmpty(t, uris)
+	assert.Equal(t, results[0].Document.URI, uris[0])
+}
@@ -0,0 +1,177 @@
+package hnswindex
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+const (
+	// DefaultOutlierZScore flags chunks further from their nearest
+	// neighbors than the mean distance plus this many standard deviations
+	DefaultOutlierZScore = 3.0
+
+	// DefaultMinLanguageRatio flags chunks in which less than this share of
+	// the characters form plain words
+	DefaultMinLanguageRatio = 0.5
+
+	minLanguageCheckChars = 40    // Shorter chunks are not checked for language
+	maxWordLength         = 30    // Longer runs of letters are not words
+	wordJoiners           = "-'." // May join the parts of a word, as in "re-run" or "v1.2"
+	outlierNeighbors      = 5     // Nearest chunks a chunk's distance is measured to
+	outlierPreviewLength  = 120
+)
+
+// OutlierOptions configures Index.FindOutliers
+type OutlierOptions struct {
+	ZScore           float64 // Embedding outlier cutoff (default DefaultOutlierZScore)
+	MinLanguageRatio float64 // Non-language cutoff (default DefaultMinLanguageRatio)
+}
+
+// SuspectChunk describes a chunk flagged by FindOutliers
+type SuspectChunk struct {
+	ChunkID       string  `json:"chunk_id"`
+	DocumentURI   string  `json:"document_uri"`
+	Preview       string  `json:"preview"`
+	Distance      float64 `json:"distance"`       // 1 - mean similarity to the nearest other chunks
+	ZScore        float64 `json:"z_score"`        // Standard deviations above the mean distance
+	LanguageRatio float64 `json:"language_ratio"` // Share of characters in plain words
+}
+
+// OutlierReport is the result of FindOutliers
+type OutlierReport struct {
+	Checked  int            `json:"checked"`  // Chunks examined
+	Outliers []SuspectChunk `json:"outliers"` // Embedding far from the rest of the index, furthest first
+	Garbage  []SuspectChunk `json:"garbage"`  // Text mostly not language, such as base64 or minified code
+}
+
+// FindOutliers reports chunks that probably should not have been indexed:
+// chunks whose embedding is far from every other chunk of the index, and
+// chunks whose text is mostly not language. Use it to tune ingestion
+// filters; nothing is deleted.
+func (i *Index) FindOutliers(opts OutlierOptions) (*OutlierReport, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.FindOutliers(opts)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// FindOutliers implementation
+func (i *indexImpl) FindOutliers(opts OutlierOptions) (*OutlierReport, error) {
+	if opts.ZScore <= 0 {
+		opts.ZScore = DefaultOutlierZScore
+	}
+	if opts.MinLanguageRatio <= 0 {
+		opts.MinLanguageRatio = DefaultMinLanguageRatio
+	}
+
+	report := &OutlierReport{Outliers: []SuspectChunk{}, Garbage: []SuspectChunk{}}
+	var checked []SuspectChunk
+	dimension := i.embeddingDimension()
+	err := i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		report.Checked++
+		suspect := SuspectChunk{
+			ChunkID:       chunk.ID,
+			DocumentURI:   chunk.DocumentURI,
+			Preview:       Chunk{Text: chunk.Text}.Preview(outlierPreviewLength),
+			LanguageRatio: languageRatio(chunk.Text),
+		}
+		if suspect.LanguageRatio < opts.MinLanguageRatio {
+			report.Garbage = append(report.Garbage, suspect)
+		}
+		if len(chunk.Embedding) != dimension {
+			return nil
+		}
+		hits, err := i.hnswIndex.Search(i.graphVector(chunk.Embedding), outlierNeighbors+1)
+		if err != nil {
+			return err
+		}
+		var similarity float64
+		neighbors := 0
+		for _, hit := range hits {
+			if hit.ID == chunk.HNSWId || neighbors == outlierNeighbors {
+				continue
+			}
+			similarity += i.similarity(hit.Distance)
+			neighbors++
+		}
+		if neighbors > 0 {
+			suspect.Distance = 1 - similarity/float64(neighbors)
+			checked = append(checked, suspect)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Flag distances far above the index's own spread
+	var mean, variance float64
+	for _, s := range checked {
+		mean += s.Distance
+	}
+	mean /= float64(max(1, len(checked)))
+	for _, s := range checked {
+		variance += (s.Distance - mean) * (s.Distance - mean)
+	}
+	stddev := math.Sqrt(variance / float64(max(1, len(checked))))
+	if stddev > 0 {
+		for _, s := range checked {
+			s.ZScore = (s.Distance - mean) / stddev
+			if s.ZScore > opts.ZScore {
+				report.Outliers = append(report.Outliers, s)
+			}
+		}
+	}
+
+	sort.SliceStable(report.Outliers, func(a, b int) bool {
+		return report.Outliers[a].ZScore > report.Outliers[b].ZScore
+	})
+	sort.SliceStable(report.Garbage, func(a, b int) bool {
+		return report.Garbage[a].LanguageRatio < report.Garbage[b].LanguageRatio
+	})
+	return report, nil
+}
+
+// languageRatio returns the share of the non-space characters of text that
+// belong to plain words: runs of letters and digits, optionally joined by a
+// hyphen, apostrophe, or period and wrapped in punctuation. Base64, hex dumps, and minified
+// code score low. Text too short to judge scores 1.
+func languageRatio(text string) float64 {
+	total, words := 0, 0
+	for _, token := range strings.Fields(text) {
+		n := utf8.RuneCountInString(token)
+		total += n
+		if isWord(strings.TrimFunc(token, unicode.IsPunct)) {
+			words += n
+		}
+	}
+	if total < minLanguageCheckChars {
+		return 1
+	}
+	return float64(words) / float64(total)
+}
+
+// isWord reports whether token is a plain word
+func isWord(token string) bool {
+	n := utf8.RuneCountInString(token)
+	if n == 0 || n > maxWordLength {
+		return false
+	}
+	prev := rune(wordJoiners[0])
+	for _, r := range token {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+		case strings.ContainsRune(wordJoiners, r) && !strings.ContainsRune(wordJoiners, prev):
+		default:
+			return false
+		}
+		prev = r
+	}
+	return !strings.ContainsRune(wordJoiners, prev)
+}
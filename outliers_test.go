@@ -0,0 +1,60 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_FindOutliers(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("outliers")
+	require.NoError(t, err)
+
+	// Copies of one page sit on top of each other in the graph, leaving the
+	// two other chunks far from their neighbors
+	var docs []Document
+	for n := 0; n < 10; n++ {
+		docs = append(docs, Document{URI: fmt.Sprintf("copy%d", n), Title: "Copy", Content: "how to rotate the database credentials"})
+	}
+	blob := "aGVsbG8gd29ybGQgdGhpcyBpcyBhIGJhc2U2NCBibG9iIHRoYXQgc2hvdWxkIG5vdCBiZSBpbmRleGVk"
+	docs = append(docs,
+		Document{URI: "lonely", Title: "Lonely", Content: "the cafeteria menu for next week"},
+		Document{URI: "blob", Title: "Blob", Content: blob},
+	)
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	report, err := index.FindOutliers(OutlierOptions{ZScore: 1.5})
+	require.NoError(t, err)
+	assert.Equal(t, 12, report.Checked)
+
+	var outliers []string
+	for _, o := range report.Outliers {
+		outliers = append(outliers, o.DocumentURI)
+		assert.Greater(t, o.ZScore, 1.5)
+	}
+	assert.ElementsMatch(t, []string{"lonely", "blob"}, outliers)
+
+	require.Len(t, report.Garbage, 1)
+	assert.Equal(t, "blob", report.Garbage[0].DocumentURI)
+	assert.Less(t, report.Garbage[0].LanguageRatio, DefaultMinLanguageRatio)
+	assert.Equal(t, blob, report.Garbage[0].Preview)
+}
+
+func TestLanguageRatio(t *testing.T) {
+	prose := "Restart the payment service, then drain the queue (see step 3.2). Don't re-run the job before v1.4 is deployed."
+	assert.Greater(t, languageRatio(prose), 0.9)
+
+	minified := `function(e){return e&&e.__esModule?e:{default:e}};var t=n(12),r=o(t);module.exports=r.default||r;`
+	assert.Less(t, languageRatio(minified), 0.2)
+
+	base64 := strings.Repeat("QUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVo=", 3)
+	assert.Equal(t, 0.0, languageRatio(base64))
+
+	assert.Equal(t, 1.0, languageRatio("x=1;y=2"))
+}
@@ -0,0 +1,262 @@
+package hnswindex
+
+import (
+	"math"
+	"sort"
+)
+
+// fitPCA returns the mean of vectors, the k directions of largest variance
+// around it (unit length, by decreasing variance) and the share of the
+// total variance they capture
+func fitPCA(vectors [][]float32, k int) ([]float32, [][]float32, float64) {
+	n := len(vectors[0])
+	mean64 := make([]float64, n)
+	for _, v := range vectors {
+		for x, value := range v {
+			mean64[x] += float64(value)
+		}
+	}
+	for x := range mean64 {
+		mean64[x] /= float64(len(vectors))
+	}
+
+	// Covariance, accumulated in the upper triangle and mirrored
+	cov := make([][]float64, n)
+	for a := range cov {
+		cov[a] = make([]float64, n)
+	}
+	centered := make([]float64, n)
+	for _, v := range vectors {
+		for x, value := range v {
+			centered[x] = float64(value) - mean64[x]
+		}
+		for a, ca := range centered {
+			if ca == 0 {
+				continue
+			}
+			row := cov[a]
+			for b := a; b < n; b++ {
+				row[b] += ca * centered[b]
+			}
+		}
+	}
+	for a := 0; a < n; a++ {
+		for b := a + 1; b < n; b++ {
+			cov[b][a] = cov[a][b]
+		}
+	}
+
+	values, vecs := symmetricEigen(cov)
+	order := make([]int, n)
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] > values[order[b]] })
+
+	var total, kept float64
+	for _, value := range values {
+		if value > 0 {
+			total += value
+		}
+	}
+	components := make([][]float32, k)
+	for c := range components {
+		col := order[c]
+		if values[col] > 0 {
+			kept += values[col]
+		}
+		components[c] = make([]float32, n)
+		for x, value := range vecs[col] {
+			components[c][x] = float32(value)
+		}
+	}
+
+	mean := make([]float32, n)
+	for x, m := range mean64 {
+		mean[x] = float32(m)
+	}
+	explained := 0.0
+	if total > 0 {
+		explained = kept / total
+	}
+	return mean, components, explained
+}
+
+// symmetricEigen returns the eigenvalues of the symmetric matrix a and its
+// eigenvectors, as the rows of the second result. a is overwritten.
+// It reduces a to tridiagonal form with Householder reflections and then
+// diagonalizes it with the implicit QL method (the EISPACK tred2 and tql2
+// routines).
+func symmetricEigen(a [][]float64) ([]float64, [][]float64) {
+	n := len(a)
+	v := a
+	d := make([]float64, n)
+	e := make([]float64, n)
+
+	// Householder reduction to tridiagonal form
+	for j := 0; j < n; j++ {
+		d[j] = v[n-1][j]
+	}
+	for i := n - 1; i > 0; i-- {
+		scale, h := 0.0, 0.0
+		for k := 0; k < i; k++ {
+			scale += math.Abs(d[k])
+		}
+		if scale == 0 {
+			e[i] = d[i-1]
+			for j := 0; j < i; j++ {
+				d[j] = v[i-1][j]
+				v[i][j] = 0
+				v[j][i] = 0
+			}
+		} else {
+			for k := 0; k < i; k++ {
+				d[k] /= scale
+				h += d[k] * d[k]
+			}
+			f := d[i-1]
+			g := math.Sqrt(h)
+			if f > 0 {
+				g = -g
+			}
+			e[i] = scale * g
+			h -= f * g
+			d[i-1] = f - g
+			for j := 0; j < i; j++ {
+				e[j] = 0
+			}
+			for j := 0; j < i; j++ {
+				f = d[j]
+				v[j][i] = f
+				g = e[j] + v[j][j]*f
+				for k := j + 1; k <= i-1; k++ {
+					g += v[k][j] * d[k]
+					e[k] += v[k][j] * f
+				}
+				e[j] = g
+			}
+			f = 0
+			for j := 0; j < i; j++ {
+				e[j] /= h
+				f += e[j] * d[j]
+			}
+			hh := f / (h + h)
+			for j := 0; j < i; j++ {
+				e[j] -= hh * d[j]
+			}
+			for j := 0; j < i; j++ {
+				f = d[j]
+				g = e[j]
+				for k := j; k <= i-1; k++ {
+					v[k][j] -= f*e[k] + g*d[k]
+				}
+				d[j] = v[i-1][j]
+				v[i][j] = 0
+			}
+		}
+		d[i] = h
+	}
+
+	// Accumulate the transformations
+	for i := 0; i < n-1; i++ {
+		v[n-1][i] = v[i][i]
+		v[i][i] = 1
+		h := d[i+1]
+		if h != 0 {
+			for k := 0; k <= i; k++ {
+				d[k] = v[k][i+1] / h
+			}
+			for j := 0; j <= i; j++ {
+				g := 0.0
+				for k := 0; k <= i; k++ {
+					g += v[k][i+1] * v[k][j]
+				}
+				for k := 0; k <= i; k++ {
+					v[k][j] -= g * d[k]
+				}
+			}
+		}
+		for k := 0; k <= i; k++ {
+			v[k][i+1] = 0
+		}
+	}
+	for j := 0; j < n; j++ {
+		d[j] = v[n-1][j]
+		v[n-1][j] = 0
+	}
+	v[n-1][n-1] = 1
+	e[0] = 0
+
+	// The rotations below combine eigenvectors; as rows they are contiguous
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			v[i][j], v[j][i] = v[j][i], v[i][j]
+		}
+	}
+
+	// Implicit QL iterations on the tridiagonal matrix
+	for i := 1; i < n; i++ {
+		e[i-1] = e[i]
+	}
+	e[n-1] = 0
+	f, tst1 := 0.0, 0.0
+	eps := math.Pow(2, -52)
+	for l := 0; l < n; l++ {
+		tst1 = math.Max(tst1, math.Abs(d[l])+math.Abs(e[l]))
+		m := l
+		for m < n-1 && math.Abs(e[m]) > eps*tst1 {
+			m++
+		}
+		if m > l {
+			for {
+				g := d[l]
+				p := (d[l+1] - g) / (2 * e[l])
+				r := math.Hypot(p, 1)
+				if p < 0 {
+					r = -r
+				}
+				d[l] = e[l] / (p + r)
+				d[l+1] = e[l] * (p + r)
+				dl1 := d[l+1]
+				h := g - d[l]
+				for i := l + 2; i < n; i++ {
+					d[i] -= h
+				}
+				f += h
+
+				p = d[m]
+				c, c2, c3 := 1.0, 1.0, 1.0
+				el1 := e[l+1]
+				s, s2 := 0.0, 0.0
+				for i := m - 1; i >= l; i-- {
+					c3 = c2
+					c2 = c
+					s2 = s
+					g = c * e[i]
+					h = c * p
+					r = math.Hypot(p, e[i])
+					e[i+1] = s * r
+					s = e[i] / r
+					c = p / r
+					p = c*d[i] - s*g
+					d[i+1] = h + s*(c*g+s*d[i])
+					next, cur := v[i+1], v[i]
+					for k := 0; k < n; k++ {
+						h = next[k]
+						next[k] = s*cur[k] + c*h
+						cur[k] = c*cur[k] - s*h
+					}
+				}
+				p = -s * s2 * c3 * el1 * e[l] / dl1
+				e[l] = s * p
+				d[l] = c * p
+				if math.Abs(e[l]) <= eps*tst1 {
+					break
+				}
+			}
+		}
+		d[l] += f
+		e[l] = 0
+	}
+	return d, v
+}
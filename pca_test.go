@@ -0,0 +1,67 @@
+package hnswindex
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymmetricEigen(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	n := 12
+	a := make([][]float64, n)
+	orig := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n)
+		orig[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			x := rng.Float64()*2 - 1
+			a[i][j], a[j][i] = x, x
+			orig[i][j], orig[j][i] = x, x
+		}
+	}
+
+	values, vecs := symmetricEigen(a)
+	for col := 0; col < n; col++ {
+		for row := 0; row < n; row++ {
+			var av float64
+			for k := 0; k < n; k++ {
+				av += orig[row][k] * vecs[col][k]
+			}
+			assert.InDelta(t, values[col]*vecs[col][row], av, 1e-9)
+		}
+		for other := 0; other < n; other++ {
+			var dot float64
+			for k := 0; k < n; k++ {
+				dot += vecs[col][k] * vecs[other][k]
+			}
+			want := 0.0
+			if other == col {
+				want = 1
+			}
+			assert.InDelta(t, want, dot, 1e-9)
+		}
+	}
+}
+
+func TestFitPCA(t *testing.T) {
+	// Points along (1, 1, 0) with a little noise in the third dimension
+	rng := rand.New(rand.NewSource(2))
+	var vectors [][]float32
+	for i := 0; i < 200; i++ {
+		x := rng.Float32()*10 - 5
+		vectors = append(vectors, []float32{x + 3, x - 1, rng.Float32() * 0.01})
+	}
+
+	mean, components, explained := fitPCA(vectors, 1)
+	require.Len(t, components, 1)
+	assert.InDelta(t, 4, mean[0]-mean[1], 1e-4)
+	assert.InDelta(t, 1/math.Sqrt2, math.Abs(float64(components[0][0])), 1e-3)
+	assert.InDelta(t, 1/math.Sqrt2, math.Abs(float64(components[0][1])), 1e-3)
+	assert.Greater(t, explained, 0.999)
+}
@@ -0,0 +1,514 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/chunker"
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// Defaults for the indexing pipeline
+const (
+	DefaultChunkWorkers  = 4
+	DefaultPipelineQueue = 64
+	commitGroupSize      = 32 // Most documents committed in one transaction
+)
+
+// pipelineDocument is a document moving through the indexing pipeline
+type pipelineDocument struct {
+	seq     int // Position in the batch, the order documents are committed in
+	doc     Document
+	hash    string // Hashed before extraction, so unchanged binary content is detected
+	created bool
+
+	chunks   []chunker.Chunk
+	previous []*storage.Chunk // Stored chunks whose embeddings are reused
+	write    storage.DocumentWrite
+	tokens   int // Tokens of the chunks embedded
+}
+
+// pipelineStats sums the time each stage spends working, to show which
+// stage limits throughput
+type pipelineStats struct {
+	check, chunk, embed, commit atomic.Int64 // Nanoseconds
+	tokens                      atomic.Int64
+}
+
+func (s *pipelineStats) add(stage *atomic.Int64, start time.Time) {
+	stage.Add(int64(time.Since(start)))
+}
+
+// runPipeline indexes docs in four stages connected by bounded queues:
+//
+//	check (1) -> chunk (ChunkWorkers) -> embed (MaxWorkers) -> commit (1)
+//
+// The check stage validates and hashes documents and drops unchanged ones
+// and all but the last version of a repeated URI.
+// The chunk stage extracts, loads, chunks and titles documents and matches
+// chunks whose embeddings can be reused. The embed stage, usually the
+// slowest, embeds the remaining chunks. The commit stage stores documents
+// in groups of up to commitGroupSize per transaction and adds their chunks
+// to the graph, so the graph sees changes in commit order. Each queue holds
+// up to PipelineQueue documents, which bounds memory for large batches.
+// It returns the number of documents that needed processing.
+func (i *indexImpl) runPipeline(ctx context.Context, docs []Document, options AddOptions, schema *MetadataSchema, result *BatchResult, sendProgress func(ProgressUpdate)) (int, error) {
+	cfg := i.manager.config
+	queue := cfg.PipelineQueue
+	if queue <= 0 {
+		queue = DefaultPipelineQueue
+	}
+	chunkWorkers := cfg.ChunkWorkers
+	if chunkWorkers <= 0 {
+		chunkWorkers = DefaultChunkWorkers
+	}
+	embedWorkers := max(1, cfg.MaxWorkers)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex // Guards result, toProcess and committed
+	toProcess, committed := 0, 0
+	fail := func(uri string, err error) {
+		mu.Lock()
+		result.FailedURIs[uri] = err.Error()
+		mu.Unlock()
+	}
+	var stats pipelineStats
+	started := time.Now()
+
+	checked := make(chan *pipelineDocument, queue)
+	chunked := make(chan *pipelineDocument, queue)
+	embedded := make(chan *pipelineDocument, queue)
+
+	// Stage 1: check
+	checkDone := make(chan struct{})
+	go func() {
+		defer close(checkDone)
+		defer close(checked)
+
+		// Only the last version of a repeated URI is indexed
+		last := make(map[string]int, len(docs))
+		for idx, doc := range docs {
+			last[doc.URI] = idx
+		}
+		for idx, doc := range docs {
+			if ctx.Err() != nil {
+				return
+			}
+			if last[doc.URI] != idx {
+				slog.Debug("Document superseded later in batch",
+					"uri", doc.URI,
+				)
+				continue
+			}
+			start := time.Now()
+			sendProgress(ProgressUpdate{
+				Stage:   "checking",
+				Current: idx + 1,
+				Total:   len(docs),
+				Message: fmt.Sprintf("Checking document: %s", doc.Title),
+				URI:     doc.URI,
+			})
+			p, err := i.checkDocument(idx, doc, options, schema, result, &mu)
+			stats.add(&stats.check, start)
+			if err != nil {
+				fail(doc.URI, err)
+				continue
+			}
+			if p == nil {
+				continue
+			}
+			mu.Lock()
+			toProcess++
+			mu.Unlock()
+			select {
+			case checked <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		mu.Lock()
+		slog.Info("Document analysis complete",
+			"new", result.NewDocuments,
+			"updated", result.UpdatedDocuments,
+			"unchanged", result.UnchangedDocuments,
+			"to_process", toProcess,
+		)
+		sendProgress(ProgressUpdate{
+			Stage:   "checking",
+			Current: len(docs),
+			Total:   len(docs),
+			Message: fmt.Sprintf("Found %d new, %d updated documents to process", result.NewDocuments, result.UpdatedDocuments),
+		})
+		mu.Unlock()
+	}()
+
+	// Stage 2: chunk
+	var chunkWG sync.WaitGroup
+	for w := 0; w < chunkWorkers; w++ {
+		chunkWG.Add(1)
+		go func() {
+			defer chunkWG.Done()
+			for p := range checked {
+				if ctx.Err() != nil {
+					return
+				}
+				start := time.Now()
+				err := i.chunkPipelineDocument(ctx, p, sendProgress)
+				stats.add(&stats.chunk, start)
+				if err != nil {
+					slog.Error("Failed to process document",
+						"uri", p.doc.URI,
+						"error", err,
+					)
+					fail(p.doc.URI, err)
+					continue
+				}
+				select {
+				case chunked <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		chunkWG.Wait()
+		close(chunked)
+	}()
+
+	// Stage 3: embed
+	var embedWG sync.WaitGroup
+	for w := 0; w < embedWorkers; w++ {
+		embedWG.Add(1)
+		go func() {
+			defer embedWG.Done()
+			for p := range chunked {
+				if ctx.Err() != nil {
+					return
+				}
+				start := time.Now()
+				err := i.embedPipelineDocument(p)
+				stats.add(&stats.embed, start)
+				stats.tokens.Add(int64(p.tokens))
+				if err != nil {
+					slog.Error("Failed to process document",
+						"uri", p.doc.URI,
+						"error", err,
+					)
+					fail(p.doc.URI, err)
+					continue
+				}
+				select {
+				case embedded <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		embedWG.Wait()
+		close(embedded)
+	}()
+
+	// Stage 4: commit, on this goroutine
+	for p := range embedded {
+		group := []*pipelineDocument{p}
+	fill:
+		for len(group) < commitGroupSize {
+			select {
+			case next, ok := <-embedded:
+				if !ok {
+					break fill
+				}
+				group = append(group, next)
+			default:
+				break fill
+			}
+		}
+
+		start := time.Now()
+		for _, p := range i.commitGroup(group, fail) {
+			mu.Lock()
+			committed++
+			result.ProcessedChunks += len(p.write.Chunks)
+			current, total := committed, toProcess
+			mu.Unlock()
+
+			sendProgress(ProgressUpdate{
+				Stage:   "processing",
+				Current: current,
+				Total:   total,
+				Message: fmt.Sprintf("Indexed: %s", p.doc.Title),
+				URI:     p.doc.URI,
+			})
+			i.manager.emitDocumentIndexed(DocumentEvent{
+				Index:   i.name,
+				URI:     p.doc.URI,
+				Title:   p.doc.Title,
+				Chunks:  len(p.write.Chunks),
+				Created: p.created,
+			})
+		}
+		stats.add(&stats.commit, start)
+	}
+
+	// After a cancellation the commit stage stops early; wait for the
+	// other stages to notice before result is handed back
+	<-checkDone
+	chunkWG.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	result.DurationEmbedding += time.Duration(stats.embed.Load())
+	result.DurationStorage += time.Duration(stats.commit.Load())
+	result.TokensEmbedded += int(stats.tokens.Load())
+	if err := ctx.Err(); err != nil {
+		return toProcess, err
+	}
+
+	slog.Debug("Indexing pipeline finished",
+		"index", i.name,
+		"documents", toProcess,
+		"duration_ms", time.Since(started).Milliseconds(),
+		"check_ms", time.Duration(stats.check.Load()).Milliseconds(),
+		"chunk_ms", time.Duration(stats.chunk.Load()).Milliseconds(),
+		"embed_ms", time.Duration(stats.embed.Load()).Milliseconds(),
+		"commit_ms", time.Duration(stats.commit.Load()).Milliseconds(),
+	)
+	return toProcess, nil
+}
+
+// checkDocument validates and hashes a document, returning nil for an
+// unchanged one. Counts are added to result under mu.
+func (i *indexImpl) checkDocument(seq int, doc Document, options AddOptions, schema *MetadataSchema, result *BatchResult, mu *sync.Mutex) (*pipelineDocument, error) {
+	// Reject or cut oversized metadata before it is validated and hashed
+	doc, err := i.manager.limitMetadata(doc)
+	if err != nil {
+		slog.Warn("Document rejected by metadata limit",
+			"uri", doc.URI,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	// Reject documents whose metadata violates the index schema
+	if err := schema.Validate(doc.Metadata); err != nil {
+		slog.Warn("Document rejected by metadata schema",
+			"uri", doc.URI,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	// Reject oversized documents before they are hashed or chunked
+	if err := i.manager.checkContentSize(doc); err != nil {
+		slog.Warn("Document rejected by size limit",
+			"uri", doc.URI,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	hash, err := i.manager.hashDocument(doc)
+	if err != nil {
+		slog.Warn("Failed to hash document",
+			"uri", doc.URI,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	p := &pipelineDocument{seq: seq, doc: doc, hash: hash}
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Check if document has changed (unless force update is set)
+	if options.ForceUpdate {
+		result.UpdatedDocuments++
+		return p, nil
+	}
+	existingHash, err := i.manager.storage.GetDocumentHash(i.name, doc.URI)
+	switch {
+	case err != nil:
+		slog.Debug("Document is new",
+			"uri", doc.URI,
+		)
+		result.NewDocuments++
+		p.created = true
+	case existingHash != hash:
+		slog.Debug("Document has changed",
+			"uri", doc.URI,
+			"old_hash", existingHash[:16],
+			"new_hash", hash[:16],
+		)
+		result.UpdatedDocuments++
+	default:
+		slog.Debug("Document unchanged",
+			"uri", doc.URI,
+		)
+		result.UnchangedDocuments++
+		return nil, nil
+	}
+	return p, nil
+}
+
+// chunkPipelineDocument extracts, loads and chunks a document and matches
+// the chunks whose stored embeddings can be reused
+func (i *indexImpl) chunkPipelineDocument(ctx context.Context, p *pipelineDocument, sendProgress func(ProgressUpdate)) error {
+	if len(p.doc.Data) > 0 {
+		sendProgress(ProgressUpdate{
+			Stage:   "extracting",
+			Message: fmt.Sprintf("Extracting text: %s", p.doc.Title),
+			URI:     p.doc.URI,
+		})
+	}
+	doc, err := i.manager.extractBinary(ctx, p.doc)
+	if err != nil {
+		return err
+	}
+
+	// Load lazily provided content only now that it is needed
+	if doc, err = i.manager.loadContent(doc); err != nil {
+		return err
+	}
+
+	// Transcripts without content are stored with their joined segment text
+	if doc.Content == "" && len(doc.Segments) > 0 {
+		doc.Content = segmentContent(doc.Segments)
+	}
+
+	// Chunk before storing, so a document over the chunk limit is not stored
+	if p.chunks, err = i.pipelineChunks(doc); err != nil {
+		return err
+	}
+	p.doc = doc
+	p.previous = i.reusableChunks(doc.URI, p.chunks)
+	return nil
+}
+
+// embedPipelineDocument embeds the chunks that cannot reuse an embedding
+// and prepares the document's write. A failed embedding leaves the previous
+// version of the document in place.
+func (i *indexImpl) embedPipelineDocument(p *pipelineDocument) error {
+	var texts []string
+	for idx, chunk := range p.chunks {
+		if p.previous[idx] == nil {
+			texts = append(texts, chunk.Text)
+		}
+	}
+	var embeddings [][]float32
+	var vectors [][][]float32
+	var sparse []map[string]float32
+	if len(texts) > 0 {
+		var err error
+		if embeddings, err = i.manager.embedTexts(texts); err != nil {
+			return fmt.Errorf("failed to process chunks: failed to generate embeddings: %w", err)
+		}
+		if err := i.checkDimension(len(embeddings[0])); err != nil {
+			return fmt.Errorf("failed to process chunks: %w", err)
+		}
+		if vectors, err = i.manager.lateVectors(texts); err != nil {
+			return fmt.Errorf("failed to process chunks: %w", err)
+		}
+		if sparse, err = i.manager.sparseVectors(texts); err != nil {
+			return fmt.Errorf("failed to process chunks: %w", err)
+		}
+		for _, text := range texts {
+			p.tokens += i.manager.chunker.CountTokens(text)
+		}
+	}
+
+	// Store the document and its chunks together
+	doc := p.doc
+	p.write = storage.DocumentWrite{
+		Index: i.name,
+		Document: storage.Document{
+			URI:      doc.URI,
+			Title:    doc.Title,
+			Content:  doc.Content,
+			Hash:     p.hash,
+			Metadata: doc.Metadata,
+		},
+	}
+	for idx, chunk := range p.chunks {
+		c := storage.Chunk{
+			ID:          chunk.ID,
+			DocumentURI: doc.URI,
+			Text:        chunk.Text,
+			Position:    chunk.Position,
+			Metadata:    chunk.Metadata,
+		}
+		if prev := p.previous[idx]; prev != nil {
+			c.Embedding, c.Vectors, c.Sparse, c.HNSWId = prev.Embedding, prev.Vectors, prev.Sparse, prev.HNSWId
+		} else {
+			c.Embedding, embeddings = embeddings[0], embeddings[1:]
+			c.Vectors, vectors = vectors[0], vectors[1:]
+			c.Sparse, sparse = sparse[0], sparse[1:]
+		}
+		p.write.Chunks = append(p.write.Chunks, c)
+	}
+	return nil
+}
+
+// commitGroup commits a group of documents in one transaction and returns
+// the committed ones. If the transaction fails, each document is committed
+// alone, so one bad document does not fail the others. Documents the graph
+// could not take are failed too.
+func (i *indexImpl) commitGroup(keep []*pipelineDocument, fail func(string, error)) []*pipelineDocument {
+	// Documents that finished together are committed in batch order
+	sort.Slice(keep, func(a, b int) bool { return keep[a].seq < keep[b].seq })
+	writes := make([]storage.DocumentWrite, len(keep))
+	for idx, p := range keep {
+		writes[idx] = p.write
+	}
+	notIndexed, err := i.commitWrites(writes)
+	if err == nil {
+		var committed []*pipelineDocument
+		for idx, p := range keep {
+			if err := notIndexed[p.doc.URI]; err != nil {
+				fail(p.doc.URI, fmt.Errorf("failed to index document: %w", err))
+				continue
+			}
+			p.write = writes[idx]
+			committed = append(committed, p)
+		}
+		return committed
+	}
+	if len(keep) == 1 {
+		slog.Error("Failed to process document",
+			"uri", keep[0].doc.URI,
+			"error", err,
+		)
+		fail(keep[0].doc.URI, fmt.Errorf("failed to store document: %w", err))
+		return nil
+	}
+
+	var committed []*pipelineDocument
+	for _, p := range keep {
+		writes := []storage.DocumentWrite{p.write}
+		notIndexed, err := i.commitWrites(writes)
+		if err != nil {
+			slog.Error("Failed to process document",
+				"uri", p.doc.URI,
+				"error", err,
+			)
+			fail(p.doc.URI, fmt.Errorf("failed to store document: %w", err))
+			continue
+		}
+		if err := notIndexed[p.doc.URI]; err != nil {
+			fail(p.doc.URI, fmt.Errorf("failed to index document: %w", err))
+			continue
+		}
+		p.write = writes[0]
+		committed = append(committed, p)
+	}
+	return committed
+}
@@ -0,0 +1,142 @@
+package hnswindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failOnce fails the first time a fault point is reached
+type failOnce struct {
+	point  FaultPoint
+	failed bool
+}
+
+func (f *failOnce) Inject(point FaultPoint, index string) error {
+	if point == f.point && !f.failed {
+		f.failed = true
+		return errors.New("commit failed")
+	}
+	return nil
+}
+
+func TestPipeline_SmallQueues(t *testing.T) {
+	cfg := NewConfig()
+	cfg.PipelineQueue = 1
+	cfg.ChunkWorkers = 1
+	cfg.MaxWorkers = 1
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	var docs []Document
+	for n := 0; n < 50; n++ {
+		docs = append(docs, Document{URI: fmt.Sprintf("doc-%d", n), Content: fmt.Sprintf("document number %d", n)})
+	}
+	docs = append(docs, Document{URI: "bad", Data: []byte{0xff}, MIMEType: "application/pdf"})
+
+	progress := make(chan ProgressUpdate, 1000)
+	result, err := index.AddDocumentBatch(context.Background(), docs, progress)
+	require.NoError(t, err)
+	close(progress)
+
+	assert.Equal(t, 51, result.NewDocuments)
+	assert.Equal(t, 50, result.ProcessedChunks)
+	assert.Contains(t, result.FailedURIs, "bad")
+
+	last := 0
+	for update := range progress {
+		if update.Stage == "processing" {
+			assert.Equal(t, last+1, update.Current)
+			last = update.Current
+		}
+	}
+	assert.Equal(t, 50, last)
+
+	// Unchanged documents are dropped by the check stage
+	result, err = index.AddDocumentBatch(context.Background(), docs[:50], nil)
+	require.NoError(t, err)
+	assert.Equal(t, 50, result.UnchangedDocuments)
+	assert.Zero(t, result.ProcessedChunks)
+}
+
+func TestPipeline_CommitGroup(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	impl := index.getImpl()
+
+	prepare := func(seq int, doc Document) *pipelineDocument {
+		p := &pipelineDocument{seq: seq, doc: doc, hash: computeDocumentHash(doc)}
+		require.NoError(t, impl.chunkPipelineDocument(context.Background(), p, func(ProgressUpdate) {}))
+		require.NoError(t, impl.embedPipelineDocument(p))
+		return p
+	}
+	first := prepare(0, Document{URI: "a", Content: "first document"})
+	second := prepare(1, Document{URI: "b", Content: "another document"})
+
+	// A failed group is retried one document at a time, in batch order
+	var failed []string
+	fail := func(uri string, err error) { failed = append(failed, uri) }
+	manager.SetFaultInjector(&failOnce{point: FaultCommit})
+	committed := impl.commitGroup([]*pipelineDocument{second, first}, fail)
+	require.Len(t, committed, 2)
+	assert.Equal(t, "a", committed[0].doc.URI)
+	assert.Empty(t, failed)
+	doc, err := index.GetDocument("b")
+	require.NoError(t, err)
+	assert.Equal(t, "another document", doc.Content)
+}
+
+func TestPipeline_RepeatedURI(t *testing.T) {
+	manager := newMockManager(t, nil)
+	counter := &countingEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.getImpl().embedder = counter
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	// Only the last version of a URI is chunked, embedded and committed
+	result, err := index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "a", Content: "first version"},
+		{URI: "b", Content: "another document"},
+		{URI: "a", Content: "second version"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.NewDocuments)
+	assert.Equal(t, 2, result.ProcessedChunks)
+	assert.Equal(t, 2, counter.texts)
+	doc, err := index.GetDocument("a")
+	require.NoError(t, err)
+	assert.Equal(t, "second version", doc.Content)
+}
+
+func TestPipeline_Timing(t *testing.T) {
+	manager := newMockManager(t, NewConfig())
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "a", Title: "A", Content: "the first document of the batch"},
+		{URI: "b", Title: "B", Content: "the second document of the batch"},
+	}
+	result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.ProcessedChunks)
+	assert.Positive(t, result.DurationTotal)
+	assert.Positive(t, result.DurationEmbedding)
+	assert.Positive(t, result.DurationStorage)
+	assert.Positive(t, result.TokensEmbedded)
+	assert.InDelta(t, float64(result.ProcessedChunks)/result.DurationTotal.Seconds(), result.ChunksPerSecond, 1e-6)
+
+	// Unchanged documents are not embedded again
+	result, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.UnchangedDocuments)
+	assert.Zero(t, result.TokensEmbedded)
+	assert.Zero(t, result.ChunksPerSecond)
+	assert.Positive(t, result.DurationTotal)
+}
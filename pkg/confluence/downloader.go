@@ -267,7 +267,7 @@ func (cd *ConfluenceDownloader) convertToDocument(content *goconfluence.Content)
 	fullContent := fmt.Sprintf("# %s\n\n%s", content.Title, bodyContent)
 	
 	return hnswindex.Document{
-		URI:      fmt.Sprintf("confluence://%s/%s", cd.spaceKey, content.ID),
+		URI:      cd.DocumentURI(content.ID),
 		Title:    content.Title,
 		Content:  fullContent,
 		Metadata: metadata,
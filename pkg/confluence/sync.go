@@ -0,0 +1,100 @@
+package confluence
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/riclib/hnswindex"
+	goconfluence "github.com/virtomize/confluence-go-api"
+)
+
+// ChangeOverlap is subtracted from the since time of Changes. CQL compares
+// dates in the timezone of the Confluence user, which is unknown here, so
+// pages modified up to a day before the cursor are fetched again. Unchanged
+// pages are skipped by the index's content hashes.
+const ChangeOverlap = 24 * time.Hour
+
+// cqlTimeFormat is the date format CQL accepts for lastmodified
+const cqlTimeFormat = "2006-01-02 15:04"
+
+// Changes downloads the pages of the space modified since the given time
+func (cd *ConfluenceDownloader) Changes(since time.Time) ([]hnswindex.Document, error) {
+	cql := fmt.Sprintf(`space = "%s" AND type = page AND lastmodified >= "%s" ORDER BY lastmodified`,
+		cd.spaceKey, since.Add(-ChangeOverlap).UTC().Format(cqlTimeFormat))
+	slog.Info("Fetching changed Confluence pages",
+		"space", cd.spaceKey,
+		"cql", cql,
+	)
+
+	query := goconfluence.SearchQuery{
+		CQL:    cql,
+		Limit:  50,
+		Expand: []string{"content.body.storage", "content.version", "content.ancestors"},
+	}
+
+	var documents []hnswindex.Document
+	next := ""
+	for {
+		result, err := cd.client.SearchWithNext(query, next)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search changed pages: %w", err)
+		}
+
+		for _, r := range result.Results {
+			page := r.Content
+			if page.ID == "" {
+				continue
+			}
+			documents = append(documents, cd.convertToDocument(&page))
+		}
+
+		if result.Links.Next == "" || len(result.Results) == 0 {
+			break
+		}
+		next = result.Links.Next
+
+		// Rate limiting
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	slog.Info("Changed Confluence pages fetched",
+		"space", cd.spaceKey,
+		"pages", len(documents),
+	)
+	return documents, nil
+}
+
+// PageIDs returns the IDs of every current page in the space, without
+// their content. Indexed pages missing from it were deleted or moved.
+func (cd *ConfluenceDownloader) PageIDs() (map[string]bool, error) {
+	query := goconfluence.ContentQuery{
+		SpaceKey: cd.spaceKey,
+		Type:     "page",
+		Limit:    100,
+	}
+
+	ids := make(map[string]bool)
+	for {
+		content, err := cd.client.GetContent(query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pages: %w", err)
+		}
+		for _, page := range content.Results {
+			ids[page.ID] = true
+		}
+		if len(content.Results) < query.Limit {
+			break
+		}
+		query.Start += query.Limit
+
+		// Rate limiting
+		time.Sleep(100 * time.Millisecond)
+	}
+	return ids, nil
+}
+
+// DocumentURI returns the URI pages of the space are indexed under
+func (cd *ConfluenceDownloader) DocumentURI(pageID string) string {
+	return fmt.Sprintf("confluence://%s/%s", cd.spaceKey, pageID)
+}
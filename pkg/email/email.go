@@ -0,0 +1,464 @@
+// Package email converts email messages from mbox files or an IMAP mailbox
+// into documents, one per message or one per thread, so support-mailbox
+// archives can be searched.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/riclib/hnswindex"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// maxPartDepth bounds the nesting of multipart bodies
+const maxPartDepth = 10
+
+// Message is a parsed email message
+type Message struct {
+	ID          string // Message-ID without angle brackets
+	InReplyTo   string
+	References  []string
+	From        string
+	To          []string
+	Cc          []string
+	Subject     string
+	Date        time.Time
+	Body        string // Plain text body (HTML bodies are converted to markdown)
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Filename string
+	MIMEType string
+	Data     []byte
+}
+
+// ThreadID returns the ID of the first message of the thread
+func (m *Message) ThreadID() string {
+	if len(m.References) > 0 {
+		return m.References[0]
+	}
+	if m.InReplyTo != "" {
+		return m.InReplyTo
+	}
+	return m.ID
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// charsetReader decodes any charset known to the WHATWG encoding index
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// ParseMessage parses an RFC 5322 message
+func ParseMessage(r io.Reader) (*Message, error) {
+	raw, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	h := raw.Header
+	msg := &Message{
+		ID:         trimID(h.Get("Message-Id")),
+		InReplyTo:  trimID(h.Get("In-Reply-To")),
+		References: splitIDs(h.Get("References")),
+		Subject:    decodeHeader(h.Get("Subject")),
+	}
+	if date, err := h.Date(); err == nil {
+		msg.Date = date
+	}
+
+	// Messages without a Message-ID get a stable ID from their headers
+	if msg.ID == "" {
+		sum := sha256.Sum256([]byte(h.Get("From") + "\x00" + h.Get("Date") + "\x00" + h.Get("Subject")))
+		msg.ID = "generated-" + hex.EncodeToString(sum[:8])
+	}
+
+	parser := &mail.AddressParser{WordDecoder: wordDecoder}
+	if from, err := parser.ParseList(h.Get("From")); err == nil && len(from) > 0 {
+		msg.From = formatAddress(from[0])
+	} else {
+		msg.From = decodeHeader(h.Get("From"))
+	}
+	msg.To = addresses(parser, h.Get("To"))
+	msg.Cc = addresses(parser, h.Get("Cc"))
+
+	var plain, html []string
+	err = walkPart(h, raw.Body, 0, func(mediaType, filename string, attachment bool, data []byte) {
+		switch {
+		case attachment || filename != "":
+			msg.Attachments = append(msg.Attachments, Attachment{Filename: filename, MIMEType: mediaType, Data: data})
+		case mediaType == "text/plain":
+			plain = append(plain, string(data))
+		case mediaType == "text/html":
+			html = append(html, string(data))
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body of %s: %w", msg.ID, err)
+	}
+
+	// Prefer the plain text alternative; fall back to converted HTML
+	if len(plain) > 0 {
+		msg.Body = strings.TrimSpace(strings.Join(plain, "\n\n"))
+	} else if len(html) > 0 {
+		msg.Body = htmlToText(strings.Join(html, "\n"))
+	}
+
+	return msg, nil
+}
+
+// partHeader is the subset of MIME part headers used by walkPart
+type partHeader interface {
+	Get(key string) string
+}
+
+// walkPart decodes a MIME entity and calls fn for every leaf part
+func walkPart(h partHeader, body io.Reader, depth int, fn func(mediaType, filename string, attachment bool, data []byte)) error {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") && depth < maxPartDepth {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := walkPart(part.Header, part, depth+1, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(h.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	filename := decodeHeader(dispParams["filename"])
+	if filename == "" {
+		filename = decodeHeader(params["name"])
+	}
+
+	if strings.HasPrefix(mediaType, "text/") {
+		if charset := params["charset"]; charset != "" && !strings.EqualFold(charset, "utf-8") {
+			if r, err := charsetReader(charset, bytes.NewReader(data)); err == nil {
+				if decoded, err := io.ReadAll(r); err == nil {
+					data = decoded
+				}
+			}
+		}
+	}
+
+	fn(mediaType, filename, disposition == "attachment", data)
+	return nil
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// newlineStripper drops the line breaks of base64 bodies
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (s *newlineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// Options controls how messages become documents
+type Options struct {
+	// Threads groups messages into one document per thread
+	Threads bool
+
+	// Mailbox is recorded in document metadata, e.g. the mbox path
+	Mailbox string
+
+	// Extractor converts non-text attachments (PDFs, images) to text.
+	// Without one only text attachments are indexed.
+	Extractor hnswindex.BinaryExtractor
+}
+
+// Documents converts messages to documents
+func Documents(ctx context.Context, msgs []*Message, opts Options) []hnswindex.Document {
+	if !opts.Threads {
+		docs := make([]hnswindex.Document, 0, len(msgs))
+		for _, msg := range msgs {
+			docs = append(docs, messageDocument(ctx, msg, opts))
+		}
+		return docs
+	}
+
+	threads := make(map[string][]*Message)
+	var order []string
+	for _, msg := range msgs {
+		id := msg.ThreadID()
+		if _, ok := threads[id]; !ok {
+			order = append(order, id)
+		}
+		threads[id] = append(threads[id], msg)
+	}
+
+	docs := make([]hnswindex.Document, 0, len(order))
+	for _, id := range order {
+		docs = append(docs, threadDocument(ctx, id, threads[id], opts))
+	}
+	return docs
+}
+
+// messageDocument converts a single message
+func messageDocument(ctx context.Context, msg *Message, opts Options) hnswindex.Document {
+	names, text := attachmentText(ctx, msg, opts.Extractor)
+
+	metadata := map[string]interface{}{
+		"source":     "email",
+		"message_id": msg.ID,
+		"thread_id":  msg.ThreadID(),
+		"from":       msg.From,
+		"to":         msg.To,
+		"subject":    msg.Subject,
+	}
+	if len(msg.Cc) > 0 {
+		metadata["cc"] = msg.Cc
+	}
+	if !msg.Date.IsZero() {
+		metadata["date"] = msg.Date.UTC().Format(time.RFC3339)
+	}
+	if len(names) > 0 {
+		metadata["attachments"] = names
+	}
+	if opts.Mailbox != "" {
+		metadata["mailbox"] = opts.Mailbox
+	}
+
+	return hnswindex.Document{
+		URI:      URI(msg.ID),
+		Title:    title(msg.Subject),
+		Content:  messageText(msg) + text,
+		Metadata: metadata,
+	}
+}
+
+// threadDocument converts the messages of a thread, oldest first
+func threadDocument(ctx context.Context, id string, msgs []*Message, opts Options) hnswindex.Document {
+	sort.SliceStable(msgs, func(a, b int) bool {
+		return msgs[a].Date.Before(msgs[b].Date)
+	})
+
+	var content strings.Builder
+	var attachments []string
+	var participants []string
+	seen := make(map[string]bool)
+
+	for idx, msg := range msgs {
+		if idx > 0 {
+			content.WriteString("\n\n---\n\n")
+		}
+		names, text := attachmentText(ctx, msg, opts.Extractor)
+		content.WriteString(messageText(msg))
+		content.WriteString(text)
+		attachments = append(attachments, names...)
+
+		for _, addr := range append([]string{msg.From}, msg.To...) {
+			if addr != "" && !seen[addr] {
+				seen[addr] = true
+				participants = append(participants, addr)
+			}
+		}
+	}
+
+	first, last := msgs[0], msgs[len(msgs)-1]
+	metadata := map[string]interface{}{
+		"source":        "email",
+		"thread_id":     id,
+		"from":          first.From,
+		"to":            first.To,
+		"participants":  participants,
+		"subject":       first.Subject,
+		"message_count": len(msgs),
+	}
+	if !first.Date.IsZero() {
+		metadata["date"] = first.Date.UTC().Format(time.RFC3339)
+		metadata["last_date"] = last.Date.UTC().Format(time.RFC3339)
+	}
+	if len(attachments) > 0 {
+		metadata["attachments"] = attachments
+	}
+	if opts.Mailbox != "" {
+		metadata["mailbox"] = opts.Mailbox
+	}
+
+	return hnswindex.Document{
+		URI:      ThreadURI(id),
+		Title:    title(first.Subject),
+		Content:  content.String(),
+		Metadata: metadata,
+	}
+}
+
+// URI returns the document URI of a message
+func URI(messageID string) string {
+	return "email://" + messageID
+}
+
+// ThreadURI returns the document URI of a thread
+func ThreadURI(threadID string) string {
+	return "email://thread/" + threadID
+}
+
+// messageText renders the headers and body of a message
+func messageText(msg *Message) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Subject: %s\nFrom: %s\n", msg.Subject, msg.From)
+	if len(msg.To) > 0 {
+		fmt.Fprintf(&sb, "To: %s\n", strings.Join(msg.To, ", "))
+	}
+	if !msg.Date.IsZero() {
+		fmt.Fprintf(&sb, "Date: %s\n", msg.Date.Format(time.RFC1123Z))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(msg.Body)
+	return sb.String()
+}
+
+// attachmentText returns the attachment names and their extracted text
+func attachmentText(ctx context.Context, msg *Message, extractor hnswindex.BinaryExtractor) ([]string, string) {
+	var names []string
+	var sb strings.Builder
+
+	for _, att := range msg.Attachments {
+		names = append(names, att.Filename)
+
+		var text string
+		switch {
+		case att.MIMEType == "text/html":
+			text = htmlToText(string(att.Data))
+		case strings.HasPrefix(att.MIMEType, "text/"):
+			text = string(att.Data)
+		case extractor != nil:
+			extracted, err := extractor.Extract(ctx, att.Data, att.MIMEType)
+			if err != nil {
+				slog.Warn("Failed to extract attachment text",
+					"message_id", msg.ID,
+					"attachment", att.Filename,
+					"error", err,
+				)
+				continue
+			}
+			text = extracted
+		default:
+			continue
+		}
+
+		if text = strings.TrimSpace(text); text != "" {
+			fmt.Fprintf(&sb, "\n\nAttachment: %s\n\n%s", att.Filename, text)
+		}
+	}
+
+	return names, sb.String()
+}
+
+func htmlToText(html string) string {
+	text, err := md.NewConverter("", true, nil).ConvertString(html)
+	if err != nil {
+		return strings.TrimSpace(html)
+	}
+	return strings.TrimSpace(text)
+}
+
+func title(subject string) string {
+	if subject == "" {
+		return "(no subject)"
+	}
+	return subject
+}
+
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+func addresses(parser *mail.AddressParser, value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	list, err := parser.ParseList(value)
+	if err != nil {
+		return []string{decodeHeader(value)}
+	}
+	result := make([]string, 0, len(list))
+	for _, addr := range list {
+		result = append(result, formatAddress(addr))
+	}
+	return result
+}
+
+// formatAddress renders an address without re-encoding the display name
+func formatAddress(addr *mail.Address) string {
+	if addr.Name == "" {
+		return addr.Address
+	}
+	return fmt.Sprintf("%s <%s>", addr.Name, addr.Address)
+}
+
+func trimID(id string) string {
+	return strings.Trim(strings.TrimSpace(id), "<>")
+}
+
+func splitIDs(value string) []string {
+	var ids []string
+	for _, field := range strings.Fields(value) {
+		if id := trimID(field); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,154 @@
+package email
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/riclib/hnswindex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const multipartMessage = "From: =?UTF-8?Q?Jos=C3=A9?= <jose@example.com>\r\n" +
+	"To: support@example.com, Ana <ana@example.com>\r\n" +
+	"Subject: =?UTF-8?Q?Login_fails_after_reset?=\r\n" +
+	"Date: Mon, 04 Mar 2024 10:00:00 +0000\r\n" +
+	"Message-ID: <m1@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"I can't log in since the reset, caf=E9 wifi.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>I can't log in since the reset</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; name=\"error.log\"\r\n" +
+	"Content-Disposition: attachment; filename=\"error.log\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"RVJST1IgdG9rZW4g\r\nZXhwaXJlZA==\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf\r\n" +
+	"Content-Disposition: attachment; filename=\"screenshot.pdf\"\r\n" +
+	"\r\n" +
+	"%PDF-1.4\r\n" +
+	"--outer--\r\n"
+
+func TestParseMessage(t *testing.T) {
+	msg, err := ParseMessage(strings.NewReader(multipartMessage))
+	require.NoError(t, err)
+
+	assert.Equal(t, "m1@example.com", msg.ID)
+	assert.Equal(t, "Login fails after reset", msg.Subject)
+	assert.Contains(t, msg.From, "jose@example.com")
+	assert.Contains(t, msg.From, "José")
+	assert.Len(t, msg.To, 2)
+	assert.Equal(t, 2024, msg.Date.Year())
+	assert.Equal(t, "I can't log in since the reset, café wifi.", msg.Body)
+
+	require.Len(t, msg.Attachments, 2)
+	assert.Equal(t, "error.log", msg.Attachments[0].Filename)
+	assert.Equal(t, "ERROR token expired", string(msg.Attachments[0].Data))
+	assert.Equal(t, "application/pdf", msg.Attachments[1].MIMEType)
+}
+
+func TestParseMessage_HTMLOnly(t *testing.T) {
+	raw := "From: a@example.com\r\nSubject: Hi\r\nContent-Type: text/html\r\n\r\n<p>Hello <b>there</b></p>\r\n"
+
+	msg, err := ParseMessage(strings.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello **there**", msg.Body)
+	assert.True(t, strings.HasPrefix(msg.ID, "generated-"), "missing Message-ID gets a stable ID")
+}
+
+func TestDocuments_Messages(t *testing.T) {
+	msg, err := ParseMessage(strings.NewReader(multipartMessage))
+	require.NoError(t, err)
+
+	extractor := hnswindex.ExtractorFunc(func(ctx context.Context, data []byte, mimeType string) (string, error) {
+		return "screenshot of the login page", nil
+	})
+
+	docs := Documents(context.Background(), []*Message{msg}, Options{Mailbox: "support.mbox", Extractor: extractor})
+	require.Len(t, docs, 1)
+
+	doc := docs[0]
+	assert.Equal(t, "email://m1@example.com", doc.URI)
+	assert.Equal(t, "Login fails after reset", doc.Title)
+	assert.Contains(t, doc.Content, "café wifi")
+	assert.Contains(t, doc.Content, "Attachment: error.log\n\nERROR token expired")
+	assert.Contains(t, doc.Content, "screenshot of the login page")
+	assert.Equal(t, "2024-03-04T10:00:00Z", doc.Metadata["date"])
+	assert.Equal(t, "support.mbox", doc.Metadata["mailbox"])
+	assert.Equal(t, []string{"error.log", "screenshot.pdf"}, doc.Metadata["attachments"])
+
+	// Without an extractor binary attachments are listed but not indexed
+	docs = Documents(context.Background(), []*Message{msg}, Options{})
+	assert.NotContains(t, docs[0].Content, "screenshot of the login page")
+}
+
+const mbox = `From jose@example.com Mon Mar  4 10:00:00 2024
+From: jose@example.com
+Subject: Export broken
+Date: Mon, 04 Mar 2024 10:00:00 +0000
+Message-ID: <t1@example.com>
+
+The CSV export is empty.
+>From the logs it looks like a timeout.
+
+From support@example.com Mon Mar  4 11:00:00 2024
+From: support@example.com
+Subject: Re: Export broken
+Date: Mon, 04 Mar 2024 11:00:00 +0000
+Message-ID: <t2@example.com>
+In-Reply-To: <t1@example.com>
+References: <t1@example.com>
+
+Fixed in 2.3.1.
+
+From ana@example.com Tue Mar  5 09:00:00 2024
+From: ana@example.com
+Subject: Invoice question
+Date: Tue, 05 Mar 2024 09:00:00 +0000
+Message-ID: <i1@example.com>
+
+Where is my invoice?
+`
+
+func TestReadMbox(t *testing.T) {
+	msgs, err := ReadMbox(strings.NewReader(mbox))
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+
+	assert.Equal(t, "t1@example.com", msgs[0].ID)
+	assert.Equal(t, "The CSV export is empty.\nFrom the logs it looks like a timeout.", msgs[0].Body)
+	assert.Equal(t, "t1@example.com", msgs[1].ThreadID())
+	assert.Equal(t, "Where is my invoice?", msgs[2].Body)
+}
+
+func TestDocuments_Threads(t *testing.T) {
+	msgs, err := ReadMbox(strings.NewReader(mbox))
+	require.NoError(t, err)
+
+	docs := Documents(context.Background(), msgs, Options{Threads: true})
+	require.Len(t, docs, 2)
+
+	thread := docs[0]
+	assert.Equal(t, "email://thread/t1@example.com", thread.URI)
+	assert.Equal(t, "Export broken", thread.Title)
+	assert.Equal(t, 2, thread.Metadata["message_count"])
+	assert.Equal(t, "2024-03-04T11:00:00Z", thread.Metadata["last_date"])
+	assert.Less(t, strings.Index(thread.Content, "CSV export is empty"), strings.Index(thread.Content, "Fixed in 2.3.1"))
+
+	assert.Equal(t, "email://thread/i1@example.com", docs[1].URI)
+}
@@ -0,0 +1,201 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IMAPConfig selects an IMAP mailbox to fetch
+type IMAPConfig struct {
+	Addr     string // host:port, e.g. "imap.example.com:993"
+	Username string
+	Password string
+	Mailbox  string // Defaults to INBOX
+	Insecure bool   // Connect without TLS (local servers only)
+
+	// SinceUID fetches only messages with a greater UID, for incremental sync
+	SinceUID uint32
+}
+
+var (
+	literalPattern = regexp.MustCompile(`\{(\d+)\}$`)
+	uidPattern     = regexp.MustCompile(`UID (\d+)`)
+)
+
+// FetchIMAP fetches the messages of an IMAP mailbox. It returns the highest
+// UID seen, to pass as SinceUID on the next call.
+func FetchIMAP(ctx context.Context, cfg IMAPConfig) ([]*Message, uint32, error) {
+	if cfg.Addr == "" {
+		return nil, 0, errors.New("IMAP address is required")
+	}
+	mailbox := cfg.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+
+	conn, err := dialIMAP(ctx, cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.close()
+
+	if _, err := conn.command("LOGIN %s %s", quote(cfg.Username), quote(cfg.Password)); err != nil {
+		return nil, 0, fmt.Errorf("IMAP login failed: %w", err)
+	}
+	if _, err := conn.command("EXAMINE %s", quote(mailbox)); err != nil {
+		return nil, 0, fmt.Errorf("failed to open mailbox %s: %w", mailbox, err)
+	}
+
+	responses, err := conn.command("UID FETCH %d:* (UID BODY.PEEK[])", cfg.SinceUID+1)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+
+	var msgs []*Message
+	lastUID := cfg.SinceUID
+	for _, resp := range responses {
+		m := uidPattern.FindStringSubmatch(resp.text)
+		if m == nil || len(resp.literals) == 0 {
+			continue
+		}
+		uid, _ := strconv.ParseUint(m[1], 10, 32)
+		// "N:*" always matches the newest message, even when it is older than N
+		if uint32(uid) <= cfg.SinceUID {
+			continue
+		}
+
+		msg, err := ParseMessage(bytes.NewReader(resp.literals[0]))
+		if err != nil {
+			slog.Warn("Skipping unparseable IMAP message",
+				"uid", uid,
+				"error", err,
+			)
+			continue
+		}
+		msgs = append(msgs, msg)
+		if uint32(uid) > lastUID {
+			lastUID = uint32(uid)
+		}
+	}
+
+	conn.command("LOGOUT")
+
+	slog.Info("IMAP messages fetched",
+		"mailbox", mailbox,
+		"messages", len(msgs),
+		"last_uid", lastUID,
+	)
+	return msgs, lastUID, nil
+}
+
+// imapConn is a minimal IMAP4rev1 client connection
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapResponse is an untagged response line with its literals
+type imapResponse struct {
+	text     string
+	literals [][]byte
+}
+
+func dialIMAP(ctx context.Context, cfg IMAPConfig) (*imapConn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if cfg.Insecure {
+		conn, err = dialer.DialContext(ctx, "tcp", cfg.Addr)
+	} else {
+		conn, err = (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", cfg.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.Addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read IMAP greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting.text, "* OK") && !strings.HasPrefix(greeting.text, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected IMAP greeting: %s", greeting.text)
+	}
+	return c, nil
+}
+
+// command sends a tagged command and returns the untagged responses
+func (c *imapConn) command(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var responses []imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if rest, ok := strings.CutPrefix(resp.text, tag+" "); ok {
+			if !strings.HasPrefix(rest, "OK") {
+				return nil, errors.New(rest)
+			}
+			return responses, nil
+		}
+		responses = append(responses, resp)
+	}
+}
+
+// readResponse reads one response, including any literals it carries
+func (c *imapConn) readResponse() (imapResponse, error) {
+	var resp imapResponse
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return resp, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		resp.text += line
+
+		m := literalPattern.FindStringSubmatch(line)
+		if m == nil {
+			return resp, nil
+		}
+		size, _ := strconv.Atoi(m[1])
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return resp, err
+		}
+		resp.literals = append(resp.literals, literal)
+	}
+}
+
+func (c *imapConn) close() {
+	c.conn.Close()
+}
+
+// quote renders an IMAP quoted string
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,96 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIMAP serves a mailbox holding messages keyed by UID
+func fakeIMAP(t *testing.T, messages map[int]string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveIMAP(conn, messages)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func serveIMAP(conn net.Conn, messages map[int]string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprintf(conn, "* OK fake IMAP ready\r\n")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		tag, cmd := fields[0], strings.ToUpper(fields[1])
+
+		switch cmd {
+		case "LOGIN":
+			if fields[3] != `"secret"` {
+				fmt.Fprintf(conn, "%s NO invalid credentials\r\n", tag)
+				continue
+			}
+		case "EXAMINE":
+			fmt.Fprintf(conn, "* %d EXISTS\r\n", len(messages))
+		case "UID":
+			var from int
+			fmt.Sscanf(fields[3], "%d:", &from)
+			for uid := 1; uid <= len(messages); uid++ {
+				// Like real servers, "N:*" returns the newest message when N is past it
+				if uid >= from || uid == len(messages) {
+					body := messages[uid]
+					fmt.Fprintf(conn, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", uid, uid, len(body), body)
+				}
+			}
+		case "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK done\r\n", tag)
+			return
+		}
+		fmt.Fprintf(conn, "%s OK done\r\n", tag)
+	}
+}
+
+func TestFetchIMAP(t *testing.T) {
+	addr := fakeIMAP(t, map[int]string{
+		1: "From: a@example.com\r\nSubject: First\r\nMessage-ID: <1@x>\r\n\r\nOne\r\n",
+		2: "From: b@example.com\r\nSubject: Second\r\nMessage-ID: <2@x>\r\n\r\nTwo\r\n",
+	})
+
+	cfg := IMAPConfig{Addr: addr, Username: "support", Password: "secret", Insecure: true}
+	msgs, lastUID, err := FetchIMAP(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, uint32(2), lastUID)
+	assert.Equal(t, "Second", msgs[1].Subject)
+
+	// Incremental fetch skips messages already seen
+	cfg.SinceUID = lastUID
+	msgs, lastUID, err = FetchIMAP(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+	assert.Equal(t, uint32(2), lastUID)
+
+	cfg.Password = "wrong"
+	_, _, err = FetchIMAP(context.Background(), cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ReadMbox reads the messages of an mbox file. Messages that fail to parse
+// are logged and skipped.
+func ReadMbox(r io.Reader) ([]*Message, error) {
+	reader := bufio.NewReader(r)
+
+	var msgs []*Message
+	var current bytes.Buffer
+	inMessage := false
+	prevBlank := true
+	line := 0
+	start := 0
+
+	flush := func() {
+		if !inMessage {
+			return
+		}
+		msg, err := ParseMessage(bytes.NewReader(current.Bytes()))
+		if err != nil {
+			slog.Warn("Skipping unparseable mbox message",
+				"line", start,
+				"error", err,
+			)
+		} else {
+			msgs = append(msgs, msg)
+		}
+		current.Reset()
+	}
+
+	for {
+		text, err := reader.ReadString('\n')
+		if len(text) > 0 {
+			line++
+			switch {
+			case prevBlank && strings.HasPrefix(text, "From "):
+				// Separator line starting a new message
+				flush()
+				inMessage = true
+				start = line
+			case inMessage:
+				// Undo mboxrd quoting of body lines starting with "From "
+				if unquoted := strings.TrimLeft(text, ">"); len(unquoted) < len(text) && strings.HasPrefix(unquoted, "From ") {
+					text = text[1:]
+				}
+				current.WriteString(text)
+			}
+			prevBlank = strings.TrimRight(text, "\r\n") == ""
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mbox: %w", err)
+		}
+	}
+	flush()
+
+	return msgs, nil
+}
+
+// ReadMboxFile reads the messages of an mbox file on disk
+func ReadMboxFile(path string) ([]*Message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	return ReadMbox(f)
+}
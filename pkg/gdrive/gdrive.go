@@ -0,0 +1,345 @@
+// Package gdrive downloads Google Docs and Sheets as text through the Drive
+// v3 REST API and tracks incremental changes with Drive change tokens.
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/riclib/hnswindex"
+)
+
+// Google Workspace MIME types that can be exported as text
+const (
+	MimeDocument = "application/vnd.google-apps.document"
+	MimeSheet    = "application/vnd.google-apps.spreadsheet"
+	mimeFolder   = "application/vnd.google-apps.folder"
+)
+
+// DefaultBaseURL is the Drive v3 API endpoint
+const DefaultBaseURL = "https://www.googleapis.com/drive/v3"
+
+// fileFields is the set of file fields requested from the API
+const fileFields = "id,name,mimeType,modifiedTime,parents,webViewLink,trashed,owners(displayName,emailAddress)"
+
+// exportTypes maps exportable MIME types to their text export format
+var exportTypes = map[string]string{
+	MimeDocument: "text/plain",
+	MimeSheet:    "text/csv", // Drive exports the first sheet only
+}
+
+// TokenSource returns an OAuth2 access token for the Drive API
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticToken returns a TokenSource for a fixed access token
+func StaticToken(token string) TokenSource {
+	return func(context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// Client downloads Docs and Sheets from Google Drive
+type Client struct {
+	baseURL string
+	token   TokenSource
+	client  *http.Client
+	folders map[string]folder // Folder cache for path resolution
+}
+
+// folder is a cached Drive folder
+type folder struct {
+	name    string
+	parents []string
+}
+
+// driveFile is the subset of the Drive file resource used here
+type driveFile struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	MimeType     string   `json:"mimeType"`
+	ModifiedTime string   `json:"modifiedTime"`
+	Parents      []string `json:"parents"`
+	WebViewLink  string   `json:"webViewLink"`
+	Trashed      bool     `json:"trashed"`
+	Owners       []struct {
+		DisplayName  string `json:"displayName"`
+		EmailAddress string `json:"emailAddress"`
+	} `json:"owners"`
+}
+
+// SyncResult is the outcome of a full or incremental sync
+type SyncResult struct {
+	Documents []hnswindex.Document // New or modified documents
+	Removed   []string             // URIs of deleted, trashed, or unshared documents
+	NextToken string               // Change token to pass to Changes next time
+}
+
+// NewClient creates a Drive client. An empty baseURL uses DefaultBaseURL.
+func NewClient(baseURL string, token TokenSource) (*Client, error) {
+	if token == nil {
+		return nil, errors.New("token source is required")
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 60 * time.Second},
+		folders: make(map[string]folder),
+	}, nil
+}
+
+// URI returns the document URI of a Drive file
+func URI(fileID string) string {
+	return "gdrive://" + fileID
+}
+
+// FullSync downloads every Doc and Sheet visible to the token, optionally
+// restricted to the direct children of folderID. The returned NextToken
+// marks the point from which Changes continues.
+func (c *Client) FullSync(ctx context.Context, folderID string) (*SyncResult, error) {
+	// Take the change token first so edits made during the sync are not lost
+	var start struct {
+		StartPageToken string `json:"startPageToken"`
+	}
+	if err := c.getJSON(ctx, "/changes/startPageToken", nil, &start); err != nil {
+		return nil, fmt.Errorf("failed to get start page token: %w", err)
+	}
+
+	query := fmt.Sprintf("trashed = false and (mimeType = '%s' or mimeType = '%s')", MimeDocument, MimeSheet)
+	if folderID != "" {
+		query += fmt.Sprintf(" and '%s' in parents", folderID)
+	}
+
+	result := &SyncResult{NextToken: start.StartPageToken}
+	pageToken := ""
+	for {
+		params := url.Values{
+			"q":        {query},
+			"fields":   {"nextPageToken,files(" + fileFields + ")"},
+			"pageSize": {"100"},
+		}
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+		var page struct {
+			NextPageToken string      `json:"nextPageToken"`
+			Files         []driveFile `json:"files"`
+		}
+		if err := c.getJSON(ctx, "/files", params, &page); err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+
+		for _, f := range page.Files {
+			doc, err := c.document(ctx, f)
+			if err != nil {
+				return nil, err
+			}
+			result.Documents = append(result.Documents, doc)
+		}
+
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	slog.Info("Google Drive full sync complete",
+		"documents", len(result.Documents),
+		"folder", folderID,
+	)
+	return result, nil
+}
+
+// Changes returns the documents changed since pageToken. Changes to files
+// outside folderID (when set) or of other types are ignored.
+func (c *Client) Changes(ctx context.Context, pageToken, folderID string) (*SyncResult, error) {
+	if pageToken == "" {
+		return nil, errors.New("page token is required; run a full sync first")
+	}
+
+	result := &SyncResult{}
+	for {
+		params := url.Values{
+			"pageToken": {pageToken},
+			"fields":    {"nextPageToken,newStartPageToken,changes(fileId,removed,file(" + fileFields + "))"},
+			"pageSize":  {"100"},
+		}
+		var page struct {
+			NextPageToken     string `json:"nextPageToken"`
+			NewStartPageToken string `json:"newStartPageToken"`
+			Changes           []struct {
+				FileID  string     `json:"fileId"`
+				Removed bool       `json:"removed"`
+				File    *driveFile `json:"file"`
+			} `json:"changes"`
+		}
+		if err := c.getJSON(ctx, "/changes", params, &page); err != nil {
+			return nil, fmt.Errorf("failed to list changes: %w", err)
+		}
+
+		for _, change := range page.Changes {
+			if change.Removed || change.File == nil || change.File.Trashed {
+				result.Removed = append(result.Removed, URI(change.FileID))
+				continue
+			}
+			if _, ok := exportTypes[change.File.MimeType]; !ok {
+				continue
+			}
+			if folderID != "" && !contains(change.File.Parents, folderID) {
+				continue
+			}
+
+			doc, err := c.document(ctx, *change.File)
+			if err != nil {
+				return nil, err
+			}
+			result.Documents = append(result.Documents, doc)
+		}
+
+		if page.NewStartPageToken != "" {
+			result.NextToken = page.NewStartPageToken
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	slog.Info("Google Drive changes fetched",
+		"changed", len(result.Documents),
+		"removed", len(result.Removed),
+	)
+	return result, nil
+}
+
+// document exports a Drive file and converts it to a Document
+func (c *Client) document(ctx context.Context, f driveFile) (hnswindex.Document, error) {
+	exportType, ok := exportTypes[f.MimeType]
+	if !ok {
+		return hnswindex.Document{}, fmt.Errorf("file %s has unsupported type %s", f.ID, f.MimeType)
+	}
+
+	content, err := c.get(ctx, "/files/"+url.PathEscape(f.ID)+"/export", url.Values{"mimeType": {exportType}})
+	if err != nil {
+		return hnswindex.Document{}, fmt.Errorf("failed to export %s (%s): %w", f.Name, f.ID, err)
+	}
+
+	metadata := map[string]interface{}{
+		"source":        "gdrive",
+		"file_id":       f.ID,
+		"mime_type":     f.MimeType,
+		"modified_time": f.ModifiedTime,
+		"url":           f.WebViewLink,
+	}
+	if len(f.Owners) > 0 {
+		metadata["owner"] = f.Owners[0].DisplayName
+		metadata["owner_email"] = f.Owners[0].EmailAddress
+	}
+	if path, err := c.folderPath(ctx, f.Parents); err == nil {
+		metadata["folder_path"] = path
+	} else {
+		slog.Warn("Failed to resolve Drive folder path",
+			"file_id", f.ID,
+			"error", err,
+		)
+	}
+
+	return hnswindex.Document{
+		URI:      URI(f.ID),
+		Title:    f.Name,
+		Content:  fmt.Sprintf("# %s\n\n%s", f.Name, strings.TrimSpace(string(content))),
+		Metadata: metadata,
+	}, nil
+}
+
+// folderPath resolves the "/"-joined folder names above a file
+func (c *Client) folderPath(ctx context.Context, parents []string) (string, error) {
+	var names []string
+	seen := make(map[string]bool)
+
+	for len(parents) > 0 && !seen[parents[0]] {
+		id := parents[0]
+		seen[id] = true
+
+		f, ok := c.folders[id]
+		if !ok {
+			var meta driveFile
+			if err := c.getJSON(ctx, "/files/"+url.PathEscape(id), url.Values{"fields": {"id,name,mimeType,parents"}}, &meta); err != nil {
+				return "", err
+			}
+			if meta.MimeType != mimeFolder {
+				break
+			}
+			f = folder{name: meta.Name, parents: meta.Parents}
+			c.folders[id] = f
+		}
+
+		names = append([]string{f.name}, names...)
+		parents = f.parents
+	}
+
+	return "/" + strings.Join(names, "/"), nil
+}
+
+// getJSON performs an authorized GET and decodes the JSON response
+func (c *Client) getJSON(ctx context.Context, path string, params url.Values, v interface{}) error {
+	body, err := c.get(ctx, path, params)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// get performs an authorized GET request
+func (c *Client) get(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	reqURL := c.baseURL + path
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("drive request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
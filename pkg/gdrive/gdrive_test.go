@@ -0,0 +1,104 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDrive serves the subset of the Drive API used by the client
+func fakeDrive(t *testing.T) *httptest.Server {
+	files := map[string]map[string]interface{}{
+		"folder-eng":  {"id": "folder-eng", "name": "Engineering", "mimeType": mimeFolder, "parents": []string{"folder-root"}},
+		"folder-root": {"id": "folder-root", "name": "Shared", "mimeType": mimeFolder},
+	}
+	doc := map[string]interface{}{
+		"id": "doc-1", "name": "Runbook", "mimeType": MimeDocument,
+		"modifiedTime": "2024-03-01T10:00:00Z", "parents": []string{"folder-eng"},
+		"webViewLink": "https://docs.google.com/document/d/doc-1",
+		"owners":      []map[string]string{{"displayName": "Ana", "emailAddress": "ana@example.com"}},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		switch {
+		case r.URL.Path == "/changes/startPageToken":
+			json.NewEncoder(w).Encode(map[string]string{"startPageToken": "100"})
+		case r.URL.Path == "/changes":
+			assert.Equal(t, "100", r.URL.Query().Get("pageToken"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"newStartPageToken": "101",
+				"changes": []map[string]interface{}{
+					{"fileId": "doc-1", "file": doc},
+					{"fileId": "doc-2", "removed": true},
+				},
+			})
+		case r.URL.Path == "/files":
+			assert.Contains(t, r.URL.Query().Get("q"), "'folder-eng' in parents")
+			json.NewEncoder(w).Encode(map[string]interface{}{"files": []interface{}{doc}})
+		case r.URL.Path == "/files/doc-1/export":
+			assert.Equal(t, "text/plain", r.URL.Query().Get("mimeType"))
+			w.Write([]byte("Restart the service.\n"))
+		case strings.HasPrefix(r.URL.Path, "/files/"):
+			f, ok := files[strings.TrimPrefix(r.URL.Path, "/files/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(f)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestClient_FullSync(t *testing.T) {
+	server := fakeDrive(t)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, StaticToken("secret"))
+	require.NoError(t, err)
+
+	result, err := client.FullSync(context.Background(), "folder-eng")
+	require.NoError(t, err)
+	assert.Equal(t, "100", result.NextToken)
+	require.Len(t, result.Documents, 1)
+
+	doc := result.Documents[0]
+	assert.Equal(t, "gdrive://doc-1", doc.URI)
+	assert.Equal(t, "Runbook", doc.Title)
+	assert.Equal(t, "# Runbook\n\nRestart the service.", doc.Content)
+	assert.Equal(t, "Ana", doc.Metadata["owner"])
+	assert.Equal(t, "ana@example.com", doc.Metadata["owner_email"])
+	assert.Equal(t, "/Shared/Engineering", doc.Metadata["folder_path"])
+	assert.Equal(t, "2024-03-01T10:00:00Z", doc.Metadata["modified_time"])
+}
+
+func TestClient_Changes(t *testing.T) {
+	server := fakeDrive(t)
+	defer server.Close()
+
+	client, err := NewClient(server.URL, StaticToken("secret"))
+	require.NoError(t, err)
+
+	result, err := client.Changes(context.Background(), "100", "")
+	require.NoError(t, err)
+	assert.Equal(t, "101", result.NextToken)
+	assert.Len(t, result.Documents, 1)
+	assert.Equal(t, []string{"gdrive://doc-2"}, result.Removed)
+
+	// Changes outside the folder are ignored
+	result, err = client.Changes(context.Background(), "100", "folder-other")
+	require.NoError(t, err)
+	assert.Empty(t, result.Documents)
+
+	_, err = client.Changes(context.Background(), "", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+// Package genkit adapts an hnswindex.Index to Firebase Genkit
+// (github.com/firebase/genkit/go) as a retriever, so flows written for
+// Genkit's vector store plugins retrieve from an index. The index embeds
+// queries with its manager's embedder; no Genkit embedder is involved.
+//
+// The package is a module of its own, so applications that do not use
+// Genkit do not depend on it.
+package genkit
+
+import (
+	"context"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/api"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/riclib/hnswindex"
+)
+
+// Provider prefixes the names of the retrievers defined by this package
+const Provider = "hnswindex"
+
+// DefaultK is the number of documents retrieved when RetrieverConfig.K is 0
+const DefaultK = 5
+
+// Metadata keys set on retrieved documents
+const (
+	KeyURI     = "uri"      // Document URI
+	KeyTitle   = "title"    // Document title
+	KeyChunkID = "chunk_id" // Chunk matched
+	KeyScore   = "score"    // Similarity of the chunk to the query
+)
+
+// RetrieverConfig configures a retrieval. Pass it with ai.WithConfig, or
+// as a JSON object from the Developer UI.
+type RetrieverConfig struct {
+	K              int                        `json:"k,omitempty"`              // Documents to retrieve (default DefaultK)
+	Filters        []hnswindex.MetadataFilter `json:"filters,omitempty"`        // Only retrieve documents whose metadata matches
+	ScoreThreshold float64                    `json:"scoreThreshold,omitempty"` // Drop chunks scoring lower
+}
+
+// DefineRetriever defines and registers a retriever named
+// "hnswindex/<name>" that retrieves chunks of index, searched with
+// options. Filters of the request's RetrieverConfig are added to
+// options.Filters.
+func DefineRetriever(g *genkit.Genkit, name string, index *hnswindex.Index, options hnswindex.SearchOptions, opts *ai.RetrieverOptions) *ai.RetrieverAction {
+	return genkit.DefineRetrieverAction(g, api.NewName(Provider, name), opts, retrieverFunc(index, options))
+}
+
+// NewRetriever returns an unregistered retriever like DefineRetriever,
+// for plugins that register their actions in Init
+func NewRetriever(name string, index *hnswindex.Index, options hnswindex.SearchOptions, opts *ai.RetrieverOptions) *ai.RetrieverAction {
+	return ai.NewRetrieverAction(api.NewName(Provider, name), opts, retrieverFunc(index, options))
+}
+
+// Retriever returns the retriever defined with name, or nil
+func Retriever(g *genkit.Genkit, name string) ai.Retriever {
+	return genkit.LookupRetriever(g, api.NewName(Provider, name))
+}
+
+// retrieverFunc returns a function searching index for the text of the
+// request's query
+func retrieverFunc(index *hnswindex.Index, options hnswindex.SearchOptions) ai.RetrieverActionFunc[*RetrieverConfig] {
+	return func(ctx context.Context, req *ai.RetrieverRequest, cfg *RetrieverConfig) (*ai.RetrieverResponse, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return retrieve(index, options, req, cfg)
+	}
+}
+
+// retrieve searches index for the text of the request's query
+func retrieve(index *hnswindex.Index, options hnswindex.SearchOptions, req *ai.RetrieverRequest, cfg *RetrieverConfig) (*ai.RetrieverResponse, error) {
+	if cfg == nil {
+		cfg = &RetrieverConfig{}
+	}
+	k := cfg.K
+	if k <= 0 {
+		k = DefaultK
+	}
+	options.Filters = append(append([]hnswindex.MetadataFilter(nil), options.Filters...), cfg.Filters...)
+
+	results, err := index.SearchWithOptions(queryText(req.Query), k, options)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*ai.Document, 0, len(results))
+	for _, result := range results {
+		if result.Score < cfg.ScoreThreshold {
+			continue
+		}
+		metadata := make(map[string]any, len(result.Document.Metadata)+4)
+		for key, value := range result.Document.Metadata {
+			metadata[key] = value
+		}
+		metadata[KeyURI] = result.Document.URI
+		metadata[KeyTitle] = result.Document.Title
+		metadata[KeyChunkID] = result.ChunkID
+		metadata[KeyScore] = result.Score
+		docs = append(docs, ai.DocumentFromText(result.ChunkText, metadata))
+	}
+	return &ai.RetrieverResponse{Documents: docs}, nil
+}
+
+// queryText joins the text parts of a query document
+func queryText(query *ai.Document) string {
+	if query == nil {
+		return ""
+	}
+	var parts []string
+	for _, part := range query.Content {
+		if part.IsText() {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
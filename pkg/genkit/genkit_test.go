@@ -0,0 +1,88 @@
+package genkit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIndex(t *testing.T) *hnswindex.Index {
+	t.Helper()
+	cfg := hnswindex.NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager, err := hnswindex.NewIndexManager(cfg)
+	require.NoError(t, err)
+	manager.SetEmbedder(embedtest.New(64))
+	t.Cleanup(func() { manager.Close() })
+
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), []hnswindex.Document{
+		{URI: "doc://failover", Title: "Failover", Content: "Database failover runbook", Metadata: map[string]interface{}{"space": "ENG"}},
+		{URI: "doc://refunds", Title: "Refunds", Content: "Refund policy for customers", Metadata: map[string]interface{}{"space": "SUP"}},
+	}, nil)
+	require.NoError(t, err)
+	return index
+}
+
+func TestDefineRetriever(t *testing.T) {
+	ctx := context.Background()
+	g := genkit.Init(ctx)
+	index := newIndex(t)
+	DefineRetriever(g, "kb", index, hnswindex.SearchOptions{}, nil)
+
+	retriever := Retriever(g, "kb")
+	require.NotNil(t, retriever)
+	assert.Equal(t, "hnswindex/kb", retriever.Name())
+
+	resp, err := genkit.Retrieve(ctx, g,
+		ai.WithRetriever(retriever),
+		ai.WithTextDocs("Refund policy for customers"),
+		ai.WithConfig(&RetrieverConfig{K: 1}),
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Documents, 1)
+	doc := resp.Documents[0]
+	assert.Equal(t, "Refund policy for customers", doc.Content[0].Text)
+	assert.Equal(t, "doc://refunds", doc.Metadata[KeyURI])
+	assert.Equal(t, "Refunds", doc.Metadata[KeyTitle])
+	assert.Equal(t, "SUP", doc.Metadata["space"])
+	assert.NotEmpty(t, doc.Metadata[KeyChunkID])
+
+	// Filters narrow results down, from typed or JSON config
+	resp, err = genkit.Retrieve(ctx, g,
+		ai.WithRetriever(retriever),
+		ai.WithTextDocs("Refund policy for customers"),
+		ai.WithConfig(map[string]any{"filters": []map[string]any{{"field": "space", "op": ":", "value": "ENG"}}}),
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Documents, 1)
+	assert.Equal(t, "doc://failover", resp.Documents[0].Metadata[KeyURI])
+
+	resp, err = retriever.Retrieve(ctx, &ai.RetrieverRequest{
+		Query:   ai.DocumentFromText("Refund policy for customers", nil),
+		Options: &RetrieverConfig{ScoreThreshold: 0.9},
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.Documents, 1)
+}
+
+func TestNewRetriever(t *testing.T) {
+	index := newIndex(t)
+	retriever := NewRetriever("kb", index, hnswindex.SearchOptions{
+		Filters: []hnswindex.MetadataFilter{{Field: "space", Op: hnswindex.FilterEq, Value: "ENG"}},
+	}, nil)
+
+	resp, err := retriever.Retrieve(context.Background(), &ai.RetrieverRequest{
+		Query: ai.DocumentFromText("Refund policy for customers", nil),
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Documents, 1)
+	assert.Equal(t, "doc://failover", resp.Documents[0].Metadata[KeyURI])
+}
@@ -0,0 +1,112 @@
+// Package helpcenter downloads published help-center articles from Zendesk
+// Guide or Intercom as documents, with section, category, and locale
+// metadata, for semantic search over support content.
+package helpcenter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/riclib/hnswindex"
+)
+
+// Source downloads the articles of a help center
+type Source interface {
+	// Articles returns the published articles in the given locales.
+	// No locales selects the help center's default locale.
+	Articles(ctx context.Context, locales []string) ([]hnswindex.Document, error)
+
+	// Name identifies the provider, e.g. "zendesk"
+	Name() string
+}
+
+// article is the provider-independent form of an article
+type article struct {
+	id        string
+	title     string
+	body      string // HTML
+	url       string
+	locale    string
+	section   string
+	category  string
+	labels    []string
+	updatedAt time.Time
+}
+
+// document converts an article to a Document
+func (a article) document(provider string, converter *md.Converter) hnswindex.Document {
+	body, err := converter.ConvertString(a.body)
+	if err != nil {
+		slog.Warn("Failed to convert article HTML to markdown",
+			"provider", provider,
+			"article", a.id,
+			"error", err,
+		)
+		body = a.body
+	}
+
+	metadata := map[string]interface{}{
+		"source":     provider,
+		"article_id": a.id,
+		"locale":     a.locale,
+		"url":        a.url,
+	}
+	if a.section != "" {
+		metadata["section"] = a.section
+	}
+	if a.category != "" {
+		metadata["category"] = a.category
+	}
+	if len(a.labels) > 0 {
+		metadata["labels"] = a.labels
+	}
+	if !a.updatedAt.IsZero() {
+		metadata["updated_at"] = a.updatedAt.UTC().Format(time.RFC3339)
+	}
+
+	return hnswindex.Document{
+		URI:      fmt.Sprintf("%s://%s/%s", provider, a.locale, a.id),
+		Title:    a.title,
+		Content:  fmt.Sprintf("# %s\n\n%s", a.title, strings.TrimSpace(body)),
+		Metadata: metadata,
+	}
+}
+
+// getJSON performs a GET request with the given headers and decodes the
+// JSON response
+func getJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %d: %s", url, resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package helpcenter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZendesk_Articles(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "agent@example.com/token", user)
+
+		switch r.URL.Path {
+		case "/api/v2/help_center/en-us/categories.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"categories": []map[string]interface{}{{"id": 1, "name": "Billing"}},
+			})
+		case "/api/v2/help_center/en-us/sections.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"sections": []map[string]interface{}{{"id": 10, "name": "Invoices", "category_id": 1}},
+			})
+		case "/api/v2/help_center/en-us/articles.json":
+			if r.URL.Query().Get("page") == "" {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"articles": []map[string]interface{}{{
+						"id": 100, "title": "Download an invoice", "body": "<p>Open <b>Billing</b>.</p>",
+						"html_url": "https://help.example.com/hc/en-us/articles/100", "locale": "en-us",
+						"section_id": 10, "label_names": []string{"invoice"}, "updated_at": "2024-03-01T10:00:00Z",
+					}},
+					"next_page": server.URL + r.URL.Path + "?page=2",
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"articles": []map[string]interface{}{{"id": 101, "title": "Draft", "locale": "en-us", "draft": true}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewZendesk(server.URL, "agent@example.com", "secret")
+	require.NoError(t, err)
+
+	docs, err := source.Articles(context.Background(), []string{"en-us"})
+	require.NoError(t, err)
+	require.Len(t, docs, 1, "drafts are skipped")
+
+	doc := docs[0]
+	assert.Equal(t, "zendesk://en-us/100", doc.URI)
+	assert.Equal(t, "# Download an invoice\n\nOpen **Billing**.", doc.Content)
+	assert.Equal(t, "Invoices", doc.Metadata["section"])
+	assert.Equal(t, "Billing", doc.Metadata["category"])
+	assert.Equal(t, "en-us", doc.Metadata["locale"])
+	assert.Equal(t, []string{"invoice"}, doc.Metadata["labels"])
+	assert.Equal(t, "2024-03-01T10:00:00Z", doc.Metadata["updated_at"])
+}
+
+func TestIntercom_Articles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/help_center/collections":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": []map[string]interface{}{
+					{"id": "1", "name": "Account", "parent_id": nil},
+					{"id": "2", "name": "Security", "parent_id": 1},
+				},
+				"pages": map[string]int{"total_pages": 1},
+			})
+		case "/articles":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": []map[string]interface{}{
+					{
+						"id": "500", "title": "Enable 2FA", "body": "<p>Go to settings.</p>",
+						"url": "https://help.example.com/en/articles/500", "state": "published",
+						"parent_id": 2, "default_locale": "en", "updated_at": 1709287200,
+						"translated_content": map[string]interface{}{
+							"type": "article_translated_content",
+							"fr":   map[string]string{"title": "Activer 2FA", "body": "<p>Allez dans les réglages.</p>", "state": "published"},
+							"de":   map[string]string{"title": "2FA", "body": "<p>Entwurf</p>", "state": "draft"},
+						},
+					},
+					{"id": "501", "title": "Unpublished", "state": "draft", "default_locale": "en"},
+				},
+				"pages": map[string]int{"total_pages": 1},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewIntercom(server.URL, "secret")
+	require.NoError(t, err)
+
+	docs, err := source.Articles(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "intercom://en/500", docs[0].URI)
+	assert.Equal(t, "Security", docs[0].Metadata["section"])
+	assert.Equal(t, "Account", docs[0].Metadata["category"])
+
+	docs, err = source.Articles(context.Background(), []string{"fr", "de"})
+	require.NoError(t, err)
+	require.Len(t, docs, 1, "unpublished translations are skipped")
+	assert.Equal(t, "intercom://fr/500", docs[0].URI)
+	assert.Equal(t, "Activer 2FA", docs[0].Title)
+	assert.Equal(t, "fr", docs[0].Metadata["locale"])
+}
@@ -0,0 +1,215 @@
+package helpcenter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/riclib/hnswindex"
+)
+
+// DefaultIntercomURL is the Intercom REST API endpoint
+const DefaultIntercomURL = "https://api.intercom.io"
+
+// intercomVersion is the API version the client is written against
+const intercomVersion = "2.11"
+
+// Intercom downloads articles from an Intercom help center
+type Intercom struct {
+	baseURL   string
+	token     string
+	client    *http.Client
+	converter *md.Converter
+}
+
+// NewIntercom creates an Intercom source. An empty baseURL uses
+// DefaultIntercomURL.
+func NewIntercom(baseURL, accessToken string) (*Intercom, error) {
+	if accessToken == "" {
+		return nil, errors.New("access token is required")
+	}
+	if baseURL == "" {
+		baseURL = DefaultIntercomURL
+	}
+
+	return &Intercom{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		token:     accessToken,
+		client:    &http.Client{Timeout: 60 * time.Second},
+		converter: md.NewConverter("", true, nil),
+	}, nil
+}
+
+// Name returns "intercom"
+func (ic *Intercom) Name() string {
+	return "intercom"
+}
+
+type intercomContent struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+	State string `json:"state"`
+}
+
+type intercomArticle struct {
+	intercomContent
+	ID                flexID                     `json:"id"`
+	ParentID          flexID                     `json:"parent_id"`
+	DefaultLocale     string                     `json:"default_locale"`
+	UpdatedAt         int64                      `json:"updated_at"`
+	TranslatedContent map[string]json.RawMessage `json:"translated_content"` // Also holds a "type" string
+}
+
+type intercomCollection struct {
+	ID       flexID `json:"id"`
+	Name     string `json:"name"`
+	ParentID flexID `json:"parent_id"`
+}
+
+// Articles returns the published articles in each locale. Articles without
+// a published translation in a requested locale are skipped for it.
+func (ic *Intercom) Articles(ctx context.Context, locales []string) ([]hnswindex.Document, error) {
+	collections, err := ic.collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var articles []intercomArticle
+	for page, totalPages := 1, 1; page <= totalPages; page++ {
+		var resp struct {
+			Data  []intercomArticle `json:"data"`
+			Pages struct {
+				TotalPages int `json:"total_pages"`
+			} `json:"pages"`
+		}
+		if err := getJSON(ctx, ic.client, fmt.Sprintf("%s/articles?page=%d&per_page=50", ic.baseURL, page), ic.headers(), &resp); err != nil {
+			return nil, fmt.Errorf("failed to list articles: %w", err)
+		}
+		articles = append(articles, resp.Data...)
+		totalPages = resp.Pages.TotalPages
+	}
+
+	var docs []hnswindex.Document
+	for _, a := range articles {
+		section, category := collectionPath(collections, a.ParentID)
+
+		wanted := locales
+		if len(wanted) == 0 {
+			wanted = []string{a.DefaultLocale}
+		}
+		for _, locale := range wanted {
+			content := &a.intercomContent
+			if locale != a.DefaultLocale {
+				content = translation(a.TranslatedContent[locale])
+			}
+			if content == nil || content.State != "published" {
+				continue
+			}
+
+			docs = append(docs, article{
+				id:        string(a.ID),
+				title:     content.Title,
+				body:      content.Body,
+				url:       content.URL,
+				locale:    locale,
+				section:   section,
+				category:  category,
+				updatedAt: time.Unix(a.UpdatedAt, 0),
+			}.document(ic.Name(), ic.converter))
+		}
+	}
+
+	slog.Info("Intercom articles downloaded",
+		"articles", len(articles),
+		"documents", len(docs),
+	)
+	return docs, nil
+}
+
+// translation decodes one locale of an article's translated content
+func translation(raw json.RawMessage) *intercomContent {
+	if len(raw) == 0 {
+		return nil
+	}
+	var content intercomContent
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return nil
+	}
+	return &content
+}
+
+// collections returns the help center collections keyed by ID
+func (ic *Intercom) collections(ctx context.Context) (map[flexID]intercomCollection, error) {
+	collections := make(map[flexID]intercomCollection)
+	for page, totalPages := 1, 1; page <= totalPages; page++ {
+		var resp struct {
+			Data  []intercomCollection `json:"data"`
+			Pages struct {
+				TotalPages int `json:"total_pages"`
+			} `json:"pages"`
+		}
+		if err := getJSON(ctx, ic.client, fmt.Sprintf("%s/help_center/collections?page=%d&per_page=50", ic.baseURL, page), ic.headers(), &resp); err != nil {
+			return nil, fmt.Errorf("failed to list collections: %w", err)
+		}
+		for _, c := range resp.Data {
+			collections[c.ID] = c
+		}
+		totalPages = resp.Pages.TotalPages
+	}
+	return collections, nil
+}
+
+// collectionPath maps an article's collection to section and category:
+// the collection itself is the section and its top-level ancestor the
+// category
+func collectionPath(collections map[flexID]intercomCollection, id flexID) (string, string) {
+	c, ok := collections[id]
+	if !ok {
+		return "", ""
+	}
+
+	top := c
+	for depth := 0; top.ParentID != "" && depth < 10; depth++ {
+		parent, ok := collections[top.ParentID]
+		if !ok {
+			break
+		}
+		top = parent
+	}
+	return c.Name, top.Name
+}
+
+func (ic *Intercom) headers() map[string]string {
+	return map[string]string{
+		"Authorization":    "Bearer " + ic.token,
+		"Intercom-Version": intercomVersion,
+	}
+}
+
+// flexID is an ID that the API returns either as a number or a string
+type flexID string
+
+func (id *flexID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*id = ""
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = flexID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*id = flexID(n.String())
+	return nil
+}
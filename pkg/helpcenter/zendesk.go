@@ -0,0 +1,186 @@
+package helpcenter
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/riclib/hnswindex"
+)
+
+// Zendesk downloads articles from a Zendesk Guide help center
+type Zendesk struct {
+	baseURL   string
+	auth      string
+	client    *http.Client
+	converter *md.Converter
+}
+
+// NewZendesk creates a Zendesk source for a help center such as
+// https://company.zendesk.com. With an email and API token the request is
+// authenticated; without them only public articles are returned.
+func NewZendesk(baseURL, email, apiToken string) (*Zendesk, error) {
+	if baseURL == "" {
+		return nil, errors.New("base URL is required")
+	}
+
+	z := &Zendesk{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		client:    &http.Client{Timeout: 60 * time.Second},
+		converter: md.NewConverter("", true, nil),
+	}
+	if email != "" && apiToken != "" {
+		credentials := email + "/token:" + apiToken
+		z.auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	return z, nil
+}
+
+// Name returns "zendesk"
+func (z *Zendesk) Name() string {
+	return "zendesk"
+}
+
+type zendeskArticle struct {
+	ID         int64     `json:"id"`
+	Title      string    `json:"title"`
+	Body       string    `json:"body"`
+	HTMLURL    string    `json:"html_url"`
+	Locale     string    `json:"locale"`
+	SectionID  int64     `json:"section_id"`
+	LabelNames []string  `json:"label_names"`
+	Draft      bool      `json:"draft"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type zendeskSection struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	CategoryID int64  `json:"category_id"`
+}
+
+type zendeskCategory struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Articles returns the published articles in each locale
+func (z *Zendesk) Articles(ctx context.Context, locales []string) ([]hnswindex.Document, error) {
+	if len(locales) == 0 {
+		locales = []string{""}
+	}
+
+	var docs []hnswindex.Document
+	for _, locale := range locales {
+		sections, err := z.sections(ctx, locale)
+		if err != nil {
+			return nil, err
+		}
+
+		var articles []zendeskArticle
+		next := z.url(locale, "articles.json")
+		for next != "" {
+			var page struct {
+				Articles []zendeskArticle `json:"articles"`
+				NextPage string           `json:"next_page"`
+			}
+			if err := getJSON(ctx, z.client, next, z.headers(), &page); err != nil {
+				return nil, fmt.Errorf("failed to list articles: %w", err)
+			}
+			articles = append(articles, page.Articles...)
+			next = page.NextPage
+		}
+
+		for _, a := range articles {
+			if a.Draft {
+				continue
+			}
+			section := sections[a.SectionID]
+			docs = append(docs, article{
+				id:        strconv.FormatInt(a.ID, 10),
+				title:     a.Title,
+				body:      a.Body,
+				url:       a.HTMLURL,
+				locale:    a.Locale,
+				section:   section.name,
+				category:  section.category,
+				labels:    a.LabelNames,
+				updatedAt: a.UpdatedAt,
+			}.document(z.Name(), z.converter))
+		}
+
+		slog.Info("Zendesk articles downloaded",
+			"locale", locale,
+			"articles", len(articles),
+		)
+	}
+
+	return docs, nil
+}
+
+// namedSection is a section with its resolved category name
+type namedSection struct {
+	name     string
+	category string
+}
+
+// sections returns the sections of a locale keyed by ID
+func (z *Zendesk) sections(ctx context.Context, locale string) (map[int64]namedSection, error) {
+	categories := make(map[int64]string)
+	next := z.url(locale, "categories.json")
+	for next != "" {
+		var page struct {
+			Categories []zendeskCategory `json:"categories"`
+			NextPage   string            `json:"next_page"`
+		}
+		if err := getJSON(ctx, z.client, next, z.headers(), &page); err != nil {
+			return nil, fmt.Errorf("failed to list categories: %w", err)
+		}
+		for _, c := range page.Categories {
+			categories[c.ID] = c.Name
+		}
+		next = page.NextPage
+	}
+
+	sections := make(map[int64]namedSection)
+	next = z.url(locale, "sections.json")
+	for next != "" {
+		var page struct {
+			Sections []zendeskSection `json:"sections"`
+			NextPage string           `json:"next_page"`
+		}
+		if err := getJSON(ctx, z.client, next, z.headers(), &page); err != nil {
+			return nil, fmt.Errorf("failed to list sections: %w", err)
+		}
+		for _, s := range page.Sections {
+			sections[s.ID] = namedSection{name: s.Name, category: categories[s.CategoryID]}
+		}
+		next = page.NextPage
+	}
+
+	return sections, nil
+}
+
+// url returns the first page URL of a help center resource
+func (z *Zendesk) url(locale, resource string) string {
+	path := "/api/v2/help_center/"
+	if locale != "" {
+		path += url.PathEscape(locale) + "/"
+	}
+	return z.baseURL + path + resource + "?per_page=100"
+}
+
+func (z *Zendesk) headers() map[string]string {
+	if z.auth == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": z.auth}
+}
@@ -0,0 +1,219 @@
+// Package langchain adapts an hnswindex.Index to LangChainGo
+// (github.com/tmc/langchaingo): Store is a vectorstores.VectorStore and
+// NewRetriever returns a schema.Retriever, so an index plugs into chains
+// and agents built for other vector stores. The index embeds documents and
+// queries with its manager's embedder.
+//
+// The package is a module of its own, so applications that do not use
+// LangChainGo do not depend on it.
+package langchain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// Metadata keys set on returned documents, and read from added ones
+const (
+	KeyURI     = "uri"      // Document URI
+	KeyTitle   = "title"    // Document title
+	KeyChunkID = "chunk_id" // Chunk matched (returned documents only)
+)
+
+// ErrEmbedderOption is returned for vectorstores.WithEmbedder: the index
+// embeds with the embedder of its manager
+var ErrEmbedderOption = errors.New("hnswindex embeds with the manager's embedder; WithEmbedder is not supported")
+
+// Store is a LangChainGo vector store backed by an index
+type Store struct {
+	index *hnswindex.Index
+
+	// Options are the search options of every SimilaritySearch, such as
+	// SparseWeight for hybrid search. Filters passed with
+	// vectorstores.WithFilters are added to Options.Filters.
+	Options hnswindex.SearchOptions
+}
+
+var _ vectorstores.VectorStore = (*Store)(nil)
+
+// New returns a store backed by index
+func New(index *hnswindex.Index) *Store {
+	return &Store{index: index}
+}
+
+// NewRetriever returns a retriever of the numDocuments chunks most similar
+// to each query
+func NewRetriever(index *hnswindex.Index, numDocuments int, options ...vectorstores.Option) vectorstores.Retriever {
+	return vectorstores.ToRetriever(New(index), numDocuments, options...)
+}
+
+// AddDocuments indexes documents, returning their URIs. The URI is the
+// KeyURI metadata value if it is a string, and otherwise derived from the
+// content, so adding the same text twice indexes it once. Documents that
+// fail to index are reported in the error.
+func (s *Store) AddDocuments(ctx context.Context, docs []schema.Document, options ...vectorstores.Option) ([]string, error) {
+	opts := getOptions(options)
+	if opts.Embedder != nil {
+		return nil, ErrEmbedderOption
+	}
+
+	batch := make([]hnswindex.Document, 0, len(docs))
+	uris := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		converted := toDocument(doc)
+		batch = append(batch, converted)
+		uris = append(uris, converted.URI)
+	}
+
+	result, err := s.index.AddDocumentBatch(ctx, batch, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.FailedURIs) > 0 {
+		failed := make([]string, 0, len(result.FailedURIs))
+		for uri, reason := range result.FailedURIs {
+			failed = append(failed, fmt.Sprintf("%s: %s", uri, reason))
+		}
+		sort.Strings(failed)
+		return uris, fmt.Errorf("failed to index %d documents: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return uris, nil
+}
+
+// SimilaritySearch returns the numDocuments chunks most similar to query,
+// as documents holding the chunk text, the metadata of the document it
+// belongs to, and its score. WithScoreThreshold drops chunks scoring
+// lower, and WithFilters takes []hnswindex.MetadataFilter, a single
+// hnswindex.MetadataFilter, or a map of metadata fields to the value they
+// must equal. The namespace is ignored.
+func (s *Store) SimilaritySearch(ctx context.Context, query string, numDocuments int, options ...vectorstores.Option) ([]schema.Document, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	opts := getOptions(options)
+	if opts.Embedder != nil {
+		return nil, ErrEmbedderOption
+	}
+
+	search := s.Options
+	filters, err := toFilters(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+	search.Filters = append(append([]hnswindex.MetadataFilter(nil), search.Filters...), filters...)
+
+	results, err := s.index.SearchWithOptions(query, numDocuments, search)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]schema.Document, 0, len(results))
+	for _, result := range results {
+		if float32(result.Score) < opts.ScoreThreshold {
+			continue
+		}
+		doc := fromResult(result)
+		if opts.Deduplicater != nil && opts.Deduplicater(ctx, doc) {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// getOptions applies vector store options
+func getOptions(options []vectorstores.Option) vectorstores.Options {
+	var opts vectorstores.Options
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts
+}
+
+// toDocument converts a LangChainGo document for indexing
+func toDocument(doc schema.Document) hnswindex.Document {
+	converted := hnswindex.Document{
+		Content:  doc.PageContent,
+		Metadata: make(map[string]interface{}, len(doc.Metadata)),
+	}
+	for key, value := range doc.Metadata {
+		switch key {
+		case KeyURI:
+			if uri, ok := value.(string); ok {
+				converted.URI = uri
+				continue
+			}
+		case KeyTitle:
+			if title, ok := value.(string); ok {
+				converted.Title = title
+				continue
+			}
+		}
+		converted.Metadata[key] = value
+	}
+	if converted.URI == "" {
+		sum := sha256.Sum256([]byte(doc.PageContent))
+		converted.URI = "langchain:" + hex.EncodeToString(sum[:])
+	}
+	return converted
+}
+
+// fromResult converts a search result into a LangChainGo document
+func fromResult(result hnswindex.SearchResult) schema.Document {
+	metadata := make(map[string]any, len(result.Document.Metadata)+3)
+	for key, value := range result.Document.Metadata {
+		metadata[key] = value
+	}
+	metadata[KeyURI] = result.Document.URI
+	metadata[KeyTitle] = result.Document.Title
+	metadata[KeyChunkID] = result.ChunkID
+	return schema.Document{
+		PageContent: result.ChunkText,
+		Metadata:    metadata,
+		Score:       float32(result.Score),
+	}
+}
+
+// toFilters converts the filters passed with vectorstores.WithFilters
+func toFilters(filters any) ([]hnswindex.MetadataFilter, error) {
+	switch f := filters.(type) {
+	case nil:
+		return nil, nil
+	case []hnswindex.MetadataFilter:
+		return f, nil
+	case hnswindex.MetadataFilter:
+		return []hnswindex.MetadataFilter{f}, nil
+	case map[string]string:
+		converted := make(map[string]any, len(f))
+		for field, value := range f {
+			converted[field] = value
+		}
+		return toFilters(converted)
+	case map[string]any:
+		fields := make([]string, 0, len(f))
+		for field := range f {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		converted := make([]hnswindex.MetadataFilter, 0, len(f))
+		for _, field := range fields {
+			converted = append(converted, hnswindex.MetadataFilter{
+				Field: field,
+				Op:    hnswindex.FilterEq,
+				Value: fmt.Sprint(f[field]),
+			})
+		}
+		return converted, nil
+	default:
+		return nil, fmt.Errorf("unsupported filters of type %T", filters)
+	}
+}
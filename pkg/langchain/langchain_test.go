@@ -0,0 +1,84 @@
+package langchain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+func newIndex(t *testing.T) *hnswindex.Index {
+	t.Helper()
+	cfg := hnswindex.NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager, err := hnswindex.NewIndexManager(cfg)
+	require.NoError(t, err)
+	manager.SetEmbedder(embedtest.New(64))
+	t.Cleanup(func() { manager.Close() })
+
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	return index
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	index := newIndex(t)
+	store := New(index)
+
+	uris, err := store.AddDocuments(ctx, []schema.Document{
+		{PageContent: "Database failover runbook", Metadata: map[string]any{"uri": "doc://failover", "title": "Failover", "space": "ENG"}},
+		{PageContent: "Refund policy for customers", Metadata: map[string]any{"space": "SUP"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, uris, 2)
+	assert.Equal(t, "doc://failover", uris[0])
+	assert.Contains(t, uris[1], "langchain:")
+
+	doc, err := index.GetDocument("doc://failover")
+	require.NoError(t, err)
+	assert.Equal(t, "Failover", doc.Title)
+	assert.Equal(t, map[string]interface{}{"space": "ENG"}, doc.Metadata)
+
+	docs, err := store.SimilaritySearch(ctx, "Refund policy for customers", 1)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "Refund policy for customers", docs[0].PageContent)
+	assert.Equal(t, uris[1], docs[0].Metadata[KeyURI])
+	assert.Equal(t, "SUP", docs[0].Metadata["space"])
+	assert.NotEmpty(t, docs[0].Metadata[KeyChunkID])
+	assert.Greater(t, docs[0].Score, float32(0.9))
+
+	// Filters and thresholds narrow results down
+	docs, err = store.SimilaritySearch(ctx, "Refund policy for customers", 5, vectorstores.WithFilters(map[string]any{"space": "ENG"}))
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "doc://failover", docs[0].Metadata[KeyURI])
+	docs, err = store.SimilaritySearch(ctx, "Refund policy for customers", 5, vectorstores.WithScoreThreshold(0.9))
+	require.NoError(t, err)
+	assert.Len(t, docs, 1)
+
+	_, err = store.SimilaritySearch(ctx, "refunds", 5, vectorstores.WithFilters(42))
+	assert.ErrorContains(t, err, "unsupported filters")
+}
+
+func TestNewRetriever(t *testing.T) {
+	ctx := context.Background()
+	index := newIndex(t)
+	_, err := New(index).AddDocuments(ctx, []schema.Document{
+		{PageContent: "Database failover runbook", Metadata: map[string]any{"uri": "doc://failover"}},
+		{PageContent: "Refund policy for customers", Metadata: map[string]any{"uri": "doc://refunds"}},
+	})
+	require.NoError(t, err)
+
+	var retriever schema.Retriever = NewRetriever(index, 1)
+	docs, err := retriever.GetRelevantDocuments(ctx, "Database failover runbook")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "doc://failover", docs[0].Metadata[KeyURI])
+}
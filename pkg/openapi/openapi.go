@@ -0,0 +1,445 @@
+// Package openapi explodes an OpenAPI 3 or Swagger 2 specification into one
+// document per endpoint, so a large API surface can be searched semantically.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+	"gopkg.in/yaml.v3"
+)
+
+// maxRefDepth bounds $ref resolution and schema rendering
+const maxRefDepth = 8
+
+// methods are the operation keys of a path item, in display order
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Spec is a parsed OpenAPI or Swagger document
+type Spec struct {
+	root    map[string]interface{}
+	Title   string
+	Version string
+}
+
+// Parse parses a JSON or YAML specification
+func Parse(data []byte) (*Spec, error) {
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse specification: %w", err)
+	}
+	if root == nil {
+		return nil, errors.New("empty specification")
+	}
+	root = normalize(root).(map[string]interface{})
+	if str(root, "openapi") == "" && str(root, "swagger") == "" {
+		return nil, errors.New("not an OpenAPI or Swagger document")
+	}
+
+	info := obj(root, "info")
+	return &Spec{
+		root:    root,
+		Title:   str(info, "title"),
+		Version: str(info, "version"),
+	}, nil
+}
+
+// LoadFile reads a specification from disk and returns its documents
+func LoadFile(path string) ([]hnswindex.Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec.Documents(path), nil
+}
+
+// Documents returns one document per operation. source is recorded in
+// document metadata.
+func (s *Spec) Documents(source string) []hnswindex.Document {
+	paths := obj(s.root, "paths")
+	names := make([]string, 0, len(paths))
+	for path := range paths {
+		names = append(names, path)
+	}
+	sort.Strings(names)
+
+	var docs []hnswindex.Document
+	for _, path := range names {
+		item := s.resolve(obj(paths, path), 0)
+		shared := list(item, "parameters")
+
+		for _, method := range methods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			docs = append(docs, s.operationDocument(source, method, path, op, shared))
+		}
+	}
+	return docs
+}
+
+// URI returns the document URI of an operation
+func (s *Spec) URI(method, path string) string {
+	api := s.Title
+	if api == "" {
+		api = "api"
+	}
+	return fmt.Sprintf("openapi://%s/%s%s", url.PathEscape(api), strings.ToUpper(method), path)
+}
+
+// operationDocument renders one operation
+func (s *Spec) operationDocument(source, method, path string, op map[string]interface{}, shared []interface{}) hnswindex.Document {
+	endpoint := strings.ToUpper(method) + " " + path
+	summary := str(op, "summary")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", endpoint)
+	if summary != "" {
+		fmt.Fprintf(&sb, "%s\n\n", summary)
+	}
+	if desc := str(op, "description"); desc != "" {
+		fmt.Fprintf(&sb, "%s\n\n", strings.TrimSpace(desc))
+	}
+	if id := str(op, "operationId"); id != "" {
+		fmt.Fprintf(&sb, "Operation ID: %s\n", id)
+	}
+	if tags := strings.Join(stringList(list(op, "tags")), ", "); tags != "" {
+		fmt.Fprintf(&sb, "Tags: %s\n", tags)
+	}
+	if deprecated, _ := op["deprecated"].(bool); deprecated {
+		sb.WriteString("Deprecated: yes\n")
+	}
+
+	s.writeParameters(&sb, append(append([]interface{}{}, shared...), list(op, "parameters")...))
+	s.writeRequestBody(&sb, op)
+	s.writeResponses(&sb, op)
+
+	metadata := map[string]interface{}{
+		"source":      "openapi",
+		"spec_file":   source,
+		"api_title":   s.Title,
+		"api_version": s.Version,
+		"method":      strings.ToUpper(method),
+		"path":        path,
+	}
+	if id := str(op, "operationId"); id != "" {
+		metadata["operation_id"] = id
+	}
+	if tagList := stringList(list(op, "tags")); len(tagList) > 0 {
+		metadata["tags"] = tagList
+	}
+	if deprecated, _ := op["deprecated"].(bool); deprecated {
+		metadata["deprecated"] = true
+	}
+
+	title := endpoint
+	if summary != "" {
+		title = endpoint + " - " + summary
+	}
+
+	return hnswindex.Document{
+		URI:      s.URI(method, path),
+		Title:    title,
+		Content:  strings.TrimSpace(sb.String()),
+		Metadata: metadata,
+	}
+}
+
+// writeParameters renders path, query, header, and cookie parameters.
+// Swagger 2 body parameters are rendered as a request body.
+func (s *Spec) writeParameters(sb *strings.Builder, params []interface{}) {
+	var lines []string
+	for _, raw := range params {
+		p := s.resolve(asObj(raw), 0)
+		if str(p, "in") == "body" {
+			fmt.Fprintf(sb, "\n## Request body\n\n")
+			s.writeSchema(sb, obj(p, "schema"))
+			continue
+		}
+
+		typ := str(p, "type") // Swagger 2
+		if schema := s.resolve(obj(p, "schema"), 0); typ == "" && schema != nil {
+			typ = schemaType(schema)
+		}
+		line := fmt.Sprintf("- %s (%s", str(p, "name"), str(p, "in"))
+		if typ != "" {
+			line += ", " + typ
+		}
+		if required, _ := p["required"].(bool); required {
+			line += ", required"
+		}
+		line += ")"
+		if desc := str(p, "description"); desc != "" {
+			line += ": " + oneLine(desc)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) > 0 {
+		fmt.Fprintf(sb, "\n## Parameters\n\n%s\n", strings.Join(lines, "\n"))
+	}
+}
+
+// writeRequestBody renders an OpenAPI 3 request body
+func (s *Spec) writeRequestBody(sb *strings.Builder, op map[string]interface{}) {
+	body := s.resolve(obj(op, "requestBody"), 0)
+	if body == nil {
+		return
+	}
+
+	fmt.Fprintf(sb, "\n## Request body\n\n")
+	if desc := str(body, "description"); desc != "" {
+		fmt.Fprintf(sb, "%s\n\n", strings.TrimSpace(desc))
+	}
+	s.writeContent(sb, obj(body, "content"))
+}
+
+// writeResponses renders the responses of an operation
+func (s *Spec) writeResponses(sb *strings.Builder, op map[string]interface{}) {
+	responses := obj(op, "responses")
+	if len(responses) == 0 {
+		return
+	}
+
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	fmt.Fprintf(sb, "\n## Responses\n")
+	for _, code := range codes {
+		resp := s.resolve(asObj(responses[code]), 0)
+		fmt.Fprintf(sb, "\n### %s\n\n", code)
+		if desc := str(resp, "description"); desc != "" {
+			fmt.Fprintf(sb, "%s\n", strings.TrimSpace(desc))
+		}
+
+		if content := obj(resp, "content"); content != nil {
+			s.writeContent(sb, content)
+			continue
+		}
+
+		// Swagger 2 responses
+		if schema := obj(resp, "schema"); schema != nil {
+			sb.WriteString("\n")
+			s.writeSchema(sb, schema)
+		}
+		if examples := obj(resp, "examples"); len(examples) > 0 {
+			writeExample(sb, examples[firstKey(examples)])
+		}
+	}
+}
+
+// writeContent renders the schema and example of the first media type
+func (s *Spec) writeContent(sb *strings.Builder, content map[string]interface{}) {
+	if len(content) == 0 {
+		return
+	}
+
+	types := make([]string, 0, len(content))
+	for mediaType := range content {
+		types = append(types, mediaType)
+	}
+	sort.Strings(types)
+
+	// Prefer JSON when several media types are offered
+	mediaType := types[0]
+	for _, t := range types {
+		if strings.Contains(t, "json") {
+			mediaType = t
+			break
+		}
+	}
+
+	media := asObj(content[mediaType])
+	fmt.Fprintf(sb, "\nContent type: %s\n", mediaType)
+	if schema := obj(media, "schema"); schema != nil {
+		sb.WriteString("\n")
+		s.writeSchema(sb, schema)
+	}
+
+	if example, ok := media["example"]; ok {
+		writeExample(sb, example)
+	} else if examples := obj(media, "examples"); len(examples) > 0 {
+		example := s.resolve(asObj(examples[firstKey(examples)]), 0)
+		writeExample(sb, example["value"])
+	}
+}
+
+// writeSchema renders the top-level properties of a schema
+func (s *Spec) writeSchema(sb *strings.Builder, schema map[string]interface{}) {
+	schema = s.resolve(schema, 0)
+	if schema == nil {
+		return
+	}
+	if str(schema, "type") == "array" {
+		fmt.Fprintf(sb, "Array of %s\n", schemaType(obj(schema, "items")))
+		schema = s.resolve(obj(schema, "items"), 0)
+	}
+
+	props := obj(schema, "properties")
+	if len(props) == 0 {
+		if typ := schemaType(schema); typ != "" {
+			fmt.Fprintf(sb, "Schema: %s\n", typ)
+		}
+		return
+	}
+
+	required := make(map[string]bool)
+	for _, name := range stringList(list(schema, "required")) {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb.WriteString("Fields:\n")
+	for _, name := range names {
+		prop := s.resolve(asObj(props[name]), 0)
+		line := fmt.Sprintf("- %s (%s", name, schemaType(prop))
+		if required[name] {
+			line += ", required"
+		}
+		line += ")"
+		if desc := str(prop, "description"); desc != "" {
+			line += ": " + oneLine(desc)
+		}
+		sb.WriteString(line + "\n")
+	}
+}
+
+// writeExample renders an example value as JSON
+func writeExample(sb *strings.Builder, example interface{}) {
+	if example == nil {
+		return
+	}
+	data, err := json.MarshalIndent(example, "", "  ")
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(sb, "\nExample:\n```json\n%s\n```\n", data)
+}
+
+// resolve follows local $ref pointers such as "#/components/schemas/User"
+func (s *Spec) resolve(node map[string]interface{}, depth int) map[string]interface{} {
+	ref := str(node, "$ref")
+	if ref == "" || depth >= maxRefDepth || !strings.HasPrefix(ref, "#/") {
+		return node
+	}
+
+	var current interface{} = s.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		current = m[token]
+	}
+
+	target, ok := current.(map[string]interface{})
+	if !ok {
+		return node
+	}
+	return s.resolve(target, depth+1)
+}
+
+// schemaType describes a schema's type, e.g. "string (uuid)" or "User"
+func schemaType(schema map[string]interface{}) string {
+	if ref := str(schema, "$ref"); ref != "" {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	typ := str(schema, "type")
+	if format := str(schema, "format"); format != "" {
+		typ += " (" + format + ")"
+	}
+	if enum := stringList(list(schema, "enum")); len(enum) > 0 {
+		typ += " one of " + strings.Join(enum, ", ")
+	}
+	return typ
+}
+
+// normalize converts YAML maps with non-string keys (such as unquoted
+// response codes) to string-keyed maps
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalize(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = normalize(value)
+		}
+		return m
+	case []interface{}:
+		for idx, value := range v {
+			v[idx] = normalize(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func str(m map[string]interface{}, key string) string {
+	if v, ok := m[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func obj(m map[string]interface{}, key string) map[string]interface{} {
+	return asObj(m[key])
+}
+
+func asObj(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func list(m map[string]interface{}, key string) []interface{} {
+	l, _ := m[key].([]interface{})
+	return l
+}
+
+func stringList(values []interface{}) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, fmt.Sprint(v))
+	}
+	return result
+}
+
+// firstKey returns the alphabetically first key of a map
+func firstKey(m map[string]interface{}) string {
+	first := ""
+	for key := range m {
+		if first == "" || key < first {
+			first = key
+		}
+	}
+	return first
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,161 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petstoreYAML = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.2.0
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetId'
+    get:
+      summary: Get a pet
+      description: Returns a single pet by its ID.
+      operationId: getPet
+      tags: [pets]
+      responses:
+        200:
+          description: The pet
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+              example:
+                id: 7
+                name: Rex
+        404:
+          description: Pet not found
+    delete:
+      summary: Delete a pet
+      deprecated: true
+      responses:
+        '204':
+          description: Deleted
+  /pets:
+    post:
+      summary: Create a pet
+      requestBody:
+        description: The pet to create
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+            examples:
+              basic:
+                value: {name: Rex}
+      responses:
+        '201':
+          description: Created
+components:
+  parameters:
+    PetId:
+      name: petId
+      in: path
+      required: true
+      description: ID of the pet
+      schema:
+        type: integer
+        format: int64
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+          description: Display name
+        status:
+          type: string
+          enum: [available, sold]
+`
+
+func TestParse_OpenAPI3(t *testing.T) {
+	spec, err := Parse([]byte(petstoreYAML))
+	require.NoError(t, err)
+	assert.Equal(t, "Petstore", spec.Title)
+	assert.Equal(t, "1.2.0", spec.Version)
+
+	docs := spec.Documents("petstore.yaml")
+	require.Len(t, docs, 3)
+
+	// Sorted by path, then method order
+	assert.Equal(t, "openapi://Petstore/POST/pets", docs[0].URI)
+	assert.Equal(t, "openapi://Petstore/GET/pets/{petId}", docs[1].URI)
+	assert.Equal(t, "openapi://Petstore/DELETE/pets/{petId}", docs[2].URI)
+
+	get := docs[1]
+	assert.Equal(t, "GET /pets/{petId} - Get a pet", get.Title)
+	assert.Contains(t, get.Content, "Returns a single pet by its ID.")
+	assert.Contains(t, get.Content, "- petId (path, integer (int64), required): ID of the pet")
+	assert.Contains(t, get.Content, "### 200")
+	assert.Contains(t, get.Content, "- name (string, required): Display name")
+	assert.Contains(t, get.Content, "- status (string one of available, sold)")
+	assert.Contains(t, get.Content, `"name": "Rex"`)
+	assert.Contains(t, get.Content, "### 404\n\nPet not found")
+	assert.Equal(t, "getPet", get.Metadata["operation_id"])
+	assert.Equal(t, []string{"pets"}, get.Metadata["tags"])
+	assert.Equal(t, "GET", get.Metadata["method"])
+	assert.Equal(t, "/pets/{petId}", get.Metadata["path"])
+	assert.Equal(t, "1.2.0", get.Metadata["api_version"])
+
+	post := docs[0]
+	assert.Contains(t, post.Content, "## Request body\n\nThe pet to create")
+	assert.Contains(t, post.Content, "Content type: application/json")
+
+	assert.Equal(t, true, docs[2].Metadata["deprecated"])
+}
+
+func TestParse_Swagger2(t *testing.T) {
+	spec, err := Parse([]byte(`{
+		"swagger": "2.0",
+		"info": {"title": "Legacy API", "version": "1"},
+		"paths": {
+			"/orders": {
+				"post": {
+					"summary": "Place an order",
+					"parameters": [
+						{"name": "dryRun", "in": "query", "type": "boolean"},
+						{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/Order"}}
+					],
+					"responses": {
+						"200": {
+							"description": "OK",
+							"schema": {"type": "array", "items": {"$ref": "#/definitions/Order"}},
+							"examples": {"application/json": [{"sku": "A1"}]}
+						}
+					}
+				}
+			}
+		},
+		"definitions": {
+			"Order": {"type": "object", "properties": {"sku": {"type": "string"}}}
+		}
+	}`))
+	require.NoError(t, err)
+
+	docs := spec.Documents("legacy.json")
+	require.Len(t, docs, 1)
+	assert.Equal(t, "openapi://Legacy%20API/POST/orders", docs[0].URI)
+	assert.Contains(t, docs[0].Content, "- dryRun (query, boolean)")
+	assert.Contains(t, docs[0].Content, "## Request body\n\nFields:\n- sku (string)")
+	assert.Contains(t, docs[0].Content, "Array of Order")
+	assert.Contains(t, docs[0].Content, `"sku": "A1"`)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte("name: not a spec"))
+	assert.Error(t, err)
+
+	_, err = Parse([]byte(""))
+	assert.Error(t, err)
+}
@@ -0,0 +1,433 @@
+// Package opensearch exports indexes to Elasticsearch or OpenSearch, for
+// migrating to or mirroring into an existing search cluster. Documents and
+// their chunks become records of one target index whose mapping holds the
+// chunk embeddings as dense vectors, searchable with the cluster's kNN
+// queries.
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/riclib/hnswindex"
+)
+
+// DefaultBatchSize is the number of records per bulk request when
+// Config.BatchSize is 0
+const DefaultBatchSize = 500
+
+// Engines
+const (
+	Elasticsearch = "elasticsearch"
+	OpenSearch    = "opensearch"
+)
+
+// Record kinds, stored in the "kind" field
+const (
+	KindDocument = "document"
+	KindChunk    = "chunk"
+)
+
+// Config configures an Exporter
+type Config struct {
+	URL      string // e.g. "http://localhost:9200"
+	Index    string // Target index, created with a compatible mapping if missing
+	Engine   string // Elasticsearch or OpenSearch; detected from the cluster if empty
+	Username string // Optional basic authentication
+	Password string
+	APIKey   string // Optional Elasticsearch API key, instead of basic authentication
+
+	BatchSize int          // Records per bulk request (default DefaultBatchSize)
+	Recreate  bool         // Delete the target index first, so records of deleted documents go too
+	Client    *http.Client // Optional HTTP client
+}
+
+// Report summarizes an export
+type Report struct {
+	Documents int               `json:"documents"`
+	Chunks    int               `json:"chunks"`
+	Engine    string            `json:"engine"`
+	Failed    map[string]string `json:"failed,omitempty"` // Error by record ID
+	Duration  time.Duration     `json:"duration"`
+}
+
+// Exporter exports indexes to an Elasticsearch or OpenSearch index
+type Exporter struct {
+	cfg    Config
+	client *http.Client
+}
+
+// New returns an Exporter for cfg
+func New(cfg Config) (*Exporter, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("opensearch: missing URL")
+	}
+	if cfg.Index == "" {
+		return nil, errors.New("opensearch: missing target index")
+	}
+	switch cfg.Engine {
+	case "", Elasticsearch, OpenSearch:
+	default:
+		return nil, fmt.Errorf("opensearch: unknown engine %q (supported: %s, %s)", cfg.Engine, Elasticsearch, OpenSearch)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &Exporter{cfg: cfg, client: client}, nil
+}
+
+// documentRecord is the record of a document
+type documentRecord struct {
+	Kind     string                 `json:"kind"`
+	URI      string                 `json:"uri"`
+	Title    string                 `json:"title,omitempty"`
+	Content  string                 `json:"content"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// chunkRecord is the record of a chunk, with the fields of its document
+// that searches filter and display by
+type chunkRecord struct {
+	Kind      string                 `json:"kind"`
+	URI       string                 `json:"uri"`
+	Title     string                 `json:"title,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	ChunkID   string                 `json:"chunk_id"`
+	Text      string                 `json:"chunk_text"`
+	Position  int                    `json:"position"`
+	Embedding []float32              `json:"embedding,omitempty"`
+}
+
+// Export writes every document of index and its chunks, with their
+// embeddings, to the target index. Records are keyed by chunk ID and
+// document URI, so exporting again updates them in place. Records the
+// cluster rejects are listed in the report; transport and mapping errors
+// fail the export.
+func (e *Exporter) Export(ctx context.Context, index *hnswindex.Index) (*Report, error) {
+	start := time.Now()
+	engine := e.cfg.Engine
+	if engine == "" {
+		var err error
+		if engine, err = e.detectEngine(ctx); err != nil {
+			return nil, err
+		}
+	}
+	report := &Report{Engine: engine, Failed: make(map[string]string)}
+
+	config, err := index.EffectiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	if e.cfg.Recreate {
+		if err := e.deleteIndex(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var batch bytes.Buffer
+	records := 0
+	created := false
+	flush := func(dimension int) error {
+		if records == 0 {
+			return nil
+		}
+		if !created {
+			if dimension == 0 {
+				dimension = config.Embedder.Dimension
+			}
+			if err := e.createIndex(ctx, engine, dimension, config.HNSW.DistanceType); err != nil {
+				return err
+			}
+			created = true
+		}
+		if err := e.bulk(ctx, batch.Bytes(), report); err != nil {
+			return err
+		}
+		batch.Reset()
+		records = 0
+		return nil
+	}
+
+	dimension := 0
+	for doc := range index.Documents() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		err := addRecord(&batch, documentID(doc.URI), documentRecord{
+			Kind:     KindDocument,
+			URI:      doc.URI,
+			Title:    doc.Title,
+			Content:  doc.Content,
+			Metadata: doc.Metadata,
+		})
+		if err != nil {
+			return nil, err
+		}
+		records++
+		report.Documents++
+
+		for chunk := range index.Chunks(doc.URI) {
+			if dimension == 0 {
+				dimension = len(chunk.Embedding)
+			}
+			err := addRecord(&batch, chunk.ID, chunkRecord{
+				Kind:      KindChunk,
+				URI:       doc.URI,
+				Title:     doc.Title,
+				Metadata:  doc.Metadata,
+				ChunkID:   chunk.ID,
+				Text:      chunk.Text,
+				Position:  chunk.Position,
+				Embedding: chunk.Embedding,
+			})
+			if err != nil {
+				return nil, err
+			}
+			records++
+			report.Chunks++
+		}
+
+		if records >= e.cfg.BatchSize {
+			if err := flush(dimension); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(dimension); err != nil {
+		return nil, err
+	}
+
+	report.Duration = time.Since(start)
+	slog.Info("Index exported",
+		"index", index.Name(),
+		"engine", engine,
+		"target", e.cfg.Index,
+		"documents", report.Documents,
+		"chunks", report.Chunks,
+		"failed", len(report.Failed),
+		"duration_ms", report.Duration.Milliseconds(),
+	)
+	return report, nil
+}
+
+// documentID returns the record ID of a document. URIs are hashed, as
+// they may be longer than record IDs can be.
+func documentID(uri string) string {
+	sum := sha256.Sum256([]byte(uri))
+	return "doc-" + hex.EncodeToString(sum[:])
+}
+
+// addRecord appends an index action and its record to a bulk request body
+func addRecord(w *bytes.Buffer, id string, record interface{}) error {
+	action := map[string]map[string]string{"index": {"_id": id}}
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(action); err != nil {
+		return err
+	}
+	if err := enc.Encode(record); err != nil {
+		return fmt.Errorf("failed to encode record %s: %w", id, err)
+	}
+	return nil
+}
+
+// detectEngine asks the cluster whether it is Elasticsearch or OpenSearch
+func (e *Exporter) detectEngine(ctx context.Context) (string, error) {
+	var info struct {
+		Version struct {
+			Distribution string `json:"distribution"`
+		} `json:"version"`
+	}
+	if err := e.do(ctx, http.MethodGet, "/", nil, &info); err != nil {
+		return "", fmt.Errorf("failed to detect the search engine: %w", err)
+	}
+	if info.Version.Distribution == OpenSearch {
+		return OpenSearch, nil
+	}
+	return Elasticsearch, nil
+}
+
+// mapping returns the settings and mapping of the target index for
+// embeddings of dimension compared with distance ("cosine" or "l2")
+func mapping(engine string, dimension int, distance string) map[string]interface{} {
+	var embedding map[string]interface{}
+	if engine == OpenSearch {
+		space := "cosinesimil"
+		if distance == "l2" {
+			space = "l2"
+		}
+		embedding = map[string]interface{}{
+			"type":      "knn_vector",
+			"dimension": dimension,
+			"method": map[string]interface{}{
+				"name":       "hnsw",
+				"space_type": space,
+				"engine":     "lucene",
+			},
+		}
+	} else {
+		similarity := "cosine"
+		if distance == "l2" {
+			similarity = "l2_norm"
+		}
+		embedding = map[string]interface{}{
+			"type":       "dense_vector",
+			"dims":       dimension,
+			"index":      true,
+			"similarity": similarity,
+		}
+	}
+
+	body := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"kind":       map[string]interface{}{"type": "keyword"},
+				"uri":        map[string]interface{}{"type": "keyword"},
+				"title":      map[string]interface{}{"type": "text"},
+				"content":    map[string]interface{}{"type": "text"},
+				"metadata":   map[string]interface{}{"type": "object"},
+				"chunk_id":   map[string]interface{}{"type": "keyword"},
+				"chunk_text": map[string]interface{}{"type": "text"},
+				"position":   map[string]interface{}{"type": "integer"},
+				"embedding":  embedding,
+			},
+		},
+	}
+	if engine == OpenSearch {
+		body["settings"] = map[string]interface{}{"index.knn": true}
+	}
+	return body
+}
+
+// createIndex creates the target index unless it exists
+func (e *Exporter) createIndex(ctx context.Context, engine string, dimension int, distance string) error {
+	if dimension <= 0 {
+		return errors.New("opensearch: unknown embedding dimension")
+	}
+	err := e.do(ctx, http.MethodHead, "/"+e.cfg.Index, nil, nil)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, errNotFound) {
+		return fmt.Errorf("failed to check target index: %w", err)
+	}
+
+	body, err := json.Marshal(mapping(engine, dimension, distance))
+	if err != nil {
+		return err
+	}
+	if err := e.do(ctx, http.MethodPut, "/"+e.cfg.Index, body, nil); err != nil {
+		return fmt.Errorf("failed to create target index: %w", err)
+	}
+	return nil
+}
+
+// deleteIndex deletes the target index, if it exists
+func (e *Exporter) deleteIndex(ctx context.Context) error {
+	err := e.do(ctx, http.MethodDelete, "/"+e.cfg.Index, nil, nil)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return fmt.Errorf("failed to delete target index: %w", err)
+	}
+	return nil
+}
+
+// bulk sends a bulk request, adding the records it rejected to report
+func (e *Exporter) bulk(ctx context.Context, body []byte, report *Report) error {
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/"+e.cfg.Index+"/_bulk", body, &resp); err != nil {
+		return fmt.Errorf("bulk request failed: %w", err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if len(result.Error) > 0 && string(result.Error) != "null" {
+				report.Failed[result.ID] = bulkError(result.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// bulkError returns the reason of a bulk item error
+func bulkError(raw json.RawMessage) string {
+	var e struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(raw, &e); err != nil || e.Reason == "" {
+		return string(raw)
+	}
+	return e.Type + ": " + e.Reason
+}
+
+// errNotFound is returned by do for 404 responses
+var errNotFound = errors.New("not found")
+
+// do sends a request to the cluster and decodes its JSON response into
+// out, if not nil
+func (e *Exporter) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, e.cfg.URL+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		if strings.HasSuffix(path, "/_bulk") {
+			req.Header.Set("Content-Type", "application/x-ndjson")
+		} else {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	if e.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+e.cfg.APIKey)
+	} else if e.cfg.Username != "" {
+		req.SetBasicAuth(e.cfg.Username, e.cfg.Password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response to %s %s: %w", method, path, err)
+	}
+	return nil
+}
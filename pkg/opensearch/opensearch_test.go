@@ -0,0 +1,229 @@
+package opensearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCluster is an in-memory Elasticsearch or OpenSearch cluster serving
+// a single index
+type fakeCluster struct {
+	distribution string
+	reject       string // Record ID whose bulk item fails
+
+	mu      sync.Mutex
+	mapping map[string]interface{}
+	records map[string]map[string]interface{}
+	bulks   int
+}
+
+func (f *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/":
+		version := map[string]string{"number": "8.15.0"}
+		if f.distribution != "" {
+			version = map[string]string{"number": "2.17.0", "distribution": f.distribution}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"version": version})
+	case r.Method == http.MethodHead || r.Method == http.MethodDelete:
+		if f.mapping == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			f.mapping, f.records = nil, nil
+		}
+	case r.Method == http.MethodPut:
+		json.NewDecoder(r.Body).Decode(&f.mapping)
+		f.records = make(map[string]map[string]interface{})
+	case strings.HasSuffix(r.URL.Path, "/_bulk"):
+		if f.mapping == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.bulks++
+		var items []interface{}
+		failed := false
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(nil, 1<<24)
+		for scanner.Scan() {
+			var action map[string]map[string]string
+			json.Unmarshal(scanner.Bytes(), &action)
+			scanner.Scan()
+			id := action["index"]["_id"]
+			if id == f.reject {
+				failed = true
+				items = append(items, map[string]interface{}{"index": map[string]interface{}{
+					"_id":   id,
+					"error": map[string]string{"type": "mapper_parsing_exception", "reason": "failed to parse"},
+				}})
+				continue
+			}
+			var record map[string]interface{}
+			json.Unmarshal(scanner.Bytes(), &record)
+			f.records[id] = record
+			items = append(items, map[string]interface{}{"index": map[string]interface{}{"_id": id}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": failed, "items": items})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// count returns the number of records of a kind
+func (f *fakeCluster) count(kind string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, record := range f.records {
+		if record["kind"] == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func newIndex(t *testing.T) *hnswindex.Index {
+	t.Helper()
+	cfg := hnswindex.NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 0
+	manager, err := hnswindex.NewIndexManager(cfg)
+	require.NoError(t, err)
+	manager.SetEmbedder(embedtest.New(64))
+	t.Cleanup(func() { manager.Close() })
+
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	var docs []hnswindex.Document
+	for n := range 5 {
+		docs = append(docs, hnswindex.Document{
+			URI:      fmt.Sprintf("doc%d", n),
+			Title:    fmt.Sprintf("Doc %d", n),
+			Content:  strings.Repeat(fmt.Sprintf("Document %d explains database failover. ", n), 20),
+			Metadata: map[string]interface{}{"space": "ENG"},
+		})
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	return index
+}
+
+func TestExport(t *testing.T) {
+	index := newIndex(t)
+	var chunks int
+	for doc := range index.Documents() {
+		for range index.Chunks(doc.URI) {
+			chunks++
+		}
+	}
+	require.Greater(t, chunks, 5)
+
+	for _, distribution := range []string{"", OpenSearch} {
+		cluster := &fakeCluster{distribution: distribution}
+		ts := httptest.NewServer(cluster)
+		defer ts.Close()
+
+		exporter, err := New(Config{URL: ts.URL, Index: "kb-mirror", BatchSize: 10})
+		require.NoError(t, err)
+		report, err := exporter.Export(context.Background(), index)
+		require.NoError(t, err)
+
+		assert.Equal(t, 5, report.Documents)
+		assert.Equal(t, chunks, report.Chunks)
+		assert.Empty(t, report.Failed)
+		assert.Equal(t, 5, cluster.count(KindDocument))
+		assert.Equal(t, chunks, cluster.count(KindChunk))
+		assert.Greater(t, cluster.bulks, 1, "records are sent in batches")
+
+		props := cluster.mapping["mappings"].(map[string]interface{})["properties"].(map[string]interface{})
+		embedding := props["embedding"].(map[string]interface{})
+		if distribution == OpenSearch {
+			assert.Equal(t, OpenSearch, report.Engine)
+			assert.Equal(t, "knn_vector", embedding["type"])
+			assert.Equal(t, float64(64), embedding["dimension"])
+			assert.Equal(t, map[string]interface{}{"index.knn": true}, cluster.mapping["settings"])
+		} else {
+			assert.Equal(t, Elasticsearch, report.Engine)
+			assert.Equal(t, "dense_vector", embedding["type"])
+			assert.Equal(t, float64(64), embedding["dims"])
+			assert.Equal(t, "cosine", embedding["similarity"])
+		}
+
+		doc := cluster.records[documentID("doc0")]
+		require.NotNil(t, doc)
+		assert.Equal(t, "Doc 0", doc["title"])
+		assert.Equal(t, map[string]interface{}{"space": "ENG"}, doc["metadata"])
+		for chunk := range index.Chunks("doc0") {
+			record := cluster.records[chunk.ID]
+			require.NotNil(t, record)
+			assert.Equal(t, "doc0", record["uri"])
+			assert.Equal(t, chunk.Text, record["chunk_text"])
+			assert.Len(t, record["embedding"], 64)
+		}
+	}
+}
+
+func TestExport_RejectedRecords(t *testing.T) {
+	index := newIndex(t)
+	cluster := &fakeCluster{reject: documentID("doc3")}
+	ts := httptest.NewServer(cluster)
+	defer ts.Close()
+
+	exporter, err := New(Config{URL: ts.URL, Index: "kb-mirror", Engine: Elasticsearch})
+	require.NoError(t, err)
+	report, err := exporter.Export(context.Background(), index)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{documentID("doc3"): "mapper_parsing_exception: failed to parse"}, report.Failed)
+	assert.Equal(t, 4, cluster.count(KindDocument))
+
+	// Recreating drops records left from earlier exports
+	cluster.records["stale"] = map[string]interface{}{"kind": KindDocument}
+	cluster.reject = ""
+	exporter, err = New(Config{URL: ts.URL, Index: "kb-mirror", Engine: Elasticsearch, Recreate: true})
+	require.NoError(t, err)
+	_, err = exporter.Export(context.Background(), index)
+	require.NoError(t, err)
+	assert.Equal(t, 5, cluster.count(KindDocument))
+}
+
+func TestExport_Errors(t *testing.T) {
+	_, err := New(Config{Index: "kb"})
+	assert.Error(t, err)
+	_, err = New(Config{URL: "http://localhost:9200"})
+	assert.Error(t, err)
+	_, err = New(Config{URL: "http://localhost:9200", Index: "kb", Engine: "solr"})
+	assert.ErrorContains(t, err, "unknown engine")
+
+	// Authentication failures fail the export
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "ApiKey secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.Copy(io.Discard, r.Body)
+			return
+		}
+		io.WriteString(w, `{"version": {"number": "8.15.0"}}`)
+	}))
+	defer ts.Close()
+	exporter, err := New(Config{URL: ts.URL, Index: "kb", APIKey: "wrong"})
+	require.NoError(t, err)
+	_, err = exporter.Export(context.Background(), newIndex(t))
+	assert.ErrorContains(t, err, "401")
+}
@@ -0,0 +1,264 @@
+// Package records maps structured records (CSV rows or JSON Lines objects)
+// to documents, so knowledge bases exported as spreadsheets can be indexed.
+package records
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/riclib/hnswindex"
+)
+
+// Mapping describes how the fields of a record become a document
+type Mapping struct {
+	URIField        string   // Field holding the unique record ID (required)
+	URIPrefix       string   // Prepended to the URI field value, e.g. "kb://faq/"
+	TitleField      string   // Field holding the title (defaults to the URI field)
+	ContentTemplate string   // text/template rendered with the record fields
+	MetadataFields  []string // Fields copied into document metadata
+}
+
+// Validate checks that the mapping can be applied
+func (m Mapping) Validate() error {
+	if m.URIField == "" {
+		return errors.New("uri field is required")
+	}
+	if m.ContentTemplate != "" {
+		if _, err := template.New("content").Option("missingkey=zero").Parse(m.ContentTemplate); err != nil {
+			return fmt.Errorf("invalid content template: %w", err)
+		}
+	}
+	return nil
+}
+
+// record is a single row with its fields in source order
+type record struct {
+	fields map[string]interface{}
+	order  []string
+}
+
+// mapper converts records to documents using a mapping
+type mapper struct {
+	mapping Mapping
+	tmpl    *template.Template
+	source  string
+}
+
+func newMapper(m Mapping, source string) (*mapper, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	mp := &mapper{mapping: m, source: source}
+	if m.ContentTemplate != "" {
+		mp.tmpl = template.Must(template.New("content").Option("missingkey=zero").Parse(m.ContentTemplate))
+	}
+	return mp, nil
+}
+
+// document converts a record (line is 1-based, for error messages)
+func (mp *mapper) document(rec record, line int) (hnswindex.Document, error) {
+	m := mp.mapping
+
+	id := fieldString(rec.fields[m.URIField])
+	if id == "" {
+		return hnswindex.Document{}, fmt.Errorf("line %d: empty %q field", line, m.URIField)
+	}
+
+	title := id
+	if m.TitleField != "" {
+		if t := fieldString(rec.fields[m.TitleField]); t != "" {
+			title = t
+		}
+	}
+
+	var content string
+	if mp.tmpl != nil {
+		var buf bytes.Buffer
+		if err := mp.tmpl.Execute(&buf, rec.fields); err != nil {
+			return hnswindex.Document{}, fmt.Errorf("line %d: failed to render content: %w", line, err)
+		}
+		content = buf.String()
+	} else {
+		// Without a template, render every field as "name: value"
+		var sb strings.Builder
+		for _, name := range rec.order {
+			if name == m.URIField {
+				continue
+			}
+			if value := fieldString(rec.fields[name]); value != "" {
+				fmt.Fprintf(&sb, "%s: %s\n", name, value)
+			}
+		}
+		content = sb.String()
+	}
+
+	metadata := map[string]interface{}{
+		"source":      "records",
+		"record_file": mp.source,
+		"record_line": line,
+		"record_id":   id,
+	}
+	for _, name := range m.MetadataFields {
+		if value, ok := rec.fields[name]; ok {
+			metadata[name] = value
+		}
+	}
+
+	return hnswindex.Document{
+		URI:      m.URIPrefix + id,
+		Title:    title,
+		Content:  strings.TrimSpace(content),
+		Metadata: metadata,
+	}, nil
+}
+
+// ReadCSV reads documents from CSV data with a header row.
+// source is recorded in document metadata.
+func ReadCSV(r io.Reader, source string, m Mapping) ([]hnswindex.Document, error) {
+	mp, err := newMapper(m, source)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for idx := range header {
+		header[idx] = strings.TrimSpace(header[idx])
+	}
+	if !contains(header, m.URIField) {
+		return nil, fmt.Errorf("CSV header has no %q column", m.URIField)
+	}
+
+	var docs []hnswindex.Document
+	line := 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		rec := record{fields: make(map[string]interface{}, len(header)), order: header}
+		for idx, name := range header {
+			if idx < len(row) {
+				rec.fields[name] = row[idx]
+			}
+		}
+
+		doc, err := mp.document(rec, line)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// ReadJSONL reads documents from JSON Lines data, one object per line.
+// Blank lines are skipped; source is recorded in document metadata.
+func ReadJSONL(r io.Reader, source string, m Mapping) ([]hnswindex.Document, error) {
+	mp, err := newMapper(m, source)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var docs []hnswindex.Document
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(text, &fields); err != nil {
+			return nil, fmt.Errorf("line %d: invalid JSON object: %w", line, err)
+		}
+
+		order := make([]string, 0, len(fields))
+		for name := range fields {
+			order = append(order, name)
+		}
+		sort.Strings(order)
+
+		doc, err := mp.document(record{fields: fields, order: order}, line)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON lines: %w", err)
+	}
+
+	return docs, nil
+}
+
+// ReadFile reads documents from a .csv, .jsonl, or .ndjson file
+func ReadFile(path string, m Mapping) ([]hnswindex.Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ReadCSV(f, path, m)
+	case ".jsonl", ".ndjson":
+		return ReadJSONL(f, path, m)
+	default:
+		return nil, fmt.Errorf("unsupported record file type: %s", filepath.Ext(path))
+	}
+}
+
+// fieldString renders a field value as text
+func fieldString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		// JSON numbers: print integers without a fractional part
+		if v == float64(int64(v)) {
+			return fmt.Sprintf("%d", int64(v))
+		}
+		return fmt.Sprintf("%g", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
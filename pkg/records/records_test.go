@@ -0,0 +1,77 @@
+package records
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCSV(t *testing.T) {
+	data := `id,question,answer,team
+42,How do I reset my password?,Use the self-service portal.,identity
+43,Who approves access?,Your manager,platform
+`
+	docs, err := ReadCSV(strings.NewReader(data), "faq.csv", Mapping{
+		URIField:        "id",
+		URIPrefix:       "faq://",
+		TitleField:      "question",
+		ContentTemplate: "Q: {{.question}}\nA: {{.answer}}",
+		MetadataFields:  []string{"team"},
+	})
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "faq://42", docs[0].URI)
+	assert.Equal(t, "How do I reset my password?", docs[0].Title)
+	assert.Equal(t, "Q: How do I reset my password?\nA: Use the self-service portal.", docs[0].Content)
+	assert.Equal(t, "identity", docs[0].Metadata["team"])
+	assert.Equal(t, "faq.csv", docs[0].Metadata["record_file"])
+	assert.Equal(t, 2, docs[0].Metadata["record_line"])
+}
+
+func TestReadCSV_DefaultContent(t *testing.T) {
+	data := "id,name,notes\n1,Alpha,first entry\n"
+	docs, err := ReadCSV(strings.NewReader(data), "kb.csv", Mapping{URIField: "id"})
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "1", docs[0].Title)
+	assert.Equal(t, "name: Alpha\nnotes: first entry", docs[0].Content)
+
+	_, err = ReadCSV(strings.NewReader(data), "kb.csv", Mapping{URIField: "missing"})
+	assert.Error(t, err)
+
+	_, err = ReadCSV(strings.NewReader("id,name\n,Nameless\n"), "kb.csv", Mapping{URIField: "id"})
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestReadJSONL(t *testing.T) {
+	data := `{"sku": 1001, "name": "Widget", "description": "A small widget", "tags": ["tools"]}
+
+{"sku": 1002, "name": "Gadget", "description": "A larger gadget"}
+`
+	docs, err := ReadJSONL(strings.NewReader(data), "products.jsonl", Mapping{
+		URIField:        "sku",
+		URIPrefix:       "product://",
+		TitleField:      "name",
+		ContentTemplate: "{{.name}}: {{.description}}",
+		MetadataFields:  []string{"tags"},
+	})
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "product://1001", docs[0].URI)
+	assert.Equal(t, "Widget: A small widget", docs[0].Content)
+	assert.Equal(t, []interface{}{"tools"}, docs[0].Metadata["tags"])
+	assert.Equal(t, 3, docs[1].Metadata["record_line"])
+
+	_, err = ReadJSONL(strings.NewReader("not json\n"), "bad.jsonl", Mapping{URIField: "sku"})
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestMapping_Validate(t *testing.T) {
+	assert.Error(t, Mapping{}.Validate())
+	assert.Error(t, Mapping{URIField: "id", ContentTemplate: "{{.broken"}.Validate())
+	assert.NoError(t, Mapping{URIField: "id", ContentTemplate: "{{.body}}"}.Validate())
+}
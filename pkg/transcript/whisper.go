@@ -0,0 +1,90 @@
+// Package transcript reads timed transcripts into documents whose chunks
+// carry time ranges, so search results can deep-link into recordings.
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+)
+
+// whisperOutput is the JSON written by Whisper (openai-whisper, whisper.cpp
+// with -oj, faster-whisper exports)
+type whisperOutput struct {
+	Text     string `json:"text"`
+	Language string `json:"language"`
+	Segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	} `json:"segments"`
+}
+
+// ReadWhisperJSON parses Whisper JSON output into transcript segments and
+// returns the detected language, if present
+func ReadWhisperJSON(r io.Reader) ([]hnswindex.Segment, string, error) {
+	var out whisperOutput
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return nil, "", fmt.Errorf("failed to decode Whisper JSON: %w", err)
+	}
+	if len(out.Segments) == 0 {
+		return nil, "", errors.New("Whisper JSON has no segments")
+	}
+
+	segments := make([]hnswindex.Segment, 0, len(out.Segments))
+	for _, seg := range out.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		segments = append(segments, hnswindex.Segment{
+			Start: seg.Start,
+			End:   seg.End,
+			Text:  text,
+		})
+	}
+	return segments, out.Language, nil
+}
+
+// LoadWhisperFile reads a Whisper JSON file into a transcript document.
+// mediaURI identifies the recording and becomes the document URI, so
+// SearchResult.DeepLink points into the recording; title defaults to the
+// file name.
+func LoadWhisperFile(path, mediaURI, title string) (hnswindex.Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return hnswindex.Document{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	segments, language, err := ReadWhisperJSON(f)
+	if err != nil {
+		return hnswindex.Document{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	metadata := map[string]interface{}{
+		"source":           "transcript",
+		"transcript_path":  path,
+		"duration_seconds": segments[len(segments)-1].End,
+	}
+	if language != "" {
+		metadata["language"] = language
+	}
+
+	return hnswindex.Document{
+		URI:      mediaURI,
+		Title:    title,
+		Segments: segments,
+		Metadata: metadata,
+	}, nil
+}
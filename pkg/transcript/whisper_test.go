@@ -0,0 +1,47 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const whisperJSON = `{
+  "text": " Welcome to the talk. Today we cover deployments.",
+  "language": "en",
+  "segments": [
+    {"id": 0, "start": 0.0, "end": 3.5, "text": " Welcome to the talk."},
+    {"id": 1, "start": 3.5, "end": 3.6, "text": "  "},
+    {"id": 2, "start": 3.6, "end": 8.25, "text": " Today we cover deployments."}
+  ]
+}`
+
+func TestReadWhisperJSON(t *testing.T) {
+	segments, language, err := ReadWhisperJSON(strings.NewReader(whisperJSON))
+	require.NoError(t, err)
+	assert.Equal(t, "en", language)
+	require.Len(t, segments, 2)
+	assert.Equal(t, "Welcome to the talk.", segments[0].Text)
+	assert.Equal(t, 3.6, segments[1].Start)
+	assert.Equal(t, 8.25, segments[1].End)
+
+	_, _, err = ReadWhisperJSON(strings.NewReader(`{"segments": []}`))
+	assert.Error(t, err)
+}
+
+func TestLoadWhisperFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "all-hands.json")
+	require.NoError(t, os.WriteFile(path, []byte(whisperJSON), 0644))
+
+	doc, err := LoadWhisperFile(path, "https://videos.example.com/all-hands.mp4", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://videos.example.com/all-hands.mp4", doc.URI)
+	assert.Equal(t, "all-hands", doc.Title)
+	assert.Len(t, doc.Segments, 2)
+	assert.Equal(t, 8.25, doc.Metadata["duration_seconds"])
+	assert.Equal(t, "en", doc.Metadata["language"])
+}
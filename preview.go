@@ -0,0 +1,48 @@
+package hnswindex
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ellipsis marks text shortened by TruncateText
+const Ellipsis = "..."
+
+// Preview returns the matched chunk text on a single line, shortened to at
+// most n characters (runes) plus an ellipsis. Use it instead of slicing
+// ChunkText, which can split multi-byte characters.
+func (r SearchResult) Preview(n int) string {
+	return TruncateText(strings.Join(strings.Fields(r.ChunkText), " "), n)
+}
+
+// Preview returns the chunk text on a single line, shortened like
+// SearchResult.Preview
+func (c Chunk) Preview(n int) string {
+	return TruncateText(strings.Join(strings.Fields(c.Text), " "), n)
+}
+
+// TruncateText shortens text to at most n runes and appends Ellipsis. The
+// cut is made at the last word boundary, unless that would drop more than
+// half of the allowed text, and never inside a multi-byte character. Text
+// of at most n runes is returned unchanged.
+func TruncateText(text string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := n
+	if !unicode.IsSpace(runes[n]) {
+		for pos := n; pos > n/2; pos-- {
+			if unicode.IsSpace(runes[pos-1]) {
+				cut = pos
+				break
+			}
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + Ellipsis
+}
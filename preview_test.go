@@ -0,0 +1,31 @@
+package hnswindex
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateText(t *testing.T) {
+	assert.Equal(t, "short", TruncateText("short", 10))
+	assert.Equal(t, "", TruncateText("anything", 0))
+	assert.Equal(t, "the quick...", TruncateText("the quick brown fox", 12))
+	assert.Equal(t, "the quick...", TruncateText("the quick brown fox", 9))
+	assert.Equal(t, "abcdefgh...", TruncateText("abcdefghijklmnop", 8))
+
+	// Multi-byte characters are never split
+	text := "Größenänderung über Ländergrenzen hinweg"
+	for n := 1; n < utf8.RuneCountInString(text); n++ {
+		got := TruncateText(text, n)
+		assert.True(t, utf8.ValidString(got), got)
+		assert.LessOrEqual(t, utf8.RuneCountInString(got), n+len(Ellipsis))
+	}
+	assert.Equal(t, "日本語の...", TruncateText("日本語のテキストです", 4))
+}
+
+func TestSearchResult_Preview(t *testing.T) {
+	r := SearchResult{ChunkText: "Première ligne\n\nDeuxième   ligne très longue"}
+	assert.Equal(t, "Première ligne Deuxième...", r.Preview(26))
+	assert.Equal(t, "Première ligne Deuxième ligne très longue", r.Preview(100))
+}
@@ -0,0 +1,78 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/embedder"
+)
+
+// ProbeCheck is the result of checking one dependency of the manager
+type ProbeCheck struct {
+	Name      string  `json:"name"`
+	OK        bool    `json:"ok"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// Ping checks the dependencies searches need: that storage can be read,
+// that the embedder is reachable with its model available, and whether
+// the model is loaded. A model that is not loaded is reported in the
+// detail but is not a failure, since Ollama unloads idle models and loads
+// them again on the next request.
+func (im *IndexManager) Ping(ctx context.Context) []ProbeCheck {
+	if impl := im.getImpl(); impl != nil {
+		return impl.Ping(ctx)
+	}
+	return []ProbeCheck{{Name: "manager", Error: "implementation not available"}}
+}
+
+// Ping implementation
+func (im *indexManagerImpl) Ping(ctx context.Context) []ProbeCheck {
+	checks := []ProbeCheck{
+		runProbe("storage", func() (string, error) {
+			return "", im.storage.Ping()
+		}),
+	}
+
+	pinger, ok := im.embedder.(embedder.Pinger)
+	if !ok {
+		return append(checks, ProbeCheck{Name: "embedder", OK: true, Detail: "custom embedder, not checked"})
+	}
+	embedderCheck := runProbe("embedder", func() (string, error) {
+		return im.config.EmbedModel, pinger.Ping(ctx)
+	})
+	checks = append(checks, embedderCheck)
+	if !embedderCheck.OK {
+		return checks
+	}
+
+	return append(checks, runProbe("model", func() (string, error) {
+		loaded, err := pinger.ModelLoaded(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !loaded {
+			return "not loaded, loads on first use", nil
+		}
+		return "loaded", nil
+	}))
+}
+
+// runProbe runs a check and times it
+func runProbe(name string, check func() (string, error)) ProbeCheck {
+	start := time.Now()
+	detail, err := check()
+	result := ProbeCheck{
+		Name:      name,
+		OK:        err == nil,
+		Detail:    detail,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Error = fmt.Sprint(err)
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package hnswindex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexManager_Ping(t *testing.T) {
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			w.Write([]byte(`{}`))
+		case "/api/ps":
+			w.Write([]byte(`{"models":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ollama.Close()
+
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.OllamaURL = ollama.URL
+	manager, err := NewIndexManager(cfg)
+	require.NoError(t, err)
+	defer manager.Close()
+
+	checks := manager.Ping(context.Background())
+	require.Len(t, checks, 3)
+	for _, c := range checks {
+		assert.True(t, c.OK, c.Name)
+	}
+	assert.Equal(t, "model", checks[2].Name)
+	assert.Contains(t, checks[2].Detail, "not loaded")
+
+	ollama.Close()
+	checks = manager.Ping(context.Background())
+	require.Len(t, checks, 2)
+	assert.True(t, checks[0].OK)
+	assert.False(t, checks[1].OK)
+	assert.Contains(t, checks[1].Error, "unreachable")
+}
+
+func TestIndexManager_PingCustomEmbedder(t *testing.T) {
+	manager := newMockManager(t, nil)
+
+	checks := manager.Ping(context.Background())
+	require.Len(t, checks, 2)
+	assert.True(t, checks[0].OK)
+	assert.Equal(t, "embedder", checks[1].Name)
+	assert.True(t, checks[1].OK)
+	assert.Contains(t, checks[1].Detail, "not checked")
+}
@@ -0,0 +1,132 @@
+package hnswindex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/riclib/hnswindex/internal/chunker"
+)
+
+// ProcessedDocument is a document as the indexing pipeline stores it,
+// without embeddings. Chunk IDs are derived from chunk text and position
+// and decide which embeddings are reused on update, so a change to them
+// means the chunks are embedded again.
+type ProcessedDocument struct {
+	URI      string                 `json:"uri"`
+	Title    string                 `json:"title"`
+	Hash     string                 `json:"hash"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Chunker  ChunkerConfig          `json:"chunker"`
+	Chunks   []ProcessedChunk       `json:"chunks"`
+}
+
+// ProcessedChunk is a chunk of a ProcessedDocument
+type ProcessedChunk struct {
+	ID       string                 `json:"id"`
+	Position int                    `json:"position"`
+	Tokens   int                    `json:"tokens"`
+	Text     string                 `json:"text"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ProcessDocument runs a document through the index's pipeline (schema
+// validation, limits, binary extraction, chunking and chunk titling)
+// without embedding or storing it. Use it with Golden for golden-file
+// tests that catch pipeline changes before they re-embed every document.
+func (i *Index) ProcessDocument(ctx context.Context, doc Document) (*ProcessedDocument, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.processOnly(ctx, doc)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Golden encodes the processed document canonically: indented JSON with
+// sorted metadata keys and a trailing newline, so equal documents encode
+// to equal bytes
+func (p *ProcessedDocument) Golden() ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode processed document: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// processOnly prepares a document as addDocumentBatch does and chunks it
+// as processDocument does
+func (i *indexImpl) processOnly(ctx context.Context, doc Document) (*ProcessedDocument, error) {
+	schema, err := i.MetadataSchema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata schema: %w", err)
+	}
+	if doc, err = i.manager.limitMetadata(doc); err != nil {
+		return nil, err
+	}
+	if err := schema.Validate(doc.Metadata); err != nil {
+		return nil, err
+	}
+	if err := i.manager.checkContentSize(doc); err != nil {
+		return nil, err
+	}
+	hash, err := i.manager.hashDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	if doc, err = i.manager.extractBinary(ctx, doc); err != nil {
+		return nil, err
+	}
+	if doc, err = i.manager.loadContent(doc); err != nil {
+		return nil, err
+	}
+	if doc.Content == "" && len(doc.Segments) > 0 {
+		doc.Content = segmentContent(doc.Segments)
+	}
+
+	chunks, err := i.pipelineChunks(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ProcessedDocument{
+		URI:      doc.URI,
+		Title:    doc.Title,
+		Hash:     hash,
+		Metadata: doc.Metadata,
+		Chunker:  i.effectiveConfig().Chunker,
+		Chunks:   make([]ProcessedChunk, len(chunks)),
+	}
+	for idx, chunk := range chunks {
+		p.Chunks[idx] = ProcessedChunk{
+			ID:       chunk.ID,
+			Position: chunk.Position,
+			Tokens:   i.manager.chunker.CountTokens(chunk.Text),
+			Text:     chunk.Text,
+			Metadata: chunk.Metadata,
+		}
+	}
+	return p, nil
+}
+
+// pipelineChunks chunks and titles a document, returning its chunks with
+// the metadata they are stored with
+func (i *indexImpl) pipelineChunks(doc Document) ([]chunker.Chunk, error) {
+	chunks, err := i.manager.chunkDocument(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to chunk document: %w", err)
+	}
+	if err := i.manager.checkChunkCount(len(chunks)); err != nil {
+		return nil, err
+	}
+
+	titles, err := i.manager.titleChunks(doc, chunks)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range chunks {
+		chunks[idx].Metadata = chunkMetadata(doc.Metadata, chunks[idx].Metadata)
+		if titles != nil && titles[idx] != "" {
+			chunks[idx].Metadata = chunkMetadata(chunks[idx].Metadata, map[string]interface{}{ChunkTitleKey: titles[idx]})
+		}
+	}
+	return chunks, nil
+}
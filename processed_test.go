@@ -0,0 +1,73 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessDocument(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 0
+	manager := newMockManager(t, cfg)
+	manager.SetChunkTitler(NewHeadingTitler())
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	doc := Document{
+		URI:      "kb://guide",
+		Title:    "Guide",
+		Content:  "# Setup\n\nInstall the agent on every host before enabling monitoring. The agent reports to the collector over TLS and needs outbound access on port 443.\n\n# Usage\n\nOpen the dashboard to see host metrics.",
+		Metadata: map[string]interface{}{"team": "platform", "area": "ops"},
+	}
+	processed, err := index.ProcessDocument(context.Background(), doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "kb://guide", processed.URI)
+	assert.Equal(t, computeDocumentHash(doc), processed.Hash)
+	assert.Equal(t, 50, processed.Chunker.ChunkSize)
+	require.Greater(t, len(processed.Chunks), 1)
+	for idx, chunk := range processed.Chunks {
+		assert.Equal(t, idx, chunk.Position)
+		assert.NotEmpty(t, chunk.ID)
+		assert.Positive(t, chunk.Tokens)
+		assert.LessOrEqual(t, chunk.Tokens, 50)
+		assert.Equal(t, "platform", chunk.Metadata["team"])
+	}
+	assert.Equal(t, "Setup", processed.Chunks[0].Metadata[ChunkTitleKey])
+
+	// Nothing is embedded or stored
+	stats, err := index.Stats()
+	require.NoError(t, err)
+	assert.Zero(t, stats.DocumentCount)
+
+	// The same document always encodes to the same bytes
+	golden, err := processed.Golden()
+	require.NoError(t, err)
+	again, err := index.ProcessDocument(context.Background(), doc)
+	require.NoError(t, err)
+	againGolden, err := again.Golden()
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), string(againGolden))
+	assert.Contains(t, string(golden), "\"area\": \"ops\",\n")
+
+	// Chunk IDs match the stored chunks, which reuse their embeddings
+	addDocuments(t, index, doc)
+	var stored []string
+	for chunk := range index.Chunks(doc.URI) {
+		stored = append(stored, chunk.ID)
+	}
+	var ids []string
+	for _, chunk := range processed.Chunks {
+		ids = append(ids, chunk.ID)
+	}
+	assert.Equal(t, ids, stored)
+
+	// Pipeline limits apply
+	cfg.MaxChunksPerDocument = 1
+	_, err = index.ProcessDocument(context.Background(), doc)
+	assert.Error(t, err)
+}
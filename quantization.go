@@ -0,0 +1,51 @@
+package hnswindex
+
+import (
+	"github.com/riclib/hnswindex/internal/indexer"
+	"github.com/riclib/hnswindex/internal/quantize"
+)
+
+// Embedding quantizations for Config.Quantization
+const (
+	QuantizationNone = "none"
+	QuantizationInt8 = "int8"
+)
+
+// quantizationSettingKey is the index setting holding the quantization of
+// the index's graph, which is fixed when the graph is created
+const quantizationSettingKey = "quantization"
+
+// graphConfig returns the graph configuration of an index whose graph
+// holds vectors with quantization
+func graphConfig(quantization string) indexer.HNSWConfig {
+	cfg := indexer.DefaultConfig()
+	cfg.Quantization = quantization
+	return cfg
+}
+
+// loadQuantization returns the quantization of a stored index's graph,
+// none for indexes created without one
+func (im *indexManagerImpl) loadQuantization(name string) (string, error) {
+	data, err := im.storage.GetIndexSetting(name, quantizationSettingKey)
+	if err != nil {
+		return "", err
+	}
+	return quantize.Check(string(data))
+}
+
+// recordQuantization records the configured quantization as that of a new
+// index's graph and returns it
+func (im *indexManagerImpl) recordQuantization(name string) (string, error) {
+	quantization, err := quantize.Check(im.config.Quantization)
+	if err != nil {
+		return "", err
+	}
+	var data []byte
+	if quantization != quantize.None {
+		data = []byte(quantization)
+	}
+	if err := im.storage.SetIndexSetting(name, quantizationSettingKey, data); err != nil {
+		return "", err
+	}
+	return quantization, nil
+}
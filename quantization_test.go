@@ -0,0 +1,61 @@
+package hnswindex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuantization(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.Quantization = QuantizationInt8
+	manager := newMockManager(t, cfg)
+
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, reductionDocs(30)...)
+
+	stored, err := index.Config()
+	require.NoError(t, err)
+	assert.Equal(t, QuantizationInt8, stored.HNSW.Quantization)
+
+	results, err := index.Search("document number 12 about topic 5", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "doc12", results[0].Document.URI)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-3)
+
+	for chunk := range index.Chunks("doc12") {
+		assert.Len(t, chunk.Embedding, 768)
+	}
+
+	// The graph stays quantized after a restart without the setting
+	require.NoError(t, manager.Close())
+	require.NoError(t, manager.getImpl().storage.Close()) // Release the database lock
+	cfg.Quantization = ""
+	reopened := newMockManager(t, cfg)
+	index, err = reopened.GetIndex("kb")
+	require.NoError(t, err)
+	stats, err := index.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 30, stats.ChunkCount)
+	results, err = index.Search("document number 20 about topic 6", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc20", results[0].Document.URI)
+
+	// New indexes follow the configuration
+	plain, err := reopened.CreateIndex("plain")
+	require.NoError(t, err)
+	stored, err = plain.Config()
+	require.NoError(t, err)
+	assert.Empty(t, stored.HNSW.Quantization)
+
+	cfg = NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.Quantization = "pq"
+	_, err = NewIndexManager(cfg)
+	assert.ErrorContains(t, err, "unsupported quantization")
+}
@@ -0,0 +1,306 @@
+package hnswindex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FilterOp is the comparison a metadata filter applies
+type FilterOp string
+
+const (
+	FilterEq  FilterOp = ":"  // Equal, or contains for list values
+	FilterGt  FilterOp = ">"  // Greater than
+	FilterGte FilterOp = ">=" // Greater than or equal
+	FilterLt  FilterOp = "<"  // Less than
+	FilterLte FilterOp = "<=" // Less than or equal
+)
+
+// MetadataFilter restricts search results by a document metadata field.
+// Filters on different fields must all match; filters on the same field
+// with FilterEq match if any of them does.
+type MetadataFilter struct {
+	Field  string   `json:"field"`
+	Op     FilterOp `json:"op"`
+	Value  string   `json:"value"`
+	Negate bool     `json:"negate,omitempty"` // Exclude matching documents
+}
+
+// String formats the filter in query syntax
+func (f MetadataFilter) String() string {
+	var b strings.Builder
+	if f.Negate {
+		b.WriteByte('-')
+	}
+	b.WriteString(f.Field)
+	b.WriteByte(':')
+	if f.Op != FilterEq {
+		b.WriteString(string(f.Op))
+	}
+	b.WriteString(quoteQueryTerm(f.Value))
+	return b.String()
+}
+
+// Query is a parsed query string: the text to search for semantically and
+// the metadata filters to apply to the results
+type Query struct {
+	Text    string
+	Filters []MetadataFilter
+}
+
+// String formats the query in query syntax
+func (q Query) String() string {
+	parts := make([]string, 0, len(q.Filters)+1)
+	for _, f := range q.Filters {
+		parts = append(parts, f.String())
+	}
+	if q.Text != "" {
+		parts = append(parts, q.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseQuery parses a query string such as
+//
+//	kind:runbook space:ENG -status:archived updated:>=2024-01-01 "database failover"
+//
+// into search text and metadata filters. A term of the form field:value
+// is a filter, optionally negated with a leading '-' and with a >, >=, <, or
+// <= comparison before the value. Values and text may be double-quoted to
+// include spaces or colons. All other terms form the search text.
+func ParseQuery(s string) (Query, error) {
+	var q Query
+	var text []string
+
+	for rest := strings.TrimSpace(s); rest != ""; rest = strings.TrimLeftFunc(rest, unicode.IsSpace) {
+		if rest[0] == '"' {
+			phrase, remaining, err := readQuoted(rest)
+			if err != nil {
+				return Query{}, err
+			}
+			if phrase != "" {
+				text = append(text, phrase)
+			}
+			rest = remaining
+			continue
+		}
+
+		filter, remaining, ok, err := readFilter(rest)
+		if err != nil {
+			return Query{}, err
+		}
+		if ok {
+			q.Filters = append(q.Filters, filter)
+			rest = remaining
+			continue
+		}
+
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		text = append(text, rest[:end])
+		rest = rest[end:]
+	}
+
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// readFilter reads a field:value term at the start of s. ok is false if s
+// does not start with a filter.
+func readFilter(s string) (filter MetadataFilter, rest string, ok bool, err error) {
+	pos := 0
+	if s[0] == '-' {
+		filter.Negate = true
+		pos = 1
+	}
+
+	start := pos
+	for pos < len(s) && isFieldChar(s[pos], pos == start) {
+		pos++
+	}
+	if pos == start || pos >= len(s) || s[pos] != ':' {
+		return MetadataFilter{}, "", false, nil
+	}
+	filter.Field = s[start:pos]
+	pos++
+
+	filter.Op = FilterEq
+	for _, op := range []FilterOp{FilterGte, FilterLte, FilterGt, FilterLt} {
+		if strings.HasPrefix(s[pos:], string(op)) {
+			filter.Op = op
+			pos += len(op)
+			break
+		}
+	}
+
+	if pos < len(s) && s[pos] == '"' {
+		value, remaining, err := readQuoted(s[pos:])
+		if err != nil {
+			return MetadataFilter{}, "", false, err
+		}
+		filter.Value = value
+		return filter, remaining, true, nil
+	}
+
+	end := strings.IndexFunc(s[pos:], unicode.IsSpace)
+	if end < 0 {
+		end = len(s) - pos
+	}
+	if end == 0 {
+		return MetadataFilter{}, "", false, fmt.Errorf("missing value for filter '%s'", filter.Field)
+	}
+	filter.Value = s[pos : pos+end]
+	return filter, s[pos+end:], true, nil
+}
+
+// readQuoted reads a double-quoted string at the start of s. Backslash
+// escapes a quote or backslash inside it.
+func readQuoted(s string) (value, rest string, err error) {
+	var b strings.Builder
+	for pos := 1; pos < len(s); pos++ {
+		switch s[pos] {
+		case '\\':
+			if pos+1 < len(s) {
+				pos++
+				b.WriteByte(s[pos])
+			}
+		case '"':
+			return b.String(), s[pos+1:], nil
+		default:
+			b.WriteByte(s[pos])
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quote in query")
+}
+
+// isFieldChar reports whether c may appear in a filter field name
+func isFieldChar(c byte, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		return true
+	case c >= '0' && c <= '9', c == '.', c == '-':
+		return !first
+	}
+	return false
+}
+
+// quoteQueryTerm quotes a value if it would not parse back unquoted
+func quoteQueryTerm(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\"\\:") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Query parses a query string with ParseQuery and searches with its text
+// and filters
+func (i *Index) Query(query string, limit int, options SearchOptions) ([]SearchResult, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if q.Text == "" {
+		return nil, fmt.Errorf("query has no search text")
+	}
+	options.Filters = append(options.Filters, q.Filters...)
+	return i.SearchWithOptions(q.Text, limit, options)
+}
+
+// matchFilters checks document metadata against filters. It returns the
+// decision for each filter and whether the document passed all of them.
+func matchFilters(metadata map[string]interface{}, filters []MetadataFilter) ([]FilterDecision, bool) {
+	decisions := make([]FilterDecision, 0, len(filters))
+	passed := true
+
+	// Equality filters on the same field are alternatives
+	alternatives := make(map[string]bool)
+	for _, f := range filters {
+		if f.Op == FilterEq && !f.Negate {
+			alternatives[f.Field] = alternatives[f.Field] || matchFilter(metadata[f.Field], f)
+		}
+	}
+
+	for _, f := range filters {
+		matched := matchFilter(metadata[f.Field], f)
+		ok := matched != f.Negate
+		if f.Op == FilterEq && !f.Negate {
+			ok = alternatives[f.Field]
+		}
+
+		decision := FilterDecision{Filter: f.String(), Passed: ok}
+		if !ok {
+			if value, exists := metadata[f.Field]; exists {
+				decision.Reason = fmt.Sprintf("%s is %v", f.Field, value)
+			} else {
+				decision.Reason = fmt.Sprintf("%s is not set", f.Field)
+			}
+		}
+		decisions = append(decisions, decision)
+		passed = passed && ok
+	}
+	return decisions, passed
+}
+
+// matchFilter reports whether a metadata value satisfies a filter, ignoring
+// negation. List values match if any element does.
+func matchFilter(value interface{}, f MetadataFilter) bool {
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if matchFilter(item, f) {
+				return true
+			}
+		}
+		return false
+	}
+	if items, ok := value.([]string); ok {
+		for _, item := range items {
+			if matchFilter(item, f) {
+				return true
+			}
+		}
+		return false
+	}
+	if value == nil {
+		return false
+	}
+
+	// Compare numerically when both sides are numbers, otherwise as text.
+	// RFC 3339 timestamps compare correctly as text.
+	cmp := 0
+	if n, ok := toFloat(value); ok {
+		target, err := strconv.ParseFloat(f.Value, 64)
+		if err != nil {
+			return false
+		}
+		switch {
+		case n < target:
+			cmp = -1
+		case n > target:
+			cmp = 1
+		}
+	} else {
+		s := fmt.Sprint(value)
+		if f.Op == FilterEq {
+			return strings.EqualFold(s, f.Value)
+		}
+		cmp = strings.Compare(s, f.Value)
+	}
+
+	switch f.Op {
+	case FilterEq:
+		return cmp == 0
+	case FilterGt:
+		return cmp > 0
+	case FilterGte:
+		return cmp >= 0
+	case FilterLt:
+		return cmp < 0
+	case FilterLte:
+		return cmp <= 0
+	}
+	return false
+}
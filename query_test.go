@@ -0,0 +1,108 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`kind:runbook space:ENG -status:archived priority:>=2 team:"Site Reliability" "database failover" now`)
+	require.NoError(t, err)
+	assert.Equal(t, "database failover now", q.Text)
+	assert.Equal(t, []MetadataFilter{
+		{Field: "kind", Op: FilterEq, Value: "runbook"},
+		{Field: "space", Op: FilterEq, Value: "ENG"},
+		{Field: "status", Op: FilterEq, Value: "archived", Negate: true},
+		{Field: "priority", Op: FilterGte, Value: "2"},
+		{Field: "team", Op: FilterEq, Value: "Site Reliability"},
+	}, q.Filters)
+
+	// Round trip through String
+	again, err := ParseQuery(q.String())
+	require.NoError(t, err)
+	assert.Equal(t, q.Filters, again.Filters)
+
+	// Terms that are not filters stay in the text
+	q, err = ParseQuery(`ratio 3:1 -verbose "http://example.com" well-known`)
+	require.NoError(t, err)
+	assert.Empty(t, q.Filters)
+	assert.Equal(t, "ratio 3:1 -verbose http://example.com well-known", q.Text)
+
+	_, err = ParseQuery(`kind:"runbook`)
+	assert.Error(t, err)
+	_, err = ParseQuery(`kind: runbook`)
+	assert.Error(t, err)
+}
+
+func TestMatchFilters(t *testing.T) {
+	metadata := map[string]interface{}{
+		"kind":     "Runbook",
+		"tags":     []interface{}{"db", "postgres"},
+		"priority": float64(3),
+		"updated":  "2024-06-01T00:00:00Z",
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"kind:runbook", true},
+		{"kind:howto", false},
+		{"kind:howto kind:runbook", true},
+		{"-kind:runbook", false},
+		{"-kind:howto", true},
+		{"tags:postgres", true},
+		{"tags:mysql", false},
+		{"priority:>2", true},
+		{"priority:<=2", false},
+		{"priority:>abc", false},
+		{"updated:>=2024-01-01", true},
+		{"updated:<2024-01-01", false},
+		{"owner:alice", false},
+		{"-owner:alice", true},
+		{"kind:runbook priority:<2", false},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		require.NoError(t, err)
+		_, passed := matchFilters(metadata, q.Filters)
+		assert.Equal(t, tt.want, passed, tt.query)
+	}
+}
+
+func TestIndex_Query(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("query")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "r1", Title: "Failover runbook", Content: "database failover steps", Metadata: map[string]interface{}{"kind": "runbook", "space": "ENG"}},
+		{URI: "r2", Title: "Failover runbook", Content: "database failover steps", Metadata: map[string]interface{}{"kind": "runbook", "space": "OPS"}},
+		{URI: "h1", Title: "Failover howto", Content: "database failover steps", Metadata: map[string]interface{}{"kind": "howto", "space": "ENG"}},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	results, err := index.Query(`kind:runbook space:ENG "database failover"`, 5, SearchOptions{Explain: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "r1", results[0].Document.URI)
+	require.Len(t, results[0].Explain.Filters, 2)
+	assert.True(t, results[0].Explain.Filters[0].Passed)
+
+	results, err = index.Query(`-space:ENG database failover`, 5, SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "r2", results[0].Document.URI)
+
+	// Filters limit the result count like unfiltered searches
+	results, err = index.Query(`space:ENG database failover`, 1, SearchOptions{})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	_, err = index.Query(`kind:runbook`, 5, SearchOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package hnswindex
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// QueryStats aggregates the query log of an index
+type QueryStats struct {
+	TotalQueries      int          `json:"total_queries"`
+	ZeroResultQueries int          `json:"zero_result_queries"`
+	ClickedQueries    int          `json:"clicked_queries"`
+	ClickThroughRate  float64      `json:"click_through_rate"`
+	AvgLatencyMs      float64      `json:"avg_latency_ms"`
+	P95LatencyMs      float64      `json:"p95_latency_ms"`
+	AvgTopScore       float64      `json:"avg_top_score"`
+	MeanClickRank     float64      `json:"mean_click_rank,omitempty"` // 1-based rank of clicked results
+	TopQueries        []QueryCount `json:"top_queries,omitempty"`
+	TopZeroResult     []QueryCount `json:"top_zero_result,omitempty"`
+	FirstQuery        time.Time    `json:"first_query,omitempty"`
+	LastQuery         time.Time    `json:"last_query,omitempty"`
+}
+
+// QueryCount is a normalized query and how often it was searched
+type QueryCount struct {
+	Query string `json:"query"`
+	Count int    `json:"count"`
+}
+
+// topQueryLimit bounds the number of queries reported in QueryStats lists
+const topQueryLimit = 10
+
+// RecordFeedback records that a search result was used (clicked) by the user.
+// The query ID is taken from SearchResult.QueryID and is only set when query
+// logging is enabled.
+func (i *Index) RecordFeedback(queryID uint64, uri string) error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.manager.storage.AddQueryClick(i.name, queryID, uri)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// QueryStats returns aggregated statistics from the query log
+func (i *Index) QueryStats() (QueryStats, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.QueryStats()
+	}
+	return QueryStats{}, fmt.Errorf("implementation not available")
+}
+
+// logQuery stores a query in the query log and returns its ID.
+// Failures are logged but never fail the search itself.
+func (i *indexImpl) logQuery(query string, latency time.Duration, results []SearchResult) uint64 {
+	entry := storage.QueryLogEntry{
+		Query:       query,
+		Timestamp:   time.Now(),
+		LatencyMs:   float64(latency.Microseconds()) / 1000.0,
+		ResultCount: len(results),
+	}
+	for _, r := range results {
+		entry.ResultURIs = append(entry.ResultURIs, r.Document.URI)
+	}
+	if len(results) > 0 {
+		entry.TopScore = results[0].Score
+	}
+
+	id, err := i.manager.storage.AppendQueryLog(i.name, entry)
+	if err != nil {
+		slog.Warn("Failed to record query log entry",
+			"index", i.name,
+			"error", err,
+		)
+		return 0
+	}
+	return id
+}
+
+// QueryStats implementation
+func (i *indexImpl) QueryStats() (QueryStats, error) {
+	var stats QueryStats
+	var latencies []float64
+	var scoreSum, rankSum float64
+	var rankCount int
+	counts := make(map[string]int)
+	zeroCounts := make(map[string]int)
+
+	err := i.manager.storage.ForEachQueryLog(i.name, func(e storage.QueryLogEntry) error {
+		stats.TotalQueries++
+		latencies = append(latencies, e.LatencyMs)
+		scoreSum += e.TopScore
+
+		if stats.FirstQuery.IsZero() || e.Timestamp.Before(stats.FirstQuery) {
+			stats.FirstQuery = e.Timestamp
+		}
+		if e.Timestamp.After(stats.LastQuery) {
+			stats.LastQuery = e.Timestamp
+		}
+
+		normalized := normalizeQuery(e.Query)
+		counts[normalized]++
+		if e.ResultCount == 0 {
+			stats.ZeroResultQueries++
+			zeroCounts[normalized]++
+		}
+
+		if len(e.Clicks) > 0 {
+			stats.ClickedQueries++
+			for _, clicked := range e.Clicks {
+				for rank, uri := range e.ResultURIs {
+					if uri == clicked {
+						rankSum += float64(rank + 1)
+						rankCount++
+						break
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to read query log: %w", err)
+	}
+
+	if stats.TotalQueries == 0 {
+		return stats, nil
+	}
+
+	latencySummary := summarize(latencies)
+	stats.AvgLatencyMs = latencySummary.Mean
+	sort.Float64s(latencies)
+	stats.P95LatencyMs = percentile(latencies, 0.95)
+	stats.AvgTopScore = scoreSum / float64(stats.TotalQueries)
+	stats.ClickThroughRate = float64(stats.ClickedQueries) / float64(stats.TotalQueries)
+	if rankCount > 0 {
+		stats.MeanClickRank = rankSum / float64(rankCount)
+	}
+	stats.TopQueries = topQueries(counts, topQueryLimit)
+	stats.TopZeroResult = topQueries(zeroCounts, topQueryLimit)
+
+	return stats, nil
+}
+
+// normalizeQuery folds case and whitespace so equivalent queries aggregate
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// topQueries returns the most frequent queries, ties broken alphabetically
+func topQueries(counts map[string]int, limit int) []QueryCount {
+	list := make([]QueryCount, 0, len(counts))
+	for q, c := range counts {
+		list = append(list, QueryCount{Query: q, Count: c})
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Count != list[b].Count {
+			return list[a].Count > list[b].Count
+		}
+		return list[a].Query < list[b].Query
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
@@ -0,0 +1,65 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLog(t *testing.T) {
+	cfg := NewConfig()
+	cfg.QueryLog = true
+	manager := newMockManager(t, cfg)
+
+	index, err := manager.CreateIndex("querylog")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "doc1", Title: "Go", Content: "Go is a statically typed language"},
+		{URI: "doc2", Title: "Python", Content: "Python is dynamically typed"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	results, err := index.Search("typed languages", 2)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	require.NotZero(t, results[0].QueryID)
+
+	_, err = index.Search("Typed  Languages", 2)
+	require.NoError(t, err)
+
+	require.NoError(t, index.RecordFeedback(results[0].QueryID, results[0].Document.URI))
+
+	stats, err := index.QueryStats()
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.TotalQueries)
+	assert.Equal(t, 1, stats.ClickedQueries)
+	assert.Equal(t, 0.5, stats.ClickThroughRate)
+	assert.Equal(t, 1.0, stats.MeanClickRank)
+	require.NotEmpty(t, stats.TopQueries)
+	assert.Equal(t, QueryCount{Query: "typed languages", Count: 2}, stats.TopQueries[0])
+}
+
+func TestQueryLog_Disabled(t *testing.T) {
+	manager := newMockManager(t, nil)
+
+	index, err := manager.CreateIndex("nolog")
+	require.NoError(t, err)
+
+	results, err := index.Search("anything", 5)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	stats, err := index.QueryStats()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.TotalQueries)
+}
+
+func TestTopQueries(t *testing.T) {
+	counts := map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}
+	top := topQueries(counts, 3)
+	assert.Equal(t, []QueryCount{{"c", 5}, {"a", 2}, {"b", 2}}, top)
+}
@@ -0,0 +1,76 @@
+package hnswindex
+
+import (
+	"fmt"
+	"io"
+)
+
+// RegisterQueryEmbedder makes e the query encoder of SearchOptions.Model
+// name, such as the query side of an asymmetric model pair or a model being
+// tried against an existing index. Its vectors must have the dimension of
+// the indexes it searches. Models that are not registered are created from
+// the configuration with EmbedModel set to the name, except with the ONNX
+// provider, whose models must be registered.
+func (im *IndexManager) RegisterQueryEmbedder(name string, e Embedder) {
+	if impl := im.getImpl(); impl != nil {
+		impl.mu.Lock()
+		defer impl.mu.Unlock()
+		if impl.queryEmbedders == nil {
+			impl.queryEmbedders = make(map[string]Embedder)
+		}
+		impl.queryEmbedders[name] = e
+	}
+}
+
+// queryEmbedder returns the embedder of a search model: the index
+// embedder for "" or Config.EmbedModel, otherwise a registered one or one
+// created, and kept, from the configuration
+func (im *indexManagerImpl) queryEmbedder(model string) (Embedder, error) {
+	if model == "" || model == im.config.EmbedModel {
+		return im.embedder, nil
+	}
+
+	im.mu.RLock()
+	e, ok := im.queryEmbedders[model]
+	im.mu.RUnlock()
+	if ok {
+		return e, nil
+	}
+
+	if im.config.EmbedProvider == EmbedProviderONNX {
+		return nil, fmt.Errorf("query model %q is not registered", model)
+	}
+	config := *im.config
+	config.EmbedModel = model
+	created, err := newEmbedder(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query model %q: %w", model, err)
+	}
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	if e, ok := im.queryEmbedders[model]; ok {
+		// Created concurrently by another search
+		if closer, ok := created.(io.Closer); ok {
+			closer.Close()
+		}
+		return e, nil
+	}
+	if im.queryEmbedders == nil {
+		im.queryEmbedders = make(map[string]Embedder)
+	}
+	im.queryEmbedders[model] = created
+	return created, nil
+}
+
+// closeQueryEmbedders closes the query embedders that hold resources
+func (im *indexManagerImpl) closeQueryEmbedders() {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+	for _, e := range im.queryEmbedders {
+		if closer, ok := e.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	im.queryEmbedders = nil
+}
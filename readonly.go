@@ -0,0 +1,104 @@
+package hnswindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrReadOnly is returned by writes to a manager opened with OpenSnapshot
+// and to its indexes
+var ErrReadOnly = errors.New("index manager is read-only")
+
+// readOnlySnapshot is the snapshot a read-only manager was opened from and
+// the temporary directory it was restored to
+type readOnlySnapshot struct {
+	path string
+	dir  string
+}
+
+// OpenSnapshot opens a snapshot file, as written by WriteSnapshot or
+// exported by UploadSnapshot to a directory store, as a read-only manager
+// for investigating an earlier state of the indexes. The snapshot is
+// verified and restored to a temporary directory, which Close removes; the
+// data directory of im is not touched. The returned manager embeds queries
+// with the embedders of im and must be closed before im.
+//
+// Searches, Get and Stats work as usual. Indexing, deleting, creating and
+// deleting indexes, sealing and maintenance fail with ErrReadOnly. Settings
+// can change, but only in the temporary copy.
+func (im *IndexManager) OpenSnapshot(path string) (*IndexManager, error) {
+	if impl := im.getImpl(); impl != nil {
+		return impl.openSnapshot(path)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// openSnapshot implementation
+func (im *indexManagerImpl) openSnapshot(path string) (*IndexManager, error) {
+	start := time.Now()
+	dir, err := os.MkdirTemp("", "hnswindex-snapshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	ok, err := DownloadSnapshot(context.Background(), NewDirStore(filepath.Dir(path)), filepath.Base(path), dir)
+	if err == nil && !ok {
+		err = fmt.Errorf("snapshot %s not found", path)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	config := *im.config
+	config.DataPath = dir
+	config.AutoSave = false
+	config.SnapshotStore = nil
+	config.SnapshotOnSave = false
+	config.MaintenanceInterval = 0
+
+	manager, err := newIndexManager(&config, im.embedder)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	impl := manager.getImpl()
+	impl.readOnly = &readOnlySnapshot{path: path, dir: dir}
+	impl.sparse = im.sparse
+
+	slog.Info("Snapshot opened",
+		"path", path,
+		"indexes", len(impl.indexes.all()),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return manager, nil
+}
+
+// ReadOnly reports whether the manager was opened with OpenSnapshot
+func (im *IndexManager) ReadOnly() bool {
+	impl := im.getImpl()
+	return impl != nil && impl.readOnly != nil
+}
+
+// close releases the restored copy of the snapshot
+func (s *readOnlySnapshot) close(im *indexManagerImpl) error {
+	err := im.storage.Close()
+	if rmErr := os.RemoveAll(s.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// readOnly returns ErrReadOnly if the manager of the index was opened with
+// OpenSnapshot
+func (i *indexImpl) readOnly() error {
+	if i.manager.readOnly != nil {
+		return fmt.Errorf("%w: %s", ErrReadOnly, i.name)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package hnswindex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenSnapshot(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "doc1", Title: "One", Content: "First document content"},
+		Document{URI: "doc2", Title: "Two", Content: "Second document content"},
+	)
+
+	path := filepath.Join(t.TempDir(), "kb.snapshot")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, manager.WriteSnapshot(f))
+	require.NoError(t, f.Close())
+
+	// The live index moves on after the snapshot
+	require.NoError(t, index.DeleteDocument("doc1"))
+
+	snap, err := manager.OpenSnapshot(path)
+	require.NoError(t, err)
+	assert.True(t, snap.ReadOnly())
+	assert.False(t, manager.ReadOnly())
+	dir := snap.getImpl().readOnly.dir
+
+	old, err := snap.GetIndex("kb")
+	require.NoError(t, err)
+	results, err := old.Search("First document content", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doc1"}, resultURIs(results))
+
+	// Every write fails with ErrReadOnly
+	_, err = old.AddDocumentBatch(context.Background(), []Document{{URI: "doc3", Content: "Third"}}, nil)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.ErrorIs(t, old.DeleteDocument("doc1"), ErrReadOnly)
+	assert.ErrorIs(t, old.Clear(), ErrReadOnly)
+	_, err = old.Seal()
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = old.Compact()
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = snap.CreateIndex("other")
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.ErrorIs(t, snap.DeleteIndex("kb"), ErrReadOnly)
+	_, err = snap.RunMaintenance(context.Background(), MaintenanceOptions{})
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	require.NoError(t, snap.Close())
+	assert.NoDirExists(t, dir)
+
+	// The live manager is untouched and keeps its embedder
+	results, err = index.Search("First document content", 5)
+	require.NoError(t, err)
+	assert.NotContains(t, resultURIs(results), "doc1")
+
+	_, err = manager.OpenSnapshot(filepath.Join(t.TempDir(), "missing.snapshot"))
+	assert.Error(t, err)
+}
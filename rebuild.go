@@ -0,0 +1,203 @@
+package hnswindex
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// rebuildPrefix names the staging index of a rebuild. Index names cannot
+// start with an underscore, so staging indexes never collide with them.
+const rebuildPrefix = "_rebuild_"
+
+func rebuildIndexName(name string) string {
+	return rebuildPrefix + name
+}
+
+func isRebuildIndex(name string) bool {
+	return strings.HasPrefix(name, rebuildPrefix)
+}
+
+// BeginRebuild starts rebuilding the index from scratch. It returns a
+// staging index, which starts empty with the index's metadata schema;
+// documents added to it are chunked and embedded with the manager's
+// current configuration. The index keeps serving reads and writes until
+// CommitRebuild replaces it with the staging index, so a rebuild (for
+// example with a new embedding model) needs no downtime. Writes to the
+// index after BeginRebuild are discarded by CommitRebuild.
+func (i *Index) BeginRebuild() (*Index, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.manager.beginRebuild(impl.name)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// CommitRebuild, called on the index or its staging index, atomically
+// replaces the index's documents, chunks, and graph with those of the
+// staging index from BeginRebuild. Searches see either the old or the new
+// version, never a mix. Added, changed, and removed documents are recorded
+// in the change log and reported as events.
+func (i *Index) CommitRebuild() error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.manager.commitRebuild(impl.name)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// AbortRebuild, called on the index or its staging index, discards the
+// staging index from BeginRebuild
+func (i *Index) AbortRebuild() error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.manager.abortRebuild(impl.name)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// beginRebuild creates the staging index of a rebuild
+func (im *indexManagerImpl) beginRebuild(name string) (*Index, error) {
+	if isRebuildIndex(name) {
+		return nil, fmt.Errorf("index '%s' is a rebuild in progress", name)
+	}
+
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
+
+	if live, exists := im.indexes.get(name); exists {
+		if err := live.writable(); err != nil {
+			return nil, err
+		}
+	}
+	staging := rebuildIndexName(name)
+	if _, exists := im.indexes.get(staging); exists {
+		return nil, fmt.Errorf("a rebuild of '%s' is already in progress", name)
+	}
+
+	if err := im.storage.CreateStagingIndex(name, staging); err != nil {
+		return nil, fmt.Errorf("failed to create staging index: %w", err)
+	}
+	// The rebuild records the configuration it is built with
+	if err := im.storage.SetIndexSetting(staging, configSettingKey, nil); err != nil {
+		im.storage.DeleteIndex(staging)
+		return nil, err
+	}
+	// and starts with full embeddings, which a new model may not share
+	if err := im.storage.SetIndexSetting(staging, reductionSettingKey, nil); err != nil {
+		im.storage.DeleteIndex(staging)
+		return nil, err
+	}
+
+	os.Remove(im.graphPath(staging)) // Left behind by an interrupted rebuild
+	impl, err := im.newIndexImpl(staging)
+	if err != nil {
+		im.storage.DeleteIndex(staging)
+		return nil, err
+	}
+	im.indexes.put(impl)
+
+	if err := impl.recordConfig(); err != nil {
+		slog.Warn("Failed to record index configuration",
+			"index", staging,
+			"error", err,
+		)
+	}
+
+	slog.Info("Index rebuild started",
+		"index", name,
+		"staging", staging,
+	)
+	return &Index{
+		name:    staging,
+		manager: im.wrapperManager(),
+	}, nil
+}
+
+// commitRebuild swaps the staging index of a rebuild into place
+func (im *indexManagerImpl) commitRebuild(name string) error {
+	name = strings.TrimPrefix(name, rebuildPrefix)
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
+
+	live, exists := im.indexes.get(name)
+	if !exists {
+		return fmt.Errorf("index '%s' not found", name)
+	}
+	staging, exists := im.indexes.get(rebuildIndexName(name))
+	if !exists {
+		return fmt.Errorf("no rebuild of '%s' in progress", name)
+	}
+
+	// Wait for writes to both versions to finish
+	staging.mu.Lock()
+	defer staging.mu.Unlock()
+	live.mu.Lock()
+	defer live.mu.Unlock()
+	if err := live.writable(); err != nil {
+		return err
+	}
+
+	if err := staging.hnswIndex.Save(); err != nil {
+		return fmt.Errorf("failed to save rebuilt graph: %w", err)
+	}
+	changes, err := im.storage.ReplaceIndex(name, staging.name)
+	if err != nil {
+		return fmt.Errorf("failed to replace index: %w", err)
+	}
+
+	// Storage now holds the rebuilt version; the graph follows. Searches
+	// in between find no chunks for the old graph's IDs, which precede
+	// the rebuild's.
+	live.deleted = true
+	staging.deleted = true
+	moveErr := staging.hnswIndex.Move(im.graphPath(name))
+	im.indexes.put(&indexImpl{
+		name:      name,
+		manager:   im,
+		hnswIndex: staging.hnswIndex,
+	})
+	im.indexes.remove(staging.name)
+	if moveErr == nil {
+		os.RemoveAll(filepath.Dir(im.graphPath(staging.name)))
+	}
+
+	for _, c := range changes {
+		event := DocumentEvent{Index: name, URI: c.URI}
+		if c.Op == storage.ChangeDelete {
+			im.emitDocumentDeleted(event)
+		} else {
+			im.emitDocumentIndexed(event)
+		}
+	}
+	im.indexSaved(name)
+
+	slog.Info("Index rebuild committed",
+		"index", name,
+		"changes", len(changes),
+	)
+	if moveErr != nil {
+		return fmt.Errorf("rebuild committed but its graph file could not be moved into place: %w", moveErr)
+	}
+	return nil
+}
+
+// abortRebuild discards the staging index of a rebuild
+func (im *indexManagerImpl) abortRebuild(name string) error {
+	name = strings.TrimPrefix(name, rebuildPrefix)
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
+
+	staging := rebuildIndexName(name)
+	if _, exists := im.indexes.get(staging); !exists {
+		return fmt.Errorf("no rebuild of '%s' in progress", name)
+	}
+	if err := im.deleteIndex(staging); err != nil {
+		return err
+	}
+	os.RemoveAll(filepath.Dir(im.graphPath(staging)))
+
+	slog.Info("Index rebuild aborted", "index", name)
+	return nil
+}
@@ -0,0 +1,144 @@
+package hnswindex
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuild_Commit(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "old", Title: "Old", Content: "Document that the rebuild drops"},
+		{URI: "kept", Title: "Kept", Content: "Document that stays the same"},
+		{URI: "edited", Title: "Edited", Content: "Document before its edit"},
+	}, nil)
+	require.NoError(t, err)
+	before, err := index.LatestChange()
+	require.NoError(t, err)
+
+	var events []string
+	manager.Subscribe(EventHandlerFuncs{
+		DocumentIndexed: func(e DocumentEvent) { events = append(events, e.Index+" indexed "+e.URI) },
+		DocumentDeleted: func(e DocumentEvent) { events = append(events, e.Index+" deleted "+e.URI) },
+	})
+
+	staging, err := index.BeginRebuild()
+	require.NoError(t, err)
+	_, err = index.BeginRebuild()
+	assert.Error(t, err, "one rebuild at a time")
+
+	_, err = staging.AddDocumentBatch(context.Background(), []Document{
+		{URI: "kept", Title: "Kept", Content: "Document that stays the same"},
+		{URI: "edited", Title: "Edited", Content: "Document after its edit"},
+		{URI: "new", Title: "New", Content: "Document the rebuild adds"},
+	}, nil)
+	require.NoError(t, err)
+
+	// The index serves the old version until the commit
+	results, err := index.Search("Document that the rebuild drops", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "old", results[0].Document.URI)
+	names, err := manager.ListIndexes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kb"}, names)
+
+	events = nil
+	require.NoError(t, index.CommitRebuild())
+	sort.Strings(events)
+	assert.Equal(t, []string{"kb deleted old", "kb indexed edited", "kb indexed new"}, events)
+
+	_, err = index.GetDocument("old")
+	assert.Error(t, err)
+	doc, err := index.GetDocument("edited")
+	require.NoError(t, err)
+	assert.Equal(t, "Document after its edit", doc.Content)
+
+	results, err = index.Search("Document the rebuild adds", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "new", results[0].Document.URI)
+
+	stats, err := index.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.DocumentCount)
+
+	changes, err := index.Changes(before, 0)
+	require.NoError(t, err)
+	assert.Len(t, changes, 3, "only the differences are logged")
+
+	_, err = staging.Search("Document", 1)
+	assert.Error(t, err, "the staging index is gone")
+	assert.Error(t, index.CommitRebuild())
+}
+
+func TestRebuild_Abort(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, Document{URI: "doc", Content: "Original document"})
+
+	staging, err := index.BeginRebuild()
+	require.NoError(t, err)
+	addDocuments(t, staging, Document{URI: "other", Content: "Replacement document"})
+	require.NoError(t, staging.AbortRebuild())
+
+	_, err = index.GetDocument("doc")
+	assert.NoError(t, err)
+	_, err = index.GetDocument("other")
+	assert.Error(t, err)
+	assert.Error(t, index.CommitRebuild())
+
+	// A new rebuild can start
+	_, err = index.BeginRebuild()
+	assert.NoError(t, err)
+}
+
+func TestRebuild_PerIndexLayout(t *testing.T) {
+	cfg := NewConfig()
+	cfg.StorageLayout = "per_index"
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, Document{URI: "doc", Content: "Original document"})
+
+	staging, err := index.BeginRebuild()
+	require.NoError(t, err)
+	addDocuments(t, staging, Document{URI: "doc", Content: "Rebuilt document"})
+	require.NoError(t, staging.CommitRebuild())
+
+	doc, err := index.GetDocument("doc")
+	require.NoError(t, err)
+	assert.Equal(t, "Rebuilt document", doc.Content)
+}
+
+func TestRebuild_DiscardedOnRestart(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.BeginRebuild()
+	require.NoError(t, err)
+	require.NoError(t, manager.Close())
+	require.NoError(t, manager.getImpl().storage.Close()) // Release the database lock
+
+	reopened := newMockManager(t, cfg)
+	index, err = reopened.GetIndex("kb")
+	require.NoError(t, err)
+	_, err = index.BeginRebuild()
+	assert.NoError(t, err)
+}
+
+func addDocuments(t *testing.T, index *Index, docs ...Document) {
+	t.Helper()
+	result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	require.Empty(t, result.FailedURIs)
+}
@@ -0,0 +1,284 @@
+package hnswindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/indexer"
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// ReductionMethod selects how embeddings are shortened before they are
+// added to an index's graph
+type ReductionMethod string
+
+const (
+	// ReductionNone keeps full embeddings in the graph
+	ReductionNone ReductionMethod = ""
+	// ReductionTruncate keeps the leading dimensions. It suits Matryoshka
+	// models (e.g. nomic-embed-text v1.5), which are trained to put the
+	// most information there.
+	ReductionTruncate ReductionMethod = "truncate"
+	// ReductionPCA projects embeddings onto the principal components of the
+	// index's stored embeddings
+	ReductionPCA ReductionMethod = "pca"
+)
+
+const (
+	reductionSettingKey = "reduction"
+	pcaSample           = 2000 // Most embeddings a PCA is fitted on
+	reductionBatch      = 1000 // Vectors added to a new graph at a time
+)
+
+// Reduction is the projection an index applies to embeddings before they
+// enter its graph. Stored chunks keep their full embeddings, so the
+// reduction can be changed or removed without embedding again.
+type Reduction struct {
+	Method         ReductionMethod `json:"method"`
+	InputDimension int             `json:"input_dimension"` // Dimension of the embeddings
+	Dimension      int             `json:"dimension"`       // Dimension of the graph
+
+	// PCA only: the mean of the fitted embeddings, the Dimension principal
+	// components, and the share of the variance they capture
+	Mean              []float32   `json:"mean,omitempty"`
+	Components        [][]float32 `json:"components,omitempty"`
+	ExplainedVariance float64     `json:"explained_variance,omitempty"`
+	FittedOn          int         `json:"fitted_on,omitempty"` // Embeddings in the fit
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Apply reduces an embedding of InputDimension values to Dimension values
+func (r *Reduction) Apply(v []float32) []float32 {
+	if r == nil {
+		return v
+	}
+	if r.Method == ReductionTruncate {
+		return append([]float32(nil), v[:r.Dimension]...)
+	}
+
+	out := make([]float32, len(r.Components))
+	for c, component := range r.Components {
+		var sum float64
+		for x, value := range v {
+			sum += float64(component[x]) * float64(value-r.Mean[x])
+		}
+		out[c] = float32(sum)
+	}
+	return out
+}
+
+// Reduce shortens the embeddings in the index's graph to dimension values,
+// trading some recall for a smaller, faster graph: 768 to 256 dimensions
+// makes the graph about three times smaller. The graph is rebuilt from
+// the stored embeddings, which stay unchanged; documents added later and
+// queries are reduced the same way. ReductionNone restores full embeddings.
+// PCA is fitted on the chunks already in the index, so add a representative
+// sample of documents first.
+func (i *Index) Reduce(method ReductionMethod, dimension int) (*Reduction, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.manager.reduce(impl.name, method, dimension)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Reduction returns the reduction the index applies to embeddings, or nil
+// if its graph holds full embeddings
+func (i *Index) Reduction() (*Reduction, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.reduction, nil
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// loadReduction reads the stored reduction of an index
+func (im *indexManagerImpl) loadReduction(name string) (*Reduction, error) {
+	data, err := im.storage.GetIndexSetting(name, reductionSettingKey)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var r Reduction
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to decode reduction: %w", err)
+	}
+	return &r, nil
+}
+
+// embeddingDimension returns the dimension of the embeddings the index
+// stores, which the graph's is smaller than under a reduction
+func (i *indexImpl) embeddingDimension() int {
+	if i.reduction != nil {
+		return i.reduction.InputDimension
+	}
+	return i.hnswIndex.Dimension()
+}
+
+// graphVector returns an embedding as it is stored in the graph
+func (i *indexImpl) graphVector(v []float32) []float32 {
+	return i.reduction.Apply(v)
+}
+
+// reduce rebuilds the graph of an index with a new reduction
+func (im *indexManagerImpl) reduce(name string, method ReductionMethod, dimension int) (*Reduction, error) {
+	if isRebuildIndex(name) {
+		return nil, fmt.Errorf("index '%s' is a rebuild in progress", name)
+	}
+
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
+
+	live, exists := im.indexes.get(name)
+	if !exists {
+		return nil, fmt.Errorf("index '%s' not found", name)
+	}
+
+	// Wait for writes to finish and hold off new ones
+	live.mu.Lock()
+	defer live.mu.Unlock()
+	live.commitMu.Lock()
+	defer live.commitMu.Unlock()
+	if err := live.writable(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	r, err := live.fitReduction(method, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	graphDimension := live.embeddingDimension()
+	if r != nil {
+		graphDimension = r.Dimension
+	}
+	path := im.graphPath(name)
+	os.Remove(path + ".reduce") // Left behind by an interrupted reduction
+	graph, err := indexer.NewHNSWIndex(path+".reduce", graphDimension, live.hnswIndex.Config())
+	if err != nil {
+		return nil, err
+	}
+	if err := live.fillGraph(graph, r); err != nil {
+		os.Remove(path + ".reduce")
+		return nil, err
+	}
+	if err := graph.Save(); err != nil {
+		os.Remove(path + ".reduce")
+		return nil, fmt.Errorf("failed to save reduced graph: %w", err)
+	}
+
+	var data []byte
+	if r != nil {
+		if data, err = json.Marshal(r); err != nil {
+			os.Remove(path + ".reduce")
+			return nil, fmt.Errorf("failed to encode reduction: %w", err)
+		}
+	}
+	if err := im.storage.SetIndexSetting(name, reductionSettingKey, data); err != nil {
+		os.Remove(path + ".reduce")
+		return nil, err
+	}
+
+	// Writers waiting on the old version fail as after a rebuild; searches
+	// move to the new graph with their next lookup
+	live.deleted = true
+	moveErr := graph.Move(path)
+	im.indexes.put(&indexImpl{
+		name:      name,
+		manager:   im,
+		hnswIndex: graph,
+		reduction: r,
+	})
+
+	slog.Info("Index graph reduced",
+		"index", name,
+		"method", method,
+		"dimension", graphDimension,
+		"nodes", graph.Size(),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	if moveErr != nil {
+		return r, fmt.Errorf("reduction applied but its graph file could not be moved into place: %w", moveErr)
+	}
+	return r, nil
+}
+
+// fitReduction computes the reduction of the index's embeddings to
+// dimension values, nil for ReductionNone
+func (i *indexImpl) fitReduction(method ReductionMethod, dimension int) (*Reduction, error) {
+	input := i.embeddingDimension()
+	switch method {
+	case ReductionNone:
+		return nil, nil
+	case ReductionTruncate, ReductionPCA:
+	default:
+		return nil, fmt.Errorf("unknown reduction method %q", method)
+	}
+	if dimension <= 0 || dimension >= input {
+		return nil, fmt.Errorf("reduced dimension must be between 1 and %d, got %d", input-1, dimension)
+	}
+
+	r := &Reduction{
+		Method:         method,
+		InputDimension: input,
+		Dimension:      dimension,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if method == ReductionTruncate {
+		return r, nil
+	}
+
+	stride := max(1, i.hnswIndex.Size()/pcaSample)
+	var vectors [][]float32
+	n := 0
+	err := i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		n++
+		if (n-1)%stride == 0 && len(chunk.Embedding) == input && len(vectors) < pcaSample {
+			vectors = append(vectors, chunk.Embedding)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) < dimension {
+		return nil, fmt.Errorf("PCA to %d dimensions needs at least %d embedded chunks, the index has %d", dimension, dimension, len(vectors))
+	}
+
+	r.Mean, r.Components, r.ExplainedVariance = fitPCA(vectors, dimension)
+	r.FittedOn = len(vectors)
+	return r, nil
+}
+
+// fillGraph adds every stored chunk to graph, reduced by r
+func (i *indexImpl) fillGraph(graph *indexer.HNSWIndex, r *Reduction) error {
+	input := i.embeddingDimension()
+	var vectors [][]float32
+	var ids []uint64
+	flush := func() error {
+		if len(ids) == 0 {
+			return nil
+		}
+		err := graph.AddBatch(vectors, ids)
+		vectors, ids = vectors[:0], ids[:0]
+		return err
+	}
+
+	err := i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		if chunk.HNSWId == 0 || len(chunk.Embedding) != input {
+			return nil
+		}
+		vectors = append(vectors, r.Apply(chunk.Embedding))
+		ids = append(ids, chunk.HNSWId)
+		if len(ids) == reductionBatch {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build graph: %w", err)
+	}
+	return flush()
+}
@@ -0,0 +1,101 @@
+package hnswindex
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reductionDocs(n int) []Document {
+	docs := make([]Document, n)
+	for i := range docs {
+		docs[i] = Document{URI: fmt.Sprintf("doc%d", i), Content: fmt.Sprintf("document number %d about topic %d", i, i%7)}
+	}
+	return docs
+}
+
+func TestIndex_Reduce(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, reductionDocs(40)...)
+
+	r, err := index.Reduce(ReductionTruncate, 64)
+	require.NoError(t, err)
+	assert.Equal(t, 768, r.InputDimension)
+	assert.Equal(t, 64, r.Dimension)
+
+	results, err := index.Search("document number 12 about topic 5", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "doc12", results[0].Document.URI)
+
+	// Documents added after the reduction are reduced too
+	addDocuments(t, index, Document{URI: "late", Content: "a document added later"})
+	results, err = index.Search("a document added later", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "late", results[0].Document.URI)
+
+	r, err = index.Reduce(ReductionPCA, 16)
+	require.NoError(t, err)
+	assert.Len(t, r.Components, 16)
+	assert.Equal(t, 41, r.FittedOn)
+	assert.Greater(t, r.ExplainedVariance, 0.0)
+	results, err = index.Search("document number 30 about topic 2", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "doc30", results[0].Document.URI)
+
+	// The reduction survives a restart
+	require.NoError(t, manager.Close())
+	require.NoError(t, manager.getImpl().storage.Close()) // Release the database lock
+	reopened := newMockManager(t, cfg)
+	index, err = reopened.GetIndex("kb")
+	require.NoError(t, err)
+	r, err = index.Reduction()
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, ReductionPCA, r.Method)
+	results, err = index.Search("document number 30 about topic 2", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "doc30", results[0].Document.URI)
+
+	// Removing the reduction restores full embeddings
+	r, err = index.Reduce(ReductionNone, 0)
+	require.NoError(t, err)
+	assert.Nil(t, r)
+	r, err = index.Reduction()
+	require.NoError(t, err)
+	assert.Nil(t, r)
+	results, err = index.Search("document number 7 about topic 0", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc7", results[0].Document.URI)
+	results, err = index.Search("document", 50)
+	require.NoError(t, err)
+	assert.Len(t, results, 41)
+}
+
+func TestIndex_ReduceErrors(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, reductionDocs(5)...)
+
+	_, err = index.Reduce("svd", 64)
+	assert.ErrorContains(t, err, "unknown reduction method")
+	_, err = index.Reduce(ReductionTruncate, 768)
+	assert.ErrorContains(t, err, "between 1 and 767")
+	_, err = index.Reduce(ReductionPCA, 16)
+	assert.ErrorContains(t, err, "at least 16 embedded chunks")
+
+	r, err := index.Reduction()
+	require.NoError(t, err)
+	assert.Nil(t, r)
+}
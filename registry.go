@@ -0,0 +1,74 @@
+package hnswindex
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// indexRegistry holds the open indexes of a manager. Lookups read an
+// immutable map without locking, so searches and ingestion never wait on
+// each other or on index creation; CreateIndex and DeleteIndex replace the
+// map under mu.
+type indexRegistry struct {
+	mu      sync.Mutex
+	current atomic.Pointer[map[string]*indexImpl]
+}
+
+// get returns the open index with the given name
+func (r *indexRegistry) get(name string) (*indexImpl, bool) {
+	m := r.current.Load()
+	if m == nil {
+		return nil, false
+	}
+	idx, ok := (*m)[name]
+	return idx, ok
+}
+
+// all returns the open indexes ordered by name
+func (r *indexRegistry) all() []*indexImpl {
+	m := r.current.Load()
+	if m == nil {
+		return nil
+	}
+	indexes := make([]*indexImpl, 0, len(*m))
+	for _, idx := range *m {
+		indexes = append(indexes, idx)
+	}
+	sort.Slice(indexes, func(a, b int) bool { return indexes[a].name < indexes[b].name })
+	return indexes
+}
+
+// put adds or replaces an index; r.mu must be held
+func (r *indexRegistry) put(idx *indexImpl) {
+	r.replace(func(m map[string]*indexImpl) { m[idx.name] = idx })
+}
+
+// remove drops an index; r.mu must be held
+func (r *indexRegistry) remove(name string) {
+	r.replace(func(m map[string]*indexImpl) { delete(m, name) })
+}
+
+func (r *indexRegistry) replace(change func(map[string]*indexImpl)) {
+	next := make(map[string]*indexImpl)
+	if m := r.current.Load(); m != nil {
+		for name, idx := range *m {
+			next[name] = idx
+		}
+	}
+	change(next)
+	r.current.Store(&next)
+}
+
+// acquire holds the index's lock shared for an operation that changes the
+// index, so DeleteIndex waits for it to finish. Searches do not take the
+// lock. The returned function releases it.
+func (i *indexImpl) acquire() (release func(), err error) {
+	i.mu.RLock()
+	if i.deleted {
+		i.mu.RUnlock()
+		return nil, fmt.Errorf("index '%s' not found", i.name)
+	}
+	return i.mu.RUnlock, nil
+}
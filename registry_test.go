@@ -0,0 +1,85 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_ConcurrentIndexes(t *testing.T) {
+	manager := newMockManager(t, nil)
+	search, err := manager.CreateIndex("search")
+	require.NoError(t, err)
+	_, err = search.AddDocumentBatch(context.Background(), []Document{
+		{URI: "doc1", Title: "One", Content: "Searchable document content"},
+	}, nil)
+	require.NoError(t, err)
+
+	ingest, err := manager.CreateIndex("ingest")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 20; n++ {
+			_, err := ingest.AddDocumentBatch(context.Background(), []Document{
+				{URI: fmt.Sprintf("doc%d", n), Content: fmt.Sprintf("Ingested document %d", n)},
+			}, nil)
+			assert.NoError(t, err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 50; n++ {
+			results, err := search.Search("Searchable document content", 1)
+			assert.NoError(t, err)
+			assert.Len(t, results, 1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 10; n++ {
+			name := fmt.Sprintf("temp%d", n)
+			_, err := manager.CreateIndex(name)
+			assert.NoError(t, err)
+			assert.NoError(t, manager.DeleteIndex(name))
+		}
+	}()
+	wg.Wait()
+
+	stats, err := ingest.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 20, stats.DocumentCount)
+}
+
+func TestRegistry_DeleteWaitsForWrites(t *testing.T) {
+	manager := newMockManager(t, nil)
+	_, err := manager.CreateIndex("docs")
+	require.NoError(t, err)
+	impl, ok := manager.getImpl().indexes.get("docs")
+	require.True(t, ok)
+
+	release, err := impl.acquire()
+	require.NoError(t, err)
+
+	deleted := make(chan error)
+	go func() { deleted <- manager.DeleteIndex("docs") }()
+
+	select {
+	case <-deleted:
+		t.Fatal("DeleteIndex returned during a write")
+	default:
+	}
+	release()
+	require.NoError(t, <-deleted)
+
+	_, err = impl.acquire()
+	assert.Error(t, err, "writes through a deleted index fail")
+	_, err = manager.GetIndex("docs")
+	assert.Error(t, err)
+}
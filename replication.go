@@ -0,0 +1,181 @@
+package hnswindex
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// ReplicaDocument is a document with its chunks and embeddings, as shipped
+// from a leader to read replicas. Replicas apply it without re-embedding.
+type ReplicaDocument struct {
+	Document Document `json:"document"`
+	Hash     string   `json:"hash"`   // Content hash on the leader
+	Chunks   []Chunk  `json:"chunks"` // Including embeddings
+}
+
+// Manifest returns the content hash of every document, keyed by URI.
+// A replica compares its manifest with the leader's to find the documents
+// to fetch and delete.
+func (i *Index) Manifest() (map[string]string, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.manager.storage.ListDocumentHashes(i.name)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// ReplicaDocuments returns the given documents with their chunks and
+// embeddings. URIs that are not in the index are skipped.
+func (i *Index) ReplicaDocuments(uris []string) ([]ReplicaDocument, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.ReplicaDocuments(uris)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// ApplyReplica upserts documents shipped by a leader, using their
+// embeddings, and deletes the given URIs. Upserts are committed in a single
+// storage transaction. Embeddings must be valid and match the index dimension.
+func (i *Index) ApplyReplica(docs []ReplicaDocument, deletes []string) error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.ApplyReplica(docs, deletes)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// ReplicaDocuments implementation
+func (i *indexImpl) ReplicaDocuments(uris []string) ([]ReplicaDocument, error) {
+	docs := make([]ReplicaDocument, 0, len(uris))
+	for _, uri := range uris {
+		stored, err := i.manager.storage.GetDocument(i.name, uri)
+		if err != nil {
+			continue // Deleted since the manifest was read
+		}
+		chunks, err := i.manager.storage.GetChunksByDocument(i.name, uri)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunks of '%s': %w", uri, err)
+		}
+
+		doc := ReplicaDocument{
+			Document: fromStorageDocument(stored),
+			Hash:     stored.Hash,
+			Chunks:   make([]Chunk, 0, len(chunks)),
+		}
+		for idx := range chunks {
+			doc.Chunks = append(doc.Chunks, fromStorageChunk(&chunks[idx]))
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// ApplyReplica implementation
+func (i *indexImpl) ApplyReplica(docs []ReplicaDocument, deletes []string) error {
+	release, err := i.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := i.writable(); err != nil {
+		return err
+	}
+
+	dimension := i.embeddingDimension()
+	writes := make([]storage.DocumentWrite, 0, len(docs))
+	created := make([]bool, 0, len(docs))
+	for _, doc := range docs {
+		w := storage.DocumentWrite{
+			Index: i.name,
+			Document: storage.Document{
+				URI:      doc.Document.URI,
+				Title:    doc.Document.Title,
+				Content:  doc.Document.Content,
+				Hash:     doc.Hash,
+				Metadata: doc.Document.Metadata,
+			},
+		}
+		for _, c := range doc.Chunks {
+			if err := validateEmbedding(c.Embedding, dimension); err != nil {
+				return fmt.Errorf("document '%s': %w", doc.Document.URI, err)
+			}
+			for _, v := range c.Vectors {
+				if err := validateEmbedding(v, dimension); err != nil {
+					return fmt.Errorf("document '%s': sub-chunk vector: %w", doc.Document.URI, err)
+				}
+			}
+			w.Chunks = append(w.Chunks, storage.Chunk{
+				ID:          c.ID,
+				DocumentURI: doc.Document.URI,
+				Text:        c.Text,
+				Embedding:   c.Embedding,
+				Vectors:     c.Vectors,
+				Sparse:      c.Sparse,
+				Position:    c.Position,
+				Metadata:    c.Metadata,
+			})
+		}
+		writes = append(writes, w)
+
+		_, err := i.manager.storage.GetDocumentHash(i.name, doc.Document.URI)
+		created = append(created, err != nil)
+	}
+
+	if len(writes) > 0 {
+		notIndexed, err := i.commitWrites(writes)
+		if err != nil {
+			return fmt.Errorf("failed to apply replicated documents: %w", err)
+		}
+		for uri, err := range notIndexed {
+			return fmt.Errorf("failed to index replicated document '%s': %w", uri, err)
+		}
+	}
+
+	for idx, w := range writes {
+		i.manager.emitDocumentIndexed(DocumentEvent{
+			Index:   i.name,
+			URI:     w.Document.URI,
+			Title:   w.Document.Title,
+			Chunks:  len(w.Chunks),
+			Created: created[idx],
+		})
+	}
+
+	for _, uri := range deletes {
+		if err := i.removeDocument(uri); err != nil {
+			return fmt.Errorf("failed to delete '%s': %w", uri, err)
+		}
+	}
+
+	if len(writes) == 0 && len(deletes) == 0 {
+		return nil
+	}
+
+	if i.manager.config.AutoSave {
+		if err := i.hnswIndex.Save(); err != nil {
+			return fmt.Errorf("failed to save HNSW index: %w", err)
+		}
+		i.manager.indexSaved(i.name)
+	}
+
+	i.manager.storage.UpdateIndexMetadata(i.name, func(metadata *storage.IndexMetadata) {
+		metadata.LastUpdated = time.Now().UTC()
+	})
+	snap := storage.StatsSnapshot{Time: time.Now(), DeletedDocuments: len(deletes)}
+	for _, isNew := range created {
+		if isNew {
+			snap.NewDocuments++
+		} else {
+			snap.UpdatedDocuments++
+		}
+	}
+	i.recordStats(snap)
+
+	slog.Info("Applied replicated changes",
+		"index", i.name,
+		"upserted", len(writes),
+		"deleted", len(deletes),
+	)
+	return nil
+}
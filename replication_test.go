@@ -0,0 +1,61 @@
+package hnswindex
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyReplica(t *testing.T) {
+	leader, err := newMockManager(t, nil).CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = leader.AddDocumentBatch(context.Background(), []Document{
+		{URI: "doc1", Title: "One", Content: "First document content"},
+		{URI: "doc2", Title: "Two", Content: "Second document content"},
+	}, nil)
+	require.NoError(t, err)
+
+	docs, err := leader.ReplicaDocuments([]string{"doc1", "doc2", "missing"})
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	require.NotEmpty(t, docs[0].Chunks)
+	assert.Len(t, docs[0].Chunks[0].Embedding, 768)
+
+	follower, err := newMockManager(t, nil).CreateIndex("kb")
+	require.NoError(t, err)
+	require.NoError(t, follower.ApplyReplica(docs, nil))
+
+	leaderManifest, err := leader.Manifest()
+	require.NoError(t, err)
+	followerManifest, err := follower.Manifest()
+	require.NoError(t, err)
+	assert.Equal(t, leaderManifest, followerManifest)
+
+	stats, err := follower.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.DocumentCount)
+
+	require.NoError(t, follower.ApplyReplica(nil, []string{"doc2"}))
+	followerManifest, err = follower.Manifest()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doc1"}, slices.Collect(maps.Keys(followerManifest)))
+}
+
+func TestApplyReplica_DimensionMismatch(t *testing.T) {
+	index, err := newMockManager(t, nil).CreateIndex("kb")
+	require.NoError(t, err)
+
+	err = index.ApplyReplica([]ReplicaDocument{{
+		Document: Document{URI: "doc1"},
+		Chunks:   []Chunk{{ID: "c1", Text: "text", Embedding: []float32{1, 2, 3}}},
+	}}, nil)
+	assert.ErrorContains(t, err, "dimension")
+
+	manifest, err := index.Manifest()
+	require.NoError(t, err)
+	assert.Empty(t, manifest)
+}
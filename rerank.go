@@ -0,0 +1,58 @@
+package hnswindex
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/riclib/hnswindex/internal/indexer"
+)
+
+// exactRerank rescores the graph hits by the exact distance of the query
+// embedding to their stored chunk embeddings, reorders them and keeps the
+// best limit. Hits whose chunk is gone keep their graph score; they are
+// dropped during hydration.
+func (i *indexImpl) exactRerank(embedding []float32, hits []indexer.SearchResult, limit int) ([]indexer.SearchResult, error) {
+	ids := make([]uint64, len(hits))
+	for idx, hr := range hits {
+		ids[idx] = hr.ID
+	}
+	chunks, err := i.manager.storage.GetChunksByHNSWIds(i.name, ids)
+	if err != nil {
+		return nil, fmt.Errorf("exact rerank: %w", err)
+	}
+
+	cosine := i.hnswIndex.DistanceType() == "cosine"
+	for idx := range hits {
+		chunk, ok := chunks[hits[idx].ID]
+		if !ok || len(chunk.Embedding) != len(embedding) {
+			continue
+		}
+		var distance float32
+		if cosine {
+			distance = float32(1 - cosineSimilarity(embedding, chunk.Embedding))
+		} else {
+			distance = euclideanDistance(embedding, chunk.Embedding)
+		}
+		hits[idx].Distance = distance
+		hits[idx].Score = i.graphScore(distance)
+	}
+
+	sort.SliceStable(hits, func(a, b int) bool {
+		return hits[a].Score > hits[b].Score
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// euclideanDistance returns the L2 distance of two vectors of equal length
+func euclideanDistance(a, b []float32) float32 {
+	var sum float64
+	for d := range a {
+		diff := float64(a[d]) - float64(b[d])
+		sum += diff * diff
+	}
+	return float32(math.Sqrt(sum))
+}
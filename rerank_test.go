@@ -0,0 +1,46 @@
+package hnswindex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch_ExactRerank(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, reductionDocs(40)...)
+	_, err = index.Reduce(ReductionTruncate, 16)
+	require.NoError(t, err)
+
+	embeddings := make(map[string][]float32)
+	for doc := range index.Documents() {
+		for chunk := range index.Chunks(doc.URI) {
+			embeddings[doc.URI] = chunk.Embedding
+		}
+	}
+	query := "document number 12 about topic 5"
+
+	results, err := index.SearchWithOptions(query, 10, SearchOptions{ExactRerank: 40, EfSearch: 100, Explain: true})
+	require.NoError(t, err)
+	require.Len(t, results, 10)
+	assert.Equal(t, "doc12", results[0].Document.URI)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-5)
+
+	// Scores are the full-dimension cosine similarity to the query, best first
+	for n, result := range results {
+		expected := (1 + cosineSimilarity(embeddings["doc12"], embeddings[result.Document.URI])) / 2
+		assert.InDelta(t, expected, result.Score, 1e-5, result.Document.URI)
+		if n > 0 {
+			assert.LessOrEqual(t, result.Score, results[n-1].Score)
+		}
+	}
+
+	// Without a rerank the scores come from the truncated vectors
+	results, err = index.SearchWithOptions(query, 10, SearchOptions{EfSearch: 100})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "doc12", results[0].Document.URI)
+}
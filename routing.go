@@ -0,0 +1,231 @@
+package hnswindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+const (
+	// RouteCentroids is the number of centroids summarizing an index. Each
+	// stands for one cluster of chunks, so an index covering several topics
+	// matches queries about any of them.
+	RouteCentroids = 8
+
+	centroidsSettingKey = "centroids"
+	centroidSample      = 10000 // Most chunks clustered per index
+	centroidIterations  = 5     // k-means refinement rounds
+)
+
+// IndexRoute ranks an index for a query by RouteQuery
+type IndexRoute struct {
+	Index  string  `json:"index"`
+	Score  float64 `json:"score"` // Best cosine similarity of the query to a centroid
+	Chunks int     `json:"chunks"`
+}
+
+// indexCentroids is the stored centroid summary of an index. Seq and Chunks
+// record the change log sequence and graph size it was computed at.
+type indexCentroids struct {
+	Seq     uint64      `json:"seq"`
+	Chunks  int         `json:"chunks"`
+	Vectors [][]float32 `json:"vectors"`
+}
+
+// RouteQuery ranks every index by how likely it is to hold results for
+// query, most relevant first, by comparing the query embedding with a few
+// centroids of each index's chunk embeddings. It costs one embedding, so
+// federated searches can use it to skip obviously irrelevant indexes.
+// Centroids are computed on first use and after the index changes.
+func (im *IndexManager) RouteQuery(query string) ([]IndexRoute, error) {
+	if impl := im.getImpl(); impl != nil {
+		return impl.RouteQuery(query)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Centroids returns the centroids summarizing the index's chunk
+// embeddings, computing them if the index changed since they were stored.
+// An empty index has none.
+func (i *Index) Centroids() ([][]float32, error) {
+	if impl := i.getImpl(); impl != nil {
+		c, err := impl.centroids()
+		if err != nil {
+			return nil, err
+		}
+		return c.Vectors, nil
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// RouteQuery implementation
+func (im *indexManagerImpl) RouteQuery(query string) ([]IndexRoute, error) {
+	embedding, err := im.embedder.GenerateEmbedding(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	embedding = normalizeEmbedding(embedding)
+
+	var routes []IndexRoute
+	for _, idx := range im.indexes.all() {
+		c, err := idx.centroids()
+		if err != nil {
+			return nil, fmt.Errorf("index '%s': %w", idx.name, err)
+		}
+		route := IndexRoute{Index: idx.name, Chunks: c.Chunks}
+		for n, v := range c.Vectors {
+			if len(v) != len(embedding) {
+				break // Built with another embedding model
+			}
+			if score := dot(embedding, v); n == 0 || score > route.Score {
+				route.Score = score
+			}
+		}
+		routes = append(routes, route)
+	}
+
+	sort.SliceStable(routes, func(a, b int) bool { return routes[a].Score > routes[b].Score })
+	return routes, nil
+}
+
+// centroids returns the stored centroids of the index, recomputing them if
+// the index changed since they were stored
+func (i *indexImpl) centroids() (*indexCentroids, error) {
+	seq, err := i.manager.storage.LatestChangeSeq(i.name)
+	if err != nil {
+		return nil, err
+	}
+	chunks := i.hnswIndex.Size()
+
+	data, err := i.manager.storage.GetIndexSetting(i.name, centroidsSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		var stored indexCentroids
+		if err := json.Unmarshal(data, &stored); err == nil && stored.Seq == seq && stored.Chunks == chunks {
+			return &stored, nil
+		}
+	}
+
+	start := time.Now()
+	vectors, err := i.sampleEmbeddings(chunks)
+	if err != nil {
+		return nil, err
+	}
+	c := &indexCentroids{
+		Seq:     seq,
+		Chunks:  chunks,
+		Vectors: kmeans(vectors, RouteCentroids, centroidIterations),
+	}
+
+	data, err = json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.manager.storage.SetIndexSetting(i.name, centroidsSettingKey, data); err != nil {
+		return nil, fmt.Errorf("failed to store centroids: %w", err)
+	}
+
+	slog.Debug("Computed index centroids",
+		"index", i.name,
+		"chunks", chunks,
+		"sampled", len(vectors),
+		"centroids", len(c.Vectors),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return c, nil
+}
+
+// sampleEmbeddings returns up to centroidSample normalized chunk
+// embeddings, evenly spread over the index
+func (i *indexImpl) sampleEmbeddings(chunks int) ([][]float32, error) {
+	stride := max(1, chunks/centroidSample)
+	dimension := i.embeddingDimension()
+
+	var vectors [][]float32
+	n := 0
+	err := i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		n++
+		if (n-1)%stride != 0 || len(chunk.Embedding) != dimension {
+			return nil
+		}
+		vectors = append(vectors, normalizeEmbedding(chunk.Embedding))
+		return nil
+	})
+	return vectors, err
+}
+
+// kmeans clusters normalized vectors into at most k groups by cosine
+// similarity and returns the normalized cluster centroids. Initial
+// centroids are spread evenly over vectors, so the result is deterministic.
+func kmeans(vectors [][]float32, k, iterations int) [][]float32 {
+	k = min(k, len(vectors))
+	if k == 0 {
+		return nil
+	}
+
+	centroids := make([][]float32, k)
+	for c := range centroids {
+		centroids[c] = append([]float32(nil), vectors[c*len(vectors)/k]...)
+	}
+
+	dimension := len(vectors[0])
+	assign := make([]int, len(vectors))
+	for iter := 0; iter < iterations; iter++ {
+		for n, v := range vectors {
+			assign[n], _ = nearestCentroid(v, centroids)
+		}
+
+		sums := make([][]float64, k)
+		for c := range sums {
+			sums[c] = make([]float64, dimension)
+		}
+		counts := make([]int, k)
+		for n, v := range vectors {
+			c := assign[n]
+			counts[c]++
+			for d, x := range v {
+				sums[c][d] += float64(x)
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				continue // Keep the previous centroid of an empty cluster
+			}
+			mean := make([]float32, dimension)
+			for d := range mean {
+				mean[d] = float32(sums[c][d] / float64(counts[c]))
+			}
+			centroids[c] = normalizeEmbedding(mean)
+		}
+	}
+	return centroids
+}
+
+// nearestCentroid returns the position of the centroid most similar to v
+// and their similarity
+func nearestCentroid(v []float32, centroids [][]float32) (int, float64) {
+	best, bestScore := 0, math.Inf(-1)
+	for c, centroid := range centroids {
+		if score := dot(v, centroid); score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, bestScore
+}
+
+// dot returns the dot product of two vectors of equal length, their
+// cosine similarity when both are normalized
+func dot(a, b []float32) float64 {
+	var sum float64
+	for d := range a {
+		sum += float64(a[d]) * float64(b[d])
+	}
+	return sum
+}
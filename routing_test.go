@@ -0,0 +1,77 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexManager_RouteQuery(t *testing.T) {
+	manager := newMockManager(t, nil)
+
+	ops, err := manager.CreateIndex("ops")
+	require.NoError(t, err)
+	_, err = ops.AddDocumentBatch(context.Background(), []Document{
+		{URI: "failover", Title: "Failover", Content: "database failover procedure"},
+		{URI: "oncall", Title: "Oncall", Content: "oncall escalation policy"},
+	}, nil)
+	require.NoError(t, err)
+
+	hr, err := manager.CreateIndex("hr")
+	require.NoError(t, err)
+	_, err = hr.AddDocumentBatch(context.Background(), []Document{
+		{URI: "onboarding", Title: "Onboarding", Content: "new hire onboarding checklist"},
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = manager.CreateIndex("empty")
+	require.NoError(t, err)
+
+	routes, err := manager.RouteQuery("database failover procedure")
+	require.NoError(t, err)
+	require.Len(t, routes, 3)
+	assert.Equal(t, "ops", routes[0].Index)
+	assert.InDelta(t, 1.0, routes[0].Score, 1e-5)
+	assert.Equal(t, 2, routes[0].Chunks)
+	assert.Equal(t, "empty", routes[2].Index)
+	assert.Equal(t, 0, routes[2].Chunks)
+
+	routes, err = manager.RouteQuery("new hire onboarding checklist")
+	require.NoError(t, err)
+	assert.Equal(t, "hr", routes[0].Index)
+
+	// Centroids are recomputed after the index changes
+	centroids, err := hr.Centroids()
+	require.NoError(t, err)
+	assert.Len(t, centroids, 1)
+	_, err = hr.AddDocumentBatch(context.Background(), []Document{
+		{URI: "leave", Title: "Leave", Content: "parental leave policy"},
+	}, nil)
+	require.NoError(t, err)
+	centroids, err = hr.Centroids()
+	require.NoError(t, err)
+	assert.Len(t, centroids, 2)
+}
+
+func TestKmeans(t *testing.T) {
+	vectors := [][]float32{
+		normalizeEmbedding([]float32{1, 0.1, 0}),
+		normalizeEmbedding([]float32{1, 0, 0.1}),
+		normalizeEmbedding([]float32{0, 1, 0.1}),
+		normalizeEmbedding([]float32{0.1, 1, 0}),
+	}
+	centroids := kmeans(vectors, 2, 5)
+	require.Len(t, centroids, 2)
+	for _, v := range vectors {
+		best := 0.0
+		for _, c := range centroids {
+			best = max(best, dot(v, c))
+		}
+		assert.Greater(t, best, 0.95)
+	}
+
+	assert.Len(t, kmeans(vectors[:1], 8, 5), 1)
+	assert.Nil(t, kmeans(nil, 8, 5))
+}
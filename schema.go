@@ -0,0 +1,233 @@
+package hnswindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetadataType is the expected type of a metadata field
+type MetadataType string
+
+const (
+	MetaAny        MetadataType = ""         // Any value is accepted
+	MetaString     MetadataType = "string"   // A string
+	MetaNumber     MetadataType = "number"   // Any integer or floating point number
+	MetaBool       MetadataType = "bool"     // A boolean
+	MetaTime       MetadataType = "time"     // A time.Time or RFC3339 string
+	MetaStringList MetadataType = "[]string" // A list of strings
+)
+
+// schemaSettingKey is the index setting holding the metadata schema
+const schemaSettingKey = "schema"
+
+// FieldSchema describes the constraints on a single metadata field
+type FieldSchema struct {
+	Type     MetadataType `json:"type,omitempty"`
+	Required bool         `json:"required,omitempty"`
+	Allowed  []string     `json:"allowed,omitempty"` // Permitted values, compared by their string form
+}
+
+// MetadataSchema describes the metadata documents must carry to be indexed
+type MetadataSchema struct {
+	Fields map[string]FieldSchema `json:"fields"`
+	Strict bool                   `json:"strict,omitempty"` // Reject fields not listed in Fields
+}
+
+// SchemaError lists the schema violations of a document's metadata
+type SchemaError struct {
+	Violations []string
+}
+
+func (e *SchemaError) Error() string {
+	return "metadata schema violation: " + strings.Join(e.Violations, "; ")
+}
+
+// Validate checks metadata against the schema.
+// It returns a *SchemaError describing every violation found.
+func (s *MetadataSchema) Validate(metadata map[string]interface{}) error {
+	if s == nil {
+		return nil
+	}
+
+	var violations []string
+
+	// Iterate fields in name order so violations are reported deterministically
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := s.Fields[name]
+		value, ok := metadata[name]
+		if !ok || value == nil {
+			if field.Required {
+				violations = append(violations, fmt.Sprintf("missing required field %q", name))
+			}
+			continue
+		}
+		if violation := field.check(value); violation != "" {
+			violations = append(violations, fmt.Sprintf("field %q %s", name, violation))
+		}
+	}
+
+	if s.Strict {
+		var unknown []string
+		for name := range metadata {
+			if _, ok := s.Fields[name]; !ok {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			violations = append(violations, fmt.Sprintf("unknown field %q", name))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+	return nil
+}
+
+// check validates a single present value, returning a description of the
+// violation or an empty string
+func (f FieldSchema) check(value interface{}) string {
+	var values []interface{}
+
+	switch f.Type {
+	case MetaAny:
+		values = []interface{}{value}
+	case MetaString:
+		if _, ok := value.(string); !ok {
+			return fmt.Sprintf("must be a string, got %T", value)
+		}
+		values = []interface{}{value}
+	case MetaNumber:
+		if _, ok := toFloat(value); !ok {
+			return fmt.Sprintf("must be a number, got %T", value)
+		}
+		values = []interface{}{value}
+	case MetaBool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Sprintf("must be a bool, got %T", value)
+		}
+		values = []interface{}{value}
+	case MetaTime:
+		switch v := value.(type) {
+		case time.Time:
+		case string:
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				return fmt.Sprintf("must be an RFC3339 time, got %q", v)
+			}
+		default:
+			return fmt.Sprintf("must be a time, got %T", value)
+		}
+		values = []interface{}{value}
+	case MetaStringList:
+		switch v := value.(type) {
+		case []string:
+			for _, s := range v {
+				values = append(values, s)
+			}
+		case []interface{}:
+			for _, item := range v {
+				if _, ok := item.(string); !ok {
+					return fmt.Sprintf("must be a list of strings, got element %T", item)
+				}
+			}
+			values = v
+		default:
+			return fmt.Sprintf("must be a list of strings, got %T", value)
+		}
+	default:
+		return fmt.Sprintf("has unknown schema type %q", f.Type)
+	}
+
+	if len(f.Allowed) == 0 {
+		return ""
+	}
+	for _, v := range values {
+		if !f.allows(fmt.Sprint(v)) {
+			return fmt.Sprintf("value %q is not one of %v", fmt.Sprint(v), f.Allowed)
+		}
+	}
+	return ""
+}
+
+// allows reports whether value is in the allowed list
+func (f FieldSchema) allows(value string) bool {
+	for _, allowed := range f.Allowed {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMetadataSchema registers the metadata schema documents must satisfy to
+// be added to the index. The schema is persisted with the index; nil removes it.
+// Documents already in the index are not revalidated.
+func (i *Index) SetMetadataSchema(schema *MetadataSchema) error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.SetMetadataSchema(schema)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// MetadataSchema returns the registered metadata schema, or nil if none is set
+func (i *Index) MetadataSchema() (*MetadataSchema, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.MetadataSchema()
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// SetMetadataSchema implementation
+func (i *indexImpl) SetMetadataSchema(schema *MetadataSchema) error {
+	if schema == nil {
+		return i.manager.storage.SetIndexSetting(i.name, schemaSettingKey, nil)
+	}
+
+	for name, field := range schema.Fields {
+		if err := field.validType(); err != nil {
+			return fmt.Errorf("invalid schema for field %q: %w", name, err)
+		}
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata schema: %w", err)
+	}
+	return i.manager.storage.SetIndexSetting(i.name, schemaSettingKey, data)
+}
+
+// MetadataSchema implementation
+func (i *indexImpl) MetadataSchema() (*MetadataSchema, error) {
+	data, err := i.manager.storage.GetIndexSetting(i.name, schemaSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var schema MetadataSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// validType reports whether the field type is known
+func (f FieldSchema) validType() error {
+	switch f.Type {
+	case MetaAny, MetaString, MetaNumber, MetaBool, MetaTime, MetaStringList:
+		return nil
+	}
+	return fmt.Errorf("unknown type %q", f.Type)
+}
@@ -0,0 +1,95 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataSchema_Validate(t *testing.T) {
+	schema := &MetadataSchema{
+		Fields: map[string]FieldSchema{
+			"source":   {Type: MetaString, Required: true, Allowed: []string{"confluence", "markdown"}},
+			"version":  {Type: MetaNumber},
+			"modified": {Type: MetaTime},
+			"labels":   {Type: MetaStringList},
+		},
+	}
+
+	assert.NoError(t, schema.Validate(map[string]interface{}{
+		"source":   "confluence",
+		"version":  float64(3),
+		"modified": time.Now().Format(time.RFC3339),
+		"labels":   []interface{}{"a", "b"},
+		"extra":    true,
+	}))
+
+	err := schema.Validate(map[string]interface{}{
+		"source":   "jira",
+		"version":  "three",
+		"modified": "yesterday",
+		"labels":   []interface{}{"a", 1},
+	})
+	var schemaErr *SchemaError
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Len(t, schemaErr.Violations, 4)
+
+	err = schema.Validate(nil)
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Equal(t, []string{`missing required field "source"`}, schemaErr.Violations)
+
+	schema.Strict = true
+	err = schema.Validate(map[string]interface{}{"source": "markdown", "extra": true})
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Equal(t, []string{`unknown field "extra"`}, schemaErr.Violations)
+
+	var none *MetadataSchema
+	assert.NoError(t, none.Validate(map[string]interface{}{"anything": 1}))
+}
+
+func TestIndex_MetadataSchema(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("schema")
+	require.NoError(t, err)
+
+	schema, err := index.MetadataSchema()
+	require.NoError(t, err)
+	assert.Nil(t, schema)
+
+	assert.Error(t, index.SetMetadataSchema(&MetadataSchema{
+		Fields: map[string]FieldSchema{"source": {Type: "uuid"}},
+	}))
+
+	require.NoError(t, index.SetMetadataSchema(&MetadataSchema{
+		Fields: map[string]FieldSchema{
+			"source": {Type: MetaString, Required: true},
+		},
+	}))
+
+	schema, err = index.MetadataSchema()
+	require.NoError(t, err)
+	require.NotNil(t, schema)
+	assert.True(t, schema.Fields["source"].Required)
+
+	docs := []Document{
+		{URI: "good", Title: "Good", Content: "valid document", Metadata: map[string]interface{}{"source": "markdown"}},
+		{URI: "bad", Title: "Bad", Content: "missing source"},
+	}
+	result, err := index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.NewDocuments)
+	assert.Contains(t, result.FailedURIs, "bad")
+	assert.NotContains(t, result.FailedURIs, "good")
+
+	_, err = index.GetDocument("bad")
+	assert.Error(t, err)
+
+	// Removing the schema accepts the document
+	require.NoError(t, index.SetMetadataSchema(nil))
+	result, err = index.AddDocumentBatch(context.Background(), docs[1:], nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.FailedURIs)
+}
@@ -0,0 +1,192 @@
+package hnswindex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// sealSettingKey is the index setting holding the seal of a sealed index
+const sealSettingKey = "seal"
+
+// ErrIndexSealed is returned by writes to a sealed index
+var ErrIndexSealed = errors.New("index is sealed")
+
+// SealInfo describes the seal of an index
+type SealInfo struct {
+	Sealed    time.Time `json:"sealed"`
+	Documents int       `json:"documents"`
+	Chunks    int       `json:"chunks"`
+}
+
+// sealedIndex holds the seal of an index with the caches its contents can
+// no longer invalidate
+type sealedIndex struct {
+	info SealInfo
+	hits sync.Map // HNSW ID to *sealedHit, filled by searches
+}
+
+// sealedHit is a search hit resolved to its stored chunk and document
+type sealedHit struct {
+	chunk *storage.Chunk
+	doc   *storage.Document
+}
+
+// Seal makes the index read-only, for example a published knowledge base
+// snapshot: adding, updating and deleting documents, Clear, Reduce,
+// rebuilds and replication fail with ErrIndexSealed until Unseal. The graph
+// is compacted and saved, and searches keep the chunks and documents they
+// resolve in memory instead of reading them from storage again. Settings such as labels, boost rules and suppressions can
+// still be changed. Sealing a sealed index returns its seal.
+func (i *Index) Seal() (*SealInfo, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Seal()
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Unseal makes a sealed index writable again
+func (i *Index) Unseal() error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Unseal()
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// Sealed returns the seal of the index, or nil if it is writable
+func (i *Index) Sealed() *SealInfo {
+	if impl := i.getImpl(); impl != nil {
+		return impl.sealInfo()
+	}
+	return nil
+}
+
+// Seal implementation
+func (i *indexImpl) Seal() (*SealInfo, error) {
+	if err := i.readOnly(); err != nil {
+		return nil, err
+	}
+	release, err := i.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	i.commitMu.Lock()
+	defer i.commitMu.Unlock()
+
+	if info := i.sealInfo(); info != nil {
+		return info, nil
+	}
+
+	start := time.Now()
+	if i.hnswIndex.Tombstones() > 0 {
+		if _, err := i.compact(); err != nil {
+			return nil, err
+		}
+	}
+	if err := i.saveGraph(); err != nil {
+		return nil, err
+	}
+
+	s := &sealedIndex{}
+	uris, err := i.manager.storage.ListDocuments(i.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	s.info = SealInfo{
+		Sealed:    time.Now().UTC(),
+		Documents: len(uris),
+		Chunks:    i.hnswIndex.Size(),
+	}
+
+	data, err := json.Marshal(s.info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode seal: %w", err)
+	}
+	if err := i.manager.storage.SetIndexSetting(i.name, sealSettingKey, data); err != nil {
+		return nil, err
+	}
+	i.sealed.Store(s)
+
+	slog.Info("Index sealed",
+		"index", i.name,
+		"documents", s.info.Documents,
+		"chunks", s.info.Chunks,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return i.sealInfo(), nil
+}
+
+// Unseal implementation
+func (i *indexImpl) Unseal() error {
+	if err := i.readOnly(); err != nil {
+		return err
+	}
+	i.commitMu.Lock()
+	defer i.commitMu.Unlock()
+
+	if i.sealed.Load() == nil {
+		return nil
+	}
+	if err := i.manager.storage.SetIndexSetting(i.name, sealSettingKey, nil); err != nil {
+		return err
+	}
+	i.sealed.Store(nil)
+	slog.Info("Index unsealed", "index", i.name)
+	return nil
+}
+
+// sealInfo returns a copy of the seal, or nil if the index is writable
+func (i *indexImpl) sealInfo() *SealInfo {
+	if s := i.sealed.Load(); s != nil {
+		info := s.info
+		return &info
+	}
+	return nil
+}
+
+// loadSeal restores the seal of an index opened from storage. The search
+// hit cache starts empty and fills as searches resolve hits.
+func (i *indexImpl) loadSeal() error {
+	data, err := i.manager.storage.GetIndexSetting(i.name, sealSettingKey)
+	if err != nil || data == nil {
+		return err
+	}
+	s := &sealedIndex{}
+	if err := json.Unmarshal(data, &s.info); err != nil {
+		return fmt.Errorf("failed to decode seal: %w", err)
+	}
+	i.sealed.Store(s)
+	return nil
+}
+
+// writable returns ErrIndexSealed if the index is sealed, and ErrReadOnly
+// if its manager was opened with OpenSnapshot. Writes check it before any
+// work and again under commitMu, which Seal holds.
+func (i *indexImpl) writable() error {
+	if err := i.readOnly(); err != nil {
+		return err
+	}
+	if i.sealed.Load() != nil {
+		return fmt.Errorf("%w: %s", ErrIndexSealed, i.name)
+	}
+	return nil
+}
+
+// findHit resolves a hit of a sealed index through its cache
+func (s *sealedIndex) findHit(hnswID uint64, load func(uint64) (*storage.Chunk, *storage.Document)) (*storage.Chunk, *storage.Document) {
+	if hit, ok := s.hits.Load(hnswID); ok {
+		h := hit.(*sealedHit)
+		return h.chunk, h.doc
+	}
+	chunk, doc := load(hnswID)
+	if chunk != nil && doc != nil {
+		s.hits.Store(hnswID, &sealedHit{chunk: chunk, doc: doc})
+	}
+	return chunk, doc
+}
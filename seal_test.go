@@ -0,0 +1,101 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_Seal(t *testing.T) {
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "doc1", Title: "One", Content: "First document content"},
+		Document{URI: "doc2", Title: "Two", Content: "Second document content"},
+		Document{URI: "doc3", Title: "Three", Content: "Third document content"},
+	)
+	require.NoError(t, index.DeleteDocument("doc3"))
+	assert.Nil(t, index.Sealed())
+
+	seal, err := index.Seal()
+	require.NoError(t, err)
+	assert.Equal(t, 2, seal.Documents)
+	assert.Equal(t, 2, seal.Chunks)
+	assert.Equal(t, seal, index.Sealed())
+
+	idx, ok := manager.getImpl().indexes.get("kb")
+	require.True(t, ok)
+	assert.Zero(t, idx.hnswIndex.Tombstones(), "sealing compacts the graph")
+
+	again, err := index.Seal()
+	require.NoError(t, err)
+	assert.Equal(t, seal.Sealed, again.Sealed)
+
+	// Every write fails with ErrIndexSealed
+	_, err = index.AddDocumentBatch(context.Background(), []Document{{URI: "doc4", Content: "Fourth"}}, nil)
+	assert.ErrorIs(t, err, ErrIndexSealed)
+	assert.ErrorIs(t, index.DeleteDocument("doc1"), ErrIndexSealed)
+	assert.ErrorIs(t, index.Clear(), ErrIndexSealed)
+	_, err = index.Reduce(ReductionTruncate, 256)
+	assert.ErrorIs(t, err, ErrIndexSealed)
+	_, err = index.BeginRebuild()
+	assert.ErrorIs(t, err, ErrIndexSealed)
+	_, err = manager.Batch(func(tx *ManagerTx) error {
+		return tx.Add("kb", Document{URI: "doc4", Content: "Fourth"})
+	})
+	assert.ErrorIs(t, err, ErrIndexSealed)
+	_, err = index.PruneExpired(nil)
+	require.NoError(t, err, "nothing expired, nothing written")
+	_, err = index.Compact()
+	assert.ErrorIs(t, err, ErrIndexSealed)
+
+	// Verify reports a broken sealed index but does not repair it
+	require.NoError(t, idx.hnswIndex.Add(make768(0.5), 9999))
+	report, err := index.Verify(true)
+	assert.ErrorIs(t, err, ErrIndexSealed)
+	require.NotNil(t, report)
+	assert.Equal(t, 1, report.OrphanVectors)
+	assert.False(t, report.Repaired)
+	require.NoError(t, idx.hnswIndex.Delete(9999))
+
+	// Reads work, twice from the cache
+	for range 2 {
+		results, err := index.Search("First document content", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"doc1"}, resultURIs(results))
+	}
+	stats, err := index.Stats()
+	require.NoError(t, err)
+	require.NotNil(t, stats.Sealed)
+	dist, err := index.Distribution()
+	require.NoError(t, err)
+	require.NotNil(t, dist)
+	assert.Equal(t, 2, stats.DocumentCount)
+
+	// Settings can still change
+	require.NoError(t, index.SetDescription("Published snapshot"))
+
+	// The seal survives a restart
+	require.NoError(t, manager.Close())
+	require.NoError(t, manager.getImpl().storage.Close()) // Release the database lock
+	reopened := newMockManager(t, cfg)
+	index, err = reopened.GetIndex("kb")
+	require.NoError(t, err)
+	require.NotNil(t, index.Sealed())
+	assert.ErrorIs(t, index.DeleteDocument("doc1"), ErrIndexSealed)
+	dist, err = index.Distribution()
+	require.NoError(t, err)
+	assert.NotNil(t, dist)
+
+	require.NoError(t, index.Unseal())
+	assert.Nil(t, index.Sealed())
+	require.NoError(t, index.DeleteDocument("doc1"))
+	results, err := index.Search("First document content", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doc2"}, resultURIs(results))
+}
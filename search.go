@@ -0,0 +1,482 @@
+package hnswindex
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/indexer"
+)
+
+// SearchOptions configures search behavior
+type SearchOptions struct {
+	Explain bool             // Attach scoring details and timings to each result
+	Filters []MetadataFilter // Only return documents whose metadata matches
+
+	// GroupBy returns the top PerGroup results for each value of a document
+	// metadata field, for up to limit groups, instead of a flat top list
+	GroupBy  string
+	PerGroup int // Results per group (default DefaultPerGroup)
+
+	// GroupByDocument returns one result per document, for up to limit
+	// documents, instead of one per chunk, so a document matching with
+	// several chunks does not crowd others out. Each result carries the
+	// document's best chunk and score, and lists every chunk of the
+	// document among the candidates in Matches, best first. Ignored with
+	// GroupBy.
+	GroupByDocument bool
+
+	// NegativeQuery steers results away from text, such as "v1 API": each
+	// result loses NegativeWeight times its similarity to it
+	NegativeQuery  string
+	NegativeWeight float64 // Penalty factor (default DefaultNegativeWeight)
+
+	// TitleBoost raises each result by up to TitleBoost times the share of
+	// query keywords found in its chunk title (or document title), and
+	// reorders the results accordingly. SearchMulti ignores it.
+	TitleBoost float64
+
+	// Model embeds the query, and any negative query, with another model
+	// than the one that built the index, such as the query encoder of an
+	// asymmetric pair; its vectors must have the index dimension. See
+	// IndexManager.RegisterQueryEmbedder. Default: Config.EmbedModel.
+	Model string
+
+	// LateInteraction rescores the graph's candidates by MaxSim over the
+	// sub-chunk vectors stored with Config.LateInteraction, for higher
+	// precision than one vector per chunk. Chunks without sub-chunk
+	// vectors are scored by their chunk embedding alone.
+	LateInteraction bool
+
+	// SparseWeight blends in the lexical leg of hybrid search: the best
+	// matches of the sparse embedder set with
+	// IndexManager.SetSparseEmbedder join the graph's candidates, and each
+	// result scores (1-SparseWeight) times its dense score plus
+	// SparseWeight times its sparse score relative to the best sparse
+	// match. 0 searches the graph alone; at most 1.
+	SparseWeight float64
+
+	// ChunkPositions only returns chunks at positions in the range, such
+	// as ChunkRange{To: 3} for the first three chunks of each document,
+	// where summaries and abstracts usually are, to bias retrieval toward
+	// overview content. The zero range returns chunks at any position.
+	ChunkPositions ChunkRange
+
+	// IgnoreBoostRules searches without the boost rules set with
+	// Index.SetBoostRules, such as to compare results with and without them
+	IgnoreBoostRules bool
+
+	// IncludeSuppressed returns documents suppressed with Index.Suppress,
+	// such as to review them
+	IncludeSuppressed bool
+
+	// Snippets sets SearchResult.Snippet to the window of up to
+	// SnippetLength characters (default DefaultSnippetLength) of the chunk
+	// text holding the most query keywords, for result lists that cannot
+	// show whole chunks, and marks the keywords with highlight spans
+	Snippets      bool
+	SnippetLength int
+
+	// EfSearch is how many candidates the graph keeps while searching, in
+	// place of the index's HNSWConfig.Ef: higher finds more of the true
+	// nearest chunks at the cost of latency. 0 uses the index's Ef.
+	EfSearch int
+
+	// ExactRerank fetches ExactRerank graph hits, or as many as the search
+	// needs if more, rescores them by the exact similarity of the query
+	// embedding to their stored chunk embeddings, at full dimension with a
+	// reduction, and keeps the best, correcting the graph's approximate
+	// order. 0 keeps the graph's scores.
+	ExactRerank int
+}
+
+// ChunkRange is a range of chunk positions in a document, the first chunk
+// being at 0
+type ChunkRange struct {
+	From int // First position in the range
+	To   int // Position after the last in the range; 0 for no end
+}
+
+// active reports whether the range excludes any position
+func (r ChunkRange) active() bool {
+	return r.From > 0 || r.To > 0
+}
+
+// contains reports whether position is in the range
+func (r ChunkRange) contains(position int) bool {
+	return position >= r.From && (r.To <= 0 || position < r.To)
+}
+
+// String formats the range like a slice expression, such as "0:3" or "2:"
+func (r ChunkRange) String() string {
+	if r.To <= 0 {
+		return fmt.Sprintf("%d:", r.From)
+	}
+	return fmt.Sprintf("%d:%d", r.From, r.To)
+}
+
+// searchOversample is how many graph hits are fetched per requested result
+// when filters or grouping may drop hits
+const searchOversample = 10
+
+// graphLimit returns how many graph hits to fetch for limit results
+func (o SearchOptions) graphLimit(limit int) int {
+	if o.GroupBy != "" {
+		return limit * o.perGroup() * searchOversample
+	}
+	if len(o.Filters) > 0 || o.GroupByDocument || o.NegativeQuery != "" || o.TitleBoost > 0 || o.LateInteraction || o.SparseWeight > 0 || o.ChunkPositions.active() {
+		return limit * searchOversample
+	}
+	return limit
+}
+
+// SearchExplain describes how a single search result was produced
+type SearchExplain struct {
+	Rank            int               `json:"rank"`             // Position in the graph result list (1-based)
+	HNSWId          uint64            `json:"hnsw_id"`          // Graph node that matched
+	Distance        float64           `json:"distance"`         // Raw distance reported by the graph
+	Similarity      float64           `json:"similarity"`       // Score before normalization (1 - distance for cosine)
+	NormalizedScore float64           `json:"normalized_score"` // Score after normalization into 0-1
+	Score           float64           `json:"score"`            // Final score after all adjustments
+	Filters         []FilterDecision  `json:"filters,omitempty"`
+	Adjustments     []ScoreAdjustment `json:"adjustments,omitempty"`
+	Timing          SearchTiming      `json:"timing"`
+}
+
+// FilterDecision records whether a result passed a search filter
+type FilterDecision struct {
+	Filter string `json:"filter"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ScoreAdjustment records a change applied to a result score after retrieval
+type ScoreAdjustment struct {
+	Stage  string  `json:"stage"`
+	Delta  float64 `json:"delta"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// SearchTiming breaks down where time was spent during a search
+type SearchTiming struct {
+	Embed   time.Duration `json:"embed"`   // Query embedding generation
+	Graph   time.Duration `json:"graph"`   // HNSW graph traversal
+	Hydrate time.Duration `json:"hydrate"` // Loading chunks and documents
+	Total   time.Duration `json:"total"`
+}
+
+// embedQuery generates the embedding of a query for searching the graph,
+// with the query encoder of model ("" for the index model)
+func (i *indexImpl) embedQuery(query, model string) ([]float32, error) {
+	e, err := i.manager.queryEmbedder(model)
+	if err != nil {
+		return nil, err
+	}
+	embedding, err := e.GenerateEmbedding(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	if model == "" && len(embedding) > 0 {
+		if err := i.checkDimension(len(embedding)); err != nil {
+			return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+		}
+	}
+	if err := validateEmbedding(embedding, i.embeddingDimension()); err != nil {
+		if model != "" {
+			return nil, fmt.Errorf("query model %q does not fit index '%s': %w", model, i.name, err)
+		}
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	if i.manager.config.NormalizeEmbeddings {
+		embedding = normalizeEmbedding(embedding)
+	}
+	return embedding, nil
+}
+
+// hitScores are changes to the scores of graph hits, by graph ID, that are
+// applied when the hits are hydrated
+type hitScores struct {
+	late      map[uint64]float64 // Late interaction rescoring
+	sparse    map[uint64]float64 // Blending with sparse scores
+	penalties map[uint64]float64 // Negative query penalties
+
+	boosts map[string]*documentBoost // Boost rules matching the query, by document URI
+
+	suppressions []Suppression // Documents left out of the results
+}
+
+// score returns the score of a hit after its changes
+func (s *hitScores) score(hr indexer.SearchResult) float64 {
+	return float64(hr.Score) + s.late[hr.ID] + s.sparse[hr.ID] - s.penalties[hr.ID]
+}
+
+// reorders reports whether the hits must all be hydrated and reordered by
+// their boosted score
+func (s *hitScores) reorders(options SearchOptions) bool {
+	return options.TitleBoost > 0 || len(s.boosts) > 0
+}
+
+// searchHits embeds the query and returns the raw graph hits, rescored
+// exactly with ExactRerank and joined by the sparse index's with a sparse
+// weight. With late interaction, a sparse weight or a negative query, hits
+// are reordered by their changed score and the changes are returned.
+// Pinned documents the graph missed join the hits. While documents are
+// suppressed, twice as many hits are fetched.
+func (i *indexImpl) searchHits(query string, limit int, options SearchOptions, timing *SearchTiming) ([]indexer.SearchResult, *hitScores, error) {
+	start := time.Now()
+	scores := &hitScores{}
+	if !options.IncludeSuppressed {
+		suppressions, err := i.Suppressions()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load suppressions: %w", err)
+		}
+		if len(suppressions) > 0 {
+			scores.suppressions = suppressions
+			limit *= 2
+		}
+	}
+
+	// Generate query embedding
+	embedding, err := i.embedQuery(query, options.Model)
+	if err != nil {
+		return nil, nil, err
+	}
+	timing.Embed = time.Since(start)
+
+	// Search in HNSW index
+	graphStart := time.Now()
+	if err := i.manager.injectFault(FaultGraphSearch, i.name); err != nil {
+		return nil, nil, fmt.Errorf("failed to search HNSW index: %w", err)
+	}
+	hnswResults, err := i.hnswIndex.SearchEf(i.graphVector(embedding), max(limit, options.ExactRerank), options.EfSearch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search HNSW index: %w", err)
+	}
+	if options.ExactRerank > 0 {
+		if hnswResults, err = i.exactRerank(embedding, hnswResults, limit); err != nil {
+			return nil, nil, err
+		}
+	}
+	timing.Graph = time.Since(graphStart)
+
+	var sparse map[uint64]float64
+	if options.SparseWeight > 0 {
+		if hnswResults, sparse, err = i.sparseCandidates(query, embedding, hnswResults, limit, timing); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if options.LateInteraction {
+		if scores.late, err = i.lateInteraction(query, embedding, hnswResults, options, timing); err != nil {
+			return nil, nil, err
+		}
+	}
+	if options.SparseWeight > 0 {
+		blendSparse(hnswResults, sparse, options.sparseWeight(), scores)
+	}
+	if options.NegativeQuery != "" {
+		if err := i.steerAway(hnswResults, options, scores, timing); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !options.IgnoreBoostRules {
+		if scores.boosts, err = i.matchBoostRules(query); err != nil {
+			return nil, nil, err
+		}
+		if hnswResults, err = i.pinnedCandidates(embedding, hnswResults, scores.boosts, timing); err != nil {
+			return nil, nil, err
+		}
+	}
+	return hnswResults, scores, nil
+}
+
+// hydrateHit loads the chunk and document of a graph hit.
+// It returns false if the hit no longer refers to a stored chunk, the
+// chunk is outside the chunk positions searched, or its document is
+// suppressed or does not match the search filters.
+func (i *indexImpl) hydrateHit(hr indexer.SearchResult, rank int, options SearchOptions, scores *hitScores) (SearchResult, bool) {
+	// Find chunk by HNSW ID
+	chunk, doc := i.findChunkAndDocument(hr.ID)
+	if chunk == nil || doc == nil {
+		slog.Debug("Skipping search hit without stored chunk",
+			"index", i.name,
+			"hnsw_id", hr.ID,
+		)
+		return SearchResult{}, false
+	}
+
+	if !options.ChunkPositions.contains(chunk.Position) {
+		return SearchResult{}, false
+	}
+	if suppressed(scores.suppressions, doc.URI, doc.Metadata) {
+		return SearchResult{}, false
+	}
+	decisions, passed := matchFilters(doc.Metadata, options.Filters)
+	if !passed {
+		return SearchResult{}, false
+	}
+	if options.ChunkPositions.active() {
+		decisions = append(decisions, FilterDecision{
+			Filter: "chunk_positions:" + options.ChunkPositions.String(),
+			Passed: true,
+		})
+	}
+
+	result := SearchResult{
+		Document:   fromStorageDocument(doc),
+		Score:      float64(hr.Score),
+		ChunkID:    chunk.ID,
+		ChunkText:  chunk.Text,
+		ChunkTitle: chunkTitle(chunk.Metadata),
+		IndexName:  i.name,
+		TimeRange:  chunkTimeRange(chunk.Metadata),
+	}
+
+	if options.Explain {
+		result.Explain = &SearchExplain{
+			Rank:            rank + 1,
+			HNSWId:          hr.ID,
+			Distance:        float64(hr.Distance),
+			Similarity:      i.similarity(hr.Distance),
+			NormalizedScore: float64(hr.Score),
+			Score:           float64(hr.Score),
+		}
+		if len(decisions) > 0 {
+			result.Explain.Filters = decisions
+		}
+	}
+	if delta, ok := scores.late[hr.ID]; ok {
+		applyLateInteraction(&result, delta)
+	}
+	if delta, ok := scores.sparse[hr.ID]; ok {
+		applySparse(&result, delta)
+	}
+	if penalty, ok := scores.penalties[hr.ID]; ok {
+		applyNegativePenalty(&result, penalty, options.NegativeQuery)
+	}
+	if boost, ok := scores.boosts[doc.URI]; ok {
+		applyBoostRules(&result, boost)
+	}
+	return result, true
+}
+
+// hydrateBoosted hydrates every graph hit, applies the title boost and
+// orders the results by their boosted score, pinned results first
+func (i *indexImpl) hydrateBoosted(query string, hits []indexer.SearchResult, options SearchOptions, scores *hitScores) []SearchResult {
+	results := make([]SearchResult, 0, len(hits))
+	for rank, hr := range hits {
+		if result, ok := i.hydrateHit(hr, rank, options, scores); ok {
+			if options.TitleBoost > 0 {
+				applyTitleBoost(&result, query, options.TitleBoost)
+			}
+			results = append(results, result)
+		}
+	}
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].Score > results[b].Score
+	})
+	pinResults(results, scores.boosts)
+	return results
+}
+
+// hydrateResults hydrates graph hits until the limit of results is
+// reached, and returns them
+func (i *indexImpl) hydrateResults(query string, hits []indexer.SearchResult, limit int, options SearchOptions, scores *hitScores) []SearchResult {
+	results := make([]SearchResult, 0, min(limit, len(hits)))
+	limiter := newResultLimiter(limit, options)
+	if scores.reorders(options) {
+		for _, result := range i.hydrateBoosted(query, hits, options, scores) {
+			if limiter.done() {
+				break
+			}
+			if limiter.accept(&result) {
+				results = append(results, result)
+			}
+		}
+	} else {
+		for rank, hr := range hits {
+			if limiter.done() {
+				break
+			}
+			if result, ok := i.hydrateHit(hr, rank, options, scores); ok && limiter.accept(&result) {
+				results = append(results, result)
+			}
+		}
+	}
+	limiter.addMatches(results)
+	return results
+}
+
+// SearchWithOptions implementation
+func (i *indexImpl) SearchWithOptions(query string, limit int, options SearchOptions) ([]SearchResult, error) {
+	results, timing, err := i.search(query, limit, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.manager.config.QueryLog {
+		queryID := i.logQuery(query, timing.Total, results)
+		for idx := range results {
+			results[idx].QueryID = queryID
+		}
+	}
+
+	i.manager.emitSearch(SearchEvent{
+		Index:    i.name,
+		Query:    query,
+		Limit:    limit,
+		Results:  len(results),
+		Duration: timing.Total,
+	})
+
+	slog.Debug("Search completed",
+		"index", i.name,
+		"results", len(results),
+		"embed_ms", timing.Embed.Milliseconds(),
+		"graph_ms", timing.Graph.Milliseconds(),
+		"hydrate_ms", timing.Hydrate.Milliseconds(),
+	)
+
+	return results, nil
+}
+
+// WarmUp implementation
+func (i *indexImpl) WarmUp(query string, limit int) error {
+	_, _, err := i.search(query, limit, SearchOptions{})
+	return err
+}
+
+// search runs a search without recording it in the query log or emitting
+// a search event
+func (i *indexImpl) search(query string, limit int, options SearchOptions) ([]SearchResult, SearchTiming, error) {
+	start := time.Now()
+	var timing SearchTiming
+
+	hnswResults, scores, err := i.searchHits(query, options.graphLimit(limit), options, &timing)
+	if err != nil {
+		return nil, timing, err
+	}
+
+	// Convert results
+	hydrateStart := time.Now()
+	results := i.hydrateResults(query, hnswResults, limit, options, scores)
+	addSnippets(results, query, options)
+	timing.Hydrate = time.Since(hydrateStart)
+	timing.Total = time.Since(start)
+
+	if options.Explain {
+		for idx := range results {
+			results[idx].Explain.Timing = timing
+		}
+	}
+	return results, timing, nil
+}
+
+// similarity converts a raw graph distance into an unnormalized similarity
+func (i *indexImpl) similarity(distance float32) float64 {
+	if i.hnswIndex.DistanceType() == "cosine" {
+		return 1.0 - float64(distance)
+	}
+	return 1.0 / (1.0 + float64(distance))
+}
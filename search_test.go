@@ -0,0 +1,78 @@
+package hnswindex
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchWithOptions_Explain(t *testing.T) {
+	manager := newMockManager(t, nil)
+
+	index, err := manager.CreateIndex("explain")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "doc1", Title: "Go", Content: "Go is a statically typed language"},
+		{URI: "doc2", Title: "Python", Content: "Python is dynamically typed"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	plain, err := index.Search("Go is a statically typed language", 2)
+	require.NoError(t, err)
+	require.NotEmpty(t, plain)
+	assert.Nil(t, plain[0].Explain)
+
+	results, err := index.SearchWithOptions("Go is a statically typed language", 2, SearchOptions{Explain: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+
+	e := results[0].Explain
+	require.NotNil(t, e)
+	assert.Equal(t, 1, e.Rank)
+	assert.Equal(t, results[0].Score, e.Score)
+	assert.InDelta(t, 1.0-e.Distance, e.Similarity, 1e-9)
+	assert.InDelta(t, 1.0-e.Distance/2, e.NormalizedScore, 1e-6)
+	assert.GreaterOrEqual(t, e.Timing.Total, e.Timing.Embed)
+}
+
+func TestSearchWithOptions_ChunkPositions(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 0
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("positions")
+	require.NoError(t, err)
+
+	var content string
+	for _, subject := range []string{"Logs", "Backups", "Audit trails", "Tickets"} {
+		content += strings.Repeat(subject+" are kept for a number of days set by the team. ", 5) + "\n\n"
+	}
+	_, err = index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "policy", Title: "Retention", Content: content},
+	}, nil)
+	require.NoError(t, err)
+
+	positions := make(map[string]int)
+	for chunk := range index.Chunks("policy") {
+		positions[chunk.ID] = chunk.Position
+	}
+	require.Greater(t, len(positions), 2)
+
+	for _, r := range []ChunkRange{{To: 1}, {From: 1, To: 2}, {From: 2}} {
+		results, err := index.SearchWithOptions("Audit trails are kept", 10, SearchOptions{
+			ChunkPositions: r,
+			Explain:        true,
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, results, r.String())
+		for _, result := range results {
+			assert.True(t, r.contains(positions[result.ChunkID]), r.String())
+			assert.Contains(t, result.Explain.Filters, FilterDecision{Filter: "chunk_positions:" + r.String(), Passed: true})
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package hnswindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSectionNotFound is returned by UpdateDocumentSections when the text a
+// change replaces does not occur exactly once in the document
+var ErrSectionNotFound = errors.New("section not found")
+
+// SectionChange is an edit reported by a source, such as a paragraph of a
+// Confluence version diff or a git hunk
+type SectionChange struct {
+	Old string // Text to replace; must occur exactly once. Empty appends New.
+	New string // Replacement text; empty deletes Old
+}
+
+// UpdateDocumentSections applies changes to the stored content of a
+// document, in order, and re-indexes it. Chunk boundaries away from the
+// changes stay where they were and those chunks keep their embeddings, so
+// only the chunks around changed and inserted text are embedded.
+// Nothing is written if a change does not apply. Documents built from
+// transcript segments lose their time ranges and should be re-added whole.
+func (i *Index) UpdateDocumentSections(uri string, changes []SectionChange) (*BatchResult, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.UpdateDocumentSections(uri, changes)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// UpdateDocumentSections implementation
+func (i *indexImpl) UpdateDocumentSections(uri string, changes []SectionChange) (*BatchResult, error) {
+	doc, err := i.GetDocument(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := applySectionChanges(doc.Content, changes)
+	if err != nil {
+		return nil, fmt.Errorf("document '%s': %w", uri, err)
+	}
+	doc.Content = content
+
+	result, err := i.AddDocumentBatch(context.Background(), []Document{*doc}, nil)
+	if err != nil {
+		return result, err
+	}
+	if msg, failed := result.FailedURIs[uri]; failed {
+		return result, fmt.Errorf("document '%s': %s", uri, msg)
+	}
+	return result, nil
+}
+
+// applySectionChanges applies changes to content in order
+func applySectionChanges(content string, changes []SectionChange) (string, error) {
+	for n, change := range changes {
+		if change.Old == "" {
+			content += change.New
+			continue
+		}
+		if count := strings.Count(content, change.Old); count != 1 {
+			return "", fmt.Errorf("%w: change %d matches %d times", ErrSectionNotFound, n+1, count)
+		}
+		content = strings.Replace(content, change.Old, change.New, 1)
+	}
+	return content, nil
+}
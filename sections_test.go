@@ -0,0 +1,99 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySectionChanges(t *testing.T) {
+	content, err := applySectionChanges("alpha beta gamma", []SectionChange{
+		{Old: "beta", New: "BETA"},
+		{New: " delta"},
+		{Old: "alpha ", New: ""},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "BETA gamma delta", content)
+
+	_, err = applySectionChanges("a a", []SectionChange{{Old: "a", New: "b"}})
+	assert.ErrorIs(t, err, ErrSectionNotFound)
+	_, err = applySectionChanges("a", []SectionChange{{Old: "missing", New: "b"}})
+	assert.ErrorIs(t, err, ErrSectionNotFound)
+}
+
+func TestIndex_UpdateDocumentSections(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 0
+	manager := newMockManager(t, cfg)
+	counter := &countingEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.getImpl().embedder = counter
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	content := strings.Repeat("The deployment pipeline builds, tests, and ships every service. ", 20) +
+		"Rollbacks are manual."
+	_, err = index.AddDocumentBatch(context.Background(), []Document{{URI: "doc1", Title: "Deploys", Content: content}}, nil)
+	require.NoError(t, err)
+	embedded := counter.texts
+
+	result, err := index.UpdateDocumentSections("doc1", []SectionChange{
+		{Old: "Rollbacks are manual.", New: "Rollbacks are automatic."},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.UpdatedDocuments)
+	chunks, err := index.getImpl().manager.storage.GetChunksByDocument("kb", "doc1")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, counter.texts-embedded, 2, "only the chunks at the end are embedded")
+	assert.Greater(t, len(chunks), 4)
+
+	doc, err := index.GetDocument("doc1")
+	require.NoError(t, err)
+	assert.Equal(t, "Deploys", doc.Title)
+	assert.True(t, strings.HasSuffix(doc.Content, "Rollbacks are automatic."))
+
+	_, err = index.UpdateDocumentSections("doc1", []SectionChange{{Old: "Rollbacks are manual.", New: "x"}})
+	assert.ErrorIs(t, err, ErrSectionNotFound)
+	_, err = index.UpdateDocumentSections("missing", nil)
+	assert.Error(t, err)
+}
+
+func TestIndex_UpdateDocumentSections_Middle(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 100
+	cfg.ChunkOverlap = 20
+	cfg.ContentDefinedChunks = true
+	manager := newMockManager(t, cfg)
+	counter := &countingEmbedder{MockEmbedder: NewMockEmbedder(768)}
+	manager.getImpl().embedder = counter
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	sections := make([]string, 40)
+	for i := range sections {
+		sections[i] = fmt.Sprintf("## Step %d\n\nCheck the %d open alerts and restart service %d. "+
+			"Escalate after %d minutes.", i, i*3%11, i*7%13, i%5+1)
+	}
+	_, err = index.AddDocumentBatch(context.Background(), []Document{{URI: "doc1", Content: strings.Join(sections, "\n\n")}}, nil)
+	require.NoError(t, err)
+	embedded := counter.texts
+
+	// The chunks after the changed section keep their boundaries and
+	// embeddings
+	_, err = index.UpdateDocumentSections("doc1", []SectionChange{
+		{Old: "## Step 20\n\nCheck the 5 open alerts", New: "## Step 20\n\nPage the on-call engineer, then check the 5 open alerts"},
+	})
+	require.NoError(t, err)
+	chunks, err := index.getImpl().manager.storage.GetChunksByDocument("kb", "doc1")
+	require.NoError(t, err)
+	require.Greater(t, len(chunks), 10)
+	assert.LessOrEqual(t, counter.texts-embedded, 4, "only the chunks around the section are embedded")
+
+	doc, err := index.GetDocument("doc1")
+	require.NoError(t, err)
+	assert.Contains(t, doc.Content, "Page the on-call engineer")
+}
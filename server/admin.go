@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/riclib/hnswindex"
+)
+
+// registerAdmin adds the maintenance endpoints
+func (s *Server) registerAdmin() {
+	s.handle("POST /admin/save", s.handleSave)
+	s.handle("GET /admin/indexes/{name}/suppressions", s.handleListSuppressions)
+	s.handle("POST /admin/indexes/{name}/suppressions", s.handleSuppress)
+	s.handle("DELETE /admin/indexes/{name}/suppressions", s.handleUnsuppress)
+	s.handle("POST /admin/indexes/{name}/seal", s.handleSeal)
+	s.handle("DELETE /admin/indexes/{name}/seal", s.handleUnseal)
+}
+
+// handleSave flushes all unsaved graphs and syncs the databases, for
+// example before a planned shutdown
+func (s *Server) handleSave(w http.ResponseWriter, r *http.Request) {
+	if err := s.manager.SaveAll(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"saved": true})
+}
+
+// suppressRequest suppresses a document by URI, or the documents matching
+// filters written in query syntax, such as "status:archived"
+type suppressRequest struct {
+	URI    string `json:"uri"`
+	Filter string `json:"filter"`
+	Reason string `json:"reason"`
+}
+
+func (s *Server) handleListSuppressions(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	suppressions, err := index.Suppressions()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if suppressions == nil {
+		suppressions = []hnswindex.Suppression{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"suppressions": suppressions})
+}
+
+func (s *Server) handleSuppress(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	var req suppressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	suppression := hnswindex.Suppression{URI: req.URI, Reason: req.Reason}
+	if req.Filter != "" {
+		parsed, err := hnswindex.ParseQuery(req.Filter)
+		if err == nil && parsed.Text != "" {
+			err = errors.New("expected only filters, such as status:archived")
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid filter: %w", err))
+			return
+		}
+		suppression.Filters = parsed.Filters
+	}
+
+	suppression, err = index.Suppress(suppression)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, suppression)
+}
+
+// handleSeal makes an index read-only (see Index.Seal)
+func (s *Server) handleSeal(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	seal, err := index.Seal()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, seal)
+}
+
+func (s *Server) handleUnseal(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if err := index.Unseal(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"unsealed": index.Name()})
+}
+
+func (s *Server) handleUnsuppress(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing query parameter 'id'"))
+		return
+	}
+	if err := index.Unsuppress(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, hnswindex.ErrSuppressionNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"removed": id})
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// APIKey is a static credential accepted by the server
+type APIKey struct {
+	Key      string   // Sent as "Authorization: Bearer <key>" or "X-API-Key: <key>"
+	Name     string   // Identifies the client in logs
+	Indexes  []string // Indexes the key may access; empty allows all
+	ReadOnly bool     `mapstructure:"read_only"` // Only search and read; writes and admin endpoints get 403
+}
+
+// Principal is an authenticated client
+type Principal struct {
+	Name string
+
+	// Indexes the client may access; empty allows all. Clients limited to
+	// some indexes cannot use endpoints spanning every index, such as
+	// diagnostics or delete indexes.
+	Indexes []string
+
+	// ReadOnly clients may only search and read: endpoints that change
+	// indexes or the server get 403
+	ReadOnly bool
+}
+
+// TokenValidator validates a bearer token that is not one of the static
+// API keys, e.g. a JWT or a token checked against an identity provider.
+// It returns an error if the token is invalid.
+type TokenValidator func(ctx context.Context, token string) (*Principal, error)
+
+// errUnauthenticated is returned for requests without valid credentials
+var errUnauthenticated = errors.New("missing or invalid credentials")
+
+// publicPatterns are served without credentials: Kubernetes probes and the
+// search page, whose API calls are authenticated
+var publicPatterns = map[string]bool{
+	"GET /healthz": true,
+	"GET /readyz":  true,
+	"GET /{$}":     true,
+}
+
+// readPatterns are requests that only read although their method is not
+// GET: replicas post the URIs of the documents they fetch
+var readPatterns = map[string]bool{
+	"POST /replication/indexes/{name}/documents": true,
+}
+
+// unrestrictedPatterns are routes of one index that still need a principal
+// not limited to some indexes, since they remove the index
+var unrestrictedPatterns = map[string]bool{
+	"DELETE /indexes/{name}": true,
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the client authenticated for a request, or
+// nil if authentication is disabled
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// allows reports whether the principal may access an index
+func (p *Principal) allows(index string) bool {
+	if p == nil || len(p.Indexes) == 0 {
+		return true
+	}
+	for _, name := range p.Indexes {
+		if name == index {
+			return true
+		}
+	}
+	return false
+}
+
+// authEnabled reports whether requests must carry credentials
+func (s *Server) authEnabled() bool {
+	return len(s.opts.APIKeys) > 0 || s.opts.ValidateToken != nil
+}
+
+// authenticate returns the principal for the credentials of a request
+func (s *Server) authenticate(r *http.Request) (*Principal, error) {
+	token := r.Header.Get("X-API-Key")
+	if token == "" {
+		scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(value)
+		}
+	}
+	if token == "" {
+		return nil, errUnauthenticated
+	}
+
+	// Compare digests so the comparison time reveals nothing about the keys
+	digest := sha256.Sum256([]byte(token))
+	for _, key := range s.opts.APIKeys {
+		keyDigest := sha256.Sum256([]byte(key.Key))
+		if subtle.ConstantTimeCompare(digest[:], keyDigest[:]) == 1 {
+			return &Principal{Name: key.Name, Indexes: key.Indexes, ReadOnly: key.ReadOnly}, nil
+		}
+	}
+
+	if s.opts.ValidateToken != nil {
+		p, err := s.opts.ValidateToken(r.Context(), token)
+		if err == nil && p != nil {
+			return p, nil
+		}
+	}
+	return nil, errUnauthenticated
+}
+
+// authorize authenticates a request for the route pattern it matched. It
+// returns the request carrying the principal, or writes an error response
+// and returns nil.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, pattern string) *http.Request {
+	if !s.authEnabled() || publicPatterns[pattern] {
+		return r
+	}
+
+	p, err := s.authenticate(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="hnswindex"`)
+		writeError(w, http.StatusUnauthorized, err)
+		return nil
+	}
+
+	// Routes of one index check it in authorizeIndex. Listing indexes is
+	// filtered; everything else spans all indexes.
+	spansIndexes := !strings.Contains(pattern, "{name}") && pattern != "GET /indexes"
+	if len(p.Indexes) > 0 && (spansIndexes || unrestrictedPatterns[pattern]) {
+		slog.Warn("Request forbidden",
+			"principal", p.Name,
+			"path", r.URL.Path,
+		)
+		writeError(w, http.StatusForbidden, errors.New("key is limited to specific indexes"))
+		return nil
+	}
+	if p.ReadOnly && r.Method != http.MethodGet && r.Method != http.MethodHead && !readPatterns[pattern] {
+		slog.Warn("Request forbidden",
+			"principal", p.Name,
+			"path", r.URL.Path,
+		)
+		writeError(w, http.StatusForbidden, errors.New("key is read-only"))
+		return nil
+	}
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, p))
+}
+
+// authorizeIndex rejects requests for an index the principal may not access
+func authorizeIndex(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p := PrincipalFromContext(r.Context()); !p.allows(r.PathValue("name")) {
+			slog.Warn("Request forbidden",
+				"principal", p.Name,
+				"index", r.PathValue("name"),
+			)
+			writeError(w, http.StatusForbidden, errors.New("key does not grant access to this index"))
+			return
+		}
+		handler(w, r)
+	}
+}
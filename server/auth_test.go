@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/riclib/hnswindex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthServer(t *testing.T, opts Options) *httptest.Server {
+	t.Helper()
+	manager := newManager(t, "http://127.0.0.1:0")
+	for _, name := range []string{"hr", "eng"} {
+		_, err := manager.CreateIndex(name)
+		require.NoError(t, err)
+	}
+	ts := httptest.NewServer(New(manager, opts))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func getWithHeader(t *testing.T, url, header, value string) (int, string) {
+	t.Helper()
+	return requestWithHeader(t, http.MethodGet, url, "", header, value)
+}
+
+func requestWithHeader(t *testing.T, method, url, body, header, value string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestServer_APIKeys(t *testing.T) {
+	ts := newAuthServer(t, Options{
+		Diagnostics: true,
+		UI:          true,
+		APIKeys: []APIKey{
+			{Key: "admin-key", Name: "admin"},
+			{Key: "hr-key", Name: "hr-bot", Indexes: []string{"hr"}},
+		},
+	})
+
+	// Probes and the search page need no credentials
+	for _, path := range []string{"/readyz", "/"} {
+		status, _ := get(t, ts.URL+path)
+		assert.Equal(t, http.StatusOK, status, path)
+	}
+
+	status, _ := get(t, ts.URL+"/indexes")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = getWithHeader(t, ts.URL+"/indexes", "Authorization", "Bearer wrong")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	var list struct {
+		Indexes []string `json:"indexes"`
+	}
+	status, body := getWithHeader(t, ts.URL+"/indexes", "Authorization", "Bearer admin-key")
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal([]byte(body), &list))
+	assert.Equal(t, []string{"eng", "hr"}, list.Indexes)
+
+	// A restricted key only sees and reaches its indexes
+	status, body = getWithHeader(t, ts.URL+"/indexes", "X-API-Key", "hr-key")
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal([]byte(body), &list))
+	assert.Equal(t, []string{"hr"}, list.Indexes)
+
+	status, _ = getWithHeader(t, ts.URL+"/indexes/hr/changes", "X-API-Key", "hr-key")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = getWithHeader(t, ts.URL+"/indexes/eng/changes", "X-API-Key", "hr-key")
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// Endpoints spanning every index need an unrestricted key
+	for _, path := range []string{"/metrics", "/debug/indexes", "/debug/pprof/"} {
+		status, _ = getWithHeader(t, ts.URL+path, "X-API-Key", "hr-key")
+		assert.Equal(t, http.StatusForbidden, status, path)
+		status, _ = getWithHeader(t, ts.URL+path, "X-API-Key", "admin-key")
+		assert.Equal(t, http.StatusOK, status, path)
+	}
+}
+
+func TestServer_ReadOnlyKeys(t *testing.T) {
+	ts := newAuthServer(t, Options{
+		Writes:      true,
+		Replication: true,
+		APIKeys: []APIKey{
+			{Key: "admin-key", Name: "admin"},
+			{Key: "reader-key", Name: "reader", ReadOnly: true},
+			{Key: "hr-key", Name: "hr-bot", Indexes: []string{"hr"}},
+		},
+	})
+
+	for _, tc := range []struct {
+		method, path, body, key string
+		want                    int
+	}{
+		// A read-only key reads, including replica fetches, but cannot write
+		{http.MethodGet, "/indexes/hr/changes", "", "reader-key", http.StatusOK},
+		{http.MethodPost, "/replication/indexes/hr/documents", `{"uris": []}`, "reader-key", http.StatusOK},
+		{http.MethodPost, "/indexes", `{"name": "kb"}`, "reader-key", http.StatusForbidden},
+		{http.MethodPost, "/indexes/hr/documents", `{"documents": [{"uri": "doc://1", "content": "x"}]}`, "reader-key", http.StatusForbidden},
+		{http.MethodDelete, "/indexes/hr/documents?uri=doc://1", "", "reader-key", http.StatusForbidden},
+		{http.MethodDelete, "/indexes/hr", "", "reader-key", http.StatusForbidden},
+
+		// A key limited to an index writes its documents but cannot delete it
+		{http.MethodDelete, "/indexes/hr/documents?uri=doc://1", "", "hr-key", http.StatusNotFound},
+		{http.MethodDelete, "/indexes/hr", "", "hr-key", http.StatusForbidden},
+		{http.MethodDelete, "/indexes/hr", "", "admin-key", http.StatusOK},
+	} {
+		status, body := requestWithHeader(t, tc.method, ts.URL+tc.path, tc.body, "X-API-Key", tc.key)
+		assert.Equal(t, tc.want, status, "%s %s as %s: %s", tc.method, tc.path, tc.key, body)
+	}
+}
+
+func TestServer_ValidateToken(t *testing.T) {
+	ts := newAuthServer(t, Options{
+		ValidateToken: func(ctx context.Context, token string) (*Principal, error) {
+			if token != "signed-token" {
+				return nil, errors.New("invalid signature")
+			}
+			return &Principal{Name: "sso-user", Indexes: []string{"eng"}}, nil
+		},
+	})
+
+	status, _ := getWithHeader(t, ts.URL+"/indexes/eng/changes", "Authorization", "Bearer signed-token")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = getWithHeader(t, ts.URL+"/indexes/hr/changes", "Authorization", "Bearer signed-token")
+	assert.Equal(t, http.StatusForbidden, status)
+	status, _ = getWithHeader(t, ts.URL+"/indexes/eng/changes", "Authorization", "Bearer forged")
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestFollower_APIKey(t *testing.T) {
+	ollama := newFakeOllama(t)
+	leader := newManager(t, ollama.URL)
+	follower := newManager(t, ollama.URL)
+
+	index, err := leader.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), []hnswindex.Document{
+		{URI: "doc1", Title: "Vacation", Content: "Employees get 25 days of paid vacation per year."},
+	}, nil)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(New(leader, Options{
+		Replication: true,
+		APIKeys:     []APIKey{{Key: "replica-key", Name: "replica"}},
+	}))
+	t.Cleanup(ts.Close)
+
+	_, err = NewFollower(follower, ts.URL, FollowerOptions{}).Sync(context.Background())
+	assert.Error(t, err)
+
+	results, err := NewFollower(follower, ts.URL, FollowerOptions{APIKey: "replica-key"}).Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []SyncResult{{Index: "kb", Upserted: 1}}, results)
+}
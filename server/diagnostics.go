@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+
+	"github.com/riclib/hnswindex"
+)
+
+// registerDiagnostics adds the profiling and diagnostics endpoints
+func (s *Server) registerDiagnostics() {
+	// Profiles run for as long as requested, so they are not instrumented
+	s.mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	s.mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	s.mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	s.mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	s.mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	s.mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+
+	s.handle("GET /metrics", s.handleMetrics)
+	s.handle("GET /debug/indexes", s.handleIndexDump)
+}
+
+// handleMetrics serves runtime, index, and request metrics in the
+// Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	diag, err := s.manager.Diagnostics()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	writeGauge(w, "go_goroutines", "Number of goroutines.", float64(runtime.NumGoroutine()))
+	writeGauge(w, "go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.", float64(mem.HeapAlloc))
+	writeGauge(w, "go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans.", float64(mem.HeapInuse))
+	writeGauge(w, "go_memstats_sys_bytes", "Bytes obtained from the OS.", float64(mem.Sys))
+	writeHeader(w, "go_gc_cycles_total", "counter", "Completed GC cycles.")
+	fmt.Fprintf(w, "go_gc_cycles_total %d\n", mem.NumGC)
+	writeHeader(w, "go_gc_pause_seconds_total", "counter", "Total GC stop-the-world pause time.")
+	fmt.Fprintf(w, "go_gc_pause_seconds_total %g\n", float64(mem.PauseTotalNs)/1e9)
+
+	writeGauge(w, "hnswindex_database_bytes", "Size of the index database file.", float64(diag.DatabaseBytes))
+	writeGauge(w, "hnswindex_embedding_cache_entries", "Embeddings in the shared cache.", float64(diag.EmbeddingCacheSize))
+	writeGauge(w, "hnswindex_blobs", "Document bodies in the blob bucket.", float64(diag.BlobCount))
+	writeGauge(w, "hnswindex_blob_bytes", "Stored size of the blob bucket.", float64(diag.BlobBytes))
+	if t := diag.EmbedTuning; t != nil {
+		writeGauge(w, "hnswindex_embed_concurrency", "Embedder calls adaptive embedding allows at once.", float64(t.Concurrency))
+		writeGauge(w, "hnswindex_embed_batch_size", "Texts per embedder call chosen by adaptive embedding.", float64(t.BatchSize))
+		writeGauge(w, "hnswindex_embed_latency_seconds", "Per-text embedding latency baseline of adaptive embedding.", t.Latency.Seconds())
+		writeHeader(w, "hnswindex_embed_errors_total", "counter", "Failed embedder calls seen by adaptive embedding.")
+		fmt.Fprintf(w, "hnswindex_embed_errors_total %d\n", t.Errors)
+	}
+
+	for _, metric := range []struct {
+		name  string
+		help  string
+		value func(hnswindex.IndexDiagnostics) float64
+	}{
+		{"hnswindex_index_documents", "Documents per index.", func(d hnswindex.IndexDiagnostics) float64 { return float64(d.Documents) }},
+		{"hnswindex_index_chunks", "Chunks per index.", func(d hnswindex.IndexDiagnostics) float64 { return float64(d.Chunks) }},
+		{"hnswindex_index_graph_nodes", "Vectors in the HNSW graph per index.", func(d hnswindex.IndexDiagnostics) float64 { return float64(d.GraphNodes) }},
+		{"hnswindex_index_unsaved", "Whether the graph has unsaved changes.", func(d hnswindex.IndexDiagnostics) float64 {
+			if d.Unsaved {
+				return 1
+			}
+			return 0
+		}},
+	} {
+		writeHeader(w, metric.name, "gauge", metric.help)
+		for _, idx := range diag.Indexes {
+			fmt.Fprintf(w, "%s{index=%q} %g\n", metric.name, idx.Name, metric.value(idx))
+		}
+	}
+
+	s.metrics.write(w)
+	s.limiter.write(w)
+}
+
+// handleIndexDump serves the manager diagnostics as JSON
+func (s *Server) handleIndexDump(w http.ResponseWriter, r *http.Request) {
+	diag, err := s.manager.Diagnostics()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, diag)
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/riclib/hnswindex"
+)
+
+// maxIngestBytes caps the body of a document batch request
+const maxIngestBytes = 64 << 20
+
+// registerWrites adds the endpoints that create and delete indexes and
+// index and delete documents
+func (s *Server) registerWrites() {
+	s.handle("POST /indexes", s.handleCreateIndex)
+	s.handle("DELETE /indexes/{name}", s.handleDeleteIndex)
+	s.handle("POST /indexes/{name}/documents", s.handleAddDocuments)
+	s.handle("DELETE /indexes/{name}/documents", s.handleDeleteDocument)
+}
+
+// createIndexRequest is the body of an index creation request
+type createIndexRequest struct {
+	Name string `json:"name"`
+}
+
+// addDocumentsRequest is the body of a document batch request. Documents
+// whose content is unchanged are skipped unless Force is set.
+type addDocumentsRequest struct {
+	Documents []hnswindex.Document `json:"documents"`
+	Force     bool                 `json:"force"`
+}
+
+// statsResponse is the body of a stats request, with the distribution
+// if it was asked for
+type statsResponse struct {
+	hnswindex.IndexStats
+	Distribution *hnswindex.StatsDistribution `json:"distribution,omitempty"`
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	distribution := false
+	if v := r.URL.Query().Get("distribution"); v != "" {
+		if distribution, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid distribution"))
+			return
+		}
+	}
+
+	stats, err := index.Stats()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp := statsResponse{IndexStats: stats}
+	if distribution {
+		if resp.Distribution, err = index.Distribution(); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGetDocument(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	uri := r.URL.Query().Get("uri")
+	if uri == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing query parameter 'uri'"))
+		return
+	}
+	doc, err := index.GetDocument(uri)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func (s *Server) handleCreateIndex(w http.ResponseWriter, r *http.Request) {
+	var req createIndexRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if _, err := s.manager.GetIndex(req.Name); err == nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("index '%s' already exists", req.Name))
+		return
+	}
+
+	index, err := s.manager.CreateIndex(req.Name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, hnswindex.ErrInvalidIndexName) {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"created": index.Name()})
+}
+
+func (s *Server) handleDeleteIndex(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, err := s.manager.GetIndex(name); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if err := s.manager.DeleteIndex(name); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deleted": name})
+}
+
+func (s *Server) handleAddDocuments(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	var req addDocumentsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIngestBytes)).Decode(&req); err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if len(req.Documents) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("no documents"))
+		return
+	}
+	for n, doc := range req.Documents {
+		if doc.URI == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("document %d has no uri", n+1))
+			return
+		}
+	}
+
+	// A client that disconnects cancels the documents not yet indexed
+	result, err := index.AddDocumentBatchWithOptions(r.Context(), req.Documents, nil,
+		hnswindex.AddOptions{ForceUpdate: req.Force})
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, hnswindex.ErrLimitExceeded):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, hnswindex.ErrIndexSealed):
+			status = http.StatusConflict
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	uri := r.URL.Query().Get("uri")
+	if uri == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing query parameter 'uri'"))
+		return
+	}
+	if _, err := index.GetDocument(uri); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err := index.DeleteDocument(uri); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, hnswindex.ErrIndexSealed) {
+			status = http.StatusConflict
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deleted": uri})
+}
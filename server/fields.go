@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/riclib/hnswindex"
+)
+
+// DefaultPreviewLength is the length of the "preview" field of projected
+// search results, in characters
+const DefaultPreviewLength = 200
+
+// resultFields are the fields the search endpoint's fields parameter can
+// select, by the key they are returned under
+var resultFields = map[string]func(r hnswindex.SearchResult) interface{}{
+	"score":       func(r hnswindex.SearchResult) interface{} { return r.Score },
+	"uri":         func(r hnswindex.SearchResult) interface{} { return r.Document.URI },
+	"title":       func(r hnswindex.SearchResult) interface{} { return r.Document.Title },
+	"metadata":    func(r hnswindex.SearchResult) interface{} { return r.Document.Metadata },
+	"content":     func(r hnswindex.SearchResult) interface{} { return r.Document.Content },
+	"chunk_id":    func(r hnswindex.SearchResult) interface{} { return r.ChunkID },
+	"chunk_text":  func(r hnswindex.SearchResult) interface{} { return r.ChunkText },
+	"chunk_title": func(r hnswindex.SearchResult) interface{} { return r.ChunkTitle },
+	"preview":     func(r hnswindex.SearchResult) interface{} { return r.Preview(DefaultPreviewLength) },
+	"index_name":  func(r hnswindex.SearchResult) interface{} { return r.IndexName },
+	"time_range":  func(r hnswindex.SearchResult) interface{} { return r.TimeRange },
+	"query_id":    func(r hnswindex.SearchResult) interface{} { return r.QueryID },
+	"group":       func(r hnswindex.SearchResult) interface{} { return r.Group },
+	"pinned":      func(r hnswindex.SearchResult) interface{} { return r.Pinned },
+	"matches":     func(r hnswindex.SearchResult) interface{} { return r.Matches },
+	"explain":     func(r hnswindex.SearchResult) interface{} { return r.Explain },
+
+	// Selecting these turns snippets on
+	"snippet":            func(r hnswindex.SearchResult) interface{} { return r.Snippet },
+	"snippet_highlights": func(r hnswindex.SearchResult) interface{} { return r.SnippetHighlights },
+	"chunk_highlights":   func(r hnswindex.SearchResult) interface{} { return r.ChunkHighlights },
+}
+
+// selectsSnippets reports whether fields include snippets or highlights
+func selectsSnippets(fields []string) bool {
+	for _, f := range fields {
+		if f == "snippet" || f == "snippet_highlights" || f == "chunk_highlights" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFields parses a comma-separated list of result fields. An empty
+// list selects whole results.
+func parseFields(v string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if resultFields[f] == nil {
+			known := make([]string, 0, len(resultFields))
+			for name := range resultFields {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown field %q (known: %s)", f, strings.Join(known, ", "))
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// projectResults keeps only the selected fields of each result
+func projectResults(results []hnswindex.SearchResult, fields []string) []map[string]interface{} {
+	projected := make([]map[string]interface{}, len(results))
+	for n, r := range results {
+		p := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			p[f] = resultFields[f](r)
+		}
+		projected[n] = p
+	}
+	return projected
+}
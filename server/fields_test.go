@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/riclib/hnswindex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields("score, uri,,title")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"score", "uri", "title"}, fields)
+
+	fields, err = parseFields("")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	_, err = parseFields("score,embedding")
+	assert.ErrorContains(t, err, `unknown field "embedding"`)
+
+	assert.True(t, selectsSnippets([]string{"uri", "snippet"}))
+	assert.False(t, selectsSnippets([]string{"uri", "preview"}))
+}
+
+func TestProjectResults(t *testing.T) {
+	results := []hnswindex.SearchResult{{
+		Document:  hnswindex.Document{URI: "doc1", Title: "One", Content: "Full content"},
+		Score:     0.9,
+		ChunkText: "Matched chunk",
+	}}
+
+	data, err := json.Marshal(projectResults(results, []string{"score", "uri", "title", "preview"}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"score": 0.9, "uri": "doc1", "title": "One", "preview": "Matched chunk"}]`, string(data))
+}
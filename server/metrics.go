@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// routeStatus identifies a request counter
+type routeStatus struct {
+	route  string
+	status int
+}
+
+// requestMetrics counts requests and their latency per route
+type requestMetrics struct {
+	mu       sync.Mutex
+	requests map[routeStatus]uint64
+	seconds  map[string]float64
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{
+		requests: make(map[routeStatus]uint64),
+		seconds:  make(map[string]float64),
+	}
+}
+
+// instrument wraps a handler to record its requests under route
+func (m *requestMetrics) instrument(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		m.observe(route, rec.status, time.Since(start))
+	})
+}
+
+func (m *requestMetrics) observe(route string, status int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[routeStatus{route, status}]++
+	m.seconds[route] += elapsed.Seconds()
+}
+
+// write writes the counters in the Prometheus text format
+func (m *requestMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]routeStatus, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].route != keys[b].route {
+			return keys[a].route < keys[b].route
+		}
+		return keys[a].status < keys[b].status
+	})
+
+	writeHeader(w, "hnswindex_http_requests_total", "counter", "HTTP requests by route and status code.")
+	for _, key := range keys {
+		fmt.Fprintf(w, "hnswindex_http_requests_total{route=%q,code=\"%d\"} %d\n", key.route, key.status, m.requests[key])
+	}
+
+	routes := make([]string, 0, len(m.seconds))
+	for route := range m.seconds {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	writeHeader(w, "hnswindex_http_request_seconds_total", "counter", "Time spent serving requests by route.")
+	for _, route := range routes {
+		fmt.Fprintf(w, "hnswindex_http_request_seconds_total{route=%q} %g\n", route, m.seconds[route])
+	}
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeGauge writes a metric with a single unlabelled sample
+func writeGauge(w io.Writer, name, help string, value float64) {
+	writeHeader(w, name, "gauge", help)
+	fmt.Fprintf(w, "%s %g\n", name, value)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// probeTimeout bounds the dependency checks of a health probe
+const probeTimeout = 5 * time.Second
+
+// warmUpQuery is searched in every index during warm-up
+const warmUpQuery = "warm up"
+
+// registerProbes adds the Kubernetes liveness and readiness endpoints.
+// Probes run every few seconds, so they are not instrumented.
+func (s *Server) registerProbes() {
+	s.mux.HandleFunc("GET /healthz", s.handleHealthz)
+	s.mux.HandleFunc("GET /readyz", s.handleReadyz)
+}
+
+// warmUpLimit is the result limit of warm-up searches with
+// Options.WarmUpQueries, enough to read a page of results as users do
+const warmUpLimit = 10
+
+// WarmUp searches every index once, or with each of Options.WarmUpQueries,
+// which loads the embedding model and pages the graphs in, then marks the
+// server ready. Warm-up searches are not recorded in the query log. ListenAndServe runs it in the background when
+// Options.WarmUp is set. Failed searches are reported by /readyz but do
+// not keep the server unready; /healthz reports the failing dependency.
+func (s *Server) WarmUp(ctx context.Context) {
+	start := time.Now()
+	names, err := s.manager.ListIndexes()
+	if err != nil {
+		s.finishWarmUp(err.Error())
+		return
+	}
+
+	queries, limit := s.opts.WarmUpQueries, warmUpLimit
+	if len(queries) == 0 {
+		queries, limit = []string{warmUpQuery}, 1
+	}
+
+	var failure string
+	for _, name := range names {
+		index, err := s.manager.GetIndex(name)
+		for _, query := range queries {
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				break
+			}
+			err = index.WarmUp(query, limit)
+		}
+		if err != nil && failure == "" {
+			failure = name + ": " + err.Error()
+		}
+	}
+
+	slog.Info("Warm-up complete",
+		"indexes", len(names),
+		"queries", len(queries),
+		"duration_ms", time.Since(start).Milliseconds(),
+		"error", failure,
+	)
+	s.finishWarmUp(failure)
+}
+
+// finishWarmUp marks the server ready
+func (s *Server) finishWarmUp(failure string) {
+	s.warmMu.Lock()
+	s.warmedUp = true
+	s.warmUpError = failure
+	s.warmMu.Unlock()
+}
+
+// handleHealthz reports whether storage and the embedder work
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), probeTimeout)
+	defer cancel()
+
+	checks := s.manager.Ping(ctx)
+	status, code := "ok", http.StatusOK
+	for _, c := range checks {
+		if !c.OK {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, code, map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// handleReadyz reports whether the indexes are loaded and warm-up is done
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	s.warmMu.Lock()
+	warmedUp, failure := s.warmedUp, s.warmUpError
+	s.warmMu.Unlock()
+
+	body := map[string]interface{}{"warmed_up": warmedUp}
+	if failure != "" {
+		body["warm_up_error"] = failure
+	}
+
+	ready := warmedUp
+	names, err := s.manager.ListIndexes()
+	if err != nil {
+		ready = false
+		body["error"] = err.Error()
+	}
+	var unloaded []string
+	for _, name := range names {
+		if _, err := s.manager.GetIndex(name); err != nil {
+			unloaded = append(unloaded, name)
+		}
+	}
+	if len(unloaded) > 0 {
+		ready = false
+		body["unloaded_indexes"] = unloaded
+	}
+	body["indexes"] = len(names) - len(unloaded)
+
+	code := http.StatusOK
+	body["status"] = "ready"
+	if !ready {
+		code = http.StatusServiceUnavailable
+		body["status"] = "not_ready"
+	}
+	writeJSON(w, code, body)
+}
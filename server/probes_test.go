@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Healthz(t *testing.T) {
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			w.Write([]byte(`{}`))
+		case "/api/ps":
+			w.Write([]byte(`{"models":[{"name":"nomic-embed-text:latest"}]}`))
+		}
+	}))
+	defer ollama.Close()
+
+	cfg := hnswindex.NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.OllamaURL = ollama.URL
+	manager, err := hnswindex.NewIndexManager(cfg)
+	require.NoError(t, err)
+	defer manager.Close()
+
+	ts := httptest.NewServer(New(manager, Options{}))
+	defer ts.Close()
+
+	var resp struct {
+		Status string                 `json:"status"`
+		Checks []hnswindex.ProbeCheck `json:"checks"`
+	}
+	status, body := get(t, ts.URL+"/healthz")
+	require.Equal(t, http.StatusOK, status, body)
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Equal(t, "ok", resp.Status)
+	require.Len(t, resp.Checks, 3)
+	assert.Equal(t, "loaded", resp.Checks[2].Detail)
+
+	ollama.Close()
+	status, body = get(t, ts.URL+"/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Equal(t, "unavailable", resp.Status)
+	assert.False(t, resp.Checks[1].OK)
+}
+
+func TestServer_Readyz(t *testing.T) {
+	ts := newTestServer(t, Options{})
+	status, body := get(t, ts.URL+"/readyz")
+	assert.Equal(t, http.StatusOK, status, body)
+
+	cfg := hnswindex.NewConfig()
+	cfg.DataPath = t.TempDir()
+	manager, err := hnswindex.NewIndexManager(cfg)
+	require.NoError(t, err)
+	defer manager.Close()
+	_, err = manager.CreateIndex("docs")
+	require.NoError(t, err)
+
+	srv := New(manager, Options{WarmUp: true})
+	warming := httptest.NewServer(srv)
+	defer warming.Close()
+
+	var resp struct {
+		Status   string `json:"status"`
+		WarmedUp bool   `json:"warmed_up"`
+		Indexes  int    `json:"indexes"`
+	}
+	status, body = get(t, warming.URL+"/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Equal(t, "not_ready", resp.Status)
+	assert.False(t, resp.WarmedUp)
+
+	srv.WarmUp(context.Background())
+	status, body = get(t, warming.URL+"/readyz")
+	assert.Equal(t, http.StatusOK, status, body)
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Equal(t, "ready", resp.Status)
+	assert.True(t, resp.WarmedUp)
+	assert.Equal(t, 1, resp.Indexes)
+}
+
+func TestServer_WarmUpQueries(t *testing.T) {
+	cfg := hnswindex.NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.QueryLog = true
+	manager, err := hnswindex.NewIndexManager(cfg)
+	require.NoError(t, err)
+	defer manager.Close()
+	emb := embedtest.New(768)
+	manager.SetEmbedder(emb)
+	for _, name := range []string{"docs", "faq"} {
+		_, err = manager.CreateIndex(name)
+		require.NoError(t, err)
+	}
+
+	srv := New(manager, Options{
+		WarmUp:        true,
+		WarmUpQueries: []string{"vacation policy", "expense report", "vpn setup"},
+	})
+	searches := 0
+	defer manager.Subscribe(hnswindex.EventHandlerFuncs{
+		Search: func(hnswindex.SearchEvent) { searches++ },
+	})()
+	srv.WarmUp(context.Background())
+
+	// Every query is searched in every index, but not logged as a user query
+	assert.Equal(t, 6, emb.Calls())
+	assert.Zero(t, searches)
+	index, err := manager.GetIndex("docs")
+	require.NoError(t, err)
+	stats, err := index.QueryStats()
+	require.NoError(t, err)
+	assert.Zero(t, stats.TotalQueries)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	status, body := get(t, ts.URL+"/readyz")
+	assert.Equal(t, http.StatusOK, status, body)
+	assert.NotContains(t, body, "warm_up_error")
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimits throttles the search, document write, and replication document
+// endpoints, which call the embedder and read or write storage. Requests
+// over a limit get 429.
+type RateLimits struct {
+	RequestsPerSecond float64 // Sustained requests per client (0: unlimited)
+	Burst             int     // Requests a client may send at once (default: one second's worth, at least 1)
+	ClientConcurrency int     // Concurrent requests per client (0: unlimited)
+	MaxConcurrent     int     // Concurrent requests across all clients (0: unlimited)
+}
+
+// limitedRoutes are the routes RateLimits apply to
+var limitedRoutes = map[string]bool{
+	"GET /indexes/{name}/search":                 true,
+	"POST /indexes/{name}/documents":             true,
+	"DELETE /indexes/{name}/documents":           true,
+	"POST /replication/indexes/{name}/documents": true,
+}
+
+// clientIdleTimeout is how long the state of an idle client is kept
+const clientIdleTimeout = 10 * time.Minute
+
+// limitReason identifies a rate-limited request counter
+type limitReason struct {
+	route  string
+	reason string // "rate", "client_concurrency", or "concurrency"
+}
+
+// rateLimiter enforces RateLimits per client. Clients are authenticated
+// principals or, without authentication, remote IP addresses.
+type rateLimiter struct {
+	limits RateLimits
+	burst  float64
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimit
+	inFlight  int
+	limited   map[limitReason]uint64
+	lastPrune time.Time
+}
+
+// clientLimit is the token bucket and in-flight count of a client
+type clientLimit struct {
+	tokens   float64
+	updated  time.Time
+	inFlight int
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{
+		limits:    limits,
+		burst:     limits.burst(),
+		clients:   make(map[string]*clientLimit),
+		limited:   make(map[limitReason]uint64),
+		lastPrune: time.Now(),
+	}
+}
+
+// burst returns the token bucket size of a client
+func (limits RateLimits) burst() float64 {
+	if limits.Burst > 0 {
+		return float64(limits.Burst)
+	}
+	return math.Max(1, math.Ceil(limits.RequestsPerSecond))
+}
+
+// setLimits replaces the limits. Requests in flight keep their slots, and
+// clients keep their tokens up to the new burst.
+func (l *rateLimiter) setLimits(limits RateLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	l.burst = limits.burst()
+	for _, c := range l.clients {
+		c.tokens = math.Min(c.tokens, l.burst)
+	}
+}
+
+// SetRateLimits replaces the rate limits of a running server, for example
+// after its configuration changed. Limits set to zero are lifted.
+func (s *Server) SetRateLimits(limits RateLimits) {
+	s.limiter.setLimits(limits)
+}
+
+// errRateLimited is returned for requests over a limit
+var errRateLimited = errors.New("rate limit exceeded")
+
+// wrap limits requests to a route
+func (l *rateLimiter) wrap(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client := clientID(r)
+		reason, retryAfter := l.acquire(client, time.Now())
+		if reason != "" {
+			l.mu.Lock()
+			l.limited[limitReason{route, reason}]++
+			l.mu.Unlock()
+
+			if retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			}
+			writeError(w, http.StatusTooManyRequests, fmt.Errorf("%w (%s)", errRateLimited, reason))
+			return
+		}
+		defer l.release(client)
+		next(w, r)
+	}
+}
+
+// acquire takes a token and an in-flight slot for client. It returns the
+// limit that was hit, if any, and when a token is next available.
+func (l *rateLimiter) acquire(client string, now time.Time) (string, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) > clientIdleTimeout {
+		l.prune(now)
+	}
+
+	c, ok := l.clients[client]
+	if !ok {
+		c = &clientLimit{tokens: l.burst, updated: now}
+		l.clients[client] = c
+	}
+
+	if l.limits.MaxConcurrent > 0 && l.inFlight >= l.limits.MaxConcurrent {
+		return "concurrency", 0
+	}
+	if l.limits.ClientConcurrency > 0 && c.inFlight >= l.limits.ClientConcurrency {
+		return "client_concurrency", 0
+	}
+	if rps := l.limits.RequestsPerSecond; rps > 0 {
+		c.tokens = math.Min(l.burst, c.tokens+now.Sub(c.updated).Seconds()*rps)
+		c.updated = now
+		if c.tokens < 1 {
+			return "rate", time.Duration((1 - c.tokens) / rps * float64(time.Second))
+		}
+		c.tokens--
+	}
+
+	c.inFlight++
+	l.inFlight++
+	return "", 0
+}
+
+// release frees the in-flight slot of a finished request
+func (l *rateLimiter) release(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	if c, ok := l.clients[client]; ok {
+		c.inFlight--
+	}
+}
+
+// prune forgets idle clients whose bucket has refilled; l.mu must be held
+func (l *rateLimiter) prune(now time.Time) {
+	for id, c := range l.clients {
+		if c.inFlight == 0 && now.Sub(c.updated) > clientIdleTimeout {
+			delete(l.clients, id)
+		}
+	}
+	l.lastPrune = now
+}
+
+// write writes the rate-limited request counters in the Prometheus text
+// format, once limits were set
+func (l *rateLimiter) write(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limits == (RateLimits{}) && len(l.limited) == 0 {
+		return
+	}
+
+	keys := make([]limitReason, 0, len(l.limited))
+	for key := range l.limited {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].route != keys[b].route {
+			return keys[a].route < keys[b].route
+		}
+		return keys[a].reason < keys[b].reason
+	})
+
+	writeHeader(w, "hnswindex_http_rate_limited_total", "counter", "Requests rejected with 429 by route and limit.")
+	for _, key := range keys {
+		fmt.Fprintf(w, "hnswindex_http_rate_limited_total{route=%q,reason=%q} %d\n", key.route, key.reason, l.limited[key])
+	}
+	writeGauge(w, "hnswindex_http_limited_in_flight", "Rate-limited requests being served.", float64(l.inFlight))
+}
+
+// clientID identifies the client of a request: its principal when
+// authentication is enabled, otherwise its remote IP address
+func clientID(r *http.Request) string {
+	if p := PrincipalFromContext(r.Context()); p != nil {
+		return "principal:" + p.Name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_TokenBucket(t *testing.T) {
+	l := newRateLimiter(RateLimits{RequestsPerSecond: 2, Burst: 3})
+	now := time.Now()
+
+	for n := 0; n < 3; n++ {
+		reason, _ := l.acquire("a", now)
+		require.Empty(t, reason)
+		l.release("a")
+	}
+	reason, retry := l.acquire("a", now)
+	assert.Equal(t, "rate", reason)
+	assert.Equal(t, 500*time.Millisecond, retry)
+
+	// Clients have separate buckets
+	reason, _ = l.acquire("b", now)
+	assert.Empty(t, reason)
+	l.release("b")
+
+	// Tokens refill at the configured rate
+	reason, _ = l.acquire("a", now.Add(500*time.Millisecond))
+	assert.Empty(t, reason)
+	l.release("a")
+}
+
+func TestRateLimiter_Concurrency(t *testing.T) {
+	l := newRateLimiter(RateLimits{ClientConcurrency: 1, MaxConcurrent: 2})
+	now := time.Now()
+
+	reason, _ := l.acquire("a", now)
+	require.Empty(t, reason)
+	reason, _ = l.acquire("a", now)
+	assert.Equal(t, "client_concurrency", reason)
+
+	reason, _ = l.acquire("b", now)
+	require.Empty(t, reason)
+	reason, _ = l.acquire("c", now)
+	assert.Equal(t, "concurrency", reason)
+
+	l.release("a")
+	reason, _ = l.acquire("c", now)
+	assert.Empty(t, reason)
+}
+
+func TestServer_RateLimits(t *testing.T) {
+	ollama := newFakeOllama(t)
+	manager := newManager(t, ollama.URL)
+	_, err := manager.CreateIndex("docs")
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(New(manager, Options{
+		Diagnostics: true,
+		RateLimits:  RateLimits{RequestsPerSecond: 0.5},
+	}))
+	t.Cleanup(ts.Close)
+
+	status, body := get(t, ts.URL+"/indexes/docs/search?q=vacation")
+	require.Equal(t, http.StatusOK, status, body)
+
+	resp, err := http.Get(ts.URL + "/indexes/docs/search?q=vacation")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+	// Other endpoints are not limited
+	status, _ = get(t, ts.URL+"/indexes")
+	assert.Equal(t, http.StatusOK, status)
+
+	_, metrics := get(t, ts.URL+"/metrics")
+	assert.Contains(t, metrics, `hnswindex_http_rate_limited_total{route="GET /indexes/{name}/search",reason="rate"} 1`)
+	assert.Contains(t, metrics, `hnswindex_http_requests_total{route="GET /indexes/{name}/search",code="429"} 1`)
+}
+
+func TestServer_RateLimits_Writes(t *testing.T) {
+	ts := newTestServer(t, Options{Writes: true, RateLimits: RateLimits{RequestsPerSecond: 0.5}})
+
+	// Document writes share the client's bucket with searches
+	status, body := request(t, http.MethodPost, ts.URL+"/indexes/docs/documents", `{"documents": [{"uri": "doc://1", "content": "Hello"}]}`)
+	assert.Equal(t, http.StatusOK, status, body)
+	status, body = request(t, http.MethodDelete, ts.URL+"/indexes/docs/documents?uri=doc://1", "")
+	assert.Equal(t, http.StatusTooManyRequests, status, body)
+	status, body = request(t, http.MethodPost, ts.URL+"/indexes/docs/documents", `{"documents": [{"uri": "doc://2", "content": "Hello"}]}`)
+	assert.Equal(t, http.StatusTooManyRequests, status, body)
+}
+
+func TestServer_SetRateLimits(t *testing.T) {
+	ollama := newFakeOllama(t)
+	manager := newManager(t, ollama.URL)
+	_, err := manager.CreateIndex("docs")
+	require.NoError(t, err)
+
+	srv := New(manager, Options{})
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	for range 3 {
+		status, body := get(t, ts.URL+"/indexes/docs/search?q=vacation")
+		require.Equal(t, http.StatusOK, status, body)
+	}
+
+	srv.SetRateLimits(RateLimits{RequestsPerSecond: 0.5})
+	status, _ := get(t, ts.URL+"/indexes/docs/search?q=vacation")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = get(t, ts.URL+"/indexes/docs/search?q=vacation")
+	assert.Equal(t, http.StatusTooManyRequests, status)
+
+	srv.SetRateLimits(RateLimits{})
+	status, _ = get(t, ts.URL+"/indexes/docs/search?q=vacation")
+	assert.Equal(t, http.StatusOK, status)
+}
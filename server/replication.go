@@ -0,0 +1,282 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/riclib/hnswindex"
+)
+
+// maxFetchURIs caps the documents requested from a leader at once
+const maxFetchURIs = 1000
+
+// registerReplication adds the endpoints followers replicate from
+func (s *Server) registerReplication() {
+	s.handle("GET /replication/indexes/{name}/manifest", s.handleManifest)
+	s.handle("POST /replication/indexes/{name}/documents", s.handleReplicaDocuments)
+}
+
+// fetchRequest is the body of a replica document request
+type fetchRequest struct {
+	URIs []string `json:"uris"`
+}
+
+func (s *Server) handleManifest(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	manifest, err := index.Manifest()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, manifest)
+}
+
+func (s *Server) handleReplicaDocuments(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	var req fetchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if len(req.URIs) > maxFetchURIs {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("at most %d URIs per request", maxFetchURIs))
+		return
+	}
+
+	docs, err := index.ReplicaDocuments(req.URIs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, docs)
+}
+
+// FollowerOptions configures a Follower
+type FollowerOptions struct {
+	Indexes   []string      // Indexes to replicate (default: all indexes of the leader)
+	Interval  time.Duration // Time between syncs in Run (default 30s)
+	BatchSize int           // Documents fetched per request (default 100)
+	Client    *http.Client  // Optional HTTP client
+	APIKey    string        // Sent as a bearer token to leaders requiring authentication
+}
+
+// SyncResult reports the changes a sync applied to one index
+type SyncResult struct {
+	Index    string
+	Upserted int
+	Deleted  int
+}
+
+// Follower keeps the indexes of a local manager in sync with a leader
+// server started with Options.Replication. Documents are shipped with
+// their embeddings, so followers do not need an embedder for ingestion.
+// Indexes on a follower must not be written to by anything else.
+type Follower struct {
+	manager  *hnswindex.IndexManager
+	leader   string
+	opts     FollowerOptions
+	interval atomic.Int64 // Nanoseconds; see SetInterval
+}
+
+// NewFollower creates a follower replicating from the server at leaderURL
+func NewFollower(manager *hnswindex.IndexManager, leaderURL string, opts FollowerOptions) *Follower {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Second
+	}
+	if opts.BatchSize <= 0 || opts.BatchSize > maxFetchURIs {
+		opts.BatchSize = 100
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	f := &Follower{
+		manager: manager,
+		leader:  strings.TrimSuffix(leaderURL, "/"),
+		opts:    opts,
+	}
+	f.interval.Store(int64(opts.Interval))
+	return f
+}
+
+// SetInterval changes the time between syncs of a running follower,
+// starting with the wait after the current or next sync. Intervals of 0 or
+// less are ignored.
+func (f *Follower) SetInterval(interval time.Duration) {
+	if interval > 0 {
+		f.interval.Store(int64(interval))
+	}
+}
+
+// Run syncs immediately and then every Interval until ctx is cancelled.
+// Sync errors are logged and retried on the next tick.
+func (f *Follower) Run(ctx context.Context) error {
+	timer := time.NewTimer(time.Duration(f.interval.Load()))
+	defer timer.Stop()
+
+	for {
+		if _, err := f.Sync(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Replication sync failed",
+				"leader", f.leader,
+				"error", err,
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		timer.Reset(time.Duration(f.interval.Load()))
+	}
+}
+
+// Sync brings every replicated index up to date with the leader once
+func (f *Follower) Sync(ctx context.Context) ([]SyncResult, error) {
+	names := f.opts.Indexes
+	if len(names) == 0 {
+		var resp struct {
+			Indexes []string `json:"indexes"`
+		}
+		if err := f.do(ctx, http.MethodGet, "/indexes", nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to list leader indexes: %w", err)
+		}
+		names = resp.Indexes
+	}
+
+	var results []SyncResult
+	for _, name := range names {
+		result, err := f.syncIndex(ctx, name)
+		if err != nil {
+			return results, fmt.Errorf("failed to sync index '%s': %w", name, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// syncIndex replicates one index by comparing manifests
+func (f *Follower) syncIndex(ctx context.Context, name string) (SyncResult, error) {
+	result := SyncResult{Index: name}
+	base := "/replication/indexes/" + url.PathEscape(name)
+
+	var remote map[string]string
+	if err := f.do(ctx, http.MethodGet, base+"/manifest", nil, &remote); err != nil {
+		return result, err
+	}
+
+	index, err := f.manager.GetIndex(name)
+	if err != nil {
+		if index, err = f.manager.CreateIndex(name); err != nil {
+			return result, err
+		}
+	}
+	local, err := index.Manifest()
+	if err != nil {
+		return result, err
+	}
+
+	var fetch, deletes []string
+	for uri, hash := range remote {
+		if local[uri] != hash {
+			fetch = append(fetch, uri)
+		}
+	}
+	for uri := range local {
+		if _, ok := remote[uri]; !ok {
+			deletes = append(deletes, uri)
+		}
+	}
+	sort.Strings(fetch)
+	sort.Strings(deletes)
+
+	for start := 0; start < len(fetch); start += f.opts.BatchSize {
+		end := min(start+f.opts.BatchSize, len(fetch))
+
+		var docs []hnswindex.ReplicaDocument
+		if err := f.do(ctx, http.MethodPost, base+"/documents", fetchRequest{URIs: fetch[start:end]}, &docs); err != nil {
+			return result, err
+		}
+		if err := index.ApplyReplica(docs, nil); err != nil {
+			return result, err
+		}
+		result.Upserted += len(docs)
+	}
+
+	if len(deletes) > 0 {
+		if err := index.ApplyReplica(nil, deletes); err != nil {
+			return result, err
+		}
+		result.Deleted = len(deletes)
+	}
+
+	if result.Upserted > 0 || result.Deleted > 0 {
+		slog.Info("Index replicated",
+			"index", name,
+			"upserted", result.Upserted,
+			"deleted", result.Deleted,
+		)
+	}
+	return result, nil
+}
+
+// do sends a JSON request to the leader and decodes the response into out
+func (f *Follower) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, f.leader+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if f.opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+f.opts.APIKey)
+	}
+
+	resp, err := f.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return errors.New(apiErr.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
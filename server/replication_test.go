@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riclib/hnswindex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeOllama serves deterministic 768-dimensional embeddings derived
+// from a hash of each input text
+func newFakeOllama(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string          `json:"model"`
+			Input json.RawMessage `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var texts []string
+		if err := json.Unmarshal(req.Input, &texts); err != nil {
+			var text string
+			require.NoError(t, json.Unmarshal(req.Input, &text))
+			texts = []string{text}
+		}
+
+		resp := struct {
+			Model      string      `json:"model"`
+			Embeddings [][]float32 `json:"embeddings"`
+		}{Model: req.Model}
+		for _, text := range texts {
+			sum := sha256.Sum256([]byte(text))
+			vec := make([]float32, 768)
+			for idx := range vec {
+				vec[idx] = float32(sum[idx%len(sum)])/255 + 0.01
+			}
+			resp.Embeddings = append(resp.Embeddings, vec)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newManager(t *testing.T, ollamaURL string) *hnswindex.IndexManager {
+	t.Helper()
+	cfg := hnswindex.NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.OllamaURL = ollamaURL
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 5
+
+	manager, err := hnswindex.NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+func TestFollower_Sync(t *testing.T) {
+	ollama := newFakeOllama(t)
+	leader := newManager(t, ollama.URL)
+	follower := newManager(t, ollama.URL)
+
+	index, err := leader.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), []hnswindex.Document{
+		{URI: "doc1", Title: "Vacation", Content: "Employees get 25 days of paid vacation per year.", Metadata: map[string]interface{}{"team": "hr"}},
+		{URI: "doc2", Title: "Laptops", Content: "Laptops are replaced every three years."},
+		{URI: "doc3", Title: "Parking", Content: "Parking spaces are assigned by seniority."},
+	}, nil)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(New(leader, Options{Replication: true}))
+	t.Cleanup(ts.Close)
+
+	f := NewFollower(follower, ts.URL, FollowerOptions{BatchSize: 2})
+	results, err := f.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []SyncResult{{Index: "kb", Upserted: 3}}, results)
+
+	replica, err := follower.GetIndex("kb")
+	require.NoError(t, err)
+	leaderManifest, err := index.Manifest()
+	require.NoError(t, err)
+	replicaManifest, err := replica.Manifest()
+	require.NoError(t, err)
+	assert.Equal(t, leaderManifest, replicaManifest)
+
+	doc, err := replica.GetDocument("doc1")
+	require.NoError(t, err)
+	assert.Equal(t, "hr", doc.Metadata["team"])
+
+	// The replica searches with the shipped embeddings
+	hits, err := replica.Search("Laptops are replaced every three years.", 1)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "doc2", hits[0].Document.URI)
+
+	// A second sync only ships changes
+	_, err = index.AddDocumentBatch(context.Background(), []hnswindex.Document{
+		{URI: "doc2", Title: "Laptops", Content: "Laptops are replaced every four years."},
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, index.DeleteDocument("doc3"))
+
+	results, err = f.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []SyncResult{{Index: "kb", Upserted: 1, Deleted: 1}}, results)
+
+	doc, err = replica.GetDocument("doc2")
+	require.NoError(t, err)
+	assert.Contains(t, doc.Content, "four years")
+	_, err = replica.GetDocument("doc3")
+	assert.Error(t, err)
+
+	results, err = f.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []SyncResult{{Index: "kb"}}, results)
+}
+
+func TestFollower_ReplicationDisabled(t *testing.T) {
+	leader := newManager(t, "http://127.0.0.1:0")
+	_, err := leader.CreateIndex("kb")
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(New(leader, Options{}))
+	t.Cleanup(ts.Close)
+
+	f := NewFollower(newManager(t, "http://127.0.0.1:0"), ts.URL, FollowerOptions{})
+	_, err = f.Sync(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,502 @@
+// Package server exposes the indexes of an IndexManager over JSON/HTTP.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/riclib/hnswindex"
+)
+
+// DefaultSearchLimit is the number of results returned when the request
+// does not specify a limit
+const DefaultSearchLimit = 10
+
+// DefaultChangesLimit is the number of change log entries returned when
+// the request does not specify a limit
+const DefaultChangesLimit = 1000
+
+// DefaultClusters is the number of clusters computed when the request does
+// not specify k
+const DefaultClusters = 10
+
+// Options configures a Server
+type Options struct {
+	// Diagnostics exposes /debug/pprof/, /metrics, and /debug/indexes.
+	// They reveal internals of the process and should only be enabled on
+	// trusted networks.
+	Diagnostics bool
+
+	// Replication serves the manifest and document endpoints that
+	// followers (see Follower) replicate indexes from
+	Replication bool
+
+	// UI serves a search page at / for trying indexes from a browser
+	UI bool
+
+	// Writes lets clients create (POST /indexes) and delete indexes, and
+	// index (POST /indexes/{name}/documents) and delete documents. Without
+	// it the server only reads. With authentication, only keys and
+	// principals not limited to some indexes may create or delete
+	// indexes, and read-only ones may not write.
+	Writes bool
+
+	// Admin exposes maintenance endpoints: POST /admin/save flushes all
+	// unsaved graphs to disk (see IndexManager.SaveAll),
+	// /admin/indexes/{name}/suppressions lists, adds (POST) and removes
+	// (DELETE ?id=) suppressions (see Index.Suppress), and
+	// /admin/indexes/{name}/seal seals (POST) and unseals (DELETE) an index
+	// (see Index.Seal). With authentication, only keys and principals not
+	// limited to some indexes may save; suppressions and seals need access
+	// to their index. Searches only accept suppressed=true, returning
+	// suppressed documents, with Admin.
+	Admin bool
+
+	// WarmUp makes ListenAndServe search every index once before /readyz
+	// reports ready (see Server.WarmUp). Without it the server is ready
+	// immediately.
+	WarmUp bool
+
+	// WarmUpQueries replaces the single warm-up search with these queries,
+	// each searched in every index. Typical user queries page in the graph
+	// regions and stored chunks that first searches would otherwise wait for.
+	WarmUpQueries []string
+
+	// APIKeys and ValidateToken enable authentication: every request except
+	// the probes and the search page must carry an API key or a bearer
+	// token that ValidateToken accepts. Keys and principals may be limited
+	// to some indexes.
+	APIKeys       []APIKey
+	ValidateToken TokenValidator
+
+	// RateLimits throttles search and replication document requests per
+	// client, protecting the embedder and storage from bursts
+	RateLimits RateLimits
+
+	// SearchDefaults apply to search requests that leave a parameter out
+	SearchDefaults SearchDefaults
+}
+
+// SearchDefaults are the values of search parameters a request leaves out
+type SearchDefaults struct {
+	Limit        int     // limit (0: DefaultSearchLimit)
+	TitleBoost   float64 // title_boost
+	SparseWeight float64 // sparse_weight
+	Model        string  // model; empty embeds queries with the index model
+}
+
+// SetSearchDefaults replaces the search defaults of a running server, for
+// example after its configuration changed
+func (s *Server) SetSearchDefaults(defaults SearchDefaults) {
+	if defaults.Limit <= 0 {
+		defaults.Limit = DefaultSearchLimit
+	}
+	s.defaults.Store(&defaults)
+}
+
+// Server serves search requests for the indexes of a manager
+type Server struct {
+	manager *hnswindex.IndexManager
+	opts    Options
+	mux     *http.ServeMux
+	metrics *requestMetrics
+	limiter *rateLimiter // Lets every request through without RateLimits
+
+	defaults atomic.Pointer[SearchDefaults]
+
+	warmMu      sync.Mutex
+	warmedUp    bool
+	warmUpError string
+}
+
+// New creates a server for the indexes of manager
+func New(manager *hnswindex.IndexManager, opts Options) *Server {
+	s := &Server{
+		manager:  manager,
+		opts:     opts,
+		mux:      http.NewServeMux(),
+		metrics:  newRequestMetrics(),
+		warmedUp: !opts.WarmUp,
+	}
+	s.limiter = newRateLimiter(opts.RateLimits)
+	s.SetSearchDefaults(opts.SearchDefaults)
+
+	s.handle("GET /indexes", s.handleListIndexes)
+	s.handle("GET /indexes/{name}/search", s.handleSearch)
+	s.handle("GET /indexes/{name}/stats", s.handleStats)
+	s.handle("GET /indexes/{name}/documents", s.handleGetDocument)
+	s.handle("GET /indexes/{name}/changes", s.handleChanges)
+	s.handle("GET /indexes/{name}/suggest", s.handleSuggest)
+	s.handle("GET /indexes/{name}/clusters", s.handleClusters)
+	s.registerProbes()
+
+	if opts.Diagnostics {
+		s.registerDiagnostics()
+	}
+	if opts.Replication {
+		s.registerReplication()
+	}
+	if opts.UI {
+		s.registerUI()
+	}
+	if opts.Writes {
+		s.registerWrites()
+	}
+	if opts.Admin {
+		s.registerAdmin()
+	}
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, pattern := s.mux.Handler(r)
+	if r = s.authorize(w, r, pattern); r == nil {
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves on addr until ctx is cancelled, then shuts down
+// gracefully, waiting for in-flight requests
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if s.opts.WarmUp {
+		go s.WarmUp(ctx)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Server listening",
+			"addr", addr,
+			"diagnostics", s.opts.Diagnostics,
+			"replication", s.opts.Replication,
+			"ui", s.opts.UI,
+			"writes", s.opts.Writes,
+			"auth", s.authEnabled(),
+		)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handle registers a handler, recording request metrics under its pattern.
+// Handlers of a single index only serve principals allowed to access it.
+func (s *Server) handle(pattern string, handler http.HandlerFunc) {
+	if strings.Contains(pattern, "{name}") {
+		handler = authorizeIndex(handler)
+	}
+	if limitedRoutes[pattern] {
+		handler = s.limiter.wrap(pattern, handler)
+	}
+	s.mux.Handle(pattern, s.metrics.instrument(pattern, handler))
+}
+
+func (s *Server) handleListIndexes(w http.ResponseWriter, r *http.Request) {
+	names, err := s.manager.ListIndexes()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	principal := PrincipalFromContext(r.Context())
+	allowed := []string{}
+	for _, name := range names {
+		if principal.allows(name) {
+			allowed = append(allowed, name)
+		}
+	}
+	names = allowed
+	writeJSON(w, http.StatusOK, map[string]interface{}{"indexes": names})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing query parameter 'q'"))
+		return
+	}
+
+	defaults := s.defaults.Load()
+	limit := defaults.Limit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid limit"))
+			return
+		}
+	}
+
+	perGroup := 0
+	if v := r.URL.Query().Get("per_group"); v != "" {
+		if perGroup, err = strconv.Atoi(v); err != nil || perGroup <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid per_group"))
+			return
+		}
+	}
+
+	titleBoost := defaults.TitleBoost
+	if v := r.URL.Query().Get("title_boost"); v != "" {
+		if titleBoost, err = strconv.ParseFloat(v, 64); err != nil || titleBoost < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid title_boost"))
+			return
+		}
+	}
+
+	sparseWeight := defaults.SparseWeight
+	if v := r.URL.Query().Get("sparse_weight"); v != "" {
+		if sparseWeight, err = strconv.ParseFloat(v, 64); err != nil || sparseWeight < 0 || sparseWeight > 1 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid sparse_weight"))
+			return
+		}
+	}
+
+	model := defaults.Model
+	if v := r.URL.Query().Get("model"); v != "" {
+		model = v
+	}
+
+	snippetLength := 0
+	if v := r.URL.Query().Get("snippet_length"); v != "" {
+		if snippetLength, err = strconv.Atoi(v); err != nil || snippetLength <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid snippet_length"))
+			return
+		}
+	}
+
+	ef := 0
+	if v := r.URL.Query().Get("ef"); v != "" {
+		if ef, err = strconv.Atoi(v); err != nil || ef <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid ef"))
+			return
+		}
+	}
+
+	exactRerank := 0
+	if v := r.URL.Query().Get("exact_rerank"); v != "" {
+		if exactRerank, err = strconv.Atoi(v); err != nil || exactRerank <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid exact_rerank"))
+			return
+		}
+	}
+
+	chunks, err := parseChunkRange(r.URL.Query().Get("chunks"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	fields, err := parseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	parsed, err := hnswindex.ParseQuery(query)
+	if err == nil && parsed.Text == "" {
+		err = errors.New("query has no search text")
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := index.SearchWithOptions(parsed.Text, limit, hnswindex.SearchOptions{
+		Explain:  r.URL.Query().Get("explain") == "true",
+		Filters:  parsed.Filters,
+		GroupBy:  r.URL.Query().Get("group_by"),
+		PerGroup: perGroup,
+
+		GroupByDocument: r.URL.Query().Get("group_by_document") == "true",
+
+		NegativeQuery: r.URL.Query().Get("not"),
+		TitleBoost:    titleBoost,
+		Model:         model,
+
+		LateInteraction: r.URL.Query().Get("late") == "true",
+		SparseWeight:    sparseWeight,
+		ChunkPositions:  chunks,
+
+		IgnoreBoostRules:  r.URL.Query().Get("boosts") == "false",
+		IncludeSuppressed: s.opts.Admin && r.URL.Query().Get("suppressed") == "true",
+
+		Snippets:      r.URL.Query().Get("snippets") == "true" || selectsSnippets(fields),
+		SnippetLength: snippetLength,
+
+		EfSearch:    ef,
+		ExactRerank: exactRerank,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if results == nil {
+		results = []hnswindex.SearchResult{}
+	}
+	if len(fields) > 0 {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"results": projectResults(results, fields)})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
+
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	var since uint64
+	if v := r.URL.Query().Get("since"); v != "" {
+		if since, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid since"))
+			return
+		}
+	}
+	limit := DefaultChangesLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid limit"))
+			return
+		}
+	}
+
+	changes, err := index.Changes(since, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	latest, err := index.LatestChange()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if changes == nil {
+		changes = []hnswindex.Change{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"changes": changes,
+		"latest":  latest,
+	})
+}
+
+func (s *Server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	prefix := r.URL.Query().Get("q")
+	if strings.TrimSpace(prefix) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing query parameter 'q'"))
+		return
+	}
+	limit := hnswindex.DefaultSuggestLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid limit"))
+			return
+		}
+	}
+
+	suggestions, err := index.Suggest(prefix, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"suggestions": suggestions})
+}
+
+func (s *Server) handleClusters(w http.ResponseWriter, r *http.Request) {
+	index, err := s.manager.GetIndex(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	k := DefaultClusters
+	if v := r.URL.Query().Get("k"); v != "" {
+		if k, err = strconv.Atoi(v); err != nil || k <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid k"))
+			return
+		}
+	}
+
+	clusters, err := index.Cluster(k)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"clusters": clusters})
+}
+
+// parseChunkRange parses the chunks parameter, a range of chunk positions
+// written like a slice expression: ":3" for the first three chunks, "2:"
+// for all but the first two, "2:5" for the three in between
+func parseChunkRange(v string) (hnswindex.ChunkRange, error) {
+	var r hnswindex.ChunkRange
+	if v == "" {
+		return r, nil
+	}
+	from, to, ok := strings.Cut(v, ":")
+	var err error
+	if ok && from != "" {
+		r.From, err = strconv.Atoi(from)
+	}
+	if ok && err == nil && to != "" {
+		r.To, err = strconv.Atoi(to)
+	}
+	if !ok || err != nil || r.From < 0 || r.To < 0 || (r.To > 0 && r.To <= r.From) {
+		return hnswindex.ChunkRange{}, errors.New("invalid chunks: expected a range such as :3 or 2:5")
+	}
+	return r, nil
+}
+
+// writeJSON writes v as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Failed to write response", "error", err)
+	}
+}
+
+// writeError writes an error response body
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
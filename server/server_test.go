@@ -0,0 +1,378 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/riclib/hnswindex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, opts Options) *httptest.Server {
+	t.Helper()
+	cfg := hnswindex.NewConfig()
+	cfg.DataPath = t.TempDir()
+
+	manager, err := hnswindex.NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+
+	_, err = manager.CreateIndex("docs")
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(New(manager, opts))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	return request(t, http.MethodGet, url, "")
+}
+
+func request(t *testing.T, method, url, body string) (int, string) {
+	t.Helper()
+	return requestWithHeader(t, method, url, body, "", "")
+}
+
+func TestServer_ListIndexes(t *testing.T) {
+	ts := newTestServer(t, Options{})
+
+	status, body := get(t, ts.URL+"/indexes")
+	require.Equal(t, http.StatusOK, status)
+
+	var resp struct {
+		Indexes []string `json:"indexes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Equal(t, []string{"docs"}, resp.Indexes)
+}
+
+func TestServer_SearchErrors(t *testing.T) {
+	ts := newTestServer(t, Options{})
+
+	status, _ := get(t, ts.URL+"/indexes/missing/search?q=test")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, body := get(t, ts.URL+"/indexes/docs/search")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "missing query")
+
+	status, _ = get(t, ts.URL+"/indexes/docs/search?q=test&limit=-1")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, body = get(t, ts.URL+"/indexes/docs/search?q="+url.QueryEscape(`kind:runbook "failover`))
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "unterminated quote")
+
+	status, _ = get(t, ts.URL+"/indexes/docs/search?q=test&group_by=space&per_group=0")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, body = get(t, ts.URL+"/indexes/docs/search?q=test&sparse_weight=2")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "invalid sparse_weight")
+
+	status, body = get(t, ts.URL+"/indexes/docs/search?q=test&snippet_length=0")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "invalid snippet_length")
+
+	status, body = get(t, ts.URL+"/indexes/docs/search?q=test&title_boost=-1")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "invalid title_boost")
+
+	status, body = get(t, ts.URL+"/indexes/docs/search?q=test&ef=0")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "invalid ef")
+
+	status, body = get(t, ts.URL+"/indexes/docs/search?q=test&exact_rerank=x")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "invalid exact_rerank")
+
+	for _, chunks := range []string{"3", "a:", "-1:", "3:2"} {
+		status, body = get(t, ts.URL+"/indexes/docs/search?q=test&chunks="+chunks)
+		assert.Equal(t, http.StatusBadRequest, status, chunks)
+		assert.Contains(t, body, "invalid chunks", chunks)
+	}
+	status, body = get(t, ts.URL+"/indexes/docs/search?q=kind:runbook")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "no search text")
+
+	status, body = get(t, ts.URL+"/indexes/docs/search?q=test&fields=score,embedding")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, `unknown field \"embedding\"`)
+}
+
+func TestServer_SearchDefaults(t *testing.T) {
+	ollama := newFakeOllama(t)
+	manager := newManager(t, ollama.URL)
+	index, err := manager.CreateIndex("docs")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), []hnswindex.Document{
+		{URI: "doc1", Title: "Vacation", Content: "Employees get 25 days of paid vacation per year."},
+		{URI: "doc2", Title: "Expenses", Content: "Submit expense reports within 30 days."},
+		{URI: "doc3", Title: "Laptops", Content: "Laptops are replaced every three years."},
+	}, nil)
+	require.NoError(t, err)
+
+	srv := New(manager, Options{SearchDefaults: SearchDefaults{Limit: 1}})
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	count := func(query string) int {
+		status, body := get(t, ts.URL+"/indexes/docs/search?q=vacation"+query)
+		require.Equal(t, http.StatusOK, status, body)
+		var resp struct {
+			Results []json.RawMessage `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		return len(resp.Results)
+	}
+	assert.Equal(t, 1, count(""))
+	assert.Equal(t, 3, count("&limit=5"))
+	assert.Equal(t, 3, count("&limit=5&group_by_document=true&fields=uri,matches"))
+
+	srv.SetSearchDefaults(SearchDefaults{Limit: 2})
+	assert.Equal(t, 2, count(""))
+}
+
+func TestServer_Changes(t *testing.T) {
+	ts := newTestServer(t, Options{})
+
+	status, body := get(t, ts.URL+"/indexes/docs/changes?since=0")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"changes": [], "latest": 0}`, body)
+
+	status, _ = get(t, ts.URL+"/indexes/docs/changes?since=x")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = get(t, ts.URL+"/indexes/missing/changes")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServer_Suggest(t *testing.T) {
+	ts := newTestServer(t, Options{})
+
+	status, body := get(t, ts.URL+"/indexes/docs/suggest?q=fail")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"suggestions": []}`, body)
+
+	status, _ = get(t, ts.URL+"/indexes/docs/suggest")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = get(t, ts.URL+"/indexes/docs/suggest?q=fail&limit=0")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = get(t, ts.URL+"/indexes/missing/suggest?q=fail")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServer_StatsDistribution(t *testing.T) {
+	ts := newTestServer(t, Options{})
+
+	status, body := get(t, ts.URL+"/indexes/docs/stats")
+	require.Equal(t, http.StatusOK, status)
+	assert.NotContains(t, body, `"distribution"`)
+
+	status, body = get(t, ts.URL+"/indexes/docs/stats?distribution=true")
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `"distribution"`)
+
+	status, _ = get(t, ts.URL+"/indexes/docs/stats?distribution=maybe")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestServer_Clusters(t *testing.T) {
+	ts := newTestServer(t, Options{})
+
+	status, body := get(t, ts.URL+"/indexes/docs/clusters?k=5")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"clusters": []}`, body)
+
+	status, _ = get(t, ts.URL+"/indexes/docs/clusters?k=0")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = get(t, ts.URL+"/indexes/missing/clusters")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServer_DiagnosticsDisabled(t *testing.T) {
+	ts := newTestServer(t, Options{})
+
+	for _, path := range []string{"/metrics", "/debug/indexes", "/debug/pprof/"} {
+		status, _ := get(t, ts.URL+path)
+		assert.Equal(t, http.StatusNotFound, status, path)
+	}
+}
+
+func TestServer_Diagnostics(t *testing.T) {
+	ts := newTestServer(t, Options{Diagnostics: true})
+
+	// Generate a request to count
+	get(t, ts.URL+"/indexes")
+
+	status, body := get(t, ts.URL+"/metrics")
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "go_goroutines ")
+	assert.Contains(t, body, `hnswindex_index_documents{index="docs"} 0`)
+	assert.Contains(t, body, `hnswindex_http_requests_total{route="GET /indexes",code="200"} 1`)
+
+	status, body = get(t, ts.URL+"/debug/indexes")
+	require.Equal(t, http.StatusOK, status)
+	var diag hnswindex.Diagnostics
+	require.NoError(t, json.Unmarshal([]byte(body), &diag))
+	require.Len(t, diag.Indexes, 1)
+	assert.Equal(t, "docs", diag.Indexes[0].Name)
+	assert.Equal(t, 768, diag.Indexes[0].Config.Embedder.Dimension)
+
+	status, body = get(t, ts.URL+"/debug/pprof/")
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, strings.Contains(body, "goroutine"))
+}
+
+func TestServer_Admin(t *testing.T) {
+	resp, err := http.Post(newTestServer(t, Options{}).URL+"/admin/save", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Post(newTestServer(t, Options{Admin: true}).URL+"/admin/save", "", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_Suppressions(t *testing.T) {
+	ts := newTestServer(t, Options{Admin: true})
+	url := ts.URL + "/admin/indexes/docs/suppressions"
+
+	status, body := request(t, http.MethodPost, url, `{"uri": "doc://old", "reason": "superseded"}`)
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Contains(t, body, `"id":"doc://old"`)
+	status, body = request(t, http.MethodPost, url, `{"filter": "status:archived"}`)
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Contains(t, body, `"id":"status:archived"`)
+
+	for _, invalid := range []string{`{}`, `{"filter": "archived docs"}`, `not json`} {
+		status, _ = request(t, http.MethodPost, url, invalid)
+		assert.Equal(t, http.StatusBadRequest, status, invalid)
+	}
+
+	status, body = get(t, url)
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "superseded")
+	assert.Contains(t, body, "status:archived")
+
+	status, _ = request(t, http.MethodDelete, url+"?id=doc://old", "")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = request(t, http.MethodDelete, url+"?id=doc://old", "")
+	assert.Equal(t, http.StatusNotFound, status)
+	status, body = get(t, url)
+	require.Equal(t, http.StatusOK, status)
+	assert.NotContains(t, body, "doc://old")
+}
+
+func TestServer_Writes(t *testing.T) {
+	ollama := newFakeOllama(t)
+	manager := newManager(t, ollama.URL)
+
+	readOnly := httptest.NewServer(New(manager, Options{}))
+	t.Cleanup(readOnly.Close)
+	resp, err := http.Post(readOnly.URL+"/indexes", "application/json", strings.NewReader(`{"name": "kb"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	ts := httptest.NewServer(New(manager, Options{Writes: true}))
+	t.Cleanup(ts.Close)
+
+	status, body := request(t, http.MethodPost, ts.URL+"/indexes", `{"name": "kb"}`)
+	require.Equal(t, http.StatusCreated, status, body)
+	status, _ = request(t, http.MethodPost, ts.URL+"/indexes", `{"name": "kb"}`)
+	assert.Equal(t, http.StatusConflict, status)
+	status, _ = request(t, http.MethodPost, ts.URL+"/indexes", `{"name": "../kb"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, body = request(t, http.MethodPost, ts.URL+"/indexes/kb/documents", `{"documents": [
+		{"uri": "doc://1", "title": "Vacation", "content": "Employees get 25 days of paid vacation per year."},
+		{"uri": "doc://2", "title": "Expenses", "content": "Submit expense reports within 30 days."}
+	]}`)
+	require.Equal(t, http.StatusOK, status, body)
+	var result hnswindex.BatchResult
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Equal(t, 2, result.NewDocuments)
+	for _, invalid := range []string{`{"documents": []}`, `{"documents": [{"title": "No URI"}]}`, `not json`} {
+		status, _ = request(t, http.MethodPost, ts.URL+"/indexes/kb/documents", invalid)
+		assert.Equal(t, http.StatusBadRequest, status, invalid)
+	}
+
+	status, body = get(t, ts.URL+"/indexes/kb/stats")
+	require.Equal(t, http.StatusOK, status, body)
+	var stats hnswindex.IndexStats
+	require.NoError(t, json.Unmarshal([]byte(body), &stats))
+	assert.Equal(t, 2, stats.DocumentCount)
+
+	status, body = get(t, ts.URL+"/indexes/kb/documents?uri="+url.QueryEscape("doc://1"))
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Contains(t, body, "paid vacation")
+
+	status, body = get(t, ts.URL+"/indexes/kb/search?q=vacation")
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Contains(t, body, "doc://1")
+
+	status, _ = request(t, http.MethodDelete, ts.URL+"/indexes/kb/documents?uri="+url.QueryEscape("doc://1"), "")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = request(t, http.MethodDelete, ts.URL+"/indexes/kb/documents?uri="+url.QueryEscape("doc://1"), "")
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = get(t, ts.URL+"/indexes/kb/documents?uri="+url.QueryEscape("doc://1"))
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = request(t, http.MethodDelete, ts.URL+"/indexes/kb", "")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = request(t, http.MethodDelete, ts.URL+"/indexes/kb", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServer_Seal(t *testing.T) {
+	ts := newTestServer(t, Options{Admin: true, Writes: true})
+
+	status, body := request(t, http.MethodPost, ts.URL+"/admin/indexes/docs/seal", "")
+	require.Equal(t, http.StatusOK, status, body)
+	status, body = get(t, ts.URL+"/indexes/docs/stats")
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `"sealed":`)
+	status, body = request(t, http.MethodPost, ts.URL+"/indexes/docs/documents", `{"documents": [{"uri": "doc://1", "content": "Hello"}]}`)
+	assert.Equal(t, http.StatusConflict, status, body)
+
+	status, body = request(t, http.MethodDelete, ts.URL+"/admin/indexes/docs/seal", "")
+	require.Equal(t, http.StatusOK, status, body)
+	status, body = get(t, ts.URL+"/indexes/docs/stats")
+	require.Equal(t, http.StatusOK, status)
+	assert.NotContains(t, body, `"sealed":`)
+	status, body = request(t, http.MethodPost, ts.URL+"/admin/indexes/missing/seal", "")
+	assert.Equal(t, http.StatusNotFound, status, body)
+}
+
+func TestServer_UI(t *testing.T) {
+	status, _ := get(t, newTestServer(t, Options{}).URL+"/")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	ts := newTestServer(t, Options{UI: true})
+	resp, err := http.Get(ts.URL + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+	assert.Contains(t, string(body), `api("indexes")`)
+
+	// Only the root path serves the page; the API is unchanged
+	status, _ = get(t, ts.URL+"/missing")
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = get(t, ts.URL+"/indexes")
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed ui/index.html
+var uiPage []byte
+
+// registerUI adds the search page at the root path
+func (s *Server) registerUI() {
+	s.handle("GET /{$}", s.handleUI)
+}
+
+// handleUI serves a single-page search form that calls the JSON API
+func (s *Server) handleUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(uiPage)
+}
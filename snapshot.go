@@ -0,0 +1,720 @@
+package hnswindex
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// DefaultSnapshotKey is the object key used when Config.SnapshotKey is empty
+const DefaultSnapshotKey = "hnswindex-snapshot.tar.gz"
+
+// Snapshot compression codecs for Config.SnapshotCompression
+const (
+	SnapshotZstd = "zstd"
+	SnapshotGzip = "gzip"
+)
+
+// Magic numbers that open gzip and zstd streams
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ErrObjectNotFound is returned by ObjectStore.Get for missing keys
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrSnapshotCorrupt is returned by RestoreSnapshot when a snapshot does
+// not match its manifest
+var ErrSnapshotCorrupt = errors.New("snapshot is corrupt")
+
+// snapshotManifestName is the snapshot entry holding the manifest
+const snapshotManifestName = "manifest.json"
+
+// SnapshotManifest describes the contents of a snapshot. It is written as
+// the last entry, so a truncated transfer lacks it, and RestoreSnapshot
+// checks every file and document against it.
+type SnapshotManifest struct {
+	Created time.Time               `json:"created"`
+	Files   map[string]SnapshotFile `json:"files"` // By entry name
+
+	// Documents holds the checksum of every document, with its chunks, by
+	// index and URI
+	Documents map[string]map[string]string `json:"documents"`
+}
+
+// SnapshotFile is the size and SHA-256 of a snapshot entry
+type SnapshotFile struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ObjectStore is a minimal blob store (S3, GCS, Azure Blob, a shared
+// directory) that data directory snapshots are persisted to.
+// pkg/s3 provides an implementation for S3-compatible services.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error) // ErrObjectNotFound if missing
+}
+
+// dirStore stores objects as files in a directory
+type dirStore struct {
+	dir string
+}
+
+// NewDirStore returns an ObjectStore backed by a local or mounted directory
+func NewDirStore(dir string) ObjectStore {
+	return &dirStore{dir: dir}
+}
+
+// Put writes the object to a temporary file and renames it into place
+func (d *dirStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	target := filepath.Join(d.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// Get opens the object file
+func (d *dirStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(d.dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+// GetRange opens the object file at offset
+func (d *dirStore) GetRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	rc, err := d.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := rc.(*os.File).Seek(offset, io.SeekStart); err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return rc, nil
+}
+
+// Delete removes the object file, and directories it leaves empty
+func (d *dirStore) Delete(ctx context.Context, key string) error {
+	target := filepath.Join(d.dir, filepath.FromSlash(key))
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for dir := filepath.Dir(target); dir != filepath.Clean(d.dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// WriteSnapshot writes a compressed tar of the data directory (a consistent
+// copy of the database and every HNSW graph) to w, ending with a
+// SnapshotManifest of file and document checksums. It is compressed with
+// Config.SnapshotCompression and written as it is produced, so it can be
+// piped to a file, a socket or object storage. Graphs are exported from
+// memory, so unsaved changes are included. The database and graphs are
+// copied one after another; take snapshots between ingestion runs for an
+// exact match.
+func (im *IndexManager) WriteSnapshot(w io.Writer) error {
+	if impl := im.getImpl(); impl != nil {
+		return impl.WriteSnapshot(w)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// UploadSnapshot streams a snapshot to store in parts of
+// Config.SnapshotPartSize, retrying parts that fail, and then stores the
+// SnapshotParts listing them under key. Parts of the snapshot it replaces
+// are deleted if the store is an ObjectDeleter.
+func (im *IndexManager) UploadSnapshot(ctx context.Context, store ObjectStore, key string) error {
+	if impl := im.getImpl(); impl != nil {
+		return impl.uploadSnapshot(ctx, store, key)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// RestoreSnapshot extracts a snapshot written by WriteSnapshot into
+// dataPath, replacing the database and graph files. It must be called
+// before a manager is opened on dataPath. The files are extracted next to
+// dataPath and checked against the snapshot manifest, then the checksum of
+// every document; only a snapshot that matches replaces any file, and one
+// that does not returns ErrSnapshotCorrupt. Snapshots written before
+// manifests were added are restored unchecked. Both zstd and gzip
+// snapshots are read.
+func RestoreSnapshot(r io.Reader, dataPath string) error {
+	zr, err := decompressSnapshot(r)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+	defer zr.Close()
+
+	if err := os.MkdirAll(dataPath, 0755); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(dataPath, ".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	var manifest *SnapshotManifest
+	files := make(map[string]SnapshotFile)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrSnapshotCorrupt, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Name == snapshotManifestName {
+			manifest = &SnapshotManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return fmt.Errorf("%w: invalid manifest: %w", ErrSnapshotCorrupt, err)
+			}
+			continue
+		}
+		if !validSnapshotEntry(hdr.Name) {
+			return fmt.Errorf("invalid snapshot entry %q", hdr.Name)
+		}
+
+		h := sha256.New()
+		target := filepath.Join(staging, filepath.FromSlash(hdr.Name))
+		if err := writeFileAtomic(target, io.TeeReader(tr, h)); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = fmt.Errorf("%w: %w", ErrSnapshotCorrupt, err)
+			}
+			return fmt.Errorf("failed to restore %s: %w", hdr.Name, err)
+		}
+		files[hdr.Name] = SnapshotFile{Size: hdr.Size, SHA256: hex.EncodeToString(h.Sum(nil))}
+	}
+
+	if manifest == nil {
+		slog.Warn("Snapshot has no manifest, restoring it unchecked",
+			"path", dataPath,
+		)
+	} else {
+		if err := manifest.verifyFiles(files); err != nil {
+			return err
+		}
+		if err := manifest.verifyDocuments(staging); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		target := filepath.Join(dataPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(staging, filepath.FromSlash(name)), target); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// verifyFiles checks that the snapshot held exactly the files of the
+// manifest, with their sizes and checksums
+func (m *SnapshotManifest) verifyFiles(files map[string]SnapshotFile) error {
+	var problems []string
+	for name, want := range m.Files {
+		got, ok := files[name]
+		switch {
+		case !ok:
+			problems = append(problems, name+" is missing")
+		case got != want:
+			problems = append(problems, name+" is damaged")
+		}
+	}
+	for name := range files {
+		if _, ok := m.Files[name]; !ok {
+			problems = append(problems, name+" is not in the manifest")
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrSnapshotCorrupt, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// verifyDocuments compares the checksums of the documents extracted into
+// dir with the manifest
+func (m *SnapshotManifest) verifyDocuments(dir string) error {
+	store, err := storage.NewStorage(filepath.Join(dir, "indexes.db"))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshotCorrupt, err)
+	}
+	defer store.Close()
+	sums, err := store.DocumentChecksums()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshotCorrupt, err)
+	}
+	return m.compareDocuments(sums)
+}
+
+// compareDocuments compares document checksums with the manifest
+func (m *SnapshotManifest) compareDocuments(sums storage.DocumentChecksums) error {
+	var problems []string
+	for index, want := range m.Documents {
+		got := sums[index]
+		var missing, damaged []string
+		for uri, sum := range want {
+			if gotSum, ok := got[uri]; !ok {
+				missing = append(missing, uri)
+			} else if gotSum != sum {
+				damaged = append(damaged, uri)
+			}
+		}
+		unexpected := 0
+		for uri := range got {
+			if _, ok := want[uri]; !ok {
+				unexpected++
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("index '%s' is missing %s", index, listURIs(missing)))
+		}
+		if len(damaged) > 0 {
+			problems = append(problems, fmt.Sprintf("index '%s' has damaged %s", index, listURIs(damaged)))
+		}
+		if unexpected > 0 {
+			problems = append(problems, fmt.Sprintf("index '%s' has %d documents not in the manifest", index, unexpected))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrSnapshotCorrupt, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// listURIs describes documents by count and their first few URIs
+func listURIs(uris []string) string {
+	sort.Strings(uris)
+	noun := "documents"
+	if len(uris) == 1 {
+		noun = "document"
+	}
+	shown := uris[:min(len(uris), 3)]
+	more := ""
+	if len(uris) > len(shown) {
+		more = ", ..."
+	}
+	return fmt.Sprintf("%d %s (%s%s)", len(uris), noun, strings.Join(shown, ", "), more)
+}
+
+// DownloadSnapshot restores the snapshot stored under key into dataPath
+// unless dataPath already holds a database, for stateless deployments that
+// hydrate their data directory at startup. It reports whether a snapshot
+// was restored; a missing snapshot is not an error. Snapshots uploaded in
+// parts are streamed part by part, and a part whose download breaks off is
+// resumed from the offset reached. Snapshots stored as a single object, by
+// older versions, are restored too.
+func DownloadSnapshot(ctx context.Context, store ObjectStore, key, dataPath string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(dataPath, "indexes.db")); err == nil {
+		return false, nil
+	}
+
+	start := time.Now()
+	rc, parts, err := openSnapshot(ctx, store, key)
+	if errors.Is(err, ErrObjectNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to download snapshot: %w", err)
+	}
+	partCount := 1
+	if parts != nil {
+		rc = newPartsReader(ctx, store, parts.Parts)
+		partCount = len(parts.Parts)
+	}
+	defer rc.Close()
+
+	if err := RestoreSnapshot(rc, dataPath); err != nil {
+		return false, err
+	}
+	slog.Info("Data directory restored from snapshot",
+		"key", key,
+		"path", dataPath,
+		"parts", partCount,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return true, nil
+}
+
+// checkSnapshotCompression rejects unknown snapshot codecs
+func checkSnapshotCompression(codec string) error {
+	switch codec {
+	case "", SnapshotZstd, SnapshotGzip:
+		return nil
+	}
+	return fmt.Errorf("unsupported snapshot compression %q (supported: zstd, gzip)", codec)
+}
+
+// compressSnapshot returns a writer compressing to w with codec
+func compressSnapshot(w io.Writer, codec string) (io.WriteCloser, error) {
+	if err := checkSnapshotCompression(codec); err != nil {
+		return nil, err
+	}
+	if codec == SnapshotGzip {
+		return gzip.NewWriter(w), nil
+	}
+	return zstd.NewWriter(w)
+}
+
+// decompressSnapshot returns a reader decompressing r, detecting the codec
+// from its first bytes
+func decompressSnapshot(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, zstdMagic):
+		dec, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	default:
+		return nil, errors.New("not a zstd or gzip stream")
+	}
+}
+
+// WriteSnapshot implementation
+func (im *indexManagerImpl) WriteSnapshot(w io.Writer) error {
+	zw, err := compressSnapshot(w, im.config.SnapshotCompression)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	sw := newSnapshotWriter(tar.NewWriter(zw), now)
+
+	sums, err := im.storage.Backup(sw, func(size int64) error {
+		return sw.WriteHeader(&tar.Header{Name: "indexes.db", Mode: 0600, Size: size, ModTime: now})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	sw.addDocuments(sums)
+
+	if err := im.writeGraphs(sw, now); err != nil {
+		return err
+	}
+
+	// Indexes stored in their own database file
+	for _, name := range im.storage.IndexFiles() {
+		sums, err := im.storage.BackupIndex(name, sw, func(size int64) error {
+			return sw.WriteHeader(&tar.Header{Name: path.Join("indexes", storage.IndexDir(name), "index.db"), Mode: 0600, Size: size, ModTime: now})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to snapshot database of '%s': %w", name, err)
+		}
+		sw.addDocuments(sums)
+	}
+
+	if err := sw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// snapshotWriter writes snapshot entries to a tar, recording each in the
+// manifest it adds on Close
+type snapshotWriter struct {
+	tw       *tar.Writer
+	manifest SnapshotManifest
+	name     string    // Entry being written
+	size     int64     // Bytes of it written
+	hash     hash.Hash // Of it
+}
+
+// newSnapshotWriter returns a writer for a snapshot taken at created
+func newSnapshotWriter(tw *tar.Writer, created time.Time) *snapshotWriter {
+	return &snapshotWriter{
+		tw: tw,
+		manifest: SnapshotManifest{
+			Created:   created.UTC(),
+			Files:     make(map[string]SnapshotFile),
+			Documents: make(map[string]map[string]string),
+		},
+	}
+}
+
+// WriteHeader starts an entry
+func (sw *snapshotWriter) WriteHeader(hdr *tar.Header) error {
+	sw.finishEntry()
+	if err := sw.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	sw.name, sw.size, sw.hash = hdr.Name, 0, sha256.New()
+	return nil
+}
+
+// Write writes to the current entry
+func (sw *snapshotWriter) Write(p []byte) (int, error) {
+	n, err := sw.tw.Write(p)
+	sw.hash.Write(p[:n])
+	sw.size += int64(n)
+	return n, err
+}
+
+// finishEntry records the entry written last in the manifest
+func (sw *snapshotWriter) finishEntry() {
+	if sw.name != "" {
+		sw.manifest.Files[sw.name] = SnapshotFile{Size: sw.size, SHA256: hex.EncodeToString(sw.hash.Sum(nil))}
+		sw.name = ""
+	}
+}
+
+// addDocuments records the checksums of the documents of a database
+func (sw *snapshotWriter) addDocuments(sums storage.DocumentChecksums) {
+	for index, docs := range sums {
+		sw.manifest.Documents[index] = docs
+	}
+}
+
+// Close adds the manifest and closes the tar
+func (sw *snapshotWriter) Close() error {
+	sw.finishEntry()
+	data, err := json.Marshal(sw.manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot manifest: %w", err)
+	}
+	hdr := &tar.Header{Name: snapshotManifestName, Mode: 0644, Size: int64(len(data)), ModTime: sw.manifest.Created}
+	if err := sw.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := sw.tw.Write(data); err != nil {
+		return err
+	}
+	return sw.tw.Close()
+}
+
+// writeGraphs adds the graphs of all indexes to the snapshot: those of
+// open indexes from memory, those of closed ones from their files. Indexes
+// are not opened or closed meanwhile.
+func (im *indexManagerImpl) writeGraphs(tw *snapshotWriter, modTime time.Time) error {
+	im.indexes.mu.Lock()
+	defer im.indexes.mu.Unlock()
+
+	for _, idx := range im.indexes.all() {
+		if err := writeGraph(tw, path.Join("indexes", storage.IndexDir(idx.name), "index.hnsw"), idx, modTime); err != nil {
+			return fmt.Errorf("failed to snapshot graph of '%s': %w", idx.name, err)
+		}
+	}
+
+	names, err := im.storage.ListIndexes()
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+	for _, name := range names {
+		if _, open := im.indexes.get(name); open || isRebuildIndex(name) {
+			continue
+		}
+		if err := writeGraphFile(tw, name, im.graphPath(name), modTime); err != nil {
+			return fmt.Errorf("failed to snapshot graph of '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// writeGraphFile adds the saved graph of a closed index to the snapshot.
+// An index closed before anything was added to it has no file.
+func writeGraphFile(tw *snapshotWriter, name, graphPath string, modTime time.Time) error {
+	file, err := os.Open(graphPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Name:    path.Join("indexes", storage.IndexDir(name), "index.hnsw"),
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// writeGraph adds an index's graph to the snapshot as entry name. The
+// graph is exported to a temporary file first because tar needs its size
+// up front.
+func writeGraph(tw *snapshotWriter, name string, idx *indexImpl, modTime time.Time) error {
+	tmp, err := os.CreateTemp("", "hnswindex-graph-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := idx.hnswIndex.Export(tmp); err != nil {
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, tmp)
+	return err
+}
+
+// validSnapshotEntry accepts only the files a snapshot is made of, which
+// also keeps entries from escaping the data directory
+func validSnapshotEntry(name string) bool {
+	if name == "indexes.db" {
+		return true
+	}
+	parts := strings.Split(name, "/")
+	return len(parts) == 3 && parts[0] == "indexes" && (parts[2] == "index.hnsw" || parts[2] == "index.db") &&
+		parts[1] != "" && parts[1] != "." && parts[1] != ".." && !strings.ContainsAny(parts[1], `\`)
+}
+
+// writeFileAtomic writes r to a temporary file next to target and renames
+// it into place
+func writeFileAtomic(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// snapshotter uploads a snapshot after index saves. Saves that happen while
+// an upload runs are coalesced into one follow-up upload.
+type snapshotter struct {
+	manager *indexManagerImpl
+	store   ObjectStore
+	key     string
+
+	mu      sync.Mutex
+	running bool
+	pending bool
+	wg      sync.WaitGroup
+}
+
+// schedule starts an upload, or queues one if an upload is running
+func (s *snapshotter) schedule() {
+	s.mu.Lock()
+	if s.running {
+		s.pending = true
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		for {
+			if err := s.manager.uploadSnapshot(context.Background(), s.store, s.key); err != nil {
+				slog.Error("Failed to upload snapshot",
+					"key", s.key,
+					"error", err,
+				)
+			}
+
+			s.mu.Lock()
+			if !s.pending {
+				s.running = false
+				s.mu.Unlock()
+				return
+			}
+			s.pending = false
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// wait blocks until scheduled uploads have finished
+func (s *snapshotter) wait() {
+	s.wg.Wait()
+}
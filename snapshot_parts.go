@@ -0,0 +1,397 @@
+package hnswindex
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// DefaultSnapshotPartSize is the part size used when
+// Config.SnapshotPartSize is 0
+const DefaultSnapshotPartSize = 64 << 20
+
+// snapshotPartsFormat identifies a SnapshotParts object
+const snapshotPartsFormat = "hnswindex-snapshot-parts/1"
+
+// snapshotAttempts is how often a part upload or download is tried before
+// the transfer fails
+const snapshotAttempts = 5
+
+// snapshotRetryDelay is the pause before the first retry of a part; it
+// doubles with every further attempt
+var snapshotRetryDelay = time.Second
+
+// SnapshotParts is stored under the key of a snapshot uploaded in parts.
+// The snapshot is the concatenation of its parts, which are stored under
+// keys of their own.
+type SnapshotParts struct {
+	Format  string         `json:"format"`
+	Created time.Time      `json:"created"`
+	Size    int64          `json:"size"`
+	Parts   []SnapshotPart `json:"parts"`
+}
+
+// SnapshotPart is a part of a snapshot: its key, where it starts in the
+// snapshot, and its size and SHA-256
+type SnapshotPart struct {
+	Key    string `json:"key"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// RangeGetter is implemented by object stores that can read an object from
+// an offset, so a part download that breaks off resumes where it stopped
+// instead of downloading the part again
+type RangeGetter interface {
+	GetRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) // ErrObjectNotFound if missing
+}
+
+// ObjectDeleter is implemented by object stores that can delete objects,
+// so uploads remove the parts of the snapshot they replace
+type ObjectDeleter interface {
+	Delete(ctx context.Context, key string) error // Deleting a missing object is not an error
+}
+
+// uploadSnapshot streams a snapshot to store in parts and stores the list
+// of parts under key
+func (im *indexManagerImpl) uploadSnapshot(ctx context.Context, store ObjectStore, key string) error {
+	start := time.Now()
+	partSize := im.config.SnapshotPartSize
+	if partSize <= 0 {
+		partSize = DefaultSnapshotPartSize
+	}
+
+	// Parts of each upload get keys of their own, so the snapshot being
+	// replaced stays readable until the new list is stored
+	pw := &partWriter{
+		ctx:      ctx,
+		store:    store,
+		prefix:   fmt.Sprintf("%s.parts/%d/", key, start.UnixNano()),
+		partSize: partSize,
+		hash:     sha256.New(),
+	}
+	defer pw.close()
+	if err := im.WriteSnapshot(pw); err != nil {
+		return err
+	}
+	if err := pw.flush(); err != nil {
+		return err
+	}
+
+	var previous *SnapshotParts
+	if rc, parts, err := openSnapshot(ctx, store, key); err == nil {
+		rc.Close()
+		previous = parts
+	}
+
+	data, err := json.Marshal(SnapshotParts{
+		Format:  snapshotPartsFormat,
+		Created: start.UTC(),
+		Size:    pw.offset,
+		Parts:   pw.parts,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot parts: %w", err)
+	}
+	err = retryTransfer(ctx, key, func() error {
+		return store.Put(ctx, key, bytes.NewReader(data), int64(len(data)))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload snapshot: %w", err)
+	}
+
+	if deleter, ok := store.(ObjectDeleter); ok && previous != nil {
+		for _, part := range previous.Parts {
+			if err := deleter.Delete(ctx, part.Key); err != nil {
+				slog.Warn("Failed to delete part of replaced snapshot",
+					"key", part.Key,
+					"error", err,
+				)
+			}
+		}
+	}
+
+	slog.Info("Snapshot uploaded",
+		"key", key,
+		"bytes", pw.offset,
+		"parts", len(pw.parts),
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return nil
+}
+
+// partWriter uploads what is written to it in parts of partSize, buffering
+// the current part in a temporary file so it can be uploaded again
+type partWriter struct {
+	ctx      context.Context
+	store    ObjectStore
+	prefix   string
+	partSize int64
+
+	tmp    *os.File
+	size   int64     // Bytes of the current part
+	hash   hash.Hash // Of the current part
+	offset int64     // Of the current part in the snapshot
+	parts  []SnapshotPart
+}
+
+// Write buffers p, uploading each part as it fills up
+func (pw *partWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if pw.tmp == nil {
+			tmp, err := os.CreateTemp("", "hnswindex-snapshot-part-*")
+			if err != nil {
+				return written, err
+			}
+			pw.tmp = tmp
+		}
+
+		n := int(min(int64(len(p)), pw.partSize-pw.size))
+		if _, err := pw.tmp.Write(p[:n]); err != nil {
+			return written, err
+		}
+		pw.hash.Write(p[:n])
+		pw.size += int64(n)
+		written += n
+		p = p[n:]
+
+		if pw.size == pw.partSize {
+			if err := pw.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush uploads the current part, if it holds anything
+func (pw *partWriter) flush() error {
+	if pw.size == 0 {
+		return nil
+	}
+
+	part := SnapshotPart{
+		Key:    fmt.Sprintf("%s%05d", pw.prefix, len(pw.parts)),
+		Offset: pw.offset,
+		Size:   pw.size,
+		SHA256: hex.EncodeToString(pw.hash.Sum(nil)),
+	}
+	err := retryTransfer(pw.ctx, part.Key, func() error {
+		if _, err := pw.tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return pw.store.Put(pw.ctx, part.Key, io.LimitReader(pw.tmp, part.Size), part.Size)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload snapshot part %d: %w", len(pw.parts), err)
+	}
+	pw.parts = append(pw.parts, part)
+	pw.offset += part.Size
+
+	pw.size = 0
+	pw.hash.Reset()
+	if err := pw.tmp.Truncate(0); err != nil {
+		return err
+	}
+	_, err = pw.tmp.Seek(0, io.SeekStart)
+	return err
+}
+
+// close removes the temporary file
+func (pw *partWriter) close() {
+	if pw.tmp != nil {
+		pw.tmp.Close()
+		os.Remove(pw.tmp.Name())
+	}
+}
+
+// openSnapshot opens the object under key. For a snapshot uploaded in
+// parts it returns its list of parts as well; otherwise the object is the
+// snapshot itself.
+func openSnapshot(ctx context.Context, store ObjectStore, key string) (io.ReadCloser, *SnapshotParts, error) {
+	var rc io.ReadCloser
+	err := retryTransfer(ctx, key, func() error {
+		var err error
+		rc, err = store.Get(ctx, key)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Snapshots start with a compression magic number, part lists with a
+	// JSON object
+	br := bufio.NewReader(rc)
+	if first, err := br.Peek(1); err != nil || first[0] != '{' {
+		return struct {
+			io.Reader
+			io.Closer
+		}{br, rc}, nil, nil
+	}
+
+	defer rc.Close()
+	var parts SnapshotParts
+	if err := json.NewDecoder(br).Decode(&parts); err != nil {
+		return nil, nil, fmt.Errorf("invalid snapshot parts: %w", err)
+	}
+	if parts.Format != snapshotPartsFormat {
+		return nil, nil, fmt.Errorf("unsupported snapshot parts format %q", parts.Format)
+	}
+	return io.NopCloser(bytes.NewReader(nil)), &parts, nil
+}
+
+// partsReader reads a snapshot uploaded in parts, checking each part's
+// SHA-256. A part whose download fails is opened again at the offset
+// reached, up to snapshotAttempts times in a row.
+type partsReader struct {
+	ctx   context.Context
+	store ObjectStore
+	parts []SnapshotPart
+
+	n        int           // Current part
+	body     io.ReadCloser // Of the current part, from read on
+	read     int64         // Bytes of the current part read
+	hash     hash.Hash     // Of the bytes read
+	failures int           // Reads of the current part failed in a row
+}
+
+// newPartsReader returns a reader of the concatenated parts
+func newPartsReader(ctx context.Context, store ObjectStore, parts []SnapshotPart) *partsReader {
+	return &partsReader{ctx: ctx, store: store, parts: parts, hash: sha256.New()}
+}
+
+// Read reads from the current part, moving on to the next part once it
+// has been read and checked
+func (pr *partsReader) Read(p []byte) (int, error) {
+	for {
+		if pr.n == len(pr.parts) {
+			return 0, io.EOF
+		}
+		part := pr.parts[pr.n]
+
+		if pr.read == part.Size {
+			pr.closeBody()
+			if hex.EncodeToString(pr.hash.Sum(nil)) != part.SHA256 {
+				return 0, fmt.Errorf("%w: part %d (%s) is damaged", ErrSnapshotCorrupt, pr.n, part.Key)
+			}
+			pr.n++
+			pr.read, pr.failures = 0, 0
+			pr.hash.Reset()
+			continue
+		}
+
+		if pr.body == nil {
+			body, err := pr.open(part)
+			if err != nil {
+				return 0, fmt.Errorf("failed to download snapshot part %d: %w", pr.n, err)
+			}
+			pr.body = body
+		}
+
+		n, err := pr.body.Read(p[:min(int64(len(p)), part.Size-pr.read)])
+		pr.hash.Write(p[:n])
+		pr.read += int64(n)
+		if err == io.EOF && pr.read < part.Size {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil && err != io.EOF {
+			pr.closeBody()
+			if pr.failures++; pr.failures == snapshotAttempts {
+				return n, fmt.Errorf("failed to download snapshot part %d: %w", pr.n, err)
+			}
+			slog.Warn("Snapshot part download broke off, resuming",
+				"key", part.Key,
+				"offset", pr.read,
+				"error", err,
+			)
+			if err := sleepContext(pr.ctx, snapshotRetryDelay<<(pr.failures-1)); err != nil {
+				return n, err
+			}
+		} else if n > 0 {
+			pr.failures = 0
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// open opens the current part at the offset read up to
+func (pr *partsReader) open(part SnapshotPart) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := retryTransfer(pr.ctx, part.Key, func() error {
+		var err error
+		if rg, ok := pr.store.(RangeGetter); ok && pr.read > 0 {
+			body, err = rg.GetRange(pr.ctx, part.Key, pr.read)
+			return err
+		}
+		if body, err = pr.store.Get(pr.ctx, part.Key); err != nil {
+			return err
+		}
+		// Skip what has been read already
+		if _, err := io.CopyN(io.Discard, body, pr.read); err != nil {
+			body.Close()
+			return err
+		}
+		return nil
+	})
+	return body, err
+}
+
+// closeBody closes the download of the current part
+func (pr *partsReader) closeBody() {
+	if pr.body != nil {
+		pr.body.Close()
+		pr.body = nil
+	}
+}
+
+// Close closes the download of the current part
+func (pr *partsReader) Close() error {
+	pr.closeBody()
+	return nil
+}
+
+// retryTransfer calls fn until it succeeds, up to snapshotAttempts times
+// with doubling pauses, for transfers over unreliable links. Missing
+// objects are not retried.
+func retryTransfer(ctx context.Context, key string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || errors.Is(err, ErrObjectNotFound) || attempt == snapshotAttempts || ctx.Err() != nil {
+			return err
+		}
+		slog.Warn("Snapshot transfer failed, retrying",
+			"key", key,
+			"attempt", attempt,
+			"error", err,
+		)
+		if err := sleepContext(ctx, snapshotRetryDelay<<(attempt-1)); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
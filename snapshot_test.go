@@ -0,0 +1,414 @@
+package hnswindex
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var snapshotDocs = []Document{
+	{URI: "doc1", Title: "One", Content: "First document content"},
+	{URI: "doc2", Title: "Two", Content: "Second document content"},
+}
+
+func TestSnapshot_UploadAndHydrate(t *testing.T) {
+	store := NewDirStore(t.TempDir())
+
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), snapshotDocs, nil)
+	require.NoError(t, err)
+	require.NoError(t, manager.UploadSnapshot(context.Background(), store, "snapshots/kb.tar.gz"))
+
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.SnapshotStore = store
+	cfg.SnapshotKey = "snapshots/kb.tar.gz"
+	restored := newMockManager(t, cfg)
+
+	index, err = restored.GetIndex("kb")
+	require.NoError(t, err)
+	doc, err := index.GetDocument("doc2")
+	require.NoError(t, err)
+	assert.Equal(t, "Two", doc.Title)
+
+	results, err := index.Search("First document content", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc1", results[0].Document.URI)
+}
+
+func TestSnapshot_MissingOrExistingDataIsLeftAlone(t *testing.T) {
+	store := NewDirStore(t.TempDir())
+	dataPath := t.TempDir()
+
+	restored, err := DownloadSnapshot(context.Background(), store, DefaultSnapshotKey, dataPath)
+	require.NoError(t, err)
+	assert.False(t, restored)
+
+	require.NoError(t, store.Put(context.Background(), DefaultSnapshotKey, bytes.NewReader([]byte("x")), 1))
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "indexes.db"), nil, 0600))
+	restored, err = DownloadSnapshot(context.Background(), store, DefaultSnapshotKey, dataPath)
+	require.NoError(t, err)
+	assert.False(t, restored)
+}
+
+func TestSnapshot_OnSave(t *testing.T) {
+	dir := t.TempDir()
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.SnapshotStore = NewDirStore(dir)
+	cfg.SnapshotOnSave = true
+
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), snapshotDocs, nil)
+	require.NoError(t, err)
+	require.NoError(t, manager.Close()) // Waits for the upload
+
+	_, err = os.Stat(filepath.Join(dir, DefaultSnapshotKey))
+	assert.NoError(t, err)
+}
+
+func TestRestoreSnapshot_RejectsUnexpectedEntries(t *testing.T) {
+	for _, name := range []string{"../evil", "indexes/../../evil/index.hnsw", "indexes/kb/other", "config.yaml"} {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: 1, Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte("x"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gz.Close())
+
+		err = RestoreSnapshot(&buf, t.TempDir())
+		assert.ErrorContains(t, err, "invalid snapshot entry", name)
+	}
+}
+
+func TestSnapshot_PerIndexLayout(t *testing.T) {
+	cfg := NewConfig()
+	cfg.StorageLayout = "per_index"
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	_, err = index.AddDocumentBatch(context.Background(), snapshotDocs, nil)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(cfg.DataPath, "indexes", "kb", "index.db"))
+
+	var buf bytes.Buffer
+	require.NoError(t, manager.WriteSnapshot(&buf))
+
+	restoredCfg := NewConfig()
+	restoredCfg.DataPath = t.TempDir()
+	require.NoError(t, RestoreSnapshot(&buf, restoredCfg.DataPath))
+	restored := newMockManager(t, restoredCfg)
+
+	index, err = restored.GetIndex("kb")
+	require.NoError(t, err)
+	doc, err := index.GetDocument("doc1")
+	require.NoError(t, err)
+	assert.Equal(t, "One", doc.Title)
+
+	require.NoError(t, manager.DeleteIndex("kb"))
+	assert.NoFileExists(t, filepath.Join(cfg.DataPath, "indexes", "kb", "index.db"))
+}
+
+// snapshotEntry is a file of a snapshot
+type snapshotEntry struct {
+	name string
+	data []byte
+}
+
+// readSnapshotEntries returns the files of a snapshot in order
+func readSnapshotEntries(t *testing.T, snapshot []byte) []snapshotEntry {
+	zr, err := decompressSnapshot(bytes.NewReader(snapshot))
+	require.NoError(t, err)
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	var entries []snapshotEntry
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries = append(entries, snapshotEntry{name: hdr.Name, data: data})
+	}
+}
+
+// writeSnapshotEntries writes files as a snapshot
+func writeSnapshotEntries(t *testing.T, entries []snapshotEntry) *bytes.Buffer {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0600, Size: int64(len(e.data)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(e.data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return &buf
+}
+
+func TestSnapshot_Manifest(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, snapshotDocs...)
+
+	var buf bytes.Buffer
+	require.NoError(t, manager.WriteSnapshot(&buf))
+	entries := readSnapshotEntries(t, buf.Bytes())
+	require.NotEmpty(t, entries)
+
+	last := entries[len(entries)-1]
+	require.Equal(t, snapshotManifestName, last.name, "the manifest comes last")
+	var manifest SnapshotManifest
+	require.NoError(t, json.Unmarshal(last.data, &manifest))
+	assert.Len(t, manifest.Files, len(entries)-1)
+	for _, e := range entries[:len(entries)-1] {
+		assert.Equal(t, int64(len(e.data)), manifest.Files[e.name].Size, e.name)
+	}
+	assert.Contains(t, manifest.Files, "indexes/kb/index.hnsw")
+	require.Contains(t, manifest.Documents, "kb")
+	assert.Len(t, manifest.Documents["kb"], 2)
+
+	// The snapshot restores cleanly
+	dataPath := t.TempDir()
+	require.NoError(t, RestoreSnapshot(bytes.NewReader(buf.Bytes()), dataPath))
+	dirEntries, err := os.ReadDir(dataPath)
+	require.NoError(t, err)
+	for _, e := range dirEntries {
+		assert.NotContains(t, e.Name(), ".restore-", "staging is cleaned up")
+	}
+}
+
+func TestRestoreSnapshot_DetectsCorruption(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, snapshotDocs...)
+	var buf bytes.Buffer
+	require.NoError(t, manager.WriteSnapshot(&buf))
+	snapshot := buf.Bytes()
+
+	corrupt := func(t *testing.T, r io.Reader, want string) {
+		dataPath := t.TempDir()
+		err := RestoreSnapshot(r, dataPath)
+		require.ErrorIs(t, err, ErrSnapshotCorrupt)
+		assert.ErrorContains(t, err, want)
+		assert.NoFileExists(t, filepath.Join(dataPath, "indexes.db"), "nothing is restored")
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		corrupt(t, bytes.NewReader(snapshot[:len(snapshot)*2/3]), "")
+	})
+
+	t.Run("damaged file", func(t *testing.T) {
+		entries := readSnapshotEntries(t, snapshot)
+		for n := range entries {
+			if entries[n].name == "indexes/kb/index.hnsw" {
+				entries[n].data[len(entries[n].data)/2] ^= 0xff
+			}
+		}
+		corrupt(t, writeSnapshotEntries(t, entries), "indexes/kb/index.hnsw is damaged")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var entries []snapshotEntry
+		for _, e := range readSnapshotEntries(t, snapshot) {
+			if e.name != "indexes/kb/index.hnsw" {
+				entries = append(entries, e)
+			}
+		}
+		corrupt(t, writeSnapshotEntries(t, entries), "indexes/kb/index.hnsw is missing")
+	})
+
+	t.Run("documents", func(t *testing.T) {
+		entries := readSnapshotEntries(t, snapshot)
+		last := &entries[len(entries)-1]
+		var manifest SnapshotManifest
+		require.NoError(t, json.Unmarshal(last.data, &manifest))
+		manifest.Documents["kb"]["doc3"] = "0000"
+		manifest.Documents["kb"]["doc1"] = "0000"
+		last.data, err = json.Marshal(manifest)
+		require.NoError(t, err)
+		corrupt(t, writeSnapshotEntries(t, entries), "index 'kb' is missing 1 document (doc3)")
+		corrupt(t, writeSnapshotEntries(t, entries), "index 'kb' has damaged 1 document (doc1)")
+	})
+
+	t.Run("without manifest", func(t *testing.T) {
+		entries := readSnapshotEntries(t, snapshot)
+		dataPath := t.TempDir()
+		require.NoError(t, RestoreSnapshot(writeSnapshotEntries(t, entries[:len(entries)-1]), dataPath))
+		assert.FileExists(t, filepath.Join(dataPath, "indexes.db"), "older snapshots restore unchecked")
+	})
+}
+
+func TestSnapshot_Compression(t *testing.T) {
+	for codec, magic := range map[string][]byte{"": zstdMagic, SnapshotZstd: zstdMagic, SnapshotGzip: gzipMagic} {
+		cfg := NewConfig()
+		cfg.SnapshotCompression = codec
+		manager := newMockManager(t, cfg)
+		index, err := manager.CreateIndex("kb")
+		require.NoError(t, err)
+		addDocuments(t, index, snapshotDocs...)
+
+		var buf bytes.Buffer
+		require.NoError(t, manager.WriteSnapshot(&buf))
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), magic), codec)
+
+		dataPath := t.TempDir()
+		require.NoError(t, RestoreSnapshot(&buf, dataPath), codec)
+		assert.FileExists(t, filepath.Join(dataPath, "indexes.db"))
+	}
+
+	cfg := NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.SnapshotCompression = "lz4"
+	_, err := NewIndexManager(cfg)
+	assert.ErrorContains(t, err, "unsupported snapshot compression")
+}
+
+// flakyStore fails every other upload and cuts every part download short
+type flakyStore struct {
+	ObjectStore
+	mu   sync.Mutex
+	puts int
+	cut  int64 // Bytes a part download delivers before failing
+	gets int   // Part downloads
+}
+
+func (f *flakyStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	f.mu.Lock()
+	f.puts++
+	fail := f.puts%2 == 1
+	f.mu.Unlock()
+	if fail {
+		io.CopyN(io.Discard, r, size/2)
+		return errors.New("connection reset")
+	}
+	return f.ObjectStore.Put(ctx, key, r, size)
+}
+
+func (f *flakyStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return f.GetRange(ctx, key, 0)
+}
+
+func (f *flakyStore) GetRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	rc, err := f.ObjectStore.(RangeGetter).GetRange(ctx, key, offset)
+	if err != nil || !strings.Contains(key, ".parts/") {
+		return rc, err
+	}
+	f.mu.Lock()
+	f.gets++
+	f.mu.Unlock()
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(io.LimitReader(rc, f.cut), iotest.ErrReader(errors.New("connection reset"))), rc}, nil
+}
+
+func (f *flakyStore) Delete(ctx context.Context, key string) error {
+	return f.ObjectStore.(ObjectDeleter).Delete(ctx, key)
+}
+
+func TestSnapshot_UploadInParts(t *testing.T) {
+	defer func(delay time.Duration) { snapshotRetryDelay = delay }(snapshotRetryDelay)
+	snapshotRetryDelay = time.Millisecond
+
+	dir := t.TempDir()
+	store := &flakyStore{ObjectStore: NewDirStore(dir), cut: 300}
+	cfg := NewConfig()
+	cfg.SnapshotPartSize = 1000
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, snapshotDocs...)
+
+	ctx := context.Background()
+	require.NoError(t, manager.UploadSnapshot(ctx, store, "kb"), "failed parts are uploaded again")
+	rc, parts, err := openSnapshot(ctx, store, "kb")
+	require.NoError(t, err)
+	rc.Close()
+	require.NotNil(t, parts)
+	require.Greater(t, len(parts.Parts), 1)
+	var offset int64
+	for _, part := range parts.Parts {
+		assert.Equal(t, offset, part.Offset)
+		assert.LessOrEqual(t, part.Size, cfg.SnapshotPartSize)
+		offset += part.Size
+	}
+	assert.Equal(t, offset, parts.Size)
+
+	// Downloads that break off resume at the offset reached
+	dataPath := t.TempDir()
+	restored, err := DownloadSnapshot(ctx, store, "kb", dataPath)
+	require.NoError(t, err)
+	require.True(t, restored)
+	assert.Greater(t, store.gets, len(parts.Parts))
+
+	cfg.DataPath = dataPath
+	index, err = newMockManager(t, cfg).GetIndex("kb")
+	require.NoError(t, err)
+	doc, err := index.GetDocument("doc2")
+	require.NoError(t, err)
+	assert.Equal(t, "Two", doc.Title)
+
+	// A new upload deletes the parts of the snapshot it replaces
+	require.NoError(t, manager.UploadSnapshot(ctx, store, "kb"))
+	for _, part := range parts.Parts {
+		assert.NoFileExists(t, filepath.Join(dir, filepath.FromSlash(part.Key)))
+	}
+
+	// A damaged part fails the restore
+	rc, parts, err = openSnapshot(ctx, store, "kb")
+	require.NoError(t, err)
+	rc.Close()
+	partPath := filepath.Join(dir, filepath.FromSlash(parts.Parts[0].Key))
+	data, err := os.ReadFile(partPath)
+	require.NoError(t, err)
+	data[len(data)/2] ^= 0xff
+	require.NoError(t, os.WriteFile(partPath, data, 0644))
+	_, err = DownloadSnapshot(ctx, store, "kb", t.TempDir())
+	assert.ErrorIs(t, err, ErrSnapshotCorrupt)
+}
+
+func TestSnapshot_SingleObject(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index, snapshotDocs...)
+
+	// Snapshots stored whole, as older versions did, are restored too
+	var buf bytes.Buffer
+	require.NoError(t, manager.WriteSnapshot(&buf))
+	store := NewDirStore(t.TempDir())
+	require.NoError(t, store.Put(context.Background(), DefaultSnapshotKey, &buf, int64(buf.Len())))
+
+	dataPath := t.TempDir()
+	restored, err := DownloadSnapshot(context.Background(), store, DefaultSnapshotKey, dataPath)
+	require.NoError(t, err)
+	assert.True(t, restored)
+	assert.FileExists(t, filepath.Join(dataPath, "indexes.db"))
+}
@@ -0,0 +1,168 @@
+package hnswindex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultSnippetLength is the snippet length, in characters, used when
+// SearchOptions.SnippetLength is 0
+const DefaultSnippetLength = 200
+
+// Span locates part of a text, from byte offset Start up to End
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// snippetLength returns the snippet length, applying the default
+func (o SearchOptions) snippetLength() int {
+	if o.SnippetLength <= 0 {
+		return DefaultSnippetLength
+	}
+	return o.SnippetLength
+}
+
+// textWord is a lowercased word of a text with its offsets in runes and
+// bytes
+type textWord struct {
+	word               string
+	start, end         int // Runes
+	byteStart, byteEnd int
+}
+
+// isWordRune reports whether r is part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// textWords splits text into its words of letters and digits
+func textWords(text string) []textWord {
+	var words []textWord
+	var word *textWord
+	runes := 0
+	for pos, r := range text {
+		switch {
+		case isWordRune(r) && word == nil:
+			word = &textWord{start: runes, byteStart: pos}
+		case !isWordRune(r) && word != nil:
+			word.end, word.byteEnd = runes, pos
+			word.word = strings.ToLower(text[word.byteStart:pos])
+			words = append(words, *word)
+			word = nil
+		}
+		runes++
+	}
+	if word != nil {
+		word.end, word.byteEnd = runes, len(text)
+		word.word = strings.ToLower(text[word.byteStart:])
+		words = append(words, *word)
+	}
+	return words
+}
+
+// matchTerm returns the query keyword word matches: the keyword itself or,
+// for keywords of four characters or more, a word it begins, so "index"
+// matches "indexes"
+func matchTerm(word string, terms map[string]bool) (string, bool) {
+	if terms[word] {
+		return word, true
+	}
+	for term := range terms {
+		if len([]rune(term)) >= 4 && strings.HasPrefix(word, term) {
+			return term, true
+		}
+	}
+	return "", false
+}
+
+// highlightSpans returns the spans of the words of text matching the query
+// keywords
+func highlightSpans(text string, terms map[string]bool) []Span {
+	var spans []Span
+	for _, w := range textWords(text) {
+		if _, ok := matchTerm(w.word, terms); ok {
+			spans = append(spans, Span{Start: w.byteStart, End: w.byteEnd})
+		}
+	}
+	return spans
+}
+
+// snippet returns the window of text, on one line, of at most n characters
+// holding the most distinct query keywords, and then the most keywords,
+// with the keywords centered in it. The window starts and ends at word
+// boundaries and Ellipsis marks where text was cut. Text without keywords
+// yields its beginning.
+func snippet(text string, terms map[string]bool, n int) string {
+	line := strings.Join(strings.Fields(text), " ")
+	runes := []rune(line)
+	if len(runes) <= n {
+		return line
+	}
+
+	var matches []textWord
+	var matched []string
+	for _, w := range textWords(line) {
+		if term, ok := matchTerm(w.word, terms); ok {
+			matches = append(matches, w)
+			matched = append(matched, term)
+		}
+	}
+
+	from := 0
+	if len(matches) > 0 {
+		first, last := 0, 0
+		bestDistinct, bestCount := 0, 0
+		for a := range matches {
+			seen := make(map[string]bool)
+			for b := a; b < len(matches) && matches[b].end-matches[a].start <= n; b++ {
+				seen[matched[b]] = true
+				count := b - a + 1
+				if len(seen) > bestDistinct || (len(seen) == bestDistinct && count > bestCount) {
+					first, last = a, b
+					bestDistinct, bestCount = len(seen), count
+				}
+			}
+		}
+		start, end := matches[first].start, matches[last].end
+		from = max(0, start-(n-(end-start))/2)
+		from = min(from, len(runes)-n)
+	}
+	to := min(from+n, len(runes))
+
+	// Do not cut words in two
+	for from > 0 && from < to && isWordRune(runes[from-1]) && isWordRune(runes[from]) {
+		from++
+	}
+	for to < len(runes) && to > from && isWordRune(runes[to-1]) && isWordRune(runes[to]) {
+		to--
+	}
+
+	s := strings.TrimSpace(string(runes[from:to]))
+	if from > 0 {
+		s = Ellipsis + s
+	}
+	if to < len(runes) {
+		s += Ellipsis
+	}
+	return s
+}
+
+// addSnippet sets the snippet and highlights of a result for
+// SearchOptions.Snippets
+func addSnippet(r *SearchResult, query string, options SearchOptions) {
+	if !options.Snippets {
+		return
+	}
+	terms := keywordTerms(query)
+	r.Snippet = snippet(r.ChunkText, terms, options.snippetLength())
+	r.SnippetHighlights = highlightSpans(r.Snippet, terms)
+	r.ChunkHighlights = highlightSpans(r.ChunkText, terms)
+}
+
+// addSnippets calls addSnippet for each result
+func addSnippets(results []SearchResult, query string, options SearchOptions) {
+	for idx := range results {
+		addSnippet(&results[idx], query, options)
+	}
+}
@@ -0,0 +1,93 @@
+package hnswindex
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// spanTexts returns the parts of text at spans
+func spanTexts(text string, spans []Span) []string {
+	var parts []string
+	for _, s := range spans {
+		parts = append(parts, text[s.Start:s.End])
+	}
+	return parts
+}
+
+func TestHighlightSpans(t *testing.T) {
+	text := "Die Größe des Index: indexes are rebuilt, and the INDEX is saved."
+	spans := highlightSpans(text, keywordTerms("index größe"))
+	assert.Equal(t, []string{"Größe", "Index", "indexes", "INDEX"}, spanTexts(text, spans))
+
+	// Short keywords match whole words only
+	assert.Equal(t, []string{"API"}, spanTexts("API of APIs", highlightSpans("API of APIs", keywordTerms("api"))))
+	assert.Empty(t, highlightSpans(text, keywordTerms("the of")))
+}
+
+func TestSnippet(t *testing.T) {
+	terms := keywordTerms("failover replica")
+	assert.Equal(t, "Short text", snippet("Short\n\ntext", terms, 50))
+
+	filler := strings.Repeat("Unrelated words fill this part of the chunk. ", 10)
+	text := filler + "Promote a replica\nduring failover, then rebuild it. " + filler + "Another replica here."
+	got := snippet(text, terms, 60)
+	assert.True(t, strings.HasPrefix(got, Ellipsis), got)
+	assert.True(t, strings.HasSuffix(got, Ellipsis), got)
+	assert.Contains(t, got, "Promote a replica during failover", "the window with both keywords wins")
+	assert.LessOrEqual(t, utf8.RuneCountInString(got), 60+2*len(Ellipsis))
+	for _, word := range strings.Fields(strings.Trim(got, Ellipsis)) {
+		assert.Contains(t, text, word, "words are not cut")
+	}
+
+	// Without keywords the snippet is the beginning of the text
+	got = snippet(text, keywordTerms("nothing matches"), 30)
+	assert.Equal(t, "Unrelated words fill this part...", got)
+
+	// Multi-byte characters are never split
+	text = strings.Repeat("ü", 100) + " Größe " + strings.Repeat("ö", 100)
+	got = snippet(text, keywordTerms("größe"), 20)
+	assert.True(t, utf8.ValidString(got))
+	assert.Contains(t, got, "Größe")
+}
+
+func TestSearchWithOptions_Snippets(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 1000 // One chunk
+	manager := newMockManager(t, cfg)
+	index, err := manager.CreateIndex("snippets")
+	require.NoError(t, err)
+	content := strings.Repeat("Background material nobody asked about. ", 8) +
+		"To fail over, promote the replica and point clients at it. " +
+		strings.Repeat("More background material. ", 8)
+	_, err = index.AddDocumentBatch(context.Background(), []Document{{URI: "runbook", Title: "Runbook", Content: content}}, nil)
+	require.NoError(t, err)
+
+	plain, err := index.Search("promote the replica", 1)
+	require.NoError(t, err)
+	require.Len(t, plain, 1)
+	assert.Empty(t, plain[0].Snippet)
+	assert.Nil(t, plain[0].ChunkHighlights)
+
+	options := SearchOptions{Snippets: true, SnippetLength: 40}
+	results, err := index.SearchWithOptions("promote the replica", 1, options)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	r := results[0]
+	assert.Contains(t, r.Snippet, "promote the replica")
+	assert.Equal(t, []string{"promote", "replica"}, spanTexts(r.Snippet, r.SnippetHighlights))
+	assert.Equal(t, []string{"promote", "replica"}, spanTexts(r.ChunkText, r.ChunkHighlights))
+
+	for result, err := range index.SearchIterWithOptions("promote the replica", 1, options) {
+		require.NoError(t, err)
+		assert.Equal(t, r.Snippet, result.Snippet)
+	}
+	fused, err := index.SearchMultiWithOptions([]string{"promote", "replica"}, 1, FusionRRF, options)
+	require.NoError(t, err)
+	require.Len(t, fused, 1)
+	assert.Len(t, fused[0].SnippetHighlights, 2, "keywords of every query are highlighted")
+}
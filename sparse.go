@@ -0,0 +1,144 @@
+package hnswindex
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/indexer"
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// SparseEmbedder generates sparse embeddings, weights of vocabulary terms
+// such as those of SPLADE models, for the lexical leg of hybrid search
+// (SearchOptions.SparseWeight). SparseDocuments embeds document chunks and
+// SparseQuery search queries. Terms of zero weight may be left out.
+type SparseEmbedder interface {
+	SparseDocuments(texts []string) ([]map[string]float32, error)
+	SparseQuery(text string) (map[string]float32, error)
+}
+
+// SetSparseEmbedder sets the sparse embedder. Chunks written from then on
+// also get a sparse embedding, stored in an inverted index next to the
+// graph; chunks written before have none until their document is updated
+// or the index rebuilt. Call it before filling or searching indexes.
+func (im *IndexManager) SetSparseEmbedder(e SparseEmbedder) {
+	if impl := im.getImpl(); impl != nil {
+		impl.sparse = e
+	}
+}
+
+// sparseVectors embeds chunk texts with the sparse embedder. All entries
+// are nil without one.
+func (im *indexManagerImpl) sparseVectors(texts []string) ([]map[string]float32, error) {
+	if im.sparse == nil {
+		return make([]map[string]float32, len(texts)), nil
+	}
+	vectors, err := im.sparse.SparseDocuments(texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate sparse embeddings: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("sparse embedder returned %d embeddings for %d texts", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// hasChunkVectors reports whether a stored chunk has the sub-chunk and
+// sparse vectors the manager would give it, so they can be reused
+func (im *indexManagerImpl) hasChunkVectors(c *storage.Chunk) bool {
+	return im.hasLateVectors(c) && (im.sparse == nil || c.Sparse != nil)
+}
+
+// sparseWeight returns the share of the sparse score in a blended score
+func (o SearchOptions) sparseWeight() float64 {
+	return min(o.SparseWeight, 1)
+}
+
+// sparseCandidates searches the sparse index for up to limit chunks and
+// adds those the graph did not return to hits, with their dense scores.
+// It returns the hits and the sparse score of each matched hit by graph ID.
+func (i *indexImpl) sparseCandidates(query string, embedding []float32, hits []indexer.SearchResult, limit int, timing *SearchTiming) ([]indexer.SearchResult, map[uint64]float64, error) {
+	if i.manager.sparse == nil {
+		return nil, nil, errors.New("sparse search: no sparse embedder set")
+	}
+	start := time.Now()
+	vector, err := i.manager.sparse.SparseQuery(query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sparse search: failed to embed query: %w", err)
+	}
+	timing.Embed += time.Since(start)
+
+	start = time.Now()
+	matches, err := i.manager.storage.SearchSparse(i.name, vector, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sparse search: %w", err)
+	}
+	found := make(map[uint64]bool, len(hits))
+	for _, hr := range hits {
+		found[hr.ID] = true
+	}
+	sparse := make(map[uint64]float64, len(matches))
+	embedding = i.graphVector(embedding)
+	for _, m := range matches {
+		sparse[m.HNSWId] = m.Score
+		if found[m.HNSWId] {
+			continue
+		}
+		distance, ok := i.hnswIndex.Distance(embedding, m.HNSWId)
+		if !ok {
+			continue // Deleted since it was written
+		}
+		hits = append(hits, indexer.SearchResult{ID: m.HNSWId, Score: i.graphScore(distance), Distance: distance})
+	}
+	timing.Graph += time.Since(start)
+	return hits, sparse, nil
+}
+
+// blendSparse mixes the sparse score of each hit, relative to the best
+// sparse score, into its score: (1-weight) times the score so far plus
+// weight times the sparse score. It records the changes in scores and
+// reorders the hits.
+func blendSparse(hits []indexer.SearchResult, sparse map[uint64]float64, weight float64, scores *hitScores) {
+	var best float64
+	for _, score := range sparse {
+		best = max(best, score)
+	}
+
+	deltas := make(map[uint64]float64, len(hits))
+	for _, hr := range hits {
+		var relative float64
+		if best > 0 {
+			relative = sparse[hr.ID] / best
+		}
+		deltas[hr.ID] = weight * (relative - scores.score(hr))
+	}
+	scores.sparse = deltas
+	sort.SliceStable(hits, func(a, b int) bool {
+		return scores.score(hits[a]) > scores.score(hits[b])
+	})
+}
+
+// graphScore converts a graph distance into a score as the graph's search
+// does
+func (i *indexImpl) graphScore(distance float32) float32 {
+	if i.hnswIndex.DistanceType() == "cosine" {
+		return 1 - distance/2
+	}
+	return 1 / (1 + distance)
+}
+
+// applySparse changes the score of a hydrated result by its blend with
+// the sparse score
+func applySparse(result *SearchResult, delta float64) {
+	result.Score += delta
+	if result.Explain != nil {
+		result.Explain.Score = result.Score
+		result.Explain.Adjustments = append(result.Explain.Adjustments, ScoreAdjustment{
+			Stage:  "sparse",
+			Delta:  delta,
+			Reason: "blended with the sparse (lexical) score",
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package hnswindex
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch_Sparse(t *testing.T) {
+	manager := newMockManager(t, nil)
+	// Hashed embeddings leave the dense ranking to chance
+	manager.SetEmbedder(embedtest.New(64))
+	manager.SetSparseEmbedder(embedtest.NewSparse())
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	var docs []Document
+	for n := 0; n < 40; n++ {
+		docs = append(docs, Document{URI: fmt.Sprintf("doc%d", n), Content: fmt.Sprintf("Release notes for version %d.", n)})
+	}
+	docs = append(docs, Document{URI: "error", Content: "Error E4021 means the sync token expired."})
+	addDocuments(t, index, docs...)
+
+	for chunk := range index.Chunks("error") {
+		assert.Equal(t, float32(1), chunk.Sparse["e4021"])
+	}
+
+	results, err := index.SearchWithOptions("what is E4021", 1, SearchOptions{
+		Explain:      true,
+		SparseWeight: 0.7,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "error", results[0].Document.URI)
+	var stages []string
+	for _, adj := range results[0].Explain.Adjustments {
+		stages = append(stages, adj.Stage)
+	}
+	assert.Contains(t, stages, "sparse")
+
+	// Deleted chunks leave the sparse index
+	require.NoError(t, index.DeleteDocument("error"))
+	hits, err := manager.getImpl().storage.SearchSparse("kb", map[string]float32{"e4021": 1}, 5)
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+	results, err = index.SearchWithOptions("what is E4021", 5, SearchOptions{SparseWeight: 1})
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.NotEqual(t, "error", r.Document.URI)
+	}
+
+	// Sparse search needs a sparse embedder
+	plain := newMockManager(t, nil)
+	other, err := plain.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, other, Document{URI: "a", Content: "text"})
+	_, err = other.SearchWithOptions("text", 1, SearchOptions{SparseWeight: 0.5})
+	assert.ErrorContains(t, err, "no sparse embedder")
+}
@@ -0,0 +1,197 @@
+package hnswindex
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// StatsDistribution describes how content is spread across an index.
+// It is useful for spotting chunking misconfiguration on a given corpus,
+// e.g. a chunk size that splits every document into hundreds of pieces.
+type StatsDistribution struct {
+	ChunksPerDocument  DistributionSummary `json:"chunks_per_document"`
+	ChunkTokens        DistributionSummary `json:"chunk_tokens"`
+	EmbeddingNorms     DistributionSummary `json:"embedding_norms"`
+	EmptyDocuments     int                 `json:"empty_documents"`      // Documents without any chunks
+	ZeroNormEmbeddings int                 `json:"zero_norm_embeddings"` // Chunks with an all-zero embedding
+}
+
+// Distribution returns how chunks and embeddings are spread across the
+// index. It tokenizes every chunk and reads every embedding, so Stats
+// leaves it out; the result is kept until the index changes, which for a
+// sealed index means it is computed once.
+func (i *Index) Distribution() (*StatsDistribution, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Distribution()
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Distribution implementation
+func (i *indexImpl) Distribution() (*StatsDistribution, error) {
+	version, err := i.distributionVersion()
+	if err != nil {
+		return nil, err
+	}
+	c := &i.distribution
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dist != nil && c.version == version {
+		return c.dist, nil
+	}
+	dist, err := i.computeDistribution()
+	if err != nil {
+		return nil, err
+	}
+	c.dist, c.version = dist, version
+	return dist, nil
+}
+
+// distributionCache holds the last distribution of an index with the
+// version of the index it describes
+type distributionCache struct {
+	mu      sync.Mutex
+	version distributionVersion
+	dist    *StatsDistribution
+}
+
+// distributionVersion identifies the state of an index's chunks. The
+// change sequence moves with every document written or deleted; clearing
+// an index also updates LastUpdated and the chunk count.
+type distributionVersion struct {
+	changeSeq   uint64
+	lastUpdated time.Time
+	chunkCount  int
+}
+
+// distributionVersion returns the current version of the index's chunks
+func (i *indexImpl) distributionVersion() (distributionVersion, error) {
+	seq, err := i.manager.storage.LatestChangeSeq(i.name)
+	if err != nil {
+		return distributionVersion{}, err
+	}
+	metadata, err := i.manager.storage.GetIndexMetadata(i.name)
+	if err != nil {
+		return distributionVersion{}, err
+	}
+	return distributionVersion{
+		changeSeq:   seq,
+		lastUpdated: metadata.LastUpdated,
+		chunkCount:  metadata.ChunkCount,
+	}, nil
+}
+
+// DistributionSummary summarizes a set of observations
+type DistributionSummary struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	P50    float64 `json:"p50"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+}
+
+// summarize computes a DistributionSummary for the given values
+func summarize(values []float64) DistributionSummary {
+	if len(values) == 0 {
+		return DistributionSummary{}
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / float64(len(sorted))
+
+	var variance float64
+	for _, v := range sorted {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(sorted))
+
+	return DistributionSummary{
+		Count:  len(sorted),
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Mean:   mean,
+		StdDev: math.Sqrt(variance),
+		P50:    percentile(sorted, 0.50),
+		P90:    percentile(sorted, 0.90),
+		P99:    percentile(sorted, 0.99),
+	}
+}
+
+// percentile returns the nearest-rank percentile of an ascending slice
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// vectorNorm returns the L2 norm of a vector
+func vectorNorm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// computeDistribution scans all chunks of the index and computes
+// distribution stats
+func (i *indexImpl) computeDistribution() (*StatsDistribution, error) {
+	counts, err := i.manager.storage.CountChunksByDocument(i.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count chunks: %w", err)
+	}
+
+	dist := &StatsDistribution{}
+	perDoc := make([]float64, 0, len(counts))
+	for _, n := range counts {
+		if n == 0 {
+			dist.EmptyDocuments++
+		}
+		perDoc = append(perDoc, float64(n))
+	}
+
+	var tokens, norms []float64
+	err = i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		tokens = append(tokens, float64(i.manager.chunker.CountTokens(chunk.Text)))
+		if len(chunk.Embedding) > 0 {
+			norm := vectorNorm(chunk.Embedding)
+			if norm == 0 {
+				dist.ZeroNormEmbeddings++
+			}
+			norms = append(norms, norm)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan chunks: %w", err)
+	}
+
+	dist.ChunksPerDocument = summarize(perDoc)
+	dist.ChunkTokens = summarize(tokens)
+	dist.EmbeddingNorms = summarize(norms)
+
+	return dist, nil
+}
@@ -0,0 +1,109 @@
+package hnswindex
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	s := summarize([]float64{5, 1, 3, 2, 4})
+
+	assert.Equal(t, 5, s.Count)
+	assert.Equal(t, 1.0, s.Min)
+	assert.Equal(t, 5.0, s.Max)
+	assert.Equal(t, 3.0, s.Mean)
+	assert.Equal(t, 3.0, s.P50)
+	assert.Equal(t, 5.0, s.P90)
+	assert.InDelta(t, 1.414, s.StdDev, 0.001)
+
+	assert.Equal(t, DistributionSummary{}, summarize(nil))
+}
+
+func TestVectorNorm(t *testing.T) {
+	assert.Equal(t, 5.0, vectorNorm([]float32{3, 4}))
+	assert.Equal(t, 0.0, vectorNorm([]float32{0, 0}))
+}
+
+func TestStats_Distribution(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 50
+	cfg.ChunkOverlap = 10
+	manager := newMockManager(t, cfg)
+
+	index, err := manager.CreateIndex("stats")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "doc1", Title: "Long", Content: generateLongText(200)},
+		{URI: "doc2", Title: "Short", Content: "Short document."},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	d, err := index.Distribution()
+	require.NoError(t, err)
+	assert.Equal(t, 2, d.ChunksPerDocument.Count)
+	assert.Equal(t, 1.0, d.ChunksPerDocument.Min)
+	assert.Greater(t, d.ChunksPerDocument.Max, 1.0)
+	assert.Greater(t, d.ChunkTokens.Mean, 0.0)
+	assert.LessOrEqual(t, d.ChunkTokens.Max, 50.0)
+	assert.Greater(t, d.EmbeddingNorms.Mean, 0.0)
+	assert.Equal(t, 0, d.EmptyDocuments)
+
+	// The distribution is kept until the index changes
+	again, err := index.Distribution()
+	require.NoError(t, err)
+	assert.Same(t, d, again)
+	require.NoError(t, index.DeleteDocument("doc2"))
+	again, err = index.Distribution()
+	require.NoError(t, err)
+	assert.NotSame(t, d, again)
+	assert.Equal(t, 1, again.ChunksPerDocument.Count)
+}
+
+func TestStats_Times(t *testing.T) {
+	manager := newMockManager(t, nil)
+	before := time.Now().Add(-time.Second)
+	index, err := manager.CreateIndex("times")
+	require.NoError(t, err)
+
+	stats, err := index.Stats()
+	require.NoError(t, err)
+	assert.True(t, stats.CreatedAt.After(before))
+	assert.True(t, stats.LastUpdated.IsZero())
+	assert.True(t, stats.LastSavedAt.IsZero())
+	assert.True(t, stats.LastSyncAt.IsZero())
+
+	// Unknown times are omitted from JSON
+	data, err := json.Marshal(stats)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"created_at":"`)
+	assert.NotContains(t, string(data), "last_updated")
+
+	addDocuments(t, index, Document{URI: "doc1", Title: "One", Content: "First document content"})
+	require.NoError(t, index.SetSyncCursor("wiki", "42"))
+
+	stats, err = index.Stats()
+	require.NoError(t, err)
+	assert.False(t, stats.LastUpdated.Before(stats.CreatedAt))
+	assert.False(t, stats.LastSavedAt.IsZero())
+	assert.False(t, stats.LastSyncAt.Before(stats.LastUpdated))
+
+	// Clearing keeps when the index was created and synced
+	require.NoError(t, index.Clear())
+	cleared, err := index.Stats()
+	require.NoError(t, err)
+	assert.True(t, cleared.CreatedAt.Equal(stats.CreatedAt))
+	assert.True(t, cleared.LastSyncAt.Equal(stats.LastSyncAt))
+	assert.False(t, cleared.LastUpdated.Before(stats.LastUpdated))
+
+	diag, err := manager.Diagnostics()
+	require.NoError(t, err)
+	require.Len(t, diag.Indexes, 1)
+	assert.True(t, diag.Indexes[0].CreatedAt.Equal(stats.CreatedAt))
+}
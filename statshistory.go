@@ -0,0 +1,80 @@
+package hnswindex
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// statsMergeWindow is how long deletions outside a batch keep adding up in
+// the same stats snapshot
+const statsMergeWindow = time.Minute
+
+// StatsSnapshot is an entry of an index's stats history: the size of the
+// index after a batch or deletion, and what the change did. A snapshot is
+// recorded after every batch that changed documents; deletions outside a
+// batch within a minute of each other share one snapshot. The most recent
+// storage.MaxStatsSnapshots snapshots are kept.
+type StatsSnapshot struct {
+	Time             time.Time     `json:"time"`
+	Documents        int           `json:"documents"`
+	Chunks           int           `json:"chunks"`
+	NewDocuments     int           `json:"new_documents,omitempty"`
+	UpdatedDocuments int           `json:"updated_documents,omitempty"`
+	DeletedDocuments int           `json:"deleted_documents,omitempty"`
+	ProcessedChunks  int           `json:"processed_chunks,omitempty"` // Chunks embedded or copied
+	BatchDuration    time.Duration `json:"batch_duration,omitempty"`   // Zero outside batches
+}
+
+// StatsHistory returns the recorded stats snapshots of the index, oldest
+// first, for charting growth, chunk churn, and batch durations
+func (i *Index) StatsHistory() ([]StatsSnapshot, error) {
+	if impl := i.getImpl(); impl != nil {
+		entries, err := impl.manager.storage.StatsHistory(i.name)
+		if err != nil {
+			return nil, err
+		}
+		history := make([]StatsSnapshot, len(entries))
+		for idx, e := range entries {
+			history[idx] = StatsSnapshot{
+				Time:             e.Time,
+				Documents:        e.Documents,
+				Chunks:           e.Chunks,
+				NewDocuments:     e.NewDocuments,
+				UpdatedDocuments: e.UpdatedDocuments,
+				DeletedDocuments: e.DeletedDocuments,
+				ProcessedChunks:  e.ProcessedChunks,
+				BatchDuration:    time.Duration(e.BatchMs * float64(time.Millisecond)),
+			}
+		}
+		return history, nil
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// recordBatchStats records a snapshot after a batch that changed documents
+func (i *indexImpl) recordBatchStats(result *BatchResult, started time.Time) {
+	if result.NewDocuments+result.UpdatedDocuments == 0 {
+		return // Nothing changed
+	}
+	i.recordStats(storage.StatsSnapshot{
+		Time:             time.Now(),
+		NewDocuments:     result.NewDocuments,
+		UpdatedDocuments: result.UpdatedDocuments,
+		ProcessedChunks:  result.ProcessedChunks,
+		BatchMs:          float64(time.Since(started)) / float64(time.Millisecond),
+	})
+}
+
+// recordStats stores a stats snapshot. History is informational, so
+// failures are logged rather than failing the write that was recorded.
+func (i *indexImpl) recordStats(snap storage.StatsSnapshot) {
+	if err := i.manager.storage.RecordStats(i.name, snap, statsMergeWindow); err != nil {
+		slog.Warn("Failed to record stats snapshot",
+			"index", i.name,
+			"error", err,
+		)
+	}
+}
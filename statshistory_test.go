@@ -0,0 +1,57 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_StatsHistory(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("history")
+	require.NoError(t, err)
+
+	history, err := index.StatsHistory()
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	docs := []Document{
+		{URI: "doc1", Title: "One", Content: "The first document"},
+		{URI: "doc2", Title: "Two", Content: "The second document"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	// Unchanged documents do not record a snapshot
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	docs[0].Content = "The first document, revised"
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, index.DeleteDocument("doc1"))
+	require.NoError(t, index.DeleteDocument("doc2"))
+
+	history, err = index.StatsHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	assert.Equal(t, 2, history[0].NewDocuments)
+	assert.Equal(t, 2, history[0].Documents)
+	assert.Greater(t, history[0].Chunks, 0)
+	assert.Greater(t, history[0].BatchDuration, time.Duration(0))
+
+	assert.Equal(t, 1, history[1].UpdatedDocuments)
+	assert.Equal(t, 2, history[1].Documents)
+
+	// Deletions in quick succession share a snapshot
+	assert.Equal(t, 2, history[2].DeletedDocuments)
+	assert.Equal(t, 0, history[2].Documents)
+	assert.Equal(t, 0, history[2].Chunks)
+	assert.Zero(t, history[2].BatchDuration)
+	assert.False(t, history[2].Time.Before(history[1].Time))
+}
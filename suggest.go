@@ -0,0 +1,280 @@
+package hnswindex
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// DefaultSuggestLimit is the number of suggestions returned when the limit
+// passed to Index.Suggest is 0
+const DefaultSuggestLimit = 10
+
+// suggestMaxLength caps the length of the titles and headings suggested;
+// longer ones are rarely what a user types
+const suggestMaxLength = 200
+
+// Suggestion kinds
+const (
+	SuggestionTitle   = "title"   // A document title
+	SuggestionHeading = "heading" // A markdown heading in a document
+)
+
+// Suggestion is a document title or heading completing a prefix
+type Suggestion struct {
+	Text      string `json:"text"`
+	Kind      string `json:"kind"`      // SuggestionTitle, or SuggestionHeading if no document has it as title
+	Documents int    `json:"documents"` // Documents with the title or heading
+	URI       string `json:"uri"`       // First of those documents by URI
+}
+
+// Suggest returns up to limit (default DefaultSuggestLimit) document titles
+// and markdown headings with a word starting with prefix, ignoring case,
+// for search-box autocomplete. Those starting with prefix come first, then
+// titles before headings, then those of more documents. The prefix index is
+// built in memory on the first call and kept up to date as documents
+// change.
+func (i *Index) Suggest(prefix string, limit int) ([]Suggestion, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Suggest(prefix, limit)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Suggest implementation
+func (i *indexImpl) Suggest(prefix string, limit int) ([]Suggestion, error) {
+	prefix = suggestKey(prefix)
+	if prefix == "" {
+		return nil, errors.New("empty prefix")
+	}
+	if limit <= 0 {
+		limit = DefaultSuggestLimit
+	}
+
+	if err := i.suggestions.build(i.manager.storage, i.name); err != nil {
+		return nil, fmt.Errorf("failed to build suggestion index: %w", err)
+	}
+	return i.suggestions.complete(prefix, limit), nil
+}
+
+// updateSuggestions refreshes a document in its index's prefix index
+func (im *indexManagerImpl) updateSuggestions(indexName, uri string) {
+	if idx, ok := im.indexes.get(indexName); ok {
+		idx.suggestions.update(im.storage, indexName, uri)
+	}
+}
+
+// suggestKey lowercases text and collapses its whitespace
+func suggestKey(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// suggestPhrases returns the title and markdown headings of a document by
+// key, a title winning over a heading with the same key
+func suggestPhrases(doc *storage.Document) map[string]suggestEntry {
+	phrases := make(map[string]suggestEntry)
+	add := func(text, kind string) {
+		text = strings.Join(strings.Fields(text), " ")
+		key := suggestKey(text)
+		if key == "" || len(text) > suggestMaxLength {
+			return
+		}
+		if _, ok := phrases[key]; !ok || kind == SuggestionTitle {
+			phrases[key] = suggestEntry{text: text, kind: kind}
+		}
+	}
+	add(doc.Title, SuggestionTitle)
+	for _, line := range strings.Split(doc.Content, "\n") {
+		if heading, ok := markdownHeading(line); ok {
+			add(heading, SuggestionHeading)
+		}
+	}
+	return phrases
+}
+
+// suggestEntry is a title or heading as a document has it
+type suggestEntry struct {
+	text string
+	kind string
+}
+
+// suggestPhrase is a title or heading and the documents that have it
+type suggestPhrase struct {
+	docs map[string]suggestEntry // URI -> the phrase in that document
+}
+
+// suggestion describes the phrase, using the text of its first document
+// that has it as title, or else of its first document
+func (p *suggestPhrase) suggestion() Suggestion {
+	uris := make([]string, 0, len(p.docs))
+	for uri := range p.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	s := Suggestion{Kind: SuggestionHeading, Documents: len(uris), URI: uris[0]}
+	s.Text = p.docs[uris[0]].text
+	for _, uri := range uris {
+		if entry := p.docs[uri]; entry.kind == SuggestionTitle {
+			s.Text, s.Kind, s.URI = entry.text, SuggestionTitle, uri
+			break
+		}
+	}
+	return s
+}
+
+// suggestWord is a word start of a phrase: the phrase key from that word on
+type suggestWord struct {
+	suffix string
+	key    string // Phrase key
+}
+
+// suggestIndex is a prefix index over the titles and headings of an
+// index's documents. The sorted word starts are rebuilt on the first
+// completion after a change.
+type suggestIndex struct {
+	mu      sync.Mutex
+	built   bool
+	phrases map[string]*suggestPhrase // Phrase key -> phrase
+	docs    map[string][]string       // URI -> phrase keys
+	words   []suggestWord             // Sorted by suffix; nil after a change
+}
+
+// build loads every document of the index unless the index is already
+// built
+func (s *suggestIndex) build(st *storage.Storage, indexName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.built {
+		return nil
+	}
+
+	s.phrases = make(map[string]*suggestPhrase)
+	s.docs = make(map[string][]string)
+	s.words = nil
+
+	after := ""
+	for {
+		page, err := st.ListDocumentsPage(indexName, after, documentPageSize)
+		if err != nil {
+			return err
+		}
+		for idx := range page {
+			s.add(&page[idx])
+		}
+		if len(page) < documentPageSize {
+			break
+		}
+		after = page[len(page)-1].URI
+	}
+	s.built = true
+	return nil
+}
+
+// add indexes the titles and headings of a document; s.mu must be held
+func (s *suggestIndex) add(doc *storage.Document) {
+	for key, entry := range suggestPhrases(doc) {
+		phrase, ok := s.phrases[key]
+		if !ok {
+			phrase = &suggestPhrase{docs: make(map[string]suggestEntry)}
+			s.phrases[key] = phrase
+			s.words = nil
+		}
+		phrase.docs[doc.URI] = entry
+		s.docs[doc.URI] = append(s.docs[doc.URI], key)
+	}
+}
+
+// removeDocument drops a document's titles and headings; s.mu must be held
+func (s *suggestIndex) removeDocument(uri string) {
+	for _, key := range s.docs[uri] {
+		phrase := s.phrases[key]
+		delete(phrase.docs, uri)
+		if len(phrase.docs) == 0 {
+			delete(s.phrases, key)
+			s.words = nil
+		}
+	}
+	delete(s.docs, uri)
+}
+
+// update re-indexes a document after it was added, updated, or deleted
+func (s *suggestIndex) update(st *storage.Storage, indexName, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.built {
+		return // Built from storage on first use
+	}
+
+	s.removeDocument(uri)
+	if doc, err := st.GetDocument(indexName, uri); err == nil {
+		s.add(doc)
+	}
+}
+
+// complete returns up to limit suggestions with a word starting with
+// prefix, best first
+func (s *suggestIndex) complete(prefix string, limit int) []Suggestion {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.words == nil {
+		s.words = make([]suggestWord, 0, len(s.phrases))
+		for key := range s.phrases {
+			for idx := 0; idx < len(key); idx++ {
+				if idx == 0 || key[idx-1] == ' ' {
+					s.words = append(s.words, suggestWord{suffix: key[idx:], key: key})
+				}
+			}
+		}
+		sort.Slice(s.words, func(a, b int) bool { return s.words[a].suffix < s.words[b].suffix })
+	}
+
+	type match struct {
+		Suggestion
+		leading bool // The phrase starts with the prefix
+	}
+	matched := make(map[string]bool)
+	var matches []match
+	start := sort.Search(len(s.words), func(n int) bool { return s.words[n].suffix >= prefix })
+	for _, word := range s.words[start:] {
+		if !strings.HasPrefix(word.suffix, prefix) {
+			break
+		}
+		if matched[word.key] {
+			continue // Several of its words start with the prefix
+		}
+		matched[word.key] = true
+		matches = append(matches, match{
+			Suggestion: s.phrases[word.key].suggestion(),
+			leading:    strings.HasPrefix(word.key, prefix),
+		})
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		ma, mb := matches[a], matches[b]
+		if ma.leading != mb.leading {
+			return ma.leading
+		}
+		if ma.Kind != mb.Kind {
+			return ma.Kind == SuggestionTitle
+		}
+		if ma.Documents != mb.Documents {
+			return ma.Documents > mb.Documents
+		}
+		if len(ma.Text) != len(mb.Text) {
+			return len(ma.Text) < len(mb.Text)
+		}
+		return ma.Text < mb.Text
+	})
+
+	suggestions := make([]Suggestion, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		suggestions = append(suggestions, m.Suggestion)
+	}
+	return suggestions
+}
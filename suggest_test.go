@@ -0,0 +1,68 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func suggestionTexts(suggestions []Suggestion) []string {
+	texts := make([]string, len(suggestions))
+	for n, s := range suggestions {
+		texts[n] = s.Text
+	}
+	return texts
+}
+
+func TestIndex_Suggest(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+
+	docs := []Document{
+		{URI: "doc1", Title: "Database Failover", Content: "# Database Failover\n\n## Promote the replica\n\nSteps.\n\n## Failback"},
+		{URI: "doc2", Title: "Cache tier", Content: "## Failback\n\nWarm the cache first."},
+		{URI: "doc3", Title: "Planned failover drills", Content: "Twice a year."},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	suggestions, err := index.Suggest("FAIL", 0)
+	require.NoError(t, err)
+	// Phrases starting with the prefix first, titles before headings, then
+	// those of more documents
+	assert.Equal(t, []string{"Failback", "Database Failover", "Planned failover drills"}, suggestionTexts(suggestions))
+	assert.Equal(t, Suggestion{Text: "Failback", Kind: SuggestionHeading, Documents: 2, URI: "doc1"}, suggestions[0])
+	assert.Equal(t, Suggestion{Text: "Database Failover", Kind: SuggestionTitle, Documents: 1, URI: "doc1"}, suggestions[1])
+
+	suggestions, err = index.Suggest("promote  the", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Promote the replica"}, suggestionTexts(suggestions))
+
+	suggestions, err = index.Suggest("fail", 1)
+	require.NoError(t, err)
+	assert.Len(t, suggestions, 1)
+
+	_, err = index.Suggest("  ", 5)
+	assert.Error(t, err)
+
+	// The prefix index follows documents as they change
+	_, err = index.AddDocumentBatch(context.Background(), []Document{
+		{URI: "doc2", Title: "Cache tier", Content: "Warm the cache first."},
+		{URI: "doc4", Title: "Failure modes", Content: "Disks, networks."},
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, index.DeleteDocument("doc3"))
+
+	suggestions, err = index.Suggest("fail", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Failure modes", "Failback", "Database Failover"}, suggestionTexts(suggestions))
+	assert.Equal(t, 1, suggestions[1].Documents)
+
+	require.NoError(t, index.Clear())
+	suggestions, err = index.Suggest("fail", 0)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
@@ -0,0 +1,155 @@
+package hnswindex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// suppressionsSettingKey is the index setting holding the suppressions
+const suppressionsSettingKey = "suppressions"
+
+// Suppression keeps a document, or every document whose metadata matches
+// filters, out of search results while it stays in the index, so it can
+// still be read, audited and restored
+type Suppression struct {
+	// ID identifies the suppression: the URI, or the filters in query
+	// syntax, such as "status:archived"
+	ID      string           `json:"id"`
+	URI     string           `json:"uri,omitempty"`     // Document suppressed
+	Filters []MetadataFilter `json:"filters,omitempty"` // Or documents matching all of them
+	Reason  string           `json:"reason,omitempty"`
+	Created time.Time        `json:"created"`
+}
+
+// ErrSuppressionNotFound is returned when removing an unknown suppression
+var ErrSuppressionNotFound = errors.New("suppression not found")
+
+// suppresses reports whether the suppression applies to a document
+func (s Suppression) suppresses(uri string, metadata map[string]interface{}) bool {
+	if s.URI != "" {
+		return s.URI == uri
+	}
+	_, passed := matchFilters(metadata, s.Filters)
+	return passed
+}
+
+// Suppress keeps a document, by URI, or the documents matching metadata
+// filters out of the results of every search, without deleting them. It
+// sets the ID and creation time of the suppression it returns; one with
+// the same ID is replaced. SearchOptions.IncludeSuppressed searches
+// without suppressions.
+func (i *Index) Suppress(s Suppression) (Suppression, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Suppress(s)
+	}
+	return Suppression{}, fmt.Errorf("implementation not available")
+}
+
+// Unsuppress removes the suppression with the given ID, returning
+// ErrSuppressionNotFound if there is none
+func (i *Index) Unsuppress(id string) error {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Unsuppress(id)
+	}
+	return fmt.Errorf("implementation not available")
+}
+
+// Suppressions returns the suppressions of the index, oldest first
+func (i *Index) Suppressions() ([]Suppression, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Suppressions()
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Suppress implementation
+func (i *indexImpl) Suppress(s Suppression) (Suppression, error) {
+	switch {
+	case s.URI != "" && len(s.Filters) > 0:
+		return Suppression{}, errors.New("invalid suppression: set a URI or filters, not both")
+	case s.URI != "":
+		s.ID = s.URI
+	case len(s.Filters) > 0:
+		s.ID = Query{Filters: s.Filters}.String()
+	default:
+		return Suppression{}, errors.New("invalid suppression: no URI or filters")
+	}
+	s.Created = time.Now().UTC()
+
+	i.settingsMu.Lock()
+	defer i.settingsMu.Unlock()
+
+	suppressions, err := i.Suppressions()
+	if err != nil {
+		return Suppression{}, err
+	}
+	kept := suppressions[:0]
+	for _, existing := range suppressions {
+		if existing.ID != s.ID {
+			kept = append(kept, existing)
+		}
+	}
+	if err := i.storeSuppressions(append(kept, s)); err != nil {
+		return Suppression{}, err
+	}
+	return s, nil
+}
+
+// Unsuppress implementation
+func (i *indexImpl) Unsuppress(id string) error {
+	i.settingsMu.Lock()
+	defer i.settingsMu.Unlock()
+
+	suppressions, err := i.Suppressions()
+	if err != nil {
+		return err
+	}
+	for n, s := range suppressions {
+		if s.ID == id {
+			return i.storeSuppressions(append(suppressions[:n], suppressions[n+1:]...))
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrSuppressionNotFound, id)
+}
+
+// Suppressions implementation
+func (i *indexImpl) Suppressions() ([]Suppression, error) {
+	data, err := i.manager.storage.GetIndexSetting(i.name, suppressionsSettingKey)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var suppressions []Suppression
+	if err := json.Unmarshal(data, &suppressions); err != nil {
+		return nil, fmt.Errorf("failed to decode suppressions: %w", err)
+	}
+	return suppressions, nil
+}
+
+// storeSuppressions replaces the stored suppressions, removing the setting
+// when there are none
+func (i *indexImpl) storeSuppressions(suppressions []Suppression) error {
+	if len(suppressions) == 0 {
+		return i.manager.storage.SetIndexSetting(i.name, suppressionsSettingKey, nil)
+	}
+	data, err := json.Marshal(suppressions)
+	if err != nil {
+		return fmt.Errorf("failed to encode suppressions: %w", err)
+	}
+	return i.manager.storage.SetIndexSetting(i.name, suppressionsSettingKey, data)
+}
+
+// suppressed reports whether any of the suppressions applies to a document
+func suppressed(suppressions []Suppression, uri string, metadata map[string]interface{}) bool {
+	for _, s := range suppressions {
+		if s.suppresses(uri, metadata) {
+			return true
+		}
+	}
+	return false
+}
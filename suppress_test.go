@@ -0,0 +1,59 @@
+package hnswindex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_Suppress(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	addDocuments(t, index,
+		Document{URI: "old-pricing", Title: "Pricing (2023)", Content: "Plans and pricing for teams"},
+		Document{URI: "pricing", Title: "Pricing", Content: "Plans and pricing for teams and companies"},
+		Document{URI: "archived", Title: "Pricing FAQ", Content: "Plans and pricing questions",
+			Metadata: map[string]interface{}{"status": "archived"}},
+	)
+
+	_, err = index.Suppress(Suppression{})
+	assert.Error(t, err)
+	_, err = index.Suppress(Suppression{URI: "pricing", Filters: []MetadataFilter{{Field: "status", Op: FilterEq, Value: "archived"}}})
+	assert.Error(t, err)
+
+	s, err := index.Suppress(Suppression{URI: "old-pricing", Reason: "superseded"})
+	require.NoError(t, err)
+	assert.Equal(t, "old-pricing", s.ID)
+	assert.False(t, s.Created.IsZero())
+	s, err = index.Suppress(Suppression{Filters: []MetadataFilter{{Field: "status", Op: FilterEq, Value: "archived"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "status:archived", s.ID)
+
+	// Suppressing again replaces the suppression
+	_, err = index.Suppress(Suppression{URI: "old-pricing", Reason: "replaced by pricing"})
+	require.NoError(t, err)
+	suppressions, err := index.Suppressions()
+	require.NoError(t, err)
+	require.Len(t, suppressions, 2)
+	assert.Equal(t, "replaced by pricing", suppressions[1].Reason)
+
+	results, err := index.Search("Plans and pricing for teams", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pricing"}, resultURIs(results))
+
+	// Suppressed documents stay in the index
+	doc, err := index.GetDocument("old-pricing")
+	require.NoError(t, err)
+	assert.Equal(t, "Pricing (2023)", doc.Title)
+	results, err = index.SearchWithOptions("Plans and pricing for teams", 10, SearchOptions{IncludeSuppressed: true})
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	require.NoError(t, index.Unsuppress("old-pricing"))
+	assert.ErrorIs(t, index.Unsuppress("old-pricing"), ErrSuppressionNotFound)
+	results, err = index.Search("Plans and pricing for teams", 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"pricing", "old-pricing"}, resultURIs(results))
+}
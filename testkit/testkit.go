@@ -0,0 +1,182 @@
+// Package testkit provides fault-injecting wrappers for testing how code
+// using hnswindex, and hnswindex itself, handle failures: embedders that
+// fail, storage commits that error, and slow graphs. Golden compares
+// pipeline output with golden files.
+//
+//	faults := testkit.NewFaults()
+//	faults.FailEvery(hnswindex.FaultCommit, 3)
+//	faults.Delay(hnswindex.FaultGraphSearch, 50*time.Millisecond)
+//	manager.SetFaultInjector(faults)
+//	manager.SetEmbedder(testkit.NewFailingEmbedder(embedtest.New(384), 5))
+package testkit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/riclib/hnswindex"
+)
+
+// ErrInjected is wrapped by every error testkit injects
+var ErrInjected = errors.New("injected fault")
+
+// FailingEmbedder wraps an embedder and fails every Nth call to it
+type FailingEmbedder struct {
+	hnswindex.Embedder
+	every int
+
+	mu    sync.Mutex
+	calls int
+}
+
+// NewFailingEmbedder wraps inner so that calls every, 2*every, ... fail.
+// Both GenerateEmbedding and GenerateEmbeddings count as calls. An every
+// of 0 or less never fails.
+func NewFailingEmbedder(inner hnswindex.Embedder, every int) *FailingEmbedder {
+	return &FailingEmbedder{Embedder: inner, every: every}
+}
+
+// GenerateEmbedding embeds a query, unless this call is set to fail
+func (f *FailingEmbedder) GenerateEmbedding(text string) ([]float32, error) {
+	if err := f.call(); err != nil {
+		return nil, err
+	}
+	return f.Embedder.GenerateEmbedding(text)
+}
+
+// GenerateEmbeddings embeds texts, unless this call is set to fail
+func (f *FailingEmbedder) GenerateEmbeddings(texts []string) ([][]float32, error) {
+	if err := f.call(); err != nil {
+		return nil, err
+	}
+	return f.Embedder.GenerateEmbeddings(texts)
+}
+
+// Calls returns how many calls have been made, failed ones included
+func (f *FailingEmbedder) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *FailingEmbedder) call() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.every > 0 && f.calls%f.every == 0 {
+		return fmt.Errorf("embedding call %d: %w", f.calls, ErrInjected)
+	}
+	return nil
+}
+
+// Faults implements hnswindex.FaultInjector with per-point failure rates
+// and delays. It is safe to reconfigure while in use.
+type Faults struct {
+	mu     sync.Mutex
+	every  map[hnswindex.FaultPoint]int
+	delays map[hnswindex.FaultPoint]time.Duration
+	counts map[hnswindex.FaultPoint]int // Reached, in any index
+	hits   map[hnswindex.FaultPoint]int // Reached in the faulted indexes
+	index  string
+}
+
+// NewFaults creates a fault injector that injects nothing until configured
+func NewFaults() *Faults {
+	return &Faults{
+		every:  make(map[hnswindex.FaultPoint]int),
+		delays: make(map[hnswindex.FaultPoint]time.Duration),
+		counts: make(map[hnswindex.FaultPoint]int),
+		hits:   make(map[hnswindex.FaultPoint]int),
+	}
+}
+
+// FailEvery fails every nth time point is reached; 1 fails every time and
+// 0 stops failing
+func (f *Faults) FailEvery(point hnswindex.FaultPoint, n int) *Faults {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.every[point] = n
+	return f
+}
+
+// Delay sleeps for d each time point is reached, e.g. to simulate a slow
+// graph with FaultGraphSearch and FaultGraphAdd
+func (f *Faults) Delay(point hnswindex.FaultPoint, d time.Duration) *Faults {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delays[point] = d
+	return f
+}
+
+// OnlyIndex limits faults to one index, whose arrivals alone count towards
+// FailEvery; empty applies them to all
+func (f *Faults) OnlyIndex(name string) *Faults {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index = name
+	return f
+}
+
+// Count returns how many times point has been reached, in any index
+func (f *Faults) Count(point hnswindex.FaultPoint) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counts[point]
+}
+
+// Inject implements hnswindex.FaultInjector
+func (f *Faults) Inject(point hnswindex.FaultPoint, index string) error {
+	f.mu.Lock()
+	f.counts[point]++
+	if f.index != "" && f.index != index {
+		f.mu.Unlock()
+		return nil
+	}
+	f.hits[point]++
+	n := f.hits[point]
+	every, delay := f.every[point], f.delays[point]
+	f.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if every > 0 && n%every == 0 {
+		return fmt.Errorf("%s %d in index '%s': %w", point, n, index, ErrInjected)
+	}
+	return nil
+}
+
+// UpdateGoldenEnv names the environment variable that makes Golden write
+// golden files instead of comparing against them
+const UpdateGoldenEnv = "HNSWINDEX_UPDATE_GOLDEN"
+
+// Golden compares got with the golden file at path, typically the output
+// of ProcessedDocument.Golden. Run the tests with HNSWINDEX_UPDATE_GOLDEN=1
+// to create or update the file after an intended change.
+func Golden(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create golden file directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (set %s=1 to create it): %v", UpdateGoldenEnv, err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("output differs from golden file %s (set %s=1 to update it)\n--- want\n%s\n--- got\n%s",
+			path, UpdateGoldenEnv, want, got)
+	}
+}
@@ -0,0 +1,150 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/riclib/hnswindex"
+	"github.com/riclib/hnswindex/embedtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newManager(t *testing.T) (*hnswindex.IndexManager, *hnswindex.Index) {
+	cfg := hnswindex.NewConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.EmbeddingCache = false
+	manager, err := hnswindex.NewIndexManager(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { manager.Close() })
+	manager.SetEmbedder(embedtest.New(32))
+
+	index, err := manager.CreateIndex("kb")
+	require.NoError(t, err)
+	return manager, index
+}
+
+func docs(n int) []hnswindex.Document {
+	var docs []hnswindex.Document
+	for i := 0; i < n; i++ {
+		docs = append(docs, hnswindex.Document{URI: fmt.Sprintf("doc-%d", i), Content: fmt.Sprintf("document number %d", i)})
+	}
+	return docs
+}
+
+func TestFailingEmbedder(t *testing.T) {
+	manager, index := newManager(t)
+	embedder := NewFailingEmbedder(embedtest.New(32), 2)
+	manager.SetEmbedder(embedder)
+
+	result, err := index.AddDocumentBatch(context.Background(), docs(4), nil)
+	require.NoError(t, err)
+	assert.Len(t, result.FailedURIs, 2)
+	for _, reason := range result.FailedURIs {
+		assert.Contains(t, reason, ErrInjected.Error())
+	}
+	assert.Equal(t, 4, embedder.Calls())
+
+	// The failed documents are indexed when retried
+	manager.SetEmbedder(embedtest.New(32))
+	result, err = index.AddDocumentBatch(context.Background(), docs(4), nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.FailedURIs)
+	assert.Equal(t, 2, result.NewDocuments)
+}
+
+func TestFaults_Commit(t *testing.T) {
+	manager, index := newManager(t)
+	faults := NewFaults().FailEvery(hnswindex.FaultCommit, 1)
+	manager.SetFaultInjector(faults)
+
+	result, err := index.AddDocumentBatch(context.Background(), docs(3), nil)
+	require.NoError(t, err)
+	assert.Len(t, result.FailedURIs, 3)
+	assert.GreaterOrEqual(t, faults.Count(hnswindex.FaultCommit), 3)
+
+	doc, err := index.GetDocument("doc-0")
+	assert.True(t, err != nil || doc == nil)
+}
+
+func TestFaults_PartialSave(t *testing.T) {
+	manager, index := newManager(t)
+	manager.SetFaultInjector(NewFaults().FailEvery(hnswindex.FaultSave, 1))
+
+	// Documents are committed and searchable even though the graph was
+	// not saved
+	_, err := index.AddDocumentBatch(context.Background(), docs(2), nil)
+	assert.ErrorIs(t, err, ErrInjected)
+	results, err := index.Search("document number 1", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc-1", results[0].Document.URI)
+}
+
+func TestFaults_SlowGraph(t *testing.T) {
+	manager, index := newManager(t)
+	_, err := index.AddDocumentBatch(context.Background(), docs(2), nil)
+	require.NoError(t, err)
+
+	faults := NewFaults().Delay(hnswindex.FaultGraphSearch, 20*time.Millisecond).OnlyIndex("kb")
+	manager.SetFaultInjector(faults)
+	start := time.Now()
+	_, err = index.Search("document", 1)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// Faults limited to another index leave this one alone
+	faults.OnlyIndex("other").FailEvery(hnswindex.FaultGraphSearch, 1)
+	_, err = index.Search("document", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, faults.Count(hnswindex.FaultGraphSearch))
+
+	faults.OnlyIndex("")
+	_, err = index.Search("document", 1)
+	assert.ErrorIs(t, err, ErrInjected)
+
+	manager.SetFaultInjector(nil)
+	_, err = index.Search("document", 1)
+	assert.NoError(t, err)
+}
+
+func TestGolden(t *testing.T) {
+	_, index := newManager(t)
+	processed, err := index.ProcessDocument(context.Background(), hnswindex.Document{
+		URI:      "kb://faq/1",
+		Title:    "Password reset",
+		Content:  "Reset your password from the account settings page. Links expire after one hour.",
+		Metadata: map[string]interface{}{"team": "identity"},
+	})
+	require.NoError(t, err)
+	got, err := processed.Golden()
+	require.NoError(t, err)
+
+	// Token counts depend on the tokenizer data, so the golden file is
+	// written here rather than checked in
+	path := filepath.Join(t.TempDir(), "golden", "faq.json")
+	t.Setenv(UpdateGoldenEnv, "1")
+	Golden(t, path, got)
+	t.Setenv(UpdateGoldenEnv, "")
+	Golden(t, path, got)
+
+	// A mismatch fails the test
+	r := &recorder{TB: t}
+	Golden(r, path, []byte("{}\n"))
+	assert.True(t, r.failed)
+}
+
+// recorder records failures instead of failing the test
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) { r.failed = true }
+
+func (r *recorder) Fatalf(format string, args ...interface{}) { r.failed = true }
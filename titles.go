@@ -0,0 +1,202 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/riclib/hnswindex/internal/chunker"
+	"github.com/riclib/hnswindex/internal/titler"
+)
+
+// ChunkTitleKey is the chunk metadata key holding the chunk title
+const ChunkTitleKey = "chunk_title"
+
+// ChunkTitler gives each chunk of a document a short title, stored in chunk
+// metadata under ChunkTitleKey and returned as SearchResult.ChunkTitle
+type ChunkTitler interface {
+	// TitleChunks returns one title per chunk text, in order. An empty
+	// title leaves the chunk untitled.
+	TitleChunks(ctx context.Context, doc Document, chunks []string) ([]string, error)
+	Name() string
+}
+
+// ChunkTitlerFunc adapts a function to the ChunkTitler interface
+type ChunkTitlerFunc func(ctx context.Context, doc Document, chunks []string) ([]string, error)
+
+// TitleChunks calls f
+func (f ChunkTitlerFunc) TitleChunks(ctx context.Context, doc Document, chunks []string) ([]string, error) {
+	return f(ctx, doc, chunks)
+}
+
+// Name identifies user-supplied titlers
+func (f ChunkTitlerFunc) Name() string {
+	return "custom"
+}
+
+// headingTitler titles chunks with their nearest markdown heading
+type headingTitler struct{}
+
+// NewHeadingTitler returns a titler that uses the markdown headings of a
+// document: a chunk is titled with the first heading it contains, or else
+// with the last heading before it. Chunks before any heading stay untitled.
+func NewHeadingTitler() ChunkTitler {
+	return headingTitler{}
+}
+
+// Name identifies the heading titler
+func (headingTitler) Name() string {
+	return "headings"
+}
+
+// TitleChunks implements ChunkTitler
+func (headingTitler) TitleChunks(_ context.Context, _ Document, chunks []string) ([]string, error) {
+	titles := make([]string, len(chunks))
+	var current string
+	for idx, text := range chunks {
+		var first string
+		for _, line := range strings.Split(text, "\n") {
+			heading, ok := markdownHeading(line)
+			if !ok {
+				continue
+			}
+			if first == "" {
+				first = heading
+			}
+			current = heading
+		}
+		if first != "" {
+			titles[idx] = first
+		} else {
+			titles[idx] = current
+		}
+	}
+	return titles, nil
+}
+
+// markdownHeading returns the text of an ATX heading line such as "## Setup"
+func markdownHeading(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || (len(line) > level && line[level] != ' ') {
+		return "", false
+	}
+	heading := strings.TrimSpace(strings.TrimRight(line[level:], "# "))
+	return heading, heading != ""
+}
+
+// ollamaTitler titles chunks one at a time with an Ollama language model
+type ollamaTitler struct {
+	titler *titler.OllamaTitler
+}
+
+// NewOllamaChunkTitler returns a titler that asks an Ollama language model
+// (e.g. "llama3.2") for a short title of each chunk. Every chunk of a new
+// or changed document costs one generation request.
+func NewOllamaChunkTitler(ollamaURL, model string) (ChunkTitler, error) {
+	t, err := titler.NewOllamaTitler(ollamaURL, model, "")
+	if err != nil {
+		return nil, err
+	}
+	return ollamaTitler{titler: t}, nil
+}
+
+// Name identifies the model used
+func (o ollamaTitler) Name() string {
+	return o.titler.Name()
+}
+
+// TitleChunks implements ChunkTitler
+func (o ollamaTitler) TitleChunks(ctx context.Context, doc Document, chunks []string) ([]string, error) {
+	titles := make([]string, len(chunks))
+	for idx, text := range chunks {
+		title, err := o.titler.Title(ctx, doc.Title, text)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", idx, err)
+		}
+		titles[idx] = title
+	}
+	return titles, nil
+}
+
+// SetChunkTitler sets the titler used when indexing documents. Without one
+// (the default), chunks are not titled. Documents indexed earlier keep
+// their titles until they are updated.
+func (im *IndexManager) SetChunkTitler(t ChunkTitler) {
+	if impl := im.getImpl(); impl != nil {
+		impl.mu.Lock()
+		impl.titler = t
+		impl.mu.Unlock()
+	}
+}
+
+// titleChunks returns the titles of the chunks of a document, or nil when
+// no titler is configured
+func (im *indexManagerImpl) titleChunks(doc Document, chunks []chunker.Chunk) ([]string, error) {
+	im.mu.RLock()
+	t := im.titler
+	im.mu.RUnlock()
+	if t == nil || len(chunks) == 0 {
+		return nil, nil
+	}
+
+	texts := make([]string, len(chunks))
+	for idx, chunk := range chunks {
+		texts[idx] = chunk.Text
+	}
+	titles, err := t.TitleChunks(context.Background(), doc, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to title chunks with %s: %w", t.Name(), err)
+	}
+	if len(titles) != len(chunks) {
+		return nil, fmt.Errorf("%s returned %d titles for %d chunks", t.Name(), len(titles), len(chunks))
+	}
+	return titles, nil
+}
+
+// chunkTitle reads the title stored in chunk metadata
+func chunkTitle(metadata map[string]interface{}) string {
+	title, _ := metadata[ChunkTitleKey].(string)
+	return title
+}
+
+// titleMatch returns the share of the query keywords (see keywordTerms)
+// that appear in title
+func titleMatch(query, title string) float64 {
+	terms := keywordTerms(query)
+	if len(terms) == 0 {
+		return 0
+	}
+	words := keywordTerms(title)
+	matched := 0
+	for term := range terms {
+		if words[term] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(terms))
+}
+
+// applyTitleBoost raises the score of a result whose chunk title (or, for
+// untitled chunks, document title) contains words of the query
+func applyTitleBoost(result *SearchResult, query string, boost float64) {
+	title := result.ChunkTitle
+	if title == "" {
+		title = result.Document.Title
+	}
+	match := titleMatch(query, title)
+	if match == 0 {
+		return
+	}
+
+	delta := boost * match
+	result.Score += delta
+	if result.Explain != nil {
+		result.Explain.Score = result.Score
+		result.Explain.Adjustments = append(result.Explain.Adjustments, ScoreAdjustment{
+			Stage:  "title_boost",
+			Delta:  delta,
+			Reason: fmt.Sprintf("%.0f%% of query words in title %q", match*100, title),
+		})
+	}
+}
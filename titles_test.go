@@ -0,0 +1,84 @@
+package hnswindex
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadingTitler(t *testing.T) {
+	titles, err := NewHeadingTitler().TitleChunks(context.Background(), Document{}, []string{
+		"Intro text before any heading",
+		"# Setup\nInstall it.\n## Linux ##\nUse apt.",
+		"More about apt.",
+		"#hashtag is not a heading\n####### nor is this",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "Setup", "Linux", "Linux"}, titles)
+}
+
+func TestTitleMatch(t *testing.T) {
+	assert.Equal(t, 1.0, titleMatch("how to rotate certificates?", "Rotate Certificates"))
+	assert.Equal(t, 0.5, titleMatch("rotate certificates", "Rotate keys"))
+	assert.Equal(t, 0.0, titleMatch("rotate certificates", ""))
+	assert.Equal(t, 0.0, titleMatch("the", "The Title"))
+}
+
+func TestIndex_ChunkTitles(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("titles")
+	require.NoError(t, err)
+
+	manager.SetChunkTitler(ChunkTitlerFunc(func(_ context.Context, doc Document, chunks []string) ([]string, error) {
+		titles := make([]string, len(chunks))
+		for idx := range chunks {
+			titles[idx] = strings.ToUpper(doc.URI) + " notes"
+		}
+		return titles, nil
+	}))
+
+	docs := []Document{
+		{URI: "alpha", Title: "Alpha", Content: "configuring the build pipeline"},
+		{URI: "beta", Title: "Beta", Content: "configuring the deploy pipeline"},
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	results, err := index.Search("configuring the build pipeline", 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "alpha", results[0].Document.URI)
+	assert.Equal(t, "ALPHA notes", results[0].ChunkTitle)
+
+	// A title match outweighs the closer embedding
+	results, err = index.SearchWithOptions("configuring the build pipeline beta", 2, SearchOptions{
+		Explain:    true,
+		TitleBoost: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "beta", results[0].Document.URI)
+	require.NotNil(t, results[0].Explain)
+	require.Len(t, results[0].Explain.Adjustments, 1)
+	assert.Equal(t, "title_boost", results[0].Explain.Adjustments[0].Stage)
+	assert.Equal(t, results[0].Score, results[0].Explain.Score)
+
+	var uris []string
+	for r, err := range index.SearchIterWithOptions("configuring the build pipeline beta", 2, SearchOptions{TitleBoost: 1}) {
+		require.NoError(t, err)
+		uris = append(uris, r.Document.URI)
+	}
+	assert.Equal(t, []string{"beta", "alpha"}, uris)
+
+	// A failing titler keeps the document out, like a failed embedding
+	manager.SetChunkTitler(ChunkTitlerFunc(func(context.Context, Document, []string) ([]string, error) {
+		return nil, errors.New("model unavailable")
+	}))
+	result, err := index.AddDocumentBatch(context.Background(), []Document{{URI: "gamma", Content: "more text"}}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.FailedURIs, "gamma")
+}
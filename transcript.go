@@ -0,0 +1,84 @@
+package hnswindex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/riclib/hnswindex/internal/chunker"
+)
+
+// Segment is a timed piece of a transcript, e.g. one Whisper segment
+type Segment struct {
+	Start float64 `json:"start"` // Start time in seconds
+	End   float64 `json:"end"`   // End time in seconds
+	Text  string  `json:"text"`
+}
+
+// TimeRange is the part of a recording a search result came from
+type TimeRange struct {
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`   // Seconds
+}
+
+// DeepLink returns the document URI with a media fragment pointing at the
+// start of the matched time range (e.g. "file://talk.mp4#t=754"). Results
+// without a time range return the plain URI.
+func (r SearchResult) DeepLink() string {
+	if r.TimeRange == nil {
+		return r.Document.URI
+	}
+	return fmt.Sprintf("%s#t=%s", r.Document.URI, strconv.FormatFloat(r.TimeRange.Start, 'f', -1, 64))
+}
+
+// segmentContent joins transcript segments into the document content
+func segmentContent(segments []Segment) string {
+	texts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if text := strings.TrimSpace(seg.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// chunkDocument splits a document into chunks. Transcripts are chunked
+// along segment boundaries and their chunks carry time ranges.
+func (im *indexManagerImpl) chunkDocument(doc Document) ([]chunker.Chunk, error) {
+	if len(doc.Segments) == 0 {
+		return im.chunker.ChunkDocument(doc.URI, doc.Content)
+	}
+
+	segments := make([]chunker.Segment, len(doc.Segments))
+	for idx, seg := range doc.Segments {
+		segments[idx] = chunker.Segment{Start: seg.Start, End: seg.End, Text: seg.Text}
+	}
+	return im.chunker.ChunkSegments(doc.URI, segments)
+}
+
+// chunkMetadata merges chunk-specific metadata (such as time ranges) over
+// the document metadata
+func chunkMetadata(docMetadata, metadata map[string]interface{}) map[string]interface{} {
+	if len(metadata) == 0 {
+		return docMetadata
+	}
+
+	merged := make(map[string]interface{}, len(docMetadata)+len(metadata))
+	for k, v := range docMetadata {
+		merged[k] = v
+	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	return merged
+}
+
+// chunkTimeRange reads the time range stored in chunk metadata
+func chunkTimeRange(metadata map[string]interface{}) *TimeRange {
+	start, ok := metadata[chunker.StartSecondsKey].(float64)
+	if !ok {
+		return nil
+	}
+	end, _ := metadata[chunker.EndSecondsKey].(float64)
+	return &TimeRange{Start: start, End: end}
+}
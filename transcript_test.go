@@ -0,0 +1,67 @@
+package hnswindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscriptIngestion(t *testing.T) {
+	cfg := NewConfig()
+	cfg.ChunkSize = 60
+	cfg.ChunkOverlap = 0
+	manager := newMockManager(t, cfg)
+
+	index, err := manager.CreateIndex("recordings")
+	require.NoError(t, err)
+
+	doc := Document{
+		URI:   "file://talks/deploys.mp4",
+		Title: "Deploy talk",
+		Segments: []Segment{
+			{Start: 0, End: 12.5, Text: "welcome everyone to the session"},
+			{Start: 12.5, End: 30, Text: "we start with the rollback procedure"},
+			{Start: 30, End: 61, Text: "then we look at canary deployments"},
+		},
+	}
+	result, err := index.AddDocumentBatch(context.Background(), []Document{doc}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.FailedURIs)
+	assert.Greater(t, result.ProcessedChunks, 1)
+
+	stored, err := index.GetDocument(doc.URI)
+	require.NoError(t, err)
+	assert.Contains(t, stored.Content, "rollback procedure")
+
+	var chunks []Chunk
+	for chunk := range index.Chunks(doc.URI) {
+		chunks = append(chunks, chunk)
+	}
+	require.NotEmpty(t, chunks)
+	assert.Equal(t, 0.0, chunks[0].Metadata["start_seconds"])
+
+	// The mock embedder is not semantic, so search with the exact chunk text
+	last := chunks[len(chunks)-1]
+	results, err := index.Search(last.Text, 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NotNil(t, results[0].TimeRange)
+	assert.Equal(t, 61.0, results[0].TimeRange.End)
+	assert.Equal(t, last.Metadata["start_seconds"], results[0].TimeRange.Start)
+	assert.Contains(t, results[0].DeepLink(), "file://talks/deploys.mp4#t=")
+
+	// Unchanged transcripts are skipped
+	result, err = index.AddDocumentBatch(context.Background(), []Document{doc}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.UnchangedDocuments)
+}
+
+func TestSearchResult_DeepLink(t *testing.T) {
+	r := SearchResult{Document: Document{URI: "https://videos/x.mp4"}}
+	assert.Equal(t, "https://videos/x.mp4", r.DeepLink())
+
+	r.TimeRange = &TimeRange{Start: 754.5, End: 790}
+	assert.Equal(t, "https://videos/x.mp4#t=754.5", r.DeepLink())
+}
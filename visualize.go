@@ -0,0 +1,108 @@
+package hnswindex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/riclib/hnswindex/internal/storage"
+)
+
+// projectionPreview is the length of the chunk text in a projection
+const projectionPreview = 200
+
+// Projection places the chunks of an index in two or three dimensions, as
+// returned by Index.Project, for plotting the structure of a corpus
+type Projection struct {
+	Dimension         int              `json:"dimension"`
+	ExplainedVariance float64          `json:"explained_variance"` // Share of the variance the axes capture
+	FittedOn          int              `json:"fitted_on"`          // Embeddings the PCA was fitted on
+	Chunks            []ProjectedChunk `json:"chunks"`
+}
+
+// ProjectedChunk is a chunk of a Projection with the labels of its
+// document
+type ProjectedChunk struct {
+	ChunkID     string                 `json:"chunk_id"`
+	DocumentURI string                 `json:"document_uri"`
+	Title       string                 `json:"title"` // Document title
+	Position    int                    `json:"position"`
+	Text        string                 `json:"text"` // Start of the chunk text
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Coords      []float32              `json:"coords"`
+}
+
+// Project maps the embedding of every chunk to dimension (2 or 3)
+// coordinates by PCA over a sample of the normalized embeddings, so nearby
+// points have similar embeddings. The first axis spreads the chunks most.
+// Chunks are labelled with their document's title and metadata.
+func (i *Index) Project(dimension int) (*Projection, error) {
+	if impl := i.getImpl(); impl != nil {
+		return impl.Project(dimension)
+	}
+	return nil, fmt.Errorf("implementation not available")
+}
+
+// Project implementation
+func (i *indexImpl) Project(dimension int) (*Projection, error) {
+	if dimension != 2 && dimension != 3 {
+		return nil, fmt.Errorf("projection dimension must be 2 or 3, got %d", dimension)
+	}
+
+	input := i.embeddingDimension()
+	stride := max(1, i.hnswIndex.Size()/pcaSample)
+	var vectors [][]float32
+	n := 0
+	err := i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		n++
+		if (n-1)%stride == 0 && len(chunk.Embedding) == input && len(vectors) < pcaSample {
+			vectors = append(vectors, normalizeEmbedding(chunk.Embedding))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) <= dimension {
+		return nil, fmt.Errorf("a projection to %d dimensions needs more than %d embedded chunks, the index has %d", dimension, dimension, len(vectors))
+	}
+	projection := &Projection{Dimension: dimension, FittedOn: len(vectors), Chunks: []ProjectedChunk{}}
+
+	pca := &Reduction{Method: ReductionPCA, InputDimension: input, Dimension: dimension}
+	pca.Mean, pca.Components, projection.ExplainedVariance = fitPCA(vectors, dimension)
+
+	err = i.manager.storage.ForEachChunk(i.name, func(chunk storage.Chunk) error {
+		if len(chunk.Embedding) != input {
+			return nil
+		}
+		projection.Chunks = append(projection.Chunks, ProjectedChunk{
+			ChunkID:     chunk.ID,
+			DocumentURI: chunk.DocumentURI,
+			Position:    chunk.Position,
+			Text:        TruncateText(strings.Join(strings.Fields(chunk.Text), " "), projectionPreview),
+			Coords:      pca.Apply(normalizeEmbedding(chunk.Embedding)),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Label chunks once the chunks are read; a document deleted meanwhile
+	// leaves its chunks unlabelled
+	documents := make(map[string]*storage.Document)
+	for c := range projection.Chunks {
+		projected := &projection.Chunks[c]
+		doc, ok := documents[projected.DocumentURI]
+		if !ok {
+			doc, _ = i.manager.storage.GetDocument(i.name, projected.DocumentURI)
+			if doc != nil {
+				doc.Content = "" // Only labels are kept
+			}
+			documents[projected.DocumentURI] = doc
+		}
+		if doc != nil {
+			projected.Title, projected.Metadata = doc.Title, doc.Metadata
+		}
+	}
+	return projection, nil
+}
@@ -0,0 +1,64 @@
+package hnswindex
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex_Project(t *testing.T) {
+	manager := newMockManager(t, nil)
+	index, err := manager.CreateIndex("topics")
+	require.NoError(t, err)
+
+	_, err = index.Project(2)
+	assert.ErrorContains(t, err, "needs more than 2 embedded chunks")
+
+	texts := []string{
+		"database failover runbook for the primary",
+		"database failover runbook for the primary",
+		"failover of the cache tier",
+		"holiday calendar for the office",
+		"office seating plan",
+	}
+	var docs []Document
+	for n, text := range texts {
+		docs = append(docs, Document{
+			URI:      fmt.Sprintf("doc%d", n),
+			Title:    fmt.Sprintf("Doc %d", n),
+			Content:  text,
+			Metadata: map[string]interface{}{"team": "ops"},
+		})
+	}
+	_, err = index.AddDocumentBatch(context.Background(), docs, nil)
+	require.NoError(t, err)
+
+	_, err = index.Project(4)
+	assert.ErrorContains(t, err, "must be 2 or 3")
+
+	for _, dimension := range []int{2, 3} {
+		projection, err := index.Project(dimension)
+		require.NoError(t, err)
+		assert.Equal(t, dimension, projection.Dimension)
+		assert.Equal(t, 5, projection.FittedOn)
+		assert.Greater(t, projection.ExplainedVariance, 0.0)
+		assert.LessOrEqual(t, projection.ExplainedVariance, 1.0+1e-9)
+		require.Len(t, projection.Chunks, 5)
+
+		coords := make(map[string][]float32)
+		for _, chunk := range projection.Chunks {
+			assert.Len(t, chunk.Coords, dimension)
+			assert.Equal(t, "Doc "+chunk.DocumentURI[len("doc"):], chunk.Title)
+			assert.Equal(t, "ops", chunk.Metadata["team"])
+			assert.NotEmpty(t, chunk.Text)
+			coords[chunk.DocumentURI] = chunk.Coords
+		}
+
+		// Chunks with the same embedding share a point
+		assert.InDeltaSlice(t, coords["doc0"], coords["doc1"], 1e-5)
+		assert.NotEqual(t, coords["doc0"], coords["doc3"])
+	}
+}